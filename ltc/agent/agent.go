@@ -0,0 +1,341 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	// ManagedByLabel marks the apps the agent created, which are the only
+	// ones it removes when their manifests are.
+	ManagedByLabel = "managed-by"
+	ManagedByValue = "ltc-agent"
+
+	DefaultDependencyTimeout = 2 * time.Minute
+
+	dependencyPollInterval = time.Second
+)
+
+type Action string
+
+const (
+	Created   Action = "created"
+	Updated   Action = "updated"
+	Recreated Action = "recreated"
+	Removed   Action = "removed"
+
+	// Waiting is only reported, while a change waits for an app it depends
+	// on to be running.
+	Waiting Action = "waiting"
+)
+
+// Change is what the agent did, or failed to do, to one app.  ChangedFields
+// are the fields of the desired LRP that were updated, by their JSON names.
+// WaitingFor is the app a Waiting change is waiting for.
+type Change struct {
+	AppName       string
+	Action        Action
+	ChangedFields []string
+	WaitingFor    string
+	Err           error
+}
+
+//go:generate counterfeiter -o fake_agent/fake_agent.go . Agent
+type Agent interface {
+	Reconcile(manifestDir string, progress func(Change)) ([]Change, error)
+	Diff(manifestDir string) ([]Change, error)
+}
+
+type AgentConfig struct {
+	AppRunner      docker_app_runner.AppRunner
+	AppExaminer    app_examiner.AppExaminer
+	ReservedAppIds reserved_app_ids.Registry
+	Clock          clock.Clock
+
+	// DependencyTimeout is how long a change waits for each app it depends
+	// on to be running.  It defaults to DefaultDependencyTimeout.
+	DependencyTimeout time.Duration
+}
+
+type agent struct {
+	AgentConfig
+}
+
+func New(config AgentConfig) Agent {
+	if config.DependencyTimeout == 0 {
+		config.DependencyTimeout = DefaultDependencyTimeout
+	}
+	return &agent{config}
+}
+
+// manifest is an app's desired LRP, and the apps listed in its depends_on,
+// which are created or updated, and running, before it is.
+type manifest struct {
+	definition receptor.DesiredLRPCreateRequest
+	dependsOn  []string
+}
+
+// Reconcile makes the apps on lattice match the desired LRP manifests, the
+// *.json files submit-lrp takes, in manifestDir: apps without one are
+// created, apps that differ from theirs are updated, and apps the agent
+// created whose manifests are gone are removed.  Nothing is changed unless
+// every manifest can be read, so that a manifest caught half-written
+// doesn't remove its app.  Each change is passed to progress once it is
+// made, as is each wait for an app another depends on.
+func (a *agent) Reconcile(manifestDir string, progress func(Change)) ([]Change, error) {
+	manifests, changes, err := a.plan(manifestDir)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := map[string]bool{}
+	for i, change := range changes {
+		if change.Err == nil && change.Action != Removed {
+			changes[i].Err = a.waitForDependencies(change.AppName, manifests, failed, progress)
+		}
+		if changes[i].Err == nil {
+			changes[i] = a.apply(changes[i], manifests)
+		}
+
+		if changes[i].Err != nil {
+			failed[change.AppName] = true
+		}
+		progress(changes[i])
+	}
+	return changes, nil
+}
+
+func (a *agent) apply(change Change, manifests map[string]manifest) Change {
+	switch change.Action {
+	case Removed:
+		change.Err = a.AppRunner.RemoveApp(change.AppName)
+	case Created:
+		_, change.Err = a.AppRunner.RestoreApp(manifests[change.AppName].definition)
+	default:
+		recreated, err := a.AppRunner.RestoreApp(manifests[change.AppName].definition)
+		change.Action = Updated
+		if recreated {
+			change.Action = Recreated
+		}
+		change.Err = err
+	}
+	return change
+}
+
+// waitForDependencies waits for each app that appName depends on to be
+// running all of its instances, and fails if one of them couldn't be
+// changed or isn't running within the dependency timeout.  The change is
+// then made on a later pass.
+func (a *agent) waitForDependencies(appName string, manifests map[string]manifest, failed map[string]bool, progress func(Change)) error {
+	for _, dependency := range manifests[appName].dependsOn {
+		if failed[dependency] {
+			return fmt.Errorf("%s depends on %s, which couldn't be changed", appName, dependency)
+		}
+
+		instances := manifests[dependency].definition.Instances
+		if a.running(dependency, instances) {
+			continue
+		}
+
+		progress(Change{AppName: appName, Action: Waiting, WaitingFor: dependency})
+		deadline := a.Clock.Now().Add(a.DependencyTimeout)
+		for !a.running(dependency, instances) {
+			if !a.Clock.Now().Before(deadline) {
+				return fmt.Errorf("timed out waiting for %s to be running", dependency)
+			}
+			a.Clock.Sleep(dependencyPollInterval)
+		}
+	}
+	return nil
+}
+
+func (a *agent) running(appName string, instances int) bool {
+	counts, err := a.AppExaminer.AppInstanceCounts(appName)
+	return err == nil && counts.Running >= instances
+}
+
+// Diff returns the changes Reconcile would make, without making them.  An
+// app that can't be looked up is returned as an update with its error.
+func (a *agent) Diff(manifestDir string) ([]Change, error) {
+	_, changes, err := a.plan(manifestDir)
+	return changes, err
+}
+
+// plan reads the manifests in manifestDir and compares them with the apps on
+// lattice, returning the manifests by app name and the changes that would
+// make the apps match them: creates and updates first, each after the apps
+// it depends on and otherwise by app name, then removals.
+func (a *agent) plan(manifestDir string) (map[string]manifest, []Change, error) {
+	manifests, err := a.loadManifests(manifestDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appNames, err := orderByDependencies(manifests)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := []Change{}
+	for _, appName := range appNames {
+		if change, changed := a.planApp(manifests[appName].definition); changed {
+			changes = append(changes, change)
+		}
+	}
+
+	apps, err := a.AppExaminer.ListApps()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, app := range apps {
+		if _, desired := manifests[app.ProcessGuid]; desired || app.Labels[ManagedByLabel] != ManagedByValue {
+			continue
+		}
+		changes = append(changes, Change{AppName: app.ProcessGuid, Action: Removed})
+	}
+
+	return manifests, changes, nil
+}
+
+// orderByDependencies returns the app names with each app after the apps
+// it depends on, and otherwise in name order.  Apps that depend on each
+// other in a cycle can't be ordered, and make the manifests invalid.
+func orderByDependencies(manifests map[string]manifest) ([]string, error) {
+	appNames := make([]string, 0, len(manifests))
+	for appName := range manifests {
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+
+	ordered := make([]string, 0, len(appNames))
+	visited := map[string]bool{}
+	var visit func(appName string, path []string) error
+	visit = func(appName string, path []string) error {
+		for i, pathAppName := range path {
+			if pathAppName == appName {
+				cycle := append(append([]string{}, path[i:]...), appName)
+				return docker_app_runner.InvalidManifestError{Err: fmt.Errorf("the apps depend on each other in a cycle: %s", strings.Join(cycle, " -> "))}
+			}
+		}
+		if visited[appName] {
+			return nil
+		}
+
+		path = append(path, appName)
+		for _, dependency := range manifests[appName].dependsOn {
+			if err := visit(dependency, path); err != nil {
+				return err
+			}
+		}
+		visited[appName] = true
+		ordered = append(ordered, appName)
+		return nil
+	}
+
+	for _, appName := range appNames {
+		if err := visit(appName, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func (a *agent) planApp(definition receptor.DesiredLRPCreateRequest) (Change, bool) {
+	change := Change{AppName: definition.ProcessGuid}
+
+	current, err := a.AppRunner.AppDefinition(definition.ProcessGuid)
+	if _, notFound := err.(docker_app_runner.AppNotFoundError); notFound {
+		change.Action = Created
+		return change, true
+	} else if err != nil {
+		change.Action = Updated
+		change.Err = err
+		return change, true
+	}
+
+	change.ChangedFields = docker_app_runner.ChangedFields(current, definition)
+	if len(change.ChangedFields) == 0 {
+		return change, false
+	}
+
+	change.Action = Updated
+	if docker_app_runner.NeedsRecreate(current, definition) {
+		change.Action = Recreated
+	}
+	return change, true
+}
+
+// loadManifests returns the manifests in manifestDir by app name, labelled
+// as managed by the agent.  Each app a manifest depends on must have a
+// manifest too.
+func (a *agent) loadManifests(manifestDir string) (map[string]manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(manifestDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if _, err := ioutil.ReadDir(manifestDir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	manifests := map[string]manifest{}
+	sources := map[string]string{}
+	appNames := []string{}
+	for _, path := range paths {
+		manifestJSON, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		definition := receptor.DesiredLRPCreateRequest{}
+		if err := json.Unmarshal(manifestJSON, &definition); err != nil {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: %s", path, err)}
+		}
+		var dependencies struct {
+			DependsOn []string `json:"depends_on"`
+		}
+		if err := json.Unmarshal(manifestJSON, &dependencies); err != nil {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: %s", path, err)}
+		}
+		if definition.ProcessGuid == "" {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: process_guid is required", path)}
+		}
+		if err := a.ReservedAppIds.Check(definition.ProcessGuid); err != nil {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: %s", path, err)}
+		}
+		if source, ok := sources[definition.ProcessGuid]; ok {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s and %s both define %s", source, path, definition.ProcessGuid)}
+		}
+
+		definition, err = docker_app_runner.LabelDefinition(definition, map[string]string{ManagedByLabel: ManagedByValue})
+		if err != nil {
+			return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: %s", path, err)}
+		}
+
+		manifests[definition.ProcessGuid] = manifest{definition: definition, dependsOn: dependencies.DependsOn}
+		sources[definition.ProcessGuid] = path
+		appNames = append(appNames, definition.ProcessGuid)
+	}
+
+	for _, appName := range appNames {
+		for _, dependency := range manifests[appName].dependsOn {
+			if _, ok := manifests[dependency]; !ok {
+				return nil, docker_app_runner.InvalidManifestError{Err: fmt.Errorf("%s: depends on %s, which has no manifest", sources[appName], dependency)}
+			}
+		}
+	}
+	return manifests, nil
+}
@@ -0,0 +1,406 @@
+package agent_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("Agent", func() {
+	var (
+		fakeAppRunner   *fake_app_runner.FakeAppRunner
+		fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		manifestDir     string
+		fakeClock       *fakeclock.FakeClock
+		current         map[string]receptor.DesiredLRPCreateRequest
+		reported        []agent.Change
+		ltcAgent        agent.Agent
+	)
+
+	progress := func(change agent.Change) {
+		reported = append(reported, change)
+	}
+
+	managedAnnotation := `{"labels":{"managed-by":"ltc-agent"}}`
+
+	writeManifest := func(fileName, manifestJSON string) {
+		Expect(ioutil.WriteFile(filepath.Join(manifestDir, fileName), []byte(manifestJSON), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fakeAppRunner = &fake_app_runner.FakeAppRunner{}
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		reported = nil
+
+		var err error
+		manifestDir, err = ioutil.TempDir("", "agent-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		current = map[string]receptor.DesiredLRPCreateRequest{}
+		fakeAppRunner.AppDefinitionStub = func(name string) (receptor.DesiredLRPCreateRequest, error) {
+			if definition, ok := current[name]; ok {
+				return definition, nil
+			}
+			return receptor.DesiredLRPCreateRequest{}, docker_app_runner.AppNotFoundError{AppName: name}
+		}
+
+		ltcAgent = agent.New(agent.AgentConfig{
+			AppRunner:      fakeAppRunner,
+			AppExaminer:    fakeAppExaminer,
+			ReservedAppIds: reserved_app_ids.Registry{"billing"},
+			Clock:          fakeClock,
+		})
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(manifestDir)).To(Succeed())
+	})
+
+	Describe("Reconcile", func() {
+		It("creates the apps that don't exist, labelled as managed by the agent", func() {
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice","instances":2}`)
+			writeManifest("README.md", "not a manifest")
+
+			changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{{AppName: "web", Action: agent.Created}}))
+			Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(1))
+			definition := fakeAppRunner.RestoreAppArgsForCall(0)
+			Expect(definition.ProcessGuid).To(Equal("web"))
+			Expect(definition.Instances).To(Equal(2))
+			Expect(definition.Annotation).To(Equal(managedAnnotation))
+		})
+
+		It("updates the apps that differ from their manifests", func() {
+			current["web"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "web", Domain: "lattice", Instances: 1, Annotation: managedAnnotation}
+			current["worker"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "worker", Domain: "lattice", MemoryMB: 128, Annotation: managedAnnotation}
+			current["api"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "api", Domain: "lattice", Instances: 3, Annotation: managedAnnotation}
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice","instances":4}`)
+			writeManifest("worker.json", `{"process_guid":"worker","domain":"lattice","memory_mb":256}`)
+			writeManifest("api.json", `{"process_guid":"api","domain":"lattice","instances":3}`)
+			fakeAppRunner.RestoreAppStub = func(definition receptor.DesiredLRPCreateRequest) (bool, error) {
+				return definition.ProcessGuid == "worker", nil
+			}
+
+			changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{
+				{AppName: "web", Action: agent.Updated, ChangedFields: []string{"instances"}},
+				{AppName: "worker", Action: agent.Recreated, ChangedFields: []string{"memory_mb"}},
+			}))
+			Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(2))
+		})
+
+		It("removes the apps it manages whose manifests are gone", func() {
+			current["web"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "web", Domain: "lattice", Annotation: managedAnnotation}
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice"}`)
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "web", Labels: map[string]string{"managed-by": "ltc-agent"}},
+				{ProcessGuid: "old-worker", Labels: map[string]string{"managed-by": "ltc-agent"}},
+				{ProcessGuid: "hand-made", Labels: map[string]string{"team": "payments"}},
+			}, nil)
+
+			changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{{AppName: "old-worker", Action: agent.Removed}}))
+			Expect(fakeAppRunner.RemoveAppCallCount()).To(Equal(1))
+			Expect(fakeAppRunner.RemoveAppArgsForCall(0)).To(Equal("old-worker"))
+		})
+
+		It("reports the apps it failed to change and carries on", func() {
+			writeManifest("api.json", `{"process_guid":"api","domain":"lattice"}`)
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice"}`)
+			fakeAppRunner.RestoreAppStub = func(definition receptor.DesiredLRPCreateRequest) (bool, error) {
+				if definition.ProcessGuid == "api" {
+					return true, errors.New("invalid LRP")
+				}
+				return true, nil
+			}
+
+			changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{
+				{AppName: "api", Action: agent.Created, Err: errors.New("invalid LRP")},
+				{AppName: "web", Action: agent.Created},
+			}))
+			Expect(reported).To(Equal(changes))
+		})
+
+		Context("when apps depend on others", func() {
+			var (
+				lock    sync.Mutex
+				running map[string]int
+			)
+
+			setRunning := func(appName string, instances int) {
+				lock.Lock()
+				defer lock.Unlock()
+				running[appName] = instances
+			}
+
+			reconcile := func() chan []agent.Change {
+				changesChan := make(chan []agent.Change, 1)
+				go func() {
+					defer GinkgoRecover()
+					changes, err := ltcAgent.Reconcile(manifestDir, progress)
+					Expect(err).NotTo(HaveOccurred())
+					changesChan <- changes
+				}()
+				return changesChan
+			}
+
+			BeforeEach(func() {
+				running = map[string]int{}
+				fakeAppExaminer.AppInstanceCountsStub = func(appName string) (app_examiner.InstanceCounts, error) {
+					lock.Lock()
+					defer lock.Unlock()
+					return app_examiner.InstanceCounts{Running: running[appName]}, nil
+				}
+				writeManifest("api.json", `{"process_guid":"api","domain":"lattice","depends_on":["db"]}`)
+				writeManifest("db.json", `{"process_guid":"db","domain":"lattice","instances":1}`)
+			})
+
+			It("changes an app once the apps it depends on are running", func() {
+				changesChan := reconcile()
+
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(1))
+				Expect(fakeAppRunner.RestoreAppArgsForCall(0).ProcessGuid).To(Equal("db"))
+
+				setRunning("db", 1)
+				fakeClock.Increment(time.Second)
+
+				var changes []agent.Change
+				Eventually(changesChan).Should(Receive(&changes))
+				Expect(changes).To(Equal([]agent.Change{
+					{AppName: "db", Action: agent.Created},
+					{AppName: "api", Action: agent.Created},
+				}))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(2))
+				Expect(fakeAppRunner.RestoreAppArgsForCall(1).ProcessGuid).To(Equal("api"))
+				Expect(reported).To(Equal([]agent.Change{
+					{AppName: "db", Action: agent.Created},
+					{AppName: "api", Action: agent.Waiting, WaitingFor: "db"},
+					{AppName: "api", Action: agent.Created},
+				}))
+			})
+
+			It("doesn't wait for apps that are already running", func() {
+				current["db"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "db", Domain: "lattice", Instances: 1, Annotation: managedAnnotation}
+				setRunning("db", 1)
+
+				changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(Equal([]agent.Change{{AppName: "api", Action: agent.Created}}))
+				Expect(reported).To(Equal(changes))
+			})
+
+			It("leaves an app for the next pass when the apps it depends on don't start in time", func() {
+				changesChan := reconcile()
+
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClock.Increment(agent.DefaultDependencyTimeout)
+
+				var changes []agent.Change
+				Eventually(changesChan).Should(Receive(&changes))
+				Expect(changes).To(Equal([]agent.Change{
+					{AppName: "db", Action: agent.Created},
+					{AppName: "api", Action: agent.Created, Err: errors.New("timed out waiting for db to be running")},
+				}))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(1))
+			})
+
+			It("doesn't change an app when an app it depends on couldn't be changed", func() {
+				fakeAppRunner.RestoreAppReturns(false, errors.New("invalid LRP"))
+
+				changes, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changes).To(Equal([]agent.Change{
+					{AppName: "db", Action: agent.Created, Err: errors.New("invalid LRP")},
+					{AppName: "api", Action: agent.Created, Err: errors.New("api depends on db, which couldn't be changed")},
+				}))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(Equal(1))
+				Expect(fakeAppExaminer.AppInstanceCountsCallCount()).To(BeZero())
+			})
+		})
+
+		It("returns errors listing the apps", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("receptor down"))
+
+			_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+			Expect(err).To(MatchError("receptor down"))
+			Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+		})
+
+		It("returns an error when the manifest directory can't be read", func() {
+			_, err := ltcAgent.Reconcile(filepath.Join(manifestDir, "missing"), progress)
+
+			Expect(err).To(HaveOccurred())
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(BeZero())
+		})
+
+		Context("when a manifest is invalid", func() {
+			BeforeEach(func() {
+				writeManifest("web.json", `{"process_guid":"web","domain":"lattice"}`)
+				fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "worker", Labels: map[string]string{"managed-by": "ltc-agent"}},
+				}, nil)
+			})
+
+			It("changes nothing when a manifest isn't JSON", func() {
+				writeManifest("worker.json", `{"process_guid":"wor`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
+				Expect(err.Error()).To(HavePrefix(filepath.Join(manifestDir, "worker.json") + ": "))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(BeZero())
+				Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+			})
+
+			It("requires a process guid", func() {
+				writeManifest("worker.json", `{"domain":"lattice"}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(MatchError(filepath.Join(manifestDir, "worker.json") + ": process_guid is required"))
+			})
+
+			It("refuses reserved app names", func() {
+				writeManifest("worker.json", `{"process_guid":"billing","domain":"lattice"}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(MatchError(filepath.Join(manifestDir, "worker.json") + ": billing is a reserved app name. It is reserved for this cluster; see ltc config get reserved-names."))
+			})
+
+			It("refuses two manifests for the same app", func() {
+				writeManifest("web-copy.json", `{"process_guid":"web","domain":"lattice"}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(MatchError(filepath.Join(manifestDir, "web-copy.json") + " and " + filepath.Join(manifestDir, "web.json") + " both define web"))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(BeZero())
+			})
+
+			It("requires the apps a manifest depends on to have manifests", func() {
+				writeManifest("worker.json", `{"process_guid":"worker","domain":"lattice","depends_on":["queue"]}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(MatchError(filepath.Join(manifestDir, "worker.json") + ": depends on queue, which has no manifest"))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(BeZero())
+			})
+
+			It("refuses apps that depend on each other in a cycle", func() {
+				writeManifest("api.json", `{"process_guid":"api","domain":"lattice","depends_on":["worker"]}`)
+				writeManifest("web.json", `{"process_guid":"web","domain":"lattice","depends_on":["api"]}`)
+				writeManifest("worker.json", `{"process_guid":"worker","domain":"lattice","depends_on":["web"]}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
+				Expect(err).To(MatchError("the apps depend on each other in a cycle: api -> worker -> web -> api"))
+				Expect(fakeAppRunner.RestoreAppCallCount()).To(BeZero())
+				Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+			})
+
+			It("requires the annotation to be a JSON object", func() {
+				writeManifest("worker.json", `{"process_guid":"worker","domain":"lattice","annotation":"build 42"}`)
+
+				_, err := ltcAgent.Reconcile(manifestDir, progress)
+
+				Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
+				Expect(err.Error()).To(ContainSubstring("The annotation must be a JSON object to add labels"))
+			})
+		})
+	})
+
+	Describe("Diff", func() {
+		It("returns what Reconcile would change without changing it", func() {
+			current["web"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "web", Domain: "lattice", Instances: 1, Annotation: managedAnnotation}
+			current["worker"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "worker", Domain: "lattice", MemoryMB: 128, Annotation: managedAnnotation}
+			current["api"] = receptor.DesiredLRPCreateRequest{ProcessGuid: "api", Domain: "lattice", Annotation: managedAnnotation}
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice","instances":4}`)
+			writeManifest("worker.json", `{"process_guid":"worker","domain":"lattice","memory_mb":256}`)
+			writeManifest("api.json", `{"process_guid":"api","domain":"lattice"}`)
+			writeManifest("cron.json", `{"process_guid":"cron","domain":"lattice"}`)
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "web", Labels: map[string]string{"managed-by": "ltc-agent"}},
+				{ProcessGuid: "old-worker", Labels: map[string]string{"managed-by": "ltc-agent"}},
+				{ProcessGuid: "hand-made"},
+			}, nil)
+
+			changes, err := ltcAgent.Diff(manifestDir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{
+				{AppName: "cron", Action: agent.Created},
+				{AppName: "web", Action: agent.Updated, ChangedFields: []string{"instances"}},
+				{AppName: "worker", Action: agent.Recreated, ChangedFields: []string{"memory_mb"}},
+				{AppName: "old-worker", Action: agent.Removed},
+			}))
+			Expect(fakeAppRunner.RestoreAppCallCount()).To(BeZero())
+			Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+		})
+
+		It("orders the changes after the apps they depend on", func() {
+			writeManifest("api.json", `{"process_guid":"api","domain":"lattice","depends_on":["db","queue"]}`)
+			writeManifest("db.json", `{"process_guid":"db","domain":"lattice"}`)
+			writeManifest("queue.json", `{"process_guid":"queue","domain":"lattice","depends_on":["db"]}`)
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice","depends_on":["api"]}`)
+
+			changes, err := ltcAgent.Diff(manifestDir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{
+				{AppName: "db", Action: agent.Created},
+				{AppName: "queue", Action: agent.Created},
+				{AppName: "api", Action: agent.Created},
+				{AppName: "web", Action: agent.Created},
+			}))
+		})
+
+		It("returns the apps that couldn't be looked up with their errors", func() {
+			writeManifest("web.json", `{"process_guid":"web","domain":"lattice"}`)
+			fakeAppRunner.AppDefinitionReturns(receptor.DesiredLRPCreateRequest{}, errors.New("receptor down"))
+
+			changes, err := ltcAgent.Diff(manifestDir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]agent.Change{{AppName: "web", Action: agent.Updated, Err: errors.New("receptor down")}}))
+		})
+
+		It("returns invalid manifests", func() {
+			writeManifest("web.json", `{"domain":"lattice"}`)
+
+			_, err := ltcAgent.Diff(manifestDir)
+
+			Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,199 @@
+package command_factory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock"
+)
+
+const DefaultInterval time.Duration = 30 * time.Second
+
+type AgentCommandFactory struct {
+	agent       agent.Agent
+	ui          terminal.UI
+	clock       clock.Clock
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewAgentCommandFactory(agent agent.Agent, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler) *AgentCommandFactory {
+	return &AgentCommandFactory{agent, ui, clock, exitHandler}
+}
+
+func (factory *AgentCommandFactory) MakeAgentCommand() cli.Command {
+	return cli.Command{
+		Name:  "agent",
+		Usage: "Keeps the apps on lattice matching a directory of manifests",
+		Description: `ltc agent MANIFEST_DIR [--interval=INTERVAL] [--once]
+
+   MANIFEST_DIR holds a desired LRP manifest for each app, the *.json files
+   'ltc submit-lrp' takes.  Every INTERVAL, until interrupted, apps without
+   one are created, apps that differ from their manifest are updated, and
+   apps the agent created whose manifest was removed are removed too.  Apps
+   the agent creates are labelled managed-by=ltc-agent; other apps are left
+   alone.
+
+   A manifest may list the apps that must be running before its app is
+   created or updated, e.g. "depends_on": ["db"].  Apps are changed after
+   the apps they depend on, and apps that depend on each other in a cycle
+   make the manifests invalid.
+
+   Nothing is changed while any manifest can't be read.  With --once, the
+   apps are reconciled once and ltc exits with 14 if anything failed.`,
+		Action: factory.runAgent,
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:  "interval, i",
+				Usage: "How often to reconcile the apps",
+				Value: DefaultInterval,
+			},
+			cli.BoolFlag{
+				Name:  "once",
+				Usage: "Reconciles the apps once, then exits",
+			},
+		},
+	}
+}
+
+func (factory *AgentCommandFactory) MakeDiffCommand() cli.Command {
+	return cli.Command{
+		Name:  "diff",
+		Usage: "Shows what ltc agent would change to match a directory of manifests",
+		Description: `ltc diff MANIFEST_DIR
+
+   Compares the manifests in MANIFEST_DIR with the apps on lattice, as one
+   pass of 'ltc agent MANIFEST_DIR' does, and prints the apps it would
+   create, update, recreate or remove, without changing them.`,
+		Action: factory.diffManifests,
+	}
+}
+
+// diffActions names the change ltc diff says the agent would make for each
+// Action the agent reports.
+var diffActions = map[agent.Action]string{
+	agent.Created:   "create",
+	agent.Updated:   "update",
+	agent.Recreated: "recreate",
+	agent.Removed:   "remove",
+}
+
+func (factory *AgentCommandFactory) diffManifests(context *cli.Context) {
+	manifestDir := context.Args().First()
+	if manifestDir == "" || len(context.Args()) > 1 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc diff MANIFEST_DIR'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	changes, err := factory.agent.Diff(manifestDir)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error comparing the manifests: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	if len(changes) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("The apps match the manifests in %s.", manifestDir))
+		return
+	}
+
+	var changeErr error
+	for _, change := range changes {
+		if change.Err != nil {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Error comparing %s: %s", change.AppName, change.Err.Error())))
+			if changeErr == nil {
+				changeErr = change.Err
+			}
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s", diffActions[change.Action], colors.Bold(change.AppName))
+		if len(change.ChangedFields) > 0 {
+			message += fmt.Sprintf(" (%s)", strings.Join(change.ChangedFields, ", "))
+		}
+		factory.ui.SayLine(message)
+	}
+
+	if changeErr != nil {
+		factory.exitHandler.Exit(exit_codes.ForError(changeErr, exit_codes.CommandFailed))
+	}
+}
+
+func (factory *AgentCommandFactory) runAgent(context *cli.Context) {
+	manifestDir := context.Args().First()
+	intervalFlag := context.Duration("interval")
+	onceFlag := context.Bool("once")
+
+	switch {
+	case manifestDir == "" || len(context.Args()) > 1:
+		factory.ui.SayIncorrectUsage("Please enter 'ltc agent MANIFEST_DIR'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case intervalFlag <= 0:
+		factory.ui.SayIncorrectUsage("--interval must be positive")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if onceFlag {
+		if err := factory.reconcile(manifestDir); err != nil {
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		}
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Reconciling apps to the manifests in %s every %s. Press Ctrl-C to stop.", manifestDir, intervalFlag))
+	done := exit_handler.Done(factory.exitHandler)
+	for {
+		factory.reconcile(manifestDir)
+
+		timer := factory.clock.NewTimer(intervalFlag)
+		select {
+		case <-timer.C():
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// reconcile prints what the agent changes as it changes it, and returns the
+// first error, so that --once can exit with it.
+func (factory *AgentCommandFactory) reconcile(manifestDir string) error {
+	changes, reconcileErr := factory.agent.Reconcile(manifestDir, factory.sayChange)
+
+	if reconcileErr != nil {
+		timestamp := factory.clock.Now().Format("15:04:05")
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%s  Error reconciling apps: %s", timestamp, reconcileErr.Error())))
+		return reconcileErr
+	}
+	for _, change := range changes {
+		if change.Err != nil {
+			return change.Err
+		}
+	}
+	return nil
+}
+
+func (factory *AgentCommandFactory) sayChange(change agent.Change) {
+	timestamp := factory.clock.Now().Format("15:04:05")
+
+	switch {
+	case change.Err != nil:
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%s  Error reconciling %s: %s", timestamp, change.AppName, change.Err.Error())))
+	case change.Action == agent.Waiting:
+		factory.ui.SayLine(fmt.Sprintf("%s  %s is waiting for %s to be running", timestamp, colors.Bold(change.AppName), colors.Bold(change.WaitingFor)))
+	default:
+		message := fmt.Sprintf("%s  %s %s", timestamp, change.Action, colors.Bold(change.AppName))
+		if len(change.ChangedFields) > 0 {
+			message += fmt.Sprintf(" (%s)", strings.Join(change.ChangedFields, ", "))
+		}
+		factory.ui.SayLine(message)
+	}
+}
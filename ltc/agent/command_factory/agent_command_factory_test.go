@@ -0,0 +1,229 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent"
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent/fake_agent"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("AgentCommandFactory", func() {
+	var (
+		fakeAgent       *fake_agent.FakeAgent
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		fakeClock       *fakeclock.FakeClock
+		agentCommand    cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeAgent = &fake_agent.FakeAgent{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+
+		commandFactory := command_factory.NewAgentCommandFactory(fakeAgent, terminal.NewUI(nil, outputBuffer, nil), fakeClock, fakeExitHandler)
+		agentCommand = commandFactory.MakeAgentCommand()
+	})
+
+	// reconcileMakes has the agent report changes as it makes them.
+	reconcileMakes := func(changes ...agent.Change) {
+		fakeAgent.ReconcileStub = func(manifestDir string, progress func(agent.Change)) ([]agent.Change, error) {
+			for _, change := range changes {
+				progress(change)
+			}
+			return changes, nil
+		}
+	}
+
+	It("reconciles the apps every interval until interrupted", func() {
+		reconcileMakes(
+			agent.Change{AppName: "web", Action: agent.Created},
+			agent.Change{AppName: "worker", Action: agent.Recreated, ChangedFields: []string{"memory_mb", "setup"}},
+			agent.Change{AppName: "old-worker", Action: agent.Removed},
+		)
+
+		commandDone := test_helpers.AsyncExecuteCommandWithArgs(agentCommand, []string{"--interval=1m", "/etc/lattice/apps"})
+
+		Eventually(outputBuffer).Should(test_helpers.SayLine("Reconciling apps to the manifests in /etc/lattice/apps every 1m0s. Press Ctrl-C to stop."))
+		Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:00  created " + colors.Bold("web")))
+		Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:00  recreated " + colors.Bold("worker") + " (memory_mb, setup)"))
+		Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:00  removed " + colors.Bold("old-worker")))
+		manifestDir, _ := fakeAgent.ReconcileArgsForCall(0)
+		Expect(manifestDir).To(Equal("/etc/lattice/apps"))
+
+		fakeAgent.ReconcileReturns([]agent.Change{}, nil)
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		fakeClock.Increment(time.Minute)
+		Eventually(fakeAgent.ReconcileCallCount).Should(Equal(2))
+		Expect(commandDone).NotTo(BeClosed())
+
+		fakeExitHandler.Exit(exit_codes.SigInt)
+
+		Eventually(commandDone).Should(BeClosed())
+		Expect(fakeAgent.ReconcileCallCount()).To(Equal(2))
+	})
+
+	It("keeps going when reconciling fails", func() {
+		fakeAgent.ReconcileReturns(nil, docker_app_runner.InvalidManifestError{Err: errors.New("/etc/lattice/apps/web.json: unexpected end of JSON input")})
+
+		commandDone := test_helpers.AsyncExecuteCommandWithArgs(agentCommand, []string{"/etc/lattice/apps"})
+
+		Eventually(outputBuffer).Should(test_helpers.SayLine(colors.Red("12:00:00  Error reconciling apps: /etc/lattice/apps/web.json: unexpected end of JSON input")))
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		fakeClock.Increment(command_factory.DefaultInterval)
+		Eventually(fakeAgent.ReconcileCallCount).Should(Equal(2))
+
+		fakeExitHandler.Exit(exit_codes.SigInt)
+
+		Eventually(commandDone).Should(BeClosed())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.SigInt}))
+	})
+
+	Context("with --once", func() {
+		It("reconciles the apps once", func() {
+			reconcileMakes(agent.Change{AppName: "web", Action: agent.Updated, ChangedFields: []string{"instances"}})
+
+			test_helpers.ExecuteCommandWithArgs(agentCommand, []string{"--once", "/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("12:00:00  updated " + colors.Bold("web") + " (instances)"))
+			Expect(fakeAgent.ReconcileCallCount()).To(Equal(1))
+			Expect(fakeClock.WatcherCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits when an app couldn't be reconciled", func() {
+			reconcileMakes(
+				agent.Change{AppName: "api", Action: agent.Created, Err: errors.New("invalid LRP")},
+				agent.Change{AppName: "web", Action: agent.Created},
+			)
+
+			test_helpers.ExecuteCommandWithArgs(agentCommand, []string{"--once", "/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("12:00:00  Error reconciling api: invalid LRP")))
+			Expect(outputBuffer).To(test_helpers.SayLine("12:00:00  created " + colors.Bold("web")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("says when a change waits for an app it depends on", func() {
+			fakeAgent.ReconcileStub = func(manifestDir string, progress func(agent.Change)) ([]agent.Change, error) {
+				progress(agent.Change{AppName: "db", Action: agent.Created})
+				progress(agent.Change{AppName: "api", Action: agent.Waiting, WaitingFor: "db"})
+				fakeClock.Increment(5 * time.Second)
+				progress(agent.Change{AppName: "api", Action: agent.Created})
+				return []agent.Change{{AppName: "db", Action: agent.Created}, {AppName: "api", Action: agent.Created}}, nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(agentCommand, []string{"--once", "/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("12:00:00  created " + colors.Bold("db")))
+			Expect(outputBuffer).To(test_helpers.SayLine("12:00:00  " + colors.Bold("api") + " is waiting for " + colors.Bold("db") + " to be running"))
+			Expect(outputBuffer).To(test_helpers.SayLine("12:00:05  created " + colors.Bold("api")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits with the error for an invalid manifest", func() {
+			fakeAgent.ReconcileReturns(nil, docker_app_runner.InvalidManifestError{Err: errors.New("/etc/lattice/apps/web.json: process_guid is required")})
+
+			test_helpers.ExecuteCommandWithArgs(agentCommand, []string{"--once", "/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("12:00:00  Error reconciling apps: /etc/lattice/apps/web.json: process_guid is required")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	It("requires a manifest directory", func() {
+		test_helpers.ExecuteCommandWithArgs(agentCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say("Please enter 'ltc agent MANIFEST_DIR'"))
+		Expect(fakeAgent.ReconcileCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+
+	It("requires a positive interval", func() {
+		test_helpers.ExecuteCommandWithArgs(agentCommand, []string{"--interval=0s", "/etc/lattice/apps"})
+
+		Expect(outputBuffer).To(test_helpers.Say("--interval must be positive"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+
+	Describe("DiffCommand", func() {
+		var diffCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewAgentCommandFactory(fakeAgent, terminal.NewUI(nil, outputBuffer, nil), fakeClock, fakeExitHandler)
+			diffCommand = commandFactory.MakeDiffCommand()
+		})
+
+		It("prints what the agent would change", func() {
+			fakeAgent.DiffReturns([]agent.Change{
+				{AppName: "cron", Action: agent.Created},
+				{AppName: "web", Action: agent.Updated, ChangedFields: []string{"instances"}},
+				{AppName: "worker", Action: agent.Recreated, ChangedFields: []string{"memory_mb", "setup"}},
+				{AppName: "old-worker", Action: agent.Removed},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("create " + colors.Bold("cron")))
+			Expect(outputBuffer).To(test_helpers.SayLine("update " + colors.Bold("web") + " (instances)"))
+			Expect(outputBuffer).To(test_helpers.SayLine("recreate " + colors.Bold("worker") + " (memory_mb, setup)"))
+			Expect(outputBuffer).To(test_helpers.SayLine("remove " + colors.Bold("old-worker")))
+			Expect(fakeAgent.DiffArgsForCall(0)).To(Equal("/etc/lattice/apps"))
+			Expect(fakeAgent.ReconcileCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("says when the apps match", func() {
+			fakeAgent.DiffReturns([]agent.Change{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("The apps match the manifests in /etc/lattice/apps."))
+		})
+
+		It("exits when an app couldn't be compared", func() {
+			fakeAgent.DiffReturns([]agent.Change{
+				{AppName: "api", Action: agent.Updated, Err: errors.New("receptor down")},
+				{AppName: "web", Action: agent.Created},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Error comparing api: receptor down")))
+			Expect(outputBuffer).To(test_helpers.SayLine("create " + colors.Bold("web")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits with the error for an invalid manifest", func() {
+			fakeAgent.DiffReturns(nil, docker_app_runner.InvalidManifestError{Err: errors.New("/etc/lattice/apps/web.json: process_guid is required")})
+
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{"/etc/lattice/apps"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error comparing the manifests: /etc/lattice/apps/web.json: process_guid is required"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("requires a manifest directory", func() {
+			test_helpers.ExecuteCommandWithArgs(diffCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Please enter 'ltc diff MANIFEST_DIR'"))
+			Expect(fakeAgent.DiffCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+})
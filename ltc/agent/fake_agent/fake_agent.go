@@ -0,0 +1,99 @@
+// This file was generated by counterfeiter
+package fake_agent
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent"
+)
+
+type FakeAgent struct {
+	ReconcileStub        func(manifestDir string, progress func(agent.Change)) ([]agent.Change, error)
+	reconcileMutex       sync.RWMutex
+	reconcileArgsForCall []struct {
+		manifestDir string
+		progress    func(agent.Change)
+	}
+	reconcileReturns struct {
+		result1 []agent.Change
+		result2 error
+	}
+	DiffStub        func(manifestDir string) ([]agent.Change, error)
+	diffMutex       sync.RWMutex
+	diffArgsForCall []struct {
+		manifestDir string
+	}
+	diffReturns struct {
+		result1 []agent.Change
+		result2 error
+	}
+}
+
+func (fake *FakeAgent) Reconcile(manifestDir string, progress func(agent.Change)) ([]agent.Change, error) {
+	fake.reconcileMutex.Lock()
+	fake.reconcileArgsForCall = append(fake.reconcileArgsForCall, struct {
+		manifestDir string
+		progress    func(agent.Change)
+	}{manifestDir, progress})
+	fake.reconcileMutex.Unlock()
+	if fake.ReconcileStub != nil {
+		return fake.ReconcileStub(manifestDir, progress)
+	} else {
+		return fake.reconcileReturns.result1, fake.reconcileReturns.result2
+	}
+}
+
+func (fake *FakeAgent) ReconcileCallCount() int {
+	fake.reconcileMutex.RLock()
+	defer fake.reconcileMutex.RUnlock()
+	return len(fake.reconcileArgsForCall)
+}
+
+func (fake *FakeAgent) ReconcileArgsForCall(i int) (string, func(agent.Change)) {
+	fake.reconcileMutex.RLock()
+	defer fake.reconcileMutex.RUnlock()
+	return fake.reconcileArgsForCall[i].manifestDir, fake.reconcileArgsForCall[i].progress
+}
+
+func (fake *FakeAgent) ReconcileReturns(result1 []agent.Change, result2 error) {
+	fake.ReconcileStub = nil
+	fake.reconcileReturns = struct {
+		result1 []agent.Change
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgent) Diff(manifestDir string) ([]agent.Change, error) {
+	fake.diffMutex.Lock()
+	fake.diffArgsForCall = append(fake.diffArgsForCall, struct {
+		manifestDir string
+	}{manifestDir})
+	fake.diffMutex.Unlock()
+	if fake.DiffStub != nil {
+		return fake.DiffStub(manifestDir)
+	} else {
+		return fake.diffReturns.result1, fake.diffReturns.result2
+	}
+}
+
+func (fake *FakeAgent) DiffCallCount() int {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	return len(fake.diffArgsForCall)
+}
+
+func (fake *FakeAgent) DiffArgsForCall(i int) string {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	return fake.diffArgsForCall[i].manifestDir
+}
+
+func (fake *FakeAgent) DiffReturns(result1 []agent.Change, result2 error) {
+	fake.DiffStub = nil
+	fake.diffReturns = struct {
+		result1 []agent.Change
+		result2 error
+	}{result1, result2}
+}
+
+var _ agent.Agent = new(FakeAgent)
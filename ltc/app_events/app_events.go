@@ -0,0 +1,172 @@
+package app_events
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+type EventType string
+
+const (
+	AppCreated      EventType = "app_created"
+	AppRemoved      EventType = "app_removed"
+	AppScaled       EventType = "app_scaled"
+	RoutesChanged   EventType = "routes_changed"
+	InstanceStarted EventType = "instance_started"
+	InstanceCrashed EventType = "instance_crashed"
+	InstanceStopped EventType = "instance_stopped"
+	PlacementFailed EventType = "placement_failed"
+)
+
+type AppEvent struct {
+	Type      EventType `json:"type"`
+	AppName   string    `json:"app_name"`
+	Index     *int      `json:"index,omitempty"`
+	CellID    string    `json:"cell_id,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+//go:generate counterfeiter -o fake_app_events/fake_app_event_source.go . AppEventSource
+type AppEventSource interface {
+	Next() (AppEvent, error)
+	Close() error
+}
+
+//go:generate counterfeiter -o fake_app_events/fake_app_event_subscriber.go . AppEventSubscriber
+type AppEventSubscriber interface {
+	Subscribe() (AppEventSource, error)
+}
+
+type appEventSubscriber struct {
+	receptorClient receptor.Client
+	clock          clock.Clock
+}
+
+func NewAppEventSubscriber(receptorClient receptor.Client, clock clock.Clock) AppEventSubscriber {
+	return &appEventSubscriber{receptorClient, clock}
+}
+
+func (s *appEventSubscriber) Subscribe() (AppEventSource, error) {
+	eventSource, err := s.receptorClient.SubscribeToEvents()
+	if err != nil {
+		return nil, err
+	}
+	return &appEventSource{eventSource: eventSource, clock: s.clock}, nil
+}
+
+// appEventSource turns receptor LRP events into app lifecycle events,
+// queueing them since one receptor event can describe several changes.
+type appEventSource struct {
+	eventSource receptor.EventSource
+	clock       clock.Clock
+	pending     []AppEvent
+}
+
+func (s *appEventSource) Next() (AppEvent, error) {
+	for len(s.pending) == 0 {
+		event, err := s.eventSource.Next()
+		if err != nil {
+			return AppEvent{}, err
+		}
+		s.pending = s.translate(event)
+	}
+
+	appEvent := s.pending[0]
+	s.pending = s.pending[1:]
+	return appEvent, nil
+}
+
+func (s *appEventSource) Close() error {
+	return s.eventSource.Close()
+}
+
+func (s *appEventSource) translate(event receptor.Event) []AppEvent {
+	var appEvents []AppEvent
+
+	switch event := event.(type) {
+	case receptor.DesiredLRPCreatedEvent:
+		desired := event.DesiredLRPResponse
+		appEvents = append(appEvents, s.appEvent(AppCreated, desired.ProcessGuid, fmt.Sprintf("App created with %d instances", desired.Instances)))
+	case receptor.DesiredLRPChangedEvent:
+		if event.Before.Instances != event.After.Instances {
+			appEvents = append(appEvents, s.appEvent(AppScaled, event.After.ProcessGuid, fmt.Sprintf("Scaled from %d to %d instances", event.Before.Instances, event.After.Instances)))
+		}
+		beforeRoutes := route_helpers.AppRoutesFromRoutingInfo(event.Before.Routes)
+		afterRoutes := route_helpers.AppRoutesFromRoutingInfo(event.After.Routes)
+		if !reflect.DeepEqual(beforeRoutes, afterRoutes) {
+			appEvents = append(appEvents, s.appEvent(RoutesChanged, event.After.ProcessGuid, "Routes changed to "+formatRoutes(afterRoutes)))
+		}
+	case receptor.DesiredLRPRemovedEvent:
+		appEvents = append(appEvents, s.appEvent(AppRemoved, event.DesiredLRPResponse.ProcessGuid, "App removed"))
+	case receptor.ActualLRPCreatedEvent:
+		if actual := event.ActualLRPResponse; actual.PlacementError != "" {
+			appEvents = append(appEvents, s.instanceEvent(PlacementFailed, actual, actual.PlacementError))
+		}
+	case receptor.ActualLRPChangedEvent:
+		before, after := event.Before, event.After
+		switch {
+		case after.PlacementError != "" && after.PlacementError != before.PlacementError:
+			appEvents = append(appEvents, s.instanceEvent(PlacementFailed, after, after.PlacementError))
+		case after.State == receptor.ActualLRPStateRunning && before.State != receptor.ActualLRPStateRunning:
+			appEvents = append(appEvents, s.instanceEvent(InstanceStarted, after, "Instance started"))
+		case after.State == receptor.ActualLRPStateCrashed && before.State != receptor.ActualLRPStateCrashed,
+			after.CrashCount > before.CrashCount:
+			message := fmt.Sprintf("Instance crashed (crash count: %d)", after.CrashCount)
+			if after.CrashReason != "" {
+				message += ": " + after.CrashReason
+			}
+			appEvents = append(appEvents, s.instanceEvent(InstanceCrashed, after, message))
+		}
+	case receptor.ActualLRPRemovedEvent:
+		appEvents = append(appEvents, s.instanceEvent(InstanceStopped, event.ActualLRPResponse, "Instance stopped"))
+	}
+
+	var visibleEvents []AppEvent
+	for _, appEvent := range appEvents {
+		if appEvent.AppName != reserved_app_ids.LatticeDebugLogStreamAppId && appEvent.AppName != reserved_app_ids.LatticeSecretsAppId {
+			visibleEvents = append(visibleEvents, appEvent)
+		}
+	}
+	return visibleEvents
+}
+
+func (s *appEventSource) appEvent(eventType EventType, appName, message string) AppEvent {
+	return AppEvent{
+		Type:      eventType,
+		AppName:   appName,
+		Message:   message,
+		Timestamp: s.clock.Now(),
+	}
+}
+
+func (s *appEventSource) instanceEvent(eventType EventType, actual receptor.ActualLRPResponse, message string) AppEvent {
+	index := actual.Index
+	appEvent := s.appEvent(eventType, actual.ProcessGuid, message)
+	appEvent.Index = &index
+	appEvent.CellID = actual.CellID
+	return appEvent
+}
+
+func formatRoutes(routes route_helpers.AppRoutes) string {
+	if len(routes) == 0 {
+		return "none"
+	}
+
+	var formattedRoutes []string
+	for port, hostnames := range routes.HostnamesByPort() {
+		for _, hostname := range hostnames {
+			formattedRoutes = append(formattedRoutes, fmt.Sprintf("%s => %d", hostname, port))
+		}
+	}
+	sort.Strings(formattedRoutes)
+	return strings.Join(formattedRoutes, ", ")
+}
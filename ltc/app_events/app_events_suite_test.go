@@ -0,0 +1,13 @@
+package app_events_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAppEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppEvents Suite")
+}
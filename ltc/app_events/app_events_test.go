@@ -0,0 +1,163 @@
+package app_events_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("AppEvents", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeEventSource    *fake_receptor.FakeEventSource
+		fakeClock          *fakeclock.FakeClock
+		receptorEvents     []receptor.Event
+		appEventSource     app_events.AppEventSource
+	)
+
+	index := func(i int) *int { return &i }
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		fakeEventSource = &fake_receptor.FakeEventSource{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		receptorEvents = nil
+
+		fakeEventSource.NextStub = func() (receptor.Event, error) {
+			if len(receptorEvents) == 0 {
+				return nil, receptor.ErrSourceClosed
+			}
+			event := receptorEvents[0]
+			receptorEvents = receptorEvents[1:]
+			return event, nil
+		}
+		fakeReceptorClient.SubscribeToEventsReturns(fakeEventSource, nil)
+
+		var err error
+		appEventSource, err = app_events.NewAppEventSubscriber(fakeReceptorClient, fakeClock).Subscribe()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns errors from subscribing", func() {
+		fakeReceptorClient.SubscribeToEventsReturns(nil, errors.New("no events for you"))
+
+		_, err := app_events.NewAppEventSubscriber(fakeReceptorClient, fakeClock).Subscribe()
+
+		Expect(err).To(MatchError("no events for you"))
+	})
+
+	It("reports app creation and removal", func() {
+		receptorEvents = []receptor.Event{
+			receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: "cool-app", Instances: 2}),
+			receptor.NewDesiredLRPRemovedEvent(receptor.DesiredLRPResponse{ProcessGuid: "cool-app"}),
+		}
+
+		Expect(appEventSource.Next()).To(Equal(app_events.AppEvent{
+			Type:      app_events.AppCreated,
+			AppName:   "cool-app",
+			Message:   "App created with 2 instances",
+			Timestamp: fakeClock.Now(),
+		}))
+		event, err := appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.AppRemoved))
+	})
+
+	It("reports scaling and route changes from a single change", func() {
+		before := receptor.DesiredLRPResponse{
+			ProcessGuid: "cool-app",
+			Instances:   1,
+			Routes:      route_helpers.AppRoutes{{Hostnames: []string{"cool-app.example.com"}, Port: 8080}}.RoutingInfo(),
+		}
+		after := before
+		after.Instances = 3
+		after.Routes = route_helpers.AppRoutes{{Hostnames: []string{"new-route.example.com"}, Port: 8080}}.RoutingInfo()
+		receptorEvents = []receptor.Event{receptor.NewDesiredLRPChangedEvent(before, after)}
+
+		event, err := appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.AppScaled))
+		Expect(event.Message).To(Equal("Scaled from 1 to 3 instances"))
+
+		event, err = appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.RoutesChanged))
+		Expect(event.Message).To(Equal("Routes changed to new-route.example.com => 8080"))
+	})
+
+	It("reports instances starting, crashing and stopping", func() {
+		claimed := receptor.ActualLRPResponse{ProcessGuid: "cool-app", Index: 1, CellID: "cell-0", State: receptor.ActualLRPStateClaimed}
+		running := claimed
+		running.State = receptor.ActualLRPStateRunning
+		crashed := running
+		crashed.State = receptor.ActualLRPStateCrashed
+		crashed.CrashCount = 1
+		crashed.CrashReason = "exit status 2"
+
+		receptorEvents = []receptor.Event{
+			receptor.NewActualLRPCreatedEvent(claimed),
+			receptor.NewActualLRPChangedEvent(claimed, running),
+			receptor.NewActualLRPChangedEvent(running, crashed),
+			receptor.NewActualLRPRemovedEvent(crashed),
+		}
+
+		Expect(appEventSource.Next()).To(Equal(app_events.AppEvent{
+			Type:      app_events.InstanceStarted,
+			AppName:   "cool-app",
+			Index:     index(1),
+			CellID:    "cell-0",
+			Message:   "Instance started",
+			Timestamp: fakeClock.Now(),
+		}))
+
+		event, err := appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.InstanceCrashed))
+		Expect(event.Message).To(Equal("Instance crashed (crash count: 1): exit status 2"))
+
+		event, err = appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.InstanceStopped))
+	})
+
+	It("reports placement failures", func() {
+		unclaimed := receptor.ActualLRPResponse{ProcessGuid: "cool-app", State: receptor.ActualLRPStateUnclaimed}
+		unplaceable := unclaimed
+		unplaceable.PlacementError = "insufficient resources"
+		receptorEvents = []receptor.Event{receptor.NewActualLRPChangedEvent(unclaimed, unplaceable)}
+
+		event, err := appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Type).To(Equal(app_events.PlacementFailed))
+		Expect(event.Message).To(Equal("insufficient resources"))
+		Expect(event.Index).To(Equal(index(0)))
+	})
+
+	It("skips events for reserved apps", func() {
+		receptorEvents = []receptor.Event{
+			receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: reserved_app_ids.LatticeSecretsAppId}),
+			receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: "cool-app"}),
+		}
+
+		event, err := appEventSource.Next()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.AppName).To(Equal("cool-app"))
+	})
+
+	It("returns errors from the event stream and closes it", func() {
+		_, err := appEventSource.Next()
+		Expect(err).To(Equal(receptor.ErrSourceClosed))
+
+		Expect(appEventSource.Close()).To(Succeed())
+		Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+	})
+})
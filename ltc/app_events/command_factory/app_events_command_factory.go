@@ -0,0 +1,109 @@
+package command_factory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+type AppEventsCommandFactory struct {
+	appEventSubscriber app_events.AppEventSubscriber
+	ui                 terminal.UI
+	exitHandler        exit_handler.ExitHandler
+}
+
+func NewAppEventsCommandFactory(appEventSubscriber app_events.AppEventSubscriber, ui terminal.UI, exitHandler exit_handler.ExitHandler) *AppEventsCommandFactory {
+	return &AppEventsCommandFactory{appEventSubscriber, ui, exitHandler}
+}
+
+func (factory *AppEventsCommandFactory) MakeEventsCommand() cli.Command {
+	var eventsFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json, j",
+			Usage: "Prints each event as a line of JSON",
+		},
+	}
+
+	var eventsCommand = cli.Command{
+		Name:    "events",
+		Aliases: []string{"ev"},
+		Usage:   "Streams app lifecycle events",
+		Description: `ltc events [APP_NAME] [--json]
+
+   Prints instance starts, crashes, placement failures, scaling and route
+   changes as they happen.  Without APP_NAME, events for all apps are shown.`,
+		Action: factory.streamEvents,
+		Flags:  eventsFlags,
+	}
+
+	return eventsCommand
+}
+
+func (factory *AppEventsCommandFactory) streamEvents(context *cli.Context) {
+	appName := context.Args().First()
+	jsonOutput := context.Bool("json")
+
+	eventSource, err := factory.appEventSubscriber.Subscribe()
+	if err != nil {
+		factory.ui.SayLine("Error subscribing to events: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	factory.exitHandler.OnExit(func() {
+		eventSource.Close()
+	})
+
+	for {
+		event, err := eventSource.Next()
+		if err == receptor.ErrSourceClosed {
+			return
+		} else if err != nil {
+			factory.ui.SayLine("Error reading events: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+
+		if appName != "" && event.AppName != appName {
+			continue
+		}
+
+		if jsonOutput {
+			factory.sayJSON(event)
+		} else {
+			factory.sayEvent(event)
+		}
+	}
+}
+
+func (factory *AppEventsCommandFactory) sayJSON(event app_events.AppEvent) {
+	eventJson, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	factory.ui.SayLine(string(eventJson))
+}
+
+func (factory *AppEventsCommandFactory) sayEvent(event app_events.AppEvent) {
+	source := event.AppName
+	if event.Index != nil {
+		source = fmt.Sprintf("%s|%d", event.AppName, *event.Index)
+	}
+
+	message := event.Message
+	switch event.Type {
+	case app_events.InstanceCrashed, app_events.PlacementFailed:
+		message = colors.Red(message)
+	case app_events.InstanceStarted:
+		message = colors.Green(message)
+	}
+
+	timeString := event.Timestamp.Format("01/02 15:04:05.00")
+	factory.ui.SayLine(fmt.Sprintf("%s [%s] %s", colors.Cyan(timeString), colors.Yellow(source), message))
+}
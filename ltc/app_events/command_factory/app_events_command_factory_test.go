@@ -0,0 +1,109 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events/fake_app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("AppEventsCommandFactory", func() {
+	var (
+		fakeSubscriber  *fake_app_events.FakeAppEventSubscriber
+		fakeEventSource *fake_app_events.FakeAppEventSource
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		events          []app_events.AppEvent
+		eventsCommand   cli.Command
+		timestamp       time.Time
+	)
+
+	BeforeEach(func() {
+		fakeSubscriber = &fake_app_events.FakeAppEventSubscriber{}
+		fakeEventSource = &fake_app_events.FakeAppEventSource{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		timestamp = time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+
+		index := 2
+		events = []app_events.AppEvent{
+			{Type: app_events.AppCreated, AppName: "other-app", Message: "App created with 1 instances", Timestamp: timestamp},
+			{Type: app_events.InstanceCrashed, AppName: "cool-app", Index: &index, Message: "Instance crashed (crash count: 1)", Timestamp: timestamp},
+		}
+		fakeEventSource.NextStub = func() (app_events.AppEvent, error) {
+			if len(events) == 0 {
+				return app_events.AppEvent{}, receptor.ErrSourceClosed
+			}
+			event := events[0]
+			events = events[1:]
+			return event, nil
+		}
+		fakeSubscriber.SubscribeReturns(fakeEventSource, nil)
+
+		commandFactory := command_factory.NewAppEventsCommandFactory(fakeSubscriber, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+		eventsCommand = commandFactory.MakeEventsCommand()
+	})
+
+	It("prints events for all apps until the stream closes", func() {
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say("06/01 12:30:00.00"))
+		Expect(outputBuffer).To(test_helpers.Say("other-app"))
+		Expect(outputBuffer).To(test_helpers.Say("App created with 1 instances"))
+		Expect(outputBuffer).To(test_helpers.Say("cool-app|2"))
+		Expect(outputBuffer).To(test_helpers.Say("Instance crashed (crash count: 1)"))
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("filters events by app name", func() {
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).NotTo(test_helpers.Say("other-app"))
+		Expect(outputBuffer).To(test_helpers.Say("Instance crashed"))
+	})
+
+	It("prints events as JSON", func() {
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{"--json", "cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(`{"type":"instance_crashed","app_name":"cool-app","index":2,"message":"Instance crashed (crash count: 1)","timestamp":"2015-06-01T12:30:00Z"}`))
+	})
+
+	It("closes the event source on exit", func() {
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{})
+
+		fakeExitHandler.Exit(exit_codes.SigInt)
+
+		Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+	})
+
+	It("exits when subscribing fails", func() {
+		fakeSubscriber.SubscribeReturns(nil, errors.New("receptor unavailable"))
+
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say("Error subscribing to events: receptor unavailable"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+
+	It("exits when the event stream fails", func() {
+		fakeEventSource.NextStub = nil
+		fakeEventSource.NextReturns(app_events.AppEvent{}, errors.New("stream broke"))
+
+		test_helpers.ExecuteCommandWithArgs(eventsCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say("Error reading events: stream broke"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+})
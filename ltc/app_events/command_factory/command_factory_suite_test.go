@@ -0,0 +1,13 @@
+package command_factory_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAppEventsCommandFactory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppEvents CommandFactory Suite")
+}
@@ -0,0 +1,252 @@
+package command_factory
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+const notifyUsage = "Please enter 'ltc notify add --url URL [--events EVENTS]', 'ltc notify list', 'ltc notify remove URL' or 'ltc notify watch'"
+
+// notifyEvents are the names `ltc notify add --events` takes for each type
+// of app event.
+var notifyEvents = map[string]app_events.EventType{
+	"create":    app_events.AppCreated,
+	"remove":    app_events.AppRemoved,
+	"scale":     app_events.AppScaled,
+	"routes":    app_events.RoutesChanged,
+	"start":     app_events.InstanceStarted,
+	"crash":     app_events.InstanceCrashed,
+	"stop":      app_events.InstanceStopped,
+	"placement": app_events.PlacementFailed,
+}
+
+// webhookPayload is what `ltc notify watch` posts for each event.  Text
+// repeats the event as a sentence so that chat webhooks such as Slack's
+// can show it without any translation.
+type webhookPayload struct {
+	app_events.AppEvent
+	Text string `json:"text"`
+}
+
+type NotifyCommandFactory struct {
+	appEventSubscriber app_events.AppEventSubscriber
+	webhooks           *config.Webhooks
+	notifier           notifier.Notifier
+	ui                 terminal.UI
+	exitHandler        exit_handler.ExitHandler
+}
+
+func NewNotifyCommandFactory(appEventSubscriber app_events.AppEventSubscriber, webhooks *config.Webhooks, notifier notifier.Notifier, ui terminal.UI, exitHandler exit_handler.ExitHandler) *NotifyCommandFactory {
+	return &NotifyCommandFactory{appEventSubscriber, webhooks, notifier, ui, exitHandler}
+}
+
+func (factory *NotifyCommandFactory) MakeNotifyCommand() cli.Command {
+	return cli.Command{
+		Name:  "notify",
+		Usage: "Posts app lifecycle events to webhooks",
+		Description: `ltc notify add --url URL [--events EVENTS]
+   ltc notify list
+   ltc notify remove URL
+   ltc notify watch
+
+   EVENTS is a comma-separated list of ` + strings.Join(notifyEventNames(), ", ") + `.
+   Without --events, every event is posted.  Adding a URL again replaces
+   its events.
+
+   The cluster doesn't post events itself: 'ltc notify watch' follows the
+   same events as 'ltc events' and posts each one, as JSON, to the webhooks
+   that want it, until interrupted.  Run it wherever it can stay up.`,
+		Action: factory.notify,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "url, u",
+				Usage: "The webhook to post events to",
+			},
+			cli.StringFlag{
+				Name:  "events, e",
+				Usage: "Comma-separated events to post, e.g. crash,scale",
+			},
+		},
+	}
+}
+
+func (factory *NotifyCommandFactory) notify(context *cli.Context) {
+	args := context.Args()
+	switch {
+	case args.First() == "add" && len(args) == 1:
+		factory.addWebhook(context.String("url"), context.String("events"))
+	case args.First() == "list" && len(args) == 1:
+		factory.listWebhooks()
+	case args.First() == "remove" && len(args) == 2:
+		factory.removeWebhook(args.Get(1))
+	case args.First() == "watch" && len(args) == 1:
+		factory.watch()
+	default:
+		factory.ui.SayIncorrectUsage(notifyUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *NotifyCommandFactory) addWebhook(webhookURL, eventsFlag string) {
+	if parsedURL, err := url.Parse(webhookURL); err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		factory.ui.SayIncorrectUsage("--url must be an http or https URL")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var events []string
+	for _, event := range strings.Split(eventsFlag, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if _, ok := notifyEvents[event]; !ok {
+			factory.ui.SayIncorrectUsage(fmt.Sprintf("Unknown event %s: must be one of %s", event, strings.Join(notifyEventNames(), ", ")))
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		events = append(events, event)
+	}
+
+	factory.webhooks.Set(webhookURL, events)
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Posting %s to %s", describeEvents(events), webhookURL))
+}
+
+func (factory *NotifyCommandFactory) listWebhooks() {
+	urls := factory.webhooks.URLs()
+	if len(urls) == 0 {
+		factory.ui.SayLine("No webhooks.")
+		return
+	}
+
+	for _, webhookURL := range urls {
+		events, _ := factory.webhooks.Get(webhookURL)
+		factory.ui.SayLine(fmt.Sprintf("%s\t%s", webhookURL, describeEvents(events)))
+	}
+}
+
+func (factory *NotifyCommandFactory) removeWebhook(webhookURL string) {
+	if !factory.webhooks.Remove(webhookURL) {
+		factory.ui.SayLine(fmt.Sprintf("No webhook for %s.", webhookURL))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Removed webhook %s.", webhookURL))
+}
+
+func (factory *NotifyCommandFactory) watch() {
+	urls := factory.webhooks.URLs()
+	if len(urls) == 0 {
+		factory.ui.SayLine("No webhooks to post to. Please add one with 'ltc notify add --url URL'.")
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	eventSource, err := factory.appEventSubscriber.Subscribe()
+	if err != nil {
+		factory.ui.SayLine("Error subscribing to events: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	factory.exitHandler.OnExit(func() {
+		eventSource.Close()
+	})
+
+	factory.ui.SayLine(fmt.Sprintf("Posting events to %d webhook(s). Press Ctrl-C to stop.", len(urls)))
+	for {
+		event, err := eventSource.Next()
+		if err == receptor.ErrSourceClosed {
+			return
+		} else if err != nil {
+			factory.ui.SayLine("Error reading events: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+
+		factory.post(urls, event)
+	}
+}
+
+// post sends the event to each webhook that wants it.  A webhook that can't
+// be reached is reported and skipped, so that it doesn't stop the others
+// from hearing about later events.
+func (factory *NotifyCommandFactory) post(urls []string, event app_events.AppEvent) {
+	source := event.AppName
+	if event.Index != nil {
+		source = fmt.Sprintf("%s|%d", event.AppName, *event.Index)
+	}
+	payload := webhookPayload{
+		AppEvent: event,
+		Text:     fmt.Sprintf("[%s] %s", source, event.Message),
+	}
+
+	for _, webhookURL := range urls {
+		events, _ := factory.webhooks.Get(webhookURL)
+		if !wantsEvent(events, event.Type) {
+			continue
+		}
+
+		if err := factory.notifier.NotifyWebhook(webhookURL, payload); err != nil {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Error posting %s for %s: %s", event.Type, source, err.Error())))
+			continue
+		}
+		factory.ui.SayLine(fmt.Sprintf("Posted %s for %s to %s", event.Type, source, webhookURL))
+	}
+}
+
+func (factory *NotifyCommandFactory) save() bool {
+	if err := factory.webhooks.Save(); err != nil {
+		factory.ui.SayLine("Error saving webhooks: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return false
+	}
+	return true
+}
+
+func wantsEvent(events []string, eventType app_events.EventType) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, event := range events {
+		if notifyEvents[event] == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func describeEvents(events []string) string {
+	if len(events) == 0 {
+		return "all events"
+	}
+	return strings.Join(events, ",")
+}
+
+func notifyEventNames() []string {
+	names := make([]string, 0, len(notifyEvents))
+	for name := range notifyEvents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,238 @@
+package command_factory_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events/fake_app_events"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier/fake_notifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("NotifyCommandFactory", func() {
+	var (
+		fakeSubscriber  *fake_app_events.FakeAppEventSubscriber
+		fakeEventSource *fake_app_events.FakeAppEventSource
+		fakeNotifier    *fake_notifier.FakeNotifier
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		memPersister    persister.Persister
+		webhooks        *config.Webhooks
+		notifyCommand   cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeSubscriber = &fake_app_events.FakeAppEventSubscriber{}
+		fakeEventSource = &fake_app_events.FakeAppEventSource{}
+		fakeNotifier = &fake_notifier.FakeNotifier{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		memPersister = persister.NewMemPersister()
+		webhooks = config.NewWebhooks(memPersister)
+
+		commandFactory := command_factory.NewNotifyCommandFactory(fakeSubscriber, webhooks, fakeNotifier, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+		notifyCommand = commandFactory.MakeNotifyCommand()
+	})
+
+	Describe("notify add", func() {
+		It("saves the webhook with its events", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"add", "--url", "https://hooks.example.com/ltc", "--events", "crash,scale"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Posting crash,scale to https://hooks.example.com/ltc"))
+
+			reloadedWebhooks := config.NewWebhooks(memPersister)
+			Expect(reloadedWebhooks.Load()).To(Succeed())
+			events, ok := reloadedWebhooks.Get("https://hooks.example.com/ltc")
+			Expect(ok).To(BeTrue())
+			Expect(events).To(Equal([]string{"crash", "scale"}))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("posts all events without --events", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"add", "--url", "https://hooks.example.com/ltc"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Posting all events to https://hooks.example.com/ltc"))
+			events, ok := webhooks.Get("https://hooks.example.com/ltc")
+			Expect(ok).To(BeTrue())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("rejects unknown events", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"add", "--url", "https://hooks.example.com/ltc", "--events", "crash,explode"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Unknown event explode: must be one of crash, create, placement, remove, routes, scale, start, stop"))
+			Expect(webhooks.URLs()).To(BeEmpty())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("requires an http or https URL", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"add", "--url", "hooks.example.com"})
+
+			Expect(outputBuffer).To(test_helpers.Say("--url must be an http or https URL"))
+			Expect(webhooks.URLs()).To(BeEmpty())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("notify list", func() {
+		It("lists the webhooks and their events", func() {
+			webhooks.Set("https://pager.example.com", []string{"crash"})
+			webhooks.Set("https://hooks.example.com/ltc", nil)
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"list"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("https://hooks.example.com/ltc\tall events"))
+			Expect(outputBuffer).To(test_helpers.SayLine("https://pager.example.com\tcrash"))
+		})
+
+		It("says when there are no webhooks", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"list"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No webhooks."))
+		})
+	})
+
+	Describe("notify remove", func() {
+		It("removes the webhook", func() {
+			webhooks.Set("https://hooks.example.com/ltc", nil)
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"remove", "https://hooks.example.com/ltc"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed webhook https://hooks.example.com/ltc."))
+			reloadedWebhooks := config.NewWebhooks(memPersister)
+			Expect(reloadedWebhooks.Load()).To(Succeed())
+			Expect(reloadedWebhooks.URLs()).To(BeEmpty())
+		})
+
+		It("exits when there is no such webhook", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"remove", "https://hooks.example.com/ltc"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No webhook for https://hooks.example.com/ltc."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+	})
+
+	Describe("notify watch", func() {
+		var events []app_events.AppEvent
+
+		BeforeEach(func() {
+			timestamp := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+			index := 2
+			events = []app_events.AppEvent{
+				{Type: app_events.AppScaled, AppName: "other-app", Message: "Scaled from 1 to 3 instances", Timestamp: timestamp},
+				{Type: app_events.InstanceCrashed, AppName: "cool-app", Index: &index, Message: "Instance crashed (crash count: 1)", Timestamp: timestamp},
+			}
+			fakeEventSource.NextStub = func() (app_events.AppEvent, error) {
+				if len(events) == 0 {
+					return app_events.AppEvent{}, receptor.ErrSourceClosed
+				}
+				event := events[0]
+				events = events[1:]
+				return event, nil
+			}
+			fakeSubscriber.SubscribeReturns(fakeEventSource, nil)
+
+			webhooks.Set("https://hooks.example.com/ltc", nil)
+			webhooks.Set("https://pager.example.com", []string{"crash"})
+		})
+
+		It("posts each event to the webhooks that want it", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Posting events to 2 webhook(s). Press Ctrl-C to stop."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Posted app_scaled for other-app to https://hooks.example.com/ltc"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Posted instance_crashed for cool-app|2 to https://hooks.example.com/ltc"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Posted instance_crashed for cool-app|2 to https://pager.example.com"))
+
+			Expect(fakeNotifier.NotifyWebhookCallCount()).To(Equal(3))
+			webhookURL, payload := fakeNotifier.NotifyWebhookArgsForCall(2)
+			Expect(webhookURL).To(Equal("https://pager.example.com"))
+			payloadJSON, err := json.Marshal(payload)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(payloadJSON).To(MatchJSON(`{
+				"type": "instance_crashed",
+				"app_name": "cool-app",
+				"index": 2,
+				"message": "Instance crashed (crash count: 1)",
+				"timestamp": "2015-06-01T12:30:00Z",
+				"text": "[cool-app|2] Instance crashed (crash count: 1)"
+			}`))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("keeps posting when a webhook fails", func() {
+			fakeNotifier.NotifyWebhookStub = func(url string, payload interface{}) error {
+				if url == "https://hooks.example.com/ltc" {
+					return errors.New("https://hooks.example.com/ltc returned 502 Bad Gateway")
+				}
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error posting app_scaled for other-app: https://hooks.example.com/ltc returned 502 Bad Gateway"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Posted instance_crashed for cool-app|2 to https://pager.example.com"))
+			Expect(fakeNotifier.NotifyWebhookCallCount()).To(Equal(3))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("closes the event source on exit", func() {
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			fakeExitHandler.Exit(exit_codes.SigInt)
+
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+		})
+
+		It("exits when there are no webhooks", func() {
+			webhooks.Remove("https://hooks.example.com/ltc")
+			webhooks.Remove("https://pager.example.com")
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No webhooks to post to. Please add one with 'ltc notify add --url URL'."))
+			Expect(fakeSubscriber.SubscribeCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits when subscribing fails", func() {
+			fakeSubscriber.SubscribeReturns(nil, errors.New("receptor unavailable"))
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error subscribing to events: receptor unavailable"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits when the event stream fails", func() {
+			fakeEventSource.NextStub = nil
+			fakeEventSource.NextReturns(app_events.AppEvent{}, errors.New("stream broke"))
+
+			test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"watch"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error reading events: stream broke"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	It("prints usage for unknown subcommands", func() {
+		test_helpers.ExecuteCommandWithArgs(notifyCommand, []string{"send"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Please enter 'ltc notify add --url URL [--events EVENTS]'"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+})
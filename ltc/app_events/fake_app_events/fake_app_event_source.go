@@ -0,0 +1,75 @@
+// This file was generated by counterfeiter
+package fake_app_events
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+)
+
+type FakeAppEventSource struct {
+	NextStub        func() (app_events.AppEvent, error)
+	nextMutex       sync.RWMutex
+	nextArgsForCall []struct{}
+	nextReturns     struct {
+		result1 app_events.AppEvent
+		result2 error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct{}
+	closeReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeAppEventSource) Next() (app_events.AppEvent, error) {
+	fake.nextMutex.Lock()
+	fake.nextArgsForCall = append(fake.nextArgsForCall, struct{}{})
+	fake.nextMutex.Unlock()
+	if fake.NextStub != nil {
+		return fake.NextStub()
+	} else {
+		return fake.nextReturns.result1, fake.nextReturns.result2
+	}
+}
+
+func (fake *FakeAppEventSource) NextCallCount() int {
+	fake.nextMutex.RLock()
+	defer fake.nextMutex.RUnlock()
+	return len(fake.nextArgsForCall)
+}
+
+func (fake *FakeAppEventSource) NextReturns(result1 app_events.AppEvent, result2 error) {
+	fake.NextStub = nil
+	fake.nextReturns = struct {
+		result1 app_events.AppEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAppEventSource) Close() error {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		return fake.CloseStub()
+	} else {
+		return fake.closeReturns.result1
+	}
+}
+
+func (fake *FakeAppEventSource) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeAppEventSource) CloseReturns(result1 error) {
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ app_events.AppEventSource = new(FakeAppEventSource)
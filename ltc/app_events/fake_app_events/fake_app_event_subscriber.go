@@ -0,0 +1,45 @@
+// This file was generated by counterfeiter
+package fake_app_events
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
+)
+
+type FakeAppEventSubscriber struct {
+	SubscribeStub        func() (app_events.AppEventSource, error)
+	subscribeMutex       sync.RWMutex
+	subscribeArgsForCall []struct{}
+	subscribeReturns     struct {
+		result1 app_events.AppEventSource
+		result2 error
+	}
+}
+
+func (fake *FakeAppEventSubscriber) Subscribe() (app_events.AppEventSource, error) {
+	fake.subscribeMutex.Lock()
+	fake.subscribeArgsForCall = append(fake.subscribeArgsForCall, struct{}{})
+	fake.subscribeMutex.Unlock()
+	if fake.SubscribeStub != nil {
+		return fake.SubscribeStub()
+	} else {
+		return fake.subscribeReturns.result1, fake.subscribeReturns.result2
+	}
+}
+
+func (fake *FakeAppEventSubscriber) SubscribeCallCount() int {
+	fake.subscribeMutex.RLock()
+	defer fake.subscribeMutex.RUnlock()
+	return len(fake.subscribeArgsForCall)
+}
+
+func (fake *FakeAppEventSubscriber) SubscribeReturns(result1 app_events.AppEventSource, result2 error) {
+	fake.SubscribeStub = nil
+	fake.subscribeReturns = struct {
+		result1 app_events.AppEventSource
+		result2 error
+	}{result1, result2}
+}
+
+var _ app_events.AppEventSubscriber = new(FakeAppEventSubscriber)
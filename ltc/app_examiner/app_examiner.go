@@ -2,8 +2,15 @@ package app_examiner
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/lrp_annotation"
 	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
 )
@@ -25,13 +32,48 @@ type AppInfo struct {
 	MemoryMB               int
 	CPUWeight              uint
 	Ports                  []uint16
+	UDPPorts               []uint16
 	Routes                 route_helpers.AppRoutes
 	LogGuid                string
 	LogSource              string
 	Annotation             string
+	Labels                 map[string]string
+	LogDrains              []string
+	Deployment             Deployment
 	ActualInstances        []InstanceInfo
 }
 
+// Deployment is when the app was created and last updated, and by whom.
+// Times are zero when ltc didn't record them.
+type Deployment struct {
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+type Health string
+
+const (
+	Healthy   Health = "healthy"
+	Degraded  Health = "degraded"
+	Unhealthy Health = "unhealthy"
+)
+
+// Health reduces the app's state to whether all of its desired instances
+// are running, some of them are, or none are.  An app scaled to zero
+// instances is healthy.
+func (a AppInfo) Health() Health {
+	switch {
+	case a.ActualRunningInstances >= a.DesiredInstances:
+		return Healthy
+	case a.ActualRunningInstances > 0:
+		return Degraded
+	default:
+		return Unhealthy
+	}
+}
+
 type PortMapping struct {
 	HostPort      uint16
 	ContainerPort uint16
@@ -40,17 +82,61 @@ type PortMapping struct {
 type InstanceInfo struct {
 	InstanceGuid   string
 	CellID         string
+	Zone           string
 	Index          int
 	Ip             string
 	Ports          []PortMapping
 	State          string
 	Since          int64
 	PlacementError string
+	Evacuating     bool
 	CrashCount     int
+	CrashReason    string
+	HasExitCode    bool
+	ExitCode       int
+	LastCrash      int64
 	HasMetrics     bool
 	Metrics        InstanceMetrics
 }
 
+// InstanceCounts is what ltc needs to know about an app's instances while
+// waiting for them to start or stop.  Starting counts the instances lattice
+// has yet to place or that are still coming up.
+type InstanceCounts struct {
+	Running        int
+	Starting       int
+	Crashed        int
+	PlacementError bool
+}
+
+// NotRunning describes the instances that aren't running, e.g.
+// "2 starting, 1 crashed", or returns "" if there are none.
+func (counts InstanceCounts) NotRunning() string {
+	var parts []string
+	if counts.Starting > 0 {
+		parts = append(parts, fmt.Sprintf("%d starting", counts.Starting))
+	}
+	if counts.Crashed > 0 {
+		parts = append(parts, fmt.Sprintf("%d crashed", counts.Crashed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (counts InstanceCounts) add(actualLRP receptor.ActualLRPResponse) InstanceCounts {
+	switch actualLRP.State {
+	case receptor.ActualLRPStateRunning:
+		counts.Running++
+	case receptor.ActualLRPStateUnclaimed, receptor.ActualLRPStateClaimed:
+		counts.Starting++
+	case receptor.ActualLRPStateCrashed:
+		counts.Crashed++
+	}
+	if actualLRP.PlacementError != "" {
+		counts.PlacementError = true
+	}
+	return counts
+}
+
 type InstanceMetrics struct {
 	CpuPercentage float64
 	MemoryBytes   uint64
@@ -89,6 +175,8 @@ type AppExaminer interface {
 	AppStatus(appName string) (AppInfo, error)
 	AppExists(name string) (bool, error)
 	RunningAppInstancesInfo(name string) (int, bool, error)
+	AppInstanceCounts(name string) (InstanceCounts, error)
+	InstanceCountsByApp() (map[string]InstanceCounts, error)
 }
 
 type appExaminer struct {
@@ -154,6 +242,7 @@ func (e *appExaminer) ListApps() ([]AppInfo, error) {
 	}
 
 	appMap := mergeDesiredActualLRPs(desiredLRPs, actualLRPs)
+	delete(appMap, reserved_app_ids.LatticeSecretsAppId)
 	return sortApps(appMap), nil
 }
 
@@ -180,6 +269,17 @@ func (e *appExaminer) AppStatus(appName string) (AppInfo, error) {
 		return AppInfo{}, errors.New(AppNotFoundErrorMessage)
 	}
 
+	if cellList, err := e.receptorClient.Cells(); err == nil {
+		zones := make(map[string]string, len(cellList))
+		for _, cell := range cellList {
+			zones[cell.CellID] = cell.Zone
+		}
+		for index := range appInfoPtr.ActualInstances {
+			instanceInfo := &appInfoPtr.ActualInstances[index]
+			instanceInfo.Zone = zones[instanceInfo.CellID]
+		}
+	}
+
 	containerMetrics, err := e.noaaConsumer.GetContainerMetrics(appName, "")
 	if err != nil {
 		return *appInfoPtr, nil
@@ -222,30 +322,46 @@ func (e *appExaminer) AppExists(name string) (bool, error) {
 }
 
 func (e *appExaminer) RunningAppInstancesInfo(name string) (count int, placementError bool, err error) {
-	runningInstances := 0
-	placementErrorOccurred := false
-	instances, err := e.receptorClient.ActualLRPsByProcessGuid(name)
+	counts, err := e.AppInstanceCounts(name)
+	return counts.Running, counts.PlacementError, err
+}
+
+// AppInstanceCounts counts the instances of the app in each state.
+func (e *appExaminer) AppInstanceCounts(name string) (InstanceCounts, error) {
+	actualLRPs, err := e.receptorClient.ActualLRPsByProcessGuid(name)
 	if err != nil {
-		return 0, false, err
+		return InstanceCounts{}, err
 	}
 
-	for _, instance := range instances {
-		if instance.State == receptor.ActualLRPStateRunning {
-			runningInstances += 1
-		}
+	counts := InstanceCounts{}
+	for _, actualLRP := range actualLRPs {
+		counts = counts.add(actualLRP)
+	}
+	return counts, nil
+}
 
-		if instance.PlacementError != "" {
-			placementErrorOccurred = true
-		}
+// InstanceCountsByApp counts the instances of every app from a single
+// request, so that waiting on many apps costs the same as waiting on one.
+// Apps with no instances are left out of the map.
+func (e *appExaminer) InstanceCountsByApp() (map[string]InstanceCounts, error) {
+	actualLRPs, err := e.receptorClient.ActualLRPs()
+	if err != nil {
+		return nil, err
+	}
+
+	instanceCounts := make(map[string]InstanceCounts)
+	for _, actualLRP := range actualLRPs {
+		instanceCounts[actualLRP.ProcessGuid] = instanceCounts[actualLRP.ProcessGuid].add(actualLRP)
 	}
 
-	return runningInstances, placementErrorOccurred, nil
+	return instanceCounts, nil
 }
 
 func mergeDesiredActualLRPs(desiredLRPs []receptor.DesiredLRPResponse, actualLRPs []receptor.ActualLRPResponse) map[string]*AppInfo {
 	appMap := make(map[string]*AppInfo)
 
 	for _, desiredLRP := range desiredLRPs {
+		annotation := lrp_annotation.ParseFields(desiredLRP.Annotation)
 		appMap[desiredLRP.ProcessGuid] = &AppInfo{
 			ProcessGuid:            desiredLRP.ProcessGuid,
 			DesiredInstances:       desiredLRP.Instances,
@@ -256,10 +372,14 @@ func mergeDesiredActualLRPs(desiredLRPs []receptor.DesiredLRPResponse, actualLRP
 			MemoryMB:               desiredLRP.MemoryMB,
 			CPUWeight:              desiredLRP.CPUWeight,
 			Ports:                  desiredLRP.Ports,
+			UDPPorts:               annotation.UDPPorts,
 			Routes:                 route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes),
 			LogGuid:                desiredLRP.LogGuid,
 			LogSource:              desiredLRP.LogSource,
 			Annotation:             desiredLRP.Annotation,
+			Labels:                 annotation.Labels,
+			LogDrains:              annotation.LogDrains,
+			Deployment:             deploymentFrom(annotation.Deployment),
 		}
 	}
 
@@ -288,9 +408,15 @@ func mergeDesiredActualLRPs(desiredLRPs []receptor.DesiredLRPResponse, actualLRP
 			State:          string(actualLRP.State),
 			Since:          actualLRP.Since,
 			PlacementError: actualLRP.PlacementError,
+			Evacuating:     actualLRP.Evacuating,
 			CrashCount:     actualLRP.CrashCount,
+			CrashReason:    actualLRP.CrashReason,
 			HasMetrics:     false,
 		}
+		instanceInfo.ExitCode, instanceInfo.HasExitCode = parseExitCode(actualLRP.CrashReason)
+		if actualLRP.State == receptor.ActualLRPStateCrashed {
+			instanceInfo.LastCrash = actualLRP.Since
+		}
 
 		appMap[actualLRP.ProcessGuid].ActualInstances = append(appMap[actualLRP.ProcessGuid].ActualInstances, instanceInfo)
 	}
@@ -302,6 +428,19 @@ func mergeDesiredActualLRPs(desiredLRPs []receptor.DesiredLRPResponse, actualLRP
 	return appMap
 }
 
+var exitStatusPattern = regexp.MustCompile(`(?i)exit(?:ed with)? status (\d+)`)
+
+// parseExitCode pulls the exit code out of a crash reason such as "Exited
+// with status 137".  Reasons like failed health checks have none.
+func parseExitCode(crashReason string) (int, bool) {
+	match := exitStatusPattern.FindStringSubmatch(crashReason)
+	if match == nil {
+		return 0, false
+	}
+	exitCode, err := strconv.Atoi(match[1])
+	return exitCode, err == nil
+}
+
 func buildEnvVars(desiredLRPResponse receptor.DesiredLRPResponse) []EnvironmentVariable {
 	envVars := make([]EnvironmentVariable, 0)
 	for _, envVar := range desiredLRPResponse.EnvironmentVariables {
@@ -310,6 +449,18 @@ func buildEnvVars(desiredLRPResponse receptor.DesiredLRPResponse) []EnvironmentV
 	return envVars
 }
 
+// deploymentFrom is when the app was deployed, as its annotation records it.
+func deploymentFrom(deployment lrp_annotation.Deployment) Deployment {
+	appDeployment := Deployment{CreatedBy: deployment.CreatedBy, UpdatedBy: deployment.UpdatedBy}
+	if deployment.CreatedAt != nil {
+		appDeployment.CreatedAt = *deployment.CreatedAt
+	}
+	if deployment.UpdatedAt != nil {
+		appDeployment.UpdatedAt = *deployment.UpdatedAt
+	}
+	return appDeployment
+}
+
 func sortApps(allApps map[string]*AppInfo) []AppInfo {
 	sortedKeys := sortAppKeys(allApps)
 
@@ -2,12 +2,14 @@ package app_examiner_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_noaa_consumer"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
@@ -104,6 +106,91 @@ var _ = Describe("AppExaminer", func() {
 			})
 		})
 
+		Context("when apps are labelled", func() {
+			It("reads the labels from their annotations", func() {
+				desiredLrps := []receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "labelled-app", Annotation: `{"udp_ports":[53],"labels":{"version":"1.2.3"}}`},
+					receptor.DesiredLRPResponse{ProcessGuid: "unlabelled-app", Annotation: "Not JSON at all."},
+				}
+				fakeReceptorClient.DesiredLRPsReturns(desiredLrps, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{}, nil)
+
+				appList, err := appExaminer.ListApps()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appList).To(HaveLen(2))
+				Expect(appList[0].Labels).To(Equal(map[string]string{"version": "1.2.3"}))
+				Expect(appList[1].Labels).To(BeEmpty())
+			})
+
+			It("reads the log drains from their annotations", func() {
+				desiredLrps := []receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "drained-app", Annotation: `{"log_drains":["syslog://logs.example.com:514"]}`},
+					receptor.DesiredLRPResponse{ProcessGuid: "undrained-app", Annotation: `{"labels":{"version":"1.2.3"}}`},
+				}
+				fakeReceptorClient.DesiredLRPsReturns(desiredLrps, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{}, nil)
+
+				appList, err := appExaminer.ListApps()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appList[0].LogDrains).To(Equal([]string{"syslog://logs.example.com:514"}))
+				Expect(appList[1].LogDrains).To(BeEmpty())
+			})
+
+			It("reads which ports are udp from their annotations", func() {
+				desiredLrps := []receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "dns-app", Ports: []uint16{53, 8080}, Annotation: `{"udp_ports":[53],"labels":{"version":"1.2.3"}}`},
+					receptor.DesiredLRPResponse{ProcessGuid: "other-app", Ports: []uint16{53}, Annotation: "Not JSON at all."},
+				}
+				fakeReceptorClient.DesiredLRPsReturns(desiredLrps, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{}, nil)
+
+				appList, err := appExaminer.ListApps()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appList[0].UDPPorts).To(Equal([]uint16{53}))
+				Expect(appList[1].UDPPorts).To(BeEmpty())
+			})
+
+			It("reads when they were deployed from their annotations", func() {
+				desiredLrps := []receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "deployed-app", Annotation: `{"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice","updated_at":"2015-07-04T12:00:00Z","updated_by":"bob"}}`},
+					receptor.DesiredLRPResponse{ProcessGuid: "other-app", Annotation: "Not JSON at all."},
+				}
+				fakeReceptorClient.DesiredLRPsReturns(desiredLrps, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{}, nil)
+
+				appList, err := appExaminer.ListApps()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appList[0].Deployment).To(Equal(app_examiner.Deployment{
+					CreatedAt: time.Date(2015, 7, 1, 9, 0, 0, 0, time.UTC),
+					CreatedBy: "alice",
+					UpdatedAt: time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC),
+					UpdatedBy: "bob",
+				}))
+				Expect(appList[1].Deployment).To(BeZero())
+			})
+		})
+
+		Context("when the secrets store LRP is desired", func() {
+			It("does not list it as an app", func() {
+				desiredLrps := []receptor.DesiredLRPResponse{
+					receptor.DesiredLRPResponse{ProcessGuid: "some-app", Instances: 1},
+					receptor.DesiredLRPResponse{ProcessGuid: reserved_app_ids.LatticeSecretsAppId, Instances: 0},
+				}
+				fakeReceptorClient.DesiredLRPsReturns(desiredLrps, nil)
+				fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{}, nil)
+
+				appList, err := appExaminer.ListApps()
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appList).To(HaveLen(1))
+				Expect(appList[0].ProcessGuid).To(Equal("some-app"))
+			})
+		})
+
 		Context("when the receptor returns errors", func() {
 			It("returns errors from from fetching the DesiredLRPs", func() {
 				fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("You should go catch it."))
@@ -310,8 +397,9 @@ var _ = Describe("AppExaminer", func() {
 						Ports: []receptor.PortMapping{
 							receptor.PortMapping{HostPort: 2786, ContainerPort: 2020},
 						},
-						State: "RUNNING",
-						Since: 2002,
+						State:      "RUNNING",
+						Since:      2002,
+						Evacuating: true,
 					}, receptor.ActualLRPResponse{
 						ProcessGuid:    "peekaboo-app",
 						Index:          2,
@@ -323,6 +411,7 @@ var _ = Describe("AppExaminer", func() {
 						Index:       3,
 						State:       "CRASHED",
 						CrashCount:  7,
+						CrashReason: "exit status 2",
 					},
 				}
 
@@ -376,6 +465,7 @@ var _ = Describe("AppExaminer", func() {
 							},
 							State:      "RUNNING",
 							Since:      2002,
+							Evacuating: true,
 							HasMetrics: true,
 							Metrics: app_examiner.InstanceMetrics{
 								CpuPercentage: 0.018138574,
@@ -407,11 +497,14 @@ var _ = Describe("AppExaminer", func() {
 							HasMetrics:     false,
 						},
 						app_examiner.InstanceInfo{
-							Index:      3,
-							State:      "CRASHED",
-							Ports:      []app_examiner.PortMapping{},
-							CrashCount: 7,
-							HasMetrics: false,
+							Index:       3,
+							State:       "CRASHED",
+							Ports:       []app_examiner.PortMapping{},
+							CrashCount:  7,
+							CrashReason: "exit status 2",
+							HasExitCode: true,
+							ExitCode:    2,
+							HasMetrics:  false,
 						},
 					},
 				}))
@@ -427,6 +520,54 @@ var _ = Describe("AppExaminer", func() {
 				Expect(token).To(BeEmpty())
 			})
 
+			It("fills in the zone of the cell each instance is placed on", func() {
+				fakeReceptorClient.GetDesiredLRPReturns(getDesiredLRPResponse, nil)
+				fakeReceptorClient.ActualLRPsByProcessGuidReturns(actualLRPsByProcessGuidResponse, nil)
+				fakeReceptorClient.CellsReturns([]receptor.CellResponse{
+					{CellID: "cell-2", Zone: "us-east-1a"},
+					{CellID: "cell-3", Zone: "us-east-1b"},
+				}, nil)
+
+				result, err := appExaminer.AppStatus("peekaboo-app")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.ActualInstances[0].Zone).To(Equal("us-east-1a"))
+				Expect(result.ActualInstances[1].Zone).To(Equal("us-east-1b"))
+				Expect(result.ActualInstances[2].Zone).To(BeEmpty())
+			})
+
+			It("records the exit code and time of each instance's last crash", func() {
+				fakeReceptorClient.GetDesiredLRPReturns(getDesiredLRPResponse, nil)
+				fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+					{ProcessGuid: "peekaboo-app", Index: 0, State: "CRASHED", CrashCount: 4, CrashReason: "Exited with status 137", Since: 3003},
+					{ProcessGuid: "peekaboo-app", Index: 1, State: "RUNNING", CrashCount: 1, CrashReason: "Exited with status 1", Since: 4004},
+					{ProcessGuid: "peekaboo-app", Index: 2, State: "CRASHED", CrashCount: 2, CrashReason: "Instance never healthy after 1m0s", Since: 5005},
+				}, nil)
+
+				result, err := appExaminer.AppStatus("peekaboo-app")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.ActualInstances[0].HasExitCode).To(BeTrue())
+				Expect(result.ActualInstances[0].ExitCode).To(Equal(137))
+				Expect(result.ActualInstances[0].LastCrash).To(Equal(int64(3003)))
+				Expect(result.ActualInstances[1].ExitCode).To(Equal(1))
+				Expect(result.ActualInstances[1].LastCrash).To(BeZero())
+				Expect(result.ActualInstances[2].HasExitCode).To(BeFalse())
+				Expect(result.ActualInstances[2].LastCrash).To(Equal(int64(5005)))
+			})
+
+			It("leaves the zones empty when the cells cannot be listed", func() {
+				fakeReceptorClient.GetDesiredLRPReturns(getDesiredLRPResponse, nil)
+				fakeReceptorClient.ActualLRPsByProcessGuidReturns(actualLRPsByProcessGuidResponse, nil)
+				fakeReceptorClient.CellsReturns(nil, errors.New("no cells for you"))
+
+				result, err := appExaminer.AppStatus("peekaboo-app")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.ActualInstances[0].CellID).To(Equal("cell-2"))
+				Expect(result.ActualInstances[0].Zone).To(BeEmpty())
+			})
+
 			Context("when desired LRP is not found, but there are actual LRPs for the process GUID (App stopping)", func() {
 				It("returns AppInfo that has ActualInstances, but is missing desiredlrp specific data", func() {
 					fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "Desired LRP with guid 'peekaboo-app' not found"})
@@ -453,6 +594,7 @@ var _ = Describe("AppExaminer", func() {
 								},
 								State:      "RUNNING",
 								Since:      2002,
+								Evacuating: true,
 								HasMetrics: true,
 								Metrics: app_examiner.InstanceMetrics{
 									CpuPercentage: 0.018138574,
@@ -484,11 +626,14 @@ var _ = Describe("AppExaminer", func() {
 								HasMetrics:     false,
 							},
 							app_examiner.InstanceInfo{
-								Index:      3,
-								State:      "CRASHED",
-								Ports:      []app_examiner.PortMapping{},
-								CrashCount: 7,
-								HasMetrics: false,
+								Index:       3,
+								State:       "CRASHED",
+								Ports:       []app_examiner.PortMapping{},
+								CrashCount:  7,
+								CrashReason: "exit status 2",
+								HasExitCode: true,
+								ExitCode:    2,
+								HasMetrics:  false,
 							},
 						},
 					}))
@@ -637,6 +782,83 @@ var _ = Describe("AppExaminer", func() {
 		})
 	})
 
+	Describe("AppInstanceCounts", func() {
+		It("counts the app's instances in each state", func() {
+			fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateRunning, Index: 0},
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateClaimed, Index: 1},
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateUnclaimed, Index: 2},
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateCrashed, Index: 3},
+			}, nil)
+
+			counts, err := appExaminer.AppInstanceCounts("americano-app")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counts).To(Equal(app_examiner.InstanceCounts{Running: 1, Starting: 2, Crashed: 1}))
+			Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(0)).To(Equal("americano-app"))
+		})
+
+		It("returns errors from the receptor", func() {
+			fakeReceptorClient.ActualLRPsByProcessGuidReturns(nil, errors.New("receptor did not like that request"))
+
+			_, err := appExaminer.AppInstanceCounts("americano-app")
+
+			Expect(err).To(MatchError("receptor did not like that request"))
+		})
+	})
+
+	Describe("InstanceCounts", func() {
+		It("describes the instances that aren't running", func() {
+			Expect(app_examiner.InstanceCounts{Running: 3, Starting: 2, Crashed: 1}.NotRunning()).To(Equal("2 starting, 1 crashed"))
+			Expect(app_examiner.InstanceCounts{Crashed: 1}.NotRunning()).To(Equal("1 crashed"))
+			Expect(app_examiner.InstanceCounts{Running: 3}.NotRunning()).To(BeEmpty())
+		})
+	})
+
+	Describe("Health", func() {
+		It("is healthy when all the desired instances are running", func() {
+			Expect(app_examiner.AppInfo{DesiredInstances: 3, ActualRunningInstances: 3}.Health()).To(Equal(app_examiner.Healthy))
+			Expect(app_examiner.AppInfo{DesiredInstances: 2, ActualRunningInstances: 3}.Health()).To(Equal(app_examiner.Healthy))
+			Expect(app_examiner.AppInfo{}.Health()).To(Equal(app_examiner.Healthy))
+		})
+
+		It("is degraded when only some are running", func() {
+			Expect(app_examiner.AppInfo{DesiredInstances: 3, ActualRunningInstances: 1}.Health()).To(Equal(app_examiner.Degraded))
+		})
+
+		It("is unhealthy when none are running", func() {
+			Expect(app_examiner.AppInfo{DesiredInstances: 3}.Health()).To(Equal(app_examiner.Unhealthy))
+		})
+	})
+
+	Describe("InstanceCountsByApp", func() {
+		It("counts the running instances of every app from one request", func() {
+			fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateRunning, Index: 0},
+				{ProcessGuid: "americano-app", State: receptor.ActualLRPStateClaimed, Index: 1},
+				{ProcessGuid: "latte-app", State: receptor.ActualLRPStateRunning, Index: 0},
+				{ProcessGuid: "latte-app", State: receptor.ActualLRPStateUnclaimed, Index: 1, PlacementError: "could not place!"},
+			}, nil)
+
+			instanceCounts, err := appExaminer.InstanceCountsByApp()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instanceCounts).To(Equal(map[string]app_examiner.InstanceCounts{
+				"americano-app": {Running: 1, Starting: 1},
+				"latte-app":     {Running: 1, Starting: 1, PlacementError: true},
+			}))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(1))
+		})
+
+		It("returns errors from the receptor", func() {
+			fakeReceptorClient.ActualLRPsReturns(nil, errors.New("receptor did not like that request"))
+
+			_, err := appExaminer.InstanceCountsByApp()
+
+			Expect(err).To(MatchError("receptor did not like that request"))
+		})
+	})
+
 	Describe("AppExists", func() {
 		It("returns true if the docker app exists", func() {
 			actualLRPs := []receptor.ActualLRPResponse{receptor.ActualLRPResponse{ProcessGuid: "americano-app"}}
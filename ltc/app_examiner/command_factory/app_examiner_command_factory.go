@@ -1,6 +1,7 @@
 package command_factory
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
@@ -12,8 +13,11 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory/graphical"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory/presentation"
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
@@ -38,6 +42,23 @@ func (p UInt16Slice) Len() int           { return len(p) }
 func (p UInt16Slice) Less(i, j int) bool { return p[i] < p[j] }
 func (p UInt16Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+type routeMapping struct {
+	hostname string
+	appName  string
+	port     uint16
+}
+
+type routeMappings []routeMapping
+
+func (r routeMappings) Len() int      { return len(r) }
+func (r routeMappings) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r routeMappings) Less(i, j int) bool {
+	if r[i].hostname != r[j].hostname {
+		return r[i].hostname < r[j].hostname
+	}
+	return r[i].port < r[j].port
+}
+
 type AppExaminerCommandFactory struct {
 	appExaminer         app_examiner.AppExaminer
 	ui                  terminal.UI
@@ -45,21 +66,35 @@ type AppExaminerCommandFactory struct {
 	exitHandler         exit_handler.ExitHandler
 	graphicalVisualizer graphical.GraphicalVisualizer
 	taskExaminer        task_examiner.TaskExaminer
+	clusterExaminer     cluster_examiner.ClusterExaminer
+	cache               caching_receptor_client.Cache
 }
 
-func NewAppExaminerCommandFactory(appExaminer app_examiner.AppExaminer, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler, graphicalVisualizer graphical.GraphicalVisualizer, taskExaminer task_examiner.TaskExaminer) *AppExaminerCommandFactory {
-	return &AppExaminerCommandFactory{appExaminer, ui, clock, exitHandler, graphicalVisualizer, taskExaminer}
+func NewAppExaminerCommandFactory(appExaminer app_examiner.AppExaminer, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler, graphicalVisualizer graphical.GraphicalVisualizer, taskExaminer task_examiner.TaskExaminer, clusterExaminer cluster_examiner.ClusterExaminer, cache caching_receptor_client.Cache) *AppExaminerCommandFactory {
+	return &AppExaminerCommandFactory{appExaminer, ui, clock, exitHandler, graphicalVisualizer, taskExaminer, clusterExaminer, cache}
 }
 
 func (factory *AppExaminerCommandFactory) MakeListAppCommand() cli.Command {
 
 	var listCommand = cli.Command{
-		Name:        "list",
-		Aliases:     []string{"li", "ls"},
-		Usage:       "Lists applications & tasks running on lattice",
-		Description: "ltc list",
-		Action:      factory.listApps,
-		Flags:       []cli.Flag{},
+		Name:    "list",
+		Aliases: []string{"li", "ls"},
+		Usage:   "Lists applications & tasks running on lattice",
+		Description: `ltc list [--crashed-only] [--selector KEY=VALUE[,KEY=VALUE...]]
+
+   Apps whose instances have crashed show the exit code or reason of the
+   most recent crash.`,
+		Action: factory.listApps,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "crashed-only",
+				Usage: "Only lists apps with crashed instances, and no tasks",
+			},
+			cli.StringFlag{
+				Name:  "selector, l",
+				Usage: "Only lists apps whose labels match KEY=VALUE[,KEY=VALUE...], and no tasks",
+			},
+		},
 	}
 
 	return listCommand
@@ -76,15 +111,24 @@ func (factory *AppExaminerCommandFactory) MakeVisualizeCommand() cli.Command {
 			Name:  "graphical, g",
 			Usage: "Visualize in a graphical screen",
 		},
+		cli.BoolFlag{
+			Name:  "apps, a",
+			Usage: "Shows a grid of the instances of each app on each cell, and the capacity each cell has free",
+		},
 	}
 
 	var visualizeCommand = cli.Command{
-		Name:        "visualize",
-		Aliases:     []string{"vz"},
-		Usage:       "Shows a visualization of the workload distribution across the lattice cells",
-		Description: "ltc visualize [-r=DELAY] [-g]",
-		Action:      factory.visualizeCells,
-		Flags:       visualizeFlags,
+		Name:    "visualize",
+		Aliases: []string{"vz"},
+		Usage:   "Shows a visualization of the workload distribution across the lattice cells",
+		Description: `ltc visualize [-r=DELAY] [-g] [-a]
+
+   Passing --apps draws a grid with a row for each cell and a column for
+   each app placed on lattice, counting the app's instances on the cell.
+   The last columns show the memory, disk and containers the cell has left
+   once its instances have made their reservations.`,
+		Action: factory.visualizeCells,
+		Flags:  visualizeFlags,
 	}
 
 	return visualizeCommand
@@ -100,15 +144,27 @@ func (factory *AppExaminerCommandFactory) MakeStatusCommand() cli.Command {
 			Name:  "rate, r",
 			Usage: "Status refresh rate (e.g., \".5s\" or \"10ms\")",
 		},
+		cli.BoolFlag{
+			Name:  "health",
+			Usage: "Prints whether the app is healthy and exits with its health",
+		},
 	}
 
 	return cli.Command{
-		Name:        "status",
-		Aliases:     []string{"st"},
-		Usage:       "Shows details about a running app on lattice",
-		Description: "ltc status APP_NAME",
-		Action:      factory.appStatus,
-		Flags:       statusFlags,
+		Name:    "status",
+		Aliases: []string{"st"},
+		Usage:   "Shows details about a running app on lattice",
+		Description: `ltc status APP_NAME
+   ltc status APP_NAME/INDEX
+   ltc status APP_NAME --health
+
+   Passing APP_NAME/INDEX shows only the instance at INDEX.
+
+   With --health, prints only whether the app is healthy (all its instances
+   are running), degraded (some are) or unhealthy (none are), and exits with
+   0, 20 or 21 respectively, for use as a monitoring check.`,
+		Action: factory.appStatus,
+		Flags:  statusFlags,
 	}
 }
 
@@ -116,53 +172,184 @@ func (factory *AppExaminerCommandFactory) MakeCellsCommand() cli.Command {
 	return cli.Command{
 		Name:    "cells",
 		Aliases: []string{"ce"},
-		Usage:   "Shows details about lattice cells",
-		Description: `ltc cells APP_NAME
- 
-    Output format is:
+		Usage:   "Shows the health and capacity of lattice cells",
+		Description: `ltc cells [--json]
+
+   Output format is:
 
-    Cell	Zone	Memory	Apps (running/claimed)`,
+   Cell	Zone	Memory (free/total)	Disk (free/total)	Containers (used/total)	Apps (running/claimed)	Heartbeat
+
+   Free memory and disk are what is left once the instances on the cell
+   have made their reservations.  The heartbeat is missing for cells that
+   have stopped reporting to lattice but still have instances placed on them.`,
 
 		Action: factory.cells,
-		Flags:  []cli.Flag{},
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "json, j",
+				Usage: "Prints the cells as JSON",
+			},
+		},
+	}
+}
+
+func (factory *AppExaminerCommandFactory) MakeRoutesCommand() cli.Command {
+	return cli.Command{
+		Name:        "routes",
+		Aliases:     []string{"ro"},
+		Usage:       "Lists the routes of every app on lattice",
+		Description: "ltc routes",
+		Action:      factory.listRoutes,
+		Flags:       []cli.Flag{},
 	}
 }
 
+type cellJSON struct {
+	CellID           string `json:"cell_id"`
+	Zone             string `json:"zone"`
+	MemoryMB         int    `json:"memory_mb"`
+	FreeMemoryMB     int    `json:"free_memory_mb"`
+	DiskMB           int    `json:"disk_mb"`
+	FreeDiskMB       int    `json:"free_disk_mb"`
+	Containers       int    `json:"containers"`
+	UsedContainers   int    `json:"used_containers"`
+	RunningInstances int    `json:"running_instances"`
+	ClaimedInstances int    `json:"claimed_instances"`
+	Heartbeat        string `json:"heartbeat"`
+}
+
 func (factory *AppExaminerCommandFactory) cells(context *cli.Context) {
-	cellList, err := factory.appExaminer.ListCells()
+	usage, err := factory.clusterExaminer.ClusterUsage()
 	if err != nil {
-		factory.ui.Say(err.Error())
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if context.Bool("json") {
+		cells := make([]cellJSON, 0, len(usage.Cells))
+		for _, cell := range usage.Cells {
+			cells = append(cells, cellJSON{
+				CellID:           cell.CellID,
+				Zone:             cell.Zone,
+				MemoryMB:         cell.MemoryMB,
+				FreeMemoryMB:     cell.FreeMemoryMB(),
+				DiskMB:           cell.DiskMB,
+				FreeDiskMB:       cell.FreeDiskMB(),
+				Containers:       cell.Containers,
+				UsedContainers:   cell.Instances,
+				RunningInstances: cell.Instances - cell.ClaimedInstances,
+				ClaimedInstances: cell.ClaimedInstances,
+				Heartbeat:        cellHeartbeat(cell),
+			})
+		}
+
+		cellsJson, err := json.MarshalIndent(cells, "", "  ")
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error encoding cells: %s", err))
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+		factory.ui.SayLine(string(cellsJson))
 		return
 	}
 
 	w := &tabwriter.Writer{}
 	w.Init(factory.ui, 9, 8, 1, '\t', 0)
 
-	fmt.Fprintln(w, "Cells\tZone\tMemory\tDisk\tApps")
+	fmt.Fprintln(w, "Cells\tZone\tMemory\tDisk\tContainers\tApps\tHeartbeat")
 
-	for _, cellInfo := range cellList {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			cellInfo.CellID,
-			cellInfo.Zone,
-			fmt.Sprintf("%dM", cellInfo.MemoryMB),
-			fmt.Sprintf("%dM", cellInfo.DiskMB),
-			fmt.Sprintf("%d/%d", cellInfo.RunningInstances, cellInfo.ClaimedInstances),
+	for _, cell := range usage.Cells {
+		heartbeat := cellHeartbeat(cell)
+		if cell.Missing {
+			heartbeat = colors.Red(heartbeat)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			cell.CellID,
+			cell.Zone,
+			fmt.Sprintf("%dM/%dM", cell.FreeMemoryMB(), cell.MemoryMB),
+			fmt.Sprintf("%dM/%dM", cell.FreeDiskMB(), cell.DiskMB),
+			fmt.Sprintf("%d/%d", cell.Instances, cell.Containers),
+			fmt.Sprintf("%d/%d", cell.Instances-cell.ClaimedInstances, cell.ClaimedInstances),
+			heartbeat,
 		)
 	}
 
 	w.Flush()
 }
 
+// cellHeartbeat reports whether the cell is still heartbeating its presence
+// to lattice.  The receptor lists cells without the time of their last
+// heartbeat, so missing cells can't say how long they have been gone.
+func cellHeartbeat(cell cluster_examiner.CellUsage) string {
+	if cell.Missing {
+		return "missing"
+	}
+	return "ok"
+}
+
+func (factory *AppExaminerCommandFactory) listRoutes(context *cli.Context) {
+	appList, err := factory.appExaminer.ListApps()
+	if err != nil {
+		factory.ui.SayLine("Error listing routes: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	var routes routeMappings
+	for _, appInfo := range appList {
+		for _, appRoute := range appInfo.Routes {
+			for _, hostname := range appRoute.Hostnames {
+				routes = append(routes, routeMapping{hostname, appInfo.ProcessGuid, appRoute.Port})
+			}
+		}
+	}
+
+	if len(routes) == 0 {
+		factory.ui.SayLine("No routes to display.")
+		return
+	}
+	sort.Sort(routes)
+
+	w := &tabwriter.Writer{}
+	w.Init(factory.ui, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\n", colors.Bold("Route"), colors.Bold("App Name"), colors.Bold("Port"))
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", colors.Cyan(route.hostname), colors.Bold(route.appName), colors.NoColor(strconv.Itoa(int(route.port))))
+	}
+
+	w.Flush()
+}
+
 func (factory *AppExaminerCommandFactory) listApps(context *cli.Context) {
+	crashedOnlyFlag := context.Bool("crashed-only")
+	selectorFlag := context.String("selector")
+
+	var selector app_examiner.Selector
+	if selectorFlag != "" {
+		var err error
+		if selector, err = app_examiner.ParseSelector(selectorFlag); err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+
 	appList, err := factory.appExaminer.ListApps()
+	if err == nil && crashedOnlyFlag {
+		appList = crashedApps(appList)
+	}
+	if err == nil && selector != nil {
+		appList = selectedApps(appList, selector)
+	}
 	if err == nil {
 		w := &tabwriter.Writer{}
 		w.Init(factory.ui, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
 		appTableHeader := strings.Repeat("-", 30) + "= Apps =" + strings.Repeat("-", 31)
 		fmt.Fprintln(w, appTableHeader)
 		if len(appList) != 0 {
-			header := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", colors.Bold("App Name"), colors.Bold("Instances"), colors.Bold("DiskMB"), colors.Bold("MemoryMB"), colors.Bold("Route"))
+			header := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", colors.Bold("App Name"), colors.Bold("Instances"), colors.Bold("DiskMB"), colors.Bold("MemoryMB"), colors.Bold("Route"), colors.Bold("Group"), colors.Bold("Labels"), colors.Bold("Last Crash"))
 			fmt.Fprintln(w, header)
 
 			for _, appInfo := range appList {
@@ -172,16 +359,33 @@ func (factory *AppExaminerCommandFactory) listApps(context *cli.Context) {
 					displayedRoute = fmt.Sprintf("%s => %d", strings.Join(appInfo.Routes.HostnamesByPort()[arbitraryPort], ", "), arbitraryPort)
 				}
 
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", colors.Bold(appInfo.ProcessGuid), colorInstances(appInfo), colors.NoColor(strconv.Itoa(appInfo.DiskMB)), colors.NoColor(strconv.Itoa(appInfo.MemoryMB)), colors.Cyan(displayedRoute))
+				// The group has its own column, so it isn't repeated with
+				// the other labels.
+				labels := make(map[string]string, len(appInfo.Labels))
+				for key, value := range appInfo.Labels {
+					if key != app_examiner.GroupLabel {
+						labels[key] = value
+					}
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", colors.Bold(appInfo.ProcessGuid), colorInstances(appInfo), colors.NoColor(strconv.Itoa(appInfo.DiskMB)), colors.NoColor(strconv.Itoa(appInfo.MemoryMB)), colors.Cyan(displayedRoute), colors.NoColor(appInfo.Labels[app_examiner.GroupLabel]), colors.NoColor(formatLabels(labels)), colors.Red(factory.formatLastCrash(appInfo.ActualInstances)))
 			}
 
+		} else if selector != nil {
+			fmt.Fprintf(w, "No apps match %s.\n", selector)
+		} else if crashedOnlyFlag {
+			fmt.Fprintf(w, "No crashed apps to display."+"\n")
 		} else {
 			fmt.Fprintf(w, "No apps to display."+"\n")
 		}
 		w.Flush()
 	} else {
 		factory.ui.Say("Error listing apps: " + err.Error())
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+	}
+	// Tasks have neither crashes nor labels to filter them by.
+	if crashedOnlyFlag || selector != nil {
+		return
 	}
 	taskList, err := factory.taskExaminer.ListTasks()
 	if err == nil {
@@ -191,7 +395,7 @@ func (factory *AppExaminerCommandFactory) listApps(context *cli.Context) {
 		taskTableHeader := strings.Repeat("-", 30) + "= Tasks =" + strings.Repeat("-", 30)
 		fmt.Fprintln(wTask, taskTableHeader)
 		if len(taskList) != 0 {
-			taskHeader := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t", colors.Bold("Task Name"), colors.Bold("Cell ID"), colors.Bold("Status"), colors.Bold("Result"), colors.Bold("Failure Reason"))
+			taskHeader := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t", colors.Bold("Task Name"), colors.Bold("Cell ID"), colors.Bold("Status"), colors.Bold("Priority"), colors.Bold("Result"), colors.Bold("Failure Reason"))
 			fmt.Fprintln(wTask, taskHeader)
 
 			for _, taskInfo := range taskList {
@@ -204,7 +408,7 @@ func (factory *AppExaminerCommandFactory) listApps(context *cli.Context) {
 				if taskInfo.FailureReason == "" {
 					taskInfo.FailureReason = "N/A"
 				}
-				coloumnInfo := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t", colors.Bold(taskInfo.TaskGuid), colors.NoColor(taskInfo.CellID), colors.NoColor(taskInfo.State), colors.NoColor(taskInfo.Result), colors.NoColor(taskInfo.FailureReason))
+				coloumnInfo := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t", colors.Bold(taskInfo.TaskGuid), colors.NoColor(taskInfo.CellID), colors.NoColor(taskInfo.State), colors.NoColor(taskInfo.Priority), colors.NoColor(taskInfo.Result), colors.NoColor(taskInfo.FailureReason))
 				fmt.Fprintln(wTask, coloumnInfo)
 			}
 
@@ -214,7 +418,7 @@ func (factory *AppExaminerCommandFactory) listApps(context *cli.Context) {
 		wTask.Flush()
 	} else {
 		factory.ui.Say("Error listing tasks: " + err.Error())
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 	}
 }
 
@@ -222,6 +426,7 @@ func (factory *AppExaminerCommandFactory) appStatus(context *cli.Context) {
 
 	summaryFlag := context.Bool("summary")
 	rateFlag := context.Duration("rate")
+	healthFlag := context.Bool("health")
 
 	if len(context.Args()) < 1 {
 		factory.ui.SayIncorrectUsage("App Name required")
@@ -229,12 +434,46 @@ func (factory *AppExaminerCommandFactory) appStatus(context *cli.Context) {
 		return
 	}
 
-	appName := context.Args()[0]
+	appName, index, err := app_examiner.ParseInstance(context.Args()[0])
+	if err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if healthFlag && (summaryFlag || rateFlag != 0 || index != app_examiner.NoInstance) {
+		factory.ui.SayIncorrectUsage("--health reports on the whole app, and can't be combined with an instance index, --summary or --rate")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appName, err = app_examiner.NewNameResolver(factory.appExaminer).ResolveOne(appName)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
 
 	appInfo, err := factory.appExaminer.AppStatus(appName)
 	if err != nil {
 		factory.ui.Say(err.Error())
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		if err.Error() == app_examiner.AppNotFoundErrorMessage {
+			factory.exitHandler.Exit(exit_codes.NotFound)
+		} else {
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		}
+		return
+	}
+
+	if healthFlag {
+		factory.printHealth(appInfo)
+		return
+	}
+
+	appInfo = onlyInstance(appInfo, index)
+	if index != app_examiner.NoInstance && len(appInfo.ActualInstances) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("%s has no instance %d.", appName, index))
+		factory.exitHandler.Exit(exit_codes.NotFound)
 		return
 	}
 
@@ -250,8 +489,13 @@ func (factory *AppExaminerCommandFactory) appStatus(context *cli.Context) {
 		return
 	}
 
+	// Refreshing reads the app's instances from a cache kept up to date by
+	// the event stream, rather than from the receptor every tick.
+	factory.cache.Start()
+	defer factory.cache.Stop()
+
 	linesWritten := appStatusLinesWritten(appInfo)
-	closeChan := make(chan struct{})
+	closeChan := make(chan struct{}, 1)
 	defer factory.ui.Say(cursor.Show())
 	factory.ui.Say(cursor.Hide())
 
@@ -268,8 +512,10 @@ func (factory *AppExaminerCommandFactory) appStatus(context *cli.Context) {
 			appInfo, err = factory.appExaminer.AppStatus(appName)
 			if err != nil {
 				factory.ui.Say("Error getting status: " + err.Error())
+				factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 				return
 			}
+			appInfo = onlyInstance(appInfo, index)
 			factory.ui.Say(cursor.Up(linesWritten))
 			factory.printAppInfo(appInfo)
 			factory.printInstanceSummary(appInfo.ActualInstances)
@@ -278,6 +524,41 @@ func (factory *AppExaminerCommandFactory) appStatus(context *cli.Context) {
 	}
 }
 
+// printHealth says how healthy the app is and exits with a code for
+// anything short of healthy, so that scripts can use it as a check.
+func (factory *AppExaminerCommandFactory) printHealth(appInfo app_examiner.AppInfo) {
+	health := appInfo.Health()
+	message := fmt.Sprintf("%s is %s: %d/%d instances running", appInfo.ProcessGuid, health, appInfo.ActualRunningInstances, appInfo.DesiredInstances)
+
+	switch health {
+	case app_examiner.Healthy:
+		factory.ui.SayLine(colors.Green(message))
+	case app_examiner.Degraded:
+		factory.ui.SayLine(colors.Yellow(message))
+		factory.exitHandler.Exit(exit_codes.AppDegraded)
+	case app_examiner.Unhealthy:
+		factory.ui.SayLine(colors.Red(message))
+		factory.exitHandler.Exit(exit_codes.AppUnhealthy)
+	}
+}
+
+// onlyInstance narrows the actual instances of appInfo to the one at index,
+// unless index is NoInstance.
+func onlyInstance(appInfo app_examiner.AppInfo, index int) app_examiner.AppInfo {
+	if index == app_examiner.NoInstance {
+		return appInfo
+	}
+
+	instances := []app_examiner.InstanceInfo{}
+	for _, instance := range appInfo.ActualInstances {
+		if instance.Index == index {
+			instances = append(instances, instance)
+		}
+	}
+	appInfo.ActualInstances = instances
+	return appInfo
+}
+
 func (factory *AppExaminerCommandFactory) printAppInfo(appInfo app_examiner.AppInfo) {
 	factory.ui.Say(cursor.ClearToEndOfDisplay())
 
@@ -292,20 +573,37 @@ func (factory *AppExaminerCommandFactory) printAppInfo(appInfo app_examiner.AppI
 	titleBar(colors.Bold(appInfo.ProcessGuid))
 
 	fmt.Fprintf(w, "%s\t%s\n", "Instances", colorInstances(appInfo))
+	fmt.Fprintf(w, "%s\t%s\n", "Crashes", colorCrashes(appInfo.ActualInstances))
 	fmt.Fprintf(w, "%s\t%d\n", "Start Timeout", appInfo.StartTimeout)
 	fmt.Fprintf(w, "%s\t%d\n", "DiskMB", appInfo.DiskMB)
 	fmt.Fprintf(w, "%s\t%d\n", "MemoryMB", appInfo.MemoryMB)
 	fmt.Fprintf(w, "%s\t%d\n", "CPUWeight", appInfo.CPUWeight)
 
+	udpPorts := make(map[uint16]bool)
+	for _, port := range appInfo.UDPPorts {
+		udpPorts[port] = true
+	}
 	portStrings := make([]string, 0)
 	for _, port := range appInfo.Ports {
-		portStrings = append(portStrings, fmt.Sprint(port))
+		if udpPorts[port] {
+			portStrings = append(portStrings, fmt.Sprintf("%d/udp", port))
+		} else {
+			portStrings = append(portStrings, fmt.Sprint(port))
+		}
 	}
 
 	fmt.Fprintf(w, "%s\t%s\n", "Ports", strings.Join(portStrings, ","))
 
 	printAppRoutes(w, appInfo)
 
+	if len(appInfo.Labels) > 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Labels", formatLabels(appInfo.Labels))
+	}
+
+	if deployed := factory.formatDeployment(appInfo.Deployment); deployed != "" {
+		fmt.Fprintf(w, "%s\t%s\n", "Deployed", deployed)
+	}
+
 	if appInfo.Annotation != "" {
 		fmt.Fprintf(w, "%s\t%s\n", "Annotation", appInfo.Annotation)
 	}
@@ -313,7 +611,11 @@ func (factory *AppExaminerCommandFactory) printAppInfo(appInfo app_examiner.AppI
 	printHorizontalRule(w, "-")
 	var envVars string
 	for _, envVar := range appInfo.EnvironmentVariables {
-		envVars += envVar.Name + `="` + envVar.Value + `" ` + "\n"
+		value := envVar.Value
+		if secrets.LooksSecret(envVar.Name) {
+			value = secrets.MaskedValue
+		}
+		envVars += envVar.Name + `="` + value + `" ` + "\n"
 	}
 	fmt.Fprintf(w, "%s\n\n%s", "Environment", envVars)
 
@@ -322,11 +624,135 @@ func (factory *AppExaminerCommandFactory) printAppInfo(appInfo app_examiner.AppI
 	w.Flush()
 }
 
+// crashedApps keeps the apps with at least one instance that is crashed.
+func crashedApps(appList []app_examiner.AppInfo) []app_examiner.AppInfo {
+	crashed := []app_examiner.AppInfo{}
+	for _, appInfo := range appList {
+		for _, instance := range appInfo.ActualInstances {
+			if instance.State == "CRASHED" {
+				crashed = append(crashed, appInfo)
+				break
+			}
+		}
+	}
+	return crashed
+}
+
+func selectedApps(appList []app_examiner.AppInfo, selector app_examiner.Selector) []app_examiner.AppInfo {
+	selected := []app_examiner.AppInfo{}
+	for _, appInfo := range appList {
+		if selector.Matches(appInfo.Labels) {
+			selected = append(selected, appInfo)
+		}
+	}
+	return selected
+}
+
+// formatLastCrash describes the most recent crash among the instances.
+// Only instances that are still crashed know when they crashed, so a crash
+// of an instance that is running again shows without a time.
+func (factory *AppExaminerCommandFactory) formatLastCrash(actualInstances []app_examiner.InstanceInfo) string {
+	var last *app_examiner.InstanceInfo
+	for index, instance := range actualInstances {
+		if instance.CrashReason == "" {
+			continue
+		}
+		if last == nil || instance.LastCrash > last.LastCrash {
+			last = &actualInstances[index]
+		}
+	}
+	if last == nil {
+		return ""
+	}
+
+	description := last.CrashReason
+	if last.HasExitCode {
+		description = fmt.Sprintf("exit %d", last.ExitCode)
+	}
+	if last.LastCrash != 0 {
+		since := factory.clock.Now().Sub(time.Unix(0, last.LastCrash))
+		description += fmt.Sprintf(", %s ago", since-since%time.Second)
+	}
+	return description
+}
+
+// formatDeployment describes when the app was created and last updated,
+// and by whom, as "created 2d ago by alice, last updated 3h ago by bob".
+// Apps ltc didn't record this for get nothing.
+func (factory *AppExaminerCommandFactory) formatDeployment(deployment app_examiner.Deployment) string {
+	var parts []string
+	if !deployment.CreatedAt.IsZero() {
+		parts = append(parts, factory.formatAgo("created", deployment.CreatedAt, deployment.CreatedBy))
+	}
+	if !deployment.UpdatedAt.IsZero() {
+		parts = append(parts, factory.formatAgo("last updated", deployment.UpdatedAt, deployment.UpdatedBy))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (factory *AppExaminerCommandFactory) formatAgo(event string, at time.Time, by string) string {
+	description := fmt.Sprintf("%s %s ago", event, formatAge(factory.clock.Now().Sub(at)))
+	if by != "" {
+		description += " by " + by
+	}
+	return description
+}
+
+// formatAge rounds an age down to its largest unit, from seconds to days.
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		if age < 0 {
+			age = 0
+		}
+		return fmt.Sprintf("%ds", int(age/time.Second))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+}
+
+// formatLabels lists labels as KEY=VALUE pairs in order of their keys.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// colorCrashes totals the crashes of the app's current instances, so that a
+// crash loop stands out without reading the instance table.
+func colorCrashes(actualInstances []app_examiner.InstanceInfo) string {
+	crashes := 0
+	for _, instance := range actualInstances {
+		crashes += instance.CrashCount
+	}
+
+	if crashes > 0 {
+		return colors.Red(strconv.Itoa(crashes))
+	}
+	return strconv.Itoa(crashes)
+}
+
 func appStatusLinesWritten(appInfo app_examiner.AppInfo) int {
-	linesWritten := 9
+	linesWritten := 10
 	for _, appRoute := range appInfo.Routes {
 		linesWritten += len(appRoute.Hostnames)
 	}
+	if len(appInfo.Labels) > 0 {
+		linesWritten++
+	}
+	if !appInfo.Deployment.CreatedAt.IsZero() || !appInfo.Deployment.UpdatedAt.IsZero() {
+		linesWritten++
+	}
+	if appInfo.Annotation != "" {
+		linesWritten++
+	}
 	linesWritten += 3
 	linesWritten += len(appInfo.EnvironmentVariables)
 	linesWritten += 4
@@ -397,6 +823,9 @@ func (factory *AppExaminerCommandFactory) printInstanceInfo(actualInstances []ap
 		if instance.PlacementError == "" && instance.State != "CRASHED" {
 			fmt.Fprintf(w, "%s\t%s\n", "InstanceGuid", instance.InstanceGuid)
 			fmt.Fprintf(w, "%s\t%s\n", "Cell ID", instance.CellID)
+			if instance.Zone != "" {
+				fmt.Fprintf(w, "%s\t%s\n", "Zone", instance.Zone)
+			}
 			fmt.Fprintf(w, "%s\t%s\n", "Ip", instance.Ip)
 
 			portMappingStrings := make([]string, 0)
@@ -413,6 +842,15 @@ func (factory *AppExaminerCommandFactory) printInstanceInfo(actualInstances []ap
 		}
 
 		fmt.Fprintf(w, "%s \t%d \n", "Crash Count", instance.CrashCount)
+		if instance.CrashReason != "" {
+			fmt.Fprintf(w, "%s \t%s \n", "Crash Reason", instance.CrashReason)
+		}
+		if instance.HasExitCode {
+			fmt.Fprintf(w, "%s \t%s \n", "Exit Code", presentation.FormatExitCode(instance.ExitCode))
+		}
+		if instance.LastCrash != 0 {
+			fmt.Fprintf(w, "%s \t%s \n", "Last Crash", time.Unix(0, instance.LastCrash).Local().Format("2006-01-02 15:04:05 (MST)"))
+		}
 
 		if instance.HasMetrics {
 			fmt.Fprintf(w, "%s \t%.2f%% \n", "CPU", instance.Metrics.CpuPercentage)
@@ -437,8 +875,13 @@ func (factory *AppExaminerCommandFactory) visualizeCells(context *cli.Context) {
 		return
 	}
 
+	printVisualization := factory.printDistribution
+	if context.Bool("apps") {
+		printVisualization = factory.printAppGrid
+	}
+
 	factory.ui.Say(colors.Bold("Distribution\n"))
-	linesWritten := factory.printDistribution()
+	linesWritten := printVisualization()
 
 	if rate == 0 {
 		return
@@ -459,7 +902,7 @@ func (factory *AppExaminerCommandFactory) visualizeCells(context *cli.Context) {
 			return
 		case <-factory.clock.NewTimer(rate).C():
 			factory.ui.Say(cursor.Up(linesWritten))
-			linesWritten = factory.printDistribution()
+			linesWritten = printVisualization()
 		}
 	}
 }
@@ -495,6 +938,70 @@ func (factory *AppExaminerCommandFactory) printDistribution() int {
 	return len(cells)
 }
 
+func (factory *AppExaminerCommandFactory) printAppGrid() int {
+	defer factory.ui.Say(cursor.ClearToEndOfDisplay())
+
+	usage, err := factory.clusterExaminer.ClusterUsage()
+	if err != nil {
+		factory.ui.Say("Error visualizing: " + err.Error())
+		factory.ui.Say(cursor.ClearToEndOfLine())
+		factory.ui.SayNewLine()
+		return 1
+	}
+
+	appNames := placedAppNames(usage.Cells)
+
+	w := tabwriter.NewWriter(factory.ui, 9, 8, 1, '\t', 0)
+
+	header := append([]string{"Cell"}, appNames...)
+	header = append(header, "Free Memory", "Free Disk", "Free Containers")
+	fmt.Fprintln(w, strings.Join(header, "\t")+cursor.ClearToEndOfLine())
+
+	for _, cell := range usage.Cells {
+		cellID := cell.CellID
+		if cell.Missing {
+			cellID += colors.Red("[MISSING]")
+		}
+
+		row := []string{cellID}
+		for _, appName := range appNames {
+			if count := cell.AppInstances[appName]; count > 0 {
+				row = append(row, strconv.Itoa(count))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		row = append(row,
+			fmt.Sprintf("%dM/%dM", cell.FreeMemoryMB(), cell.MemoryMB),
+			fmt.Sprintf("%dM/%dM", cell.FreeDiskMB(), cell.DiskMB),
+			fmt.Sprintf("%d/%d", cell.FreeContainers(), cell.Containers),
+		)
+		fmt.Fprintln(w, strings.Join(row, "\t")+cursor.ClearToEndOfLine())
+	}
+
+	w.Flush()
+
+	return len(usage.Cells) + 1
+}
+
+// placedAppNames returns the sorted names of the apps with instances on
+// any of cells.
+func placedAppNames(cells []cluster_examiner.CellUsage) []string {
+	placed := make(map[string]bool)
+	for _, cell := range cells {
+		for appName := range cell.AppInstances {
+			placed[appName] = true
+		}
+	}
+
+	appNames := make([]string, 0, len(placed))
+	for appName := range placed {
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+	return appNames
+}
+
 func printHorizontalRule(w io.Writer, pattern string) {
 	header := strings.Repeat(pattern, 90) + "\n"
 	fmt.Fprintf(w, header)
@@ -511,9 +1018,34 @@ func colorInstances(appInfo app_examiner.AppInfo) string {
 	return colors.Yellow(instances)
 }
 
+// formatRouteOptions prints options sorted by name, e.g.
+// "[session-affinity=JSESSIONID]".
+func formatRouteOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		if options[name] == "" {
+			formatted = append(formatted, name)
+		} else {
+			formatted = append(formatted, name+"="+options[name])
+		}
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
 func printAppRoutes(w io.Writer, appInfo app_examiner.AppInfo) {
+	optionsByPort := appInfo.Routes.OptionsByPort()
 	formatRoute := func(hostname string, port uint16) string {
-		return colors.Cyan(fmt.Sprintf("%s => %d", hostname, port))
+		route := colors.Cyan(fmt.Sprintf("%s => %d", hostname, port))
+		if options, ok := optionsByPort[port]; ok {
+			route += " " + colors.Gray(formatRouteOptions(options))
+		}
+		return route
 	}
 
 	routeStringsByPort := appInfo.Routes.HostnamesByPort()
@@ -1,9 +1,9 @@
 package command_factory_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -15,6 +15,9 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory/graphical/fake_graphical_visualizer"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client/fake_cache"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner/fake_cluster_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
@@ -37,10 +40,11 @@ var _ = Describe("CommandFactory", func() {
 		outputBuffer        *gbytes.Buffer
 		terminalUI          terminal.UI
 		clock               *fakeclock.FakeClock
-		osSignalChan        chan os.Signal
 		fakeExitHandler     *fake_exit_handler.FakeExitHandler
 		graphicalVisualizer *fake_graphical_visualizer.FakeGraphicalVisualizer
 		taskExaminer        *fake_task_examiner.FakeTaskExaminer
+		clusterExaminer     *fake_cluster_examiner.FakeClusterExaminer
+		fakeCache           *fake_cache.FakeCache
 	)
 
 	BeforeEach(func() {
@@ -48,17 +52,18 @@ var _ = Describe("CommandFactory", func() {
 		taskExaminer = &fake_task_examiner.FakeTaskExaminer{}
 		outputBuffer = gbytes.NewBuffer()
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
-		osSignalChan = make(chan os.Signal, 1)
 		clock = fakeclock.NewFakeClock(time.Now())
 		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
 		graphicalVisualizer = &fake_graphical_visualizer.FakeGraphicalVisualizer{}
+		clusterExaminer = &fake_cluster_examiner.FakeClusterExaminer{}
+		fakeCache = &fake_cache.FakeCache{}
 	})
 
 	Describe("ListAppsCommand", func() {
 		var listAppsCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer)
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer, nil, fakeCache)
 			listAppsCommand = commandFactory.MakeListAppCommand()
 		})
 
@@ -66,14 +71,14 @@ var _ = Describe("CommandFactory", func() {
 			listApps := []app_examiner.AppInfo{
 				app_examiner.AppInfo{ProcessGuid: "process1", DesiredInstances: 21, ActualRunningInstances: 0, DiskMB: 100, MemoryMB: 50, Ports: []uint16{54321}, Routes: route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"alldaylong.com"}, Port: 54321}}},
 				app_examiner.AppInfo{ProcessGuid: "process2", DesiredInstances: 8, ActualRunningInstances: 9, DiskMB: 400, MemoryMB: 30, Ports: []uint16{1234}, Routes: route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"never.io"}, Port: 1234}}},
-				app_examiner.AppInfo{ProcessGuid: "process3", DesiredInstances: 5, ActualRunningInstances: 5, DiskMB: 600, MemoryMB: 90, Ports: []uint16{1234}, Routes: route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"allthetime.com", "herewego.org"}, Port: 1234}}},
+				app_examiner.AppInfo{ProcessGuid: "process3", DesiredInstances: 5, ActualRunningInstances: 5, DiskMB: 600, MemoryMB: 90, Ports: []uint16{1234}, Routes: route_helpers.AppRoutes{route_helpers.AppRoute{Hostnames: []string{"allthetime.com", "herewego.org"}, Port: 1234}}, Labels: map[string]string{"version": "1.2.3", "tier": "web", "group": "payment"}},
 				app_examiner.AppInfo{ProcessGuid: "process4", DesiredInstances: 0, ActualRunningInstances: 0, DiskMB: 10, MemoryMB: 10, Routes: route_helpers.AppRoutes{}},
 			}
 
 			listTasks := []task_examiner.TaskInfo{
-				task_examiner.TaskInfo{TaskGuid: "task-guid-1", CellID: "cell-01", Failed: false, FailureReason: "", Result: "Finished", State: "COMPLETED"},
-				task_examiner.TaskInfo{TaskGuid: "task-guid-2", CellID: "cell-02", Failed: true, FailureReason: "No compatible container", Result: "Finished", State: "COMPLETED"},
-				task_examiner.TaskInfo{TaskGuid: "task-guid-3", CellID: "", Failed: true, FailureReason: "", Result: "", State: "COMPLETED"},
+				task_examiner.TaskInfo{TaskGuid: "task-guid-1", CellID: "cell-01", Failed: false, FailureReason: "", Result: "Finished", State: "COMPLETED", Priority: "normal"},
+				task_examiner.TaskInfo{TaskGuid: "task-guid-2", CellID: "cell-02", Failed: true, FailureReason: "No compatible container", Result: "Finished", State: "COMPLETED", Priority: "low"},
+				task_examiner.TaskInfo{TaskGuid: "task-guid-3", CellID: "", Failed: true, FailureReason: "", Result: "", State: "COMPLETED", Priority: "normal"},
 			}
 			appExaminer.ListAppsReturns(listApps, nil)
 			taskExaminer.ListTasksReturns(listTasks, nil)
@@ -85,6 +90,8 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("DiskMB")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("MemoryMB")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Route")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Group")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Labels")))
 
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("process1")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Red("0/21")))
@@ -103,6 +110,8 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("600")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("90")))
 			Expect(outputBuffer).To(test_helpers.Say("allthetime.com, herewego.org => 1234"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("payment")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("tier=web,version=1.2.3")))
 
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("process4")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Green("0/0")))
@@ -112,18 +121,21 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Task Name")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Cell ID")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Status")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Priority")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Result")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Failure Reason")))
 
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("task-guid-1")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("cell-01")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("COMPLETED")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("normal")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("Finished")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("N/A")))
 
 			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("task-guid-2")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("cell-02")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("COMPLETED")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("low")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("Finished")))
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("No compatible container")))
 
@@ -134,6 +146,82 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("N/A")))
 		})
 
+		Context("when instances have crashed", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "healthy-app", DesiredInstances: 1, ActualRunningInstances: 1, ActualInstances: []app_examiner.InstanceInfo{{Index: 0, State: "RUNNING"}}},
+					{ProcessGuid: "recovered-app", DesiredInstances: 1, ActualRunningInstances: 1, ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, State: "RUNNING", CrashCount: 1, CrashReason: "Instance never healthy after 1m0s"},
+					}},
+					{ProcessGuid: "crashing-app", DesiredInstances: 2, ActualRunningInstances: 0, ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, State: "CRASHED", CrashCount: 3, CrashReason: "Exited with status 1", HasExitCode: true, ExitCode: 1, LastCrash: clock.Now().Add(-time.Hour).UnixNano()},
+						{Index: 1, State: "CRASHED", CrashCount: 2, CrashReason: "Exited with status 137", HasExitCode: true, ExitCode: 137, LastCrash: clock.Now().Add(-90 * time.Second).UnixNano()},
+					}},
+				}, nil)
+			})
+
+			It("shows the most recent crash of each app", func() {
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Last Crash")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("recovered-app")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("Instance never healthy after 1m0s")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("crashing-app")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("exit 137, 1m30s ago")))
+			})
+
+			It("lists only the apps with crashed instances, without tasks, with --crashed-only", func() {
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{"--crashed-only"})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("crashing-app")))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("healthy-app"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("recovered-app"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("Tasks"))
+				Expect(taskExaminer.ListTasksCallCount()).To(BeZero())
+			})
+
+			It("says when no apps have crashed instances", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{{ProcessGuid: "healthy-app"}}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{"--crashed-only"})
+
+				Expect(outputBuffer).To(test_helpers.Say("No crashed apps to display."))
+			})
+		})
+
+		Context("with --selector", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "web-staging", Labels: map[string]string{"env": "staging", "team": "web"}},
+					{ProcessGuid: "web-prod", Labels: map[string]string{"env": "prod", "team": "web"}},
+					{ProcessGuid: "unlabelled"},
+				}, nil)
+			})
+
+			It("lists only the apps whose labels match, without tasks", func() {
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{"--selector", "team=web,env=prod"})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("web-prod")))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("web-staging"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("unlabelled"))
+				Expect(taskExaminer.ListTasksCallCount()).To(BeZero())
+			})
+
+			It("says when no apps match", func() {
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{"--selector", "team=data"})
+
+				Expect(outputBuffer).To(test_helpers.Say("No apps match team=data."))
+			})
+
+			It("validates the selector", func() {
+				test_helpers.ExecuteCommandWithArgs(listAppsCommand, []string{"--selector", "team"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid selector team"))
+				Expect(appExaminer.ListAppsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
 		It("alerts the user if there are no apps or tasks", func() {
 			listApps := []app_examiner.AppInfo{}
 			listTasks := []task_examiner.TaskInfo{}
@@ -206,7 +294,7 @@ var _ = Describe("CommandFactory", func() {
 		var visualizeCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, graphicalVisualizer, taskExaminer)
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, graphicalVisualizer, taskExaminer, clusterExaminer, fakeCache)
 			visualizeCommand = commandFactory.MakeVisualizeCommand()
 		})
 
@@ -306,6 +394,56 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when the apps flag is passed", func() {
+			var closeChan chan struct{}
+
+			BeforeEach(func() {
+				clusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{
+					Cells: []cluster_examiner.CellUsage{
+						{CellID: "cell-1", MemoryMB: 4096, DiskMB: 8192, Containers: 256, Instances: 3, ReservedMemoryMB: 768, ReservedDiskMB: 3072, AppInstances: map[string]int{"web": 2, "api": 1}},
+						{CellID: "cell-2", MemoryMB: 2048, DiskMB: 4096, Containers: 128, Instances: 1, ReservedMemoryMB: 128, ReservedDiskMB: 1024, AppInstances: map[string]int{"web": 1}},
+						{CellID: "cell-3", Missing: true, MemoryMB: 2048, DiskMB: 4096, Containers: 128},
+					},
+				}, nil)
+			})
+
+			It("displays a grid of the app instances and free capacity of each cell", func() {
+				test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{"--apps"})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Distribution\n")))
+				Expect(outputBuffer).To(gbytes.Say(`Cell\s+api\s+web\s+Free Memory\s+Free Disk\s+Free Containers`))
+				Expect(outputBuffer).To(gbytes.Say(`cell-1\s+1\s+2\s+3328M/4096M\s+5120M/8192M\s+253/256`))
+				Expect(outputBuffer).To(gbytes.Say(`cell-2\s+-\s+1\s+1920M/2048M\s+3072M/4096M\s+127/128`))
+				Expect(outputBuffer).To(test_helpers.Say("cell-3" + colors.Red("[MISSING]")))
+				Expect(outputBuffer).To(gbytes.Say(`\s+-\s+-\s+2048M/2048M\s+4096M/4096M\s+128/128`))
+				Expect(appExaminer.ListCellsCallCount()).To(Equal(0))
+			})
+
+			It("alerts the user when examining the cluster fails", func() {
+				clusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{}, errors.New("cells went dark"))
+
+				test_helpers.ExecuteCommandWithArgs(visualizeCommand, []string{"--apps"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error visualizing: cells went dark"))
+			})
+
+			It("refreshes the grid at the given rate", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(visualizeCommand, []string{"--apps", "--rate", "1s"})
+
+				Eventually(outputBuffer).Should(gbytes.Say(`cell-1\s+1\s+2`))
+				Expect(clusterExaminer.ClusterUsageCallCount()).To(Equal(1))
+
+				clock.IncrementBySeconds(1)
+
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(4)))
+				Eventually(outputBuffer).Should(gbytes.Say(`cell-1\s+1\s+2`))
+				Expect(clusterExaminer.ClusterUsageCallCount()).To(Equal(2))
+
+				go fakeExitHandler.Exit(exit_codes.SigInt)
+				Eventually(closeChan).Should(BeClosed())
+			})
+		})
+
 		Context("when the graphical flag is passed", func() {
 
 			It("makes a successful call to the graphical visualizer and returns", func() {
@@ -360,7 +498,7 @@ var _ = Describe("CommandFactory", func() {
 		}
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer)
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer, nil, fakeCache)
 			statusCommand = commandFactory.MakeStatusCommand()
 
 			sampleAppInfo = app_examiner.AppInfo{
@@ -389,10 +527,12 @@ var _ = Describe("CommandFactory", func() {
 				LogGuid:    "a9s8dfa99023r",
 				LogSource:  "wompy-app-logz",
 				Annotation: "I love this app. So wompy.",
+				Labels:     map[string]string{"version": "1.2.3", "tier": "web"},
 				ActualInstances: []app_examiner.InstanceInfo{
 					app_examiner.InstanceInfo{
 						InstanceGuid: "a0s9f-u9a8sf-aasdioasdjoi",
 						CellID:       "cell-12",
+						Zone:         "z1",
 						Index:        3,
 						Ip:           "10.85.12.100",
 						Ports: []app_examiner.PortMapping{
@@ -421,9 +561,10 @@ var _ = Describe("CommandFactory", func() {
 						HasMetrics:     false,
 					},
 					app_examiner.InstanceInfo{
-						Index:      5,
-						State:      "CRASHED",
-						CrashCount: 7,
+						Index:       5,
+						State:       "CRASHED",
+						CrashCount:  7,
+						CrashReason: "exit status 2",
 					},
 				},
 			}
@@ -442,6 +583,9 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("Instances"))
 			Expect(outputBuffer).To(test_helpers.Say("1/12"))
 
+			Expect(outputBuffer).To(test_helpers.Say("Crashes"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("9")))
+
 			Expect(outputBuffer).To(test_helpers.Say("Start Timeout"))
 			Expect(outputBuffer).To(test_helpers.Say("600"))
 
@@ -463,12 +607,16 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("cranky-app.my-fun-domain.com => 8080"))
 			Expect(outputBuffer).To(test_helpers.Say("route-me.my-fun-domain.com => 9090"))
 
+			Expect(outputBuffer).To(test_helpers.Say("Labels"))
+			Expect(outputBuffer).To(test_helpers.Say("tier=web,version=1.2.3"))
+
 			Expect(outputBuffer).To(test_helpers.Say("Annotation"))
 			Expect(outputBuffer).To(test_helpers.Say("I love this app. So wompy."))
 
 			Expect(outputBuffer).To(test_helpers.Say("Environment"))
-			Expect(outputBuffer).To(test_helpers.Say(`WOMPY_APP_PASSWORD="seekreet pass"`))
+			Expect(outputBuffer).To(test_helpers.Say(`WOMPY_APP_PASSWORD="********"`))
 			Expect(outputBuffer).To(test_helpers.Say(`WOMPY_APP_USERNAME="mrbigglesworth54"`))
+			Expect(outputBuffer.Contents()).NotTo(ContainSubstring("seekreet pass"))
 
 			Expect(outputBuffer).To(test_helpers.Say("Instance 3"))
 			Expect(outputBuffer).To(test_helpers.Say("RUNNING"))
@@ -479,6 +627,9 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("Cell ID"))
 			Expect(outputBuffer).To(test_helpers.Say("cell-12"))
 
+			Expect(outputBuffer).To(test_helpers.Say("Zone"))
+			Expect(outputBuffer).To(test_helpers.Say("z1"))
+
 			Expect(outputBuffer).To(test_helpers.Say("Ip"))
 			Expect(outputBuffer).To(test_helpers.Say("10.85.12.100"))
 
@@ -517,11 +668,75 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).NotTo(test_helpers.Say("InstanceGuid"))
 			Expect(outputBuffer).To(test_helpers.Say("Crash Count"))
 			Expect(outputBuffer).To(test_helpers.Say("7"))
+			Expect(outputBuffer).To(test_helpers.Say("Crash Reason"))
+			Expect(outputBuffer).To(test_helpers.Say("exit status 2"))
 
 			Expect(outputBuffer).NotTo(test_helpers.Say("CPU"))
 			Expect(outputBuffer).NotTo(test_helpers.Say("Memory"))
 		})
 
+		Context("when routes have options", func() {
+			It("prints the options after each route to the port", func() {
+				sampleAppInfo.Routes[1].Options = map[string]string{"session-affinity": "JSESSIONID"}
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("wompy-app.my-fun-domain.com => 8080") + " " + colors.Gray("[session-affinity=JSESSIONID]")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("cranky-app.my-fun-domain.com => 8080") + " " + colors.Gray("[session-affinity=JSESSIONID]")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("route-me.my-fun-domain.com => 9090") + "\n"))
+			})
+		})
+
+		Context("when the app exposes udp ports", func() {
+			It("marks them in the ports", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "dns-app", Ports: []uint16{53, 8080}, UDPPorts: []uint16{53}}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"dns-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Ports"))
+				Expect(outputBuffer).To(test_helpers.Say("53/udp,8080"))
+			})
+		})
+
+		Context("when an instance has crashed", func() {
+			It("shows the crash reason, exit code and time of the crash", func() {
+				crashedAt := time.Date(2015, 2, 6, 16, 52, 40, 0, time.Local)
+				appExaminer.AppStatusReturns(
+					app_examiner.AppInfo{
+						ActualInstances: []app_examiner.InstanceInfo{
+							{Index: 0, State: "CRASHED", CrashCount: 4, CrashReason: "Exited with status 137", HasExitCode: true, ExitCode: 137, LastCrash: crashedAt.UnixNano()},
+						},
+					}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"crashy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Crash Count"))
+				Expect(outputBuffer).To(test_helpers.Say("4"))
+				Expect(outputBuffer).To(test_helpers.Say("Crash Reason"))
+				Expect(outputBuffer).To(test_helpers.Say("Exited with status 137"))
+				Expect(outputBuffer).To(test_helpers.Say("Exit Code"))
+				Expect(outputBuffer).To(test_helpers.Say("137 (killed by SIGKILL, often for exceeding the memory limit)"))
+				Expect(outputBuffer).To(test_helpers.Say("Last Crash"))
+				Expect(outputBuffer).To(test_helpers.Say(crashedAt.Format("2006-01-02 15:04:05 (MST)")))
+			})
+
+			It("leaves out what the crash reason doesn't say", func() {
+				appExaminer.AppStatusReturns(
+					app_examiner.AppInfo{
+						ActualInstances: []app_examiner.InstanceInfo{
+							{Index: 0, State: "RUNNING", CrashCount: 1, CrashReason: "Instance never healthy after 1m0s"},
+						},
+					}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"crashy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Instance never healthy after 1m0s"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("Exit Code"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("Last Crash"))
+			})
+		})
+
 		Context("when there is a placement error on an actualLRP", func() {
 			It("Displays UNCLAIMED in red, and outputs only the placement error", func() {
 				appExaminer.AppStatusReturns(
@@ -635,13 +850,26 @@ var _ = Describe("CommandFactory", func() {
 				clock.IncrementBySeconds(1)
 
 				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
-				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(24)))
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(27)))
 				Eventually(outputBuffer).Should(test_helpers.Say("wompy-app"))
 
 				roundedTimeSince = roundTime(clock.Now().Add(-1*time.Second), time.Unix(0, 405234567*1e9))
 				Expect(outputBuffer).To(test_helpers.Say(roundedTimeSince))
 			})
 
+			It("reads the app from the cache while refreshing", func() {
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(statusCommand, []string{"wompy-app", "--rate", "1s"})
+
+				Eventually(fakeCache.StartCallCount).Should(Equal(1))
+
+				fakeExitHandler.Exit(exit_codes.SigInt)
+
+				Eventually(closeChan).Should(BeClosed())
+				Expect(fakeCache.StopCallCount()).To(Equal(1))
+			})
+
 			It("dynamically displays any errors", func() {
 				appExaminer.AppStatusReturns(sampleAppInfo, nil)
 
@@ -659,6 +887,7 @@ var _ = Describe("CommandFactory", func() {
 				Expect(outputBuffer).ToNot(test_helpers.Say(TerminalEsc + "\\d+A"))
 				Expect(outputBuffer).To(test_helpers.Say("Error getting status: error fetching status"))
 				Expect(outputBuffer).To(test_helpers.Say(cursor.Show()))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 			})
 
 			Context("when the user interrupts ltc status with ctrl-c", func() {
@@ -676,16 +905,134 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when --health is passed", func() {
+			It("reports a healthy app and exits successfully", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "wompy-app", DesiredInstances: 3, ActualRunningInstances: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app", "--health"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("wompy-app is healthy: 3/3 instances running")))
+				Expect(outputBuffer).NotTo(test_helpers.Say("Instances"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits with AppDegraded when only some instances are running", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "wompy-app", DesiredInstances: 3, ActualRunningInstances: 1}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app", "--health"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Yellow("wompy-app is degraded: 1/3 instances running")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppDegraded}))
+			})
+
+			It("exits with AppUnhealthy when no instances are running", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "wompy-app", DesiredInstances: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app", "--health"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("wompy-app is unhealthy: 0/3 instances running")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AppUnhealthy}))
+			})
+
+			It("exits with NotFound for a missing app", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New(app_examiner.AppNotFoundErrorMessage))
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app", "--health"})
+
+				Expect(outputBuffer).To(test_helpers.Say(app_examiner.AppNotFoundErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("can't be combined with an instance index, --summary or --rate", func() {
+				for _, args := range [][]string{{"wompy-app/1", "--health"}, {"wompy-app", "--health", "--summary"}, {"wompy-app", "--health", "--rate=1s"}} {
+					test_helpers.ExecuteCommandWithArgs(statusCommand, args)
+				}
+
+				Expect(outputBuffer).To(test_helpers.Say("--health reports on the whole app, and can't be combined with an instance index, --summary or --rate"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+				Expect(appExaminer.AppStatusCallCount()).To(BeZero())
+			})
+		})
+
 		Context("when annotation is empty", func() {
-			It("omits annotation from the output", func() {
+			It("omits annotation and labels from the output", func() {
 				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "jumpy-app"}, nil)
 
 				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"jumpy-app"})
 
+				Expect(outputBuffer).NotTo(test_helpers.Say("Labels"))
 				Expect(outputBuffer).NotTo(test_helpers.Say("Annotation"))
 			})
 		})
 
+		Context("when ltc recorded when the app was deployed", func() {
+			It("shows when the app was created and last updated, and by whom", func() {
+				sampleAppInfo.Deployment = app_examiner.Deployment{
+					CreatedAt: clock.Now().Add(-50 * time.Hour),
+					CreatedBy: "alice",
+					UpdatedAt: clock.Now().Add(-3*time.Hour - 20*time.Minute),
+					UpdatedBy: "bob",
+				}
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Deployed"))
+				Expect(outputBuffer).To(test_helpers.Say("created 2d ago by alice, last updated 3h ago by bob"))
+			})
+
+			It("leaves out what wasn't recorded", func() {
+				sampleAppInfo.Deployment = app_examiner.Deployment{CreatedAt: clock.Now().Add(-90 * time.Second)}
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Deployed"))
+				Expect(outputBuffer).To(test_helpers.Say("created 1m ago\n"))
+			})
+
+			It("omits the deployment for apps without one", func() {
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app"})
+
+				Expect(outputBuffer).NotTo(test_helpers.Say("Deployed"))
+			})
+		})
+
+		Context("when an instance is specified", func() {
+			It("shows only that instance", func() {
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app/4"})
+
+				Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("wompy-app"))
+				Expect(outputBuffer).To(test_helpers.Say("wompy-app"))
+				Expect(outputBuffer).To(test_helpers.Say("Instance 4"))
+				Expect(outputBuffer).To(test_helpers.Say("insufficient resources."))
+				Expect(outputBuffer).NotTo(test_helpers.Say("Instance 5"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("Instance 3"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits with NotFound when the app has no such instance", func() {
+				appExaminer.AppStatusReturns(sampleAppInfo, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app/9"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("wompy-app has no instance 9."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("rejects malformed instances", func() {
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"wompy-app/first"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(appExaminer.AppStatusCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
 		Context("when no app name is specified", func() {
 			It("prints usage information", func() {
 				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{})
@@ -703,55 +1050,189 @@ var _ = Describe("CommandFactory", func() {
 			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 		})
 
+		It("exits with NotFound when the app does not exist", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New(app_examiner.AppNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"zany-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say(app_examiner.AppNotFoundErrorMessage))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		Context("when the app name is a pattern", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "zany-app"},
+					{ProcessGuid: "zippy-app"},
+				}, nil)
+			})
+
+			It("shows the status of the matching app", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "zany-app"}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"zan*"})
+
+				Expect(appExaminer.AppStatusCallCount()).To(Equal(1))
+				Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("zany-app"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits when the pattern matches more than one app", func() {
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"z*"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("z* matches more than one app: zany-app, zippy-app"))
+				Expect(appExaminer.AppStatusCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("exits with NotFound when nothing matches", func() {
+				test_helpers.ExecuteCommandWithArgs(statusCommand, []string{"boring-*"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match boring-*."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+		})
+
+	})
+
+	Describe("RoutesCommand", func() {
+		var routesCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer, nil, fakeCache)
+			routesCommand = commandFactory.MakeRoutesCommand()
+		})
+
+		It("lists every route of every app sorted by hostname", func() {
+			appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				app_examiner.AppInfo{ProcessGuid: "process1", Routes: route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"zebra.com", "apple.com"}, Port: 8080},
+				}},
+				app_examiner.AppInfo{ProcessGuid: "process2", Routes: route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"mango.com"}, Port: 9090},
+				}},
+				app_examiner.AppInfo{ProcessGuid: "process3"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(routesCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Route")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("App Name")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Port")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("apple.com")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("process1")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("8080")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("mango.com")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("process2")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("9090")))
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Cyan("zebra.com")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("process1")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.NoColor("8080")))
+
+			Expect(outputBuffer.Contents()).NotTo(ContainSubstring("process3"))
+		})
+
+		It("says when there are no routes", func() {
+			appExaminer.ListAppsReturns([]app_examiner.AppInfo{app_examiner.AppInfo{ProcessGuid: "process1"}}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(routesCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No routes to display."))
+		})
+
+		Context("when the app examiner returns an error", func() {
+			It("prints the error", func() {
+				appExaminer.ListAppsReturns(nil, errors.New("The list was lost"))
+
+				test_helpers.ExecuteCommandWithArgs(routesCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error listing routes: The list was lost"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
 	})
 
 	Describe("Cells", func() {
 		var cellsCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer)
+			commandFactory := command_factory.NewAppExaminerCommandFactory(appExaminer, terminalUI, clock, fakeExitHandler, nil, taskExaminer, clusterExaminer, fakeCache)
 			cellsCommand = commandFactory.MakeCellsCommand()
-		})
 
-		It("lists the cells", func() {
-			appExaminer.ListCellsReturns([]app_examiner.CellInfo{
-				app_examiner.CellInfo{
-					CellID:           "cell-one",
-					RunningInstances: 37,
-					ClaimedInstances: 12,
-					Zone:             "z1",
-					MemoryMB:         1229,
-					DiskMB:           4301,
-					Containers:       256,
+			clusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{
+				Cells: []cluster_examiner.CellUsage{
+					{
+						CellID:           "cell-one",
+						Zone:             "z1",
+						MemoryMB:         1229,
+						DiskMB:           4301,
+						Containers:       256,
+						Instances:        49,
+						ClaimedInstances: 12,
+						ReservedMemoryMB: 1024,
+						ReservedDiskMB:   2048,
+					},
+					{CellID: "cell-two", Missing: true},
 				},
-				app_examiner.CellInfo{CellID: "cell-two"},
 			}, nil)
+		})
 
+		It("lists the health and capacity of the cells", func() {
 			test_helpers.ExecuteCommandWithArgs(cellsCommand, []string{})
 
-			Expect(appExaminer.ListCellsCallCount()).To(Equal(1))
+			Expect(clusterExaminer.ClusterUsageCallCount()).To(Equal(1))
 
 			Expect(outputBuffer).To(test_helpers.Say("Cells"))
 			Expect(outputBuffer).To(test_helpers.Say("Zone"))
 			Expect(outputBuffer).To(test_helpers.Say("Memory"))
 			Expect(outputBuffer).To(test_helpers.Say("Disk"))
+			Expect(outputBuffer).To(test_helpers.Say("Containers"))
 			Expect(outputBuffer).To(test_helpers.Say("Apps"))
+			Expect(outputBuffer).To(test_helpers.Say("Heartbeat"))
 			Expect(outputBuffer).To(test_helpers.SayNewLine())
 
 			Expect(outputBuffer).To(test_helpers.Say("cell-one"))
 			Expect(outputBuffer).To(test_helpers.Say("z1"))
-			Expect(outputBuffer).To(test_helpers.Say("1229M"))
-			Expect(outputBuffer).To(test_helpers.Say("4301M"))
+			Expect(outputBuffer).To(test_helpers.Say("205M/1229M"))
+			Expect(outputBuffer).To(test_helpers.Say("2253M/4301M"))
+			Expect(outputBuffer).To(test_helpers.Say("49/256"))
 			Expect(outputBuffer).To(test_helpers.Say("37/12"))
+			Expect(outputBuffer).To(test_helpers.Say("ok"))
 			Expect(outputBuffer).To(test_helpers.SayNewLine())
 
 			Expect(outputBuffer).To(test_helpers.Say("cell-two"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("missing")))
 			Expect(outputBuffer).To(test_helpers.SayNewLine())
 		})
 
+		It("prints the cells as JSON", func() {
+			test_helpers.ExecuteCommandWithArgs(cellsCommand, []string{"--json"})
+
+			var cells []map[string]interface{}
+			Expect(json.Unmarshal(outputBuffer.Contents(), &cells)).To(Succeed())
+			Expect(cells).To(HaveLen(2))
+			Expect(cells[0]).To(Equal(map[string]interface{}{
+				"cell_id":           "cell-one",
+				"zone":              "z1",
+				"memory_mb":         1229.0,
+				"free_memory_mb":    205.0,
+				"disk_mb":           4301.0,
+				"free_disk_mb":      2253.0,
+				"containers":        256.0,
+				"used_containers":   49.0,
+				"running_instances": 37.0,
+				"claimed_instances": 12.0,
+				"heartbeat":         "ok",
+			}))
+			Expect(cells[1]["heartbeat"]).To(Equal("missing"))
+		})
+
 		Context("when the receptor returns an error", func() {
 			It("prints an error", func() {
-				appExaminer.ListCellsReturns(nil, errors.New("these are not the cells you're looking for"))
+				clusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{}, errors.New("these are not the cells you're looking for"))
 
 				test_helpers.ExecuteCommandWithArgs(cellsCommand, []string{})
 
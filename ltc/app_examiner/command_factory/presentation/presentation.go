@@ -39,3 +39,36 @@ func PadAndColorInstanceState(instanceInfo app_examiner.InstanceInfo) string {
 
 	return fmt.Sprintf("%s%s", ColorInstanceState(instanceInfo), strings.Repeat(" ", padLength))
 }
+
+var signalNames = map[int]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	6:  "SIGABRT",
+	9:  "SIGKILL",
+	11: "SIGSEGV",
+	15: "SIGTERM",
+}
+
+// FormatExitCode explains the exit code of a crashed instance.  Shells
+// report a process killed by a signal as 128 plus the signal's number.
+func FormatExitCode(exitCode int) string {
+	var description string
+	switch {
+	case exitCode == 0:
+		description = "exited without an error"
+	case exitCode == 126:
+		description = "start command not executable"
+	case exitCode == 127:
+		description = "start command not found"
+	case exitCode == 128+9:
+		description = "killed by SIGKILL, often for exceeding the memory limit"
+	case exitCode > 128 && signalNames[exitCode-128] != "":
+		description = "killed by " + signalNames[exitCode-128]
+	case exitCode > 128:
+		description = fmt.Sprintf("killed by signal %d", exitCode-128)
+	default:
+		return fmt.Sprint(exitCode)
+	}
+
+	return fmt.Sprintf("%d (%s)", exitCode, description)
+}
@@ -62,4 +62,21 @@ var _ = Describe("Presentation", func() {
 			Expect(presentation.PadAndColorInstanceState(instanceInfo)).To(Equal(colors.Cyan(string(receptor.ActualLRPStateUnclaimed))))
 		})
 	})
+
+	Describe("FormatExitCode", func() {
+		It("explains exit codes that mean a signal killed the process", func() {
+			Expect(presentation.FormatExitCode(137)).To(Equal("137 (killed by SIGKILL, often for exceeding the memory limit)"))
+			Expect(presentation.FormatExitCode(143)).To(Equal("143 (killed by SIGTERM)"))
+			Expect(presentation.FormatExitCode(140)).To(Equal("140 (killed by signal 12)"))
+		})
+
+		It("explains exit codes the shell uses for bad start commands", func() {
+			Expect(presentation.FormatExitCode(127)).To(Equal("127 (start command not found)"))
+			Expect(presentation.FormatExitCode(0)).To(Equal("0 (exited without an error)"))
+		})
+
+		It("leaves the app's own exit codes as they are", func() {
+			Expect(presentation.FormatExitCode(2)).To(Equal("2"))
+		})
+	})
 })
@@ -50,6 +50,22 @@ type FakeAppExaminer struct {
 		result2 bool
 		result3 error
 	}
+	AppInstanceCountsStub        func(name string) (app_examiner.InstanceCounts, error)
+	appInstanceCountsMutex       sync.RWMutex
+	appInstanceCountsArgsForCall []struct {
+		name string
+	}
+	appInstanceCountsReturns struct {
+		result1 app_examiner.InstanceCounts
+		result2 error
+	}
+	InstanceCountsByAppStub        func() (map[string]app_examiner.InstanceCounts, error)
+	instanceCountsByAppMutex       sync.RWMutex
+	instanceCountsByAppArgsForCall []struct{}
+	instanceCountsByAppReturns     struct {
+		result1 map[string]app_examiner.InstanceCounts
+		result2 error
+	}
 }
 
 func (fake *FakeAppExaminer) ListApps() ([]app_examiner.AppInfo, error) {
@@ -202,4 +218,62 @@ func (fake *FakeAppExaminer) RunningAppInstancesInfoReturns(result1 int, result2
 	}{result1, result2, result3}
 }
 
+func (fake *FakeAppExaminer) AppInstanceCounts(name string) (app_examiner.InstanceCounts, error) {
+	fake.appInstanceCountsMutex.Lock()
+	fake.appInstanceCountsArgsForCall = append(fake.appInstanceCountsArgsForCall, struct {
+		name string
+	}{name})
+	fake.appInstanceCountsMutex.Unlock()
+	if fake.AppInstanceCountsStub != nil {
+		return fake.AppInstanceCountsStub(name)
+	} else {
+		return fake.appInstanceCountsReturns.result1, fake.appInstanceCountsReturns.result2
+	}
+}
+
+func (fake *FakeAppExaminer) AppInstanceCountsCallCount() int {
+	fake.appInstanceCountsMutex.RLock()
+	defer fake.appInstanceCountsMutex.RUnlock()
+	return len(fake.appInstanceCountsArgsForCall)
+}
+
+func (fake *FakeAppExaminer) AppInstanceCountsArgsForCall(i int) string {
+	fake.appInstanceCountsMutex.RLock()
+	defer fake.appInstanceCountsMutex.RUnlock()
+	return fake.appInstanceCountsArgsForCall[i].name
+}
+
+func (fake *FakeAppExaminer) AppInstanceCountsReturns(result1 app_examiner.InstanceCounts, result2 error) {
+	fake.AppInstanceCountsStub = nil
+	fake.appInstanceCountsReturns = struct {
+		result1 app_examiner.InstanceCounts
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAppExaminer) InstanceCountsByApp() (map[string]app_examiner.InstanceCounts, error) {
+	fake.instanceCountsByAppMutex.Lock()
+	fake.instanceCountsByAppArgsForCall = append(fake.instanceCountsByAppArgsForCall, struct{}{})
+	fake.instanceCountsByAppMutex.Unlock()
+	if fake.InstanceCountsByAppStub != nil {
+		return fake.InstanceCountsByAppStub()
+	} else {
+		return fake.instanceCountsByAppReturns.result1, fake.instanceCountsByAppReturns.result2
+	}
+}
+
+func (fake *FakeAppExaminer) InstanceCountsByAppCallCount() int {
+	fake.instanceCountsByAppMutex.RLock()
+	defer fake.instanceCountsByAppMutex.RUnlock()
+	return len(fake.instanceCountsByAppArgsForCall)
+}
+
+func (fake *FakeAppExaminer) InstanceCountsByAppReturns(result1 map[string]app_examiner.InstanceCounts, result2 error) {
+	fake.InstanceCountsByAppStub = nil
+	fake.instanceCountsByAppReturns = struct {
+		result1 map[string]app_examiner.InstanceCounts
+		result2 error
+	}{result1, result2}
+}
+
 var _ app_examiner.AppExaminer = new(FakeAppExaminer)
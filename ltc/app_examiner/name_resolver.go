@@ -0,0 +1,126 @@
+package app_examiner
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// NoInstance is the index ParseInstance returns for a name that addresses
+// the whole app.
+const NoInstance = -1
+
+// NoMatchError is returned when a pattern matches none of the apps.
+type NoMatchError struct {
+	Pattern string
+}
+
+func (e NoMatchError) Error() string {
+	return fmt.Sprintf("No apps match %s.", e.Pattern)
+}
+
+func (NoMatchError) NotFound() bool {
+	return true
+}
+
+// AmbiguousMatchError is returned when a command that acts on a single app is
+// given a pattern matching several.
+type AmbiguousMatchError struct {
+	Pattern string
+	Matches []string
+}
+
+func (e AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%s matches more than one app: %s", e.Pattern, strings.Join(e.Matches, ", "))
+}
+
+// NameResolver expands glob patterns such as 'worker-*' into the names of the
+// matching apps.
+type NameResolver struct {
+	appExaminer AppExaminer
+}
+
+func NewNameResolver(appExaminer AppExaminer) *NameResolver {
+	return &NameResolver{appExaminer}
+}
+
+// Resolve expands each pattern in turn, dropping duplicates.  Names without
+// glob characters are passed through untouched, so commands keep reporting
+// missing apps the way they always have, and the app list is only fetched
+// when there is a pattern to match.
+func (r *NameResolver) Resolve(patterns ...string) ([]string, error) {
+	var appNames []string
+	var appList []AppInfo
+	seen := make(map[string]bool)
+
+	add := func(appName string) {
+		if !seen[appName] {
+			seen[appName] = true
+			appNames = append(appNames, appName)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !IsPattern(pattern) {
+			add(pattern)
+			continue
+		}
+
+		if appList == nil {
+			var err error
+			if appList, err = r.appExaminer.ListApps(); err != nil {
+				return nil, err
+			}
+		}
+
+		matched := false
+		for _, app := range appList {
+			ok, err := path.Match(pattern, app.ProcessGuid)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid app name pattern %s: %s", pattern, err)
+			}
+			if ok {
+				matched = true
+				add(app.ProcessGuid)
+			}
+		}
+		if !matched {
+			return nil, NoMatchError{pattern}
+		}
+	}
+
+	return appNames, nil
+}
+
+// ResolveOne is Resolve for commands that act on a single app.
+func (r *NameResolver) ResolveOne(pattern string) (string, error) {
+	appNames, err := r.Resolve(pattern)
+	if err != nil {
+		return "", err
+	}
+	if len(appNames) > 1 {
+		return "", AmbiguousMatchError{pattern, appNames}
+	}
+	return appNames[0], nil
+}
+
+// ParseInstance splits APP_NAME/INDEX, which addresses a single instance of
+// an app, into the app name and the index.
+func ParseInstance(name string) (string, int, error) {
+	slash := strings.LastIndex(name, "/")
+	if slash == -1 {
+		return name, NoInstance, nil
+	}
+
+	index, err := strconv.Atoi(name[slash+1:])
+	if err != nil || index < 0 || slash == 0 {
+		return "", NoInstance, fmt.Errorf("Invalid instance %s: instances must be of the format APP_NAME/INDEX", name)
+	}
+	return name[:slash], index, nil
+}
+
+// IsPattern reports whether name contains glob characters.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
@@ -0,0 +1,105 @@
+package app_examiner_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+)
+
+var _ = Describe("NameResolver", func() {
+	var (
+		fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		nameResolver    *app_examiner.NameResolver
+	)
+
+	BeforeEach(func() {
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+			{ProcessGuid: "tmp-1"},
+			{ProcessGuid: "tmp-2"},
+			{ProcessGuid: "worker-a"},
+			{ProcessGuid: "worker-b"},
+		}, nil)
+		nameResolver = app_examiner.NewNameResolver(fakeAppExaminer)
+	})
+
+	Describe("Resolve", func() {
+		It("expands patterns against the app list", func() {
+			appNames, err := nameResolver.Resolve("worker-*", "tmp-?")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appNames).To(Equal([]string{"worker-a", "worker-b", "tmp-1", "tmp-2"}))
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(Equal(1))
+		})
+
+		It("passes plain names through without listing apps", func() {
+			appNames, err := nameResolver.Resolve("missing-app", "worker-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appNames).To(Equal([]string{"missing-app", "worker-a"}))
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(BeZero())
+		})
+
+		It("drops duplicates", func() {
+			appNames, err := nameResolver.Resolve("worker-a", "worker-*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appNames).To(Equal([]string{"worker-a", "worker-b"}))
+		})
+
+		It("returns a NoMatchError when a pattern matches nothing", func() {
+			_, err := nameResolver.Resolve("web-*")
+			Expect(err).To(MatchError(app_examiner.NoMatchError{Pattern: "web-*"}))
+			Expect(err.Error()).To(Equal("No apps match web-*."))
+		})
+
+		It("returns an error for a malformed pattern", func() {
+			_, err := nameResolver.Resolve("worker-[")
+			Expect(err).To(MatchError("Invalid app name pattern worker-[: syntax error in pattern"))
+		})
+
+		It("returns errors from listing the apps", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("can't list"))
+
+			_, err := nameResolver.Resolve("worker-*")
+			Expect(err).To(MatchError("can't list"))
+		})
+	})
+
+	Describe("ParseInstance", func() {
+		It("splits the app name from the instance index", func() {
+			appName, index, err := app_examiner.ParseInstance("worker-a/2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appName).To(Equal("worker-a"))
+			Expect(index).To(Equal(2))
+		})
+
+		It("returns NoInstance for plain app names", func() {
+			appName, index, err := app_examiner.ParseInstance("worker-*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appName).To(Equal("worker-*"))
+			Expect(index).To(Equal(app_examiner.NoInstance))
+		})
+
+		It("rejects malformed instances", func() {
+			for _, name := range []string{"worker-a/", "worker-a/two", "worker-a/-1", "/2"} {
+				_, _, err := app_examiner.ParseInstance(name)
+				Expect(err).To(MatchError("Invalid instance " + name + ": instances must be of the format APP_NAME/INDEX"))
+			}
+		})
+	})
+
+	Describe("ResolveOne", func() {
+		It("returns the single matching app", func() {
+			appName, err := nameResolver.ResolveOne("tmp-1*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appName).To(Equal("tmp-1"))
+		})
+
+		It("returns an AmbiguousMatchError when the pattern matches several apps", func() {
+			_, err := nameResolver.ResolveOne("tmp-*")
+			Expect(err).To(MatchError("tmp-* matches more than one app: tmp-1, tmp-2"))
+		})
+	})
+})
@@ -0,0 +1,87 @@
+package app_examiner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GroupLabel is the label that puts an app in a group, so that 'ltc group'
+// can act on the group's apps together.
+const GroupLabel = "group"
+
+// Selector picks apps by their labels, as KEY=VALUE pairs that must all
+// match.  Values are glob patterns, and a dot-separated part of a value that
+// is just 'x', as in 'version=1.1.x', matches any part.
+type Selector map[string]string
+
+// ParseSelector parses comma-separated KEY=VALUE pairs.
+func ParseSelector(selector string) (Selector, error) {
+	parsed := Selector{}
+	for _, pair := range strings.Split(selector, ",") {
+		pieces := strings.SplitN(pair, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" || pieces[1] == "" {
+			return nil, fmt.Errorf("Invalid selector %s: selectors must be of the format KEY=VALUE[,KEY=VALUE...]", selector)
+		}
+
+		if _, err := path.Match(versionPattern(pieces[1]), ""); err != nil {
+			return nil, fmt.Errorf("Invalid selector %s: %s", selector, err)
+		}
+		parsed[pieces[0]] = pieces[1]
+	}
+	return parsed, nil
+}
+
+// Matches reports whether labels satisfy every pair of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for key, pattern := range s {
+		value, ok := labels[key]
+		if !ok {
+			return false
+		}
+		if matched, _ := path.Match(versionPattern(pattern), value); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	pairs := make([]string, 0, len(s))
+	for key, pattern := range s {
+		pairs = append(pairs, key+"="+pattern)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func versionPattern(value string) string {
+	parts := strings.Split(value, ".")
+	for index, part := range parts {
+		if part == "x" || part == "X" {
+			parts[index] = "*"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// ResolveSelector returns the names of the apps whose labels match the
+// selector, or a NoMatchError if there are none.
+func (r *NameResolver) ResolveSelector(selector Selector) ([]string, error) {
+	appList, err := r.appExaminer.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	var appNames []string
+	for _, app := range appList {
+		if selector.Matches(app.Labels) {
+			appNames = append(appNames, app.ProcessGuid)
+		}
+	}
+	if len(appNames) == 0 {
+		return nil, NoMatchError{selector.String()}
+	}
+	return appNames, nil
+}
@@ -0,0 +1,93 @@
+package app_examiner_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+)
+
+var _ = Describe("Selector", func() {
+	Describe("ParseSelector", func() {
+		It("parses comma-separated pairs", func() {
+			selector, err := app_examiner.ParseSelector("version=1.1.x,tier=web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector).To(Equal(app_examiner.Selector{"version": "1.1.x", "tier": "web"}))
+			Expect(selector.String()).To(Equal("tier=web,version=1.1.x"))
+		})
+
+		It("rejects pairs without a key or value", func() {
+			for _, selector := range []string{"version", "=1.2.3", "version=", "version=1.2.3,"} {
+				_, err := app_examiner.ParseSelector(selector)
+				Expect(err).To(MatchError("Invalid selector " + selector + ": selectors must be of the format KEY=VALUE[,KEY=VALUE...]"))
+			}
+		})
+
+		It("rejects malformed patterns", func() {
+			_, err := app_examiner.ParseSelector("version=1.[")
+			Expect(err).To(MatchError("Invalid selector version=1.[: syntax error in pattern"))
+		})
+	})
+
+	Describe("Matches", func() {
+		labels := map[string]string{"version": "1.1.4", "tier": "web"}
+
+		It("matches when every pair matches", func() {
+			Expect(app_examiner.Selector{"version": "1.1.4", "tier": "web"}.Matches(labels)).To(BeTrue())
+			Expect(app_examiner.Selector{"version": "1.1.4", "tier": "worker"}.Matches(labels)).To(BeFalse())
+			Expect(app_examiner.Selector{"team": "coffee"}.Matches(labels)).To(BeFalse())
+		})
+
+		It("treats values as glob patterns", func() {
+			Expect(app_examiner.Selector{"version": "1.1.*"}.Matches(labels)).To(BeTrue())
+			Expect(app_examiner.Selector{"tier": "w?b"}.Matches(labels)).To(BeTrue())
+		})
+
+		It("treats x parts of values as wildcards", func() {
+			Expect(app_examiner.Selector{"version": "1.1.x"}.Matches(labels)).To(BeTrue())
+			Expect(app_examiner.Selector{"version": "1.X.x"}.Matches(labels)).To(BeTrue())
+			Expect(app_examiner.Selector{"version": "1.2.x"}.Matches(labels)).To(BeFalse())
+			Expect(app_examiner.Selector{"tier": "x"}.Matches(map[string]string{"tier": "web"})).To(BeTrue())
+			Expect(app_examiner.Selector{"tier": "xweb"}.Matches(labels)).To(BeFalse())
+		})
+	})
+
+	Describe("ResolveSelector", func() {
+		var (
+			fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+			nameResolver    *app_examiner.NameResolver
+		)
+
+		BeforeEach(func() {
+			fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "web-1", Labels: map[string]string{"version": "1.1.3"}},
+				{ProcessGuid: "web-2", Labels: map[string]string{"version": "1.2.0"}},
+				{ProcessGuid: "web-3", Labels: map[string]string{"version": "1.1.4"}},
+				{ProcessGuid: "worker"},
+			}, nil)
+			nameResolver = app_examiner.NewNameResolver(fakeAppExaminer)
+		})
+
+		It("returns the apps whose labels match", func() {
+			appNames, err := nameResolver.ResolveSelector(app_examiner.Selector{"version": "1.1.x"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(appNames).To(Equal([]string{"web-1", "web-3"}))
+		})
+
+		It("returns a NoMatchError when no apps match", func() {
+			_, err := nameResolver.ResolveSelector(app_examiner.Selector{"version": "2.x"})
+			Expect(err).To(MatchError("No apps match version=2.x."))
+		})
+
+		It("returns errors from listing the apps", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("can't list"))
+
+			_, err := nameResolver.ResolveSelector(app_examiner.Selector{"version": "1.1.x"})
+			Expect(err).To(MatchError("can't list"))
+		})
+	})
+})
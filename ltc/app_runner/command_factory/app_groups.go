@@ -0,0 +1,204 @@
+package command_factory
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+)
+
+const groupUsage = "Please enter 'ltc group create GROUP APP_NAME...', 'ltc group status GROUP', 'ltc group remove GROUP' or 'ltc group scale GROUP INSTANCES|FACTORx'"
+
+var groupNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func (factory *AppRunnerCommandFactory) MakeGroupCommand() cli.Command {
+	var groupFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Removes the group's apps without asking for confirmation",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the group's apps to scale or stop",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the requests are submitted, without waiting for the instances",
+		},
+	}
+
+	return cli.Command{
+		Name:  "group",
+		Usage: "Operates on a group of related docker apps together",
+		Description: `ltc group create GROUP APP_NAME [APP_NAME...]
+   ltc group status GROUP
+   ltc group remove GROUP
+   ltc group scale GROUP INSTANCES|FACTORx
+
+   A group is the apps labelled group=GROUP, so 'ltc label APP_NAME group-'
+   takes an app out of its group.  'ltc group scale payment 2x' doubles the
+   instances of each app in the payment group, while 'ltc group scale
+   payment 2' scales each of them to 2.`,
+		Action: factory.group,
+		Flags:  groupFlags,
+	}
+}
+
+func (factory *AppRunnerCommandFactory) group(c *cli.Context) {
+	args := c.Args()
+	switch {
+	case args.First() == "create" && len(args) >= 3:
+		factory.createGroup(args.Get(1), args[2:])
+	case args.First() == "status" && len(args) == 2:
+		factory.groupStatus(args.Get(1))
+	case args.First() == "remove" && len(args) == 2:
+		factory.removeGroup(c, args.Get(1))
+	case args.First() == "scale" && len(args) == 3:
+		factory.scaleGroup(c, args.Get(1), args.Get(2))
+	default:
+		factory.ui.SayIncorrectUsage(groupUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *AppRunnerCommandFactory) createGroup(group string, patterns []string) {
+	if !groupNamePattern.MatchString(group) {
+		factory.ui.SayIncorrectUsage(fmt.Sprintf("Invalid group name %s: group names must be lowercase letters, digits and hyphens", group))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appNames, err := app_examiner.NewNameResolver(factory.appExaminer).Resolve(patterns...)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	for _, appName := range appNames {
+		if err := factory.appRunner.UpdateAppLabels(appName, map[string]string{app_examiner.GroupLabel: group}); err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error adding %s to group %s: %s", appName, group, err))
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Added %s to group %s.", strings.Join(appNames, ", "), group))
+}
+
+func (factory *AppRunnerCommandFactory) groupStatus(group string) {
+	apps, ok := factory.groupApps(group)
+	if !ok {
+		return
+	}
+
+	running, desired := 0, 0
+	w := tabwriter.NewWriter(factory.ui, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\n", colors.Bold("App Name"), colors.Bold("Instances"))
+	for _, app := range apps {
+		instances := fmt.Sprintf("%d/%d", app.ActualRunningInstances, app.DesiredInstances)
+		if app.ActualRunningInstances == app.DesiredInstances {
+			instances = colors.Green(instances)
+		} else {
+			instances = colors.Red(instances)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", app.ProcessGuid, instances)
+		running += app.ActualRunningInstances
+		desired += app.DesiredInstances
+	}
+	w.Flush()
+
+	factory.ui.SayLine(fmt.Sprintf("Group %s: %d app(s), %d/%d instances running", group, len(apps), running, desired))
+}
+
+func (factory *AppRunnerCommandFactory) removeGroup(c *cli.Context, group string) {
+	apps, ok := factory.groupApps(group)
+	if !ok {
+		return
+	}
+
+	appNames := make([]string, len(apps))
+	for i, app := range apps {
+		appNames[i] = app.ProcessGuid
+	}
+	factory.removeApps(c, appNames)
+}
+
+func (factory *AppRunnerCommandFactory) scaleGroup(c *cli.Context, group, instancesArg string) {
+	scale, err := parseGroupScale(instancesArg)
+	if err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	apps, ok := factory.groupApps(group)
+	if !ok {
+		return
+	}
+
+	appNames := make([]string, len(apps))
+	instances := make(map[string]int, len(apps))
+	for i, app := range apps {
+		appNames[i] = app.ProcessGuid
+		instances[app.ProcessGuid] = scale(app.DesiredInstances)
+	}
+
+	if exitCodes := factory.scaleApps(c.Duration("timeout"), c.Bool("no-wait"), appNames, instances); len(exitCodes) > 0 {
+		factory.exitHandler.Exit(aggregateExitCode(exitCodes))
+	}
+}
+
+// groupApps returns the apps in group, saying why and exiting if there are
+// none.
+func (factory *AppRunnerCommandFactory) groupApps(group string) ([]app_examiner.AppInfo, bool) {
+	appList, err := factory.appExaminer.ListApps()
+	if err != nil {
+		factory.ui.SayLine("Error listing apps: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return nil, false
+	}
+
+	var apps []app_examiner.AppInfo
+	for _, app := range appList {
+		if app.Labels[app_examiner.GroupLabel] == group {
+			apps = append(apps, app)
+		}
+	}
+	if len(apps) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("No apps are in group %s.", group))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return nil, false
+	}
+	return apps, true
+}
+
+// parseGroupScale parses either a number of instances for every app, or a
+// factor such as 2x or 0.5x of each app's own instances, rounded up.
+func parseGroupScale(instancesArg string) (func(int) int, error) {
+	if factorArg := strings.TrimSuffix(instancesArg, "x"); factorArg != instancesArg {
+		factor, err := strconv.ParseFloat(factorArg, 64)
+		if err != nil || factor < 0 || math.IsNaN(factor) || math.IsInf(factor, 0) {
+			return nil, fmt.Errorf("Invalid scale factor %s: factors must be a non-negative number followed by x, such as 2x", instancesArg)
+		}
+		return func(current int) int {
+			return int(math.Ceil(float64(current) * factor))
+		}, nil
+	}
+
+	instances, err := strconv.Atoi(instancesArg)
+	if err != nil || instances < 0 {
+		return nil, fmt.Errorf("Invalid number of instances %s: pass a non-negative integer, or a factor such as 2x", instancesArg)
+	}
+	return func(int) int {
+		return instances
+	}, nil
+}
@@ -0,0 +1,187 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("GroupCommand", func() {
+	var (
+		appRunner       *fake_app_runner.FakeAppRunner
+		appExaminer     *fake_app_examiner.FakeAppExaminer
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		groupCommand    cli.Command
+	)
+
+	BeforeEach(func() {
+		appRunner = &fake_app_runner.FakeAppRunner{}
+		appExaminer = &fake_app_examiner.FakeAppExaminer{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+			AppRunner:   appRunner,
+			AppExaminer: appExaminer,
+			UI:          terminal.NewUI(nil, outputBuffer, nil),
+			Clock:       fakeclock.NewFakeClock(time.Now()),
+			ExitHandler: fakeExitHandler,
+		})
+		groupCommand = commandFactory.MakeGroupCommand()
+
+		appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+			{ProcessGuid: "api", DesiredInstances: 2, ActualRunningInstances: 2, Labels: map[string]string{"group": "payment"}},
+			{ProcessGuid: "worker", DesiredInstances: 3, ActualRunningInstances: 1, Labels: map[string]string{"group": "payment", "tier": "batch"}},
+			{ProcessGuid: "blog", DesiredInstances: 1, ActualRunningInstances: 1, Labels: map[string]string{"group": "marketing"}},
+			{ProcessGuid: "db", DesiredInstances: 1, ActualRunningInstances: 1},
+		}, nil)
+	})
+
+	Describe("group create", func() {
+		It("labels each app with the group", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"create", "payment", "api", "worker", "db"})
+
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(Equal(3))
+			for i, appName := range []string{"api", "worker", "db"} {
+				name, labels := appRunner.UpdateAppLabelsArgsForCall(i)
+				Expect(name).To(Equal(appName))
+				Expect(labels).To(Equal(map[string]string{"group": "payment"}))
+			}
+			Expect(outputBuffer).To(test_helpers.SayLine("Added api, worker, db to group payment."))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("adds the apps matching a pattern", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"create", "everything", "*"})
+
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(Equal(4))
+		})
+
+		It("reports errors labelling an app", func() {
+			appRunner.UpdateAppLabelsReturns(errors.New("api not found"))
+
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"create", "payment", "api"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error adding api to group payment: api not found"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("validates the group name", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"create", "Pay,ment", "api"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid group name Pay,ment"))
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("group status", func() {
+		It("shows the instances of each app in the group", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"status", "payment"})
+
+			Expect(outputBuffer).To(test_helpers.Say("api"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("2/2")))
+			Expect(outputBuffer).To(test_helpers.Say("worker"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("1/3")))
+			Expect(outputBuffer).To(test_helpers.SayLine("Group payment: 2 app(s), 3/5 instances running"))
+			Expect(outputBuffer.Contents()).NotTo(ContainSubstring("blog"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits with NotFound when the group has no apps", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"status", "billing"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No apps are in group billing."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("reports errors listing apps", func() {
+			appExaminer.ListAppsReturns(nil, errors.New("receptor is down"))
+
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"status", "payment"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing apps: receptor is down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	Describe("group remove", func() {
+		It("removes each app in the group", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"remove", "payment", "--force", "--no-wait"})
+
+			Expect(appRunner.RemoveAppCallCount()).To(Equal(2))
+			removed := []string{appRunner.RemoveAppArgsForCall(0), appRunner.RemoveAppArgsForCall(1)}
+			Expect(removed).To(ConsistOf("api", "worker"))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Removed 2 of 2 apps.")))
+		})
+	})
+
+	Describe("group scale", func() {
+		scaledTo := func() map[string]int {
+			scaled := map[string]int{}
+			for i := 0; i < appRunner.ScaleAppCallCount(); i++ {
+				appName, instances := appRunner.ScaleAppArgsForCall(i)
+				scaled[appName] = instances
+			}
+			return scaled
+		}
+
+		It("multiplies each app's instances by a factor", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"scale", "payment", "1.5x", "--no-wait"})
+
+			Expect(scaledTo()).To(Equal(map[string]int{"api": 3, "worker": 5}))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("scales each app to a number of instances", func() {
+			appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+				"api":    {Running: 2},
+				"worker": {Running: 2},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"scale", "payment", "2"})
+
+			Expect(scaledTo()).To(Equal(map[string]int{"api": 2, "worker": 2}))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Scaled 2 of 2 apps.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("validates the instances", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"scale", "payment", "twice"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid number of instances twice"))
+			Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("validates the factor", func() {
+			test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"scale", "payment", "halfx"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid scale factor halfx"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	It("validates the subcommand", func() {
+		test_helpers.ExecuteCommandWithArgs(groupCommand, []string{"rename", "payment"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc group create GROUP APP_NAME...'"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+})
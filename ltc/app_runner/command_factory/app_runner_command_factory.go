@@ -1,22 +1,40 @@
 package command_factory
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/progress"
+	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
@@ -26,11 +44,19 @@ type pollingAction string
 
 const (
 	InvalidPortErrorMessage          = "Invalid port specified. Ports must be a comma-delimited list of integers between 0-65535."
-	MalformedRouteErrorMessage       = "Malformed route. Routes must be of the format port:route"
+	MalformedRouteErrorMessage       = "Malformed route. Routes must be of the format port:route, where route is a hostname prefix or a full hostname, optionally followed by a /path"
 	MustSetMonitoredPortErrorMessage = "Must set monitor-port when specifying multiple exposed ports unless --no-monitor is set."
 	MonitorPortNotExposed            = "Must have an exposed port that matches the monitored port"
+	MonitorPortIsUDP                 = "Healthchecks only support tcp ports. Monitor an exposed tcp port or set --no-monitor."
+	ReservedEnvVarErrorMessage       = "PROCESS_GUID is set by ltc and can't be changed"
+	MalformedSecretEnvErrorMessage   = "Malformed secret env. Secret env vars must be of the format ENV_VAR_NAME=SECRET_NAME"
+	SkipMetadataFetchErrorMessage    = "--skip-metadata-fetch requires --ports or --udp-ports, --working-dir and a START_COMMAND after '--'"
+	MalformedRouteOptionErrorMessage = "Malformed route option. Route options must be of the format [PORT:]session-affinity[=COOKIE_NAME]"
+	MalformedLabelErrorMessage       = "Malformed label. Labels must be of the format KEY=VALUE, and neither may contain commas"
+	DryRunMessage                    = "Dry run: no changes were made."
 
 	DefaultPollingTimeout time.Duration = 2 * time.Minute
+	DefaultPollInterval   time.Duration = 1 * time.Second
 
 	pollingStart pollingAction = "start"
 	pollingScale pollingAction = "scale"
@@ -38,43 +64,144 @@ const (
 
 type AppRunnerCommandFactory struct {
 	appRunner             docker_app_runner.AppRunner
+	dryRunAppRunner       docker_app_runner.AppRunner
+	cleanupAppRunner      docker_app_runner.AppRunner
 	appExaminer           app_examiner.AppExaminer
 	ui                    terminal.UI
 	dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
+	secretStore           secrets.SecretStore
 	domain                string
 	env                   []string
 	clock                 clock.Clock
 	tailedLogsOutputter   console_tailed_logs_outputter.TailedLogsOutputter
 	exitHandler           exit_handler.ExitHandler
+	auditLog              audit.Log
+	pollInterval          time.Duration
+	maxPollInterval       time.Duration
+	lookupHost            func(host string) ([]string, error)
+	routeHTTPClient       *http.Client
+	taskRunner            task_runner.TaskRunner
+	taskExaminer          task_examiner.TaskExaminer
+	clusterExaminer       cluster_examiner.ClusterExaminer
+	cache                 caching_receptor_client.Cache
 }
 
 type AppRunnerCommandFactoryConfig struct {
 	AppRunner             docker_app_runner.AppRunner
+	DryRunAppRunner       docker_app_runner.AppRunner
 	AppExaminer           app_examiner.AppExaminer
 	UI                    terminal.UI
 	DockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
+	SecretStore           secrets.SecretStore
 	Domain                string
 	Env                   []string
 	Clock                 clock.Clock
 	Logger                lager.Logger
 	TailedLogsOutputter   console_tailed_logs_outputter.TailedLogsOutputter
 	ExitHandler           exit_handler.ExitHandler
+	AuditLog              audit.Log
+
+	// PollInterval is how long to wait between checks while waiting on
+	// lattice, doubling after each check up to MaxPollInterval.  It defaults
+	// to DefaultPollInterval, and MaxPollInterval to not backing off at all.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+
+	// CleanupAppRunner removes apps whose creation was interrupted.  Its
+	// requests must not be canceled when ltc exits, as AppRunner's are.  It
+	// defaults to AppRunner.
+	CleanupAppRunner docker_app_runner.AppRunner
+
+	// LookupHost resolves routes for ltc wait --routes.  It defaults to
+	// net.LookupHost.
+	LookupHost func(host string) ([]string, error)
+
+	// RouteHTTPClient requests app routes for ltc create --verify-route.  By
+	// default it doesn't follow redirects, as any answer from the app will do.
+	RouteHTTPClient *http.Client
+
+	// TaskRunner submits and deletes the tasks of ltc run.  Like
+	// CleanupAppRunner's, its requests must not be canceled when ltc exits, as
+	// that is when the task is deleted.
+	TaskRunner   task_runner.TaskRunner
+	TaskExaminer task_examiner.TaskExaminer
+
+	// ClusterExaminer reports the room left on the cells, checked before
+	// create and scale.  It defaults to examining AppExaminer's cells.
+	ClusterExaminer cluster_examiner.ClusterExaminer
+
+	// Cache, when set, is started while waiting on several apps at once, so
+	// that AppExaminer follows them through the receptor's event stream
+	// rather than refetching every instance in the cluster each tick.
+	Cache caching_receptor_client.Cache
 }
 
 func NewAppRunnerCommandFactory(config AppRunnerCommandFactoryConfig) *AppRunnerCommandFactory {
+	pollInterval := config.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	maxPollInterval := config.MaxPollInterval
+	if maxPollInterval < pollInterval {
+		maxPollInterval = pollInterval
+	}
+	cleanupAppRunner := config.CleanupAppRunner
+	if cleanupAppRunner == nil {
+		cleanupAppRunner = config.AppRunner
+	}
+	lookupHost := config.LookupHost
+	if lookupHost == nil {
+		lookupHost = net.LookupHost
+	}
+	clusterExaminer := config.ClusterExaminer
+	if clusterExaminer == nil {
+		clusterExaminer = cluster_examiner.New(config.AppExaminer, nil)
+	}
+	routeHTTPClient := config.RouteHTTPClient
+	if routeHTTPClient == nil {
+		routeHTTPClient = &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return errRouteRedirected
+			},
+		}
+	}
+
 	return &AppRunnerCommandFactory{
-		appRunner:   config.AppRunner,
-		appExaminer: config.AppExaminer,
-		ui:          config.UI,
+		appRunner:             config.AppRunner,
+		dryRunAppRunner:       config.DryRunAppRunner,
+		cleanupAppRunner:      cleanupAppRunner,
+		appExaminer:           config.AppExaminer,
+		ui:                    config.UI,
 		dockerMetadataFetcher: config.DockerMetadataFetcher,
+		secretStore:           config.SecretStore,
 		domain:                config.Domain,
 		env:                   config.Env,
 		clock:                 config.Clock,
 		tailedLogsOutputter:   config.TailedLogsOutputter,
 		exitHandler:           config.ExitHandler,
+		auditLog:              config.AuditLog,
+		pollInterval:          pollInterval,
+		maxPollInterval:       maxPollInterval,
+		lookupHost:            lookupHost,
+		routeHTTPClient:       routeHTTPClient,
+		taskRunner:            config.TaskRunner,
+		taskExaminer:          config.TaskExaminer,
+		clusterExaminer:       clusterExaminer,
+		cache:                 config.Cache,
 	}
 }
 
+var dryRunFlag = cli.BoolFlag{
+	Name:  "dry-run",
+	Usage: "Prints the requests that would be sent to the receptor, without sending them",
+}
+
+var strictFlag = cli.BoolFlag{
+	Name:  "strict",
+	Usage: "Refuses, rather than warns, when the cells don't have room for the instances",
+}
+
 func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 
 	var createFlags = []cli.Flag{
@@ -89,7 +216,12 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 		},
 		cli.StringSliceFlag{
 			Name:  "env, e",
-			Usage: "Environment variables (can be passed multiple times)",
+			Usage: "Environment variables as NAME=VALUE, or NAME to use ltc's value (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.StringSliceFlag{
+			Name:  "secret-env",
+			Usage: "Environment variables sourced from secrets as ENV_VAR_NAME=SECRET_NAME (can be passed multiple times)",
 			Value: &cli.StringSlice{},
 		},
 		cli.IntFlag{
@@ -111,6 +243,10 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 			Name:  "ports, p",
 			Usage: "Ports to expose on the container (comma delimited)",
 		},
+		cli.StringFlag{
+			Name:  "udp-ports",
+			Usage: "UDP ports to expose on the container (comma delimited), which get no routes or healthcheck",
+		},
 		cli.IntFlag{
 			Name:  "monitor-port, M",
 			Usage: "Selects the port used to healthcheck the app",
@@ -130,11 +266,39 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 			Usage: "Route mappings to exposed ports as follows:\n\t\t" +
 				"--routes=80:web,8080:api will route web to 80 and api to 8080",
 		},
+		cli.StringSliceFlag{
+			Name: "route-option",
+			Usage: "Asks the router for special handling of the app's routes, as [PORT:]NAME[=VALUE]\n\t\t" +
+				"--route-option=8080:session-affinity makes routes to 8080 sticky (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.StringSliceFlag{
+			Name:  "sidecar",
+			Usage: "Runs COMMAND ARGS... alongside the start command in each container (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "Labels the app with KEY=VALUE, such as version=1.2.3 (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
 		cli.IntFlag{
 			Name:  "instances, i",
 			Usage: "Number of application instances to spawn on launch",
 			Value: 1,
 		},
+		cli.BoolFlag{
+			Name:  "ignore-image-env",
+			Usage: "Does not set the environment variables declared by the docker image",
+		},
+		cli.BoolFlag{
+			Name:  "override-entrypoint",
+			Usage: "Runs START_COMMAND in place of the image's ENTRYPOINT instead of passing it as arguments",
+		},
+		cli.BoolFlag{
+			Name:  "skip-metadata-fetch",
+			Usage: "Does not query the docker registry for image metadata (requires --ports or --udp-ports, --working-dir and START_COMMAND)",
+		},
 		cli.BoolFlag{
 			Name:  "no-monitor",
 			Usage: "Disables healthchecking for the app",
@@ -148,6 +312,28 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 			Usage: "Polling timeout for app to start",
 			Value: DefaultPollingTimeout,
 		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the app is submitted, without waiting for it to start",
+		},
+		cli.BoolFlag{
+			Name:  "interactive",
+			Usage: "Prompts for the app configuration, using flags and image metadata as defaults",
+		},
+		cli.BoolFlag{
+			Name:  "update-if-exists",
+			Usage: "Updates the app to match the given configuration if it already exists",
+		},
+		cli.BoolFlag{
+			Name:  "cleanup-on-interrupt",
+			Usage: "Removes the app without asking if ltc is interrupted while it starts",
+		},
+		cli.BoolFlag{
+			Name:  "verify-route",
+			Usage: "Waits for the app's routes to answer through the router before reporting it running",
+		},
+		strictFlag,
+		dryRunFlag,
 	}
 
 	var createAppCommand = cli.Command{
@@ -166,13 +352,45 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
    To provide a custom command:
    ltc create APP_NAME DOCKER_IMAGE <optional flags> -- START_COMMAND APP_ARG1 APP_ARG2 ...
 
+   As with docker run, a custom command replaces the image's CMD and is passed
+   as arguments to its ENTRYPOINT, if it has one.  To replace the ENTRYPOINT too:
+   ltc create APP_NAME DOCKER_IMAGE --override-entrypoint -- START_COMMAND APP_ARG1 ...
+
    ltc will also fetch the working directory associated with your Docker image.
    If the image does not specify a working directory, ltc will default the working directory to "/"
    To provide a custom working directory:
    ltc create APP_NAME DOCKER_IMAGE --working-dir=/foo/app-folder -- START_COMMAND APP_ARG1 APP_ARG2 ...
 
+   When the registry can't be reached from this machine but can be from the cells,
+   skip fetching the image metadata and provide everything it would have supplied:
+   ltc create APP_NAME DOCKER_IMAGE --skip-metadata-fetch --ports=8080 --working-dir=/app -- START_COMMAND ...
+
+   To expose udp ports, e.g. for DNS or syslog, alongside any tcp ports:
+   ltc create APP_NAME DOCKER_IMAGE --udp-ports=53,514 --no-monitor
+
    To specify environment variables:
    ltc create APP_NAME DOCKER_IMAGE -e FOO=BAR -e BAZ=WIBBLE
+
+   To expose secrets stored with ltc set-secret as environment variables:
+   ltc create APP_NAME DOCKER_IMAGE --secret-env DB_PASSWORD=db-password
+
+   To label the app, for 'ltc remove --selector' and the like:
+   ltc create APP_NAME DOCKER_IMAGE --label version=1.2.3 --label tier=web
+
+   To be guided through the app configuration:
+   ltc create --interactive [APP_NAME] [DOCKER_IMAGE]
+
+   To make a deployment script re-runnable, update the app if it already exists.
+   Instances, routes and labels are changed in place; any other change
+   creates the app again, restarting every instance:
+   ltc create APP_NAME DOCKER_IMAGE --update-if-exists
+
+   To review the request that would be sent to the receptor without creating the app:
+   ltc create APP_NAME DOCKER_IMAGE --dry-run
+
+   ltc warns before creating an app whose instances can't all fit in the
+   memory, disk and containers left on the cells.  To refuse instead:
+   ltc create APP_NAME DOCKER_IMAGE --strict
 `,
 		Action: factory.createApp,
 		Flags:  createFlags,
@@ -181,6 +399,30 @@ func (factory *AppRunnerCommandFactory) MakeCreateAppCommand() cli.Command {
 	return createAppCommand
 }
 
+func (factory *AppRunnerCommandFactory) MakeInspectImageCommand() cli.Command {
+	var inspectImageFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json, j",
+			Usage: "Prints the image metadata as JSON",
+		},
+	}
+
+	var inspectImageCommand = cli.Command{
+		Name:    "inspect-image",
+		Aliases: []string{"ii"},
+		Usage:   "Shows the docker image metadata that ltc create uses",
+		Description: `ltc inspect-image DOCKER_IMAGE [--json]
+
+   Prints the exposed ports, start command, working directory, environment
+   and user of DOCKER_IMAGE as fetched from its registry, along with the
+   defaults ltc create falls back to when the image leaves them unset.`,
+		Action: factory.inspectImage,
+		Flags:  inspectImageFlags,
+	}
+
+	return inspectImageCommand
+}
+
 func (factory *AppRunnerCommandFactory) MakeSubmitLrpCommand() cli.Command {
 	var submitLrpCommand = cli.Command{
 		Name:    "submit-lrp",
@@ -203,12 +445,26 @@ func (factory *AppRunnerCommandFactory) MakeScaleAppCommand() cli.Command {
 			Usage: "Polling timeout for app to scale",
 			Value: DefaultPollingTimeout,
 		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the scale request is submitted, without waiting for the instances",
+		},
+		cli.IntFlag{
+			Name:  "batch, b",
+			Usage: "Scales up this many instances at a time, waiting for each batch to be running",
+		},
+		cli.StringFlag{
+			Name:  "selector, l",
+			Usage: "Scales the apps whose labels match KEY=VALUE[,KEY=VALUE...] instead of APP_NAME",
+		},
+		strictFlag,
+		dryRunFlag,
 	}
 	var scaleAppCommand = cli.Command{
 		Name:        "scale",
 		Aliases:     []string{"sc"},
 		Usage:       "Scales a docker app on lattice",
-		Description: "ltc scale APP_NAME NUM_INSTANCES",
+		Description: "ltc scale APP_NAME NUM_INSTANCES [--batch=BATCH_SIZE] [--strict]\n   ltc scale --selector KEY=VALUE[,KEY=VALUE...] NUM_INSTANCES [--strict]\n\n   Warns when the new instances can't all fit on the cells, or with --strict refuses to scale.",
 		Action:      factory.scaleApp,
 		Flags:       scaleFlags,
 	}
@@ -216,12 +472,167 @@ func (factory *AppRunnerCommandFactory) MakeScaleAppCommand() cli.Command {
 	return scaleAppCommand
 }
 
+func (factory *AppRunnerCommandFactory) MakeResizeAppCommand() cli.Command {
+	var resizeFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "New memory limit for the app's containers in MB (0 removes the limit)",
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "New disk limit for the app's containers in MB (0 removes the limit)",
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight, c",
+			Usage: "New relative CPU weight for the app's containers (valid values: 1-100)",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for each set of instances to start",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "recreate",
+			Usage: "Recreates the app without a stand-in, dropping requests while its instances restart",
+		},
+	}
+
+	var resizeAppCommand = cli.Command{
+		Name:  "resize",
+		Usage: "Changes the memory and disk limits and CPU weight of a docker app",
+		Description: `ltc resize APP_NAME [--memory-mb=MEMORY_MB] [--disk-mb=DISK_MB] [--cpu-weight=CPU_WEIGHT]
+
+   Diego can't change the limits of running instances, so the app is
+   recreated.  To keep serving requests meanwhile, ltc first starts a copy
+   of the app named APP_NAME-resizing with the new limits and the same
+   routes, and removes it once the recreated app is running.  This needs
+   room on the cells for both sets of instances; pass --recreate to skip
+   the stand-in.`,
+		Action: factory.resizeApp,
+		Flags:  resizeFlags,
+	}
+
+	return resizeAppCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeStopAppCommand() cli.Command {
+	var stopFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the app's instances to stop",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the stop request is submitted, without waiting for the instances to stop",
+		},
+	}
+
+	var stopAppCommand = cli.Command{
+		Name:    "stop",
+		Aliases: []string{"sp"},
+		Usage:   "Stops a docker app on lattice without removing it",
+		Description: `ltc stop APP_NAME
+
+   Scales the app to zero, remembering its instance count for 'ltc start'.`,
+		Action: factory.stopApp,
+		Flags:  stopFlags,
+	}
+
+	return stopAppCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeStartAppCommand() cli.Command {
+	var startFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for app to start",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the start request is submitted, without waiting for the instances",
+		},
+	}
+
+	var startAppCommand = cli.Command{
+		Name:    "start",
+		Aliases: []string{"sa"},
+		Usage:   "Starts a docker app stopped with 'ltc stop'",
+		Description: `ltc start APP_NAME
+
+   Brings the app back to the instance count it had when it was stopped.`,
+		Action: factory.startApp,
+		Flags:  startFlags,
+	}
+
+	return startAppCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeRestartInstanceCommand() cli.Command {
+	var restartFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the instance to restart",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the instance is stopped, without waiting for it to run again",
+		},
+	}
+
+	var restartInstanceCommand = cli.Command{
+		Name:  "restart",
+		Usage: "Restarts one instance of a docker app on lattice",
+		Description: `ltc restart APP_NAME/INDEX
+
+   Stops the instance at INDEX, which lattice then starts again in a new
+   container.  The app's other instances keep running.`,
+		Action: factory.restartInstance,
+		Flags:  restartFlags,
+	}
+
+	return restartInstanceCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeRollbackAppCommand() cli.Command {
+	var rollbackFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the app's instances to be running",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the rollback is submitted, without waiting for the instances",
+		},
+	}
+
+	var rollbackAppCommand = cli.Command{
+		Name:  "rollback",
+		Usage: "Restores a docker app's previous configuration",
+		Description: `ltc rollback APP_NAME
+
+   Restores the most recent configuration of the app recorded by
+   'ltc history' that differs from the app as it is now, including its
+   image, environment, routes and instances.  Rolling back twice undoes
+   the first rollback.  Changes other than to routes, labels and
+   instances recreate the app, restarting all of its instances.`,
+		Action: factory.rollbackApp,
+		Flags:  rollbackFlags,
+	}
+
+	return rollbackAppCommand
+}
+
 func (factory *AppRunnerCommandFactory) MakeUpdateRoutesCommand() cli.Command {
 	var updateRoutesFlags = []cli.Flag{
 		cli.BoolFlag{
 			Name:  "no-routes",
 			Usage: "Registers no routes for the app",
 		},
+		dryRunFlag,
 	}
 
 	var updateRoutesCommand = cli.Command{
@@ -236,27 +647,252 @@ func (factory *AppRunnerCommandFactory) MakeUpdateRoutesCommand() cli.Command {
 	return updateRoutesCommand
 }
 
+func (factory *AppRunnerCommandFactory) MakeMapRouteCommand() cli.Command {
+	var mapRouteCommand = cli.Command{
+		Name:    "map-route",
+		Aliases: []string{"mr"},
+		Usage:   "Adds a route to a running app",
+		Description: `ltc map-route APP_NAME PORT:ROUTE
+
+   Routes ROUTE to PORT of the app, keeping its existing routes.`,
+		Action: factory.mapRoute,
+	}
+
+	return mapRouteCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeUnmapRouteCommand() cli.Command {
+	var unmapRouteCommand = cli.Command{
+		Name:    "unmap-route",
+		Aliases: []string{"umr"},
+		Usage:   "Removes a route from a running app",
+		Description: `ltc unmap-route APP_NAME PORT:ROUTE
+
+   Stops routing ROUTE to PORT of the app, keeping its other routes.`,
+		Action: factory.unmapRoute,
+	}
+
+	return unmapRouteCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeLabelAppCommand() cli.Command {
+	var labelAppCommand = cli.Command{
+		Name:  "label",
+		Usage: "Sets or removes labels on a running app",
+		Description: `ltc label APP_NAME KEY=VALUE... [KEY-...]
+
+   Sets each KEY=VALUE label on the app, keeping its other labels, and
+   removes each label given as KEY-.  Labels are shown by 'ltc list' and
+   'ltc status', and select apps for 'ltc remove --selector'.
+   Relabelling an app does not restart it.`,
+		Action: factory.labelApp,
+	}
+
+	return labelAppCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeBindLogDrainCommand() cli.Command {
+	var bindLogDrainCommand = cli.Command{
+		Name:  "bind-log-drain",
+		Usage: "Forwards the logs of an app to a syslog or https endpoint",
+		Description: `ltc bind-log-drain APP_NAME DRAIN_URL
+
+   DRAIN_URL is syslog://HOST:PORT or syslog-tls://HOST:PORT for a syslog
+   server, or an https:// URL each log line is POSTed to.  Logs are sent as
+   RFC 5424 syslog messages by 'ltc drain-logs', which must be running for
+   the logs to be forwarded.  Binding a drain does not restart the app.`,
+		Action: factory.bindLogDrain,
+	}
+
+	return bindLogDrainCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeUnbindLogDrainCommand() cli.Command {
+	var unbindLogDrainCommand = cli.Command{
+		Name:  "unbind-log-drain",
+		Usage: "Stops forwarding the logs of an app to a drain",
+		Description: `ltc unbind-log-drain APP_NAME DRAIN_URL
+
+   DRAIN_URL must be given as it was bound, as shown by 'ltc log-drains'.`,
+		Action: factory.unbindLogDrain,
+	}
+
+	return unbindLogDrainCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeLogDrainsCommand() cli.Command {
+	var logDrainsCommand = cli.Command{
+		Name:        "log-drains",
+		Usage:       "Lists the drains the logs of an app are forwarded to",
+		Description: "ltc log-drains APP_NAME",
+		Action:      factory.listLogDrains,
+	}
+
+	return logDrainsCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeEnvCommand() cli.Command {
+	var envFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "show-secrets",
+			Usage: "Shows the values of variables that look like secrets",
+		},
+	}
+
+	var envCommand = cli.Command{
+		Name:  "env",
+		Usage: "Shows the environment variables of an app",
+		Description: `ltc env APP_NAME [--show-secrets]
+
+   The values of variables whose names look like they hold secrets, such
+   as DB_PASSWORD or API_TOKEN, are masked unless --show-secrets is passed.`,
+		Action: factory.showEnv,
+		Flags:  envFlags,
+	}
+
+	return envCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeSetEnvCommand() cli.Command {
+	var setEnvCommand = cli.Command{
+		Name:  "set-env",
+		Usage: "Sets environment variables of a docker app",
+		Description: `ltc set-env APP_NAME NAME=VALUE... [NAME...]
+
+   Sets each variable, keeping the app's others.  A NAME without a value
+   takes its value from ltc's environment, which keeps the value out of
+   'ltc history'.
+
+   Diego can't change the environment of running instances, so the app is
+   recreated as 'ltc resize' does it, behind a stand-in named
+   APP_NAME-updating.`,
+		Action: factory.setEnv,
+		Flags:  envUpdateFlags(),
+	}
+
+	return setEnvCommand
+}
+
+func (factory *AppRunnerCommandFactory) MakeUnsetEnvCommand() cli.Command {
+	var unsetEnvCommand = cli.Command{
+		Name:  "unset-env",
+		Usage: "Removes environment variables from a docker app",
+		Description: `ltc unset-env APP_NAME NAME...
+
+   The app is recreated as for 'ltc set-env'.`,
+		Action: factory.unsetEnv,
+		Flags:  envUpdateFlags(),
+	}
+
+	return unsetEnvCommand
+}
+
+func envUpdateFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for each set of instances to start",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "recreate",
+			Usage: "Recreates the app without a stand-in, dropping requests while its instances restart",
+		},
+	}
+}
+
 func (factory *AppRunnerCommandFactory) MakeRemoveAppCommand() cli.Command {
+	var removeFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "Removes every app on the target",
+		},
+		cli.StringFlag{
+			Name:  "selector, l",
+			Usage: "Removes the apps whose labels match KEY=VALUE[,KEY=VALUE...], such as version=1.1.x",
+		},
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Removes the app(s) without asking for confirmation",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the apps' instances to stop",
+			Value: DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the removal is submitted, without waiting for the instances to stop",
+		},
+		dryRunFlag,
+	}
 
 	var removeAppCommand = cli.Command{
 		Name:        "remove",
 		Aliases:     []string{"rm"},
-		Description: "ltc remove APP1_NAME [APP2_NAME APP3_NAME...]",
+		Description: "ltc remove APP1_NAME [APP2_NAME APP3_NAME...]\n   ltc remove --selector KEY=VALUE[,KEY=VALUE...]\n   ltc remove --all",
 		Usage:       "Stops and removes docker app(s) from lattice",
 		Action:      factory.removeApp,
+		Flags:       removeFlags,
 	}
 
 	return removeAppCommand
 }
 
+func (factory *AppRunnerCommandFactory) MakeWaitCommand() cli.Command {
+	var waitFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "state",
+			Usage: "State to wait for: running or stopped",
+			Value: "running",
+		},
+		cli.IntFlag{
+			Name:  "instances, i",
+			Usage: "Number of running instances to wait for [default: the app's instance count]",
+		},
+		cli.BoolFlag{
+			Name:  "routes",
+			Usage: "Also waits for the app's routes to resolve",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the app to reach the state",
+			Value: DefaultPollingTimeout,
+		},
+	}
+
+	var waitCommand = cli.Command{
+		Name:  "wait",
+		Usage: "Waits for a docker app to be running or stopped",
+		Description: `ltc wait APP_NAME [--state running|stopped] [--instances N] [--routes]
+
+   Blocks until the app's instances are running, such as after 'ltc create --no-wait'.
+   Instances count as running once their healthcheck passes.
+
+   Exits with 16 if the app doesn't reach the state before the timeout, 17 if
+   the app doesn't exist and 11 if lattice can't place its instances.`,
+		Action: factory.waitForApp,
+		Flags:  waitFlags,
+	}
+
+	return waitCommand
+}
+
 func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
+	if context.Bool("interactive") {
+		factory.createAppInteractively(context)
+		return
+	}
+
 	workingDirFlag := context.String("working-dir")
 	envVarsFlag := context.StringSlice("env")
+	secretEnvFlag := context.StringSlice("secret-env")
 	instancesFlag := context.Int("instances")
 	cpuWeightFlag := uint(context.Int("cpu-weight"))
 	memoryMBFlag := context.Int("memory-mb")
 	diskMBFlag := context.Int("disk-mb")
 	portsFlag := context.String("ports")
+	udpPortsFlag := context.String("udp-ports")
 	noMonitorFlag := context.Bool("no-monitor")
 	portMonitorFlag := context.Int("monitor-port")
 	urlMonitorFlag := context.String("monitor-url")
@@ -264,6 +900,7 @@ func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
 	routesFlag := context.String("routes")
 	noRoutesFlag := context.Bool("no-routes")
 	timeoutFlag := context.Duration("timeout")
+	overrideEntrypointFlag := context.Bool("override-entrypoint")
 	name := context.Args().Get(0)
 	dockerImage := context.Args().Get(1)
 	terminator := context.Args().Get(2)
@@ -288,21 +925,45 @@ func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
 		return
 	}
 
-	imageMetadata, err := factory.dockerMetadataFetcher.FetchMetadata(dockerImage)
-	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error fetching image metadata: %s", err))
-		factory.exitHandler.Exit(exit_codes.BadDocker)
+	if context.Bool("verify-route") && (noRoutesFlag || context.Bool("no-wait")) {
+		factory.ui.SayIncorrectUsage("--verify-route can't be used with --no-routes or --no-wait")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := docker_app_runner.ValidateAppName(name); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
 
-	exposedPorts, err := factory.getExposedPortsFromArgs(portsFlag, imageMetadata)
+	var imageMetadata *docker_metadata_fetcher.ImageMetadata
+	if context.Bool("skip-metadata-fetch") {
+		if (portsFlag == "" && udpPortsFlag == "") || workingDirFlag == "" || startCommand == "" {
+			factory.ui.SayIncorrectUsage(SkipMetadataFetchErrorMessage)
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		imageMetadata = &docker_metadata_fetcher.ImageMetadata{}
+	} else {
+		var err error
+		imageMetadata, err = factory.dockerMetadataFetcher.FetchMetadata(dockerImage)
+		if err != nil {
+			factory.ui.Say(fmt.Sprintf("Error fetching image metadata: %s", err))
+			factory.exitHandler.Exit(exit_codes.BadDocker)
+			return
+		}
+	}
+
+	exposedPorts, udpPorts, err := factory.getExposedPortsFromArgs(portsFlag, udpPortsFlag, imageMetadata)
 	if err != nil {
 		factory.ui.Say(err.Error())
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
 
-	monitorConfig, err := factory.getMonitorConfigFromArgs(exposedPorts, portMonitorFlag, noMonitorFlag, urlMonitorFlag, monitorTimeoutFlag, imageMetadata)
+	monitorConfig, err := factory.getMonitorConfigFromArgs(exposedPorts, udpPorts, portMonitorFlag, noMonitorFlag, urlMonitorFlag, monitorTimeoutFlag, imageMetadata)
 	if err != nil {
 		factory.ui.Say(err.Error())
 		if err.Error() == MonitorPortNotExposed {
@@ -314,20 +975,20 @@ func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
 	}
 
 	if workingDirFlag == "" {
-		factory.ui.Say("No working directory specified, using working directory from the image metadata...\n")
+		factory.ui.SayInfo("No working directory specified, using working directory from the image metadata...\n")
 		if imageMetadata.WorkingDir != "" {
 			workingDirFlag = imageMetadata.WorkingDir
-			factory.ui.Say("Working directory is:\n")
-			factory.ui.Say(workingDirFlag + "\n")
+			factory.ui.SayInfo("Working directory is:\n")
+			factory.ui.SayInfo(workingDirFlag + "\n")
 		} else {
 			workingDirFlag = "/"
 		}
 	}
 
 	if !noMonitorFlag {
-		factory.ui.Say(fmt.Sprintf("Monitoring the app on port %d...\n", monitorConfig.Port))
+		factory.ui.SayInfo(fmt.Sprintf("Monitoring the app on port %d...\n", monitorConfig.Port))
 	} else {
-		factory.ui.Say("No ports will be monitored.\n")
+		factory.ui.SayInfo("No ports will be monitored.\n")
 	}
 
 	if startCommand == "" {
@@ -337,13 +998,21 @@ func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
 			return
 		}
 
-		factory.ui.Say("No start command specified, using start command from the image metadata...\n")
+		factory.ui.SayInfo("No start command specified, using start command from the image metadata...\n")
 		startCommand = imageMetadata.StartCommand[0]
 
-		factory.ui.Say("Start command is:\n")
-		factory.ui.Say(strings.Join(imageMetadata.StartCommand, " ") + "\n")
+		factory.ui.SayInfo("Start command is:\n")
+		factory.ui.SayInfo(strings.Join(imageMetadata.StartCommand, " ") + "\n")
 
 		appArgs = imageMetadata.StartCommand[1:]
+	} else if len(imageMetadata.Entrypoint) > 0 && !overrideEntrypointFlag {
+		factory.ui.SayInfo("Passing the start command to the entrypoint from the image metadata...\n")
+		commandArgs := append([]string{startCommand}, appArgs...)
+		startCommand = imageMetadata.Entrypoint[0]
+		appArgs = append(append([]string{}, imageMetadata.Entrypoint[1:]...), commandArgs...)
+
+		factory.ui.SayInfo("Start command is:\n")
+		factory.ui.SayInfo(strings.Join(append([]string{startCommand}, appArgs...), " ") + "\n")
 	}
 
 	routeOverrides, err := parseRouteOverrides(routesFlag)
@@ -353,203 +1022,1708 @@ func (factory *AppRunnerCommandFactory) createApp(context *cli.Context) {
 		return
 	}
 
-	err = factory.appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
-		Name:                 name,
-		DockerImagePath:      dockerImage,
-		StartCommand:         startCommand,
-		AppArgs:              appArgs,
-		EnvironmentVariables: factory.buildEnvironment(envVarsFlag, name),
-		Privileged:           context.Bool("run-as-root"),
-		Monitor:              monitorConfig,
-		Instances:            instancesFlag,
-		CPUWeight:            cpuWeightFlag,
-		MemoryMB:             memoryMBFlag,
-		DiskMB:               diskMBFlag,
-		ExposedPorts:         exposedPorts,
+	routeOptions, err := parseRouteOptions(context.StringSlice("route-option"))
+	if err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	sidecars, err := parseSidecars(context.StringSlice("sidecar"))
+	if err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	labels, err := parseLabels(context.StringSlice("label"), false)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var imageEnv []string
+	if !context.Bool("ignore-image-env") {
+		imageEnv = imageMetadata.Env
+	}
+
+	environment, err := factory.buildEnvironment(envVarsFlag, name, imageEnv)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if err := factory.addSecretsToEnvironment(environment, secretEnvFlag); err != nil {
+		factory.ui.Say(err.Error())
+		if err.Error() == MalformedSecretEnvErrorMessage {
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		} else {
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+		}
+		return
+	}
+
+	factory.createDockerApp(docker_app_runner.CreateDockerAppParams{
+		Name:                 name,
+		DockerImagePath:      dockerImage,
+		StartCommand:         startCommand,
+		AppArgs:              appArgs,
+		EnvironmentVariables: environment,
+		Privileged:           context.Bool("run-as-root"),
+		Monitor:              monitorConfig,
+		Instances:            instancesFlag,
+		CPUWeight:            cpuWeightFlag,
+		MemoryMB:             memoryMBFlag,
+		DiskMB:               diskMBFlag,
+		ExposedPorts:         exposedPorts,
+		UDPPorts:             udpPorts,
 		WorkingDir:           workingDirFlag,
 		RouteOverrides:       routeOverrides,
+		RouteOptions:         routeOptions,
 		NoRoutes:             noRoutesFlag,
+		Sidecars:             sidecars,
+		Labels:               labels,
 		Timeout:              timeoutFlag,
+		NoWait:               context.Bool("no-wait"),
+		UpdateIfExists:       context.Bool("update-if-exists"),
+		DryRun:               context.Bool("dry-run"),
+		CleanupOnInterrupt:   context.Bool("cleanup-on-interrupt"),
+		VerifyRoute:          context.Bool("verify-route"),
+		Strict:               context.Bool("strict"),
 	})
+}
+
+func (factory *AppRunnerCommandFactory) createDockerApp(params docker_app_runner.CreateDockerAppParams) {
+	name := params.Name
+
+	appRunner := factory.appRunner
+	if params.DryRun {
+		appRunner = factory.dryRunAppRunner
+	}
+
+	// An app that already exists is left to --update-if-exists, which only
+	// recreates it when more than its instances change.
+	if !factory.checkCapacity(params.Strict, func(usage cluster_examiner.ClusterUsage) []cluster_examiner.Placement {
+		for _, app := range usage.Apps {
+			if app.Name == name {
+				return nil
+			}
+		}
+		return []cluster_examiner.Placement{{AppName: name, Instances: params.Instances, MemoryMB: params.MemoryMB, DiskMB: params.DiskMB}}
+	}) {
+		return
+	}
+
+	created := false
+	err := appRunner.CreateDockerApp(params)
+	if _, exists := err.(docker_app_runner.AppAlreadyExistsError); exists && params.UpdateIfExists {
+		if !factory.updateDockerApp(appRunner, params) {
+			return
+		}
+	} else if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error creating app: %s", err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	} else {
+		factory.ui.SayInfo("Creating App: " + name + "\n")
+		created = true
+	}
+
+	if params.DryRun {
+		factory.ui.SayLine(DryRunMessage)
+		return
+	}
+
+	if params.NoWait {
+		if !params.NoRoutes {
+			factory.ui.Say("App will be reachable at:\n")
+			factory.sayAppUrls(params)
+		}
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", name))
+		return
+	}
+
+	go factory.tailedLogsOutputter.OutputTailedLogs(name)
+	defer factory.tailedLogsOutputter.StopOutputting()
+
+	// Only an app this command created is offered for removal; one that
+	// --update-if-exists changed was there before.
+	cleanup := &interruptCleanup{factory: factory, appName: name, automatic: params.CleanupOnInterrupt, disarmed: !created}
+	factory.exitHandler.OnExit(cleanup.run)
+
+	exitCode := factory.pollUntilAllInstancesRunning(params.Timeout, name, params.Instances, "start")
+	cleanup.disarm()
+	if exitCode == exit_codes.PlacementError {
+		factory.exitHandler.Exit(exitCode)
+		return
+	} else if exitCode != 0 {
+		defer factory.exitHandler.Exit(exitCode)
+	}
+
+	if exitCode == 0 && params.VerifyRoute {
+		if exitCode = factory.verifyRoutes(params.Timeout, factory.appUrls(params)); exitCode != 0 {
+			defer factory.exitHandler.Exit(exitCode)
+		}
+	}
+
+	if params.NoRoutes {
+		if exitCode == 0 {
+			factory.ui.Say(colors.Green(name + " is now running.\n"))
+		}
+		return
+	} else if exitCode == 0 {
+		factory.ui.Say(colors.Green(name + " is now running.\n"))
+		factory.ui.Say("App is reachable at:\n")
+	} else {
+		factory.ui.Say("App will be reachable at:\n")
+	}
+
+	factory.sayAppUrls(params)
+}
+
+// verifyRoutes polls urls through the router until each of them answers
+// with something other than 502 Bad Gateway, which the router returns until
+// it has a running instance to send requests to.
+func (factory *AppRunnerCommandFactory) verifyRoutes(pollTimeout time.Duration, urls []string) int {
+	done := exit_handler.Done(factory.exitHandler)
+	unreachable := map[string]error{}
+	pending := urls
+
+	factory.ui.SayInfo("Verifying routes...")
+	ok := factory.pollUntilSuccess(done, pollTimeout, func() bool {
+		var stillPending []string
+		for _, url := range pending {
+			if err := factory.checkRoute(done, url); err != nil {
+				unreachable[url] = err
+				stillPending = append(stillPending, url)
+			}
+		}
+		pending = stillPending
+		return len(pending) == 0
+	}, progress.NewSpinner(factory.ui))
+
+	if !ok {
+		for _, url := range pending {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to be reachable: %s", url, unreachable[url])))
+		}
+		return exit_codes.Timeout
+	}
+	return 0
+}
+
+// errRouteRedirected stops the route client at the first redirect, which
+// is as much an answer from the app as any other response.
+var errRouteRedirected = errors.New("the route redirected")
+
+// checkRoute tells DNS failures apart from the router refusing connections
+// or having no instance to route to, as they have different fixes.  The
+// request is abandoned once cancel is closed.
+func (factory *AppRunnerCommandFactory) checkRoute(cancel <-chan struct{}, routeURL string) error {
+	request, err := http.NewRequest("GET", routeURL, nil)
 	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error creating app: %s", err))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return err
+	}
+	request.Cancel = cancel
+
+	response, err := factory.routeHTTPClient.Do(request)
+	if urlErr, ok := err.(*url.Error); ok && urlErr.Err == errRouteRedirected {
+		err = nil
+	}
+	if err != nil {
+		if dnsErr, ok := dnsError(err); ok {
+			return fmt.Errorf("DNS error: %s", dnsErr)
+		}
+		// Do wraps every error with the method and URL, which the caller
+		// already says.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("router error: %s", err)
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusBadGateway {
+		return errors.New("router error: 502 Bad Gateway")
+	}
+	return nil
+}
+
+// dnsError is the DNS failure behind an error from an HTTP client, if any.
+// Dialing wraps it in a *net.OpError, which the client wraps in a
+// *url.Error.
+func dnsError(err error) (*net.DNSError, bool) {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	dnsErr, ok := err.(*net.DNSError)
+	return dnsErr, ok
+}
+
+// interruptCleanup removes an app when ltc is interrupted while waiting for
+// it to start, asking first unless it is automatic.  It is disarmed once ltc
+// stops waiting, as any later exit is the command's own.
+type interruptCleanup struct {
+	factory   *AppRunnerCommandFactory
+	appName   string
+	automatic bool
+
+	mutex    sync.Mutex
+	disarmed bool
+}
+
+func (cleanup *interruptCleanup) run() {
+	cleanup.mutex.Lock()
+	defer cleanup.mutex.Unlock()
+
+	if cleanup.disarmed {
+		return
+	}
+	cleanup.disarmed = true
+
+	factory := cleanup.factory
+	factory.tailedLogsOutputter.StopOutputting()
+	factory.ui.SayNewLine()
+
+	// A prompt that can't be answered exits, which would run the exit
+	// handler again, so without a terminal the app is left as it was.
+	confirmed := cleanup.automatic || factory.ui.IsInteractive() && factory.ui.PromptForConfirmation(fmt.Sprintf("Remove the partially created %s?", cleanup.appName))
+	if !confirmed {
+		factory.ui.SayLine(fmt.Sprintf("%s was left as it was. To remove it:\n\tltc remove %s", cleanup.appName, cleanup.appName))
+		return
+	}
+
+	if err := factory.cleanupAppRunner.RemoveApp(cleanup.appName); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error removing %s: %s", cleanup.appName, err))
+		return
+	}
+	factory.ui.SayLine(fmt.Sprintf("Removed %s.", cleanup.appName))
+}
+
+func (cleanup *interruptCleanup) disarm() {
+	cleanup.mutex.Lock()
+	defer cleanup.mutex.Unlock()
+
+	cleanup.disarmed = true
+}
+
+// updateDockerApp reports whether the existing app changed, and so whether
+// to wait for its instances.
+func (factory *AppRunnerCommandFactory) updateDockerApp(appRunner docker_app_runner.AppRunner, params docker_app_runner.CreateDockerAppParams) bool {
+	update, err := appRunner.UpdateDockerApp(params)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error updating app: %s", err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return false
+	}
+
+	if len(update.ChangedFields) == 0 {
+		factory.ui.SayLine(colors.Green(params.Name + " is up to date."))
+		return false
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Updating App: %s (changed %s)\n", params.Name, strings.Join(update.ChangedFields, ", ")))
+	if update.Recreated {
+		factory.ui.SayInfo("Lattice can't change these in place, so the app was created again.\n")
+	}
+	return true
+}
+
+func (factory *AppRunnerCommandFactory) sayAppUrls(params docker_app_runner.CreateDockerAppParams) {
+	for _, url := range factory.appUrls(params) {
+		factory.ui.Say(colors.Green(url + "\n"))
+	}
+}
+
+func (factory *AppRunnerCommandFactory) appUrls(params docker_app_runner.CreateDockerAppParams) []string {
+	if params.RouteOverrides == nil {
+		return []string{factory.urlForApp(params.Name)}
+	}
+
+	var urls []string
+	for _, route := range params.RouteOverrides {
+		urls = append(urls, "http://"+route.Route(factory.domain))
+	}
+	return urls
+}
+
+func (factory *AppRunnerCommandFactory) inspectImage(context *cli.Context) {
+	dockerImage := context.Args().First()
+	if dockerImage == "" {
+		factory.ui.SayIncorrectUsage("DOCKER_IMAGE is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	imageMetadata, err := factory.dockerMetadataFetcher.FetchMetadata(dockerImage)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error fetching image metadata: %s", err))
+		factory.exitHandler.Exit(exit_codes.BadDocker)
+		return
+	}
+
+	if context.Bool("json") {
+		imageJson, err := json.MarshalIndent(imageMetadata, "", "  ")
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error encoding image metadata: %s", err))
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+		factory.ui.SayLine(string(imageJson))
+		return
+	}
+
+	var portStrings []string
+	for _, port := range imageMetadata.ExposedPorts {
+		portStrings = append(portStrings, strconv.Itoa(int(port)))
+	}
+	for _, port := range imageMetadata.UDPPorts {
+		portStrings = append(portStrings, fmt.Sprintf("%d/udp", port))
+	}
+
+	ports := strings.Join(portStrings, ", ")
+	if ports == "" {
+		ports = colors.Gray("none (ltc create defaults to 8080)")
+	}
+	workingDir := imageMetadata.WorkingDir
+	if workingDir == "" {
+		workingDir = colors.Gray("none (ltc create defaults to /)")
+	}
+	startCommand := strings.Join(imageMetadata.StartCommand, " ")
+	if startCommand == "" {
+		startCommand = colors.Gray("none (ltc create requires one after '--')")
+	}
+	user := imageMetadata.User
+	if user == "" {
+		user = colors.Gray("none")
+	}
+
+	w := tabwriter.NewWriter(factory.ui, 9, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "%s\t%s\n", "Image", colors.Bold(dockerImage))
+	fmt.Fprintf(w, "%s\t%s\n", "Ports", ports)
+	fmt.Fprintf(w, "%s\t%s\n", "Start Command", startCommand)
+	fmt.Fprintf(w, "%s\t%s\n", "Working Dir", workingDir)
+	fmt.Fprintf(w, "%s\t%s\n", "User", user)
+	if len(imageMetadata.Env) == 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Env", colors.Gray("none"))
+	}
+	for index, envVar := range imageMetadata.Env {
+		label := ""
+		if index == 0 {
+			label = "Env"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, envVar)
+	}
+	w.Flush()
+}
+
+func (factory *AppRunnerCommandFactory) submitLrp(context *cli.Context) {
+
+	filePath := context.Args().First()
+	if filePath == "" {
+		factory.ui.Say("Path to JSON is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	jsonBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		factory.ui.Say(fmt.Sprintf("Error reading file: %s", err.Error()))
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	lrpName, err := factory.appRunner.SubmitLrp(jsonBytes)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error creating %s: %s", lrpName, err.Error()))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.Say(colors.Green(fmt.Sprintf("Successfully submitted %s.", lrpName)) + "\n")
+	factory.ui.SayInfo(fmt.Sprintf("To view the status of your application: ltc status %s\n", lrpName))
+}
+
+func (factory *AppRunnerCommandFactory) scaleApp(c *cli.Context) {
+	appName := c.Args().First()
+	instancesArg := c.Args().Get(1)
+	timeoutFlag := c.Duration("timeout")
+	selectorFlag := c.String("selector")
+	if selectorFlag != "" {
+		if len(c.Args()) != 1 {
+			factory.ui.SayIncorrectUsage("Please enter 'ltc scale --selector KEY=VALUE NUMBER_OF_INSTANCES'")
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		appName, instancesArg = "", c.Args().First()
+	} else if appName == "" || instancesArg == "" {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc scale APP_NAME NUMBER_OF_INSTANCES'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	instances, err := strconv.Atoi(instancesArg)
+	if err != nil {
+		factory.ui.SayIncorrectUsage("Number of Instances must be an integer")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	batchFlag := c.Int("batch")
+	if batchFlag < 0 {
+		factory.ui.SayIncorrectUsage("Batch size must be a positive integer")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if batchFlag > 0 && c.Bool("no-wait") {
+		factory.ui.SayIncorrectUsage("--batch waits for each batch, so it cannot be combined with --no-wait")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var appNames []string
+	if selectorFlag != "" {
+		var selector app_examiner.Selector
+		if selector, err = app_examiner.ParseSelector(selectorFlag); err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		appNames, err = app_examiner.NewNameResolver(factory.appExaminer).ResolveSelector(selector)
+	} else {
+		appNames, err = app_examiner.NewNameResolver(factory.appExaminer).Resolve(appName)
+	}
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	appInstances := make(map[string]int, len(appNames))
+	for _, appName := range appNames {
+		appInstances[appName] = instances
+	}
+	if !factory.checkCapacity(c.Bool("strict"), scalePlacements(appNames, appInstances)) {
+		return
+	}
+
+	if c.Bool("dry-run") {
+		for _, appName := range appNames {
+			if err := factory.dryRunAppRunner.ScaleApp(appName, instances); err != nil {
+				factory.ui.SayLine(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
+				factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+				return
+			}
+		}
+		factory.ui.SayLine(DryRunMessage)
+		return
+	}
+
+	var exitCodes []int
+	if len(appNames) > 1 && batchFlag == 0 {
+		exitCodes = factory.scaleApps(timeoutFlag, c.Bool("no-wait"), appNames, appInstances)
+	} else {
+		for i, appName := range appNames {
+			if i > 0 {
+				factory.ui.SayNewLine()
+			}
+			if exitCode := factory.setAppInstances(timeoutFlag, c.Bool("no-wait"), appName, instances, batchFlag); exitCode != 0 {
+				exitCodes = append(exitCodes, exitCode)
+			}
+		}
+	}
+
+	if len(exitCodes) > 0 {
+		factory.exitHandler.Exit(aggregateExitCode(exitCodes))
+	}
+}
+
+func (factory *AppRunnerCommandFactory) resizeApp(c *cli.Context) {
+	appName := c.Args().First()
+	memorySet := c.IsSet("memory-mb") || c.IsSet("m")
+	diskSet := c.IsSet("disk-mb") || c.IsSet("d")
+	cpuWeightSet := c.IsSet("cpu-weight") || c.IsSet("c")
+	timeoutFlag := c.Duration("timeout")
+
+	if appName == "" || len(c.Args()) > 1 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc resize APP_NAME [--memory-mb=MEMORY_MB] [--disk-mb=DISK_MB] [--cpu-weight=CPU_WEIGHT]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if !memorySet && !diskSet && !cpuWeightSet {
+		factory.ui.SayIncorrectUsage("Pass --memory-mb, --disk-mb, --cpu-weight or a combination of them")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if c.Int("memory-mb") < 0 || c.Int("disk-mb") < 0 {
+		factory.ui.SayIncorrectUsage("Memory and disk limits can't be negative")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if cpuWeightSet && (c.Int("cpu-weight") < 1 || c.Int("cpu-weight") > 100) {
+		factory.ui.SayIncorrectUsage("CPU weight must be between 1 and 100")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	definition, err := factory.appRunner.AppDefinition(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error resizing %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	resized := definition
+	if memorySet {
+		resized.MemoryMB = c.Int("memory-mb")
+	}
+	if diskSet {
+		resized.DiskMB = c.Int("disk-mb")
+	}
+	if cpuWeightSet {
+		resized.CPUWeight = uint(c.Int("cpu-weight"))
+	}
+	if docker_app_runner.SameDefinition(resized, definition) {
+		factory.ui.SayLine(fmt.Sprintf("%s already has those limits.", appName))
+		return
+	}
+
+	recreating := fmt.Sprintf("Recreating %s with %d MB of memory and %d MB of disk...\n", appName, resized.MemoryMB, resized.DiskMB)
+	if cpuWeightSet {
+		recreating = fmt.Sprintf("Recreating %s with %d MB of memory, %d MB of disk and a CPU weight of %d...\n", appName, resized.MemoryMB, resized.DiskMB, resized.CPUWeight)
+	}
+	if factory.recreateApp("resize", resized, timeoutFlag, !c.Bool("recreate"), recreating) {
+		factory.ui.SayLine(colors.Green(fmt.Sprintf("Resized %s.", appName)))
+	}
+}
+
+// recreateApp replaces an app with updated, for changes Diego can't make to
+// a desired LRP in place.  Unless withStandIn is false, a copy of the updated
+// app first takes over the app's routes, so that requests are served while
+// the app is recreated.  verb names the command in messages.  recreateApp
+// reports whether the app is running as updated; if not, it has exited.
+func (factory *AppRunnerCommandFactory) recreateApp(verb string, updated receptor.DesiredLRPCreateRequest, pollTimeout time.Duration, withStandIn bool, recreating string) bool {
+	appName := updated.ProcessGuid
+	instances := updated.Instances
+	standInName := ""
+	if instances > 0 && withStandIn {
+		var ok bool
+		if standInName, ok = factory.startStandIn(verb, pollTimeout, updated); !ok {
+			return false
+		}
+	}
+
+	// failed leaves the stand-in serving, since the app may not be.
+	failed := func(exitCode int) bool {
+		if standInName != "" {
+			factory.ui.SayLine(fmt.Sprintf("%s is still serving %s's routes.  Remove it with 'ltc remove %s' once %s is running.", standInName, appName, standInName, appName))
+		}
+		factory.exitHandler.Exit(exitCode)
+		return false
+	}
+
+	factory.ui.SayInfo(recreating)
+	if _, err := factory.appRunner.RestoreApp(updated); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error %s %s: %s", gerund(verb), appName, err))
+		return failed(exit_codes.ForError(err, exit_codes.CommandFailed))
+	}
+
+	if instances > 0 {
+		if exitCode := factory.pollUntilAllInstancesRunning(pollTimeout, appName, instances, pollingStart); exitCode != 0 {
+			return failed(exitCode)
+		}
+	}
+
+	if standInName != "" {
+		if err := factory.appRunner.RemoveApp(standInName); err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error removing %s: %s", standInName, err))
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return false
+		}
+	}
+	return true
+}
+
+// startStandIn runs a copy of the updated app alongside it, named after the
+// app and the verb, as in APP_NAME-resizing, and on the same routes.  The
+// copy logs as the app but keeps its own metrics.  If the copy can't be
+// started it is removed again, leaving the app as it was.
+func (factory *AppRunnerCommandFactory) startStandIn(verb string, pollTimeout time.Duration, updated receptor.DesiredLRPCreateRequest) (string, bool) {
+	appName := updated.ProcessGuid
+	standIn := updated
+	standIn.ProcessGuid = appName + "-" + gerund(verb)
+	standIn.MetricsGuid = standIn.ProcessGuid
+
+	if exists, err := factory.appExaminer.AppExists(standIn.ProcessGuid); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error %s %s: %s", gerund(verb), appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return "", false
+	} else if exists {
+		factory.ui.SayLine(fmt.Sprintf("%s already exists. Remove it, or %s %s with --recreate.", standIn.ProcessGuid, verb, appName))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return "", false
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Starting %s to serve %s while it is recreated...\n", standIn.ProcessGuid, appName))
+	if _, err := factory.appRunner.RestoreApp(standIn); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error %s %s: %s", gerund(verb), appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return "", false
+	}
+
+	if exitCode := factory.pollUntilAllInstancesRunning(pollTimeout, standIn.ProcessGuid, standIn.Instances, pollingStart); exitCode != 0 {
+		factory.appRunner.RemoveApp(standIn.ProcessGuid)
+		factory.ui.SayLine(fmt.Sprintf("%s was left as it was.", appName))
+		factory.exitHandler.Exit(exitCode)
+		return "", false
+	}
+
+	return standIn.ProcessGuid, true
+}
+
+func (factory *AppRunnerCommandFactory) stopApp(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc stop APP_NAME'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := factory.appRunner.StopApp(appName); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error stopping %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if c.Bool("no-wait") {
+		factory.ui.SayInfo(fmt.Sprintf("Stopping %s.\n", appName))
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", appName))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Stopping %s...", appName))
+	ok := factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), c.Duration("timeout"), func() bool {
+		counts, err := factory.appExaminer.AppInstanceCounts(appName)
+		return err == nil && counts.Running == 0
+	}, progress.NewSpinner(factory.ui))
+
+	if !ok {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to stop.", appName)))
+		factory.ui.SayLine("Lattice is still stopping its instances in the background.")
+		factory.exitHandler.Exit(exit_codes.Timeout)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("Stopped %s.", appName)))
+	factory.ui.SayInfo(fmt.Sprintf("To start it again:\n\tltc start %s\n", appName))
+}
+
+func (factory *AppRunnerCommandFactory) startApp(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc start APP_NAME'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	instances, err := factory.appRunner.StartApp(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error starting %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Starting %s with %d instances\n", appName, instances))
+
+	if c.Bool("no-wait") {
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", appName))
+		return
+	}
+
+	if exitCode := factory.pollUntilAllInstancesRunning(c.Duration("timeout"), appName, instances, pollingStart); exitCode != 0 {
+		factory.exitHandler.Exit(exitCode)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green("App Started Successfully"))
+}
+
+func (factory *AppRunnerCommandFactory) restartInstance(c *cli.Context) {
+	appName, index, err := app_examiner.ParseInstance(c.Args().First())
+	if err != nil || len(c.Args()) != 1 || index == app_examiner.NoInstance {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc restart APP_NAME/INDEX'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appName, err = app_examiner.NewNameResolver(factory.appExaminer).ResolveOne(appName)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	appInfo, err := factory.appExaminer.AppStatus(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error restarting %s/%d: %s", appName, index, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	previousGuid := instanceGuidAt(appInfo, index)
+
+	if err := factory.appRunner.RestartInstance(appName, index); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error restarting %s/%d: %s", appName, index, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if c.Bool("no-wait") {
+		factory.ui.SayInfo(fmt.Sprintf("Restarting %s/%d.\n", appName, index))
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s/%d\n", appName, index))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Restarting %s/%d...", appName, index))
+	ok := factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), c.Duration("timeout"), func() bool {
+		appInfo, err := factory.appExaminer.AppStatus(appName)
+		if err != nil {
+			return false
+		}
+		for _, instance := range appInfo.ActualInstances {
+			if instance.Index == index {
+				return instance.State == "RUNNING" && instance.InstanceGuid != previousGuid
+			}
+		}
+		return false
+	}, progress.NewSpinner(factory.ui))
+
+	if !ok {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s/%d to restart.", appName, index)))
+		factory.ui.SayLine(fmt.Sprintf("To view logs:\n\tltc logs %s/%d", appName, index))
+		factory.exitHandler.Exit(exit_codes.Timeout)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("Restarted %s/%d.", appName, index)))
+}
+
+// instanceGuidAt returns the guid of the instance at index, so that its
+// replacement can be told apart from it.
+func instanceGuidAt(appInfo app_examiner.AppInfo, index int) string {
+	for _, instance := range appInfo.ActualInstances {
+		if instance.Index == index {
+			return instance.InstanceGuid
+		}
+	}
+	return ""
+}
+
+func (factory *AppRunnerCommandFactory) rollbackApp(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc rollback APP_NAME'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	entries, err := factory.auditLog.Entries()
+	if err != nil {
+		factory.ui.SayLine("Error reading the history: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	exists, err := factory.appExaminer.AppExists(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error rolling back %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	var current *receptor.DesiredLRPCreateRequest
+	if exists {
+		definition, err := factory.appRunner.AppDefinition(appName)
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error rolling back %s: %s", appName, err))
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+		current = &definition
+	}
+
+	revision, ok := factory.previousRevision(entries, appName, current)
+	if !ok {
+		factory.ui.SayLine(fmt.Sprintf("No earlier revision of %s is recorded for %s.", appName, factory.domain))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+
+	created, err := factory.appRunner.RestoreApp(*revision.Revision)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error rolling back %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Rolling %s back to its configuration from %s (ltc %s)\n", appName, revision.Time.Local().Format("2006-01-02 15:04:05"), strings.Join(revision.Args, " ")))
+
+	instances := revision.Revision.Instances
+	if c.Bool("no-wait") || instances == 0 {
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", appName))
+		return
+	}
+
+	action := pollingScale
+	if created {
+		action = pollingStart
+	}
+	if exitCode := factory.pollUntilAllInstancesRunning(c.Duration("timeout"), appName, instances, action); exitCode != 0 {
+		factory.exitHandler.Exit(exitCode)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("Rolled back %s.", appName)))
+}
+
+// previousRevision finds the most recent revision of the app recorded for
+// the current target that differs from current, which is nil when the app
+// no longer exists.
+func (factory *AppRunnerCommandFactory) previousRevision(entries []audit.Entry, appName string, current *receptor.DesiredLRPCreateRequest) (audit.Entry, bool) {
+	for index := len(entries) - 1; index >= 0; index-- {
+		entry := entries[index]
+		if !entry.Succeeded() || entry.Target != factory.domain || entry.Revision == nil || entry.Revision.ProcessGuid != appName {
+			continue
+		}
+		if current == nil || !docker_app_runner.SameDefinition(*entry.Revision, *current) {
+			return entry, true
+		}
+	}
+	return audit.Entry{}, false
+}
+
+func (factory *AppRunnerCommandFactory) updateAppRoutes(c *cli.Context) {
+	appName := c.Args().First()
+	userDefinedRoutes := c.Args().Get(1)
+	noRoutesFlag := c.Bool("no-routes")
+
+	if appName == "" || (userDefinedRoutes == "" && !noRoutesFlag) {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	desiredRoutes := docker_app_runner.RouteOverrides{}
+	var err error
+	if !noRoutesFlag {
+		desiredRoutes, err = parseRouteOverrides(userDefinedRoutes)
+		if err != nil {
+			factory.ui.Say(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+
+	appRunner := factory.appRunner
+	if c.Bool("dry-run") {
+		appRunner = factory.dryRunAppRunner
+	}
+
+	err = appRunner.UpdateAppRoutes(appName, desiredRoutes)
+	if err != nil {
+		factory.ui.Say(fmt.Sprintf("Error updating routes: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if c.Bool("dry-run") {
+		factory.ui.SayLine(DryRunMessage)
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Updating %s routes. You can check this app's current routes by running 'ltc status %s'", appName, appName))
+}
+
+// mapRoute adds a single route to the app, leaving its other routes as they
+// are, unlike update-routes which replaces them all.
+func (factory *AppRunnerCommandFactory) mapRoute(c *cli.Context) {
+	appName, route, ok := factory.parseRouteArgs(c, "map-route")
+	if !ok {
+		return
+	}
+
+	if err := factory.appRunner.AddRoute(appName, route); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error mapping route: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Mapped %s to port %d of %s.", route.Route(factory.domain), route.Port, appName))
+}
+
+func (factory *AppRunnerCommandFactory) unmapRoute(c *cli.Context) {
+	appName, route, ok := factory.parseRouteArgs(c, "unmap-route")
+	if !ok {
+		return
+	}
+
+	if err := factory.appRunner.RemoveRoute(appName, route); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error unmapping route: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Unmapped %s from port %d of %s.", route.Route(factory.domain), route.Port, appName))
+}
+
+func (factory *AppRunnerCommandFactory) labelApp(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" || len(c.Args()) < 2 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc label APP_NAME KEY=VALUE... [KEY-...]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	labels, err := parseLabels(c.Args()[1:], true)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := factory.appRunner.UpdateAppLabels(appName, labels); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error labelling app: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Updated the labels of %s.", appName))
+}
+
+func (factory *AppRunnerCommandFactory) bindLogDrain(c *cli.Context) {
+	appName, drainURL, ok := factory.parseLogDrainArgs(c, "bind-log-drain")
+	if !ok {
+		return
+	}
+
+	if _, err := log_drain.ParseURL(drainURL); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := factory.appRunner.BindLogDrain(appName, drainURL); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error binding log drain: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Bound %s to %s.", drainURL, appName))
+	factory.ui.SayLine("Its logs are forwarded while 'ltc drain-logs' is running.")
+}
+
+func (factory *AppRunnerCommandFactory) unbindLogDrain(c *cli.Context) {
+	appName, drainURL, ok := factory.parseLogDrainArgs(c, "unbind-log-drain")
+	if !ok {
+		return
+	}
+
+	if err := factory.appRunner.UnbindLogDrain(appName, drainURL); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error unbinding log drain: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Unbound %s from %s.", drainURL, appName))
+}
+
+func (factory *AppRunnerCommandFactory) listLogDrains(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" || len(c.Args()) > 1 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc log-drains APP_NAME'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appInfo, err := factory.appExaminer.AppStatus(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error getting the log drains of %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if len(appInfo.LogDrains) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("%s has no log drains.", appName))
+		return
+	}
+	for _, drainURL := range appInfo.LogDrains {
+		factory.ui.SayLine(drainURL)
+	}
+}
+
+func (factory *AppRunnerCommandFactory) parseLogDrainArgs(c *cli.Context, commandName string) (string, string, bool) {
+	if len(c.Args()) != 2 || c.Args()[0] == "" || c.Args()[1] == "" {
+		factory.ui.SayIncorrectUsage(fmt.Sprintf("Please enter 'ltc %s APP_NAME DRAIN_URL'", commandName))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return "", "", false
+	}
+	return c.Args()[0], c.Args()[1], true
+}
+
+func (factory *AppRunnerCommandFactory) showEnv(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" || len(c.Args()) > 1 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc env APP_NAME [--show-secrets]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	definition, err := factory.appRunner.AppDefinition(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error getting the environment of %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	envVars := append([]receptor.EnvironmentVariable{}, definition.EnvironmentVariables...)
+	sort.Sort(envVarsByName(envVars))
+
+	masked := false
+	for _, envVar := range envVars {
+		value := envVar.Value
+		if !c.Bool("show-secrets") && secrets.LooksSecret(envVar.Name) {
+			value = secrets.MaskedValue
+			masked = true
+		}
+		factory.ui.SayLine(fmt.Sprintf("%s=%s", envVar.Name, value))
+	}
+
+	if masked {
+		factory.ui.SayNewLine()
+		factory.ui.SayLine("Values that look like secrets are masked.  Pass --show-secrets to show them.")
+	}
+}
+
+func (factory *AppRunnerCommandFactory) setEnv(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" || len(c.Args()) < 2 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc set-env APP_NAME NAME=VALUE... [NAME...]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	changes, err := factory.parseEnvVars(c.Args()[1:])
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if _, ok := changes["PROCESS_GUID"]; ok {
+		factory.ui.SayLine(ReservedEnvVarErrorMessage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	factory.updateEnv(c, appName, func(envVars []receptor.EnvironmentVariable) ([]receptor.EnvironmentVariable, error) {
+		updated := make([]receptor.EnvironmentVariable, 0, len(envVars)+len(changes))
+		for _, envVar := range envVars {
+			if value, ok := changes[envVar.Name]; ok {
+				envVar.Value = value
+				delete(changes, envVar.Name)
+			}
+			updated = append(updated, envVar)
+		}
+
+		added := make([]receptor.EnvironmentVariable, 0, len(changes))
+		for name, value := range changes {
+			added = append(added, receptor.EnvironmentVariable{Name: name, Value: value})
+		}
+		sort.Sort(envVarsByName(added))
+		return append(updated, added...), nil
+	})
+}
+
+func (factory *AppRunnerCommandFactory) unsetEnv(c *cli.Context) {
+	appName := c.Args().First()
+	if appName == "" || len(c.Args()) < 2 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc unset-env APP_NAME NAME...'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	removals := map[string]bool{}
+	for _, name := range c.Args()[1:] {
+		if name == "PROCESS_GUID" {
+			factory.ui.SayLine(ReservedEnvVarErrorMessage)
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		removals[name] = true
+	}
+
+	factory.updateEnv(c, appName, func(envVars []receptor.EnvironmentVariable) ([]receptor.EnvironmentVariable, error) {
+		updated := make([]receptor.EnvironmentVariable, 0, len(envVars))
+		for _, envVar := range envVars {
+			if removals[envVar.Name] {
+				delete(removals, envVar.Name)
+				continue
+			}
+			updated = append(updated, envVar)
+		}
+
+		for _, name := range c.Args()[1:] {
+			if removals[name] {
+				return nil, fmt.Errorf("%s has no env var %s.", appName, name)
+			}
+		}
+		return updated, nil
+	})
+}
+
+// updateEnv recreates the app with the environment returned by change,
+// behind a stand-in unless --recreate is passed.
+func (factory *AppRunnerCommandFactory) updateEnv(c *cli.Context, appName string, change func([]receptor.EnvironmentVariable) ([]receptor.EnvironmentVariable, error)) {
+	definition, err := factory.appRunner.AppDefinition(appName)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error updating %s: %s", appName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	updated := definition
+	if updated.EnvironmentVariables, err = change(definition.EnvironmentVariables); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+	if docker_app_runner.SameDefinition(updated, definition) {
+		factory.ui.SayLine(fmt.Sprintf("%s already has that environment.", appName))
+		return
+	}
+
+	recreating := fmt.Sprintf("Recreating %s with the new environment...\n", appName)
+	if factory.recreateApp("update", updated, c.Duration("timeout"), !c.Bool("recreate"), recreating) {
+		factory.ui.SayLine(colors.Green(fmt.Sprintf("Updated the environment of %s.", appName)))
+	}
+}
+
+func (factory *AppRunnerCommandFactory) parseRouteArgs(c *cli.Context, commandName string) (string, docker_app_runner.RouteOverride, bool) {
+	appName := c.Args().First()
+	routeArg := c.Args().Get(1)
+
+	if appName == "" || routeArg == "" {
+		factory.ui.SayIncorrectUsage(fmt.Sprintf("Please enter 'ltc %s APP_NAME PORT:ROUTE'", commandName))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return "", docker_app_runner.RouteOverride{}, false
+	}
+
+	routes, err := parseRouteOverrides(routeArg)
+	if err != nil || len(routes) != 1 {
+		factory.ui.SayLine(MalformedRouteErrorMessage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return "", docker_app_runner.RouteOverride{}, false
+	}
+
+	return appName, routes[0], true
+}
+
+// setAppInstances returns 0 once the app is scaled, or the exit code the
+// command should finish with.  A non-zero batchSize scales up in steps of
+// that many instances, each of which must be running before the next.
+func (factory *AppRunnerCommandFactory) setAppInstances(pollTimeout time.Duration, noWait bool, appName string, instances, batchSize int) int {
+	if batchSize > 0 {
+		appInfo, err := factory.appExaminer.AppStatus(appName)
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
+			if err.Error() == app_examiner.AppNotFoundErrorMessage {
+				return exit_codes.NotFound
+			}
+			return exit_codes.ForError(err, exit_codes.CommandFailed)
+		}
+
+		for batch := appInfo.DesiredInstances + batchSize; batch < instances; batch += batchSize {
+			if exitCode := factory.scaleAndWait(pollTimeout, false, appName, batch); exitCode != 0 {
+				return exitCode
+			}
+		}
+	}
+
+	if exitCode := factory.scaleAndWait(pollTimeout, noWait, appName, instances); exitCode != 0 || noWait {
+		return exitCode
+	}
+
+	factory.ui.Say(colors.Green("App Scaled Successfully"))
+	return 0
+}
+
+func (factory *AppRunnerCommandFactory) scaleAndWait(pollTimeout time.Duration, noWait bool, appName string, instances int) int {
+	err := factory.appRunner.ScaleApp(appName, instances)
+
+	if err != nil {
+		factory.ui.Say(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
+		return exit_codes.ForError(err, exit_codes.CommandFailed)
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Scaling %s to %d instances \n", appName, instances))
+
+	if noWait {
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", appName))
+		return 0
+	}
+
+	return factory.pollUntilAllInstancesRunning(pollTimeout, appName, instances, pollingScale)
+}
+
+// scaleApps scales several apps at once, each to its own number of
+// instances, and waits for all of them together rather than one after
+// another.  It returns the exit codes of the apps that failed.
+func (factory *AppRunnerCommandFactory) scaleApps(pollTimeout time.Duration, noWait bool, appNames []string, instances map[string]int) []int {
+	scaleErrors := forEachApp(appNames, func(appName string) error {
+		return factory.appRunner.ScaleApp(appName, instances[appName])
+	})
+
+	var exitCodes []int
+	var scaling []string
+	for i, appName := range appNames {
+		if err := scaleErrors[i]; err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error Scaling App to %d instances: %s", instances[appName], err))
+			exitCodes = append(exitCodes, exit_codes.ForError(err, exit_codes.CommandFailed))
+		} else {
+			factory.ui.SayInfo(fmt.Sprintf("Scaling %s to %d instances\n", appName, instances[appName]))
+			scaling = append(scaling, appName)
+		}
+	}
+
+	if noWait || len(scaling) == 0 {
+		if len(scaling) > 0 {
+			factory.ui.SayInfo("To view status:\n\tltc list\n")
+		}
+		return exitCodes
+	}
+
+	appExitCodes := factory.pollUntilAllAppsRunning(pollTimeout, scaling, instances)
+
+	timedOut := false
+	for _, appName := range scaling {
+		switch appExitCodes[appName] {
+		case exit_codes.PlacementError:
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Error, %s", docker_app_runner.InsufficientResourcesError{AppName: appName})))
+		case exit_codes.Timeout:
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to scale.", appName)))
+			timedOut = true
+		default:
+			continue
+		}
+		exitCodes = append(exitCodes, appExitCodes[appName])
+	}
+	if timedOut {
+		factory.ui.SayLine("Lattice is still scaling your apps in the background.")
+	}
+
+	scaled := len(appNames) - len(exitCodes)
+	summary := fmt.Sprintf("Scaled %d of %d apps.", scaled, len(appNames))
+	if scaled == len(appNames) {
+		factory.ui.SayLine(colors.Green(summary))
+	} else {
+		factory.ui.SayLine(colors.Red(summary))
+	}
+
+	return exitCodes
+}
+
+func (factory *AppRunnerCommandFactory) removeApp(c *cli.Context) {
+	appNames := []string(c.Args())
+	allFlag := c.Bool("all")
+	selectorFlag := c.String("selector")
+
+	if allFlag && len(appNames) > 0 {
+		factory.ui.SayIncorrectUsage("Pass either app names or --all, not both")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if selectorFlag != "" && (allFlag || len(appNames) > 0) {
+		factory.ui.SayIncorrectUsage("Pass only one of app names, --selector or --all")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if selectorFlag != "" {
+		selector, err := app_examiner.ParseSelector(selectorFlag)
+		if err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		appNames, err = app_examiner.NewNameResolver(factory.appExaminer).ResolveSelector(selector)
+		if err != nil {
+			factory.ui.SayLine(err.Error())
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+	} else if allFlag {
+		appList, err := factory.appExaminer.ListApps()
+		if err != nil {
+			factory.ui.SayLine("Error listing apps: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+		for _, app := range appList {
+			appNames = append(appNames, app.ProcessGuid)
+		}
+		if len(appNames) == 0 {
+			factory.ui.SayLine("No apps to remove.")
+			return
+		}
+	} else if len(appNames) == 0 {
+		factory.ui.SayIncorrectUsage("App Name required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
+	} else {
+		var err error
+		appNames, err = app_examiner.NewNameResolver(factory.appExaminer).Resolve(appNames...)
+		if err != nil {
+			factory.ui.SayLine(err.Error())
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
 	}
 
-	factory.ui.Say("Creating App: " + name + "\n")
-
-	go factory.tailedLogsOutputter.OutputTailedLogs(name)
-	defer factory.tailedLogsOutputter.StopOutputting()
+	factory.removeApps(c, appNames)
+}
 
-	ok := factory.pollUntilAllInstancesRunning(timeoutFlag, name, instancesFlag, "start")
+// removeApps removes the named apps as the remove command's flags ask.
+func (factory *AppRunnerCommandFactory) removeApps(c *cli.Context, appNames []string) {
+	if c.Bool("dry-run") {
+		for _, appName := range appNames {
+			if err := factory.dryRunAppRunner.RemoveApp(appName); err != nil {
+				factory.ui.SayLine(fmt.Sprintf("Error stopping %s: %s", appName, err))
+				factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+				return
+			}
+		}
+		factory.ui.SayLine(DryRunMessage)
+		return
+	}
 
-	if noRoutesFlag {
-		factory.ui.Say(colors.Green(name + " is now running.\n"))
+	if !c.Bool("force") && !factory.ui.PromptForConfirmation(fmt.Sprintf("Really remove %s?", strings.Join(appNames, ", "))) {
+		factory.ui.SayLine("App removal cancelled.")
 		return
-	} else if ok {
-		factory.ui.Say(colors.Green(name + " is now running.\n"))
-		factory.ui.Say("App is reachable at:\n")
-	} else {
-		factory.ui.Say("App will be reachable at:\n")
 	}
 
-	if routeOverrides != nil {
-		for _, route := range strings.Split(routesFlag, ",") {
-			factory.ui.Say(colors.Green(factory.urlForApp(strings.Split(route, ":")[1])))
+	timeoutFlag := c.Duration("timeout")
+	noWaitFlag := c.Bool("no-wait")
+
+	factory.ui.SayInfo(fmt.Sprintf("Removing %s...", strings.Join(appNames, ", ")))
+
+	removeErrors := forEachApp(appNames, factory.appRunner.RemoveApp)
+
+	stopping := make(map[string]bool)
+	for i, appName := range appNames {
+		if removeErrors[i] == nil {
+			stopping[appName] = true
 		}
+	}
+
+	if noWaitFlag {
+		factory.ui.SayNewLine()
 	} else {
-		factory.ui.Say(colors.Green(factory.urlForApp(name)))
+		defer factory.watchApps()()
+		factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), timeoutFlag, func() bool {
+			instanceCounts, err := factory.appExaminer.InstanceCountsByApp()
+			if err != nil {
+				return false
+			}
+			for appName := range stopping {
+				if _, exists := instanceCounts[appName]; !exists {
+					delete(stopping, appName)
+				}
+			}
+			return len(stopping) == 0
+		}, progress.NewSpinner(factory.ui))
 	}
-}
 
-func (factory *AppRunnerCommandFactory) submitLrp(context *cli.Context) {
+	var exitCodes []int
+	removed := 0
+	for i, appName := range appNames {
+		if err := removeErrors[i]; err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error stopping %s: %s", appName, err))
+			exitCodes = append(exitCodes, exit_codes.ForError(err, exit_codes.CommandFailed))
+		} else if stopping[appName] && !noWaitFlag {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to stop.", appName)))
+			exitCodes = append(exitCodes, exit_codes.Timeout)
+		} else {
+			removed++
+		}
+	}
+	if len(stopping) > 0 && !noWaitFlag {
+		factory.ui.SayLine("Lattice is still stopping its instances in the background.")
+	}
 
-	filePath := context.Args().First()
-	if filePath == "" {
-		factory.ui.Say("Path to JSON is required")
-		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
-		return
+	if len(appNames) > 1 {
+		summary := fmt.Sprintf("Removed %d of %d apps.", removed, len(appNames))
+		if removed == len(appNames) {
+			factory.ui.SayLine(colors.Green(summary))
+		} else {
+			factory.ui.SayLine(colors.Red(summary))
+		}
 	}
 
-	jsonBytes, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error reading file: %s", err.Error()))
-		factory.exitHandler.Exit(exit_codes.FileSystemError)
-		return
+	if len(exitCodes) > 0 {
+		factory.exitHandler.Exit(aggregateExitCode(exitCodes))
+	}
+}
+
+// maxConcurrentRequests bounds how many apps a command sends requests for at
+// once, so that commands over many apps don't flood the receptor.
+const maxConcurrentRequests = 8
+
+// forEachApp calls action for every app on a bounded pool of goroutines and
+// returns the errors in the same order as appNames.
+func forEachApp(appNames []string, action func(appName string) error) []error {
+	errs := make([]error, len(appNames))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < maxConcurrentRequests && worker < len(appNames); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = action(appNames[i])
+			}
+		}()
 	}
 
-	lrpName, err := factory.appRunner.SubmitLrp(jsonBytes)
-	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error creating %s: %s", lrpName, err.Error()))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
-		return
+	for i := range appNames {
+		indexes <- i
 	}
+	close(indexes)
+	wg.Wait()
 
-	factory.ui.Say(colors.Green(fmt.Sprintf("Successfully submitted %s.", lrpName)) + "\n")
-	factory.ui.Say(fmt.Sprintf("To view the status of your application: ltc status %s\n", lrpName))
+	return errs
 }
 
-func (factory *AppRunnerCommandFactory) scaleApp(c *cli.Context) {
+// aggregateExitCode reports a shared failure as is, and anything mixed as a
+// general failure.
+func aggregateExitCode(exitCodes []int) int {
+	for _, exitCode := range exitCodes[1:] {
+		if exitCode != exitCodes[0] {
+			return exit_codes.CommandFailed
+		}
+	}
+	return exitCodes[0]
+}
+
+func (factory *AppRunnerCommandFactory) waitForApp(c *cli.Context) {
 	appName := c.Args().First()
-	instancesArg := c.Args().Get(1)
-	timeoutFlag := c.Duration("timeout")
-	if appName == "" || instancesArg == "" {
-		factory.ui.SayIncorrectUsage("Please enter 'ltc scale APP_NAME NUMBER_OF_INSTANCES'")
+	state := c.String("state")
+	instances := c.Int("instances")
+	if appName == "" {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc wait APP_NAME'")
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
-
-	instances, err := strconv.Atoi(instancesArg)
-	if err != nil {
-		factory.ui.SayIncorrectUsage("Number of Instances must be an integer")
+	if state != "running" && state != "stopped" {
+		factory.ui.SayIncorrectUsage("--state must be running or stopped")
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
-
-	factory.setAppInstances(timeoutFlag, appName, instances)
-}
-
-func (factory *AppRunnerCommandFactory) updateAppRoutes(c *cli.Context) {
-	appName := c.Args().First()
-	userDefinedRoutes := c.Args().Get(1)
-	noRoutesFlag := c.Bool("no-routes")
-
-	if appName == "" || (userDefinedRoutes == "" && !noRoutesFlag) {
-		factory.ui.SayIncorrectUsage("Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag.")
+	if instances < 0 {
+		factory.ui.SayIncorrectUsage("--instances must not be negative")
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
-
-	desiredRoutes := docker_app_runner.RouteOverrides{}
-	var err error
-	if !noRoutesFlag {
-		desiredRoutes, err = parseRouteOverrides(userDefinedRoutes)
-		if err != nil {
-			factory.ui.Say(err.Error())
-			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
-			return
-		}
+	if state == "stopped" && (instances > 0 || c.Bool("routes")) {
+		factory.ui.SayIncorrectUsage("--instances and --routes only apply to --state running")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
 	}
 
-	err = factory.appRunner.UpdateAppRoutes(appName, desiredRoutes)
+	appInfo, err := factory.appExaminer.AppStatus(appName)
 	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error updating routes: %s", err))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.ui.SayLine(fmt.Sprintf("Error waiting for %s: %s", appName, err))
+		if err.Error() == app_examiner.AppNotFoundErrorMessage {
+			factory.exitHandler.Exit(exit_codes.NotFound)
+			return
+		}
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 		return
 	}
 
-	factory.ui.Say(fmt.Sprintf("Updating %s routes. You can check this app's current routes by running 'ltc status %s'", appName, appName))
+	var exitCode int
+	if state == "stopped" {
+		exitCode = factory.waitUntilStopped(c.Duration("timeout"), appName)
+	} else {
+		if instances == 0 {
+			instances = appInfo.DesiredInstances
+		}
+		var hostnames []string
+		if c.Bool("routes") {
+			hostnames = routeHostnames(appInfo.Routes)
+		}
+		exitCode = factory.waitUntilRunning(c.Duration("timeout"), appName, instances, hostnames)
+	}
+	if exitCode != 0 {
+		factory.exitHandler.Exit(exitCode)
+	}
 }
 
-func (factory *AppRunnerCommandFactory) setAppInstances(pollTimeout time.Duration, appName string, instances int) {
-	err := factory.appRunner.ScaleApp(appName, instances)
+func (factory *AppRunnerCommandFactory) waitUntilStopped(pollTimeout time.Duration, appName string) int {
+	factory.ui.SayInfo(fmt.Sprintf("Waiting for %s to stop...", appName))
+	ok := factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), pollTimeout, func() bool {
+		counts, err := factory.appExaminer.AppInstanceCounts(appName)
+		return err == nil && counts.Running == 0
+	}, progress.NewSpinner(factory.ui))
 
-	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error Scaling App to %d instances: %s", instances, err))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
-		return
+	if !ok {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to stop.", appName)))
+		factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", appName))
+		return exit_codes.Timeout
 	}
 
-	factory.ui.Say(fmt.Sprintf("Scaling %s to %d instances \n", appName, instances))
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("%s is stopped.", appName)))
+	return 0
+}
 
-	ok := factory.pollUntilAllInstancesRunning(pollTimeout, appName, instances, "scale")
+// waitUntilRunning waits for at least instances of the app to be running,
+// unlike pollUntilAllInstancesRunning, so that an app that has since been
+// scaled up still satisfies the wait.  hostnames are only looked up once
+// the instances are running, as the router doesn't serve them before then.
+func (factory *AppRunnerCommandFactory) waitUntilRunning(pollTimeout time.Duration, appName string, instances int, hostnames []string) int {
+	placementErrorOccurred := false
+	instancesRunning := false
+	unresolved := hostnames
+	progressBar := progress.NewBar(factory.ui, instances, "instances running")
+	estimator := progress.NewEstimator(factory.clock, instances)
+	ok := factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), pollTimeout, func() bool {
+		if !instancesRunning {
+			counts, err := factory.appExaminer.AppInstanceCounts(appName)
+			if err != nil {
+				return false
+			}
+			if counts.PlacementError {
+				placementErrorOccurred = true
+				return true
+			}
+			running := counts.Running
+			if running > instances {
+				running = instances
+			}
+			progressBar.SetCurrent(running)
+			progressBar.SetDetail(counts.NotRunning())
+			progressBar.SetRemaining(estimator.Remaining(running))
+			instancesRunning = running == instances
+		}
+		if instancesRunning {
+			unresolved = factory.unresolvedHostnames(unresolved)
+		}
+		return instancesRunning && len(unresolved) == 0
+	}, progressBar)
 
-	if ok {
-		factory.ui.Say(colors.Green("App Scaled Successfully"))
+	if placementErrorOccurred {
+		err := docker_app_runner.InsufficientResourcesError{AppName: appName}
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Error, %s", err)))
+		factory.ui.SayRemedy(err)
+		return exit_codes.PlacementError
+	} else if !ok {
+		if instancesRunning {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to resolve.", strings.Join(unresolved, ", "))))
+		} else {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %d instance(s) of %s to be running.", instances, appName)))
+			factory.ui.SayLine(fmt.Sprintf("To view logs:\n\tltc logs %s", appName))
+		}
+		factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", appName))
+		return exit_codes.Timeout
 	}
-}
 
-func (factory *AppRunnerCommandFactory) removeApp(c *cli.Context) {
-	appNames := c.Args()
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("%s is running.", appName)))
+	return 0
+}
 
-	if len(appNames) == 0 {
-		factory.ui.SayIncorrectUsage("App Name required")
-		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
-		return
+func (factory *AppRunnerCommandFactory) unresolvedHostnames(hostnames []string) []string {
+	var unresolved []string
+	for _, hostname := range hostnames {
+		if _, err := factory.lookupHost(hostname); err != nil {
+			unresolved = append(unresolved, hostname)
+		}
 	}
+	return unresolved
+}
 
-	for _, appName := range appNames {
-		factory.ui.SayLine(fmt.Sprintf("Removing %s...", appName))
-		err := factory.appRunner.RemoveApp(appName)
-		if err != nil {
-			factory.ui.SayLine(fmt.Sprintf("Error stopping %s: %s", appName, err))
-			factory.exitHandler.Exit(exit_codes.CommandFailed) // TODO: how to handle partial failure
+// routeHostnames returns the hosts the app's routes are served on, without
+// their paths.
+func routeHostnames(routes route_helpers.AppRoutes) []string {
+	var hostnames []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		for _, hostname := range route.Hostnames {
+			hostname = strings.SplitN(hostname, "/", 2)[0]
+			if !seen[hostname] {
+				seen[hostname] = true
+				hostnames = append(hostnames, hostname)
+			}
 		}
 	}
+	return hostnames
 }
 
-func (factory *AppRunnerCommandFactory) pollUntilSuccess(pollTimeout time.Duration, pollingFunc func() bool, outputProgress bool) (ok bool) {
+// pollUntilSuccess calls pollingFunc until it succeeds, the timeout elapses
+// or done is closed.  The wait between calls starts at the factory's poll
+// interval and doubles each time, up to its max poll interval, so that slow
+// apps don't keep the receptor busy.
+func (factory *AppRunnerCommandFactory) pollUntilSuccess(done <-chan struct{}, pollTimeout time.Duration, pollingFunc func() bool, indicator progress.Indicator) (ok bool) {
+	defer indicator.Finish()
+
+	interval := factory.pollInterval
 	startingTime := factory.clock.Now()
 	for startingTime.Add(pollTimeout).After(factory.clock.Now()) {
 		if result := pollingFunc(); result {
-			factory.ui.SayNewLine()
 			return true
-		} else if outputProgress {
-			factory.ui.Say(".")
+		}
+		indicator.Tick()
+
+		timer := factory.clock.NewTimer(interval)
+		select {
+		case <-timer.C():
+		case <-done:
+			timer.Stop()
+			return false
 		}
 
-		factory.clock.Sleep(1 * time.Second)
+		interval *= 2
+		if interval > factory.maxPollInterval {
+			interval = factory.maxPollInterval
+		}
 	}
-	factory.ui.SayNewLine()
 	return false
 }
 
-func (factory *AppRunnerCommandFactory) pollUntilAllInstancesRunning(pollTimeout time.Duration, appName string, instances int, action pollingAction) bool {
+// pollUntilAllInstancesRunning returns 0 once all instances are running, or
+// the exit code the command should finish with if they never come up.  Each
+// tick shows how many instances are running, starting and crashed, and how
+// long they should take at the rate they have been starting.
+func (factory *AppRunnerCommandFactory) pollUntilAllInstancesRunning(pollTimeout time.Duration, appName string, instances int, action pollingAction) int {
 	placementErrorOccurred := false
-	ok := factory.pollUntilSuccess(pollTimeout, func() bool {
-		numberOfRunningInstances, placementError, _ := factory.appExaminer.RunningAppInstancesInfo(appName)
-		if placementError {
-			factory.ui.Say(colors.Red("Error, could not place all instances: insufficient resources. Try requesting fewer instances or reducing the requested memory or disk capacity."))
+	progressBar := progress.NewBar(factory.ui, instances, "instances running")
+	estimator := progress.NewEstimator(factory.clock, instances)
+	ok := factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), pollTimeout, func() bool {
+		counts, err := factory.appExaminer.AppInstanceCounts(appName)
+		if err != nil {
+			return false
+		}
+		if counts.PlacementError {
 			placementErrorOccurred = true
 			return true
 		}
-		return numberOfRunningInstances == instances
-	}, true)
+		progressBar.SetCurrent(counts.Running)
+		progressBar.SetDetail(counts.NotRunning())
+		progressBar.SetRemaining(estimator.Remaining(counts.Running))
+		return counts.Running == instances
+	}, progressBar)
 
 	if placementErrorOccurred {
-		factory.exitHandler.Exit(exit_codes.PlacementError)
-		return false
+		err := docker_app_runner.InsufficientResourcesError{AppName: appName}
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Error, %s", err)))
+		factory.ui.SayRemedy(err)
+		return exit_codes.PlacementError
 	} else if !ok {
 		if action == pollingStart {
 			factory.ui.Say(colors.Red("Timed out waiting for the container to come up."))
@@ -564,70 +2738,192 @@ func (factory *AppRunnerCommandFactory) pollUntilAllInstancesRunning(pollTimeout
 		factory.ui.SayLine(fmt.Sprintf("To view logs:\n\tltc logs %s", appName))
 		factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", appName))
 		factory.ui.SayNewLine()
+		return exit_codes.Timeout
+	}
+	return 0
+}
+
+// watchApps starts the cache, if there is one, for the polls that follow,
+// and returns the func that stops it again.  The cache fetches the LRPs once
+// and then applies the receptor's events, so that each poll's
+// InstanceCountsByApp is answered without a request.
+func (factory *AppRunnerCommandFactory) watchApps() func() {
+	if factory.cache == nil {
+		return func() {}
+	}
+	factory.cache.Start()
+	return factory.cache.Stop
+}
+
+// pollUntilAllAppsRunning waits for every app to have the given number of
+// running instances, checking all of them together each tick.  It
+// returns the exit code for each app that never came up.
+func (factory *AppRunnerCommandFactory) pollUntilAllAppsRunning(pollTimeout time.Duration, appNames []string, instances map[string]int) map[string]int {
+	waiting := make(map[string]bool)
+	total := 0
+	for _, appName := range appNames {
+		waiting[appName] = true
+		total += instances[appName]
 	}
-	return ok
+	exitCodes := make(map[string]int)
+
+	defer factory.watchApps()()
+	progressBar := progress.NewBar(factory.ui, total, "instances running")
+	factory.pollUntilSuccess(exit_handler.Done(factory.exitHandler), pollTimeout, func() bool {
+		instanceCounts, err := factory.appExaminer.InstanceCountsByApp()
+		if err != nil {
+			return false
+		}
+
+		running := 0
+		notRunning := app_examiner.InstanceCounts{}
+		for _, appName := range appNames {
+			counts := instanceCounts[appName]
+			if counts.Running < instances[appName] {
+				running += counts.Running
+			} else {
+				running += instances[appName]
+			}
+			notRunning.Starting += counts.Starting
+			notRunning.Crashed += counts.Crashed
+
+			if !waiting[appName] {
+				continue
+			}
+			if counts.PlacementError {
+				exitCodes[appName] = exit_codes.PlacementError
+				delete(waiting, appName)
+			} else if counts.Running == instances[appName] {
+				delete(waiting, appName)
+			}
+		}
+		progressBar.SetCurrent(running)
+		progressBar.SetDetail(notRunning.NotRunning())
+		return len(waiting) == 0
+	}, progressBar)
+
+	for appName := range waiting {
+		exitCodes[appName] = exit_codes.Timeout
+	}
+	return exitCodes
 }
 
 func (factory *AppRunnerCommandFactory) urlForApp(name string) string {
-	return fmt.Sprintf("http://%s.%s\n", name, factory.domain)
+	return fmt.Sprintf("http://%s.%s", name, factory.domain)
 }
 
-func (factory *AppRunnerCommandFactory) buildEnvironment(envVars []string, appName string) map[string]string {
+// buildEnvironment layers the --env flags over PROCESS_GUID, which is in
+// turn layered over the environment declared by the image.
+func (factory *AppRunnerCommandFactory) buildEnvironment(envVars []string, appName string, imageEnv []string) (map[string]string, error) {
+	flagEnvironment, err := factory.parseEnvVars(envVars)
+	if err != nil {
+		return nil, err
+	}
+
 	environment := make(map[string]string)
+	for _, envVarPair := range imageEnv {
+		name, value := parseEnvVarPair(envVarPair)
+		environment[name] = value
+	}
+
 	environment["PROCESS_GUID"] = appName
 
-	for _, envVarPair := range envVars {
-		name, value := parseEnvVarPair(envVarPair)
+	for name, value := range flagEnvironment {
+		environment[name] = value
+	}
+	return environment, nil
+}
+
+// parseEnvVars parses env vars given as NAME=VALUE, or as a bare NAME that
+// takes its value from ltc's environment.  A name given more than once is
+// warned about, and its last value is used.
+func (factory *AppRunnerCommandFactory) parseEnvVars(envVars []string) (map[string]string, error) {
+	environment := make(map[string]string)
+	for _, envVar := range envVars {
+		name, value, inherit, err := parseEnvVar(envVar)
+		if err != nil {
+			return nil, err
+		}
 
-		if value == "" {
-			value = factory.grabVarFromEnv(name)
+		if inherit {
+			var found bool
+			if value, found = factory.grabVarFromEnv(name); !found {
+				factory.ui.SayLine(fmt.Sprintf("Warning: %s is not set in ltc's environment, so it will be empty.", name))
+			}
 		}
 
+		if _, duplicate := environment[name]; duplicate {
+			factory.ui.SayLine(fmt.Sprintf("Warning: %s is given more than once, so its last value will be used.", name))
+		}
 		environment[name] = value
 	}
-	return environment
+	return environment, nil
+}
+
+func (factory *AppRunnerCommandFactory) addSecretsToEnvironment(environment map[string]string, secretEnvs []string) error {
+	for _, secretEnv := range secretEnvs {
+		envVarName, secretName := parseEnvVarPair(secretEnv)
+		if envVarName == "" || secretName == "" {
+			return errors.New(MalformedSecretEnvErrorMessage)
+		}
+
+		value, err := factory.secretStore.GetSecret(secretName)
+		if err != nil {
+			return fmt.Errorf("Error reading secret %s: %s", secretName, err)
+		}
+
+		environment[envVarName] = value
+	}
+	return nil
 }
 
-func (factory *AppRunnerCommandFactory) grabVarFromEnv(name string) string {
+func (factory *AppRunnerCommandFactory) grabVarFromEnv(name string) (string, bool) {
 	for _, envVarPair := range factory.env {
-		if strings.HasPrefix(envVarPair, name) {
-			_, value := parseEnvVarPair(envVarPair)
-			return value
+		if envName, value := parseEnvVarPair(envVarPair); envName == name {
+			return value, true
 		}
 	}
-	return ""
+	return "", false
 }
 
-func (factory *AppRunnerCommandFactory) getExposedPortsFromArgs(portsFlag string, imageMetadata *docker_metadata_fetcher.ImageMetadata) ([]uint16, error) {
-	if portsFlag != "" {
-		portStrings := strings.Split(portsFlag, ",")
-		sort.Strings(portStrings)
-
-		convertedPorts := []uint16{}
-		for _, p := range portStrings {
-			intPort, err := strconv.Atoi(p)
-			if err != nil || intPort > 65535 {
-				return []uint16{}, errors.New(InvalidPortErrorMessage)
-			}
-			convertedPorts = append(convertedPorts, uint16(intPort))
+// getExposedPortsFromArgs returns the tcp and udp ports to expose, from
+// --ports if given or else from the image metadata, along with the udp
+// ports from --udp-ports.
+func (factory *AppRunnerCommandFactory) getExposedPortsFromArgs(portsFlag, udpPortsFlag string, imageMetadata *docker_metadata_fetcher.ImageMetadata) ([]uint16, []uint16, error) {
+	var extraUDPPorts []uint16
+	if udpPortsFlag != "" {
+		var err error
+		if extraUDPPorts, err = parsePorts(udpPortsFlag); err != nil {
+			return []uint16{}, nil, err
 		}
-		return convertedPorts, nil
 	}
 
-	if len(imageMetadata.ExposedPorts) > 0 {
+	if portsFlag != "" {
+		tcpPorts, udpPorts, err := parsePortsWithProtocols(portsFlag)
+		return tcpPorts, appendNewPorts(udpPorts, extraUDPPorts), err
+	}
+
+	if len(imageMetadata.ExposedPorts) > 0 || len(imageMetadata.UDPPorts) > 0 {
 		var exposedPortStrings []string
 		for _, port := range imageMetadata.ExposedPorts {
 			exposedPortStrings = append(exposedPortStrings, strconv.Itoa(int(port)))
 		}
-		factory.ui.Say(fmt.Sprintf("No port specified, using exposed ports from the image metadata.\n\tExposed Ports: %s\n", strings.Join(exposedPortStrings, ", ")))
-		return imageMetadata.ExposedPorts, nil
+		for _, port := range imageMetadata.UDPPorts {
+			exposedPortStrings = append(exposedPortStrings, fmt.Sprintf("%d/udp", port))
+		}
+		factory.ui.SayInfo(fmt.Sprintf("No port specified, using exposed ports from the image metadata.\n\tExposed Ports: %s\n", strings.Join(exposedPortStrings, ", ")))
+		return imageMetadata.ExposedPorts, appendNewPorts(imageMetadata.UDPPorts, extraUDPPorts), nil
 	}
 
-	factory.ui.Say(fmt.Sprintf("No port specified, image metadata did not contain exposed ports. Defaulting to 8080.\n"))
-	return []uint16{8080}, nil
+	if len(extraUDPPorts) > 0 {
+		return []uint16{}, extraUDPPorts, nil
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("No port specified, image metadata did not contain exposed ports. Defaulting to 8080.\n"))
+	return []uint16{8080}, nil, nil
 }
 
-func (factory *AppRunnerCommandFactory) getMonitorConfigFromArgs(exposedPorts []uint16, portMonitorFlag int, noMonitorFlag bool, urlMonitorFlag string, monitorTimeoutFlag time.Duration, imageMetadata *docker_metadata_fetcher.ImageMetadata) (docker_app_runner.MonitorConfig, error) {
+func (factory *AppRunnerCommandFactory) getMonitorConfigFromArgs(exposedPorts, udpPorts []uint16, portMonitorFlag int, noMonitorFlag bool, urlMonitorFlag string, monitorTimeoutFlag time.Duration, imageMetadata *docker_metadata_fetcher.ImageMetadata) (docker_app_runner.MonitorConfig, error) {
 	if noMonitorFlag {
 		return docker_app_runner.MonitorConfig{
 			Method: docker_app_runner.NoMonitor,
@@ -645,7 +2941,7 @@ func (factory *AppRunnerCommandFactory) getMonitorConfigFromArgs(exposedPorts []
 			return docker_app_runner.MonitorConfig{}, errors.New(InvalidPortErrorMessage)
 		}
 
-		if err := checkPortExposed(exposedPorts, uint16(urlMonitorPort)); err != nil {
+		if err := checkPortMonitorable(exposedPorts, udpPorts, uint16(urlMonitorPort)); err != nil {
 			return docker_app_runner.MonitorConfig{}, err
 		}
 
@@ -657,20 +2953,21 @@ func (factory *AppRunnerCommandFactory) getMonitorConfigFromArgs(exposedPorts []
 		}, nil
 	}
 
-	var sortedPorts []int
-	for _, port := range exposedPorts {
-		sortedPorts = append(sortedPorts, int(port))
-	}
-	sort.Ints(sortedPorts)
-
-	// Unsafe array access:  because we'll default exposing 8080 if
-	// both --ports is empty and docker image has no EXPOSE ports
-	monitorPort := uint16(sortedPorts[0])
+	var monitorPort uint16
 	if portMonitorFlag > 0 {
 		monitorPort = uint16(portMonitorFlag)
+	} else if len(exposedPorts) > 0 {
+		var sortedPorts []int
+		for _, port := range exposedPorts {
+			sortedPorts = append(sortedPorts, int(port))
+		}
+		sort.Ints(sortedPorts)
+		monitorPort = uint16(sortedPorts[0])
+	} else {
+		return docker_app_runner.MonitorConfig{}, errors.New(MonitorPortIsUDP)
 	}
 
-	if err := checkPortExposed(exposedPorts, monitorPort); err != nil {
+	if err := checkPortMonitorable(exposedPorts, udpPorts, monitorPort); err != nil {
 		return docker_app_runner.MonitorConfig{}, err
 	}
 
@@ -681,6 +2978,65 @@ func (factory *AppRunnerCommandFactory) getMonitorConfigFromArgs(exposedPorts []
 	}, nil
 }
 
+func parsePorts(ports string) ([]uint16, error) {
+	portStrings := strings.Split(ports, ",")
+	sort.Strings(portStrings)
+
+	convertedPorts := []uint16{}
+	for _, p := range portStrings {
+		intPort, err := strconv.Atoi(p)
+		if err != nil || intPort > 65535 {
+			return []uint16{}, errors.New(InvalidPortErrorMessage)
+		}
+		convertedPorts = append(convertedPorts, uint16(intPort))
+	}
+	return convertedPorts, nil
+}
+
+// parsePortsWithProtocols splits --ports into tcp and udp ports.  Ports
+// are tcp unless suffixed with /udp, as in EXPOSE directives.
+func parsePortsWithProtocols(ports string) ([]uint16, []uint16, error) {
+	var tcpPortStrings, udpPortStrings []string
+	for _, portString := range strings.Split(ports, ",") {
+		switch {
+		case strings.HasSuffix(portString, "/udp"):
+			udpPortStrings = append(udpPortStrings, strings.TrimSuffix(portString, "/udp"))
+		case strings.HasSuffix(portString, "/tcp"):
+			tcpPortStrings = append(tcpPortStrings, strings.TrimSuffix(portString, "/tcp"))
+		default:
+			tcpPortStrings = append(tcpPortStrings, portString)
+		}
+	}
+
+	tcpPorts := []uint16{}
+	if len(tcpPortStrings) > 0 {
+		var err error
+		if tcpPorts, err = parsePorts(strings.Join(tcpPortStrings, ",")); err != nil {
+			return []uint16{}, nil, err
+		}
+	}
+
+	var udpPorts []uint16
+	if len(udpPortStrings) > 0 {
+		var err error
+		if udpPorts, err = parsePorts(strings.Join(udpPortStrings, ",")); err != nil {
+			return []uint16{}, nil, err
+		}
+	}
+
+	return tcpPorts, udpPorts, nil
+}
+
+// appendNewPorts appends the ports in more that aren't in ports already.
+func appendNewPorts(ports, more []uint16) []uint16 {
+	for _, port := range more {
+		if checkPortExposed(ports, port) != nil {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
 func checkPortExposed(exposedPorts []uint16, monitorPort uint16) error {
 	portFound := false
 	for _, port := range exposedPorts {
@@ -696,6 +3052,15 @@ func checkPortExposed(exposedPorts []uint16, monitorPort uint16) error {
 	return nil
 }
 
+// checkPortMonitorable rejects monitoring a port that is only exposed for
+// udp, since the healthcheck connects over tcp.
+func checkPortMonitorable(exposedPorts, udpPorts []uint16, monitorPort uint16) error {
+	if checkPortExposed(exposedPorts, monitorPort) != nil && checkPortExposed(udpPorts, monitorPort) == nil {
+		return errors.New(MonitorPortIsUDP)
+	}
+	return checkPortExposed(exposedPorts, monitorPort)
+}
+
 func parseRouteOverrides(routes string) (docker_app_runner.RouteOverrides, error) {
 	var routeOverrides docker_app_runner.RouteOverrides
 
@@ -703,20 +3068,119 @@ func parseRouteOverrides(routes string) (docker_app_runner.RouteOverrides, error
 		if route == "" {
 			continue
 		}
-		routeArr := strings.Split(route, ":")
+		routeArr := strings.SplitN(route, ":", 2)
 		maybePort, err := strconv.Atoi(routeArr[0])
 		if err != nil || len(routeArr) < 2 {
 			return nil, errors.New(MalformedRouteErrorMessage)
 		}
 
-		port := uint16(maybePort)
-		hostnamePrefix := routeArr[1]
-		routeOverrides = append(routeOverrides, docker_app_runner.RouteOverride{HostnamePrefix: hostnamePrefix, Port: port})
+		routeOverride, err := parseRoute(routeArr[1])
+		if err != nil {
+			return nil, err
+		}
+		routeOverride.Port = uint16(maybePort)
+		routeOverrides = append(routeOverrides, routeOverride)
 	}
 
 	return routeOverrides, nil
 }
 
+var (
+	routeHostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+	routePathPattern     = regexp.MustCompile(`^(/[a-zA-Z0-9._~!$&'()*+,;=:@%-]+)*$`)
+)
+
+// parseRoute treats a route containing a dot as a complete hostname and
+// anything else as a prefix for the system domain.
+func parseRoute(route string) (docker_app_runner.RouteOverride, error) {
+	hostname, path := route, ""
+	if slash := strings.Index(route, "/"); slash != -1 {
+		hostname, path = route[:slash], strings.TrimRight(route[slash:], "/")
+	}
+
+	if !routeHostnamePattern.MatchString(hostname) || !routePathPattern.MatchString(path) {
+		return docker_app_runner.RouteOverride{}, errors.New(MalformedRouteErrorMessage)
+	}
+
+	if strings.Contains(hostname, ".") {
+		return docker_app_runner.RouteOverride{Hostname: hostname, Path: path}, nil
+	}
+	return docker_app_runner.RouteOverride{HostnamePrefix: hostname, Path: path}, nil
+}
+
+// parseRouteOptions parses [PORT:]NAME[=VALUE] options.  session-affinity is
+// the only option so far; its value names the cookie the router pins on.
+func parseRouteOptions(options []string) (docker_app_runner.RouteOptions, error) {
+	var routeOptions docker_app_runner.RouteOptions
+
+	for _, option := range options {
+		var routeOption docker_app_runner.RouteOption
+		if colon := strings.Index(option, ":"); colon != -1 {
+			port, err := strconv.ParseUint(option[:colon], 10, 16)
+			if err != nil || port == 0 {
+				return nil, errors.New(MalformedRouteOptionErrorMessage)
+			}
+			routeOption.Port = uint16(port)
+			option = option[colon+1:]
+		}
+
+		routeOption.Name, routeOption.Value = parseEnvVarPair(option)
+		if routeOption.Name != docker_app_runner.SessionAffinityRouteOption {
+			return nil, errors.New(MalformedRouteOptionErrorMessage)
+		}
+		routeOptions = append(routeOptions, routeOption)
+	}
+
+	return routeOptions, nil
+}
+
+// parseSidecars splits each sidecar command on whitespace; there is no
+// quoting, so arguments can't contain spaces.
+func parseSidecars(commands []string) ([]docker_app_runner.Sidecar, error) {
+	var sidecars []docker_app_runner.Sidecar
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return nil, errors.New("--sidecar requires a command")
+		}
+		sidecars = append(sidecars, docker_app_runner.Sidecar{Path: fields[0], Args: fields[1:]})
+	}
+	return sidecars, nil
+}
+
+// parseLabels parses KEY=VALUE labels.  When removals are allowed, KEY- asks
+// for the label to be removed and is returned with an empty value.
+func parseLabels(pairs []string, allowRemovals bool) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range pairs {
+		if allowRemovals && strings.HasSuffix(pair, "-") && !strings.ContainsAny(pair, "=,") && len(pair) > 1 {
+			labels[strings.TrimSuffix(pair, "-")] = ""
+			continue
+		}
+
+		key, value := parseEnvVarPair(pair)
+		if key == "" || value == "" || strings.Contains(pair, ",") {
+			return nil, errors.New(MalformedLabelErrorMessage)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// envVarName matches the names env vars can have in a shell.
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvVar parses an env var given as NAME=VALUE, where the value runs
+// from the first '=' and may contain more, or as a bare NAME whose value is
+// to be inherited.  NAME= gives an empty value.
+func parseEnvVar(envVar string) (name, value string, inherit bool, err error) {
+	name, value = parseEnvVarPair(envVar)
+	if !envVarName.MatchString(name) {
+		return "", "", false, fmt.Errorf("Invalid env var name '%s'. Names must be letters, digits and underscores, and can't start with a digit.", name)
+	}
+	return name, value, !strings.Contains(envVar, "="), nil
+}
+
 func parseEnvVarPair(envVarPair string) (name, value string) {
 	s := strings.SplitN(envVarPair, "=", 2)
 	if len(s) > 1 {
@@ -724,3 +3188,14 @@ func parseEnvVarPair(envVarPair string) (name, value string) {
 	}
 	return s[0], ""
 }
+
+type envVarsByName []receptor.EnvironmentVariable
+
+func (e envVarsByName) Len() int           { return len(e) }
+func (e envVarsByName) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e envVarsByName) Less(i, j int) bool { return e[i].Name < e[j].Name }
+
+// gerund turns a verb such as "resize" into "resizing".
+func gerund(verb string) string {
+	return strings.TrimSuffix(verb, "e") + "ing"
+}
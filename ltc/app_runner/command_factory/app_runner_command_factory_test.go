@@ -4,36 +4,54 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit/fake_audit_log"
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client/fake_cache"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets/fake_secret_store"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
 	. "github.com/cloudfoundry-incubator/lattice/ltc/test_helpers/matchers"
+	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager"
 )
 
+type notFoundError string
+
+func (err notFoundError) Error() string  { return string(err) }
+func (err notFoundError) NotFound() bool { return true }
+
 var _ = Describe("CommandFactory", func() {
 
 	var (
 		appRunner                     *fake_app_runner.FakeAppRunner
+		dryRunAppRunner               *fake_app_runner.FakeAppRunner
 		appExaminer                   *fake_app_examiner.FakeAppExaminer
 		outputBuffer                  *gbytes.Buffer
 		terminalUI                    terminal.UI
@@ -44,10 +62,13 @@ var _ = Describe("CommandFactory", func() {
 		logger                        lager.Logger
 		fakeTailedLogsOutputter       *fake_tailed_logs_outputter.FakeTailedLogsOutputter
 		fakeExitHandler               *fake_exit_handler.FakeExitHandler
+		fakeSecretStore               *fake_secret_store.FakeSecretStore
+		fakeCache                     *fake_cache.FakeCache
 	)
 
 	BeforeEach(func() {
 		appRunner = &fake_app_runner.FakeAppRunner{}
+		dryRunAppRunner = &fake_app_runner.FakeAppRunner{}
 		appExaminer = &fake_app_examiner.FakeAppExaminer{}
 		outputBuffer = gbytes.NewBuffer()
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
@@ -56,6 +77,8 @@ var _ = Describe("CommandFactory", func() {
 		logger = lager.NewLogger("ltc-test")
 		fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
 		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeSecretStore = &fake_secret_store.FakeSecretStore{}
+		fakeCache = &fake_cache.FakeCache{}
 	})
 
 	Describe("CreateAppCommand", func() {
@@ -65,9 +88,11 @@ var _ = Describe("CommandFactory", func() {
 			env := []string{"SHELL=/bin/bash", "COLOR=Blue"}
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:   appRunner,
+				DryRunAppRunner: dryRunAppRunner,
 				AppExaminer: appExaminer,
 				UI:          terminalUI,
 				DockerMetadataFetcher: dockerMetadataFetcher,
+				SecretStore:           fakeSecretStore,
 				Domain:                domain,
 				Env:                   env,
 				Clock:                 clock,
@@ -104,7 +129,7 @@ var _ = Describe("CommandFactory", func() {
 				"AppArg0",
 				`--appFlavor="purple"`,
 			}
-			appExaminer.RunningAppInstancesInfoReturns(22, false, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 22}, nil)
 
 			test_helpers.ExecuteCommandWithArgs(createCommand, args)
 			Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(Equal(1))
@@ -147,6 +172,47 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://route-1111-me-too.192.168.11.11.xip.io\n")))
 		})
 
+		Describe("parsing --env", func() {
+			BeforeEach(func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/start"}}, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+			})
+
+			It("keeps everything after the first '=' as the value, and sets empty values", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--env=DSN=user=app;host=db", "--env=EMPTY=", "--env=COLOR="})
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				environment := appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables
+				Expect(environment).To(HaveKeyWithValue("DSN", "user=app;host=db"))
+				Expect(environment).To(HaveKeyWithValue("EMPTY", ""))
+				Expect(environment).To(HaveKeyWithValue("COLOR", ""))
+			})
+
+			It("inherits a bare NAME from ltc's environment by exact name", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "-e", "COLOR", "-e", "COL"})
+
+				environment := appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables
+				Expect(environment).To(HaveKeyWithValue("COLOR", "Blue"))
+				Expect(environment).To(HaveKeyWithValue("COL", ""))
+				Expect(outputBuffer).To(test_helpers.SayLine("Warning: COL is not set in ltc's environment, so it will be empty."))
+			})
+
+			It("warns about names given more than once and uses the last value", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "-e", "TIER=web", "-e", "TIER=worker"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Warning: TIER is given more than once, so its last value will be used."))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables).To(HaveKeyWithValue("TIER", "worker"))
+			})
+
+			It("rejects invalid names", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--env", "MY-VAR=value"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Invalid env var name 'MY-VAR'. Names must be letters, digits and underscores, and can't start with a digit."))
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
 		Context("when the PROCESS_GUID is passed in as --env", func() {
 			It("sets the PROCESS_GUID to the value passed in", func() {
 				args := []string{
@@ -155,7 +221,7 @@ var _ = Describe("CommandFactory", func() {
 					"--env=PROCESS_GUID=MyHappyGuid",
 				}
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{""}}, nil)
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 
 				test_helpers.ExecuteCommandWithArgs(createCommand, args)
 
@@ -169,6 +235,254 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when the image declares environment variables", func() {
+			args := []string{
+				"--env=LANG=en_US.UTF-8",
+				"cool-web-app",
+				"superfun/app",
+			}
+
+			BeforeEach(func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					StartCommand: []string{"/start"},
+					Env:          []string{"JAVA_HOME=/usr/lib/jvm", "LANG=C", "PROCESS_GUID=image-guid"},
+				}, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+			})
+
+			It("sets them underneath PROCESS_GUID and the --env flags", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables).To(Equal(map[string]string{
+					"JAVA_HOME":    "/usr/lib/jvm",
+					"LANG":         "en_US.UTF-8",
+					"PROCESS_GUID": "cool-web-app",
+				}))
+			})
+
+			It("leaves them out with --ignore-image-env", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, append([]string{"--ignore-image-env"}, args...))
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables).To(Equal(map[string]string{
+					"LANG":         "en_US.UTF-8",
+					"PROCESS_GUID": "cool-web-app",
+				}))
+			})
+		})
+
+		Context("when --secret-env is passed", func() {
+			BeforeEach(func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/start"}}, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+			})
+
+			It("resolves the secrets into environment variables without printing them", func() {
+				fakeSecretStore.GetSecretStub = func(name string) (string, error) {
+					return "value-of-" + name, nil
+				}
+				args := []string{
+					"--secret-env=DB_PASSWORD=db-password",
+					"--secret-env=API_TOKEN=api-token",
+					"cool-web-app",
+					"superfun/app",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(fakeSecretStore.GetSecretCallCount()).To(Equal(2))
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				appEnvVars := appRunner.CreateDockerAppArgsForCall(0).EnvironmentVariables
+				Expect(appEnvVars["DB_PASSWORD"]).To(Equal("value-of-db-password"))
+				Expect(appEnvVars["API_TOKEN"]).To(Equal("value-of-api-token"))
+				Expect(outputBuffer.Contents()).ToNot(ContainSubstring("value-of-"))
+			})
+
+			It("fails when the secret env is malformed", func() {
+				args := []string{
+					"--secret-env=DB_PASSWORD",
+					"cool-web-app",
+					"superfun/app",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedSecretEnvErrorMessage))
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("fails when the secret cannot be read", func() {
+				fakeSecretStore.GetSecretReturns("", errors.New("Secret db-password not found."))
+				args := []string{
+					"--secret-env=DB_PASSWORD=db-password",
+					"cool-web-app",
+					"superfun/app",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Error reading secret db-password: Secret db-password not found."))
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
+		Context("when full hostnames and paths are passed as routes", func() {
+			It("routes them as given and prints their urls", func() {
+				args := []string{
+					"--routes=8080:api.example.com/v1,8080:docs/guide/,9090:admin.example.com",
+					"--no-wait",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).RouteOverrides).To(Equal(docker_app_runner.RouteOverrides{
+					docker_app_runner.RouteOverride{Hostname: "api.example.com", Path: "/v1", Port: 8080},
+					docker_app_runner.RouteOverride{HostnamePrefix: "docs", Path: "/guide", Port: 8080},
+					docker_app_runner.RouteOverride{Hostname: "admin.example.com", Port: 9090},
+				}))
+
+				Expect(outputBuffer).To(test_helpers.Say("App will be reachable at:\n"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://api.example.com/v1\n")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://docs.192.168.11.11.xip.io/guide\n")))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://admin.example.com\n")))
+			})
+		})
+
+		Context("when sidecars are passed", func() {
+			It("passes them to the app runner", func() {
+				args := []string{
+					"--sidecar=/bin/exporter --port 9100",
+					"--sidecar=/bin/agent",
+					"--no-wait",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).Sidecars).To(Equal([]docker_app_runner.Sidecar{
+					docker_app_runner.Sidecar{Path: "/bin/exporter", Args: []string{"--port", "9100"}},
+					docker_app_runner.Sidecar{Path: "/bin/agent", Args: []string{}},
+				}))
+			})
+
+			It("errors out when a sidecar has no command", func() {
+				args := []string{
+					"--sidecar= ",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --sidecar requires a command"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when labels are passed", func() {
+			It("passes them to the app runner", func() {
+				args := []string{
+					"--label=version=1.2.3",
+					"--label=tier=web",
+					"--no-wait",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).Labels).To(Equal(map[string]string{"version": "1.2.3", "tier": "web"}))
+			})
+
+			It("errors out when a label is malformed", func() {
+				args := []string{
+					"--label=version",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.MalformedLabelErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when route options are passed", func() {
+			It("passes them to the app runner", func() {
+				args := []string{
+					"--route-option=session-affinity",
+					"--route-option=8080:session-affinity=JSESSIONID",
+					"--no-wait",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).RouteOptions).To(Equal(docker_app_runner.RouteOptions{
+					docker_app_runner.RouteOption{Name: "session-affinity"},
+					docker_app_runner.RouteOption{Port: 8080, Name: "session-affinity", Value: "JSESSIONID"},
+				}))
+			})
+
+			It("errors out when the option is unknown", func() {
+				args := []string{
+					"--route-option=round-robin",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteOptionErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("errors out when the port is not an int", func() {
+				args := []string{
+					"--route-option=web:session-affinity",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteOptionErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
 		Context("when a malformed routes flag is passed", func() {
 			It("errors out when the port is not an int", func() {
 				args := []string{
@@ -186,6 +500,38 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 
+			It("errors out when the hostname is invalid", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--routes=8080:bad_host..example.com/v1",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("errors out when the path is invalid", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--routes=8080:api.example.com/v1?query",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
 			It("errors out when there is no colon", func() {
 				args := []string{
 					"cool-web-app",
@@ -205,7 +551,7 @@ var _ = Describe("CommandFactory", func() {
 
 		Describe("Exposed Ports", func() {
 			BeforeEach(func() {
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 			})
 
 			It("exposes ports passed by --ports", func() {
@@ -260,44 +606,181 @@ var _ = Describe("CommandFactory", func() {
 				Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080, 9090}))
 			})
 
-			Context("when the metadata does not have EXPOSE ports", func() {
-				It("exposes the default port 8080", func() {
-					args := []string{
-						"cool-web-app",
-						"superfun/app",
-						"--no-monitor",
-						"--",
-						"/start-me-please",
-					}
+			It("exposes udp ports from image metadata", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					ExposedPorts: []uint16{8080},
+					UDPPorts:     []uint16{53},
+				}, nil)
 
-					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
 
-					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
-					Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
-				})
+				Expect(outputBuffer).To(test_helpers.Say("No port specified, using exposed ports from the image metadata.\n\tExposed Ports: 8080, 53/udp\n"))
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+				Expect(createDockerAppParameters.Monitor.Port).To(Equal(uint16(8080)))
 			})
 
-			Context("when malformed --ports flag is passed", func() {
-				It("blows up when you pass bad port strings", func() {
-					args := []string{
-						"--ports=1000,98feh34",
-						"cool-web-app",
-						"superfun/app:mycooltag",
-						"--",
-						"/start-me-please",
-					}
-
-					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+			It("exposes udp ports passed to --ports with a /udp suffix", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--ports=8080,53/udp,9090/tcp",
+					"--monitor-port=8080",
+					"--",
+					"/start-me-please",
+				}
 
-					Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
-					Expect(outputBuffer).To(test_helpers.Say(command_factory.InvalidPortErrorMessage))
-					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
 
-				})
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080, 9090}))
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+			})
 
-				It("errors out when any port is > 65535 (max Linux port number)", func() {
-					args := []string{
-						"cool-web-app",
+			It("refuses to monitor a udp port", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--ports=8080,53/udp",
+					"--monitor-port=53",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MonitorPortIsUDP))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("requires --no-monitor when only udp ports are exposed", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--ports=53/udp",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MonitorPortIsUDP))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("adds the ports passed to --udp-ports to the udp ones", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--ports=8080,53/udp",
+					"--udp-ports=514,53",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53, 514}))
+				Expect(createDockerAppParameters.Monitor.Port).To(Equal(uint16(8080)))
+			})
+
+			It("adds the ports passed to --udp-ports to the image's exposed ports", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+					ExposedPorts: []uint16{8080},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--udp-ports=53", "--", "/start-me-please"})
+
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+			})
+
+			It("does not default to exposing 8080 when only --udp-ports is given", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--udp-ports=53", "--no-monitor", "--", "/start-me-please"})
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(BeEmpty())
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+				Expect(createDockerAppParameters.Monitor.Method).To(Equal(docker_app_runner.NoMonitor))
+			})
+
+			It("rejects invalid --udp-ports", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--udp-ports=dns", "--", "/start-me-please"})
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.InvalidPortErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("exposes only udp ports with --no-monitor", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--ports=53/udp",
+					"--no-monitor",
+					"--",
+					"/start-me-please",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+				Expect(createDockerAppParameters.ExposedPorts).To(BeEmpty())
+				Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+			})
+
+			Context("when the metadata does not have EXPOSE ports", func() {
+				It("exposes the default port 8080", func() {
+					args := []string{
+						"cool-web-app",
+						"superfun/app",
+						"--no-monitor",
+						"--",
+						"/start-me-please",
+					}
+
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
+				})
+			})
+
+			Context("when malformed --ports flag is passed", func() {
+				It("blows up when you pass bad port strings", func() {
+					args := []string{
+						"--ports=1000,98feh34",
+						"cool-web-app",
+						"superfun/app:mycooltag",
+						"--",
+						"/start-me-please",
+					}
+
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+					Expect(outputBuffer).To(test_helpers.Say(command_factory.InvalidPortErrorMessage))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+
+				})
+
+				It("errors out when any port is > 65535 (max Linux port number)", func() {
+					args := []string{
+						"cool-web-app",
 						"superfun/app",
 						"--ports=8080,65536",
 						"--monitor-port=8080",
@@ -325,7 +808,7 @@ var _ = Describe("CommandFactory", func() {
 						"--",
 						"/start-me-please",
 					}
-					appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 
 					test_helpers.ExecuteCommandWithArgs(createCommand, args)
 
@@ -362,12 +845,83 @@ var _ = Describe("CommandFactory", func() {
 					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadDocker}))
 				})
 			})
+
+			Context("when --skip-metadata-fetch is passed", func() {
+				It("creates the app from the flags without fetching the Docker metadata", func() {
+					args := []string{
+						"--skip-metadata-fetch",
+						"--ports=8080,53/udp",
+						"--working-dir=/app",
+						"cool-web-app",
+						"superfun/app",
+						"--",
+						"/start-me-please",
+						"--now",
+					}
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(BeZero())
+
+					Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.DockerImagePath).To(Equal("superfun/app"))
+					Expect(createDockerAppParameters.StartCommand).To(Equal("/start-me-please"))
+					Expect(createDockerAppParameters.AppArgs).To(Equal([]string{"--now"}))
+					Expect(createDockerAppParameters.WorkingDir).To(Equal("/app"))
+					Expect(createDockerAppParameters.ExposedPorts).To(Equal([]uint16{8080}))
+					Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+					Expect(createDockerAppParameters.Monitor.Port).To(Equal(uint16(8080)))
+					Expect(createDockerAppParameters.EnvironmentVariables).To(Equal(map[string]string{"PROCESS_GUID": "cool-web-app"}))
+				})
+
+				It("accepts --udp-ports in place of --ports", func() {
+					args := []string{
+						"--skip-metadata-fetch",
+						"--udp-ports=53",
+						"--no-monitor",
+						"--working-dir=/app",
+						"cool-web-app",
+						"superfun/app",
+						"--",
+						"/start-me-please",
+					}
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(BeZero())
+					Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.ExposedPorts).To(BeEmpty())
+					Expect(createDockerAppParameters.UDPPorts).To(Equal([]uint16{53}))
+				})
+
+				It("requires the ports, working directory and start command", func() {
+					args := []string{
+						"--skip-metadata-fetch",
+						"--ports=8080",
+						"cool-web-app",
+						"superfun/app",
+						"--",
+						"/start-me-please",
+					}
+
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: " + command_factory.SkipMetadataFetchErrorMessage))
+					Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(BeZero())
+					Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				})
+			})
 		})
 
 		Describe("Monitor Config", func() {
 
 			BeforeEach(func() {
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 			})
 
 			Context("when --no-monitor is passed", func() {
@@ -579,7 +1133,7 @@ var _ = Describe("CommandFactory", func() {
 					"--",
 					"/start-me-please",
 				}
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
 
 				test_helpers.ExecuteCommandWithArgs(createCommand, args)
@@ -601,7 +1155,7 @@ var _ = Describe("CommandFactory", func() {
 					"--",
 					"/start-me-please",
 				}
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{WorkingDir: "/work/it"}, nil)
 
 				test_helpers.ExecuteCommandWithArgs(createCommand, args)
@@ -618,7 +1172,7 @@ var _ = Describe("CommandFactory", func() {
 			}
 
 			BeforeEach(func() {
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 			})
 
 			It("creates a Docker app with the create command retrieved from the docker image metadata", func() {
@@ -646,6 +1200,17 @@ var _ = Describe("CommandFactory", func() {
 				Expect(outputBuffer).To(test_helpers.Say("/fetch-start arg1 arg2\n"))
 			})
 
+			It("says nothing about the metadata it used when quiet", func() {
+				terminalUI.SetVerbosity(terminal.Quiet)
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/fetch-start"}}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, append([]string{"--no-monitor"}, args...))
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(outputBuffer).NotTo(test_helpers.Say("No ports will be monitored."))
+				Expect(outputBuffer).NotTo(test_helpers.Say("Start command is:"))
+			})
+
 			It("does not output the working directory if it is not set", func() {
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/fetch-start"}}, nil)
 
@@ -655,6 +1220,45 @@ var _ = Describe("CommandFactory", func() {
 				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
 			})
 
+			Context("when the image has an entrypoint", func() {
+				BeforeEach(func() {
+					dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+						Entrypoint:   []string{"/entrypoint.sh", "--verbose"},
+						Cmd:          []string{"serve"},
+						StartCommand: []string{"/entrypoint.sh", "--verbose", "serve"},
+					}, nil)
+				})
+
+				It("runs the entrypoint with the image's cmd", func() {
+					test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.StartCommand).To(Equal("/entrypoint.sh"))
+					Expect(createDockerAppParameters.AppArgs).To(Equal([]string{"--verbose", "serve"}))
+				})
+
+				It("passes a custom start command to the entrypoint in place of the cmd", func() {
+					test_helpers.ExecuteCommandWithArgs(createCommand, append(args, "--", "migrate", "--all"))
+
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.StartCommand).To(Equal("/entrypoint.sh"))
+					Expect(createDockerAppParameters.AppArgs).To(Equal([]string{"--verbose", "migrate", "--all"}))
+
+					Expect(outputBuffer).To(test_helpers.Say("Passing the start command to the entrypoint from the image metadata...\n"))
+					Expect(outputBuffer).To(test_helpers.Say("Start command is:\n"))
+					Expect(outputBuffer).To(test_helpers.Say("/entrypoint.sh --verbose migrate --all\n"))
+				})
+
+				It("replaces the entrypoint with --override-entrypoint", func() {
+					test_helpers.ExecuteCommandWithArgs(createCommand, append([]string{"--override-entrypoint"}, append(args, "--", "/bin/sh", "-c", "env")...))
+
+					createDockerAppParameters := appRunner.CreateDockerAppArgsForCall(0)
+					Expect(createDockerAppParameters.StartCommand).To(Equal("/bin/sh"))
+					Expect(createDockerAppParameters.AppArgs).To(Equal([]string{"-c", "env"}))
+					Expect(outputBuffer).NotTo(test_helpers.Say("Passing the start command to the entrypoint"))
+				})
+			})
+
 			Context("when the metadata also has no start command", func() {
 				It("outputs an error message and exits", func() {
 					dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
@@ -676,7 +1280,7 @@ var _ = Describe("CommandFactory", func() {
 					"fun-org/app",
 				}
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{""}}, nil)
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 
 				test_helpers.ExecuteCommandWithArgs(createCommand, args)
 
@@ -686,6 +1290,32 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when the --no-wait flag is passed", func() {
+			It("returns after submitting the app without polling for it to start", func() {
+				args := []string{
+					"--no-wait",
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
+				}
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).NoWait).To(BeTrue())
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+
+				Expect(outputBuffer).To(test_helpers.Say("Creating App: cool-web-app\n"))
+				Expect(outputBuffer).To(test_helpers.Say("App will be reachable at:\n"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://cool-web-app.192.168.11.11.xip.io\n")))
+				Expect(outputBuffer).To(test_helpers.SayLine("To view status:\n\tltc status cool-web-app"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
 		Describe("polling for the app to start after desiring the app", func() {
 			It("polls for the app to start with correct number of instances, outputting logs while the app starts", func() {
 				args := []string{
@@ -697,7 +1327,7 @@ var _ = Describe("CommandFactory", func() {
 				}
 
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
-				appExaminer.RunningAppInstancesInfoReturns(0, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
 
 				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, args)
 
@@ -706,18 +1336,18 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(Equal(1))
 				Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("cool-web-app"))
 
-				Expect(appExaminer.RunningAppInstancesInfoCallCount()).To(Equal(1))
-				Expect(appExaminer.RunningAppInstancesInfoArgsForCall(0)).To(Equal("cool-web-app"))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(Equal(1))
+				Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
 
 				clock.IncrementBySeconds(1)
 				Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(0))
 
-				appExaminer.RunningAppInstancesInfoReturns(9, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 9}, nil)
 				clock.IncrementBySeconds(1)
 				Expect(commandFinishChan).ShouldNot(BeClosed())
 				Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(0))
 
-				appExaminer.RunningAppInstancesInfoReturns(10, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 10}, nil)
 				clock.IncrementBySeconds(1)
 
 				Eventually(commandFinishChan).Should(BeClosed())
@@ -737,7 +1367,7 @@ var _ = Describe("CommandFactory", func() {
 						"/start-me-please",
 					}
 					dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
-					appExaminer.RunningAppInstancesInfoReturns(0, false, nil)
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
 
 					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, args)
 
@@ -756,6 +1386,7 @@ var _ = Describe("CommandFactory", func() {
 					Expect(outputBuffer).To(test_helpers.SayLine("To view status:\n\tltc status cool-web-app"))
 					Expect(outputBuffer).To(test_helpers.Say("App will be reachable at:\n"))
 					Expect(outputBuffer).To(test_helpers.Say(colors.Green("http://cool-web-app.192.168.11.11.xip.io\n")))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
 				})
 			})
 
@@ -772,31 +1403,198 @@ var _ = Describe("CommandFactory", func() {
 					}
 
 					dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
-					appExaminer.RunningAppInstancesInfoReturns(0, false, nil)
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
 
 					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, args)
 
 					Eventually(outputBuffer).Should(test_helpers.Say("Monitoring the app on port 3000..."))
 					Eventually(outputBuffer).Should(test_helpers.Say("Creating App: cool-web-app"))
 
-					Expect(appExaminer.RunningAppInstancesInfoCallCount()).To(Equal(1))
-					Expect(appExaminer.RunningAppInstancesInfoArgsForCall(0)).To(Equal("cool-web-app"))
+					Expect(appExaminer.AppInstanceCountsCallCount()).To(Equal(1))
+					Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
 
 					clock.IncrementBySeconds(1)
 					Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(0))
 					Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
 
-					appExaminer.RunningAppInstancesInfoReturns(9, true, nil)
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 9, PlacementError: true}, nil)
 					clock.IncrementBySeconds(1)
 					Eventually(commandFinishChan).Should(BeClosed())
 					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
 					Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
 
 					Expect(outputBuffer).To(test_helpers.SayNewLine())
-					Expect(outputBuffer).To(test_helpers.Say(colors.Red("Error, could not place all instances: insufficient resources. Try requesting fewer instances or reducing the requested memory or disk capacity.")))
+					Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Error, could not place all instances of cool-web-app: insufficient resources.")))
+					Expect(outputBuffer).To(test_helpers.SayLine("Try requesting fewer instances or reducing the requested memory or disk capacity."))
 					Expect(outputBuffer).ToNot(test_helpers.Say("Timed out waiting for the container"))
 				})
 			})
+
+			Context("with --verify-route", func() {
+				var (
+					server        *httptest.Server
+					statusCodes   chan int
+					requestedHost chan string
+				)
+
+				// dialWith sends the route requests to dial instead of the router.
+				dialWith := func(dial func(network, address string) (net.Conn, error)) {
+					appRunnerCommandFactoryConfig.RouteHTTPClient = &http.Client{Transport: &http.Transport{Dial: dial}}
+					createCommand = command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig).MakeCreateAppCommand()
+				}
+
+				BeforeEach(func() {
+					statusCodes = make(chan int, 10)
+					requestedHost = make(chan string, 10)
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						requestedHost <- r.Host
+						select {
+						case statusCode := <-statusCodes:
+							w.WriteHeader(statusCode)
+						default:
+						}
+					}))
+					dialWith(func(network, address string) (net.Conn, error) {
+						return net.Dial(network, server.Listener.Addr().String())
+					})
+
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+				})
+
+				AfterEach(func() {
+					server.Close()
+				})
+
+				It("waits until the router stops returning 502 before reporting the app running", func() {
+					statusCodes <- http.StatusBadGateway
+
+					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, []string{"--verify-route", "cool-web-app", "superfun/app", "--", "/start-me-please"})
+
+					Eventually(outputBuffer).Should(test_helpers.Say("Verifying routes..."))
+					Eventually(requestedHost).Should(Receive(Equal("cool-web-app.192.168.11.11.xip.io")))
+					Eventually(clock.WatcherCount).Should(Equal(1))
+					Expect(outputBuffer).NotTo(test_helpers.Say("is now running"))
+					clock.IncrementBySeconds(1)
+
+					Eventually(commandFinishChan).Should(BeClosed())
+					Expect(requestedHost).To(Receive())
+					Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app is now running.\n")))
+					Expect(outputBuffer).To(test_helpers.Say("App is reachable at:\n"))
+					Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+				})
+
+				It("reports DNS errors", func() {
+					dialWith(func(network, address string) (net.Conn, error) {
+						return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: "no such host", Name: "cool-web-app.192.168.11.11.xip.io"}}
+					})
+
+					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, []string{"--verify-route", "--timeout=5s", "cool-web-app", "superfun/app", "--", "/start-me-please"})
+
+					Eventually(clock.WatcherCount).Should(Equal(1))
+					clock.IncrementBySeconds(6)
+					Eventually(commandFinishChan).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for http://cool-web-app.192.168.11.11.xip.io to be reachable: DNS error: lookup cool-web-app.192.168.11.11.xip.io: no such host")))
+					Expect(outputBuffer).To(test_helpers.Say("App will be reachable at:\n"))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+				})
+
+				It("reports router errors", func() {
+					dialWith(func(network, address string) (net.Conn, error) {
+						return nil, errors.New("connection refused")
+					})
+
+					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, []string{"--verify-route", "--timeout=5s", "cool-web-app", "superfun/app", "--", "/start-me-please"})
+
+					Eventually(clock.WatcherCount).Should(Equal(1))
+					clock.IncrementBySeconds(6)
+					Eventually(commandFinishChan).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for http://cool-web-app.192.168.11.11.xip.io to be reachable: router error: connection refused")))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+				})
+
+				It("can't be used with --no-wait", func() {
+					test_helpers.ExecuteCommandWithArgs(createCommand, []string{"--verify-route", "--no-wait", "cool-web-app", "superfun/app"})
+
+					Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --verify-route can't be used with --no-routes or --no-wait"))
+					Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				})
+			})
+
+			Context("when ltc is interrupted while the app starts", func() {
+				// interrupt creates cool-web-app, answering a confirmation
+				// with answer, and exits ltc once it is waiting for instances.
+				interrupt := func(answer string, args ...string) {
+					appRunnerCommandFactoryConfig.UI = terminal.NewUI(strings.NewReader(answer), outputBuffer, nil)
+					createCommand = command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig).MakeCreateAppCommand()
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
+
+					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, append(args, "cool-web-app", "superfun/app", "--", "/start-me-please"))
+
+					Eventually(outputBuffer).Should(test_helpers.Say("Creating App: cool-web-app"))
+					Eventually(clock.WatcherCount).Should(Equal(1))
+					fakeExitHandler.Exit(exit_codes.SigInt)
+
+					Eventually(commandFinishChan).Should(BeClosed())
+					Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(BeNumerically(">=", 1))
+				}
+
+				It("offers to remove the app", func() {
+					interrupt("y\n")
+
+					Expect(outputBuffer).To(test_helpers.Say("Remove the partially created cool-web-app? [y/N]: "))
+					Expect(outputBuffer).To(test_helpers.SayLine("Removed cool-web-app."))
+					Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+					Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app"))
+				})
+
+				It("leaves the app when the offer is declined", func() {
+					interrupt("n\n")
+
+					Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app was left as it was. To remove it:\n\tltc remove cool-web-app"))
+					Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				})
+
+				It("removes the app without asking with --cleanup-on-interrupt", func() {
+					cleanupAppRunner := &fake_app_runner.FakeAppRunner{}
+					appRunnerCommandFactoryConfig.CleanupAppRunner = cleanupAppRunner
+
+					interrupt("", "--cleanup-on-interrupt")
+
+					Expect(outputBuffer).NotTo(test_helpers.Say("Remove the partially created"))
+					Expect(cleanupAppRunner.RemoveAppCallCount()).To(Equal(1))
+					Expect(cleanupAppRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app"))
+					Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				})
+
+				It("reports errors removing the app", func() {
+					appRunner.RemoveAppReturns(errors.New("receptor is down"))
+
+					interrupt("", "--cleanup-on-interrupt")
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Error removing cool-web-app: receptor is down"))
+				})
+
+				It("doesn't remove an app it only updated", func() {
+					appRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+					appRunner.UpdateDockerAppReturns(docker_app_runner.AppUpdate{ChangedFields: []string{"instances"}}, nil)
+
+					appRunnerCommandFactoryConfig.UI = terminal.NewUI(nil, outputBuffer, nil)
+					createCommand = command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig).MakeCreateAppCommand()
+					appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
+
+					commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(createCommand, []string{"--update-if-exists", "--cleanup-on-interrupt", "cool-web-app", "superfun/app", "--", "/start-me-please"})
+
+					Eventually(outputBuffer).Should(test_helpers.Say("Updating App: cool-web-app"))
+					Eventually(clock.WatcherCount).Should(Equal(1))
+					fakeExitHandler.Exit(exit_codes.SigInt)
+
+					Eventually(commandFinishChan).Should(BeClosed())
+					Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				})
+			})
 		})
 
 		Context("invalid syntax", func() {
@@ -836,15 +1634,29 @@ var _ = Describe("CommandFactory", func() {
 				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: '--' Required before start command"))
 				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
 			})
-		})
 
-		Context("when the app runner returns an error", func() {
-			It("outputs error messages", func() {
+			It("validates the app name before fetching the image's metadata", func() {
 				args := []string{
-					"cool-web-app",
+					"Cool_App",
 					"superfun/app",
-					"--",
-					"/start-me-please",
+				}
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Cool_App is not a valid app name: it contains uppercase letters."))
+				Expect(outputBuffer).To(test_helpers.Say("App names are used in the app's hostnames"))
+				Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(Equal(0))
+				Expect(appRunner.CreateDockerAppCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when the app runner returns an error", func() {
+			It("outputs error messages", func() {
+				args := []string{
+					"cool-web-app",
+					"superfun/app",
+					"--",
+					"/start-me-please",
 				}
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
 				appRunner.CreateDockerAppReturns(errors.New("Major Fault"))
@@ -854,6 +1666,206 @@ var _ = Describe("CommandFactory", func() {
 				Expect(outputBuffer).To(test_helpers.Say("Error creating app: Major Fault"))
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 			})
+
+			It("suggests a remedy when the app already exists", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+				appRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error creating app: cool-web-app is already running"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Choose another name, or run ltc remove cool-web-app first."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AlreadyExists}))
+			})
+		})
+
+		Context("with --dry-run", func() {
+			It("fetches the metadata but only prints the requests", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{StartCommand: []string{"/start"}, ExposedPorts: []uint16{8080}}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--dry-run"})
+
+				Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(Equal(1))
+				Expect(dryRunAppRunner.CreateDockerAppCallCount()).To(Equal(1))
+				Expect(dryRunAppRunner.CreateDockerAppArgsForCall(0).StartCommand).To(Equal("/start"))
+				Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.DryRunMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("shows the update of an existing app with --update-if-exists", func() {
+				dryRunAppRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+				dryRunAppRunner.UpdateDockerAppReturns(docker_app_runner.AppUpdate{ChangedFields: []string{"instances"}}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--dry-run", "--update-if-exists", "--", "/start-me-please"})
+
+				Expect(dryRunAppRunner.UpdateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.UpdateDockerAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine("Updating App: cool-web-app (changed instances)"))
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.DryRunMessage))
+			})
+
+			It("validates the input first", func() {
+				test_helpers.ExecuteCommandWithArgs(createCommand, []string{"cool-web-app", "superfun/app", "--dry-run", "--label=version", "--", "/start-me-please"})
+
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedLabelErrorMessage))
+				Expect(dryRunAppRunner.CreateDockerAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("with --update-if-exists", func() {
+			var args []string
+
+			BeforeEach(func() {
+				args = []string{"cool-web-app", "superfun/app", "--update-if-exists", "--instances=3", "--", "/start-me-please"}
+				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+			})
+
+			It("creates the app if it doesn't exist", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Creating App: cool-web-app"))
+				Expect(appRunner.CreateDockerAppArgsForCall(0).UpdateIfExists).To(BeTrue())
+				Expect(appRunner.UpdateDockerAppCallCount()).To(BeZero())
+			})
+
+			It("updates the app if it exists, then waits for it", func() {
+				appRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+				appRunner.UpdateDockerAppReturns(docker_app_runner.AppUpdate{ChangedFields: []string{"instances", "memory_mb"}, Recreated: true}, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(appRunner.UpdateDockerAppCallCount()).To(Equal(1))
+				Expect(appRunner.UpdateDockerAppArgsForCall(0).Instances).To(Equal(3))
+				Expect(outputBuffer).To(test_helpers.SayLine("Updating App: cool-web-app (changed instances, memory_mb)"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Lattice can't change these in place, so the app was created again."))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("cool-web-app is now running.\n")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("says when the app is up to date", func() {
+				appRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+				appRunner.UpdateDockerAppReturns(docker_app_runner.AppUpdate{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("cool-web-app is up to date.")))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("reports errors updating the app", func() {
+				appRunner.CreateDockerAppReturns(docker_app_runner.AppAlreadyExistsError{AppName: "cool-web-app"})
+				appRunner.UpdateDockerAppReturns(docker_app_runner.AppUpdate{}, errors.New("receptor down"))
+
+				test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error updating app: receptor down"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+	})
+
+	Describe("InspectImageCommand", func() {
+		var inspectImageCommand cli.Command
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+				TailedLogsOutputter:   fakeTailedLogsOutputter,
+				ExitHandler:           fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			inspectImageCommand = commandFactory.MakeInspectImageCommand()
+
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+				WorkingDir:   "/app",
+				User:         "vcap",
+				ExposedPorts: []uint16{8080, 9000},
+				UDPPorts:     []uint16{53},
+				Entrypoint:   []string{"/entrypoint.sh"},
+				Cmd:          []string{"serve"},
+				StartCommand: []string{"/entrypoint.sh", "serve"},
+				Env:          []string{"LANG=C", "HOME=/app"},
+			}, nil)
+		})
+
+		It("prints the image metadata", func() {
+			test_helpers.ExecuteCommandWithArgs(inspectImageCommand, []string{"cloudfoundry/lattice-app"})
+
+			Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(Equal(1))
+			Expect(dockerMetadataFetcher.FetchMetadataArgsForCall(0)).To(Equal("cloudfoundry/lattice-app"))
+
+			Expect(outputBuffer).To(test_helpers.Say("Image"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Bold("cloudfoundry/lattice-app")))
+			Expect(outputBuffer).To(test_helpers.Say("Ports"))
+			Expect(outputBuffer).To(test_helpers.Say("8080, 9000, 53/udp"))
+			Expect(outputBuffer).To(test_helpers.Say("Start Command"))
+			Expect(outputBuffer).To(test_helpers.Say("/entrypoint.sh serve"))
+			Expect(outputBuffer).To(test_helpers.Say("Working Dir"))
+			Expect(outputBuffer).To(test_helpers.Say("/app"))
+			Expect(outputBuffer).To(test_helpers.Say("User"))
+			Expect(outputBuffer).To(test_helpers.Say("vcap"))
+			Expect(outputBuffer).To(test_helpers.Say("Env"))
+			Expect(outputBuffer).To(test_helpers.Say("LANG=C"))
+			Expect(outputBuffer).To(test_helpers.Say("HOME=/app"))
+		})
+
+		It("prints the defaults ltc create applies when the image leaves them unset", func() {
+			dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(inspectImageCommand, []string{"cloudfoundry/lattice-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Gray("none (ltc create defaults to 8080)")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Gray("none (ltc create requires one after '--')")))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Gray("none (ltc create defaults to /)")))
+		})
+
+		It("prints the image metadata as JSON with --json", func() {
+			test_helpers.ExecuteCommandWithArgs(inspectImageCommand, []string{"--json", "cloudfoundry/lattice-app"})
+
+			Expect(outputBuffer.Contents()).To(MatchJSON(`{
+				"working_dir": "/app",
+				"user": "vcap",
+				"exposed_ports": [8080, 9000],
+				"udp_ports": [53],
+				"entrypoint": ["/entrypoint.sh"],
+				"cmd": ["serve"],
+				"start_command": ["/entrypoint.sh", "serve"],
+				"env": ["LANG=C", "HOME=/app"]
+			}`))
+		})
+
+		It("requires DOCKER_IMAGE", func() {
+			test_helpers.ExecuteCommandWithArgs(inspectImageCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: DOCKER_IMAGE is required"))
+			Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("when the metadata can't be fetched", func() {
+			It("prints the error and exits", func() {
+				dockerMetadataFetcher.FetchMetadataReturns(nil, errors.New("Docker Says No."))
+
+				test_helpers.ExecuteCommandWithArgs(inspectImageCommand, []string{"cloudfoundry/lattice-app"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error fetching image metadata: Docker Says No."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadDocker}))
+			})
 		})
 	})
 
@@ -917,6 +1929,16 @@ var _ = Describe("CommandFactory", func() {
 				Expect(appRunner.SubmitLrpCallCount()).To(Equal(1))
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 			})
+
+			It("suggests a remedy for invalid JSON", func() {
+				appRunner.SubmitLrpReturns("", docker_app_runner.InvalidManifestError{Err: errors.New("unexpected end of JSON input")})
+
+				test_helpers.ExecuteCommandWithArgs(submitLrpCommand, []string{tmpFile.Name()})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error creating : unexpected end of JSON input"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Check the JSON against the receptor API; see docs/lattice-api.md."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
 		})
 
 		It("is an error when no path is passed in", func() {
@@ -947,6 +1969,7 @@ var _ = Describe("CommandFactory", func() {
 		BeforeEach(func() {
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:   appRunner,
+				DryRunAppRunner: dryRunAppRunner,
 				AppExaminer: appExaminer,
 				UI:          terminalUI,
 				DockerMetadataFetcher: dockerMetadataFetcher,
@@ -956,6 +1979,7 @@ var _ = Describe("CommandFactory", func() {
 				Logger:                logger,
 				TailedLogsOutputter:   fakeTailedLogsOutputter,
 				ExitHandler:           fakeExitHandler,
+				Cache:                 fakeCache,
 			}
 
 			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
@@ -968,7 +1992,7 @@ var _ = Describe("CommandFactory", func() {
 				"22",
 			}
 
-			appExaminer.RunningAppInstancesInfoReturns(22, false, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 22}, nil)
 
 			test_helpers.ExecuteCommandWithArgs(scaleCommand, args)
 
@@ -981,27 +2005,320 @@ var _ = Describe("CommandFactory", func() {
 			Expect(instances).To(Equal(22))
 		})
 
+		Context("when the --dry-run flag is passed", func() {
+			It("only prints the request, without waiting", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"cool-web-app", "22", "--dry-run"})
+
+				Expect(dryRunAppRunner.ScaleAppCallCount()).To(Equal(1))
+				name, instances := dryRunAppRunner.ScaleAppArgsForCall(0)
+				Expect(name).To(Equal("cool-web-app"))
+				Expect(instances).To(Equal(22))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.DryRunMessage))
+			})
+
+			It("reports errors", func() {
+				dryRunAppRunner.ScaleAppReturns(errors.New("app not found"))
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"cool-web-app", "22", "--dry-run"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error Scaling App to 22 instances: app not found"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
+		Context("when the --selector flag is passed", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "web-prod", Labels: map[string]string{"env": "prod", "team": "web"}},
+					{ProcessGuid: "web-staging", Labels: map[string]string{"env": "staging", "team": "web"}},
+					{ProcessGuid: "api-prod", Labels: map[string]string{"env": "prod", "team": "api"}},
+				}, nil)
+			})
+
+			It("scales the apps whose labels match", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--selector", "env=prod", "--no-wait", "3"})
+
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(2))
+				var scaled []string
+				for i := 0; i < 2; i++ {
+					name, instances := appRunner.ScaleAppArgsForCall(i)
+					Expect(instances).To(Equal(3))
+					scaled = append(scaled, name)
+				}
+				Expect(scaled).To(ConsistOf("web-prod", "api-prod"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits with NotFound when no apps match", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--selector", "env=dev", "3"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match env=dev."))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("takes only the number of instances", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--selector", "env=prod", "web-prod", "3"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc scale --selector KEY=VALUE NUMBER_OF_INSTANCES'"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("validates the selector", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--selector", "env", "3"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid selector env"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when the --batch flag is passed", func() {
+			BeforeEach(func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-web-app", DesiredInstances: 2}, nil)
+				appExaminer.AppInstanceCountsStub = func(string) (app_examiner.InstanceCounts, error) {
+					_, instances := appRunner.ScaleAppArgsForCall(appRunner.ScaleAppCallCount() - 1)
+					return app_examiner.InstanceCounts{Running: instances}, nil
+				}
+			})
+
+			It("scales up one batch at a time", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=3", "cool-web-app", "10"})
+
+				Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("cool-web-app"))
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(3))
+				for i, instances := range []int{5, 8, 10} {
+					name, scaledTo := appRunner.ScaleAppArgsForCall(i)
+					Expect(name).To(Equal("cool-web-app"))
+					Expect(scaledTo).To(Equal(instances))
+				}
+
+				Expect(outputBuffer).To(test_helpers.Say("Scaling cool-web-app to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.Say("Scaling cool-web-app to 8 instances"))
+				Expect(outputBuffer).To(test_helpers.Say("Scaling cool-web-app to 10 instances"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("scales straight to the target when scaling down", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=3", "cool-web-app", "1"})
+
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+				_, scaledTo := appRunner.ScaleAppArgsForCall(0)
+				Expect(scaledTo).To(Equal(1))
+			})
+
+			It("stops at the first batch that cannot be placed", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2, PlacementError: true}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=3", "cool-web-app", "10"})
+
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+				Expect(outputBuffer).To(test_helpers.Say("Error, could not place all instances"))
+				Expect(outputBuffer).NotTo(test_helpers.Say("App Scaled Successfully"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+			})
+
+			It("exits with NotFound when the app does not exist", func() {
+				appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New(app_examiner.AppNotFoundErrorMessage))
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=3", "cool-web-app", "10"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error Scaling App to 10 instances: " + app_examiner.AppNotFoundErrorMessage))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("rejects a negative batch size", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=-1", "cool-web-app", "10"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Batch size must be a positive integer"))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("cannot be combined with --no-wait", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=3", "--no-wait", "cool-web-app", "10"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --batch waits for each batch, so it cannot be combined with --no-wait"))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when the app name is a pattern", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "worker-a"},
+					{ProcessGuid: "web"},
+					{ProcessGuid: "worker-b"},
+				}, nil)
+			})
+
+			scaledApps := func() []string {
+				var names []string
+				for i := 0; i < appRunner.ScaleAppCallCount(); i++ {
+					name, _ := appRunner.ScaleAppArgsForCall(i)
+					names = append(names, name)
+				}
+				return names
+			}
+
+			It("scales every matching app", func() {
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+					"worker-a": {Running: 5},
+					"worker-b": {Running: 5},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"worker-*", "5"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Scaling worker-a to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Scaling worker-b to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Scaled 2 of 2 apps.")))
+
+				Expect(scaledApps()).To(ConsistOf("worker-a", "worker-b"))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("waits for all of the apps with one request per poll", func() {
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+					"worker-a": {Running: 5},
+					"worker-b": {Running: 2},
+				}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, []string{"worker-*", "5"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("(7/10 instances running)"))
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(Equal(1))
+
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+					"worker-a": {Running: 5},
+					"worker-b": {Running: 5},
+				}, nil)
+				clock.IncrementBySeconds(1)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(Equal(2))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Scaled 2 of 2 apps.")))
+			})
+
+			It("watches the apps through the cache only while waiting on them", func() {
+				appExaminer.InstanceCountsByAppStub = func() (map[string]app_examiner.InstanceCounts, error) {
+					Expect(fakeCache.StartCallCount()).To(Equal(1))
+					Expect(fakeCache.StopCallCount()).To(BeZero())
+					return map[string]app_examiner.InstanceCounts{
+						"worker-a": {Running: 5},
+						"worker-b": {Running: 5},
+					}, nil
+				}
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"worker-*", "5"})
+
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(Equal(1))
+				Expect(fakeCache.StopCallCount()).To(Equal(1))
+			})
+
+			It("doesn't start the cache with --no-wait", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--no-wait", "worker-*", "5"})
+
+				Expect(fakeCache.StartCallCount()).To(BeZero())
+			})
+
+			It("reports the apps that could not be placed or timed out", func() {
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+					"worker-a": {Running: 1, PlacementError: true},
+					"worker-b": {Running: 2},
+				}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, []string{"--timeout=5s", "worker-*", "5"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Scaling worker-b to 5 instances"))
+				clock.IncrementBySeconds(5)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Error, could not place all instances of worker-a: insufficient resources.")))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for worker-b to scale.")))
+				Expect(outputBuffer).To(test_helpers.SayLine("Lattice is still scaling your apps in the background."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Scaled 0 of 2 apps.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("does not wait when --no-wait is passed", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--no-wait", "worker-*", "5"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Scaling worker-b to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine("To view status:"))
+				Expect(outputBuffer).To(test_helpers.SayLine("\tltc list"))
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("scales the apps one after another with --batch", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 5}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--batch=5", "worker-*", "5"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Scaling worker-a to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+				Expect(outputBuffer).To(test_helpers.Say("Scaling worker-b to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+				Expect(scaledApps()).To(Equal([]string{"worker-a", "worker-b"}))
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(BeZero())
+			})
+
+			It("exits with NotFound when nothing matches", func() {
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"tmp-*", "5"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match tmp-*."))
+				Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("exits once when some of the apps fail to scale", func() {
+				appRunner.ScaleAppStub = func(name string, instances int) error {
+					if name == "worker-a" {
+						return errors.New("Major Fault")
+					}
+					return nil
+				}
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{
+					"worker-b": {Running: 5},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"worker-*", "5"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error Scaling App to 5 instances: Major Fault"))
+				Expect(outputBuffer).To(test_helpers.Say("Scaling worker-b to 5 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Scaled 1 of 2 apps.")))
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(2))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
 		It("polls until the required number of instances are running", func() {
 			args := []string{
 				"cool-web-app",
 				"22",
 			}
 
-			appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 
 			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, args)
 
 			Eventually(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 22 instances"))
 
-			Expect(appExaminer.RunningAppInstancesInfoCallCount()).To(Equal(1))
-			Expect(appExaminer.RunningAppInstancesInfoArgsForCall(0)).To(Equal("cool-web-app"))
+			Expect(appExaminer.AppInstanceCountsCallCount()).To(Equal(1))
+			Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
 
+			Eventually(outputBuffer).Should(test_helpers.Say("(1/22 instances running)."))
 			clock.IncrementBySeconds(1)
 			Eventually(outputBuffer).Should(test_helpers.Say("."))
 			clock.IncrementBySeconds(1)
 			Eventually(outputBuffer).Should(test_helpers.Say("."))
 
-			appExaminer.RunningAppInstancesInfoReturns(22, false, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 22}, nil)
 			clock.IncrementBySeconds(1)
 
 			Eventually(commandFinishChan).Should(BeClosed())
@@ -1010,9 +2327,47 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
 		})
 
+		It("reports the states of the instances and estimates the time left", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2, Starting: 8}, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, []string{"cool-web-app", "10"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("(2/10 instances running (8 starting))."))
+
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 4, Starting: 5, Crashed: 1}, nil)
+			clock.IncrementBySeconds(10)
+
+			Eventually(outputBuffer).Should(test_helpers.Say("(4/10 instances running (5 starting, 1 crashed), about 30s left)."))
+
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 10}, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("App Scaled Successfully")))
+		})
+
+		Context("when the --no-wait flag is passed", func() {
+			It("returns after submitting the scale request without polling", func() {
+				args := []string{
+					"--no-wait",
+					"cool-web-app",
+					"22",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(scaleCommand, args)
+
+				Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.Say("Scaling cool-web-app to 22 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine("To view status:\n\tltc status cool-web-app"))
+				Expect(outputBuffer).ToNot(test_helpers.Say("App Scaled Successfully"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
 		Context("when the app does not scale before the timeout elapses", func() {
 			It("alerts the user the app took too long to scale", func() {
-				appExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
 				args := []string{
 					"cool-web-app",
 					"22",
@@ -1034,6 +2389,8 @@ var _ = Describe("CommandFactory", func() {
 				Expect(outputBuffer).To(test_helpers.SayLine("To view logs:\n\tltc logs cool-web-app"))
 				Expect(outputBuffer).To(test_helpers.SayLine("To view status:\n\tltc status cool-web-app"))
 				Expect(outputBuffer).To(test_helpers.SayNewLine())
+				Expect(outputBuffer).ToNot(test_helpers.Say("App Scaled Successfully"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
 			})
 		})
 
@@ -1099,186 +2456,1380 @@ var _ = Describe("CommandFactory", func() {
 				}
 
 				dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
-				appExaminer.RunningAppInstancesInfoReturns(0, false, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
 
 				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(scaleCommand, args)
 
 				Eventually(outputBuffer).Should(test_helpers.Say("Scaling cool-web-app to 3 instances"))
 
-				Expect(appExaminer.RunningAppInstancesInfoCallCount()).To(Equal(1))
-				Expect(appExaminer.RunningAppInstancesInfoArgsForCall(0)).To(Equal("cool-web-app"))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(Equal(1))
+				Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
 
 				clock.IncrementBySeconds(1)
 				Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(0))
 				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
 
-				appExaminer.RunningAppInstancesInfoReturns(2, true, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2, PlacementError: true}, nil)
 				clock.IncrementBySeconds(1)
 				Eventually(commandFinishChan).Should(BeClosed())
 
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
 
 				Expect(outputBuffer).To(test_helpers.SayNewLine())
-				Expect(outputBuffer).To(test_helpers.Say(colors.Red("Error, could not place all instances: insufficient resources. Try requesting fewer instances or reducing the requested memory or disk capacity.")))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Error, could not place all instances of cool-web-app: insufficient resources.")))
+				Expect(outputBuffer).To(test_helpers.SayLine("Try requesting fewer instances or reducing the requested memory or disk capacity."))
 				Expect(outputBuffer).ToNot(test_helpers.Say("Timed out waiting for the container"))
 			})
 		})
 	})
 
+	Describe("StopAppCommand and StartAppCommand", func() {
+		var stopCommand, startCommand cli.Command
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:           appRunner,
+				AppExaminer:         appExaminer,
+				UI:                  terminalUI,
+				Clock:               clock,
+				Logger:              logger,
+				TailedLogsOutputter: fakeTailedLogsOutputter,
+				ExitHandler:         fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			stopCommand = commandFactory.MakeStopAppCommand()
+			startCommand = commandFactory.MakeStartAppCommand()
+		})
+
+		Describe("stop", func() {
+			It("stops the app and waits for its instances to go away", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(stopCommand, []string{"cool-web-app"})
+
+				Expect(appRunner.StopAppCallCount()).To(Equal(1))
+				Expect(appRunner.StopAppArgsForCall(0)).To(Equal("cool-web-app"))
+				Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
+
+				Expect(outputBuffer).To(test_helpers.Say("Stopping cool-web-app..."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Stopped cool-web-app.")))
+				Expect(outputBuffer).To(test_helpers.Say("ltc start cool-web-app"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("does not wait when --no-wait is passed", func() {
+				test_helpers.ExecuteCommandWithArgs(stopCommand, []string{"--no-wait", "cool-web-app"})
+
+				Expect(appRunner.StopAppCallCount()).To(Equal(1))
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine("Stopping cool-web-app."))
+			})
+
+			It("times out if the instances don't stop", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(stopCommand, []string{"--timeout=5s", "cool-web-app"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Stopping cool-web-app..."))
+				clock.IncrementBySeconds(6)
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool-web-app to stop.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+
+			It("backs off between polls up to the max poll interval", func() {
+				appRunnerCommandFactoryConfig.PollInterval = time.Second
+				appRunnerCommandFactoryConfig.MaxPollInterval = 4 * time.Second
+				stopCommand = command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig).MakeStopAppCommand()
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(stopCommand, []string{"cool-web-app"})
+
+				for poll, interval := range []uint64{1, 2, 4, 4} {
+					Eventually(appExaminer.AppInstanceCountsCallCount).Should(Equal(poll + 1))
+					Eventually(clock.WatcherCount).Should(Equal(1))
+
+					clock.IncrementBySeconds(interval - 1)
+					Consistently(appExaminer.AppInstanceCountsCallCount, "50ms").Should(Equal(poll + 1))
+					clock.IncrementBySeconds(1)
+				}
+
+				Eventually(appExaminer.AppInstanceCountsCallCount).Should(Equal(5))
+				Eventually(clock.WatcherCount).Should(Equal(1))
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
+				clock.IncrementBySeconds(4)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Stopped cool-web-app.")))
+			})
+
+			It("stops polling when ltc exits", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(stopCommand, []string{"cool-web-app"})
+
+				Eventually(clock.WatcherCount).Should(Equal(1))
+				fakeExitHandler.Exit(exit_codes.SigInt)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(Equal(1))
+			})
+
+			It("prints errors from the app runner", func() {
+				appRunner.StopAppReturns(errors.New("cool-web-app is already stopped."))
+
+				test_helpers.ExecuteCommandWithArgs(stopCommand, []string{"cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error stopping cool-web-app: cool-web-app is already stopped."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("validates that the name is passed in", func() {
+				test_helpers.ExecuteCommandWithArgs(stopCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc stop APP_NAME'"))
+				Expect(appRunner.StopAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Describe("start", func() {
+			It("starts the app and waits for the restored instances", func() {
+				appRunner.StartAppReturns(3, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app"})
+
+				Expect(appRunner.StartAppCallCount()).To(Equal(1))
+				Expect(appRunner.StartAppArgsForCall(0)).To(Equal("cool-web-app"))
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Starting cool-web-app with 3 instances"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("App Started Successfully")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("does not wait when --no-wait is passed", func() {
+				appRunner.StartAppReturns(3, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"--no-wait", "cool-web-app"})
+
+				Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine("Starting cool-web-app with 3 instances"))
+				Expect(outputBuffer).To(test_helpers.Say("ltc status cool-web-app"))
+			})
+
+			It("reports placement errors", func() {
+				appRunner.StartAppReturns(3, nil)
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, PlacementError: true}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error, could not place all instances"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+			})
+
+			It("prints errors from the app runner", func() {
+				appRunner.StartAppReturns(0, errors.New("cool-web-app is not stopped."))
+
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{"cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error starting cool-web-app: cool-web-app is not stopped."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("validates that the name is passed in", func() {
+				test_helpers.ExecuteCommandWithArgs(startCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc start APP_NAME'"))
+				Expect(appRunner.StartAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+
+	Describe("WaitCommand", func() {
+		var (
+			waitCommand   cli.Command
+			lookedUpHosts []string
+			lookupErr     error
+		)
+
+		BeforeEach(func() {
+			lookedUpHosts = []string{}
+			lookupErr = nil
+
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:           appRunner,
+				AppExaminer:         appExaminer,
+				UI:                  terminalUI,
+				Clock:               clock,
+				Logger:              logger,
+				TailedLogsOutputter: fakeTailedLogsOutputter,
+				ExitHandler:         fakeExitHandler,
+				LookupHost: func(host string) ([]string, error) {
+					lookedUpHosts = append(lookedUpHosts, host)
+					return []string{"192.168.11.11"}, lookupErr
+				},
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			waitCommand = commandFactory.MakeWaitCommand()
+
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{
+				DesiredInstances: 3,
+				Routes: route_helpers.AppRoutes{
+					{Hostnames: []string{"cool-web-app.192.168.11.11.xip.io", "api.example.com/v1"}, Port: 8080},
+					{Hostnames: []string{"api.example.com/v2"}, Port: 9090},
+				},
+			}, nil)
+		})
+
+		It("waits for all of the app's instances to be running", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, Starting: 2}, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(waitCommand, []string{"cool-web-app"})
+
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("cool-web-app"))
+			Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app"))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("cool-web-app is running.")))
+			Expect(lookedUpHosts).To(BeEmpty())
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("waits for at least --instances to be running", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"--instances=2", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("cool-web-app is running.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("times out if the instances aren't running", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, Starting: 2}, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(waitCommand, []string{"--timeout=5s", "cool-web-app"})
+
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			clock.IncrementBySeconds(6)
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for 3 instance(s) of cool-web-app to be running.")))
+			Expect(outputBuffer).To(test_helpers.Say("ltc logs cool-web-app"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+		})
+
+		It("reports placement errors", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, PlacementError: true}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error, could not place all instances"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+		})
+
+		Context("with --routes", func() {
+			It("waits for each of the app's hostnames to resolve", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"--routes", "cool-web-app"})
+
+				Expect(lookedUpHosts).To(Equal([]string{"cool-web-app.192.168.11.11.xip.io", "api.example.com"}))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("cool-web-app is running.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("doesn't look up hostnames until the instances are running", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, Starting: 2}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(waitCommand, []string{"--routes", "--timeout=5s", "cool-web-app"})
+
+				Eventually(clock.WatcherCount).Should(Equal(1))
+				clock.IncrementBySeconds(6)
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(lookedUpHosts).To(BeEmpty())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+
+			It("times out if a hostname doesn't resolve", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+				lookupErr = errors.New("no such host")
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(waitCommand, []string{"--routes", "--timeout=5s", "cool-web-app"})
+
+				Eventually(clock.WatcherCount).Should(Equal(1))
+				clock.IncrementBySeconds(6)
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool-web-app.192.168.11.11.xip.io, api.example.com to resolve.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+		})
+
+		Context("with --state stopped", func() {
+			It("waits for the app's instances to stop", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"--state=stopped", "cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Waiting for cool-web-app to stop..."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("cool-web-app is stopped.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("times out if the instances don't stop", func() {
+				appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(waitCommand, []string{"--state=stopped", "--timeout=5s", "cool-web-app"})
+
+				Eventually(clock.WatcherCount).Should(Equal(1))
+				clock.IncrementBySeconds(6)
+				Eventually(commandFinishChan).Should(BeClosed())
+
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool-web-app to stop.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+
+			It("rejects --instances and --routes", func() {
+				test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"--state=stopped", "--routes", "cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --instances and --routes only apply to --state running"))
+				Expect(appExaminer.AppStatusCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("exits with NotFound when the app doesn't exist", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New(app_examiner.AppNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error waiting for cool-web-app: " + app_examiner.AppNotFoundErrorMessage))
+			Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(waitCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc wait APP_NAME'"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("validates the state", func() {
+			test_helpers.ExecuteCommandWithArgs(waitCommand, []string{"--state=crashed", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --state must be running or stopped"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("ResizeAppCommand", func() {
+		var (
+			resizeCommand cli.Command
+			definition    receptor.DesiredLRPCreateRequest
+		)
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:           appRunner,
+				AppExaminer:         appExaminer,
+				UI:                  terminalUI,
+				Domain:              domain,
+				Clock:               clock,
+				Logger:              logger,
+				TailedLogsOutputter: fakeTailedLogsOutputter,
+				ExitHandler:         fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			resizeCommand = commandFactory.MakeResizeAppCommand()
+
+			definition = receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", MetricsGuid: "cool-web-app", LogGuid: "cool-web-app", Instances: 2, MemoryMB: 128, DiskMB: 1024}
+			appRunner.AppDefinitionReturns(definition, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+		})
+
+		It("starts a resized stand-in, recreates the app and then removes the stand-in", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"cool-web-app", "--memory-mb=512", "-d", "2048"})
+
+			Expect(appExaminer.AppExistsArgsForCall(0)).To(Equal("cool-web-app-resizing"))
+			Expect(appRunner.RestoreAppCallCount()).To(Equal(2))
+			Expect(appRunner.RestoreAppArgsForCall(0)).To(Equal(receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app-resizing", MetricsGuid: "cool-web-app-resizing", LogGuid: "cool-web-app", Instances: 2, MemoryMB: 512, DiskMB: 2048}))
+			Expect(appRunner.RestoreAppArgsForCall(1)).To(Equal(receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", MetricsGuid: "cool-web-app", LogGuid: "cool-web-app", Instances: 2, MemoryMB: 512, DiskMB: 2048}))
+			Expect(appExaminer.AppInstanceCountsArgsForCall(0)).To(Equal("cool-web-app-resizing"))
+			Expect(appExaminer.AppInstanceCountsArgsForCall(1)).To(Equal("cool-web-app"))
+			Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+			Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-resizing"))
+
+			Expect(outputBuffer).To(test_helpers.Say("Starting cool-web-app-resizing to serve cool-web-app while it is recreated..."))
+			Expect(outputBuffer).To(test_helpers.Say("Recreating cool-web-app with 512 MB of memory and 2048 MB of disk..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Resized cool-web-app.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("keeps the limit that isn't passed", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=0", "cool-web-app"})
+
+			Expect(appRunner.RestoreAppArgsForCall(1).MemoryMB).To(Equal(0))
+			Expect(appRunner.RestoreAppArgsForCall(1).DiskMB).To(Equal(1024))
+		})
+
+		It("changes the CPU weight", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--cpu-weight=25", "cool-web-app"})
+
+			Expect(appRunner.RestoreAppArgsForCall(1).CPUWeight).To(Equal(uint(25)))
+			Expect(appRunner.RestoreAppArgsForCall(1).MemoryMB).To(Equal(128))
+			Expect(outputBuffer).To(test_helpers.Say("Recreating cool-web-app with 128 MB of memory, 1024 MB of disk and a CPU weight of 25..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Resized cool-web-app.")))
+		})
+
+		It("recreates the app without a stand-in with --recreate", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "--recreate", "cool-web-app"})
+
+			Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+			Expect(appRunner.RestoreAppArgsForCall(0).ProcessGuid).To(Equal("cool-web-app"))
+			Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Resized cool-web-app.")))
+		})
+
+		It("recreates a stopped app without waiting", func() {
+			definition.Instances = 0
+			appRunner.AppDefinitionReturns(definition, nil)
+
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "cool-web-app"})
+
+			Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+			Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Resized cool-web-app.")))
+		})
+
+		It("does nothing when the limits are unchanged", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=128", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app already has those limits."))
+			Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+		})
+
+		Context("when the stand-in can't be placed", func() {
+			BeforeEach(func() {
+				appExaminer.AppInstanceCountsStub = func(name string) (app_examiner.InstanceCounts, error) {
+					return app_examiner.InstanceCounts{PlacementError: name == "cool-web-app-resizing"}, nil
+				}
+			})
+
+			It("removes the stand-in and leaves the app alone", func() {
+				test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "cool-web-app"})
+
+				Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-resizing"))
+				Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app was left as it was."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+			})
+		})
+
+		Context("when the recreated app can't be placed", func() {
+			BeforeEach(func() {
+				appExaminer.AppInstanceCountsStub = func(name string) (app_examiner.InstanceCounts, error) {
+					if name == "cool-web-app" {
+						return app_examiner.InstanceCounts{PlacementError: true}, nil
+					}
+					return app_examiner.InstanceCounts{Running: 2}, nil
+				}
+			})
+
+			It("leaves the stand-in serving", func() {
+				test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "cool-web-app"})
+
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app-resizing is still serving cool-web-app's routes.  Remove it with 'ltc remove cool-web-app-resizing' once cool-web-app is running."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+			})
+		})
+
+		It("refuses to replace an existing app with the stand-in's name", func() {
+			appExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app-resizing already exists. Remove it, or resize cool-web-app with --recreate."))
+			Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits when the app doesn't exist", func() {
+			appRunner.AppDefinitionReturns(receptor.DesiredLRPCreateRequest{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error resizing cool-web-app: not found"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("validates its arguments", func() {
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--memory-mb=512"})
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"cool-web-app"})
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--disk-mb=-1", "cool-web-app"})
+			test_helpers.ExecuteCommandWithArgs(resizeCommand, []string{"--cpu-weight=0", "cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc resize APP_NAME [--memory-mb=MEMORY_MB] [--disk-mb=DISK_MB] [--cpu-weight=CPU_WEIGHT]'"))
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Pass --memory-mb, --disk-mb, --cpu-weight or a combination of them"))
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Memory and disk limits can't be negative"))
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: CPU weight must be between 1 and 100"))
+			Expect(appRunner.AppDefinitionCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax, exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("RollbackAppCommand", func() {
+		var (
+			rollbackCommand cli.Command
+			fakeAuditLog    *fake_audit_log.FakeLog
+			v1, v2          receptor.DesiredLRPCreateRequest
+		)
+
+		BeforeEach(func() {
+			fakeAuditLog = &fake_audit_log.FakeLog{}
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:           appRunner,
+				AppExaminer:         appExaminer,
+				UI:                  terminalUI,
+				Domain:              domain,
+				Clock:               clock,
+				Logger:              logger,
+				TailedLogsOutputter: fakeTailedLogsOutputter,
+				ExitHandler:         fakeExitHandler,
+				AuditLog:            fakeAuditLog,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			rollbackCommand = commandFactory.MakeRollbackAppCommand()
+
+			v1 = receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", RootFS: "docker:///cool/app#v1", Instances: 2}
+			v2 = receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", RootFS: "docker:///cool/app#v2", Instances: 2}
+			other := receptor.DesiredLRPCreateRequest{ProcessGuid: "other-app", RootFS: "docker:///other#v1", Instances: 1}
+			failed := receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", RootFS: "docker:///cool/app#broken", Instances: 2}
+			elsewhere := receptor.DesiredLRPCreateRequest{ProcessGuid: "cool-web-app", RootFS: "docker:///cool/app#v0", Instances: 2}
+
+			fakeAuditLog.EntriesReturns([]audit.Entry{
+				{Target: domain, Command: "create", Args: []string{"create", "cool-web-app", "cool/app:v1"}, Revision: &v1},
+				{Target: domain, Command: "create", Args: []string{"create", "other-app", "other"}, Revision: &other},
+				{Target: "other.example.com", Command: "create", Args: []string{"create", "cool-web-app", "cool/app:v0"}, Revision: &elsewhere},
+				{Target: domain, Command: "scale", Args: []string{"scale", "cool-web-app", "2"}, Revision: &v2},
+				{Target: domain, Command: "scale", Args: []string{"scale", "cool-web-app", "2"}, ExitCode: exit_codes.Timeout, Revision: &failed},
+			}, nil)
+			appExaminer.AppExistsReturns(true, nil)
+			appRunner.AppDefinitionReturns(v2, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+		})
+
+		It("restores the latest recorded revision that differs from the app", func() {
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(appRunner.AppDefinitionArgsForCall(0)).To(Equal("cool-web-app"))
+			Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+			Expect(appRunner.RestoreAppArgsForCall(0)).To(Equal(v1))
+
+			Expect(outputBuffer).To(test_helpers.Say("Rolling cool-web-app back to its configuration from"))
+			Expect(outputBuffer).To(test_helpers.Say("(ltc create cool-web-app cool/app:v1)"))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Rolled back cool-web-app.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("restores the latest revision when the app no longer exists", func() {
+			appExaminer.AppExistsReturns(false, nil)
+
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(appRunner.AppDefinitionCallCount()).To(BeZero())
+			Expect(appRunner.RestoreAppArgsForCall(0)).To(Equal(v2))
+		})
+
+		It("does not wait when --no-wait is passed", func() {
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"--no-wait", "cool-web-app"})
+
+			Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+			Expect(appExaminer.AppInstanceCountsCallCount()).To(BeZero())
+			Expect(outputBuffer).To(test_helpers.Say("ltc status cool-web-app"))
+		})
+
+		It("reports placement errors", func() {
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1, PlacementError: true}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error, could not place all instances"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+		})
+
+		It("exits when there is no earlier revision", func() {
+			appRunner.AppDefinitionReturns(v1, nil)
+			fakeAuditLog.EntriesReturns([]audit.Entry{
+				{Target: domain, Command: "create", Args: []string{"create", "cool-web-app", "cool/app:v1"}, Revision: &v1},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No earlier revision of cool-web-app is recorded for 192.168.11.11.xip.io."))
+			Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("exits when the history can't be read", func() {
+			fakeAuditLog.EntriesReturns(nil, errors.New("permission denied"))
+
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error reading the history: permission denied"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+		})
+
+		It("prints errors from the app runner", func() {
+			appRunner.RestoreAppReturns(false, errors.New("receptor down"))
+
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error rolling back cool-web-app: receptor down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("validates that the name is passed in", func() {
+			test_helpers.ExecuteCommandWithArgs(rollbackCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc rollback APP_NAME'"))
+			Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
 	Describe("UpdateRoutesCommand", func() {
 		var updateRoutesCommand cli.Command
 
-		BeforeEach(func() {
-			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
-				AppRunner:   appRunner,
-				AppExaminer: appExaminer,
-				UI:          terminalUI,
-				DockerMetadataFetcher: dockerMetadataFetcher,
-				Domain:                domain,
-				Env:                   []string{},
-				Clock:                 clock,
-				Logger:                logger,
-				ExitHandler:           fakeExitHandler,
-			}
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				DryRunAppRunner: dryRunAppRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+				ExitHandler:           fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			updateRoutesCommand = commandFactory.MakeUpdateRoutesCommand()
+		})
+
+		It("updates the routes", func() {
+			args := []string{
+				"cool-web-app",
+				"8080:foo,9090:bar.com/v1",
+			}
+
+			expectedRouteOverrides := docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{
+					HostnamePrefix: "foo",
+					Port:           8080,
+				},
+				docker_app_runner.RouteOverride{
+					Hostname: "bar.com",
+					Path:     "/v1",
+					Port:     9090,
+				},
+			}
+
+			test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+			Expect(outputBuffer).To(test_helpers.Say("Updating cool-web-app routes. You can check this app's current routes by running 'ltc status cool-web-app'"))
+			Expect(dryRunAppRunner.UpdateAppRoutesCallCount()).To(BeZero())
+
+			Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
+
+			name, routeOverrides := appRunner.UpdateAppRoutesArgsForCall(0)
+
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(routeOverrides).To(Equal(expectedRouteOverrides))
+		})
+
+		Context("when the --no-routes flag is passed", func() {
+			It("deregisters all the routes", func() {
+				args := []string{
+					"cool-web-app",
+					"--no-routes",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
+				name, routeOverrides := appRunner.UpdateAppRoutesArgsForCall(0)
+
+				Expect(name).To(Equal("cool-web-app"))
+				Expect(routeOverrides).To(Equal(docker_app_runner.RouteOverrides{}))
+
+				Expect(outputBuffer).To(test_helpers.Say("Updating cool-web-app routes. You can check this app's current routes by running 'ltc status cool-web-app'"))
+			})
+		})
+
+		Context("when the receptor returns errors", func() {
+			It("outputs error messages", func() {
+				args := []string{
+					"cool-web-app",
+					"8080:foo.com",
+				}
+
+				appRunner.UpdateAppRoutesReturns(errors.New("Major Fault"))
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Error updating routes: Major Fault"))
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
+		Context("invalid syntax", func() {
+			It("validates that the name is passed in", func() {
+				args := []string{
+					"",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag."))
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("validates that the routes are passed in", func() {
+				args := []string{
+					"cool-web-app",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag."))
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("malformed route", func() {
+			It("errors out when the port is not an int", func() {
+				args := []string{
+					"cool-web-app",
+					"woo:aahh",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("errors out when there is no colon", func() {
+				args := []string{
+					"cool-web-app",
+					"8888",
+				}
+
+				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+
+				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
+				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+	})
+
+	Describe("UpdateRoutesCommand with --dry-run", func() {
+		It("only prints the request", func() {
+			commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:       appRunner,
+				DryRunAppRunner: dryRunAppRunner,
+				UI:              terminalUI,
+				ExitHandler:     fakeExitHandler,
+			})
+
+			test_helpers.ExecuteCommandWithArgs(commandFactory.MakeUpdateRoutesCommand(), []string{"cool-web-app", "8080:foo", "--dry-run"})
+
+			Expect(dryRunAppRunner.UpdateAppRoutesCallCount()).To(Equal(1))
+			Expect(appRunner.UpdateAppRoutesCallCount()).To(BeZero())
+			Expect(outputBuffer).To(test_helpers.SayLine(command_factory.DryRunMessage))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+	})
+
+	Describe("MapRouteCommand and UnmapRouteCommand", func() {
+		var (
+			mapRouteCommand   cli.Command
+			unmapRouteCommand cli.Command
+		)
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+				ExitHandler:           fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			mapRouteCommand = commandFactory.MakeMapRouteCommand()
+			unmapRouteCommand = commandFactory.MakeUnmapRouteCommand()
+		})
+
+		It("maps the route", func() {
+			test_helpers.ExecuteCommandWithArgs(mapRouteCommand, []string{"cool-web-app", "8080:api"})
+
+			Expect(appRunner.AddRouteCallCount()).To(Equal(1))
+			name, route := appRunner.AddRouteArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(route).To(Equal(docker_app_runner.RouteOverride{HostnamePrefix: "api", Port: 8080}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Mapped api.192.168.11.11.xip.io to port 8080 of cool-web-app."))
+		})
+
+		It("unmaps the route", func() {
+			test_helpers.ExecuteCommandWithArgs(unmapRouteCommand, []string{"cool-web-app", "8080:api"})
+
+			Expect(appRunner.RemoveRouteCallCount()).To(Equal(1))
+			name, route := appRunner.RemoveRouteArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(route).To(Equal(docker_app_runner.RouteOverride{HostnamePrefix: "api", Port: 8080}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Unmapped api.192.168.11.11.xip.io from port 8080 of cool-web-app."))
+		})
+
+		It("requires APP_NAME and PORT:ROUTE", func() {
+			test_helpers.ExecuteCommandWithArgs(mapRouteCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc map-route APP_NAME PORT:ROUTE'"))
+			Expect(appRunner.AddRouteCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("accepts a single well-formed route only", func() {
+			test_helpers.ExecuteCommandWithArgs(unmapRouteCommand, []string{"cool-web-app", "8080:api,9090:admin"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(command_factory.MalformedRouteErrorMessage))
+			Expect(appRunner.RemoveRouteCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("when the app runner returns an error", func() {
+			It("prints the error when mapping", func() {
+				appRunner.AddRouteReturns(errors.New("Major Fault"))
+
+				test_helpers.ExecuteCommandWithArgs(mapRouteCommand, []string{"cool-web-app", "8080:api"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error mapping route: Major Fault"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("prints the error when unmapping", func() {
+				appRunner.RemoveRouteReturns(errors.New("Major Fault"))
+
+				test_helpers.ExecuteCommandWithArgs(unmapRouteCommand, []string{"cool-web-app", "8080:api"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error unmapping route: Major Fault"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+	})
+
+	Describe("RestartInstanceCommand", func() {
+		var restartCommand cli.Command
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				Clock:       clock,
+				Logger:      logger,
+				ExitHandler: fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			restartCommand = commandFactory.MakeRestartInstanceCommand()
+
+			appExaminer.AppStatusStub = func(appName string) (app_examiner.AppInfo, error) {
+				instanceGuid := "old-guid"
+				if appRunner.RestartInstanceCallCount() > 0 {
+					instanceGuid = "new-guid"
+				}
+				return app_examiner.AppInfo{
+					ProcessGuid: appName,
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, InstanceGuid: "other-guid", State: "RUNNING"},
+						{Index: 1, InstanceGuid: instanceGuid, State: "RUNNING"},
+					},
+				}, nil
+			}
+		})
+
+		It("restarts the instance and waits for its replacement to run", func() {
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"cool-web-app/1"})
+
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(1))
+			name, index := appRunner.RestartInstanceArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(index).To(Equal(1))
+
+			Expect(outputBuffer).To(test_helpers.Say("Restarting cool-web-app/1..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Restarted cool-web-app/1.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("does not wait when --no-wait is passed", func() {
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"--no-wait", "cool-web-app/1"})
+
+			Expect(appRunner.RestartInstanceCallCount()).To(Equal(1))
+			Expect(appExaminer.AppStatusCallCount()).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.SayLine("Restarting cool-web-app/1."))
+			Expect(outputBuffer).To(test_helpers.Say("ltc status cool-web-app/1"))
+		})
+
+		It("times out if the instance doesn't come back", func() {
+			appExaminer.AppStatusStub = nil
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{
+				ActualInstances: []app_examiner.InstanceInfo{{Index: 1, InstanceGuid: "old-guid", State: "RUNNING"}},
+			}, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(restartCommand, []string{"--timeout=5s", "cool-web-app/1"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Restarting cool-web-app/1..."))
+			clock.IncrementBySeconds(6)
+			Eventually(commandFinishChan).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool-web-app/1 to restart.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+		})
+
+		It("prints errors from the app runner", func() {
+			appRunner.RestartInstanceReturns(errors.New("cool-web-app has no instance 1."))
+
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"cool-web-app/1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error restarting cool-web-app/1: cool-web-app has no instance 1."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("requires an instance index", func() {
+			test_helpers.ExecuteCommandWithArgs(restartCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(appRunner.RestartInstanceCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("LabelAppCommand", func() {
+		var labelCommand cli.Command
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				DockerMetadataFetcher: dockerMetadataFetcher,
+				Domain:                domain,
+				Env:                   []string{},
+				Clock:                 clock,
+				Logger:                logger,
+				ExitHandler:           fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			labelCommand = commandFactory.MakeLabelAppCommand()
+		})
+
+		It("sets and removes labels", func() {
+			test_helpers.ExecuteCommandWithArgs(labelCommand, []string{"cool-web-app", "version=1.2.4", "tier-"})
+
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(Equal(1))
+			name, labels := appRunner.UpdateAppLabelsArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(labels).To(Equal(map[string]string{"version": "1.2.4", "tier": ""}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Updated the labels of cool-web-app."))
+		})
+
+		It("requires APP_NAME and a label", func() {
+			test_helpers.ExecuteCommandWithArgs(labelCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc label APP_NAME KEY=VALUE... [KEY-...]'"))
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("rejects malformed labels", func() {
+			for _, label := range []string{"version", "=1.2.4", "version=", "version=1,2", "-"} {
+				test_helpers.ExecuteCommandWithArgs(labelCommand, []string{"cool-web-app", label})
+			}
+
+			Expect(outputBuffer).To(test_helpers.SayLine(command_factory.MalformedLabelErrorMessage))
+			Expect(appRunner.UpdateAppLabelsCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(HaveLen(5))
+		})
+
+		It("prints errors from the app runner", func() {
+			appRunner.UpdateAppLabelsReturns(errors.New("cool-web-app is not started."))
+
+			test_helpers.ExecuteCommandWithArgs(labelCommand, []string{"cool-web-app", "version=1.2.4"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error labelling app: cool-web-app is not started."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	Describe("BindLogDrainCommand, UnbindLogDrainCommand and LogDrainsCommand", func() {
+		var (
+			bindLogDrainCommand   cli.Command
+			unbindLogDrainCommand cli.Command
+			logDrainsCommand      cli.Command
+		)
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:   appRunner,
+				AppExaminer: appExaminer,
+				UI:          terminalUI,
+				Domain:      domain,
+				Env:         []string{},
+				Clock:       clock,
+				Logger:      logger,
+				ExitHandler: fakeExitHandler,
+			}
+
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			bindLogDrainCommand = commandFactory.MakeBindLogDrainCommand()
+			unbindLogDrainCommand = commandFactory.MakeUnbindLogDrainCommand()
+			logDrainsCommand = commandFactory.MakeLogDrainsCommand()
+		})
+
+		It("binds a drain to an app", func() {
+			test_helpers.ExecuteCommandWithArgs(bindLogDrainCommand, []string{"cool-web-app", "syslog://logs.example.com:514"})
+
+			Expect(appRunner.BindLogDrainCallCount()).To(Equal(1))
+			name, drainURL := appRunner.BindLogDrainArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(drainURL).To(Equal("syslog://logs.example.com:514"))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Bound syslog://logs.example.com:514 to cool-web-app."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Its logs are forwarded while 'ltc drain-logs' is running."))
+		})
+
+		It("rejects drains ltc can't forward to", func() {
+			test_helpers.ExecuteCommandWithArgs(bindLogDrainCommand, []string{"cool-web-app", "http://logs.example.com"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Invalid log drain http://logs.example.com: the scheme must be syslog, syslog-tls or https"))
+			Expect(appRunner.BindLogDrainCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("requires APP_NAME and DRAIN_URL", func() {
+			test_helpers.ExecuteCommandWithArgs(bindLogDrainCommand, []string{"cool-web-app"})
+			test_helpers.ExecuteCommandWithArgs(unbindLogDrainCommand, []string{"cool-web-app", "syslog://logs.example.com:514", "extra"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc bind-log-drain APP_NAME DRAIN_URL'"))
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc unbind-log-drain APP_NAME DRAIN_URL'"))
+			Expect(appRunner.BindLogDrainCallCount()).To(BeZero())
+			Expect(appRunner.UnbindLogDrainCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+		})
+
+		It("prints errors binding a drain", func() {
+			appRunner.BindLogDrainReturns(errors.New("cool-web-app already drains its logs to syslog://logs.example.com:514."))
+
+			test_helpers.ExecuteCommandWithArgs(bindLogDrainCommand, []string{"cool-web-app", "syslog://logs.example.com:514"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error binding log drain: cool-web-app already drains its logs to syslog://logs.example.com:514."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("unbinds a drain from an app", func() {
+			test_helpers.ExecuteCommandWithArgs(unbindLogDrainCommand, []string{"cool-web-app", "syslog://logs.example.com:514"})
+
+			Expect(appRunner.UnbindLogDrainCallCount()).To(Equal(1))
+			name, drainURL := appRunner.UnbindLogDrainArgsForCall(0)
+			Expect(name).To(Equal("cool-web-app"))
+			Expect(drainURL).To(Equal("syslog://logs.example.com:514"))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Unbound syslog://logs.example.com:514 from cool-web-app."))
+		})
+
+		It("exits with NotFound unbinding a drain that isn't bound", func() {
+			appRunner.UnbindLogDrainReturns(notFoundError("cool-web-app has no log drain syslog://logs.example.com:514."))
+
+			test_helpers.ExecuteCommandWithArgs(unbindLogDrainCommand, []string{"cool-web-app", "syslog://logs.example.com:514"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error unbinding log drain: cool-web-app has no log drain syslog://logs.example.com:514."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("lists the drains of an app", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-web-app", LogDrains: []string{"syslog://logs.example.com:514", "https://logs.example.com/drain"}}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(logDrainsCommand, []string{"cool-web-app"})
+
+			Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("cool-web-app"))
+			Expect(outputBuffer).To(test_helpers.SayLine("syslog://logs.example.com:514"))
+			Expect(outputBuffer).To(test_helpers.SayLine("https://logs.example.com/drain"))
+		})
+
+		It("says when an app has no drains", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-web-app"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(logDrainsCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app has no log drains."))
+		})
 
-			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
-			updateRoutesCommand = commandFactory.MakeUpdateRoutesCommand()
+		It("prints errors examining the app", func() {
+			appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New("receptor down"))
+
+			test_helpers.ExecuteCommandWithArgs(logDrainsCommand, []string{"cool-web-app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error getting the log drains of cool-web-app: receptor down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 		})
+	})
 
-		It("updates the routes", func() {
-			args := []string{
-				"cool-web-app",
-				"8080:foo.com,9090:bar.com",
+	Describe("EnvCommand, SetEnvCommand and UnsetEnvCommand", func() {
+		var (
+			envCommand      cli.Command
+			setEnvCommand   cli.Command
+			unsetEnvCommand cli.Command
+			definition      receptor.DesiredLRPCreateRequest
+		)
+
+		BeforeEach(func() {
+			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
+				AppRunner:           appRunner,
+				AppExaminer:         appExaminer,
+				UI:                  terminalUI,
+				Domain:              domain,
+				Env:                 []string{"DB_PASSWORD=from-env"},
+				Clock:               clock,
+				Logger:              logger,
+				TailedLogsOutputter: fakeTailedLogsOutputter,
+				ExitHandler:         fakeExitHandler,
 			}
 
-			expectedRouteOverrides := docker_app_runner.RouteOverrides{
-				docker_app_runner.RouteOverride{
-					HostnamePrefix: "foo.com",
-					Port:           8080,
-				},
-				docker_app_runner.RouteOverride{
-					HostnamePrefix: "bar.com",
-					Port:           9090,
+			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
+			envCommand = commandFactory.MakeEnvCommand()
+			setEnvCommand = commandFactory.MakeSetEnvCommand()
+			unsetEnvCommand = commandFactory.MakeUnsetEnvCommand()
+
+			definition = receptor.DesiredLRPCreateRequest{
+				ProcessGuid: "cool-web-app",
+				Instances:   2,
+				EnvironmentVariables: []receptor.EnvironmentVariable{
+					{Name: "PROCESS_GUID", Value: "cool-web-app"},
+					{Name: "COLOR", Value: "blue"},
+					{Name: "API_TOKEN", Value: "abc123"},
 				},
 			}
+			appRunner.AppDefinitionReturns(definition, nil)
+			appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+		})
 
-			test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
-
-			Expect(outputBuffer).To(test_helpers.Say("Updating cool-web-app routes. You can check this app's current routes by running 'ltc status cool-web-app'"))
+		Describe("env", func() {
+			It("prints the env vars by name, masking secrets", func() {
+				test_helpers.ExecuteCommandWithArgs(envCommand, []string{"cool-web-app"})
 
-			Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
+				Expect(appRunner.AppDefinitionArgsForCall(0)).To(Equal("cool-web-app"))
+				Expect(outputBuffer).To(test_helpers.SayLine("API_TOKEN=********"))
+				Expect(outputBuffer).To(test_helpers.SayLine("COLOR=blue"))
+				Expect(outputBuffer).To(test_helpers.SayLine("PROCESS_GUID=cool-web-app"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Values that look like secrets are masked.  Pass --show-secrets to show them."))
+			})
 
-			name, routeOverrides := appRunner.UpdateAppRoutesArgsForCall(0)
+			It("shows secrets with --show-secrets", func() {
+				test_helpers.ExecuteCommandWithArgs(envCommand, []string{"--show-secrets", "cool-web-app"})
 
-			Expect(name).To(Equal("cool-web-app"))
-			Expect(routeOverrides).To(Equal(expectedRouteOverrides))
-		})
+				Expect(outputBuffer).To(test_helpers.SayLine("API_TOKEN=abc123"))
+				Expect(outputBuffer).NotTo(test_helpers.Say("masked"))
+			})
 
-		Context("when the --no-routes flag is passed", func() {
-			It("deregisters all the routes", func() {
-				args := []string{
-					"cool-web-app",
-					"--no-routes",
-				}
+			It("exits when the app doesn't exist", func() {
+				appRunner.AppDefinitionReturns(receptor.DesiredLRPCreateRequest{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
 
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+				test_helpers.ExecuteCommandWithArgs(envCommand, []string{"cool-web-app"})
 
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
-				name, routeOverrides := appRunner.UpdateAppRoutesArgsForCall(0)
+				Expect(outputBuffer).To(test_helpers.SayLine("Error getting the environment of cool-web-app: not found"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
 
-				Expect(name).To(Equal("cool-web-app"))
-				Expect(routeOverrides).To(Equal(docker_app_runner.RouteOverrides{}))
+			It("requires APP_NAME", func() {
+				test_helpers.ExecuteCommandWithArgs(envCommand, []string{})
 
-				Expect(outputBuffer).To(test_helpers.Say("Updating cool-web-app routes. You can check this app's current routes by running 'ltc status cool-web-app'"))
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc env APP_NAME [--show-secrets]'"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 		})
 
-		Context("when the receptor returns errors", func() {
-			It("outputs error messages", func() {
-				args := []string{
-					"cool-web-app",
-					"8080:foo.com",
-				}
+		Describe("set-env", func() {
+			It("sets env vars behind a stand-in, keeping the others", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", "COLOR=green", "SIZE=large", "DB_PASSWORD"})
+
+				Expect(appExaminer.AppExistsArgsForCall(0)).To(Equal("cool-web-app-updating"))
+				Expect(appRunner.RestoreAppCallCount()).To(Equal(2))
+				Expect(appRunner.RestoreAppArgsForCall(0).ProcessGuid).To(Equal("cool-web-app-updating"))
+				Expect(appRunner.RestoreAppArgsForCall(1).EnvironmentVariables).To(Equal([]receptor.EnvironmentVariable{
+					{Name: "PROCESS_GUID", Value: "cool-web-app"},
+					{Name: "COLOR", Value: "green"},
+					{Name: "API_TOKEN", Value: "abc123"},
+					{Name: "DB_PASSWORD", Value: "from-env"},
+					{Name: "SIZE", Value: "large"},
+				}))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool-web-app-updating"))
+
+				Expect(outputBuffer).To(test_helpers.Say("Starting cool-web-app-updating to serve cool-web-app while it is recreated..."))
+				Expect(outputBuffer).To(test_helpers.Say("Recreating cool-web-app with the new environment..."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Updated the environment of cool-web-app.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
 
-				appRunner.UpdateAppRoutesReturns(errors.New("Major Fault"))
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+			It("warns about env vars given more than once", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", "COLOR=green", "COLOR=red"})
 
-				Expect(outputBuffer).To(test_helpers.Say("Error updating routes: Major Fault"))
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(1))
-				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				Expect(outputBuffer).To(test_helpers.SayLine("Warning: COLOR is given more than once, so its last value will be used."))
+				Expect(appRunner.RestoreAppArgsForCall(1).EnvironmentVariables).To(ContainElement(receptor.EnvironmentVariable{Name: "COLOR", Value: "red"}))
 			})
-		})
 
-		Context("invalid syntax", func() {
-			It("validates that the name is passed in", func() {
-				args := []string{
-					"",
-				}
+			It("recreates the app without a stand-in with --recreate", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"--recreate", "cool-web-app", "COLOR=green"})
 
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+				Expect(appRunner.RestoreAppCallCount()).To(Equal(1))
+				Expect(appRunner.RestoreAppArgsForCall(0).ProcessGuid).To(Equal("cool-web-app"))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+			})
 
-				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag."))
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
+			It("does nothing when the env vars are already set", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", "COLOR=blue"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app already has that environment."))
+				Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+			})
+
+			It("refuses to change PROCESS_GUID", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", "PROCESS_GUID=other"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.ReservedEnvVarErrorMessage))
+				Expect(appRunner.AppDefinitionCallCount()).To(BeZero())
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 
-			It("validates that the routes are passed in", func() {
-				args := []string{
-					"cool-web-app",
+			It("validates its arguments", func() {
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app"})
+				for _, envVar := range []string{"=blue", "1ST=blue", "MY VAR"} {
+					test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", envVar})
 				}
 
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc set-env APP_NAME NAME=VALUE... [NAME...]'"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Invalid env var name ''. Names must be letters, digits and underscores, and can't start with a digit."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Invalid env var name '1ST'. Names must be letters, digits and underscores, and can't start with a digit."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Invalid env var name 'MY VAR'. Names must be letters, digits and underscores, and can't start with a digit."))
+				Expect(appRunner.AppDefinitionCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(HaveLen(4))
+			})
 
-				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc update-routes APP_NAME NEW_ROUTES' or pass '--no-routes' flag."))
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
-				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			It("exits when the app doesn't exist", func() {
+				appRunner.AppDefinitionReturns(receptor.DesiredLRPCreateRequest{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+
+				test_helpers.ExecuteCommandWithArgs(setEnvCommand, []string{"cool-web-app", "COLOR=green"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error updating cool-web-app: not found"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
 			})
 		})
 
-		Context("malformed route", func() {
-			It("errors out when the port is not an int", func() {
-				args := []string{
-					"cool-web-app",
-					"woo:aahh",
-				}
+		Describe("unset-env", func() {
+			It("removes env vars behind a stand-in", func() {
+				test_helpers.ExecuteCommandWithArgs(unsetEnvCommand, []string{"cool-web-app", "COLOR"})
 
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+				Expect(appRunner.RestoreAppCallCount()).To(Equal(2))
+				Expect(appRunner.RestoreAppArgsForCall(1).EnvironmentVariables).To(Equal([]receptor.EnvironmentVariable{
+					{Name: "PROCESS_GUID", Value: "cool-web-app"},
+					{Name: "API_TOKEN", Value: "abc123"},
+				}))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Updated the environment of cool-web-app.")))
+			})
 
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
-				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
-				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			It("exits when an env var isn't set", func() {
+				test_helpers.ExecuteCommandWithArgs(unsetEnvCommand, []string{"cool-web-app", "COLOR", "SIZE"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("cool-web-app has no env var SIZE."))
+				Expect(appRunner.RestoreAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
 			})
 
-			It("errors out when there is no colon", func() {
-				args := []string{
-					"cool-web-app",
-					"8888",
-				}
+			It("refuses to remove PROCESS_GUID", func() {
+				test_helpers.ExecuteCommandWithArgs(unsetEnvCommand, []string{"cool-web-app", "PROCESS_GUID"})
 
-				test_helpers.ExecuteCommandWithArgs(updateRoutesCommand, args)
+				Expect(outputBuffer).To(test_helpers.SayLine(command_factory.ReservedEnvVarErrorMessage))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
 
-				Expect(appRunner.UpdateAppRoutesCallCount()).To(Equal(0))
-				Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+			It("requires APP_NAME and an env var", func() {
+				test_helpers.ExecuteCommandWithArgs(unsetEnvCommand, []string{"cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc unset-env APP_NAME NAME...'"))
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 		})
-
 	})
 
 	Describe("RemoveAppCommand", func() {
-		var removeCommand cli.Command
+		var (
+			removeCommand cli.Command
+			confirmation  string
+		)
+
+		removedApps := func() []string {
+			var appNames []string
+			for i := 0; i < appRunner.RemoveAppCallCount(); i++ {
+				appNames = append(appNames, appRunner.RemoveAppArgsForCall(i))
+			}
+			return appNames
+		}
 
 		BeforeEach(func() {
+			confirmation = "y\n"
+		})
+
+		JustBeforeEach(func() {
 			appRunnerCommandFactoryConfig = command_factory.AppRunnerCommandFactoryConfig{
 				AppRunner:   appRunner,
+				DryRunAppRunner: dryRunAppRunner,
 				AppExaminer: appExaminer,
-				UI:          terminalUI,
+				UI:          terminal.NewUI(strings.NewReader(confirmation), outputBuffer, nil),
 				DockerMetadataFetcher: dockerMetadataFetcher,
 				Domain:                domain,
 				Env:                   []string{},
 				Clock:                 clock,
 				Logger:                logger,
 				ExitHandler:           fakeExitHandler,
+				Cache:                 fakeCache,
 			}
 
 			commandFactory := command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
@@ -1292,12 +3843,53 @@ var _ = Describe("CommandFactory", func() {
 
 			test_helpers.ExecuteCommandWithArgs(removeCommand, args)
 
+			Expect(outputBuffer).To(test_helpers.Say("Really remove cool? [y/N]: "))
 			Eventually(outputBuffer).Should(test_helpers.Say("Removing cool"))
 
 			Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
 			Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool"))
 		})
 
+		It("only prints the requests with --dry-run", func() {
+			test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"app1", "app2", "--dry-run"})
+
+			Expect(dryRunAppRunner.RemoveAppCallCount()).To(Equal(2))
+			Expect(dryRunAppRunner.RemoveAppArgsForCall(0)).To(Equal("app1"))
+			Expect(dryRunAppRunner.RemoveAppArgsForCall(1)).To(Equal("app2"))
+			Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+			Expect(outputBuffer).NotTo(test_helpers.Say("Really remove"))
+			Expect(outputBuffer).To(test_helpers.SayLine(command_factory.DryRunMessage))
+		})
+
+		Context("when the user does not confirm", func() {
+			BeforeEach(func() {
+				confirmation = "no\n"
+			})
+
+			It("does not remove the apps", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"app1", "app2"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Really remove app1, app2? [y/N]: "))
+				Expect(outputBuffer).To(test_helpers.SayLine("App removal cancelled."))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
+		Context("when the --force flag is passed", func() {
+			BeforeEach(func() {
+				confirmation = ""
+			})
+
+			It("removes the apps without asking", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"-f", "cool"})
+
+				Expect(outputBuffer).NotTo(test_helpers.Say("Really remove"))
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(1))
+				Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("cool"))
+			})
+		})
+
 		It("removes multiple apps", func() {
 
 			args := []string{
@@ -1308,14 +3900,239 @@ var _ = Describe("CommandFactory", func() {
 
 			test_helpers.ExecuteCommandWithArgs(removeCommand, args)
 
-			Eventually(outputBuffer).Should(test_helpers.SayLine("Removing app1..."))
-			Eventually(outputBuffer).Should(test_helpers.SayLine("Removing app2..."))
-			Eventually(outputBuffer).Should(test_helpers.SayLine("Removing app3..."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Removing app1, app2, app3..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Removed 3 of 3 apps.")))
 
 			Expect(appRunner.RemoveAppCallCount()).To(Equal(3))
-			Expect(appRunner.RemoveAppArgsForCall(0)).To(Equal("app1"))
-			Expect(appRunner.RemoveAppArgsForCall(1)).To(Equal("app2"))
-			Expect(appRunner.RemoveAppArgsForCall(2)).To(Equal("app3"))
+			Expect(removedApps()).To(ConsistOf("app1", "app2", "app3"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("sends the removals for many apps a few at a time", func() {
+			var appNames []string
+			for i := 0; i < 20; i++ {
+				appNames = append(appNames, fmt.Sprintf("app%d", i))
+			}
+
+			var lock sync.Mutex
+			inFlight, maxInFlight := 0, 0
+			appRunner.RemoveAppStub = func(string) error {
+				lock.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				lock.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				lock.Lock()
+				inFlight--
+				lock.Unlock()
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(removeCommand, appNames)
+
+			Expect(appRunner.RemoveAppCallCount()).To(Equal(20))
+			Expect(maxInFlight).To(BeNumerically("<=", 8))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Removed 20 of 20 apps.")))
+		})
+
+		It("removes the apps matching a pattern", func() {
+			appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "tmp-1"},
+				{ProcessGuid: "keep"},
+				{ProcessGuid: "tmp-2"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"tmp-*"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Really remove tmp-1, tmp-2? [y/N]: "))
+			Expect(removedApps()).To(ConsistOf("tmp-1", "tmp-2"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits with NotFound when a pattern matches nothing", func() {
+			test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"tmp-*"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No apps match tmp-*."))
+			Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		Context("when the --all flag is passed", func() {
+			It("removes every app", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "app1"},
+					{ProcessGuid: "app2"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--all"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Really remove app1, app2? [y/N]: "))
+				Expect(outputBuffer).To(test_helpers.SayLine("Removing app1, app2..."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Removed 2 of 2 apps.")))
+				Expect(removedApps()).To(ConsistOf("app1", "app2"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("says so when there are no apps", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--all"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps to remove."))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits when listing the apps fails", func() {
+				appExaminer.ListAppsReturns(nil, errors.New("no apps for you"))
+
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--all"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error listing apps: no apps for you"))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("rejects app names alongside --all", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--all", "app1"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Pass either app names or --all, not both"))
+				Expect(appExaminer.ListAppsCallCount()).To(BeZero())
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when the --selector flag is passed", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "web-1", Labels: map[string]string{"version": "1.1.3"}},
+					{ProcessGuid: "web-2", Labels: map[string]string{"version": "1.2.0"}},
+					{ProcessGuid: "web-3", Labels: map[string]string{"version": "1.1.4"}},
+				}, nil)
+			})
+
+			It("removes the apps whose labels match", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--selector", "version=1.1.x"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Really remove web-1, web-3? [y/N]: "))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Removed 2 of 2 apps.")))
+				Expect(removedApps()).To(ConsistOf("web-1", "web-3"))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("exits when no apps match", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"-l", "version=2.x"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match version=2.x."))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("rejects malformed selectors", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--selector", "version"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid selector version"))
+				Expect(appExaminer.ListAppsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects app names or --all alongside --selector", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--selector", "version=1.1.x", "web-2"})
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--selector", "version=1.1.x", "--all"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Pass only one of app names, --selector or --all"))
+				Expect(appRunner.RemoveAppCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("polls until the app's instances have stopped", func() {
+			appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{"cool": {Running: 1}}, nil)
+
+			commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"cool"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say("Removing cool..."))
+			Eventually(appExaminer.InstanceCountsByAppCallCount).Should(Equal(1))
+
+			clock.IncrementBySeconds(1)
+			Eventually(outputBuffer).Should(test_helpers.Say("."))
+			Expect(commandFinishChan).ToNot(BeClosed())
+
+			appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{}, nil)
+			clock.IncrementBySeconds(1)
+
+			Eventually(commandFinishChan).Should(BeClosed())
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("watches the apps through the cache while they stop", func() {
+			appExaminer.InstanceCountsByAppStub = func() (map[string]app_examiner.InstanceCounts, error) {
+				Expect(fakeCache.StartCallCount()).To(Equal(1))
+				return map[string]app_examiner.InstanceCounts{}, nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"cool", "uncool"})
+
+			Expect(appExaminer.InstanceCountsByAppCallCount()).To(Equal(1))
+			Expect(fakeCache.StopCallCount()).To(Equal(1))
+		})
+
+		Context("when the app's instances do not stop before the timeout elapses", func() {
+			It("alerts the user and exits with a timeout", func() {
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{"cool": {}}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"--timeout=5s", "cool"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Removing cool..."))
+				clock.IncrementBySeconds(5)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool to stop.")))
+				Expect(outputBuffer).To(test_helpers.SayLine("Lattice is still stopping its instances in the background."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+
+			It("reports only the apps that are still stopping", func() {
+				appExaminer.InstanceCountsByAppReturns(map[string]app_examiner.InstanceCounts{"app2": {Running: 1}}, nil)
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"--timeout=5s", "app1", "app2"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Removing app1, app2..."))
+				clock.IncrementBySeconds(5)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("app1 to stop"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for app2 to stop.")))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Removed 1 of 2 apps.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+
+			It("keeps waiting when counting the instances fails", func() {
+				appExaminer.InstanceCountsByAppReturns(nil, errors.New("receptor down"))
+
+				commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(removeCommand, []string{"--timeout=5s", "cool"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("Removing cool..."))
+				clock.IncrementBySeconds(5)
+
+				Eventually(commandFinishChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for cool to stop.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+			})
+		})
+
+		Context("when the --no-wait flag is passed", func() {
+			It("returns after submitting the removal without polling", func() {
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"--no-wait", "app1", "app2"})
+
+				Expect(appRunner.RemoveAppCallCount()).To(Equal(2))
+				Expect(appExaminer.InstanceCountsByAppCallCount()).To(BeZero())
+				Expect(outputBuffer).To(test_helpers.SayLine("Removing app1, app2..."))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
 		})
 
 		Context("invalid syntax", func() {
@@ -1360,14 +4177,46 @@ var _ = Describe("CommandFactory", func() {
 
 				test_helpers.ExecuteCommandWithArgs(removeCommand, args)
 
-				Expect(outputBuffer).To(test_helpers.SayLine("Removing app1..."))
-				Expect(outputBuffer).To(test_helpers.SayLine("Removing app2..."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Removing app1, app2, app3..."))
 				Expect(outputBuffer).To(test_helpers.SayLine("Error stopping app2: Major Fault"))
-				Expect(outputBuffer).To(test_helpers.SayLine("Removing app3..."))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Removed 2 of 3 apps.")))
 
 				Expect(appRunner.RemoveAppCallCount()).To(Equal(3))
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 			})
+
+			It("exits once with the shared code when every app fails the same way", func() {
+				appRunner.RemoveAppReturns(receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"app1", "app2"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error stopping app1: not found"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Error stopping app2: not found"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Removed 0 of 2 apps.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("exits with CommandFailed when the apps fail in different ways", func() {
+				appRunner.RemoveAppStub = func(name string) error {
+					if name == "app1" {
+						return receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"}
+					}
+					return errors.New("Major Fault")
+				}
+
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"app1", "app2"})
+
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("exits with NotFound when the app does not exist", func() {
+				appRunner.RemoveAppReturns(receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+
+				test_helpers.ExecuteCommandWithArgs(removeCommand, []string{"cool-web-app"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error stopping cool-web-app: not found"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
 		})
 
 	})
@@ -0,0 +1,76 @@
+package command_factory
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+)
+
+// checkCapacity warns about the new instances that can't possibly fit in
+// the room left on the cells, rather than leaving it to the placement error
+// lattice reports once ltc has polled for them.  With strict it refuses
+// them instead, exiting and returning false.  placements lists the new
+// instances given the cells' usage.
+//
+// No cells at all is left to lattice, as they may still be registering.
+func (factory *AppRunnerCommandFactory) checkCapacity(strict bool, placements func(cluster_examiner.ClusterUsage) []cluster_examiner.Placement) bool {
+	usage, err := factory.clusterExaminer.ClusterCapacity()
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Couldn't check whether the cells have room: %s", err))
+		if strict {
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return false
+		}
+		return true
+	}
+	if len(usage.Cells) == 0 {
+		return true
+	}
+
+	newInstances := placements(usage)
+	unplaced := usage.Unplaced(newInstances)
+	prefix := "Warning"
+	if strict {
+		prefix = "Error"
+	}
+	for _, placement := range newInstances {
+		if unplaced[placement.AppName] == 0 {
+			continue
+		}
+		factory.ui.SayLine(fmt.Sprintf("%s: %d of %d new instances of %s can't fit in the memory, disk and containers left on the cells.", prefix, unplaced[placement.AppName], placement.Instances, placement.AppName))
+	}
+
+	if strict && len(unplaced) > 0 {
+		factory.ui.SayRemedy(docker_app_runner.InsufficientResourcesError{})
+		factory.exitHandler.Exit(exit_codes.PlacementError)
+		return false
+	}
+	return true
+}
+
+// scalePlacements lists the instances that scaling the apps would add.
+func scalePlacements(appNames []string, instances map[string]int) func(cluster_examiner.ClusterUsage) []cluster_examiner.Placement {
+	return func(usage cluster_examiner.ClusterUsage) []cluster_examiner.Placement {
+		apps := make(map[string]cluster_examiner.AppUsage, len(usage.Apps))
+		for _, app := range usage.Apps {
+			apps[app.Name] = app
+		}
+
+		placements := []cluster_examiner.Placement{}
+		for _, appName := range appNames {
+			app, ok := apps[appName]
+			if !ok || instances[appName] <= app.DesiredInstances {
+				continue
+			}
+			placements = append(placements, cluster_examiner.Placement{
+				AppName:   appName,
+				Instances: instances[appName] - app.DesiredInstances,
+				MemoryMB:  app.MemoryMB,
+				DiskMB:    app.DiskMB,
+			})
+		}
+		return placements
+	}
+}
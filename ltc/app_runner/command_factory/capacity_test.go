@@ -0,0 +1,184 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner/fake_cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("Capacity checks", func() {
+	var (
+		appRunner           *fake_app_runner.FakeAppRunner
+		dryRunAppRunner     *fake_app_runner.FakeAppRunner
+		fakeClusterExaminer *fake_cluster_examiner.FakeClusterExaminer
+		outputBuffer        *gbytes.Buffer
+		fakeExitHandler     *fake_exit_handler.FakeExitHandler
+		createCommand       cli.Command
+		scaleCommand        cli.Command
+	)
+
+	BeforeEach(func() {
+		appRunner = &fake_app_runner.FakeAppRunner{}
+		dryRunAppRunner = &fake_app_runner.FakeAppRunner{}
+		fakeClusterExaminer = &fake_cluster_examiner.FakeClusterExaminer{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		dockerMetadataFetcher := &fake_docker_metadata_fetcher.FakeDockerMetadataFetcher{}
+		dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{}, nil)
+
+		commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+			AppRunner:             appRunner,
+			DryRunAppRunner:       dryRunAppRunner,
+			AppExaminer:           &fake_app_examiner.FakeAppExaminer{},
+			UI:                    terminal.NewUI(nil, outputBuffer, nil),
+			DockerMetadataFetcher: dockerMetadataFetcher,
+			Clock:                 fakeclock.NewFakeClock(time.Now()),
+			TailedLogsOutputter:   fake_tailed_logs_outputter.NewFakeTailedLogsOutputter(),
+			ExitHandler:           fakeExitHandler,
+			ClusterExaminer:       fakeClusterExaminer,
+		})
+		createCommand = commandFactory.MakeCreateAppCommand()
+		scaleCommand = commandFactory.MakeScaleAppCommand()
+
+		fakeClusterExaminer.ClusterCapacityReturns(cluster_examiner.ClusterUsage{
+			Cells: []cluster_examiner.CellUsage{
+				{CellID: "cell-1", MemoryMB: 1024, DiskMB: 8192, Containers: 256, ReservedMemoryMB: 512, ReservedDiskMB: 2048, Instances: 2},
+				{CellID: "cell-2", MemoryMB: 1024, DiskMB: 8192, Containers: 256},
+			},
+			Apps: []cluster_examiner.AppUsage{
+				{Name: "api", DesiredInstances: 2, RunningInstances: 2, MemoryMB: 256, DiskMB: 1024},
+				{Name: "worker", DesiredInstances: 1, MemoryMB: 512, DiskMB: 1024},
+			},
+		}, nil)
+	})
+
+	createArgs := func(args ...string) []string {
+		return append(args, "--no-monitor", "--no-wait", "--working-dir=/", "web", "acme/web", "--", "/start")
+	}
+
+	Describe("ltc create", func() {
+		It("creates the app without warning when its instances fit", func() {
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs("--instances=5", "--memory-mb=256"))
+
+			Expect(fakeClusterExaminer.ClusterCapacityCallCount()).To(Equal(1))
+			Expect(outputBuffer).NotTo(test_helpers.Say("can't fit"))
+			Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("warns when its instances can't fit, and creates the app anyway", func() {
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs("--instances=4", "--memory-mb=512"))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Warning: 1 of 4 new instances of web can't fit in the memory, disk and containers left on the cells."))
+			Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("refuses to create the app with --strict", func() {
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs("--strict", "--instances=4", "--memory-mb=512"))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error: 1 of 4 new instances of web can't fit in the memory, disk and containers left on the cells."))
+			Expect(outputBuffer).To(test_helpers.SayLine(docker_app_runner.InsufficientResourcesError{}.Remedy()))
+			Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+		})
+
+		It("leaves apps that already exist to --update-if-exists", func() {
+			args := []string{"--strict", "--update-if-exists", "--instances=10", "--memory-mb=512", "--no-monitor", "--no-wait", "--working-dir=/", "api", "acme/api", "--", "/start"}
+
+			test_helpers.ExecuteCommandWithArgs(createCommand, args)
+
+			Expect(outputBuffer).NotTo(test_helpers.Say("can't fit"))
+			Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+		})
+
+		It("leaves placement to lattice when no cells have registered", func() {
+			fakeClusterExaminer.ClusterCapacityReturns(cluster_examiner.ClusterUsage{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs("--strict", "--instances=4"))
+
+			Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("warns and goes on when the cells can't be checked", func() {
+			fakeClusterExaminer.ClusterCapacityReturns(cluster_examiner.ClusterUsage{}, errors.New("no cells for you"))
+
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs())
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Couldn't check whether the cells have room: no cells for you"))
+			Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits when the cells can't be checked with --strict", func() {
+			fakeClusterExaminer.ClusterCapacityReturns(cluster_examiner.ClusterUsage{}, errors.New("no cells for you"))
+
+			test_helpers.ExecuteCommandWithArgs(createCommand, createArgs("--strict"))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Couldn't check whether the cells have room: no cells for you"))
+			Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	Describe("ltc scale", func() {
+		It("checks only the instances added to the app", func() {
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--strict", "--no-wait", "api", "7"})
+
+			Expect(outputBuffer).NotTo(test_helpers.Say("can't fit"))
+			Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("warns when the new instances can't fit", func() {
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--no-wait", "api", "9"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Warning: 1 of 7 new instances of api can't fit in the memory, disk and containers left on the cells."))
+			Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("refuses to scale with --strict", func() {
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--strict", "--no-wait", "worker", "5"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error: 1 of 4 new instances of worker can't fit in the memory, disk and containers left on the cells."))
+			Expect(appRunner.ScaleAppCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+		})
+
+		It("doesn't check scaling down", func() {
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--strict", "--no-wait", "worker", "0"})
+
+			Expect(outputBuffer).NotTo(test_helpers.Say("can't fit"))
+			Expect(appRunner.ScaleAppCallCount()).To(Equal(1))
+		})
+
+		It("checks before a dry run", func() {
+			test_helpers.ExecuteCommandWithArgs(scaleCommand, []string{"--dry-run", "worker", "5"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Warning: 1 of 4 new instances of worker can't fit in the memory, disk and containers left on the cells."))
+			Expect(dryRunAppRunner.ScaleAppCallCount()).To(Equal(1))
+		})
+	})
+})
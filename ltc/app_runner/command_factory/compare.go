@@ -0,0 +1,339 @@
+package command_factory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/lrp_annotation"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/codegangsta/cli"
+)
+
+// compareContextLines is how many unchanged lines surround each change, as
+// in diff -u.
+const compareContextLines = 3
+
+func (factory *AppRunnerCommandFactory) MakeCompareCommand() cli.Command {
+	var compareFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "show-secrets",
+			Usage: "Shows the values of variables that look like secrets",
+		},
+	}
+
+	var compareCommand = cli.Command{
+		Name:  "compare",
+		Usage: "Shows how the definitions of two apps differ",
+		Description: `ltc compare APP1 APP2 [--show-secrets]
+
+   Prints a unified diff of the two apps' image, start command, resources,
+   ports, routes, labels, log drains and environment, e.g. to find out why
+   an app works in staging but not in production.  Values of variables that
+   look like secrets are masked as in 'ltc env', though masked values that
+   differ are still shown as changed.`,
+		Action: factory.compareApps,
+		Flags:  compareFlags,
+	}
+
+	return compareCommand
+}
+
+func (factory *AppRunnerCommandFactory) compareApps(c *cli.Context) {
+	if len(c.Args()) != 2 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc compare APP1 APP2 [--show-secrets]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var descriptions [2][]definitionLine
+	for index, appName := range c.Args() {
+		definition, err := factory.appRunner.AppDefinition(appName)
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error getting the definition of %s: %s", appName, err))
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+		descriptions[index] = describeDefinition(definition, c.Bool("show-secrets"))
+	}
+
+	hunks := diffHunks(diffLines(descriptions[0], descriptions[1]), compareContextLines)
+	if len(hunks) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("%s and %s have the same definition.", c.Args().Get(0), c.Args().Get(1)))
+		return
+	}
+
+	factory.ui.SayLine(colors.Bold("--- " + c.Args().Get(0)))
+	factory.ui.SayLine(colors.Bold("+++ " + c.Args().Get(1)))
+	for _, hunk := range hunks {
+		factory.ui.SayLine(colors.Cyan(hunk.header()))
+		for _, line := range hunk.lines {
+			switch line.op {
+			case diffDelete:
+				factory.ui.SayLine(colors.Red("-" + line.display))
+			case diffInsert:
+				factory.ui.SayLine(colors.Green("+" + line.display))
+			default:
+				factory.ui.SayLine(" " + line.display)
+			}
+		}
+	}
+}
+
+// definitionLine is one line of an app's description.  Lines are compared
+// by key, which holds the real value of masked env vars, so that masked
+// values that differ still show as changed.
+type definitionLine struct {
+	key     string
+	display string
+}
+
+// describeDefinition lists what matters about an app, one line per value,
+// in an order that lines up the same values of two apps.  The app's name
+// and the fields derived from it are left out, since they always differ.
+func describeDefinition(definition receptor.DesiredLRPCreateRequest, showSecrets bool) []definitionLine {
+	lines := []definitionLine{}
+	add := func(name string, value interface{}) {
+		line := fmt.Sprintf("%s: %v", name, value)
+		lines = append(lines, definitionLine{line, line})
+	}
+
+	annotation := lrp_annotation.ParseFields(definition.Annotation)
+
+	add("image", definition.RootFS)
+	describeAction(definition.Action, add)
+	add("instances", definition.Instances)
+	add("memory_mb", definition.MemoryMB)
+	add("disk_mb", definition.DiskMB)
+	add("cpu_weight", definition.CPUWeight)
+	add("start_timeout", definition.StartTimeout)
+
+	udpPorts := map[uint16]bool{}
+	for _, port := range annotation.UDPPorts {
+		udpPorts[port] = true
+	}
+	ports := []string{}
+	for _, port := range definition.Ports {
+		if udpPorts[port] {
+			ports = append(ports, fmt.Sprintf("%d/udp", port))
+		} else {
+			ports = append(ports, fmt.Sprint(port))
+		}
+	}
+	add("ports", strings.Join(ports, ","))
+
+	if monitor, ok := definition.Monitor.(*models.RunAction); ok {
+		add("monitor", strings.Join(monitor.Args, " "))
+	} else if definition.Monitor != nil {
+		add("monitor", marshalAction(definition.Monitor))
+	}
+
+	routes := []string{}
+	for _, route := range route_helpers.AppRoutesFromRoutingInfo(definition.Routes) {
+		for _, hostname := range route.Hostnames {
+			routes = append(routes, fmt.Sprintf("%s => %d", hostname, route.Port))
+		}
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		add("route", route)
+	}
+
+	labels := make([]string, 0, len(annotation.Labels))
+	for key, value := range annotation.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		add("label", label)
+	}
+	for _, logDrain := range annotation.LogDrains {
+		add("log_drain", logDrain)
+	}
+
+	envVars := append([]receptor.EnvironmentVariable{}, definition.EnvironmentVariables...)
+	sort.Sort(envVarsByName(envVars))
+	for _, envVar := range envVars {
+		if envVar.Name == "PROCESS_GUID" {
+			continue
+		}
+		line := definitionLine{key: fmt.Sprintf("env: %s=%s", envVar.Name, envVar.Value)}
+		line.display = line.key
+		if !showSecrets && secrets.LooksSecret(envVar.Name) {
+			line.display = fmt.Sprintf("env: %s=%s", envVar.Name, secrets.MaskedValue)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// describeAction describes the start command, and the sidecars run in
+// parallel with it, of apps created by ltc.  Other actions are shown as
+// their JSON.
+func describeAction(action models.Action, add func(name string, value interface{})) {
+	runActions := []*models.RunAction{}
+	switch action := action.(type) {
+	case *models.RunAction:
+		runActions = append(runActions, action)
+	case *models.ParallelAction:
+		for _, parallel := range action.Actions {
+			runAction, ok := parallel.(*models.RunAction)
+			if !ok {
+				add("action", marshalAction(action))
+				return
+			}
+			runActions = append(runActions, runAction)
+		}
+	default:
+		if action != nil {
+			add("action", marshalAction(action))
+		}
+		return
+	}
+
+	for index, runAction := range runActions {
+		command := strings.Join(append([]string{runAction.Path}, runAction.Args...), " ")
+		if index == 0 {
+			add("command", command)
+			add("working_dir", runAction.Dir)
+			add("run_as_root", runAction.Privileged)
+		} else {
+			add("sidecar", command)
+		}
+	}
+}
+
+func marshalAction(action models.Action) string {
+	data, err := models.MarshalAction(action)
+	if err != nil {
+		return fmt.Sprintf("%#v", action)
+	}
+	return string(data)
+}
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	op      diffOp
+	display string
+}
+
+// diffLines lines up a with b by their longest common subsequence.  Apps'
+// descriptions are short enough for the quadratic table.
+func diffLines(a, b []definitionLine) []diffLine {
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].key == b[j].key {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i].key == b[j].key:
+			lines = append(lines, diffLine{diffEqual, a[i].display})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{diffDelete, a[i].display})
+			i++
+		default:
+			lines = append(lines, diffLine{diffInsert, b[j].display})
+			j++
+		}
+	}
+	return lines
+}
+
+type diffHunk struct {
+	aStart, aLength int
+	bStart, bLength int
+	lines           []diffLine
+}
+
+// header numbers lines from 1, and an empty range from the line before it,
+// as diff -u does.
+func (h diffHunk) header() string {
+	aStart, bStart := h.aStart+1, h.bStart+1
+	if h.aLength == 0 {
+		aStart--
+	}
+	if h.bLength == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, h.aLength, bStart, h.bLength)
+}
+
+// diffHunks groups the changes with up to context unchanged lines around
+// them, merging changes that are close enough to share their context.
+func diffHunks(lines []diffLine, context int) []diffHunk {
+	type span struct{ start, end int }
+	spans := []span{}
+	for index, line := range lines {
+		if line.op == diffEqual {
+			continue
+		}
+		start, end := index-context, index+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if len(spans) > 0 && start <= spans[len(spans)-1].end {
+			spans[len(spans)-1].end = end
+		} else {
+			spans = append(spans, span{start, end})
+		}
+	}
+
+	hunks := []diffHunk{}
+	aLine, bLine, index := 0, 0, 0
+	for _, span := range spans {
+		for ; index < span.start; index++ {
+			aLine, bLine = advance(lines[index].op, aLine, bLine)
+		}
+		hunk := diffHunk{aStart: aLine, bStart: bLine, lines: lines[span.start:span.end]}
+		for ; index < span.end; index++ {
+			aLine, bLine = advance(lines[index].op, aLine, bLine)
+		}
+		hunk.aLength, hunk.bLength = aLine-hunk.aStart, bLine-hunk.bStart
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// advance counts a line of the diff against the apps it belongs to.
+func advance(op diffOp, aLine, bLine int) (int, int) {
+	switch op {
+	case diffDelete:
+		return aLine + 1, bLine
+	case diffInsert:
+		return aLine, bLine + 1
+	default:
+		return aLine + 1, bLine + 1
+	}
+}
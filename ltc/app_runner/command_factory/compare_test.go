@@ -0,0 +1,177 @@
+package command_factory_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("CompareCommand", func() {
+	var (
+		appRunner       *fake_app_runner.FakeAppRunner
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		compareCommand  cli.Command
+		staging         receptor.DesiredLRPCreateRequest
+		production      receptor.DesiredLRPCreateRequest
+	)
+
+	definitionFor := func(name, image string, instances int, hostname, password, logLevel string) receptor.DesiredLRPCreateRequest {
+		return receptor.DesiredLRPCreateRequest{
+			ProcessGuid:  name,
+			RootFS:       image,
+			Action:       &models.RunAction{Path: "/app/web", Args: []string{"--port", "8080"}, Dir: "/app"},
+			Monitor:      &models.RunAction{Path: "/tmp/healthcheck", Args: []string{"-port", "8080"}},
+			Instances:    instances,
+			MemoryMB:     128,
+			DiskMB:       1024,
+			CPUWeight:    100,
+			StartTimeout: 60,
+			Ports:        []uint16{8080},
+			Routes:       route_helpers.AppRoutes{{Hostnames: []string{hostname}, Port: 8080}}.RoutingInfo(),
+			EnvironmentVariables: []receptor.EnvironmentVariable{
+				{Name: "PROCESS_GUID", Value: name},
+				{Name: "LOG_LEVEL", Value: logLevel},
+				{Name: "DB_PASSWORD", Value: password},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		appRunner = &fake_app_runner.FakeAppRunner{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+			AppRunner:   appRunner,
+			UI:          terminal.NewUI(nil, outputBuffer, nil),
+			Clock:       fakeclock.NewFakeClock(time.Now()),
+			ExitHandler: fakeExitHandler,
+		})
+		compareCommand = commandFactory.MakeCompareCommand()
+
+		staging = definitionFor("web-staging", "docker:///acme/web#1.2", 1, "web-staging.example.com", "s1", "debug")
+		production = definitionFor("web", "docker:///acme/web#1.1", 3, "web.example.com", "s2", "debug")
+		appRunner.AppDefinitionStub = func(name string) (receptor.DesiredLRPCreateRequest, error) {
+			if name == "web" {
+				return production, nil
+			}
+			return staging, nil
+		}
+	})
+
+	It("prints a unified diff of the apps' definitions", func() {
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging", "web"})
+
+		Expect(appRunner.AppDefinitionCallCount()).To(Equal(2))
+		Expect(appRunner.AppDefinitionArgsForCall(0)).To(Equal("web-staging"))
+		Expect(appRunner.AppDefinitionArgsForCall(1)).To(Equal("web"))
+
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Bold("--- web-staging")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Bold("+++ web")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Cyan("@@ -1,14 +1,14 @@")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-image: docker:///acme/web#1.2")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+image: docker:///acme/web#1.1")))
+		Expect(outputBuffer).To(test_helpers.SayLine(" command: /app/web --port 8080"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" working_dir: /app"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" run_as_root: false"))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-instances: 1")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+instances: 3")))
+		Expect(outputBuffer).To(test_helpers.SayLine(" memory_mb: 128"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" disk_mb: 1024"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" cpu_weight: 100"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" start_timeout: 60"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" ports: 8080"))
+		Expect(outputBuffer).To(test_helpers.SayLine(" monitor: -port 8080"))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-route: web-staging.example.com => 8080")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-env: DB_PASSWORD=********")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+route: web.example.com => 8080")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+env: DB_PASSWORD=********")))
+		Expect(outputBuffer).To(test_helpers.SayLine(" env: LOG_LEVEL=debug"))
+		Expect(outputBuffer).NotTo(test_helpers.Say("PROCESS_GUID"))
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("shows changes that are far apart in separate hunks", func() {
+		production = definitionFor("web", "docker:///acme/web#1.1", 1, "web-staging.example.com", "s1", "info")
+
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging", "web"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Cyan("@@ -1,4 +1,4 @@")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-image: docker:///acme/web#1.2")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+image: docker:///acme/web#1.1")))
+		Expect(outputBuffer).To(test_helpers.SayLine(" run_as_root: false"))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Cyan("@@ -11,4 +11,4 @@")))
+		Expect(outputBuffer).To(test_helpers.SayLine(" monitor: -port 8080"))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-env: LOG_LEVEL=debug")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+env: LOG_LEVEL=info")))
+	})
+
+	It("shows secrets with --show-secrets", func() {
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"--show-secrets", "web-staging", "web"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-env: DB_PASSWORD=s1")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("+env: DB_PASSWORD=s2")))
+	})
+
+	It("describes sidecars, udp ports, labels and log drains", func() {
+		staging.Action = models.Parallel(
+			&models.RunAction{Path: "/app/web", Dir: "/app"},
+			&models.RunAction{Path: "/bin/proxy", Args: []string{"-v"}, LogSource: "SIDECAR"},
+		)
+		staging.Ports = []uint16{53, 8080}
+		staging.Annotation = `{"udp_ports":[53],"labels":{"tier":"web","group":"payment"},"log_drains":["syslog://logs.example.com:514"]}`
+
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging", "web"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-command: /app/web")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-sidecar: /bin/proxy -v")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-ports: 53/udp,8080")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-label: group=payment")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-label: tier=web")))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("-log_drain: syslog://logs.example.com:514")))
+	})
+
+	It("says when the apps have the same definition", func() {
+		production = definitionFor("web", "docker:///acme/web#1.2", 1, "web-staging.example.com", "s1", "debug")
+
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging", "web"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("web-staging and web have the same definition."))
+		Expect(outputBuffer).NotTo(test_helpers.Say("@@"))
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("exits when an app doesn't exist", func() {
+		appRunner.AppDefinitionStub = nil
+		appRunner.AppDefinitionReturns(receptor.DesiredLRPCreateRequest{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"})
+
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging", "web"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error getting the definition of web-staging: not found"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+	})
+
+	It("requires two app names", func() {
+		test_helpers.ExecuteCommandWithArgs(compareCommand, []string{"web-staging"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc compare APP1 APP2 [--show-secrets]'"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		Expect(appRunner.AppDefinitionCallCount()).To(BeZero())
+	})
+})
@@ -0,0 +1,220 @@
+package command_factory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/codegangsta/cli"
+)
+
+const noMonitorChoice = "none"
+
+func (factory *AppRunnerCommandFactory) createAppInteractively(context *cli.Context) {
+	name := factory.ui.PromptForValidInput("App name", context.Args().Get(0), validateAppName)
+	dockerImage := factory.ui.PromptForValidInput("Docker image", context.Args().Get(1), requireValue("Docker image"))
+	if name == "" || dockerImage == "" {
+		factory.ui.SayIncorrectUsage("APP_NAME and DOCKER_IMAGE are required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	imageMetadata, err := factory.dockerMetadataFetcher.FetchMetadata(dockerImage)
+	if err != nil {
+		factory.ui.Say(fmt.Sprintf("Error fetching image metadata: %s", err))
+		factory.exitHandler.Exit(exit_codes.BadDocker)
+		return
+	}
+
+	defaultPorts := context.String("ports")
+	if defaultPorts == "" {
+		defaultPorts = "8080"
+		if len(imageMetadata.ExposedPorts) > 0 {
+			defaultPorts = joinPorts(imageMetadata.ExposedPorts)
+		}
+	}
+	exposedPorts, err := parsePorts(factory.ui.PromptForValidInput("Ports (comma delimited)", defaultPorts, validatePorts))
+	if err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	monitorChoices := append(strings.Split(joinPorts(exposedPorts), ","), noMonitorChoice)
+	defaultMonitor := monitorChoices[0]
+	if context.Bool("no-monitor") {
+		defaultMonitor = noMonitorChoice
+	} else if monitorPort := context.Int("monitor-port"); monitorPort > 0 {
+		defaultMonitor = strconv.Itoa(monitorPort)
+	}
+	monitorConfig := docker_app_runner.MonitorConfig{Method: docker_app_runner.NoMonitor}
+	if monitorChoice := factory.ui.PromptForChoice("Port to monitor", monitorChoices, defaultMonitor); monitorChoice != noMonitorChoice {
+		monitorPort, _ := strconv.Atoi(monitorChoice)
+		monitorConfig = docker_app_runner.MonitorConfig{
+			Method:  docker_app_runner.PortMonitor,
+			Port:    uint16(monitorPort),
+			Timeout: context.Duration("monitor-timeout"),
+		}
+	}
+
+	startCommandLine := factory.ui.PromptForValidInput("Start command", strings.Join(imageMetadata.StartCommand, " "), requireValue("Start command"))
+	startCommandFields := strings.Fields(startCommandLine)
+	if len(startCommandFields) == 0 {
+		factory.ui.SayLine("Start command is required.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	defaultWorkingDir := context.String("working-dir")
+	if defaultWorkingDir == "" {
+		defaultWorkingDir = "/"
+		if imageMetadata.WorkingDir != "" {
+			defaultWorkingDir = imageMetadata.WorkingDir
+		}
+	}
+	workingDir := factory.ui.PromptWithDefault("Working directory", defaultWorkingDir)
+
+	routes := factory.ui.PromptForValidInput("Routes as PORT:ROUTE,... (blank for default routes)", context.String("routes"), validateRoutes)
+	routeOverrides, err := parseRouteOverrides(routes)
+	if err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	instances, instancesErr := strconv.Atoi(factory.ui.PromptForValidInput("Instances", strconv.Itoa(context.Int("instances")), validateIntInRange(0, -1)))
+	memoryMB, memoryErr := strconv.Atoi(factory.ui.PromptForValidInput("Memory limit in MB (0 for unlimited)", strconv.Itoa(context.Int("memory-mb")), validateIntInRange(0, -1)))
+	diskMB, diskErr := strconv.Atoi(factory.ui.PromptForValidInput("Disk limit in MB (0 for unlimited)", strconv.Itoa(context.Int("disk-mb")), validateIntInRange(0, -1)))
+	cpuWeight, cpuErr := strconv.Atoi(factory.ui.PromptForValidInput("CPU weight (1-100)", strconv.Itoa(context.Int("cpu-weight")), validateIntInRange(1, 100)))
+	if instancesErr != nil || memoryErr != nil || diskErr != nil || cpuErr != nil {
+		factory.ui.SayLine("Resource values must be integers.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var imageEnv []string
+	if !context.Bool("ignore-image-env") {
+		imageEnv = imageMetadata.Env
+	}
+
+	environment, err := factory.buildEnvironment(context.StringSlice("env"), name, imageEnv)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if err := factory.addSecretsToEnvironment(environment, context.StringSlice("secret-env")); err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	params := docker_app_runner.CreateDockerAppParams{
+		Name:                 name,
+		DockerImagePath:      dockerImage,
+		StartCommand:         startCommandFields[0],
+		AppArgs:              startCommandFields[1:],
+		EnvironmentVariables: environment,
+		Privileged:           context.Bool("run-as-root"),
+		Monitor:              monitorConfig,
+		Instances:            instances,
+		CPUWeight:            uint(cpuWeight),
+		MemoryMB:             memoryMB,
+		DiskMB:               diskMB,
+		ExposedPorts:         exposedPorts,
+		UDPPorts:             imageMetadata.UDPPorts,
+		WorkingDir:           workingDir,
+		RouteOverrides:       routeOverrides,
+		NoRoutes:             context.Bool("no-routes"),
+		Timeout:              context.Duration("timeout"),
+		NoWait:               context.Bool("no-wait"),
+		UpdateIfExists:       context.Bool("update-if-exists"),
+		DryRun:               context.Bool("dry-run"),
+		Strict:               context.Bool("strict"),
+	}
+
+	factory.sayCreateAppSummary(params, routes)
+	if !factory.ui.PromptForConfirmation("Create app with this configuration?") {
+		factory.ui.SayLine("App creation cancelled.")
+		return
+	}
+
+	factory.createDockerApp(params)
+}
+
+func (factory *AppRunnerCommandFactory) sayCreateAppSummary(params docker_app_runner.CreateDockerAppParams, routes string) {
+	monitor := noMonitorChoice
+	if params.Monitor.Method == docker_app_runner.PortMonitor {
+		monitor = fmt.Sprintf("port %d", params.Monitor.Port)
+	}
+	if params.NoRoutes {
+		routes = "none"
+	} else if routes == "" {
+		routes = "default"
+	}
+
+	factory.ui.SayNewLine()
+	factory.ui.SayLine("App configuration:")
+	factory.ui.SayLine(fmt.Sprintf("  Name:\t\t%s", params.Name))
+	factory.ui.SayLine(fmt.Sprintf("  Docker Image:\t%s", params.DockerImagePath))
+	factory.ui.SayLine(fmt.Sprintf("  Start Command:\t%s", strings.Join(append([]string{params.StartCommand}, params.AppArgs...), " ")))
+	factory.ui.SayLine(fmt.Sprintf("  Working Dir:\t%s", params.WorkingDir))
+	factory.ui.SayLine(fmt.Sprintf("  Ports:\t\t%s", joinPorts(params.ExposedPorts)))
+	factory.ui.SayLine(fmt.Sprintf("  Monitor:\t%s", monitor))
+	factory.ui.SayLine(fmt.Sprintf("  Routes:\t%s", routes))
+	factory.ui.SayLine(fmt.Sprintf("  Instances:\t%d", params.Instances))
+	factory.ui.SayLine(fmt.Sprintf("  Memory MB:\t%d", params.MemoryMB))
+	factory.ui.SayLine(fmt.Sprintf("  Disk MB:\t%d", params.DiskMB))
+	factory.ui.SayLine(fmt.Sprintf("  CPU Weight:\t%d", params.CPUWeight))
+	factory.ui.SayNewLine()
+}
+
+func joinPorts(ports []uint16) string {
+	portStrings := make([]string, 0, len(ports))
+	for _, port := range ports {
+		portStrings = append(portStrings, strconv.Itoa(int(port)))
+	}
+	return strings.Join(portStrings, ",")
+}
+
+func requireValue(name string) func(string) error {
+	return func(answer string) error {
+		if strings.TrimSpace(answer) == "" {
+			return fmt.Errorf("%s is required.", name)
+		}
+		return nil
+	}
+}
+
+func validateAppName(answer string) error {
+	if err := requireValue("App name")(answer); err != nil {
+		return err
+	}
+	return docker_app_runner.ValidateAppName(answer)
+}
+
+func validatePorts(answer string) error {
+	_, err := parsePorts(answer)
+	return err
+}
+
+func validateRoutes(answer string) error {
+	_, err := parseRouteOverrides(answer)
+	return err
+}
+
+// validateIntInRange accepts integers >= min, and <= max unless max is negative.
+func validateIntInRange(min, max int) func(string) error {
+	return func(answer string) error {
+		value, err := strconv.Atoi(answer)
+		if err != nil || value < min || (max >= 0 && value > max) {
+			if max >= 0 {
+				return fmt.Errorf("Must be an integer between %d and %d.", min, max)
+			}
+			return fmt.Errorf("Must be an integer of at least %d.", min)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+package command_factory_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+)
+
+var _ = Describe("CreateAppCommand --interactive", func() {
+	var (
+		appRunner             *fake_app_runner.FakeAppRunner
+		appExaminer           *fake_app_examiner.FakeAppExaminer
+		outputBuffer          *gbytes.Buffer
+		dockerMetadataFetcher *fake_docker_metadata_fetcher.FakeDockerMetadataFetcher
+		fakeExitHandler       *fake_exit_handler.FakeExitHandler
+		input                 string
+	)
+
+	createCommand := func() cli.Command {
+		commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+			AppRunner:             appRunner,
+			AppExaminer:           appExaminer,
+			UI:                    terminal.NewUI(strings.NewReader(input), outputBuffer, nil),
+			DockerMetadataFetcher: dockerMetadataFetcher,
+			Domain:                "192.168.11.11.xip.io",
+			Env:                   []string{},
+			Clock:                 fakeclock.NewFakeClock(time.Now()),
+			Logger:                lager.NewLogger("ltc-test"),
+			TailedLogsOutputter:   fake_tailed_logs_outputter.NewFakeTailedLogsOutputter(),
+			ExitHandler:           fakeExitHandler,
+		})
+		return commandFactory.MakeCreateAppCommand()
+	}
+
+	BeforeEach(func() {
+		appRunner = &fake_app_runner.FakeAppRunner{}
+		appExaminer = &fake_app_examiner.FakeAppExaminer{}
+		outputBuffer = gbytes.NewBuffer()
+		dockerMetadataFetcher = &fake_docker_metadata_fetcher.FakeDockerMetadataFetcher{}
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+			WorkingDir:   "/app",
+			ExposedPorts: []uint16{8080, 9090},
+			StartCommand: []string{"/start", "--fast"},
+		}, nil)
+		appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+	})
+
+	It("uses the image metadata as defaults and creates the app after confirmation", func() {
+		input = strings.Join([]string{
+			"",  // app name (from args)
+			"",  // docker image (from args)
+			"",  // ports
+			"",  // monitor port
+			"",  // start command
+			"",  // working dir
+			"",  // routes
+			"2", // instances
+			"",  // memory
+			"",  // disk
+			"",  // cpu weight
+			"y", // confirm
+			"",
+		}, "\n")
+
+		test_helpers.ExecuteCommandWithArgs(createCommand(), []string{"--interactive", "cool-app", "cool/image"})
+
+		Expect(outputBuffer).To(test_helpers.Say("App name [cool-app]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Docker image [cool/image]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Ports (comma delimited) [8080,9090]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Port to monitor (8080, 9090, none) [8080]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Start command [/start --fast]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Working directory [/app]: "))
+		Expect(outputBuffer).To(test_helpers.Say("Instances [1]: "))
+		Expect(outputBuffer).To(test_helpers.Say("CPU weight (1-100) [100]: "))
+		Expect(outputBuffer).To(test_helpers.Say("App configuration:"))
+		Expect(outputBuffer).To(test_helpers.Say("Create app with this configuration? [y/N]: "))
+
+		Expect(dockerMetadataFetcher.FetchMetadataArgsForCall(0)).To(Equal("cool/image"))
+		Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+		params := appRunner.CreateDockerAppArgsForCall(0)
+		Expect(params.Name).To(Equal("cool-app"))
+		Expect(params.DockerImagePath).To(Equal("cool/image"))
+		Expect(params.StartCommand).To(Equal("/start"))
+		Expect(params.AppArgs).To(Equal([]string{"--fast"}))
+		Expect(params.WorkingDir).To(Equal("/app"))
+		Expect(params.ExposedPorts).To(Equal([]uint16{8080, 9090}))
+		Expect(params.Monitor).To(Equal(docker_app_runner.MonitorConfig{
+			Method:  docker_app_runner.PortMonitor,
+			Port:    8080,
+			Timeout: time.Second,
+		}))
+		Expect(params.RouteOverrides).To(BeNil())
+		Expect(params.Instances).To(Equal(2))
+		Expect(params.MemoryMB).To(Equal(128))
+		Expect(params.DiskMB).To(Equal(0))
+		Expect(params.CPUWeight).To(Equal(uint(100)))
+
+		Expect(outputBuffer).To(test_helpers.Say("cool-app is now running."))
+	})
+
+	It("re-prompts for invalid answers and honors overrides", func() {
+		input = strings.Join([]string{
+			"",              // app name
+			"My_App",        // app name (invalid)
+			"my-app",        // app name
+			"other/image",   // docker image
+			"80,http",       // ports (invalid)
+			"3000",          // ports
+			"none",          // monitor
+			"/run it",       // start command
+			"/srv",          // working dir
+			"3000",          // routes (invalid)
+			"3000:my-route", // routes
+			"-1",            // instances (invalid)
+			"3",             // instances
+			"256",           // memory
+			"512",           // disk
+			"101",           // cpu weight (invalid)
+			"50",            // cpu weight
+			"yes",           // confirm
+			"",
+		}, "\n")
+		appExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 3}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(createCommand(), []string{"--interactive"})
+
+		Expect(outputBuffer).To(test_helpers.Say("App name is required."))
+		Expect(outputBuffer).To(test_helpers.Say("My_App is not a valid app name: it contains uppercase letters."))
+		Expect(outputBuffer).To(test_helpers.Say(command_factory.InvalidPortErrorMessage))
+		Expect(outputBuffer).To(test_helpers.Say(command_factory.MalformedRouteErrorMessage))
+		Expect(outputBuffer).To(test_helpers.Say("Must be an integer of at least 0."))
+		Expect(outputBuffer).To(test_helpers.Say("Must be an integer between 1 and 100."))
+
+		Expect(appRunner.CreateDockerAppCallCount()).To(Equal(1))
+		params := appRunner.CreateDockerAppArgsForCall(0)
+		Expect(params.Name).To(Equal("my-app"))
+		Expect(params.DockerImagePath).To(Equal("other/image"))
+		Expect(params.StartCommand).To(Equal("/run"))
+		Expect(params.AppArgs).To(Equal([]string{"it"}))
+		Expect(params.WorkingDir).To(Equal("/srv"))
+		Expect(params.ExposedPorts).To(Equal([]uint16{3000}))
+		Expect(params.Monitor.Method).To(Equal(docker_app_runner.NoMonitor))
+		Expect(params.RouteOverrides).To(Equal(docker_app_runner.RouteOverrides{
+			docker_app_runner.RouteOverride{HostnamePrefix: "my-route", Port: 3000},
+		}))
+		Expect(params.Instances).To(Equal(3))
+		Expect(params.MemoryMB).To(Equal(256))
+		Expect(params.DiskMB).To(Equal(512))
+		Expect(params.CPUWeight).To(Equal(uint(50)))
+
+		Expect(outputBuffer).To(test_helpers.Say("http://my-route.192.168.11.11.xip.io"))
+	})
+
+	It("does not create the app when the configuration is not confirmed", func() {
+		input = strings.Repeat("\n", 11) + "n\n"
+
+		test_helpers.ExecuteCommandWithArgs(createCommand(), []string{"--interactive", "cool-app", "cool/image"})
+
+		Expect(outputBuffer).To(test_helpers.Say("App creation cancelled."))
+		Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("requires an app name and docker image", func() {
+		input = ""
+
+		test_helpers.ExecuteCommandWithArgs(createCommand(), []string{"--interactive"})
+
+		Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+		Expect(dockerMetadataFetcher.FetchMetadataCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+
+	It("exits when the image metadata cannot be fetched", func() {
+		input = "\n\n"
+		dockerMetadataFetcher.FetchMetadataReturns(nil, errors.New("no such image"))
+
+		test_helpers.ExecuteCommandWithArgs(createCommand(), []string{"--interactive", "cool-app", "cool/image"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Error fetching image metadata: no such image"))
+		Expect(appRunner.CreateDockerAppCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadDocker}))
+	})
+})
@@ -0,0 +1,254 @@
+package command_factory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+const DefaultRunTimeout time.Duration = time.Hour
+
+func (factory *AppRunnerCommandFactory) MakeRunCommand() cli.Command {
+	var runFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "working-dir, w",
+			Usage: "Working directory for the command (overrides Docker metadata)",
+		},
+		cli.BoolFlag{
+			Name:  "run-as-root, r",
+			Usage: "Runs in the context of the root user",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "Environment variables as NAME=VALUE, or NAME to use ltc's value (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight, c",
+			Usage: "Relative CPU weight for the container (valid values: 1-100)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "Memory limit for container in MB",
+			Value: 128,
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "Disk limit for container in MB",
+			Value: 0,
+		},
+		cli.StringFlag{
+			Name:  "priority, p",
+			Usage: "Priority of the task: normal or low",
+		},
+		cli.BoolFlag{
+			Name:  "override-entrypoint",
+			Usage: "Runs the command instead of passing it to the image's entrypoint",
+		},
+		cli.StringFlag{
+			Name:  "result-file",
+			Usage: "Keeps the task once it succeeds, with the contents of this file as its result",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "How long to wait for the command to finish before removing it",
+			Value: DefaultRunTimeout,
+		},
+	}
+
+	var runCommand = cli.Command{
+		Name:  "run",
+		Usage: "Runs a command once in a docker image, then removes it",
+		Description: `ltc run TASK_NAME DOCKER_IMAGE [-- COMMAND [ARGS...]]
+
+   Runs COMMAND, or the image's start command, once as a task and streams its
+   output until it finishes.  The task is then deleted, as it is when ltc exits
+   early, e.g. on Ctrl-C.
+
+   --priority=low marks a long batch job so that it gets little CPU while apps
+   and their staging want it.
+
+   With --result-file, a task that succeeds is kept instead, so that the file
+   can be read with 'ltc task-result TASK_NAME'.
+
+   Lattice can't attach to a container's stdin, so the command can't read
+   from the terminal.
+
+   Exits with 14 if the command fails and 16 if it doesn't finish before the timeout.`,
+		Action: factory.runTask,
+		Flags:  runFlags,
+	}
+
+	return runCommand
+}
+
+func (factory *AppRunnerCommandFactory) runTask(context *cli.Context) {
+	cpuWeightFlag := uint(context.Int("cpu-weight"))
+	priorityFlag := context.String("priority")
+	resultFileFlag := context.String("result-file")
+	timeoutFlag := context.Duration("timeout")
+	name := context.Args().Get(0)
+	dockerImage := context.Args().Get(1)
+	terminator := context.Args().Get(2)
+	startCommand := context.Args().Get(3)
+
+	var args []string
+	if len(context.Args()) > 4 {
+		args = context.Args()[4:]
+	}
+
+	switch {
+	case len(context.Args()) < 2:
+		factory.ui.SayIncorrectUsage("TASK_NAME and DOCKER_IMAGE are required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case len(context.Args()) > 2 && terminator != "--":
+		factory.ui.SayIncorrectUsage("'--' Required before command")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case cpuWeightFlag < 1 || cpuWeightFlag > 100:
+		factory.ui.SayIncorrectUsage("Invalid CPU Weight")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := docker_app_runner.ValidateAppName(name); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := task_runner.ValidatePriority(priorityFlag); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	imageMetadata, err := factory.dockerMetadataFetcher.FetchMetadata(dockerImage)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error fetching image metadata: %s", err))
+		factory.exitHandler.Exit(exit_codes.BadDocker)
+		return
+	}
+
+	workingDir := context.String("working-dir")
+	if workingDir == "" {
+		workingDir = imageMetadata.WorkingDir
+	}
+	if workingDir == "" {
+		workingDir = "/"
+	}
+
+	if startCommand == "" {
+		if len(imageMetadata.StartCommand) == 0 {
+			factory.ui.SayLine("Unable to determine start command from image metadata.")
+			factory.exitHandler.Exit(exit_codes.BadDocker)
+			return
+		}
+		startCommand = imageMetadata.StartCommand[0]
+		args = imageMetadata.StartCommand[1:]
+	} else if len(imageMetadata.Entrypoint) > 0 && !context.Bool("override-entrypoint") {
+		commandArgs := append([]string{startCommand}, args...)
+		startCommand = imageMetadata.Entrypoint[0]
+		args = append(append([]string{}, imageMetadata.Entrypoint[1:]...), commandArgs...)
+	}
+
+	environment, err := factory.buildEnvironment(context.StringSlice("env"), name, imageMetadata.Env)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	err = factory.taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{
+		Name:                 name,
+		DockerImagePath:      dockerImage,
+		StartCommand:         startCommand,
+		Args:                 args,
+		EnvironmentVariables: environment,
+		WorkingDir:           workingDir,
+		ResultFile:           resultFileFlag,
+		Priority:             priorityFlag,
+		Privileged:           context.Bool("run-as-root"),
+		CPUWeight:            cpuWeightFlag,
+		MemoryMB:             context.Int("memory-mb"),
+		DiskMB:               context.Int("disk-mb"),
+	})
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error running %s: %s", name, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	factory.ui.SayInfo(fmt.Sprintf("Running %s: %s\n", name, strings.Join(append([]string{startCommand}, args...), " ")))
+
+	go factory.tailedLogsOutputter.OutputTailedLogs(name)
+
+	// The task is deleted once, whether it finishes, times out or ltc exits
+	// while it runs, unless it is kept for its result.
+	var deleteOnce sync.Once
+	deleteTask := func() {
+		deleteOnce.Do(func() {
+			factory.tailedLogsOutputter.StopOutputting()
+			if err := factory.taskRunner.DeleteTask(name); err != nil {
+				factory.ui.SayLine(fmt.Sprintf("Error removing %s: %s", name, err))
+				factory.ui.SayLine(fmt.Sprintf("To remove it:\n\tltc delete-task %s", name))
+			}
+		})
+	}
+	factory.exitHandler.OnExit(deleteTask)
+
+	done := exit_handler.Done(factory.exitHandler)
+	var taskInfo task_examiner.TaskInfo
+	ok := factory.pollUntilSuccess(done, timeoutFlag, func() bool {
+		var err error
+		taskInfo, err = factory.taskExaminer.TaskStatus(name)
+		return err == nil && taskInfo.State == receptor.TaskStateCompleted
+	}, silentIndicator{})
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	keepTask := ok && !taskInfo.Failed && resultFileFlag != ""
+	if keepTask {
+		deleteOnce.Do(factory.tailedLogsOutputter.StopOutputting)
+	} else {
+		deleteTask()
+	}
+
+	if !ok {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out waiting for %s to finish.", name)))
+		factory.exitHandler.Exit(exit_codes.Timeout)
+		return
+	} else if taskInfo.Failed {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%s failed: %s", name, taskInfo.FailureReason)))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	factory.ui.SayInfo(colors.Green(name+" finished.") + "\n")
+	if keepTask {
+		factory.ui.SayLine(fmt.Sprintf("To view its result:\n\tltc task-result %s", name))
+		factory.ui.SayLine(fmt.Sprintf("To remove it:\n\tltc delete-task %s", name))
+	}
+}
+
+// silentIndicator shows nothing while ltc run waits, as the command's own
+// output does that, and dots would end up among it when it is redirected.
+type silentIndicator struct{}
+
+func (silentIndicator) Tick()   {}
+func (silentIndicator) Finish() {}
@@ -0,0 +1,251 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher/fake_docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/fake_task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("RunCommand", func() {
+	var (
+		taskRunner              *fake_task_runner.FakeTaskRunner
+		taskExaminer            *fake_task_examiner.FakeTaskExaminer
+		dockerMetadataFetcher   *fake_docker_metadata_fetcher.FakeDockerMetadataFetcher
+		fakeTailedLogsOutputter *fake_tailed_logs_outputter.FakeTailedLogsOutputter
+		outputBuffer            *gbytes.Buffer
+		clock                   *fakeclock.FakeClock
+		fakeExitHandler         *fake_exit_handler.FakeExitHandler
+		runCommand              cli.Command
+	)
+
+	BeforeEach(func() {
+		taskRunner = &fake_task_runner.FakeTaskRunner{}
+		taskExaminer = &fake_task_examiner.FakeTaskExaminer{}
+		dockerMetadataFetcher = &fake_docker_metadata_fetcher.FakeDockerMetadataFetcher{}
+		fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
+		outputBuffer = gbytes.NewBuffer()
+		clock = fakeclock.NewFakeClock(time.Now())
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		commandFactory := command_factory.NewAppRunnerCommandFactory(command_factory.AppRunnerCommandFactoryConfig{
+			TaskRunner:            taskRunner,
+			TaskExaminer:          taskExaminer,
+			DockerMetadataFetcher: dockerMetadataFetcher,
+			TailedLogsOutputter:   fakeTailedLogsOutputter,
+			UI:                    terminal.NewUI(nil, outputBuffer, nil),
+			Clock:                 clock,
+			ExitHandler:           fakeExitHandler,
+		})
+		runCommand = commandFactory.MakeRunCommand()
+
+		dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{
+			WorkingDir:   "/app",
+			StartCommand: []string{"/start", "--serve"},
+			Env:          []string{"LANG=C"},
+		}, nil)
+		taskExaminer.TaskStatusReturns(task_examiner.TaskInfo{TaskGuid: "migrate", State: receptor.TaskStateCompleted}, nil)
+	})
+
+	It("runs the command in the image, streams its output and deletes the task", func() {
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app", "--env", "STAGE=prod", "--", "/migrate", "--up"})
+
+		Expect(dockerMetadataFetcher.FetchMetadataArgsForCall(0)).To(Equal("superfun/app"))
+		Expect(taskRunner.SubmitDockerTaskCallCount()).To(Equal(1))
+		Expect(taskRunner.SubmitDockerTaskArgsForCall(0)).To(Equal(task_runner.DockerTaskParams{
+			Name:                 "migrate",
+			DockerImagePath:      "superfun/app",
+			StartCommand:         "/migrate",
+			Args:                 []string{"--up"},
+			EnvironmentVariables: map[string]string{"LANG": "C", "PROCESS_GUID": "migrate", "STAGE": "prod"},
+			WorkingDir:           "/app",
+			CPUWeight:            100,
+			MemoryMB:             128,
+		}))
+		Expect(outputBuffer).To(test_helpers.SayLine("Running migrate: /migrate --up"))
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("migrate finished.")))
+
+		Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+		Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("migrate"))
+		Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
+		Expect(taskExaminer.TaskStatusArgsForCall(0)).To(Equal("migrate"))
+		Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+		Expect(taskRunner.DeleteTaskArgsForCall(0)).To(Equal("migrate"))
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("runs the image's start command when no command is given", func() {
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		params := taskRunner.SubmitDockerTaskArgsForCall(0)
+		Expect(params.StartCommand).To(Equal("/start"))
+		Expect(params.Args).To(Equal([]string{"--serve"}))
+	})
+
+	It("passes the command to the image's entrypoint", func() {
+		dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{Entrypoint: []string{"/entry", "-v"}}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app", "--", "rake", "db:migrate"})
+
+		params := taskRunner.SubmitDockerTaskArgsForCall(0)
+		Expect(params.StartCommand).To(Equal("/entry"))
+		Expect(params.Args).To(Equal([]string{"-v", "rake", "db:migrate"}))
+		Expect(params.WorkingDir).To(Equal("/"))
+	})
+
+	It("runs the command itself with --override-entrypoint", func() {
+		dockerMetadataFetcher.FetchMetadataReturns(&docker_metadata_fetcher.ImageMetadata{Entrypoint: []string{"/entry"}}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--override-entrypoint", "migrate", "superfun/app", "--", "rake"})
+
+		params := taskRunner.SubmitDockerTaskArgsForCall(0)
+		Expect(params.StartCommand).To(Equal("rake"))
+		Expect(params.Args).To(BeEmpty())
+	})
+
+	It("passes the priority to the task", func() {
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--priority=low", "migrate", "superfun/app"})
+
+		Expect(taskRunner.SubmitDockerTaskArgsForCall(0).Priority).To(Equal(task_examiner.PriorityLow))
+	})
+
+	Context("with --result-file", func() {
+		It("keeps the task once it succeeds", func() {
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--result-file", "/tmp/report.json", "report", "superfun/app"})
+
+			Expect(taskRunner.SubmitDockerTaskArgsForCall(0).ResultFile).To(Equal("/tmp/report.json"))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("report finished.")))
+			Expect(outputBuffer).To(test_helpers.SayLine("To view its result:\n\tltc task-result report"))
+			Expect(outputBuffer).To(test_helpers.SayLine("To remove it:\n\tltc delete-task report"))
+			Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
+			Expect(taskRunner.DeleteTaskCallCount()).To(BeZero())
+		})
+
+		It("deletes the task when it fails", func() {
+			taskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: receptor.TaskStateCompleted, Failed: true}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--result-file", "/tmp/report.json", "report", "superfun/app"})
+
+			Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+		})
+	})
+
+	It("reports a failed command", func() {
+		taskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: receptor.TaskStateCompleted, Failed: true, FailureReason: "Exited with status 3"}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("migrate failed: Exited with status 3")))
+		Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+
+	It("deletes the task when it doesn't finish before the timeout", func() {
+		taskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: receptor.TaskStateRunning}, nil)
+
+		commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(runCommand, []string{"--timeout", "5s", "migrate", "superfun/app"})
+
+		Eventually(clock.WatcherCount).Should(Equal(1))
+		clock.IncrementBySeconds(6)
+
+		Eventually(commandFinishChan).Should(BeClosed())
+		Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for migrate to finish.")))
+		Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+	})
+
+	It("deletes the task when ltc is interrupted", func() {
+		taskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: receptor.TaskStateRunning}, nil)
+
+		commandFinishChan := test_helpers.AsyncExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		Eventually(clock.WatcherCount).Should(Equal(1))
+		fakeExitHandler.Exit(exit_codes.SigInt)
+
+		Eventually(commandFinishChan).Should(BeClosed())
+		Expect(taskRunner.DeleteTaskCallCount()).To(Equal(1))
+		Expect(taskRunner.DeleteTaskArgsForCall(0)).To(Equal("migrate"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.SigInt}))
+	})
+
+	It("says how to remove a task it couldn't delete", func() {
+		taskRunner.DeleteTaskReturns(errors.New("receptor is down"))
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error removing migrate: receptor is down"))
+		Expect(outputBuffer).To(test_helpers.SayLine("To remove it:\n\tltc delete-task migrate"))
+	})
+
+	It("reports errors submitting the task", func() {
+		taskRunner.SubmitDockerTaskReturns(task_runner.TaskAlreadyExistsError{TaskGuid: "migrate"})
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error running migrate: migrate has already been submitted"))
+		Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+		Expect(taskRunner.DeleteTaskCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AlreadyExists}))
+	})
+
+	It("reports errors fetching the image metadata", func() {
+		dockerMetadataFetcher.FetchMetadataReturns(nil, errors.New("no such image"))
+
+		test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error fetching image metadata: no such image"))
+		Expect(taskRunner.SubmitDockerTaskCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadDocker}))
+	})
+
+	Context("invalid syntax", func() {
+		It("requires a task name and image", func() {
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME and DOCKER_IMAGE are required"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("requires '--' before the command", func() {
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"migrate", "superfun/app", "/migrate"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: '--' Required before command"))
+			Expect(taskRunner.SubmitDockerTaskCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("validates the CPU weight", func() {
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--cpu-weight", "0", "migrate", "superfun/app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid CPU Weight"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("validates the priority", func() {
+			test_helpers.ExecuteCommandWithArgs(runCommand, []string{"--priority=urgent", "migrate", "superfun/app"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid priority urgent: must be normal or low"))
+			Expect(taskRunner.SubmitDockerTaskCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+})
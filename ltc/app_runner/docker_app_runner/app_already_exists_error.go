@@ -0,0 +1,21 @@
+package docker_app_runner
+
+import "fmt"
+
+// AppAlreadyExistsError is returned when creating an app whose name is
+// already desired on lattice.
+type AppAlreadyExistsError struct {
+	AppName string
+}
+
+func (err AppAlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s is already running", err.AppName)
+}
+
+func (err AppAlreadyExistsError) AlreadyExists() bool {
+	return true
+}
+
+func (err AppAlreadyExistsError) Remedy() string {
+	return fmt.Sprintf("Choose another name, or run ltc remove %s first.", err.AppName)
+}
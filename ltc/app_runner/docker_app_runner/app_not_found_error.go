@@ -0,0 +1,20 @@
+package docker_app_runner
+
+import "fmt"
+
+// AppNotFoundError is returned when the named app isn't desired on lattice.
+type AppNotFoundError struct {
+	AppName string
+}
+
+func (err AppNotFoundError) Error() string {
+	return fmt.Sprintf("%s is not started.", err.AppName)
+}
+
+func (err AppNotFoundError) NotFound() bool {
+	return true
+}
+
+func (err AppNotFoundError) Remedy() string {
+	return "Run ltc list to see the apps on lattice."
+}
@@ -2,13 +2,15 @@ package docker_app_runner
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_repository_name_formatter"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/lrp_annotation"
 	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
@@ -21,16 +23,30 @@ const (
 	PortMonitor
 	URLMonitor
 
-	AttemptedToCreateLatticeDebugErrorMessage = reserved_app_ids.LatticeDebugLogStreamAppId + " is a reserved app name. It is used internally to stream debug logs for lattice components."
+	AttemptedToCreateLatticeDebugErrorMessage   = reserved_app_ids.LatticeDebugLogStreamAppId + " is a reserved app name. It is used internally to stream debug logs for lattice components."
+	AttemptedToCreateLatticeSecretsErrorMessage = reserved_app_ids.LatticeSecretsAppId + " is a reserved app name. It is used internally to store secrets."
+
+	SessionAffinityRouteOption = "session-affinity"
 )
 
 //go:generate counterfeiter -o fake_app_runner/fake_app_runner.go . AppRunner
 type AppRunner interface {
 	CreateDockerApp(params CreateDockerAppParams) error
+	UpdateDockerApp(params CreateDockerAppParams) (AppUpdate, error)
 	SubmitLrp(submitLrpJson []byte) (string, error)
 	ScaleApp(name string, instances int) error
 	UpdateAppRoutes(name string, routes RouteOverrides) error
+	AddRoute(name string, route RouteOverride) error
+	RemoveRoute(name string, route RouteOverride) error
+	StopApp(name string) error
+	StartApp(name string) (int, error)
 	RemoveApp(name string) error
+	RestartInstance(name string, index int) error
+	UpdateAppLabels(name string, labels map[string]string) error
+	BindLogDrain(name, drainURL string) error
+	UnbindLogDrain(name, drainURL string) error
+	AppDefinition(name string) (receptor.DesiredLRPCreateRequest, error)
+	RestoreApp(definition receptor.DesiredLRPCreateRequest) (bool, error)
 }
 
 type MonitorConfig struct {
@@ -42,11 +58,49 @@ type MonitorConfig struct {
 
 type RouteOverrides []RouteOverride
 
+// RouteOverride routes either HostnamePrefix under the system domain or the
+// complete Hostname to Port.  Path, if set, starts with a slash and limits
+// the route to requests under it.
 type RouteOverride struct {
 	HostnamePrefix string
+	Hostname       string
+	Path           string
 	Port           uint16
 }
 
+// RouteOption asks the router for special handling of the routes to Port, or
+// of every route when Port is 0.
+type RouteOption struct {
+	Port  uint16
+	Name  string
+	Value string
+}
+
+type RouteOptions []RouteOption
+
+func (options RouteOptions) applyTo(appRoutes route_helpers.AppRoutes) {
+	for index, appRoute := range appRoutes {
+		for _, option := range options {
+			if option.Port != 0 && option.Port != appRoute.Port {
+				continue
+			}
+			if appRoutes[index].Options == nil {
+				appRoutes[index].Options = make(map[string]string)
+			}
+			appRoutes[index].Options[option.Name] = option.Value
+		}
+	}
+}
+
+// Route returns the route as registered with the router.
+func (route RouteOverride) Route(systemDomain string) string {
+	hostname := route.Hostname
+	if hostname == "" {
+		hostname = fmt.Sprintf("%s.%s", route.HostnamePrefix, systemDomain)
+	}
+	return hostname + route.Path
+}
+
 type CreateDockerAppParams struct {
 	Name                 string
 	StartCommand         string
@@ -60,10 +114,34 @@ type CreateDockerAppParams struct {
 	MemoryMB             int
 	DiskMB               int
 	ExposedPorts         []uint16
+	UDPPorts             []uint16
 	WorkingDir           string
 	RouteOverrides       RouteOverrides
+	RouteOptions         RouteOptions
 	NoRoutes             bool
+	Sidecars             []Sidecar
+	Labels               map[string]string
 	Timeout              time.Duration
+	NoWait               bool
+	UpdateIfExists       bool
+	DryRun               bool
+	CleanupOnInterrupt   bool
+	VerifyRoute          bool
+	Strict               bool
+}
+
+// AppUpdate is what UpdateDockerApp changed: the fields of the desired LRP,
+// by their JSON names, and whether the app had to be created again.
+type AppUpdate struct {
+	ChangedFields []string
+	Recreated     bool
+}
+
+// Sidecar is an auxiliary process, such as a metrics exporter, run in the
+// same container as the app's start command.
+type Sidecar struct {
+	Path string
+	Args []string
 }
 
 const (
@@ -74,27 +152,94 @@ const (
 type appRunner struct {
 	receptorClient receptor.Client
 	systemDomain   string
+	reservedAppIds reserved_app_ids.Registry
+}
+
+// New returns an AppRunner that refuses to create apps under the app ids in
+// reservedAppIds, as well as under those lattice uses internally.
+func New(receptorClient receptor.Client, systemDomain string, reservedAppIds reserved_app_ids.Registry) AppRunner {
+	return &appRunner{receptorClient, systemDomain, reservedAppIds}
 }
 
-func New(receptorClient receptor.Client, systemDomain string) AppRunner {
-	return &appRunner{receptorClient, systemDomain}
+// ValidateAppName returns an InvalidAppNameError unless name can be used in
+// the app's hostnames.
+func ValidateAppName(name string) error {
+	var reason string
+	invalid := strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789-")
+	switch {
+	case len(name) > route_helpers.MaxLabelLength:
+		reason = fmt.Sprintf("it is longer than %d characters", route_helpers.MaxLabelLength)
+	case strings.ToLower(name) != name:
+		reason = "it contains uppercase letters"
+	case invalid != "":
+		reason = fmt.Sprintf("it contains '%c'", []rune(invalid)[0])
+	case strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-"):
+		reason = "it starts or ends with a hyphen"
+	default:
+		return nil
+	}
+	return InvalidAppNameError{AppName: name, Reason: reason}
 }
 
 func (appRunner *appRunner) CreateDockerApp(params CreateDockerAppParams) error {
-	if params.Name == reserved_app_ids.LatticeDebugLogStreamAppId {
-		return errors.New(AttemptedToCreateLatticeDebugErrorMessage)
+	if err := appRunner.reservedAppIds.Check(params.Name); err != nil {
+		return err
+	}
+	if err := ValidateAppName(params.Name); err != nil {
+		return err
 	}
 	if exists, err := appRunner.desiredLRPExists(params.Name); err != nil {
 		return err
 	} else if exists {
-		return newExistingAppError(params.Name)
+		return AppAlreadyExistsError{AppName: params.Name}
+	}
+
+	req, err := appRunner.desiredLRPCreateRequest(params)
+	if err != nil {
+		return err
 	}
 
 	if err := appRunner.receptorClient.UpsertDomain(lrpDomain, 0); err != nil {
 		return err
 	}
+	return appRunner.receptorClient.CreateDesiredLRP(req)
+}
+
+// UpdateDockerApp makes an existing app match what CreateDockerApp would
+// create from params, keeping the log drains bound to it.  Like RestoreApp,
+// it has to create the app again when anything but its instances, routes or
+// annotation changes.
+func (appRunner *appRunner) UpdateDockerApp(params CreateDockerAppParams) (AppUpdate, error) {
+	desiredLRP, err := appRunner.getDesiredLRP(params.Name)
+	if err != nil {
+		return AppUpdate{}, err
+	}
+
+	definition, err := appRunner.desiredLRPCreateRequest(params)
+	if err != nil {
+		return AppUpdate{}, err
+	}
 
-	return appRunner.desireLrp(params)
+	annotation, err := parseAnnotation(desiredLRP)
+	if err != nil {
+		return AppUpdate{}, err
+	}
+	if drains, ok := annotation[lrp_annotation.LogDrainsKey]; ok {
+		desiredAnnotation, err := lrp_annotation.Parse(definition.Annotation)
+		if err != nil {
+			desiredAnnotation = lrp_annotation.Annotation{}
+		}
+		desiredAnnotation[lrp_annotation.LogDrainsKey] = drains
+		definition.Annotation = desiredAnnotation.String()
+	}
+
+	changed := ChangedFields(createRequestFor(desiredLRP), definition)
+	if len(changed) == 0 {
+		return AppUpdate{}, nil
+	}
+
+	recreated, err := appRunner.RestoreApp(definition)
+	return AppUpdate{ChangedFields: changed, Recreated: recreated}, err
 }
 
 func (appRunner *appRunner) SubmitLrp(submitLrpJson []byte) (string, error) {
@@ -103,17 +248,17 @@ func (appRunner *appRunner) SubmitLrp(submitLrpJson []byte) (string, error) {
 
 	err := json.Unmarshal(submitLrpJson, &desiredLRP)
 	if err != nil {
-		return "", err
+		return "", InvalidManifestError{Err: err}
 	}
 
-	if desiredLRP.ProcessGuid == reserved_app_ids.LatticeDebugLogStreamAppId {
-		return desiredLRP.ProcessGuid, errors.New(AttemptedToCreateLatticeDebugErrorMessage)
+	if err := appRunner.reservedAppIds.Check(desiredLRP.ProcessGuid); err != nil {
+		return desiredLRP.ProcessGuid, err
 	}
 
 	if exists, err := appRunner.desiredLRPExists(desiredLRP.ProcessGuid); err != nil {
 		return desiredLRP.ProcessGuid, err
 	} else if exists {
-		return desiredLRP.ProcessGuid, newExistingAppError(desiredLRP.ProcessGuid)
+		return desiredLRP.ProcessGuid, AppAlreadyExistsError{AppName: desiredLRP.ProcessGuid}
 	}
 
 	if err := appRunner.receptorClient.UpsertDomain(lrpDomain, 0); err != nil {
@@ -121,6 +266,9 @@ func (appRunner *appRunner) SubmitLrp(submitLrpJson []byte) (string, error) {
 	}
 
 	err = appRunner.receptorClient.CreateDesiredLRP(desiredLRP)
+	if receptorErr, ok := err.(receptor.Error); ok && receptorErr.Type == receptor.InvalidLRP {
+		return desiredLRP.ProcessGuid, InvalidManifestError{Err: err}
+	}
 	return desiredLRP.ProcessGuid, err
 }
 
@@ -128,32 +276,313 @@ func (appRunner *appRunner) ScaleApp(name string, instances int) error {
 	if exists, err := appRunner.desiredLRPExists(name); err != nil {
 		return err
 	} else if !exists {
-		return newAppNotStartedError(name)
+		return AppNotFoundError{AppName: name}
 	}
 
 	return appRunner.updateLrpInstances(name, instances)
 }
 
+// UpdateAppRoutes replaces the app's routes, keeping the route options of
+// any port that is still routed.
 func (appRunner *appRunner) UpdateAppRoutes(name string, routes RouteOverrides) error {
-	if exists, err := appRunner.desiredLRPExists(name); err != nil {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
 		return err
-	} else if !exists {
-		return newAppNotStartedError(name)
 	}
 
-	return appRunner.updateLrpRoutes(name, routes)
+	return appRunner.updateLrpRoutes(desiredLRP, routes)
+}
+
+// AddRoute maps one more hostname to a port of the app, leaving its other
+// routes as they are.  Adding a route the app already has does nothing.
+func (appRunner *appRunner) AddRoute(name string, route RouteOverride) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+
+	hostname := route.Route(appRunner.systemDomain)
+	appRoutes := route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes)
+
+	for index, appRoute := range appRoutes {
+		if appRoute.Port != route.Port {
+			continue
+		}
+		for _, existing := range appRoute.Hostnames {
+			if existing == hostname {
+				return nil
+			}
+		}
+		appRoutes[index].Hostnames = append(appRoute.Hostnames, hostname)
+		return appRunner.updateLrpAppRoutes(desiredLRP, appRoutes)
+	}
+
+	appRoutes = append(appRoutes, route_helpers.AppRoute{Hostnames: []string{hostname}, Port: route.Port})
+	return appRunner.updateLrpAppRoutes(desiredLRP, appRoutes)
+}
+
+// RemoveRoute unmaps one hostname from a port of the app, leaving its other
+// routes as they are.
+func (appRunner *appRunner) RemoveRoute(name string, route RouteOverride) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+
+	hostname := route.Route(appRunner.systemDomain)
+	appRoutes := route_helpers.AppRoutes{}
+	found := false
+
+	for _, appRoute := range route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes) {
+		hostnames := []string{}
+		for _, existing := range appRoute.Hostnames {
+			if appRoute.Port == route.Port && existing == hostname {
+				found = true
+				continue
+			}
+			hostnames = append(hostnames, existing)
+		}
+		if len(hostnames) > 0 {
+			appRoutes = append(appRoutes, route_helpers.AppRoute{Hostnames: hostnames, Port: appRoute.Port, Options: appRoute.Options})
+		}
+	}
+
+	if !found {
+		return newRouteNotFoundError(name, hostname, route.Port)
+	}
+	return appRunner.updateLrpAppRoutes(desiredLRP, appRoutes)
+}
+
+// StopApp scales the app to zero, recording its instance count in the
+// desired LRP's annotation so that StartApp can bring it back.  The rest of
+// the app's configuration stays on the desired LRP untouched.
+func (appRunner *appRunner) StopApp(name string) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+
+	annotation, err := parseAnnotation(desiredLRP)
+	if err != nil {
+		return err
+	}
+	if _, stopped := annotation[lrp_annotation.StoppedInstancesKey]; stopped {
+		return fmt.Errorf("%s is already stopped.", name)
+	}
+	if desiredLRP.Instances == 0 {
+		return fmt.Errorf("%s has no instances to stop.", name)
+	}
+
+	instances, _ := json.Marshal(desiredLRP.Instances)
+	annotation[lrp_annotation.StoppedInstancesKey] = json.RawMessage(instances)
+
+	zero := 0
+	updatedAnnotation := annotation.String()
+	return appRunner.receptorClient.UpdateDesiredLRP(name, receptor.DesiredLRPUpdateRequest{
+		Instances:  &zero,
+		Annotation: &updatedAnnotation,
+	})
+}
+
+// StartApp restores the instance count recorded by StopApp and returns it.
+func (appRunner *appRunner) StartApp(name string) (int, error) {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return 0, err
+	}
+
+	annotation, err := parseAnnotation(desiredLRP)
+	if err != nil {
+		return 0, err
+	}
+
+	var instances int
+	if data, stopped := annotation[lrp_annotation.StoppedInstancesKey]; !stopped {
+		return 0, fmt.Errorf("%s is not stopped.", name)
+	} else if err := json.Unmarshal(data, &instances); err != nil {
+		return 0, fmt.Errorf("%s has an invalid stopped instance count: %s", name, err)
+	}
+	delete(annotation, lrp_annotation.StoppedInstancesKey)
+
+	updatedAnnotation := annotation.String()
+	err = appRunner.receptorClient.UpdateDesiredLRP(name, receptor.DesiredLRPUpdateRequest{
+		Instances:  &instances,
+		Annotation: &updatedAnnotation,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return instances, nil
+}
+
+// RestartInstance stops the instance of the app at index.  Diego notices
+// the instance is gone and starts a new one at the same index.
+func (appRunner *appRunner) RestartInstance(name string, index int) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= desiredLRP.Instances {
+		return fmt.Errorf("%s has no instance %d.", name, index)
+	}
+
+	return appRunner.receptorClient.KillActualLRPByProcessGuidAndIndex(name, index)
+}
+
+// UpdateAppLabels merges labels into the app's labels.  A label with an
+// empty value is removed.
+func (appRunner *appRunner) UpdateAppLabels(name string, labels map[string]string) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+
+	annotation, err := parseAnnotation(desiredLRP)
+	if err != nil {
+		return err
+	}
+
+	appLabels := map[string]string{}
+	if data, ok := annotation[lrp_annotation.LabelsKey]; ok {
+		if err := json.Unmarshal(data, &appLabels); err != nil {
+			return fmt.Errorf("%s has invalid labels: %s", name, err)
+		}
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(appLabels, key)
+		} else {
+			appLabels[key] = value
+		}
+	}
+
+	if len(appLabels) == 0 {
+		delete(annotation, lrp_annotation.LabelsKey)
+	} else {
+		annotation[lrp_annotation.LabelsKey], _ = json.Marshal(appLabels)
+	}
+
+	updatedAnnotation := annotation.String()
+	return appRunner.receptorClient.UpdateDesiredLRP(name, receptor.DesiredLRPUpdateRequest{
+		Annotation: &updatedAnnotation,
+	})
+}
+
+// BindLogDrain records drainURL in the app's annotation as a drain its logs
+// are forwarded to.  Binding a drain does not restart the app.
+func (appRunner *appRunner) BindLogDrain(name, drainURL string) error {
+	return appRunner.updateLogDrains(name, func(drainURLs []string) ([]string, error) {
+		for _, boundURL := range drainURLs {
+			if boundURL == drainURL {
+				return nil, fmt.Errorf("%s already drains its logs to %s.", name, drainURL)
+			}
+		}
+		return append(drainURLs, drainURL), nil
+	})
+}
+
+func (appRunner *appRunner) UnbindLogDrain(name, drainURL string) error {
+	return appRunner.updateLogDrains(name, func(drainURLs []string) ([]string, error) {
+		for index, boundURL := range drainURLs {
+			if boundURL == drainURL {
+				return append(drainURLs[:index], drainURLs[index+1:]...), nil
+			}
+		}
+		return nil, newLogDrainNotFoundError(name, drainURL)
+	})
+}
+
+func (appRunner *appRunner) updateLogDrains(name string, change func([]string) ([]string, error)) error {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return err
+	}
+
+	annotation, err := parseAnnotation(desiredLRP)
+	if err != nil {
+		return err
+	}
+
+	var drainURLs []string
+	if data, ok := annotation[lrp_annotation.LogDrainsKey]; ok {
+		if err := json.Unmarshal(data, &drainURLs); err != nil {
+			return fmt.Errorf("%s has invalid log drains: %s", name, err)
+		}
+	}
+
+	drainURLs, err = change(drainURLs)
+	if err != nil {
+		return err
+	}
+
+	if len(drainURLs) == 0 {
+		delete(annotation, lrp_annotation.LogDrainsKey)
+	} else {
+		annotation[lrp_annotation.LogDrainsKey], _ = json.Marshal(drainURLs)
+	}
+
+	updatedAnnotation := annotation.String()
+	return appRunner.receptorClient.UpdateDesiredLRP(name, receptor.DesiredLRPUpdateRequest{
+		Annotation: &updatedAnnotation,
+	})
 }
 
 func (appRunner *appRunner) RemoveApp(name string) error {
 	if lrpExists, err := appRunner.desiredLRPExists(name); err != nil {
 		return err
 	} else if !lrpExists {
-		return newAppNotStartedError(name)
+		return AppNotFoundError{AppName: name}
 	}
 
 	return appRunner.receptorClient.DeleteDesiredLRP(name)
 }
 
+// AppDefinition returns the request that would create the app as it is now.
+func (appRunner *appRunner) AppDefinition(name string) (receptor.DesiredLRPCreateRequest, error) {
+	desiredLRP, err := appRunner.getDesiredLRP(name)
+	if err != nil {
+		return receptor.DesiredLRPCreateRequest{}, err
+	}
+
+	return createRequestFor(desiredLRP), nil
+}
+
+// RestoreApp makes the app match a definition from AppDefinition, creating
+// the app if it no longer exists.  Instances, routes and the annotation are
+// updated in place, but Diego can't change anything else about a desired
+// LRP, so any other difference means deleting the app and creating it
+// again, restarting every instance.  RestoreApp reports whether it created
+// the app.
+func (appRunner *appRunner) RestoreApp(definition receptor.DesiredLRPCreateRequest) (bool, error) {
+	desiredLRP, err := appRunner.getDesiredLRP(definition.ProcessGuid)
+	if _, notStarted := err.(AppNotFoundError); notStarted {
+		if err := appRunner.receptorClient.UpsertDomain(lrpDomain, 0); err != nil {
+			return false, err
+		}
+		return true, appRunner.receptorClient.CreateDesiredLRP(definition)
+	} else if err != nil {
+		return false, err
+	}
+
+	if NeedsRecreate(createRequestFor(desiredLRP), definition) {
+		if err := appRunner.receptorClient.DeleteDesiredLRP(definition.ProcessGuid); err != nil {
+			return false, err
+		}
+		return true, appRunner.receptorClient.CreateDesiredLRP(keepDeployment(definition, desiredLRP))
+	}
+
+	routes := definition.Routes
+	if routes == nil {
+		routes = receptor.RoutingInfo{}
+	}
+	annotation := keepDeployment(definition, desiredLRP).Annotation
+	return false, appRunner.receptorClient.UpdateDesiredLRP(definition.ProcessGuid, receptor.DesiredLRPUpdateRequest{
+		Instances:  &definition.Instances,
+		Routes:     routes,
+		Annotation: &annotation,
+	})
+}
+
 func (appRunner *appRunner) desiredLRPExists(name string) (exists bool, err error) {
 	desiredLRPs, err := appRunner.receptorClient.DesiredLRPs()
 	if err != nil {
@@ -169,10 +598,25 @@ func (appRunner *appRunner) desiredLRPExists(name string) (exists bool, err erro
 	return false, nil
 }
 
-func (appRunner *appRunner) desireLrp(params CreateDockerAppParams) error {
+func (appRunner *appRunner) getDesiredLRP(name string) (receptor.DesiredLRPResponse, error) {
+	desiredLRPs, err := appRunner.receptorClient.DesiredLRPs()
+	if err != nil {
+		return receptor.DesiredLRPResponse{}, err
+	}
+
+	for _, desiredLRP := range desiredLRPs {
+		if desiredLRP.ProcessGuid == name {
+			return desiredLRP, nil
+		}
+	}
+
+	return receptor.DesiredLRPResponse{}, AppNotFoundError{AppName: name}
+}
+
+func (appRunner *appRunner) desiredLRPCreateRequest(params CreateDockerAppParams) (receptor.DesiredLRPCreateRequest, error) {
 	dockerImageUrl, err := docker_repository_name_formatter.FormatForReceptor(params.DockerImagePath)
 	if err != nil {
-		return err
+		return receptor.DesiredLRPCreateRequest{}, err
 	}
 
 	envVars := buildEnvironmentVariables(params.EnvironmentVariables)
@@ -184,17 +628,32 @@ func (appRunner *appRunner) desireLrp(params CreateDockerAppParams) error {
 	} else if len(params.RouteOverrides) > 0 {
 		routeMap := make(map[uint16][]string)
 		for _, override := range params.RouteOverrides {
-			routeMap[override.Port] = append(routeMap[override.Port], fmt.Sprintf("%s.%s", override.HostnamePrefix, appRunner.systemDomain))
+			routeMap[override.Port] = append(routeMap[override.Port], override.Route(appRunner.systemDomain))
 		}
-		for port, hostnames := range routeMap {
+		ports := make([]int, 0, len(routeMap))
+		for port := range routeMap {
+			ports = append(ports, int(port))
+		}
+		sort.Ints(ports)
+		for _, port := range ports {
 			appRoutes = append(appRoutes, route_helpers.AppRoute{
-				Hostnames: hostnames,
-				Port:      port,
+				Hostnames: routeMap[uint16(port)],
+				Port:      uint16(port),
 			})
 		}
 	} else {
 		appRoutes = appRunner.buildDefaultRoutingInfo(params.Name, params.ExposedPorts, params.Monitor.Port)
 	}
+	params.RouteOptions.applyTo(appRoutes)
+
+	for _, appRoute := range appRoutes {
+		for _, route := range appRoute.Hostnames {
+			hostname := strings.SplitN(route, "/", 2)[0]
+			if err := route_helpers.ValidateHostname(hostname); err != nil {
+				return receptor.DesiredLRPCreateRequest{}, InvalidRouteError{Err: err}
+			}
+		}
+	}
 
 	req := receptor.DesiredLRPCreateRequest{
 		ProcessGuid:          params.Name,
@@ -206,7 +665,8 @@ func (appRunner *appRunner) desireLrp(params CreateDockerAppParams) error {
 		MemoryMB:             params.MemoryMB,
 		DiskMB:               params.DiskMB,
 		Privileged:           true,
-		Ports:                params.ExposedPorts,
+		Ports:                mergePorts(params.ExposedPorts, params.UDPPorts),
+		Annotation:           createAnnotation(params),
 		LogGuid:              params.Name,
 		LogSource:            "APP",
 		MetricsGuid:          params.Name,
@@ -215,12 +675,7 @@ func (appRunner *appRunner) desireLrp(params CreateDockerAppParams) error {
 			From: healthcheckDownloadUrl,
 			To:   "/tmp",
 		},
-		Action: &models.RunAction{
-			Path:       params.StartCommand,
-			Args:       params.AppArgs,
-			Privileged: params.Privileged,
-			Dir:        params.WorkingDir,
-		},
+		Action: buildRunAction(params),
 	}
 
 	var healthCheckArgs []string
@@ -242,7 +697,34 @@ func (appRunner *appRunner) desireLrp(params CreateDockerAppParams) error {
 		}
 	}
 
-	return appRunner.receptorClient.CreateDesiredLRP(req)
+	return req, nil
+}
+
+// buildRunAction runs any sidecars in parallel with the start command.  The
+// sidecars share the app's working directory, user and environment, and
+// log with the SIDECAR source.
+func buildRunAction(params CreateDockerAppParams) models.Action {
+	runAction := &models.RunAction{
+		Path:       params.StartCommand,
+		Args:       params.AppArgs,
+		Privileged: params.Privileged,
+		Dir:        params.WorkingDir,
+	}
+	if len(params.Sidecars) == 0 {
+		return runAction
+	}
+
+	actions := []models.Action{runAction}
+	for _, sidecar := range params.Sidecars {
+		actions = append(actions, &models.RunAction{
+			Path:       sidecar.Path,
+			Args:       sidecar.Args,
+			Privileged: params.Privileged,
+			Dir:        params.WorkingDir,
+			LogSource:  "SIDECAR",
+		})
+	}
+	return models.Parallel(actions...)
 }
 
 func (appRunner *appRunner) updateLrpInstances(name string, instances int) error {
@@ -256,28 +738,46 @@ func (appRunner *appRunner) updateLrpInstances(name string, instances int) error
 	return err
 }
 
-func (appRunner *appRunner) updateLrpRoutes(name string, routes RouteOverrides) error {
+func (appRunner *appRunner) updateLrpRoutes(desiredLRP receptor.DesiredLRPResponse, routes RouteOverrides) error {
 	appRoutes := route_helpers.AppRoutes{}
 
+	optionsByPort := make(map[uint16]map[string]string)
+	for _, appRoute := range route_helpers.AppRoutesFromRoutingInfo(desiredLRP.Routes) {
+		optionsByPort[appRoute.Port] = appRoute.Options
+	}
+
 	routeMap := make(map[uint16][]string)
 	for _, override := range routes {
-		routeMap[override.Port] = append(routeMap[override.Port], fmt.Sprintf("%s.%s", override.HostnamePrefix, appRunner.systemDomain))
+		routeMap[override.Port] = append(routeMap[override.Port], override.Route(appRunner.systemDomain))
 	}
 	for port, hostnames := range routeMap {
 		appRoutes = append(appRoutes, route_helpers.AppRoute{
 			Hostnames: hostnames,
 			Port:      port,
+			Options:   optionsByPort[port],
 		})
 	}
 
-	err := appRunner.receptorClient.UpdateDesiredLRP(
-		name,
+	return appRunner.updateLrpAppRoutes(desiredLRP, appRoutes)
+}
+
+// updateLrpAppRoutes replaces only the app's cf-router routes, keeping any
+// routing info that other routers registered.
+func (appRunner *appRunner) updateLrpAppRoutes(desiredLRP receptor.DesiredLRPResponse, appRoutes route_helpers.AppRoutes) error {
+	routes := receptor.RoutingInfo{}
+	for router, info := range desiredLRP.Routes {
+		routes[router] = info
+	}
+	for router, info := range appRoutes.RoutingInfo() {
+		routes[router] = info
+	}
+
+	return appRunner.receptorClient.UpdateDesiredLRP(
+		desiredLRP.ProcessGuid,
 		receptor.DesiredLRPUpdateRequest{
-			Routes: appRoutes.RoutingInfo(),
+			Routes: routes,
 		},
 	)
-
-	return err
 }
 
 func (appRunner *appRunner) buildDefaultRoutingInfo(appName string, exposedPorts []uint16, monitorPort uint16) route_helpers.AppRoutes {
@@ -299,6 +799,189 @@ func (appRunner *appRunner) buildDefaultRoutingInfo(appName string, exposedPorts
 	return appRoutes
 }
 
+func mergePorts(tcpPorts, udpPorts []uint16) []uint16 {
+	ports := append([]uint16{}, tcpPorts...)
+	for _, udpPort := range udpPorts {
+		found := false
+		for _, port := range ports {
+			if port == udpPort {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ports = append(ports, udpPort)
+		}
+	}
+	return ports
+}
+
+// createAnnotation records which ports are udp, since the ports of a desired
+// LRP carry no protocol, along with the app's labels.
+func createAnnotation(params CreateDockerAppParams) string {
+	annotation := lrp_annotation.Annotation{}
+	if len(params.UDPPorts) > 0 {
+		annotation[lrp_annotation.UDPPortsKey], _ = json.Marshal(params.UDPPorts)
+	}
+	if len(params.Labels) > 0 {
+		annotation[lrp_annotation.LabelsKey], _ = json.Marshal(params.Labels)
+	}
+	return annotation.String()
+}
+
+func parseAnnotation(desiredLRP receptor.DesiredLRPResponse) (lrp_annotation.Annotation, error) {
+	annotation, err := lrp_annotation.Parse(desiredLRP.Annotation)
+	if err != nil {
+		return nil, fmt.Errorf("%s has an annotation ltc did not write, so ltc can't record its state.", desiredLRP.ProcessGuid)
+	}
+	return annotation, nil
+}
+
+func createRequestFor(desiredLRP receptor.DesiredLRPResponse) receptor.DesiredLRPCreateRequest {
+	return receptor.DesiredLRPCreateRequest{
+		ProcessGuid:          desiredLRP.ProcessGuid,
+		Domain:               desiredLRP.Domain,
+		RootFS:               desiredLRP.RootFS,
+		Instances:            desiredLRP.Instances,
+		EnvironmentVariables: desiredLRP.EnvironmentVariables,
+		Setup:                desiredLRP.Setup,
+		Action:               desiredLRP.Action,
+		Monitor:              desiredLRP.Monitor,
+		StartTimeout:         desiredLRP.StartTimeout,
+		DiskMB:               desiredLRP.DiskMB,
+		MemoryMB:             desiredLRP.MemoryMB,
+		CPUWeight:            desiredLRP.CPUWeight,
+		Privileged:           desiredLRP.Privileged,
+		Ports:                desiredLRP.Ports,
+		Routes:               desiredLRP.Routes,
+		LogGuid:              desiredLRP.LogGuid,
+		LogSource:            desiredLRP.LogSource,
+		MetricsGuid:          desiredLRP.MetricsGuid,
+		Annotation:           desiredLRP.Annotation,
+		EgressRules:          desiredLRP.EgressRules,
+	}
+}
+
+// keepDeployment carries when and by whom the app was deployed over to a
+// definition it is created again or updated from, so that it isn't taken
+// for a new app.
+func keepDeployment(definition receptor.DesiredLRPCreateRequest, desiredLRP receptor.DesiredLRPResponse) receptor.DesiredLRPCreateRequest {
+	current, err := parseAnnotation(desiredLRP)
+	if err != nil || current[lrp_annotation.DeploymentKey] == nil {
+		return definition
+	}
+
+	annotation, err := lrp_annotation.Parse(definition.Annotation)
+	if err != nil {
+		return definition
+	}
+	if _, ok := annotation[lrp_annotation.DeploymentKey]; !ok {
+		annotation[lrp_annotation.DeploymentKey] = current[lrp_annotation.DeploymentKey]
+		definition.Annotation = annotation.String()
+	}
+	return definition
+}
+
+// LabelDefinition adds labels to those in an app definition's annotation,
+// where `ltc label` keeps them, so that the app is created with them.
+func LabelDefinition(definition receptor.DesiredLRPCreateRequest, labels map[string]string) (receptor.DesiredLRPCreateRequest, error) {
+	annotation, err := lrp_annotation.Parse(definition.Annotation)
+	if err != nil {
+		return definition, InvalidManifestError{Err: fmt.Errorf("The annotation must be a JSON object to add labels: %s", err)}
+	}
+
+	appLabels := map[string]string{}
+	if data, ok := annotation[lrp_annotation.LabelsKey]; ok {
+		if err := json.Unmarshal(data, &appLabels); err != nil {
+			return definition, InvalidManifestError{Err: fmt.Errorf("%s has invalid labels: %s", definition.ProcessGuid, err)}
+		}
+	}
+	for key, value := range labels {
+		appLabels[key] = value
+	}
+
+	annotation[lrp_annotation.LabelsKey], _ = json.Marshal(appLabels)
+	definition.Annotation = annotation.String()
+	return definition, nil
+}
+
+// NeedsRecreate reports whether giving an app definition in place of
+// current takes deleting the app and creating it again, as the receptor only
+// updates the instances, routes and annotation of a running app.
+func NeedsRecreate(current, definition receptor.DesiredLRPCreateRequest) bool {
+	current.Instances = definition.Instances
+	current.Routes = definition.Routes
+	current.Annotation = definition.Annotation
+	return !SameDefinition(current, definition)
+}
+
+// SameDefinition reports whether two app definitions would create the same
+// app.
+func SameDefinition(a, b receptor.DesiredLRPCreateRequest) bool {
+	return len(ChangedFields(a, b)) == 0
+}
+
+// ChangedFields lists, by their JSON names, the fields that differ between
+// two app definitions.  Fields are compared as JSON, since actions are
+// interfaces, with environment variables in any order and empty values
+// equal to missing ones.  When the app was deployed isn't part of its
+// definition, so the deployment in the annotation is ignored.
+func ChangedFields(a, b receptor.DesiredLRPCreateRequest) []string {
+	aFields, aErr := definitionFields(a)
+	bFields, bErr := definitionFields(b)
+	if aErr != nil || bErr != nil {
+		return []string{"definition"}
+	}
+
+	changed := []string{}
+	for name, value := range aFields {
+		if string(bFields[name]) != string(value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range bFields {
+		if _, ok := aFields[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func definitionFields(definition receptor.DesiredLRPCreateRequest) (map[string]json.RawMessage, error) {
+	env := append([]receptor.EnvironmentVariable{}, definition.EnvironmentVariables...)
+	sort.Sort(byName(env))
+	definition.EnvironmentVariables = env
+
+	if annotation, err := lrp_annotation.Parse(definition.Annotation); err == nil {
+		delete(annotation, lrp_annotation.DeploymentKey)
+		definition.Annotation = annotation.String()
+	}
+
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		switch string(value) {
+		case "null", "[]", "{}", `""`, "0", "false":
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+type byName []receptor.EnvironmentVariable
+
+func (env byName) Len() int           { return len(env) }
+func (env byName) Swap(i, j int)      { env[i], env[j] = env[j], env[i] }
+func (env byName) Less(i, j int) bool { return env[i].Name < env[j].Name }
+
 func buildEnvironmentVariables(environmentVariables map[string]string) []receptor.EnvironmentVariable {
 	appEnvVars := make([]receptor.EnvironmentVariable, 0, len(environmentVariables)+1)
 	for name, value := range environmentVariables {
@@ -3,6 +3,8 @@ package docker_app_runner_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/cloudfoundry-incubator/lattice/ltc/test_helpers/matchers"
@@ -10,6 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
@@ -26,7 +29,21 @@ var _ = Describe("DockerAppRunner", func() {
 
 	BeforeEach(func() {
 		fakeReceptorClient = &fake_receptor.FakeClient{}
-		appRunner = docker_app_runner.New(fakeReceptorClient, "myDiegoInstall.com")
+		appRunner = docker_app_runner.New(fakeReceptorClient, "myDiegoInstall.com", reserved_app_ids.Registry{"billing"})
+	})
+
+	Describe("ValidateAppName", func() {
+		It("accepts lowercase letters, digits and hyphens", func() {
+			Expect(docker_app_runner.ValidateAppName("my-app-2")).To(Succeed())
+		})
+
+		It("explains what is wrong with the name", func() {
+			Expect(docker_app_runner.ValidateAppName("MyApp")).To(MatchError("MyApp is not a valid app name: it contains uppercase letters."))
+			Expect(docker_app_runner.ValidateAppName("my_app")).To(MatchError("my_app is not a valid app name: it contains '_'."))
+			Expect(docker_app_runner.ValidateAppName("my.app")).To(MatchError("my.app is not a valid app name: it contains '.'."))
+			Expect(docker_app_runner.ValidateAppName("-app")).To(MatchError("-app is not a valid app name: it starts or ends with a hyphen."))
+			Expect(docker_app_runner.ValidateAppName(strings.Repeat("a", 64))).To(MatchError(strings.Repeat("a", 64) + " is not a valid app name: it is longer than 63 characters."))
+		})
 	})
 
 	Describe("CreateDockerApp", func() {
@@ -94,6 +111,27 @@ var _ = Describe("DockerAppRunner", func() {
 			}))
 		})
 
+		Context("when sidecars are passed", func() {
+			It("runs them in parallel with the start command", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "americano-app",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					AppArgs:         []string{"app-arg"},
+					WorkingDir:      "/app",
+					Sidecars: []docker_app_runner.Sidecar{
+						docker_app_runner.Sidecar{Path: "/bin/exporter", Args: []string{"--port", "9100"}},
+					},
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Action).To(Equal(models.Parallel(
+					&models.RunAction{Path: "/app-run-statement", Args: []string{"app-arg"}, Dir: "/app"},
+					&models.RunAction{Path: "/bin/exporter", Args: []string{"--port", "9100"}, Dir: "/app", LogSource: "SIDECAR"},
+				)))
+			})
+		})
+
 		Context("when 'lattice-debug' is passed as the appId", func() {
 			It("is an error because that id is reserved for the lattice-debug log stream", func() {
 				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
@@ -107,6 +145,65 @@ var _ = Describe("DockerAppRunner", func() {
 			})
 		})
 
+		Context("when 'lattice-secrets' is passed as the appId", func() {
+			It("is an error because that id is reserved for the secrets store", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            reserved_app_ids.LatticeSecretsAppId,
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					AppArgs:         []string{},
+				})
+
+				Expect(err).To(MatchError(docker_app_runner.AttemptedToCreateLatticeSecretsErrorMessage))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the app name is reserved for the cluster", func() {
+			It("is an error", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "billing",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+				})
+
+				Expect(err).To(MatchError("billing is a reserved app name. It is reserved for this cluster; see ltc config get reserved-names."))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the app name can't be used in a hostname", func() {
+			It("returns an InvalidAppNameError without asking the receptor", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "My_App",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+				})
+
+				Expect(err).To(MatchError("My_App is not a valid app name: it contains uppercase letters."))
+				Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidAppNameError{}))
+				Expect(fakeReceptorClient.GetDesiredLRPCallCount()).To(Equal(0))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a default route's hostname would be too long", func() {
+			It("returns an InvalidRouteError before creating anything", func() {
+				name := strings.Repeat("a", 60)
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            name,
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					ExposedPorts:    []uint16{8080},
+					Monitor:         docker_app_runner.MonitorConfig{Port: 8080},
+				})
+
+				Expect(err).To(MatchError(fmt.Sprintf("Invalid route: %s-8080.myDiegoInstall.com has a label longer than 63 characters: %s-8080", name, name)))
+				Expect(fakeReceptorClient.UpsertDomainCallCount()).To(Equal(0))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when overrideRoutes is not empty", func() {
 			It("uses the override Routes instead of the defaults", func() {
 				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
@@ -130,6 +227,72 @@ var _ = Describe("DockerAppRunner", func() {
 			})
 		})
 
+		Context("when route options are passed", func() {
+			It("adds the options to the routes of their port, or of every port when none is given", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "americano-app",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					AppArgs:         []string{},
+					RouteOverrides: docker_app_runner.RouteOverrides{
+						docker_app_runner.RouteOverride{HostnamePrefix: "wiggle", Port: 2000},
+						docker_app_runner.RouteOverride{HostnamePrefix: "shuffle", Port: 4000},
+					},
+					RouteOptions: docker_app_runner.RouteOptions{
+						docker_app_runner.RouteOption{Name: "session-affinity"},
+						docker_app_runner.RouteOption{Port: 4000, Name: "session-affinity", Value: "JSESSIONID"},
+					},
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(route_helpers.AppRoutesFromRoutingInfo(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"wiggle.myDiegoInstall.com"}, Port: 2000, Options: map[string]string{"session-affinity": ""}},
+					route_helpers.AppRoute{Hostnames: []string{"shuffle.myDiegoInstall.com"}, Port: 4000, Options: map[string]string{"session-affinity": "JSESSIONID"}},
+				}))
+			})
+		})
+
+		Context("when udp ports are exposed", func() {
+			It("exposes them alongside the tcp ports, without routes, and records their protocol", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "americano-app",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					AppArgs:         []string{},
+					ExposedPorts:    []uint16{53, 8080},
+					UDPPorts:        []uint16{53, 5353},
+					Monitor:         docker_app_runner.MonitorConfig{Method: docker_app_runner.PortMonitor, Port: 8080},
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+				req := fakeReceptorClient.CreateDesiredLRPArgsForCall(0)
+				Expect(req.Ports).To(Equal([]uint16{53, 8080, 5353}))
+				Expect(req.Annotation).To(MatchJSON(`{"udp_ports":[53,5353]}`))
+				Expect(route_helpers.AppRoutesFromRoutingInfo(req.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"americano-app-53.myDiegoInstall.com"}, Port: 53},
+					route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com", "americano-app-8080.myDiegoInstall.com"}, Port: 8080},
+				}))
+			})
+		})
+
+		Context("when labels are given", func() {
+			It("records them in the annotation", func() {
+				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+					Name:            "americano-app",
+					StartCommand:    "/app-run-statement",
+					DockerImagePath: "runtest/runner",
+					ExposedPorts:    []uint16{53, 8080},
+					UDPPorts:        []uint16{53},
+					Labels:          map[string]string{"version": "1.2.3", "tier": "web"},
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				req := fakeReceptorClient.CreateDesiredLRPArgsForCall(0)
+				Expect(req.Annotation).To(MatchJSON(`{"udp_ports":[53],"labels":{"version":"1.2.3","tier":"web"}}`))
+			})
+		})
+
 		Context("when NoRoutes is true", func() {
 			It("does not register any routes for the app", func() {
 				err := appRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
@@ -260,6 +423,7 @@ var _ = Describe("DockerAppRunner", func() {
 			})
 
 			Expect(err).To(MatchError("app-already-desired is already running"))
+			Expect(err).To(Equal(docker_app_runner.AppAlreadyExistsError{AppName: "app-already-desired"}))
 			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(1))
 		})
 
@@ -317,6 +481,107 @@ var _ = Describe("DockerAppRunner", func() {
 		})
 	})
 
+	Describe("UpdateDockerApp", func() {
+		var params docker_app_runner.CreateDockerAppParams
+
+		// existingApp makes the receptor report the app as CreateDockerApp
+		// would create it from params.
+		existingApp := func(params docker_app_runner.CreateDockerAppParams, annotation string) {
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{}, nil)
+			Expect(appRunner.CreateDockerApp(params)).To(Succeed())
+			req := fakeReceptorClient.CreateDesiredLRPArgsForCall(fakeReceptorClient.CreateDesiredLRPCallCount() - 1)
+			if annotation != "" {
+				req.Annotation = annotation
+			}
+
+			var desiredLRP receptor.DesiredLRPResponse
+			data, err := json.Marshal(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(data, &desiredLRP)).To(Succeed())
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{desiredLRP}, nil)
+		}
+
+		BeforeEach(func() {
+			params = docker_app_runner.CreateDockerAppParams{
+				Name:                 "americano-app",
+				StartCommand:         "/app-run-statement",
+				DockerImagePath:      "runtest/runner",
+				EnvironmentVariables: map[string]string{"A": "1", "B": "2", "C": "3"},
+				Monitor: docker_app_runner.MonitorConfig{
+					Method: docker_app_runner.PortMonitor,
+					Port:   8080,
+				},
+				Instances:    2,
+				MemoryMB:     128,
+				ExposedPorts: []uint16{8080},
+				RouteOverrides: docker_app_runner.RouteOverrides{
+					{HostnamePrefix: "americano", Port: 8080},
+					{HostnamePrefix: "americano-admin", Port: 9090},
+				},
+			}
+		})
+
+		It("leaves an app that already matches alone", func() {
+			existingApp(params, "")
+
+			update, err := appRunner.UpdateDockerApp(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.ChangedFields).To(BeEmpty())
+
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("updates instances and labels in place", func() {
+			existingApp(params, "")
+			params.Instances = 5
+			params.Labels = map[string]string{"tier": "web"}
+
+			update, err := appRunner.UpdateDockerApp(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update).To(Equal(docker_app_runner.AppUpdate{ChangedFields: []string{"annotation", "instances"}}))
+
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(*updateRequest.Instances).To(Equal(5))
+			Expect(*updateRequest.Annotation).To(Equal(`{"labels":{"tier":"web"}}`))
+		})
+
+		It("creates the app again when anything else changes", func() {
+			existingApp(params, "")
+			params.MemoryMB = 256
+			params.DockerImagePath = "runtest/runner:v2"
+
+			update, err := appRunner.UpdateDockerApp(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update).To(Equal(docker_app_runner.AppUpdate{ChangedFields: []string{"memory_mb", "rootfs"}, Recreated: true}))
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("americano-app"))
+			Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(1).MemoryMB).To(Equal(256))
+		})
+
+		It("keeps the app's log drains", func() {
+			existingApp(params, `{"log_drains":["syslog://logs.example.com:514"]}`)
+
+			update, err := appRunner.UpdateDockerApp(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.ChangedFields).To(BeEmpty())
+
+			params.Labels = map[string]string{"tier": "web"}
+			_, err = appRunner.UpdateDockerApp(params)
+			Expect(err).NotTo(HaveOccurred())
+			_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(*updateRequest.Annotation).To(Equal(`{"labels":{"tier":"web"},"log_drains":["syslog://logs.example.com:514"]}`))
+		})
+
+		It("returns an error if the app doesn't exist", func() {
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{}, nil)
+
+			_, err := appRunner.UpdateDockerApp(params)
+			Expect(err).To(Equal(docker_app_runner.AppNotFoundError{AppName: "americano-app"}))
+		})
+	})
+
 	Describe("SubmitLrp", func() {
 		It("Creates an app from JSON", func() {
 			desiredLRP := receptor.DesiredLRPCreateRequest{
@@ -402,10 +667,39 @@ var _ = Describe("DockerAppRunner", func() {
 			})
 		})
 
+		Context("when 'lattice-secrets' is passed as the appId", func() {
+			It("is an error because that id is reserved for the secrets store", func() {
+				desiredLRP := receptor.DesiredLRPCreateRequest{
+					ProcessGuid: "lattice-secrets",
+				}
+				lrpJson, marshalErr := json.Marshal(desiredLRP)
+				Expect(marshalErr).NotTo(HaveOccurred())
+
+				lrpName, err := appRunner.SubmitLrp(lrpJson)
+
+				Expect(err).To(MatchError(docker_app_runner.AttemptedToCreateLatticeSecretsErrorMessage))
+				Expect(lrpName).To(Equal("lattice-secrets"))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the process guid is reserved for the cluster", func() {
+			It("is an error", func() {
+				lrpJson, marshalErr := json.Marshal(receptor.DesiredLRPCreateRequest{ProcessGuid: "billing"})
+				Expect(marshalErr).NotTo(HaveOccurred())
+
+				_, err := appRunner.SubmitLrp(lrpJson)
+
+				Expect(err).To(BeAssignableToTypeOf(reserved_app_ids.ReservedAppIdError{}))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
+			})
+		})
+
 		It("returns an error for invalid JSON", func() {
 			lrpName, err := appRunner.SubmitLrp([]byte(`{"Value":"test value`))
 
 			Expect(err).To(MatchError("unexpected end of JSON input"))
+			Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
 			Expect(lrpName).To(BeEmpty())
 			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(0))
 		})
@@ -456,6 +750,15 @@ var _ = Describe("DockerAppRunner", func() {
 				Expect(err).To(MatchError(receptorError))
 				Expect(lrpName).To(Equal("nescafe-app"))
 			})
+
+			It("returns the receptor's rejection of the LRP as an invalid manifest", func() {
+				receptorError := receptor.Error{Type: receptor.InvalidLRP, Message: "invalid field: Action"}
+				fakeReceptorClient.CreateDesiredLRPReturns(receptorError)
+
+				_, err := appRunner.SubmitLrp([]byte(`{"process_guid":"nescafe-app"}`))
+
+				Expect(err).To(Equal(docker_app_runner.InvalidManifestError{Err: receptorError}))
+			})
 		})
 
 	})
@@ -483,6 +786,7 @@ var _ = Describe("DockerAppRunner", func() {
 			err := appRunner.ScaleApp("app-not-running", 15)
 
 			Expect(err).To(MatchError("app-not-running is not started."))
+			Expect(err).To(Equal(docker_app_runner.AppNotFoundError{AppName: "app-not-running"}))
 			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(1))
 		})
 
@@ -537,6 +841,45 @@ var _ = Describe("DockerAppRunner", func() {
 			Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(expectedRoutes))
 		})
 
+		It("routes full hostnames and paths as given", func() {
+			desiredLRPs := []receptor.DesiredLRPResponse{receptor.DesiredLRPResponse{ProcessGuid: "americano-app"}}
+			fakeReceptorClient.DesiredLRPsReturns(desiredLRPs, nil)
+
+			err := appRunner.UpdateAppRoutes("americano-app", docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{Hostname: "api.example.com", Path: "/v1", Port: 8080},
+				docker_app_runner.RouteOverride{HostnamePrefix: "docs", Path: "/guide", Port: 8080},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+				route_helpers.AppRoute{Hostnames: []string{"api.example.com/v1", "docs.myDiegoInstall.com/guide"}, Port: 8080},
+			}))
+		})
+
+		It("keeps the route options of ports that are still routed", func() {
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+				receptor.DesiredLRPResponse{
+					ProcessGuid: "americano-app",
+					Routes: route_helpers.AppRoutes{
+						route_helpers.AppRoute{Hostnames: []string{"old.myDiegoInstall.com"}, Port: 8080, Options: map[string]string{"session-affinity": ""}},
+					}.RoutingInfo(),
+				},
+			}, nil)
+
+			err := appRunner.UpdateAppRoutes("americano-app", docker_app_runner.RouteOverrides{
+				docker_app_runner.RouteOverride{HostnamePrefix: "new", Port: 8080},
+				docker_app_runner.RouteOverride{HostnamePrefix: "other", Port: 9090},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+				route_helpers.AppRoute{Hostnames: []string{"new.myDiegoInstall.com"}, Port: 8080, Options: map[string]string{"session-affinity": ""}},
+				route_helpers.AppRoute{Hostnames: []string{"other.myDiegoInstall.com"}, Port: 9090},
+			}))
+		})
+
 		Context("when an empty routes is passed", func() {
 			It("deregisters the routes", func() {
 				desiredLRPs := []receptor.DesiredLRPResponse{receptor.DesiredLRPResponse{ProcessGuid: "americano-app"}}
@@ -595,6 +938,348 @@ var _ = Describe("DockerAppRunner", func() {
 		})
 	})
 
+	Describe("AddRoute and RemoveRoute", func() {
+		var otherRouterInfo json.RawMessage
+
+		BeforeEach(func() {
+			otherRouterInfo = json.RawMessage(`{"some":"thing"}`)
+			routes := route_helpers.AppRoutes{
+				route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com", "latte.myDiegoInstall.com"}, Port: 8080},
+				route_helpers.AppRoute{Hostnames: []string{"americano-app-9090.myDiegoInstall.com"}, Port: 9090},
+			}.RoutingInfo()
+			routes["other-router"] = &otherRouterInfo
+
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+				receptor.DesiredLRPResponse{ProcessGuid: "other-app"},
+				receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Routes: routes},
+			}, nil)
+		})
+
+		Describe("AddRoute", func() {
+			It("adds the hostname to an already routed port", func() {
+				err := appRunner.AddRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "mocha", Port: 8080})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+				processGuid, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(processGuid).To(Equal("americano-app"))
+				Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com", "latte.myDiegoInstall.com", "mocha.myDiegoInstall.com"}, Port: 8080},
+					route_helpers.AppRoute{Hostnames: []string{"americano-app-9090.myDiegoInstall.com"}, Port: 9090},
+				}))
+				Expect(updateRequest.Routes["other-router"]).To(Equal(&otherRouterInfo))
+			})
+
+			It("routes a port that had no routes", func() {
+				err := appRunner.AddRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "admin", Port: 7070})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com", "latte.myDiegoInstall.com"}, Port: 8080},
+					route_helpers.AppRoute{Hostnames: []string{"americano-app-9090.myDiegoInstall.com"}, Port: 9090},
+					route_helpers.AppRoute{Hostnames: []string{"admin.myDiegoInstall.com"}, Port: 7070},
+				}))
+			})
+
+			It("does nothing when the route already exists", func() {
+				err := appRunner.AddRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "latte", Port: 8080})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+			})
+
+			It("returns an error if the app is not started", func() {
+				err := appRunner.AddRoute("app-not-running", docker_app_runner.RouteOverride{HostnamePrefix: "mocha", Port: 8080})
+				Expect(err).To(MatchError("app-not-running is not started."))
+			})
+
+			It("returns errors from the receptor", func() {
+				fakeReceptorClient.UpdateDesiredLRPReturns(errors.New("error - Updating an LRP"))
+
+				err := appRunner.AddRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "mocha", Port: 8080})
+				Expect(err).To(MatchError("error - Updating an LRP"))
+			})
+		})
+
+		Describe("RemoveRoute", func() {
+			It("removes only the hostname from the port", func() {
+				err := appRunner.RemoveRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "latte", Port: 8080})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+				processGuid, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(processGuid).To(Equal("americano-app"))
+				Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com"}, Port: 8080},
+					route_helpers.AppRoute{Hostnames: []string{"americano-app-9090.myDiegoInstall.com"}, Port: 9090},
+				}))
+				Expect(updateRequest.Routes["other-router"]).To(Equal(&otherRouterInfo))
+			})
+
+			It("drops the port once its last hostname is removed", func() {
+				err := appRunner.RemoveRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "americano-app-9090", Port: 9090})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(route_helpers.AppRoutesFromRoutingInfo(updateRequest.Routes)).To(ContainExactly(route_helpers.AppRoutes{
+					route_helpers.AppRoute{Hostnames: []string{"americano-app.myDiegoInstall.com", "latte.myDiegoInstall.com"}, Port: 8080},
+				}))
+			})
+
+			It("returns a not found error when the app has no such route", func() {
+				err := appRunner.RemoveRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "latte", Port: 9090})
+				Expect(err).To(MatchError("americano-app has no route latte.myDiegoInstall.com on port 9090."))
+				Expect(err.(interface {
+					NotFound() bool
+				}).NotFound()).To(BeTrue())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+			})
+
+			It("returns an error if the app is not started", func() {
+				err := appRunner.RemoveRoute("app-not-running", docker_app_runner.RouteOverride{HostnamePrefix: "latte", Port: 8080})
+				Expect(err).To(MatchError("app-not-running is not started."))
+			})
+
+			It("returns errors fetching the app", func() {
+				fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("error - Desired LRPs"))
+
+				err := appRunner.RemoveRoute("americano-app", docker_app_runner.RouteOverride{HostnamePrefix: "latte", Port: 8080})
+				Expect(err).To(MatchError("error - Desired LRPs"))
+			})
+		})
+	})
+
+	Describe("StopApp and StartApp", func() {
+		var desiredLRP receptor.DesiredLRPResponse
+
+		BeforeEach(func() {
+			desiredLRP = receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 3, Annotation: `{"udp_ports":[53]}`}
+			fakeReceptorClient.DesiredLRPsStub = func() ([]receptor.DesiredLRPResponse, error) {
+				return []receptor.DesiredLRPResponse{desiredLRP}, nil
+			}
+			fakeReceptorClient.UpdateDesiredLRPStub = func(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+				desiredLRP.Instances = *update.Instances
+				desiredLRP.Annotation = *update.Annotation
+				return nil
+			}
+		})
+
+		Describe("StopApp", func() {
+			It("scales the app to zero, recording its instances alongside the rest of the annotation", func() {
+				err := appRunner.StopApp("americano-app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+				processGuid, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(processGuid).To(Equal("americano-app"))
+				Expect(*updateRequest.Instances).To(Equal(0))
+				Expect(*updateRequest.Annotation).To(MatchJSON(`{"udp_ports":[53],"stopped_instances":3}`))
+				Expect(updateRequest.Routes).To(BeNil())
+			})
+
+			It("returns an error if the app is already stopped", func() {
+				Expect(appRunner.StopApp("americano-app")).To(Succeed())
+
+				err := appRunner.StopApp("americano-app")
+				Expect(err).To(MatchError("americano-app is already stopped."))
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			})
+
+			It("returns an error if the app has no instances", func() {
+				desiredLRP.Instances = 0
+
+				err := appRunner.StopApp("americano-app")
+				Expect(err).To(MatchError("americano-app has no instances to stop."))
+			})
+
+			It("returns an error if the annotation isn't ltc's", func() {
+				desiredLRP.Annotation = "I love this app."
+
+				err := appRunner.StopApp("americano-app")
+				Expect(err).To(MatchError("americano-app has an annotation ltc did not write, so ltc can't record its state."))
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+			})
+
+			It("returns errors if the app does not exist", func() {
+				err := appRunner.StopApp("app-not-running")
+				Expect(err).To(MatchError("app-not-running is not started."))
+			})
+
+			It("returns errors from the receptor", func() {
+				fakeReceptorClient.UpdateDesiredLRPStub = nil
+				fakeReceptorClient.UpdateDesiredLRPReturns(errors.New("oops"))
+
+				Expect(appRunner.StopApp("americano-app")).To(MatchError("oops"))
+			})
+		})
+
+		Describe("StartApp", func() {
+			It("restores the recorded instances and clears them from the annotation", func() {
+				Expect(appRunner.StopApp("americano-app")).To(Succeed())
+
+				instances, err := appRunner.StartApp("americano-app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(instances).To(Equal(3))
+
+				_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(1)
+				Expect(*updateRequest.Instances).To(Equal(3))
+				Expect(*updateRequest.Annotation).To(MatchJSON(`{"udp_ports":[53]}`))
+			})
+
+			It("leaves an empty annotation once nothing else is recorded", func() {
+				desiredLRP.Annotation = ""
+				Expect(appRunner.StopApp("americano-app")).To(Succeed())
+
+				_, err := appRunner.StartApp("americano-app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(desiredLRP.Annotation).To(BeEmpty())
+			})
+
+			It("returns an error if the app is not stopped", func() {
+				_, err := appRunner.StartApp("americano-app")
+				Expect(err).To(MatchError("americano-app is not stopped."))
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+			})
+
+			It("returns errors if the app does not exist", func() {
+				_, err := appRunner.StartApp("app-not-running")
+				Expect(err).To(MatchError("app-not-running is not started."))
+			})
+		})
+	})
+
+	Describe("RestartInstance", func() {
+		BeforeEach(func() {
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{{ProcessGuid: "americano-app", Instances: 3}}, nil)
+		})
+
+		It("kills the instance so that Diego starts it again", func() {
+			err := appRunner.RestartInstance("americano-app", 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.KillActualLRPByProcessGuidAndIndexCallCount()).To(Equal(1))
+			processGuid, index := fakeReceptorClient.KillActualLRPByProcessGuidAndIndexArgsForCall(0)
+			Expect(processGuid).To(Equal("americano-app"))
+			Expect(index).To(Equal(2))
+		})
+
+		It("returns an error if the app has no instance at the index", func() {
+			err := appRunner.RestartInstance("americano-app", 3)
+			Expect(err).To(MatchError("americano-app has no instance 3."))
+			Expect(fakeReceptorClient.KillActualLRPByProcessGuidAndIndexCallCount()).To(BeZero())
+		})
+
+		It("returns errors if the app does not exist", func() {
+			err := appRunner.RestartInstance("app-not-running", 0)
+			Expect(err).To(MatchError("app-not-running is not started."))
+		})
+
+		It("returns errors killing the instance", func() {
+			fakeReceptorClient.KillActualLRPByProcessGuidAndIndexReturns(errors.New("kill failed"))
+
+			err := appRunner.RestartInstance("americano-app", 0)
+			Expect(err).To(MatchError("kill failed"))
+		})
+	})
+
+	Describe("UpdateAppLabels", func() {
+		var desiredLRP receptor.DesiredLRPResponse
+
+		BeforeEach(func() {
+			desiredLRP = receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 3, Annotation: `{"udp_ports":[53],"labels":{"version":"1.2.3","tier":"web"}}`}
+			fakeReceptorClient.DesiredLRPsStub = func() ([]receptor.DesiredLRPResponse, error) {
+				return []receptor.DesiredLRPResponse{desiredLRP}, nil
+			}
+			fakeReceptorClient.UpdateDesiredLRPStub = func(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+				desiredLRP.Annotation = *update.Annotation
+				return nil
+			}
+		})
+
+		It("sets and removes labels, keeping the rest of the annotation", func() {
+			err := appRunner.UpdateAppLabels("americano-app", map[string]string{"version": "1.2.4", "tier": "", "team": "coffee"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			processGuid, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(processGuid).To(Equal("americano-app"))
+			Expect(updateRequest.Instances).To(BeNil())
+			Expect(*updateRequest.Annotation).To(MatchJSON(`{"udp_ports":[53],"labels":{"version":"1.2.4","team":"coffee"}}`))
+		})
+
+		It("drops the labels from the annotation once none are left", func() {
+			err := appRunner.UpdateAppLabels("americano-app", map[string]string{"version": "", "tier": ""})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(desiredLRP.Annotation).To(MatchJSON(`{"udp_ports":[53]}`))
+		})
+
+		It("returns an error if the annotation isn't ltc's", func() {
+			desiredLRP.Annotation = "I love this app."
+
+			err := appRunner.UpdateAppLabels("americano-app", map[string]string{"version": "1.2.4"})
+			Expect(err).To(MatchError("americano-app has an annotation ltc did not write, so ltc can't record its state."))
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("returns errors if the app does not exist", func() {
+			err := appRunner.UpdateAppLabels("app-not-running", map[string]string{"version": "1.2.4"})
+			Expect(err).To(MatchError("app-not-running is not started."))
+		})
+	})
+
+	Describe("BindLogDrain and UnbindLogDrain", func() {
+		var desiredLRP receptor.DesiredLRPResponse
+
+		BeforeEach(func() {
+			desiredLRP = receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 3, Annotation: `{"labels":{"tier":"web"}}`}
+			fakeReceptorClient.DesiredLRPsStub = func() ([]receptor.DesiredLRPResponse, error) {
+				return []receptor.DesiredLRPResponse{desiredLRP}, nil
+			}
+			fakeReceptorClient.UpdateDesiredLRPStub = func(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+				desiredLRP.Annotation = *update.Annotation
+				return nil
+			}
+		})
+
+		It("adds and removes drains, keeping the rest of the annotation", func() {
+			Expect(appRunner.BindLogDrain("americano-app", "syslog://logs.example.com:514")).To(Succeed())
+			Expect(appRunner.BindLogDrain("americano-app", "https://logs.example.com/drain")).To(Succeed())
+			Expect(desiredLRP.Annotation).To(MatchJSON(`{"labels":{"tier":"web"},"log_drains":["syslog://logs.example.com:514","https://logs.example.com/drain"]}`))
+
+			Expect(appRunner.UnbindLogDrain("americano-app", "syslog://logs.example.com:514")).To(Succeed())
+			Expect(desiredLRP.Annotation).To(MatchJSON(`{"labels":{"tier":"web"},"log_drains":["https://logs.example.com/drain"]}`))
+
+			Expect(appRunner.UnbindLogDrain("americano-app", "https://logs.example.com/drain")).To(Succeed())
+			Expect(desiredLRP.Annotation).To(MatchJSON(`{"labels":{"tier":"web"}}`))
+
+			_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(updateRequest.Instances).To(BeNil())
+		})
+
+		It("returns an error binding a drain twice", func() {
+			Expect(appRunner.BindLogDrain("americano-app", "syslog://logs.example.com:514")).To(Succeed())
+
+			err := appRunner.BindLogDrain("americano-app", "syslog://logs.example.com:514")
+			Expect(err).To(MatchError("americano-app already drains its logs to syslog://logs.example.com:514."))
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+		})
+
+		It("returns a not found error unbinding a drain that isn't bound", func() {
+			err := appRunner.UnbindLogDrain("americano-app", "syslog://logs.example.com:514")
+			Expect(err).To(MatchError("americano-app has no log drain syslog://logs.example.com:514."))
+			Expect(exit_codes.ForError(err, exit_codes.CommandFailed)).To(Equal(exit_codes.NotFound))
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("returns errors if the app does not exist", func() {
+			err := appRunner.BindLogDrain("app-not-running", "syslog://logs.example.com:514")
+			Expect(err).To(MatchError("app-not-running is not started."))
+		})
+	})
+
 	Describe("RemoveApp", func() {
 		It("Removes a Docker App", func() {
 			desiredLRPs := []receptor.DesiredLRPResponse{receptor.DesiredLRPResponse{ProcessGuid: "americano-app", Instances: 1}}
@@ -640,4 +1325,226 @@ var _ = Describe("DockerAppRunner", func() {
 			})
 		})
 	})
+
+	Describe("AppDefinition and RestoreApp", func() {
+		var (
+			desiredLRP receptor.DesiredLRPResponse
+			definition receptor.DesiredLRPCreateRequest
+		)
+
+		BeforeEach(func() {
+			routes := route_helpers.AppRoutes{{Hostnames: []string{"americano-app.myDiegoInstall.com"}, Port: 8080}}.RoutingInfo()
+			desiredLRP = receptor.DesiredLRPResponse{
+				ProcessGuid:          "americano-app",
+				Domain:               "lattice",
+				RootFS:               "docker:///americano#v1",
+				Instances:            2,
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "COLOR", Value: "blue"}},
+				Action:               &models.RunAction{Path: "/start"},
+				MemoryMB:             128,
+				Ports:                []uint16{8080},
+				Routes:               routes,
+				LogGuid:              "americano-app",
+				Annotation:           `{"udp_ports":[53]}`,
+				ModificationTag:      receptor.ModificationTag{Epoch: "abc", Index: 3},
+			}
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{desiredLRP}, nil)
+
+			definition = receptor.DesiredLRPCreateRequest{
+				ProcessGuid:          "americano-app",
+				Domain:               "lattice",
+				RootFS:               "docker:///americano#v1",
+				Instances:            2,
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "COLOR", Value: "blue"}},
+				Action:               &models.RunAction{Path: "/start"},
+				MemoryMB:             128,
+				Ports:                []uint16{8080},
+				Routes:               routes,
+				LogGuid:              "americano-app",
+				Annotation:           `{"udp_ports":[53]}`,
+			}
+		})
+
+		Describe("AppDefinition", func() {
+			It("returns the request that would create the app as it is", func() {
+				appDefinition, err := appRunner.AppDefinition("americano-app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(appDefinition).To(Equal(definition))
+			})
+
+			It("returns errors if the app does not exist", func() {
+				_, err := appRunner.AppDefinition("app-not-running")
+				Expect(err).To(MatchError("app-not-running is not started."))
+			})
+		})
+
+		Describe("RestoreApp", func() {
+			It("updates instances, routes and the annotation in place", func() {
+				definition.Instances = 5
+				definition.Routes = route_helpers.AppRoutes{{Hostnames: []string{"espresso.myDiegoInstall.com"}, Port: 8080}}.RoutingInfo()
+				definition.Annotation = ""
+
+				created, err := appRunner.RestoreApp(definition)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeFalse())
+
+				Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+				processGuid, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(processGuid).To(Equal("americano-app"))
+				Expect(*updateRequest.Instances).To(Equal(5))
+				Expect(updateRequest.Routes).To(Equal(definition.Routes))
+				Expect(*updateRequest.Annotation).To(BeEmpty())
+			})
+
+			It("recreates the app when anything else differs", func() {
+				definition.RootFS = "docker:///americano#v0"
+				definition.EnvironmentVariables = []receptor.EnvironmentVariable{{Name: "COLOR", Value: "red"}}
+
+				created, err := appRunner.RestoreApp(definition)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeTrue())
+
+				Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+				Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+				Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("americano-app"))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+				Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0)).To(Equal(definition))
+			})
+
+			It("keeps when the app was deployed when it recreates it", func() {
+				desiredLRP.Annotation = `{"udp_ports":[53],"deployment":{"created_at":"2015-07-01T12:00:00Z","created_by":"alice"}}`
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{desiredLRP}, nil)
+				definition.MemoryMB = 256
+
+				_, err := appRunner.RestoreApp(definition)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Annotation).To(MatchJSON(`{"udp_ports":[53],"deployment":{"created_at":"2015-07-01T12:00:00Z","created_by":"alice"}}`))
+			})
+
+			It("keeps when the app was deployed when it updates it in place", func() {
+				desiredLRP.Annotation = `{"deployment":{"created_at":"2015-07-01T12:00:00Z","created_by":"alice"}}`
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{desiredLRP}, nil)
+				definition.Annotation = `{"labels":{"tier":"web"}}`
+
+				_, err := appRunner.RestoreApp(definition)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, updateRequest := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+				Expect(*updateRequest.Annotation).To(MatchJSON(`{"labels":{"tier":"web"},"deployment":{"created_at":"2015-07-01T12:00:00Z","created_by":"alice"}}`))
+			})
+
+			It("creates the app if it no longer exists", func() {
+				fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{}, nil)
+
+				created, err := appRunner.RestoreApp(definition)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeTrue())
+
+				Expect(fakeReceptorClient.UpsertDomainCallCount()).To(Equal(1))
+				Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+				Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0)).To(Equal(definition))
+			})
+
+			It("does not create the app again if deleting it fails", func() {
+				definition.MemoryMB = 256
+				fakeReceptorClient.DeleteDesiredLRPReturns(errors.New("oops"))
+
+				_, err := appRunner.RestoreApp(definition)
+				Expect(err).To(MatchError("oops"))
+				Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(BeZero())
+			})
+
+			It("returns errors fetching the app", func() {
+				fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("receptor down"))
+
+				_, err := appRunner.RestoreApp(definition)
+				Expect(err).To(MatchError("receptor down"))
+			})
+		})
+
+		Describe("LabelDefinition", func() {
+			It("adds the labels to those in the annotation", func() {
+				definition.Annotation = `{"labels":{"team":"payments"},"udp_ports":[53]}`
+
+				labeled, err := docker_app_runner.LabelDefinition(definition, map[string]string{"managed-by": "ltc-agent"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(labeled.Annotation).To(MatchJSON(`{"labels":{"managed-by":"ltc-agent","team":"payments"},"udp_ports":[53]}`))
+				Expect(labeled.ProcessGuid).To(Equal(definition.ProcessGuid))
+			})
+
+			It("labels a definition without an annotation", func() {
+				definition.Annotation = ""
+
+				labeled, err := docker_app_runner.LabelDefinition(definition, map[string]string{"managed-by": "ltc-agent"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(labeled.Annotation).To(Equal(`{"labels":{"managed-by":"ltc-agent"}}`))
+			})
+
+			It("returns an InvalidManifestError when the annotation isn't a JSON object", func() {
+				definition.Annotation = "build 42"
+
+				_, err := docker_app_runner.LabelDefinition(definition, map[string]string{"managed-by": "ltc-agent"})
+				Expect(err).To(BeAssignableToTypeOf(docker_app_runner.InvalidManifestError{}))
+				Expect(err.Error()).To(HavePrefix("The annotation must be a JSON object to add labels: "))
+			})
+		})
+
+		Describe("SameDefinition", func() {
+			It("compares definitions by what they would create", func() {
+				copied := definition
+				copied.Action = &models.RunAction{Path: "/start"}
+				Expect(docker_app_runner.SameDefinition(definition, copied)).To(BeTrue())
+
+				copied.Action = &models.RunAction{Path: "/start", Args: []string{"--debug"}}
+				Expect(docker_app_runner.SameDefinition(definition, copied)).To(BeFalse())
+			})
+		})
+
+		Describe("NeedsRecreate", func() {
+			It("leaves the instances, routes and annotation to an update", func() {
+				copied := definition
+				copied.Instances = 7
+				copied.Routes = receptor.RoutingInfo{}
+				copied.Annotation = `{"labels":{"tier":"web"}}`
+				Expect(docker_app_runner.NeedsRecreate(definition, copied)).To(BeFalse())
+
+				copied.MemoryMB = 1024
+				Expect(docker_app_runner.NeedsRecreate(definition, copied)).To(BeTrue())
+			})
+		})
+
+		Describe("ChangedFields", func() {
+			It("lists the fields that differ by their JSON names", func() {
+				copied := definition
+				copied.MemoryMB = 1024
+				copied.Instances = 7
+				Expect(docker_app_runner.ChangedFields(definition, copied)).To(Equal([]string{"instances", "memory_mb"}))
+			})
+
+			It("ignores the order of environment variables", func() {
+				definition.EnvironmentVariables = []receptor.EnvironmentVariable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}
+				copied := definition
+				copied.EnvironmentVariables = []receptor.EnvironmentVariable{{Name: "B", Value: "2"}, {Name: "A", Value: "1"}}
+				Expect(docker_app_runner.ChangedFields(definition, copied)).To(BeEmpty())
+			})
+
+			It("treats empty values as missing", func() {
+				definition.Ports = nil
+				copied := definition
+				copied.Ports = []uint16{}
+				Expect(docker_app_runner.ChangedFields(definition, copied)).To(BeEmpty())
+			})
+
+			It("ignores when the app was deployed", func() {
+				copied := definition
+				copied.Annotation = `{"deployment":{"created_at":"2015-07-01T12:00:00Z","created_by":"alice"},"udp_ports":[53]}`
+				Expect(docker_app_runner.ChangedFields(definition, copied)).To(BeEmpty())
+
+				copied.Annotation = `{"deployment":{"created_at":"2015-07-01T12:00:00Z"},"udp_ports":[54]}`
+				Expect(docker_app_runner.ChangedFields(definition, copied)).To(Equal([]string{"annotation"}))
+			})
+		})
+	})
 })
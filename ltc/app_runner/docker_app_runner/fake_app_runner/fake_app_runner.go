@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/receptor"
 )
 
 type FakeAppRunner struct {
@@ -16,6 +17,15 @@ type FakeAppRunner struct {
 	createDockerAppReturns struct {
 		result1 error
 	}
+	UpdateDockerAppStub        func(params docker_app_runner.CreateDockerAppParams) (docker_app_runner.AppUpdate, error)
+	updateDockerAppMutex       sync.RWMutex
+	updateDockerAppArgsForCall []struct {
+		params docker_app_runner.CreateDockerAppParams
+	}
+	updateDockerAppReturns struct {
+		result1 docker_app_runner.AppUpdate
+		result2 error
+	}
 	SubmitLrpStub        func(submitLrpJson []byte) (string, error)
 	submitLrpMutex       sync.RWMutex
 	submitLrpArgsForCall []struct {
@@ -43,6 +53,41 @@ type FakeAppRunner struct {
 	updateAppRoutesReturns struct {
 		result1 error
 	}
+	AddRouteStub        func(name string, route docker_app_runner.RouteOverride) error
+	addRouteMutex       sync.RWMutex
+	addRouteArgsForCall []struct {
+		name  string
+		route docker_app_runner.RouteOverride
+	}
+	addRouteReturns struct {
+		result1 error
+	}
+	RemoveRouteStub        func(name string, route docker_app_runner.RouteOverride) error
+	removeRouteMutex       sync.RWMutex
+	removeRouteArgsForCall []struct {
+		name  string
+		route docker_app_runner.RouteOverride
+	}
+	removeRouteReturns struct {
+		result1 error
+	}
+	StopAppStub        func(name string) error
+	stopAppMutex       sync.RWMutex
+	stopAppArgsForCall []struct {
+		name string
+	}
+	stopAppReturns struct {
+		result1 error
+	}
+	StartAppStub        func(name string) (int, error)
+	startAppMutex       sync.RWMutex
+	startAppArgsForCall []struct {
+		name string
+	}
+	startAppReturns struct {
+		result1 int
+		result2 error
+	}
 	RemoveAppStub        func(name string) error
 	removeAppMutex       sync.RWMutex
 	removeAppArgsForCall []struct {
@@ -51,6 +96,60 @@ type FakeAppRunner struct {
 	removeAppReturns struct {
 		result1 error
 	}
+	RestartInstanceStub        func(name string, index int) error
+	restartInstanceMutex       sync.RWMutex
+	restartInstanceArgsForCall []struct {
+		name  string
+		index int
+	}
+	restartInstanceReturns struct {
+		result1 error
+	}
+	UpdateAppLabelsStub        func(name string, labels map[string]string) error
+	updateAppLabelsMutex       sync.RWMutex
+	updateAppLabelsArgsForCall []struct {
+		name   string
+		labels map[string]string
+	}
+	updateAppLabelsReturns struct {
+		result1 error
+	}
+	BindLogDrainStub        func(name, drainURL string) error
+	bindLogDrainMutex       sync.RWMutex
+	bindLogDrainArgsForCall []struct {
+		name     string
+		drainURL string
+	}
+	bindLogDrainReturns struct {
+		result1 error
+	}
+	UnbindLogDrainStub        func(name, drainURL string) error
+	unbindLogDrainMutex       sync.RWMutex
+	unbindLogDrainArgsForCall []struct {
+		name     string
+		drainURL string
+	}
+	unbindLogDrainReturns struct {
+		result1 error
+	}
+	AppDefinitionStub        func(name string) (receptor.DesiredLRPCreateRequest, error)
+	appDefinitionMutex       sync.RWMutex
+	appDefinitionArgsForCall []struct {
+		name string
+	}
+	appDefinitionReturns struct {
+		result1 receptor.DesiredLRPCreateRequest
+		result2 error
+	}
+	RestoreAppStub        func(definition receptor.DesiredLRPCreateRequest) (bool, error)
+	restoreAppMutex       sync.RWMutex
+	restoreAppArgsForCall []struct {
+		definition receptor.DesiredLRPCreateRequest
+	}
+	restoreAppReturns struct {
+		result1 bool
+		result2 error
+	}
 }
 
 func (fake *FakeAppRunner) CreateDockerApp(params docker_app_runner.CreateDockerAppParams) error {
@@ -85,6 +184,39 @@ func (fake *FakeAppRunner) CreateDockerAppReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) UpdateDockerApp(params docker_app_runner.CreateDockerAppParams) (docker_app_runner.AppUpdate, error) {
+	fake.updateDockerAppMutex.Lock()
+	fake.updateDockerAppArgsForCall = append(fake.updateDockerAppArgsForCall, struct {
+		params docker_app_runner.CreateDockerAppParams
+	}{params})
+	fake.updateDockerAppMutex.Unlock()
+	if fake.UpdateDockerAppStub != nil {
+		return fake.UpdateDockerAppStub(params)
+	} else {
+		return fake.updateDockerAppReturns.result1, fake.updateDockerAppReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) UpdateDockerAppCallCount() int {
+	fake.updateDockerAppMutex.RLock()
+	defer fake.updateDockerAppMutex.RUnlock()
+	return len(fake.updateDockerAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) UpdateDockerAppArgsForCall(i int) docker_app_runner.CreateDockerAppParams {
+	fake.updateDockerAppMutex.RLock()
+	defer fake.updateDockerAppMutex.RUnlock()
+	return fake.updateDockerAppArgsForCall[i].params
+}
+
+func (fake *FakeAppRunner) UpdateDockerAppReturns(result1 docker_app_runner.AppUpdate, result2 error) {
+	fake.UpdateDockerAppStub = nil
+	fake.updateDockerAppReturns = struct {
+		result1 docker_app_runner.AppUpdate
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAppRunner) SubmitLrp(submitLrpJson []byte) (string, error) {
 	fake.submitLrpMutex.Lock()
 	fake.submitLrpArgsForCall = append(fake.submitLrpArgsForCall, struct {
@@ -184,6 +316,137 @@ func (fake *FakeAppRunner) UpdateAppRoutesReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) AddRoute(name string, route docker_app_runner.RouteOverride) error {
+	fake.addRouteMutex.Lock()
+	fake.addRouteArgsForCall = append(fake.addRouteArgsForCall, struct {
+		name  string
+		route docker_app_runner.RouteOverride
+	}{name, route})
+	fake.addRouteMutex.Unlock()
+	if fake.AddRouteStub != nil {
+		return fake.AddRouteStub(name, route)
+	} else {
+		return fake.addRouteReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) AddRouteCallCount() int {
+	fake.addRouteMutex.RLock()
+	defer fake.addRouteMutex.RUnlock()
+	return len(fake.addRouteArgsForCall)
+}
+
+func (fake *FakeAppRunner) AddRouteArgsForCall(i int) (string, docker_app_runner.RouteOverride) {
+	fake.addRouteMutex.RLock()
+	defer fake.addRouteMutex.RUnlock()
+	return fake.addRouteArgsForCall[i].name, fake.addRouteArgsForCall[i].route
+}
+
+func (fake *FakeAppRunner) AddRouteReturns(result1 error) {
+	fake.AddRouteStub = nil
+	fake.addRouteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) RemoveRoute(name string, route docker_app_runner.RouteOverride) error {
+	fake.removeRouteMutex.Lock()
+	fake.removeRouteArgsForCall = append(fake.removeRouteArgsForCall, struct {
+		name  string
+		route docker_app_runner.RouteOverride
+	}{name, route})
+	fake.removeRouteMutex.Unlock()
+	if fake.RemoveRouteStub != nil {
+		return fake.RemoveRouteStub(name, route)
+	} else {
+		return fake.removeRouteReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) RemoveRouteCallCount() int {
+	fake.removeRouteMutex.RLock()
+	defer fake.removeRouteMutex.RUnlock()
+	return len(fake.removeRouteArgsForCall)
+}
+
+func (fake *FakeAppRunner) RemoveRouteArgsForCall(i int) (string, docker_app_runner.RouteOverride) {
+	fake.removeRouteMutex.RLock()
+	defer fake.removeRouteMutex.RUnlock()
+	return fake.removeRouteArgsForCall[i].name, fake.removeRouteArgsForCall[i].route
+}
+
+func (fake *FakeAppRunner) RemoveRouteReturns(result1 error) {
+	fake.RemoveRouteStub = nil
+	fake.removeRouteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) StopApp(name string) error {
+	fake.stopAppMutex.Lock()
+	fake.stopAppArgsForCall = append(fake.stopAppArgsForCall, struct {
+		name string
+	}{name})
+	fake.stopAppMutex.Unlock()
+	if fake.StopAppStub != nil {
+		return fake.StopAppStub(name)
+	} else {
+		return fake.stopAppReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) StopAppCallCount() int {
+	fake.stopAppMutex.RLock()
+	defer fake.stopAppMutex.RUnlock()
+	return len(fake.stopAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) StopAppArgsForCall(i int) string {
+	fake.stopAppMutex.RLock()
+	defer fake.stopAppMutex.RUnlock()
+	return fake.stopAppArgsForCall[i].name
+}
+
+func (fake *FakeAppRunner) StopAppReturns(result1 error) {
+	fake.StopAppStub = nil
+	fake.stopAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) StartApp(name string) (int, error) {
+	fake.startAppMutex.Lock()
+	fake.startAppArgsForCall = append(fake.startAppArgsForCall, struct {
+		name string
+	}{name})
+	fake.startAppMutex.Unlock()
+	if fake.StartAppStub != nil {
+		return fake.StartAppStub(name)
+	} else {
+		return fake.startAppReturns.result1, fake.startAppReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) StartAppCallCount() int {
+	fake.startAppMutex.RLock()
+	defer fake.startAppMutex.RUnlock()
+	return len(fake.startAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) StartAppArgsForCall(i int) string {
+	fake.startAppMutex.RLock()
+	defer fake.startAppMutex.RUnlock()
+	return fake.startAppArgsForCall[i].name
+}
+
+func (fake *FakeAppRunner) StartAppReturns(result1 int, result2 error) {
+	fake.StartAppStub = nil
+	fake.startAppReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAppRunner) RemoveApp(name string) error {
 	fake.removeAppMutex.Lock()
 	fake.removeAppArgsForCall = append(fake.removeAppArgsForCall, struct {
@@ -216,4 +479,202 @@ func (fake *FakeAppRunner) RemoveAppReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) RestartInstance(name string, index int) error {
+	fake.restartInstanceMutex.Lock()
+	fake.restartInstanceArgsForCall = append(fake.restartInstanceArgsForCall, struct {
+		name  string
+		index int
+	}{name, index})
+	fake.restartInstanceMutex.Unlock()
+	if fake.RestartInstanceStub != nil {
+		return fake.RestartInstanceStub(name, index)
+	} else {
+		return fake.restartInstanceReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) RestartInstanceCallCount() int {
+	fake.restartInstanceMutex.RLock()
+	defer fake.restartInstanceMutex.RUnlock()
+	return len(fake.restartInstanceArgsForCall)
+}
+
+func (fake *FakeAppRunner) RestartInstanceArgsForCall(i int) (string, int) {
+	fake.restartInstanceMutex.RLock()
+	defer fake.restartInstanceMutex.RUnlock()
+	return fake.restartInstanceArgsForCall[i].name, fake.restartInstanceArgsForCall[i].index
+}
+
+func (fake *FakeAppRunner) RestartInstanceReturns(result1 error) {
+	fake.RestartInstanceStub = nil
+	fake.restartInstanceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) UpdateAppLabels(name string, labels map[string]string) error {
+	fake.updateAppLabelsMutex.Lock()
+	fake.updateAppLabelsArgsForCall = append(fake.updateAppLabelsArgsForCall, struct {
+		name   string
+		labels map[string]string
+	}{name, labels})
+	fake.updateAppLabelsMutex.Unlock()
+	if fake.UpdateAppLabelsStub != nil {
+		return fake.UpdateAppLabelsStub(name, labels)
+	} else {
+		return fake.updateAppLabelsReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) UpdateAppLabelsCallCount() int {
+	fake.updateAppLabelsMutex.RLock()
+	defer fake.updateAppLabelsMutex.RUnlock()
+	return len(fake.updateAppLabelsArgsForCall)
+}
+
+func (fake *FakeAppRunner) UpdateAppLabelsArgsForCall(i int) (string, map[string]string) {
+	fake.updateAppLabelsMutex.RLock()
+	defer fake.updateAppLabelsMutex.RUnlock()
+	return fake.updateAppLabelsArgsForCall[i].name, fake.updateAppLabelsArgsForCall[i].labels
+}
+
+func (fake *FakeAppRunner) UpdateAppLabelsReturns(result1 error) {
+	fake.UpdateAppLabelsStub = nil
+	fake.updateAppLabelsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) BindLogDrain(name string, drainURL string) error {
+	fake.bindLogDrainMutex.Lock()
+	fake.bindLogDrainArgsForCall = append(fake.bindLogDrainArgsForCall, struct {
+		name     string
+		drainURL string
+	}{name, drainURL})
+	fake.bindLogDrainMutex.Unlock()
+	if fake.BindLogDrainStub != nil {
+		return fake.BindLogDrainStub(name, drainURL)
+	} else {
+		return fake.bindLogDrainReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) BindLogDrainCallCount() int {
+	fake.bindLogDrainMutex.RLock()
+	defer fake.bindLogDrainMutex.RUnlock()
+	return len(fake.bindLogDrainArgsForCall)
+}
+
+func (fake *FakeAppRunner) BindLogDrainArgsForCall(i int) (string, string) {
+	fake.bindLogDrainMutex.RLock()
+	defer fake.bindLogDrainMutex.RUnlock()
+	return fake.bindLogDrainArgsForCall[i].name, fake.bindLogDrainArgsForCall[i].drainURL
+}
+
+func (fake *FakeAppRunner) BindLogDrainReturns(result1 error) {
+	fake.BindLogDrainStub = nil
+	fake.bindLogDrainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) UnbindLogDrain(name string, drainURL string) error {
+	fake.unbindLogDrainMutex.Lock()
+	fake.unbindLogDrainArgsForCall = append(fake.unbindLogDrainArgsForCall, struct {
+		name     string
+		drainURL string
+	}{name, drainURL})
+	fake.unbindLogDrainMutex.Unlock()
+	if fake.UnbindLogDrainStub != nil {
+		return fake.UnbindLogDrainStub(name, drainURL)
+	} else {
+		return fake.unbindLogDrainReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) UnbindLogDrainCallCount() int {
+	fake.unbindLogDrainMutex.RLock()
+	defer fake.unbindLogDrainMutex.RUnlock()
+	return len(fake.unbindLogDrainArgsForCall)
+}
+
+func (fake *FakeAppRunner) UnbindLogDrainArgsForCall(i int) (string, string) {
+	fake.unbindLogDrainMutex.RLock()
+	defer fake.unbindLogDrainMutex.RUnlock()
+	return fake.unbindLogDrainArgsForCall[i].name, fake.unbindLogDrainArgsForCall[i].drainURL
+}
+
+func (fake *FakeAppRunner) UnbindLogDrainReturns(result1 error) {
+	fake.UnbindLogDrainStub = nil
+	fake.unbindLogDrainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAppRunner) AppDefinition(name string) (receptor.DesiredLRPCreateRequest, error) {
+	fake.appDefinitionMutex.Lock()
+	fake.appDefinitionArgsForCall = append(fake.appDefinitionArgsForCall, struct {
+		name string
+	}{name})
+	fake.appDefinitionMutex.Unlock()
+	if fake.AppDefinitionStub != nil {
+		return fake.AppDefinitionStub(name)
+	} else {
+		return fake.appDefinitionReturns.result1, fake.appDefinitionReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) AppDefinitionCallCount() int {
+	fake.appDefinitionMutex.RLock()
+	defer fake.appDefinitionMutex.RUnlock()
+	return len(fake.appDefinitionArgsForCall)
+}
+
+func (fake *FakeAppRunner) AppDefinitionArgsForCall(i int) string {
+	fake.appDefinitionMutex.RLock()
+	defer fake.appDefinitionMutex.RUnlock()
+	return fake.appDefinitionArgsForCall[i].name
+}
+
+func (fake *FakeAppRunner) AppDefinitionReturns(result1 receptor.DesiredLRPCreateRequest, result2 error) {
+	fake.AppDefinitionStub = nil
+	fake.appDefinitionReturns = struct {
+		result1 receptor.DesiredLRPCreateRequest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAppRunner) RestoreApp(definition receptor.DesiredLRPCreateRequest) (bool, error) {
+	fake.restoreAppMutex.Lock()
+	fake.restoreAppArgsForCall = append(fake.restoreAppArgsForCall, struct {
+		definition receptor.DesiredLRPCreateRequest
+	}{definition})
+	fake.restoreAppMutex.Unlock()
+	if fake.RestoreAppStub != nil {
+		return fake.RestoreAppStub(definition)
+	} else {
+		return fake.restoreAppReturns.result1, fake.restoreAppReturns.result2
+	}
+}
+
+func (fake *FakeAppRunner) RestoreAppCallCount() int {
+	fake.restoreAppMutex.RLock()
+	defer fake.restoreAppMutex.RUnlock()
+	return len(fake.restoreAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) RestoreAppArgsForCall(i int) receptor.DesiredLRPCreateRequest {
+	fake.restoreAppMutex.RLock()
+	defer fake.restoreAppMutex.RUnlock()
+	return fake.restoreAppArgsForCall[i].definition
+}
+
+func (fake *FakeAppRunner) RestoreAppReturns(result1 bool, result2 error) {
+	fake.RestoreAppStub = nil
+	fake.restoreAppReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 var _ docker_app_runner.AppRunner = new(FakeAppRunner)
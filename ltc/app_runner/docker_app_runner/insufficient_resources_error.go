@@ -0,0 +1,21 @@
+package docker_app_runner
+
+import "fmt"
+
+// InsufficientResourcesError is reported when lattice has no cell with room
+// for some of an app's instances.
+type InsufficientResourcesError struct {
+	AppName string
+}
+
+func (err InsufficientResourcesError) Error() string {
+	return fmt.Sprintf("could not place all instances of %s: insufficient resources.", err.AppName)
+}
+
+func (err InsufficientResourcesError) PlacementError() bool {
+	return true
+}
+
+func (err InsufficientResourcesError) Remedy() string {
+	return "Try requesting fewer instances or reducing the requested memory or disk capacity."
+}
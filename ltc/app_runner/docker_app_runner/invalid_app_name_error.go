@@ -0,0 +1,22 @@
+package docker_app_runner
+
+import "fmt"
+
+// InvalidAppNameError is returned for app names that can't be used in the
+// app's hostnames, saying what is wrong with the name.
+type InvalidAppNameError struct {
+	AppName string
+	Reason  string
+}
+
+func (err InvalidAppNameError) Error() string {
+	return fmt.Sprintf("%s is not a valid app name: %s.", err.AppName, err.Reason)
+}
+
+func (err InvalidAppNameError) InvalidName() bool {
+	return true
+}
+
+func (err InvalidAppNameError) Remedy() string {
+	return "App names are used in the app's hostnames, so may only contain lowercase letters, digits and hyphens, must start and end with a letter or digit, and can be at most 63 characters long."
+}
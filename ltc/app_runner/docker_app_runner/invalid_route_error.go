@@ -0,0 +1,21 @@
+package docker_app_runner
+
+import "fmt"
+
+// InvalidRouteError is returned when the hostname of one of an app's routes,
+// whether given or made from the app's name, can't be routed to.
+type InvalidRouteError struct {
+	Err error
+}
+
+func (err InvalidRouteError) Error() string {
+	return fmt.Sprintf("Invalid route: %s", err.Err)
+}
+
+func (err InvalidRouteError) InvalidName() bool {
+	return true
+}
+
+func (err InvalidRouteError) Remedy() string {
+	return "Apps are routed at APP_NAME.DOMAIN and APP_NAME-PORT.DOMAIN unless given --routes: choose a shorter app name, or pass --routes."
+}
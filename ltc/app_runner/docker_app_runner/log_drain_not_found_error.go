@@ -0,0 +1,20 @@
+package docker_app_runner
+
+import "fmt"
+
+type logDrainNotFoundError struct {
+	appName  string
+	drainURL string
+}
+
+func newLogDrainNotFoundError(appName, drainURL string) logDrainNotFoundError {
+	return logDrainNotFoundError{appName, drainURL}
+}
+
+func (err logDrainNotFoundError) Error() string {
+	return fmt.Sprintf("%s has no log drain %s.", err.appName, err.drainURL)
+}
+
+func (err logDrainNotFoundError) NotFound() bool {
+	return true
+}
@@ -0,0 +1,21 @@
+package docker_app_runner
+
+import "fmt"
+
+type routeNotFoundError struct {
+	appName  string
+	hostname string
+	port     uint16
+}
+
+func newRouteNotFoundError(appName, hostname string, port uint16) routeNotFoundError {
+	return routeNotFoundError{appName, hostname, port}
+}
+
+func (err routeNotFoundError) Error() string {
+	return fmt.Sprintf("%s has no route %s on port %d.", err.appName, err.hostname, err.port)
+}
+
+func (err routeNotFoundError) NotFound() bool {
+	return true
+}
@@ -2,6 +2,7 @@ package docker_metadata_fetcher
 
 import (
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -10,10 +11,20 @@ import (
 	"github.com/docker/docker/nat"
 )
 
+// ImageMetadata describes how an image runs by default.  ExposedPorts are
+// the tcp ports the image exposes and UDPPorts the udp ones.  StartCommand
+// is the image's Entrypoint followed by its Cmd, and Env holds the image's
+// environment as NAME=VALUE pairs.  User is the image's USER, which lattice
+// reports but does not run as.
 type ImageMetadata struct {
-	WorkingDir   string
-	ExposedPorts []uint16
-	StartCommand []string
+	WorkingDir   string   `json:"working_dir"`
+	User         string   `json:"user"`
+	ExposedPorts []uint16 `json:"exposed_ports"`
+	UDPPorts     []uint16 `json:"udp_ports"`
+	Entrypoint   []string `json:"entrypoint"`
+	Cmd          []string `json:"cmd"`
+	StartCommand []string `json:"start_command"`
+	Env          []string `json:"env"`
 }
 
 //go:generate counterfeiter -o fake_docker_metadata_fetcher/fake_docker_metadata_fetcher.go . DockerMetadataFetcher
@@ -23,14 +34,18 @@ type DockerMetadataFetcher interface {
 
 type dockerMetadataFetcher struct {
 	dockerSessionFactory DockerSessionFactory
+	dockerRegistryV2     DockerRegistryV2
 }
 
-func New(sessionFactory DockerSessionFactory) DockerMetadataFetcher {
+func New(sessionFactory DockerSessionFactory, registryV2 DockerRegistryV2) DockerMetadataFetcher {
 	return &dockerMetadataFetcher{
 		dockerSessionFactory: sessionFactory,
+		dockerRegistryV2:     registryV2,
 	}
 }
 
+// FetchMetadata reads the image config through the registry v2 API, falling
+// back to the legacy registry API for registries that don't support v2.
 func (fetcher *dockerMetadataFetcher) FetchMetadata(dockerImageReference string) (*ImageMetadata, error) {
 
 	indexName, remoteName, tag, err := docker_repository_name_formatter.ParseRepoNameAndTagFromImageReference(dockerImageReference)
@@ -38,6 +53,64 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(dockerImageReference string)
 		return nil, err
 	}
 
+	imgJSON, err := fetcher.fetchImageJSONV2(indexName, remoteName, tag)
+	if err == ErrRegistryV2Unsupported {
+		imgJSON, err = fetcher.fetchImageJSONV1(indexName, remoteName, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := image.NewImgJSON(imgJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing remote image json for specified docker image:\n%s", err.Error())
+	}
+
+	if img.Config == nil {
+		return nil, fmt.Errorf("Parsing start command failed")
+	}
+
+	var startCommand []string
+	startCommand = append(startCommand, img.Config.Entrypoint...)
+	startCommand = append(startCommand, img.Config.Cmd...)
+
+	exposedPorts := make(map[nat.Port]struct{})
+	for port := range img.ContainerConfig.ExposedPorts {
+		exposedPorts[port] = struct{}{}
+	}
+	for port := range img.Config.ExposedPorts {
+		exposedPorts[port] = struct{}{}
+	}
+
+	return &ImageMetadata{
+		WorkingDir:   img.Config.WorkingDir,
+		User:         img.Config.User,
+		Entrypoint:   img.Config.Entrypoint,
+		Cmd:          img.Config.Cmd,
+		StartCommand: startCommand,
+		Env:          img.Config.Env,
+		ExposedPorts: sortPorts(exposedPorts, "tcp"),
+		UDPPorts:     sortPorts(exposedPorts, "udp"),
+	}, nil
+}
+
+// fetchImageJSONV2 retries private registries over plain HTTP when they
+// can't be reached over HTTPS, and leaves them to the legacy API when they
+// can't be reached at all, since it has its own ways of connecting.
+func (fetcher *dockerMetadataFetcher) fetchImageJSONV2(indexName, remoteName, tag string) ([]byte, error) {
+	imgJSON, err := fetcher.dockerRegistryV2.GetImageJSON(indexName, remoteName, tag, false)
+	if _, ok := err.(*url.Error); !ok || indexName == "" || indexName == docker_repository_name_formatter.DockerIndexServer {
+		return imgJSON, err
+	}
+
+	imgJSON, err = fetcher.dockerRegistryV2.GetImageJSON(indexName, remoteName, tag, true)
+	if _, ok := err.(*url.Error); ok {
+		return nil, ErrRegistryV2Unsupported
+	}
+	return imgJSON, err
+}
+
+func (fetcher *dockerMetadataFetcher) fetchImageJSONV1(indexName, remoteName, tag string) ([]byte, error) {
 	var reposName string
 	if len(indexName) > 0 {
 		reposName = fmt.Sprintf("%s/%s", indexName, remoteName)
@@ -45,8 +118,7 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(dockerImageReference string)
 		reposName = remoteName
 	}
 
-	var session DockerSession
-	session, err = fetcher.dockerSessionFactory.MakeSession(reposName, false)
+	session, err := fetcher.dockerSessionFactory.MakeSession(reposName, false)
 	if err != nil {
 		if !strings.Contains(err.Error(), "this private registry supports only HTTP or HTTPS with an unknown CA certificate") {
 			return nil, err
@@ -73,38 +145,15 @@ func (fetcher *dockerMetadataFetcher) FetchMetadata(dockerImageReference string)
 		return nil, fmt.Errorf("Unknown tag: %s:%s", remoteName, tag)
 	}
 
-	var img *image.Image
 	endpoint := repoData.Endpoints[0]
 	imgJSON, _, err := session.GetRemoteImageJSON(imgID, endpoint, repoData.Tokens)
-
-	if err != nil {
-		return nil, err
-	}
-
-	img, err = image.NewImgJSON(imgJSON)
-	if err != nil {
-		return nil, fmt.Errorf("Error parsing remote image json for specified docker image:\n%s", err.Error())
-	}
-
-	if img.Config == nil {
-		return nil, fmt.Errorf("Parsing start command failed")
-	}
-
-	startCommand := append(img.Config.Entrypoint, img.Config.Cmd...)
-
-	uintExposedPorts := sortPorts(img.ContainerConfig.ExposedPorts)
-
-	return &ImageMetadata{
-		WorkingDir:   img.Config.WorkingDir,
-		StartCommand: startCommand,
-		ExposedPorts: uintExposedPorts,
-	}, nil
+	return imgJSON, err
 }
 
-func sortPorts(dockerExposedPorts map[nat.Port]struct{}) []uint16 {
+func sortPorts(dockerExposedPorts map[nat.Port]struct{}, proto string) []uint16 {
 	intPorts := make([]int, 0)
 	for natPort, _ := range dockerExposedPorts {
-		if natPort.Proto() == "tcp" {
+		if natPort.Proto() == proto {
 			intPorts = append(intPorts, natPort.Int())
 		}
 	}
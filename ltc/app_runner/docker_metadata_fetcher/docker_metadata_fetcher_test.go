@@ -2,6 +2,7 @@ package docker_metadata_fetcher_test
 
 import (
 	"errors"
+	"net/url"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,18 +17,110 @@ var _ = Describe("DockerMetaDataFetcher", func() {
 		dockerMetadataFetcher docker_metadata_fetcher.DockerMetadataFetcher
 		dockerSessionFactory  *fake_docker_session.FakeDockerSessionFactory
 		fakeDockerSession     *fake_docker_session.FakeDockerSession
+		fakeDockerRegistryV2  *fake_docker_session.FakeDockerRegistryV2
 	)
 
 	BeforeEach(func() {
 		fakeDockerSession = &fake_docker_session.FakeDockerSession{}
 		dockerSessionFactory = &fake_docker_session.FakeDockerSessionFactory{}
-		dockerMetadataFetcher = docker_metadata_fetcher.New(dockerSessionFactory)
+		fakeDockerRegistryV2 = &fake_docker_session.FakeDockerRegistryV2{}
+		fakeDockerRegistryV2.GetImageJSONReturns(nil, docker_metadata_fetcher.ErrRegistryV2Unsupported)
+		dockerMetadataFetcher = docker_metadata_fetcher.New(dockerSessionFactory, fakeDockerRegistryV2)
 	})
 
 	Describe("FetchMetadata", func() {
 
+		Context("when the registry supports the v2 API", func() {
+			It("returns the image metadata from the image config without using the legacy API", func() {
+				fakeDockerRegistryV2.GetImageJSONReturns([]byte(`{
+					"config":{
+						"WorkingDir":"/home/app",
+						"Entrypoint":["/lattice-app"],
+						"Cmd":["--enableAwesomeMode=true"],
+						"Env":["LANG=C.UTF-8"],
+						"ExposedPorts":{"8080/tcp":{}, "5353/udp":{}}
+					}
+				}`), nil)
+
+				imageMetadata, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetapp:v1")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeDockerRegistryV2.GetImageJSONCallCount()).To(Equal(1))
+				indexName, remoteName, tag, allowInsecure := fakeDockerRegistryV2.GetImageJSONArgsForCall(0)
+				Expect(indexName).To(BeEmpty())
+				Expect(remoteName).To(Equal("cool_user123/sweetapp"))
+				Expect(tag).To(Equal("v1"))
+				Expect(allowInsecure).To(BeFalse())
+
+				Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+
+				Expect(imageMetadata.WorkingDir).To(Equal("/home/app"))
+				Expect(imageMetadata.StartCommand).To(Equal([]string{"/lattice-app", "--enableAwesomeMode=true"}))
+				Expect(imageMetadata.Env).To(Equal([]string{"LANG=C.UTF-8"}))
+				Expect(imageMetadata.ExposedPorts).To(Equal([]uint16{8080}))
+				Expect(imageMetadata.UDPPorts).To(Equal([]uint16{5353}))
+			})
+
+			Context("when a custom registry can't be reached over https", func() {
+				It("retries over http", func() {
+					fakeDockerRegistryV2.GetImageJSONStub = func(indexName, remoteName, tag string, allowInsecure bool) ([]byte, error) {
+						if !allowInsecure {
+							return nil, &url.Error{Op: "Get", URL: "https://my.registry.example.com:5000/v2/", Err: errors.New("x509: certificate signed by unknown authority")}
+						}
+						return []byte(`{"config":{"WorkingDir":"/insecure"}}`), nil
+					}
+
+					imageMetadata, err := dockerMetadataFetcher.FetchMetadata("my.registry.example.com:5000/sweetapp")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeDockerRegistryV2.GetImageJSONCallCount()).To(Equal(2))
+					indexName, _, _, allowInsecure := fakeDockerRegistryV2.GetImageJSONArgsForCall(1)
+					Expect(indexName).To(Equal("my.registry.example.com:5000"))
+					Expect(allowInsecure).To(BeTrue())
+
+					Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+					Expect(imageMetadata.WorkingDir).To(Equal("/insecure"))
+				})
+
+				It("falls back to the legacy API when http fails too", func() {
+					fakeDockerRegistryV2.GetImageJSONReturns(nil, &url.Error{Op: "Get", URL: "http://my.registry.example.com:5000/v2/", Err: errors.New("connection refused")})
+					dockerSessionFactory.MakeSessionReturns(fakeDockerSession, errors.New("legacy session failed"))
+
+					_, err := dockerMetadataFetcher.FetchMetadata("my.registry.example.com:5000/sweetapp")
+					Expect(err).To(MatchError("legacy session failed"))
+
+					Expect(fakeDockerRegistryV2.GetImageJSONCallCount()).To(Equal(2))
+					Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when docker hub can't be reached", func() {
+				It("returns the error without retrying", func() {
+					fakeDockerRegistryV2.GetImageJSONReturns(nil, &url.Error{Op: "Get", URL: "https://registry-1.docker.io/v2/", Err: errors.New("no route to host")})
+
+					_, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetapp")
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("no route to host"))
+
+					Expect(fakeDockerRegistryV2.GetImageJSONCallCount()).To(Equal(1))
+					Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the registry returns an error", func() {
+				It("returns the error", func() {
+					fakeDockerRegistryV2.GetImageJSONReturns(nil, errors.New("Unknown tag: cool_user123/sweetapp:nope"))
+
+					_, err := dockerMetadataFetcher.FetchMetadata("cool_user123/sweetapp:nope")
+					Expect(err).To(MatchError("Unknown tag: cool_user123/sweetapp:nope"))
+
+					Expect(dockerSessionFactory.MakeSessionCallCount()).To(Equal(0))
+				})
+			})
+		})
+
 		Context("when fetching metadata from the docker hub registry", func() {
-			It("returns the ImageMetadata with the WorkingDir, StartCommand, and PortConfig, and sets the monitored port to the lowest exposed tcp port", func() {
+			It("returns the ImageMetadata with the WorkingDir, User, Entrypoint, Cmd, StartCommand, Env, and tcp and udp ports, and sets the monitored port to the lowest exposed tcp port", func() {
 				dockerSessionFactory.MakeSessionReturns(fakeDockerSession, nil)
 
 				imageList := map[string]*registry.ImgData{
@@ -52,8 +145,10 @@ var _ = Describe("DockerMetaDataFetcher", func() {
 					"container_config":{ "ExposedPorts":{"28321/tcp":{}, "6923/udp":{}, "27017/tcp":{}} },
 				 	"config":{
 				 				"WorkingDir":"/home/app",
+				 				"User":"app",
 				 				"Entrypoint":["/lattice-app"],
-				 				"Cmd":["--enableAwesomeMode=true","iloveargs"]
+				 				"Cmd":["--enableAwesomeMode=true","iloveargs"],
+				 				"Env":["PATH=/usr/local/bin:/usr/bin:/bin","LANG=C.UTF-8"]
 							}
 						}`),
 					0,
@@ -83,8 +178,13 @@ var _ = Describe("DockerMetaDataFetcher", func() {
 				Expect(remoteImageTokensParam).To(Equal([]string{"signature=abc,repository=\"cloudfoundry/lattice-app\",access=read"}))
 
 				Expect(imageMetadata.WorkingDir).To(Equal("/home/app"))
+				Expect(imageMetadata.User).To(Equal("app"))
+				Expect(imageMetadata.Entrypoint).To(Equal([]string{"/lattice-app"}))
+				Expect(imageMetadata.Cmd).To(Equal([]string{"--enableAwesomeMode=true", "iloveargs"}))
 				Expect(imageMetadata.StartCommand).To(Equal([]string{"/lattice-app", "--enableAwesomeMode=true", "iloveargs"}))
+				Expect(imageMetadata.Env).To(Equal([]string{"PATH=/usr/local/bin:/usr/bin:/bin", "LANG=C.UTF-8"}))
 				Expect(imageMetadata.ExposedPorts).To(Equal([]uint16{uint16(27017), uint16(28321)}))
+				Expect(imageMetadata.UDPPorts).To(Equal([]uint16{uint16(6923)}))
 			})
 		})
 
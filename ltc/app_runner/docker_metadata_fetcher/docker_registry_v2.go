@@ -0,0 +1,238 @@
+package docker_metadata_fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_repository_name_formatter"
+)
+
+const (
+	dockerHubRegistryV2Host = "registry-1.docker.io"
+
+	manifestV2Schema2MediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	manifestV2Schema1MediaType = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+)
+
+var ErrRegistryV2Unsupported = errors.New("registry does not support the v2 API")
+
+var errRequestCanceled = errors.New("registry request canceled")
+
+//go:generate counterfeiter -o fake_docker_session/fake_docker_registry_v2.go . DockerRegistryV2
+type DockerRegistryV2 interface {
+	GetImageJSON(indexName, remoteName, tag string, allowInsecure bool) ([]byte, error)
+}
+
+type dockerRegistryV2 struct {
+	cancel     <-chan struct{}
+	httpClient *http.Client
+}
+
+// NewDockerRegistryV2 returns a DockerRegistryV2 whose requests are aborted
+// once cancel is closed.
+func NewDockerRegistryV2(cancel <-chan struct{}, httpClient *http.Client) DockerRegistryV2 {
+	return &dockerRegistryV2{cancel, httpClient}
+}
+
+// GetImageJSON returns the image config for a tag in the same format as
+// the legacy registry's image JSON.  Registries that hand out bearer tokens
+// are authenticated with anonymously, and ErrRegistryV2Unsupported is
+// returned by registries that only speak the legacy protocol.
+func (r *dockerRegistryV2) GetImageJSON(indexName, remoteName, tag string, allowInsecure bool) ([]byte, error) {
+	host := indexName
+	if host == "" || host == docker_repository_name_formatter.DockerIndexServer {
+		host = dockerHubRegistryV2Host
+	}
+
+	scheme := "https"
+	if allowInsecure {
+		scheme = "http"
+	}
+	repositoryURL := fmt.Sprintf("%s://%s/v2/%s", scheme, host, remoteName)
+
+	session := &registryV2Session{cancel: r.cancel, httpClient: r.httpClient}
+
+	manifestResponse, err := session.get(repositoryURL+"/manifests/"+tag, manifestV2Schema2MediaType, manifestV2Schema1MediaType, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer manifestResponse.Body.Close()
+
+	if manifestResponse.Header.Get("Docker-Distribution-Api-Version") == "" {
+		return nil, ErrRegistryV2Unsupported
+	}
+	if manifestResponse.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Unknown tag: %s:%s", remoteName, tag)
+	}
+	if manifestResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error fetching manifest for %s:%s: registry returned %s", remoteName, tag, manifestResponse.Status)
+	}
+
+	var manifest struct {
+		SchemaVersion int `json:"schemaVersion"`
+		Config        struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		History []struct {
+			V1Compatibility string `json:"v1Compatibility"`
+		} `json:"history"`
+	}
+	if err := json.NewDecoder(manifestResponse.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("Error parsing manifest for %s:%s: %s", remoteName, tag, err)
+	}
+
+	switch {
+	case manifest.SchemaVersion == 1 && len(manifest.History) > 0:
+		return []byte(manifest.History[0].V1Compatibility), nil
+	case manifest.SchemaVersion == 2 && manifest.Config.Digest != "":
+		configResponse, err := session.get(repositoryURL + "/blobs/" + manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		defer configResponse.Body.Close()
+
+		if configResponse.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Error fetching image config for %s:%s: registry returned %s", remoteName, tag, configResponse.Status)
+		}
+		return ioutil.ReadAll(configResponse.Body)
+	default:
+		return nil, fmt.Errorf("Unsupported manifest for %s:%s", remoteName, tag)
+	}
+}
+
+// registryV2Session remembers the bearer token from the first challenge so
+// that the manifest and config requests share it.
+type registryV2Session struct {
+	cancel     <-chan struct{}
+	httpClient *http.Client
+	token      string
+}
+
+func (s *registryV2Session) get(requestURL string, accept ...string) (*http.Response, error) {
+	response, err := s.do(requestURL, accept)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || s.token != "" {
+		return response, err
+	}
+
+	challenge := response.Header.Get("WWW-Authenticate")
+	response.Body.Close()
+
+	if s.token, err = s.fetchToken(challenge); err != nil {
+		return nil, err
+	}
+	return s.do(requestURL, accept)
+}
+
+func (s *registryV2Session) do(requestURL string, accept []string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, mediaType := range accept {
+		request.Header.Add("Accept", mediaType)
+	}
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return s.send(request)
+}
+
+// send makes request unless the session is already canceled, abandoning it
+// if the session is canceled while it is in flight.
+func (s *registryV2Session) send(request *http.Request) (*http.Response, error) {
+	select {
+	case <-s.cancel:
+		return nil, errRequestCanceled
+	default:
+	}
+
+	request.Cancel = s.cancel
+	return s.httpClient.Do(request)
+}
+
+func (s *registryV2Session) fetchToken(challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", errors.New("Registry requires credentials, which ltc does not support")
+	}
+
+	params := parseChallengeParams(strings.TrimPrefix(challenge, "Bearer "))
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("Invalid token realm in registry challenge: %s", challenge)
+	}
+
+	query := realm.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value, ok := params[name]; ok {
+			query.Set(name, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := s.send(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Error fetching registry token: %s", response.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("Error parsing registry token: %s", err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	if tokenResponse.AccessToken != "" {
+		return tokenResponse.AccessToken, nil
+	}
+	return "", errors.New("Registry did not return a token")
+}
+
+// parseChallengeParams parses the comma-separated key="value" pairs of a
+// WWW-Authenticate challenge.
+func parseChallengeParams(params string) map[string]string {
+	parsed := make(map[string]string)
+	for params != "" {
+		equals := strings.Index(params, "=")
+		if equals == -1 {
+			break
+		}
+		name := strings.TrimSpace(params[:equals])
+		params = params[equals+1:]
+
+		var value string
+		if strings.HasPrefix(params, `"`) {
+			end := strings.Index(params[1:], `"`)
+			if end == -1 {
+				value, params = params[1:], ""
+			} else {
+				value, params = params[1:end+1], params[end+2:]
+			}
+		} else if comma := strings.Index(params, ","); comma != -1 {
+			value, params = params[:comma], params[comma:]
+		} else {
+			value, params = params, ""
+		}
+
+		parsed[name] = value
+		params = strings.TrimLeft(params, ", ")
+	}
+	return parsed
+}
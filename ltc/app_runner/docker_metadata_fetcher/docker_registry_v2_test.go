@@ -0,0 +1,159 @@
+package docker_metadata_fetcher_test
+
+import (
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+)
+
+var _ = Describe("DockerRegistryV2", func() {
+	var (
+		registryHost         string
+		dockerRegistryServer *ghttp.Server
+		registryV2           docker_metadata_fetcher.DockerRegistryV2
+		v2Header             http.Header
+	)
+
+	BeforeEach(func() {
+		dockerRegistryServer = ghttp.NewServer()
+		parts, _ := url.Parse(dockerRegistryServer.URL())
+		registryHost = parts.Host
+
+		registryV2 = docker_metadata_fetcher.NewDockerRegistryV2(nil, &http.Client{})
+		v2Header = http.Header{"Docker-Distribution-Api-Version": []string{"registry/2.0"}}
+	})
+
+	AfterEach(func() {
+		dockerRegistryServer.Close()
+	})
+
+	Describe("GetImageJSON", func() {
+		Context("when the registry serves a schema 2 manifest behind token auth", func() {
+			BeforeEach(func() {
+				challengeHeader := http.Header{
+					"Docker-Distribution-Api-Version": []string{"registry/2.0"},
+					"Www-Authenticate":                []string{`Bearer realm="` + dockerRegistryServer.URL() + `/token",service="registry.example.com",scope="repository:cool_user123/sweetapp:pull"`},
+				}
+
+				dockerRegistryServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/cool_user123/sweetapp/manifests/v1"),
+						ghttp.RespondWith(http.StatusUnauthorized, "", challengeHeader),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/token", "scope=repository%3Acool_user123%2Fsweetapp%3Apull&service=registry.example.com"),
+						ghttp.RespondWith(http.StatusOK, `{"token":"some-token"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/cool_user123/sweetapp/manifests/v1"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+						ghttp.VerifyHeaderKV("Accept",
+							"application/vnd.docker.distribution.manifest.v2+json",
+							"application/vnd.docker.distribution.manifest.v1+prettyjws",
+							"application/json",
+						),
+						ghttp.RespondWith(http.StatusOK, `{
+							"schemaVersion": 2,
+							"config": {"digest": "sha256:abc123"}
+						}`, v2Header),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/cool_user123/sweetapp/blobs/sha256:abc123"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+						ghttp.RespondWith(http.StatusOK, `{"config":{"WorkingDir":"/home/app"}}`),
+					),
+				)
+			})
+
+			It("fetches a token and returns the image config", func() {
+				imgJSON, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "v1", true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(imgJSON).To(MatchJSON(`{"config":{"WorkingDir":"/home/app"}}`))
+				Expect(dockerRegistryServer.ReceivedRequests()).To(HaveLen(4))
+			})
+		})
+
+		Context("when the registry serves a schema 1 manifest", func() {
+			BeforeEach(func() {
+				dockerRegistryServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/cool_user123/sweetapp/manifests/latest"),
+						ghttp.RespondWith(http.StatusOK, `{
+							"schemaVersion": 1,
+							"history": [
+								{"v1Compatibility": "{\"config\":{\"WorkingDir\":\"/newest\"}}"},
+								{"v1Compatibility": "{\"config\":{\"WorkingDir\":\"/oldest\"}}"}
+							]
+						}`, v2Header),
+					),
+				)
+			})
+
+			It("returns the image JSON of the newest layer", func() {
+				imgJSON, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "latest", true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(imgJSON).To(MatchJSON(`{"config":{"WorkingDir":"/newest"}}`))
+			})
+		})
+
+		Context("when the registry only speaks the legacy API", func() {
+			It("returns ErrRegistryV2Unsupported", func() {
+				dockerRegistryServer.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+				_, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "latest", true)
+				Expect(err).To(Equal(docker_metadata_fetcher.ErrRegistryV2Unsupported))
+			})
+		})
+
+		Context("when the tag does not exist", func() {
+			It("returns an error", func() {
+				dockerRegistryServer.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, "", v2Header))
+
+				_, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "nope", true)
+				Expect(err).To(MatchError("Unknown tag: cool_user123/sweetapp:nope"))
+			})
+		})
+
+		Context("when the registry asks for credentials", func() {
+			It("returns an error", func() {
+				dockerRegistryServer.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, "", http.Header{
+					"Docker-Distribution-Api-Version": []string{"registry/2.0"},
+					"Www-Authenticate":                []string{`Basic realm="registry"`},
+				}))
+
+				_, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "latest", true)
+				Expect(err).To(MatchError("Registry requires credentials, which ltc does not support"))
+			})
+		})
+
+		Context("when the registry can't be reached", func() {
+			It("returns the url error", func() {
+				closedServer := ghttp.NewServer()
+				parts, _ := url.Parse(closedServer.URL())
+				closedServer.Close()
+
+				_, err := registryV2.GetImageJSON(parts.Host, "cool_user123/sweetapp", "latest", true)
+				Expect(err).To(BeAssignableToTypeOf(&url.Error{}))
+			})
+		})
+
+		Context("when the registry is canceled", func() {
+			It("aborts the request", func() {
+				cancel := make(chan struct{})
+				registryV2 = docker_metadata_fetcher.NewDockerRegistryV2(cancel, &http.Client{})
+				close(cancel)
+
+				_, err := registryV2.GetImageJSON(registryHost, "cool_user123/sweetapp", "latest", true)
+				Expect(err).To(MatchError(ContainSubstring("canceled")))
+				Expect(dockerRegistryServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+})
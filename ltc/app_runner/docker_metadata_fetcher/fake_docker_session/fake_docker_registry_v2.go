@@ -0,0 +1,61 @@
+// This file was generated by counterfeiter
+package fake_docker_session
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+)
+
+type FakeDockerRegistryV2 struct {
+	GetImageJSONStub        func(indexName string, remoteName string, tag string, allowInsecure bool) ([]byte, error)
+	getImageJSONMutex       sync.RWMutex
+	getImageJSONArgsForCall []struct {
+		indexName     string
+		remoteName    string
+		tag           string
+		allowInsecure bool
+	}
+	getImageJSONReturns struct {
+		result1 []byte
+		result2 error
+	}
+}
+
+func (fake *FakeDockerRegistryV2) GetImageJSON(indexName string, remoteName string, tag string, allowInsecure bool) ([]byte, error) {
+	fake.getImageJSONMutex.Lock()
+	fake.getImageJSONArgsForCall = append(fake.getImageJSONArgsForCall, struct {
+		indexName     string
+		remoteName    string
+		tag           string
+		allowInsecure bool
+	}{indexName, remoteName, tag, allowInsecure})
+	fake.getImageJSONMutex.Unlock()
+	if fake.GetImageJSONStub != nil {
+		return fake.GetImageJSONStub(indexName, remoteName, tag, allowInsecure)
+	} else {
+		return fake.getImageJSONReturns.result1, fake.getImageJSONReturns.result2
+	}
+}
+
+func (fake *FakeDockerRegistryV2) GetImageJSONCallCount() int {
+	fake.getImageJSONMutex.RLock()
+	defer fake.getImageJSONMutex.RUnlock()
+	return len(fake.getImageJSONArgsForCall)
+}
+
+func (fake *FakeDockerRegistryV2) GetImageJSONArgsForCall(i int) (string, string, string, bool) {
+	fake.getImageJSONMutex.RLock()
+	defer fake.getImageJSONMutex.RUnlock()
+	return fake.getImageJSONArgsForCall[i].indexName, fake.getImageJSONArgsForCall[i].remoteName, fake.getImageJSONArgsForCall[i].tag, fake.getImageJSONArgsForCall[i].allowInsecure
+}
+
+func (fake *FakeDockerRegistryV2) GetImageJSONReturns(result1 []byte, result2 error) {
+	fake.GetImageJSONStub = nil
+	fake.getImageJSONReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+var _ docker_metadata_fetcher.DockerRegistryV2 = new(FakeDockerRegistryV2)
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+// Entry records one run of a command that changed what's running on a
+// Lattice target.  Args are the command's args as they were typed, except
+// for the values passed to set-env, so the command can be run again with
+// `ltc` prepended.  Commands that change an
+// app's definition also record the resulting Revision of the app.
+type Entry struct {
+	Time     time.Time                         `json:"time"`
+	Target   string                            `json:"target"`
+	Command  string                            `json:"command"`
+	Args     []string                          `json:"args"`
+	ExitCode int                               `json:"exit_code"`
+	Revision *receptor.DesiredLRPCreateRequest `json:"revision,omitempty"`
+}
+
+func (e Entry) Succeeded() bool {
+	return e.ExitCode == 0
+}
+
+//go:generate counterfeiter -o fake_audit_log/fake_audit_log.go . Log
+type Log interface {
+	Append(entry Entry) error
+	Entries() ([]Entry, error)
+}
+
+type fileLog struct {
+	path string
+}
+
+// NewFileLog returns a Log that keeps one JSON entry per line in the file
+// at path.  Entries are only ever appended.
+func NewFileLog(path string) Log {
+	return &fileLog{path}
+}
+
+func (l *fileLog) Append(entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(entryJSON, '\n'))
+	return err
+}
+
+func (l *fileLog) Entries() ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Entries are decoded one after another rather than line by line, as
+	// ones that record a revision can be longer than a bufio.Scanner's lines.
+	entries := []Entry{}
+	decoder := json.NewDecoder(file)
+	for {
+		var entry Entry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Recorder appends an Entry to the log for the command it was started for.
+// Commands report failure by exiting, so the Recorder stands in for their
+// ExitHandler to learn the exit code; a command that returns normally is
+// finished with 0, and one that is interrupted with SigInt.
+type Recorder struct {
+	exit_handler.ExitHandler
+
+	log     Log
+	clock   clock.Clock
+	mutex   sync.Mutex
+	pending *Entry
+	onExit  sync.Once
+}
+
+func NewRecorder(log Log, exitHandler exit_handler.ExitHandler, clock clock.Clock) *Recorder {
+	return &Recorder{ExitHandler: exitHandler, log: log, clock: clock}
+}
+
+func (r *Recorder) Start(target, command string, args []string) {
+	r.onExit.Do(func() {
+		r.ExitHandler.OnExit(func() {
+			r.Finish(exit_codes.SigInt)
+		})
+	})
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.pending = &Entry{
+		Time:    r.clock.Now(),
+		Target:  target,
+		Command: command,
+		Args:    args,
+	}
+}
+
+// Finish logs the started command with exitCode.  It does nothing if no
+// command is in progress.
+func (r *Recorder) Finish(exitCode int) error {
+	return r.finish(exitCode, nil)
+}
+
+// FinishWithRevision logs the started command as having succeeded, along
+// with the definition of the app it left behind.
+func (r *Recorder) FinishWithRevision(revision receptor.DesiredLRPCreateRequest) error {
+	return r.finish(0, &revision)
+}
+
+// Started reports whether a command is in progress.
+func (r *Recorder) Started() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.pending != nil
+}
+
+func (r *Recorder) finish(exitCode int, revision *receptor.DesiredLRPCreateRequest) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.pending == nil {
+		return nil
+	}
+
+	entry := *r.pending
+	entry.ExitCode = exitCode
+	entry.Revision = revision
+	r.pending = nil
+	return r.log.Append(entry)
+}
+
+func (r *Recorder) Exit(code int) {
+	r.Finish(code)
+	r.ExitHandler.Exit(code)
+}
@@ -0,0 +1,193 @@
+package audit_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit/fake_audit_log"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("Audit", func() {
+	Describe("FileLog", func() {
+		var (
+			tmpDir  string
+			logPath string
+			log     audit.Log
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "audit")
+			Expect(err).NotTo(HaveOccurred())
+
+			logPath = filepath.Join(tmpDir, ".lattice", "audit.log")
+			log = audit.NewFileLog(logPath)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("appends entries and reads them back in order", func() {
+			first := audit.Entry{Time: time.Unix(100, 0).UTC(), Target: "lattice.example.com", Command: "scale", Args: []string{"scale", "app", "3"}}
+			second := audit.Entry{Time: time.Unix(200, 0).UTC(), Target: "lattice.example.com", Command: "remove", Args: []string{"remove", "app"}, ExitCode: exit_codes.NotFound}
+
+			Expect(log.Append(first)).To(Succeed())
+			Expect(audit.NewFileLog(logPath).Append(second)).To(Succeed())
+
+			entries, err := log.Entries()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(Equal([]audit.Entry{first, second}))
+		})
+
+		It("keeps the revision of the app a command changed", func() {
+			revision := &receptor.DesiredLRPCreateRequest{
+				ProcessGuid:          "app",
+				RootFS:               "docker:///app#v2",
+				Instances:            2,
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "COLOR", Value: "blue"}},
+				Action:               &models.RunAction{Path: "/start"},
+			}
+			Expect(log.Append(audit.Entry{Command: "create", Args: []string{"create", "app", "app:v2"}, Revision: revision})).To(Succeed())
+
+			entries, err := log.Entries()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Revision).To(Equal(revision))
+		})
+
+		It("reads back entries longer than a line is usually buffered", func() {
+			revision := &receptor.DesiredLRPCreateRequest{
+				ProcessGuid:          "app",
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "CERT", Value: strings.Repeat("x", 128*1024)}},
+			}
+			Expect(log.Append(audit.Entry{Command: "create", Revision: revision})).To(Succeed())
+			Expect(log.Append(audit.Entry{Command: "scale"})).To(Succeed())
+
+			entries, err := log.Entries()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Revision).To(Equal(revision))
+		})
+
+		It("only lets the user read the log", func() {
+			Expect(log.Append(audit.Entry{Command: "create"})).To(Succeed())
+
+			info, err := os.Stat(logPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("returns no entries before anything is logged", func() {
+			entries, err := log.Entries()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("returns an error when the log is corrupt", func() {
+			Expect(os.MkdirAll(filepath.Dir(logPath), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(logPath, []byte("{not json\n"), 0600)).To(Succeed())
+
+			_, err := log.Entries()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Recorder", func() {
+		var (
+			fakeLog         *fake_audit_log.FakeLog
+			fakeExitHandler *fake_exit_handler.FakeExitHandler
+			fakeClock       *fakeclock.FakeClock
+			recorder        *audit.Recorder
+		)
+
+		BeforeEach(func() {
+			fakeLog = &fake_audit_log.FakeLog{}
+			fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+			fakeClock = fakeclock.NewFakeClock(time.Unix(100, 0))
+			recorder = audit.NewRecorder(fakeLog, fakeExitHandler, fakeClock)
+		})
+
+		It("logs a started command with its exit code when it finishes", func() {
+			recorder.Start("lattice.example.com", "scale", []string{"scale", "app", "3"})
+			fakeClock.IncrementBySeconds(5)
+
+			Expect(recorder.Finish(0)).To(Succeed())
+
+			Expect(fakeLog.AppendCallCount()).To(Equal(1))
+			Expect(fakeLog.AppendArgsForCall(0)).To(Equal(audit.Entry{
+				Time:    time.Unix(100, 0),
+				Target:  "lattice.example.com",
+				Command: "scale",
+				Args:    []string{"scale", "app", "3"},
+			}))
+		})
+
+		It("logs a revision of the app the command changed", func() {
+			recorder.Start("lattice.example.com", "scale", []string{"scale", "app", "3"})
+			Expect(recorder.Started()).To(BeTrue())
+
+			Expect(recorder.FinishWithRevision(receptor.DesiredLRPCreateRequest{ProcessGuid: "app", Instances: 3})).To(Succeed())
+
+			Expect(recorder.Started()).To(BeFalse())
+			Expect(fakeLog.AppendCallCount()).To(Equal(1))
+			Expect(fakeLog.AppendArgsForCall(0).ExitCode).To(Equal(0))
+			Expect(fakeLog.AppendArgsForCall(0).Revision).To(Equal(&receptor.DesiredLRPCreateRequest{ProcessGuid: "app", Instances: 3}))
+		})
+
+		It("logs the code a command exits with, then exits", func() {
+			recorder.Start("lattice.example.com", "remove", []string{"remove", "app"})
+
+			recorder.Exit(exit_codes.NotFound)
+
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			Expect(fakeLog.AppendCallCount()).To(Equal(1))
+			Expect(fakeLog.AppendArgsForCall(0).ExitCode).To(Equal(exit_codes.NotFound))
+		})
+
+		It("logs an interrupted command", func() {
+			recorder.Start("lattice.example.com", "create", []string{"create", "app", "image"})
+
+			fakeExitHandler.Exit(exit_codes.SigInt)
+
+			Expect(fakeLog.AppendCallCount()).To(Equal(1))
+			Expect(fakeLog.AppendArgsForCall(0).ExitCode).To(Equal(exit_codes.SigInt))
+		})
+
+		It("logs each command once", func() {
+			recorder.Start("lattice.example.com", "scale", []string{"scale", "app", "3"})
+
+			recorder.Exit(exit_codes.CommandFailed)
+			Expect(recorder.Finish(0)).To(Succeed())
+
+			Expect(fakeLog.AppendCallCount()).To(Equal(1))
+		})
+
+		It("logs nothing when no command was started", func() {
+			recorder.Exit(exit_codes.InvalidSyntax)
+
+			Expect(fakeLog.AppendCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("returns errors writing the log", func() {
+			fakeLog.AppendReturns(errors.New("disk full"))
+			recorder.Start("lattice.example.com", "scale", []string{"scale", "app", "3"})
+
+			Expect(recorder.Finish(0)).To(MatchError("disk full"))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package command_factory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	HistoryCommandName = "history"
+
+	historyUsage = "Please enter 'ltc history' or 'ltc history rerun ID'"
+)
+
+type HistoryCommandFactory struct {
+	log         audit.Log
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewHistoryCommandFactory(log audit.Log, ui terminal.UI, exitHandler exit_handler.ExitHandler) *HistoryCommandFactory {
+	return &HistoryCommandFactory{log, ui, exitHandler}
+}
+
+func (factory *HistoryCommandFactory) MakeHistoryCommand() cli.Command {
+	var historyFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "last, n",
+			Usage: "Number of most recent commands to show (0 shows all)",
+			Value: 20,
+		},
+	}
+
+	return cli.Command{
+		Name:  HistoryCommandName,
+		Usage: "Shows or reruns past commands that changed apps or tasks",
+		Description: `ltc history [--last=20]
+   ltc history rerun ID
+
+   Commands are listed with the ID to pass to 'ltc history rerun'.`,
+		Action: factory.history,
+		Flags:  historyFlags,
+	}
+}
+
+func (factory *HistoryCommandFactory) history(context *cli.Context) {
+	args := context.Args()
+	switch {
+	case len(args) == 0:
+		factory.listHistory(context.Int("last"))
+	case args.First() == "rerun" && len(args) == 2:
+		factory.rerun(context.App, args.Get(1))
+	default:
+		factory.ui.SayIncorrectUsage(historyUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *HistoryCommandFactory) listHistory(last int) {
+	entries, ok := factory.entries()
+	if !ok {
+		return
+	}
+
+	if len(entries) == 0 {
+		factory.ui.SayLine("No commands in the history.")
+		return
+	}
+
+	first := 0
+	if last > 0 && len(entries) > last {
+		first = len(entries) - last
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(factory.ui, 10+colors.ColorCodeLength, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", colors.Bold("ID"), colors.Bold("Time"), colors.Bold("Target"), colors.Bold("Result"), colors.Bold("Command"))
+	for index := first; index < len(entries); index++ {
+		entry := entries[index]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			colors.NoColor(strconv.Itoa(index+1)),
+			colors.NoColor(entry.Time.Local().Format("2006-01-02 15:04:05")),
+			colors.NoColor(entry.Target),
+			colorResult(entry),
+			colors.NoColor("ltc "+strings.Join(entry.Args, " ")),
+		)
+	}
+	w.Flush()
+}
+
+func (factory *HistoryCommandFactory) rerun(app *cli.App, id string) {
+	entries, ok := factory.entries()
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(id)
+	if err != nil {
+		factory.ui.SayIncorrectUsage("ID must be a number")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if index < 1 || index > len(entries) {
+		factory.ui.SayLine(fmt.Sprintf("No command with ID %s in the history.", id))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+
+	args := entries[index-1].Args
+	factory.ui.SayInfo(fmt.Sprintf("Running: ltc %s\n", strings.Join(args, " ")))
+	if err := app.Run(append([]string{app.Name}, args...)); err != nil {
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *HistoryCommandFactory) entries() ([]audit.Entry, bool) {
+	entries, err := factory.log.Entries()
+	if err != nil {
+		factory.ui.SayLine("Error reading the history: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return nil, false
+	}
+	return entries, true
+}
+
+func colorResult(entry audit.Entry) string {
+	if entry.Succeeded() {
+		return colors.Green("ok")
+	}
+	return colors.Red(fmt.Sprintf("exit %d", entry.ExitCode))
+}
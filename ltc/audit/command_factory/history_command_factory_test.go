@@ -0,0 +1,146 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit/fake_audit_log"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("HistoryCommandFactory", func() {
+	var (
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		fakeLog         *fake_audit_log.FakeLog
+		cliApp          *cli.App
+		scaleArgs       []string
+	)
+
+	runHistory := func(args ...string) {
+		Expect(cliApp.Run(append([]string{"ltc", "history"}, args...))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeLog = &fake_audit_log.FakeLog{}
+		scaleArgs = nil
+
+		fakeLog.EntriesReturns([]audit.Entry{
+			{Time: time.Now(), Target: "lattice.example.com", Command: "create", Args: []string{"create", "app", "image"}},
+			{Time: time.Now(), Target: "lattice.example.com", Command: "scale", Args: []string{"scale", "--no-wait", "app", "3"}},
+			{Time: time.Now(), Target: "other.example.com", Command: "remove", Args: []string{"remove", "app"}, ExitCode: exit_codes.NotFound},
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewHistoryCommandFactory(fakeLog, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+
+		cliApp = cli.NewApp()
+		cliApp.Commands = []cli.Command{
+			commandFactory.MakeHistoryCommand(),
+			{
+				Name:  "scale",
+				Flags: []cli.Flag{cli.BoolFlag{Name: "no-wait"}},
+				Action: func(context *cli.Context) {
+					scaleArgs = append([]string{context.String("no-wait")}, context.Args()...)
+				},
+			},
+		}
+	})
+
+	Describe("history", func() {
+		It("lists the commands with their IDs, targets and results", func() {
+			runHistory()
+
+			Expect(outputBuffer).To(test_helpers.Say("ID"))
+			Expect(outputBuffer).To(test_helpers.Say("Command"))
+			Expect(outputBuffer).To(test_helpers.Say("1"))
+			Expect(outputBuffer).To(test_helpers.Say("lattice.example.com"))
+			Expect(outputBuffer).To(test_helpers.Say("ok"))
+			Expect(outputBuffer).To(test_helpers.Say("ltc create app image"))
+			Expect(outputBuffer).To(test_helpers.Say("2"))
+			Expect(outputBuffer).To(test_helpers.Say("ltc scale --no-wait app 3"))
+			Expect(outputBuffer).To(test_helpers.Say("3"))
+			Expect(outputBuffer).To(test_helpers.Say("other.example.com"))
+			Expect(outputBuffer).To(test_helpers.Say("exit 17"))
+			Expect(outputBuffer).To(test_helpers.Say("ltc remove app"))
+		})
+
+		It("shows only the most recent commands with --last", func() {
+			runHistory("--last", "1")
+
+			Expect(outputBuffer).NotTo(test_helpers.Say("ltc create app image"))
+			Expect(outputBuffer).To(test_helpers.Say("3"))
+			Expect(outputBuffer).To(test_helpers.Say("ltc remove app"))
+		})
+
+		Context("when nothing has been logged", func() {
+			BeforeEach(func() {
+				fakeLog.EntriesReturns([]audit.Entry{}, nil)
+			})
+
+			It("says so", func() {
+				runHistory()
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No commands in the history."))
+			})
+		})
+
+		Context("when the log can't be read", func() {
+			BeforeEach(func() {
+				fakeLog.EntriesReturns(nil, errors.New("permission denied"))
+			})
+
+			It("exits with a file system error", func() {
+				runHistory()
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error reading the history: permission denied"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+			})
+		})
+
+		It("rejects unknown args", func() {
+			runHistory("bogus")
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("history rerun", func() {
+		It("runs the command again with its original args", func() {
+			runHistory("rerun", "2")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Running: ltc scale --no-wait app 3"))
+			Expect(scaleArgs).To(Equal([]string{"true", "app", "3"}))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits when no command has the ID", func() {
+			runHistory("rerun", "4")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No command with ID 4 in the history."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("rejects IDs that aren't numbers", func() {
+			runHistory("rerun", "two")
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(scaleArgs).To(BeNil())
+		})
+	})
+})
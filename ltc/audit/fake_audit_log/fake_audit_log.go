@@ -0,0 +1,85 @@
+// This file was generated by counterfeiter
+package fake_audit_log
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+)
+
+type FakeLog struct {
+	AppendStub        func(entry audit.Entry) error
+	appendMutex       sync.RWMutex
+	appendArgsForCall []struct {
+		entry audit.Entry
+	}
+	appendReturns struct {
+		result1 error
+	}
+	EntriesStub        func() ([]audit.Entry, error)
+	entriesMutex       sync.RWMutex
+	entriesArgsForCall []struct{}
+	entriesReturns     struct {
+		result1 []audit.Entry
+		result2 error
+	}
+}
+
+func (fake *FakeLog) Append(entry audit.Entry) error {
+	fake.appendMutex.Lock()
+	fake.appendArgsForCall = append(fake.appendArgsForCall, struct {
+		entry audit.Entry
+	}{entry})
+	fake.appendMutex.Unlock()
+	if fake.AppendStub != nil {
+		return fake.AppendStub(entry)
+	} else {
+		return fake.appendReturns.result1
+	}
+}
+
+func (fake *FakeLog) AppendCallCount() int {
+	fake.appendMutex.RLock()
+	defer fake.appendMutex.RUnlock()
+	return len(fake.appendArgsForCall)
+}
+
+func (fake *FakeLog) AppendArgsForCall(i int) audit.Entry {
+	fake.appendMutex.RLock()
+	defer fake.appendMutex.RUnlock()
+	return fake.appendArgsForCall[i].entry
+}
+
+func (fake *FakeLog) AppendReturns(result1 error) {
+	fake.AppendStub = nil
+	fake.appendReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLog) Entries() ([]audit.Entry, error) {
+	fake.entriesMutex.Lock()
+	fake.entriesArgsForCall = append(fake.entriesArgsForCall, struct{}{})
+	fake.entriesMutex.Unlock()
+	if fake.EntriesStub != nil {
+		return fake.EntriesStub()
+	} else {
+		return fake.entriesReturns.result1, fake.entriesReturns.result2
+	}
+}
+
+func (fake *FakeLog) EntriesCallCount() int {
+	fake.entriesMutex.RLock()
+	defer fake.entriesMutex.RUnlock()
+	return len(fake.entriesArgsForCall)
+}
+
+func (fake *FakeLog) EntriesReturns(result1 []audit.Entry, result2 error) {
+	fake.EntriesStub = nil
+	fake.entriesReturns = struct {
+		result1 []audit.Entry
+		result2 error
+	}{result1, result2}
+}
+
+var _ audit.Log = new(FakeLog)
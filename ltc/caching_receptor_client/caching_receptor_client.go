@@ -0,0 +1,263 @@
+package caching_receptor_client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+// ResyncInterval is how often the cache is refetched in full, in case the
+// event stream missed events while it reconnected.
+const ResyncInterval = 30 * time.Second
+
+//go:generate counterfeiter -o fake_cache/fake_cache.go . Cache
+type Cache interface {
+	// Start makes the client serve desired and actual LRP reads from the
+	// cache, which is filled on the next read.
+	Start()
+	// Stop goes back to sending every read to the receptor.
+	Stop()
+}
+
+type CachingClient interface {
+	receptor.Client
+	Cache
+}
+
+type actualLRPKey struct {
+	processGuid string
+	index       int
+	evacuating  bool
+}
+
+// cachingClient keeps the desired and actual LRPs up to date from the
+// receptor event stream once started, so that commands that refresh every
+// tick don't refetch every LRP in the cluster each time.  Until the cache is
+// filled, or whenever the event stream fails, reads go to the receptor.
+type cachingClient struct {
+	receptor.Client
+	clock clock.Clock
+
+	lock        sync.Mutex
+	started     bool
+	eventSource receptor.EventSource
+	syncedAt    time.Time
+	desiredLRPs map[string]receptor.DesiredLRPResponse
+	actualLRPs  map[actualLRPKey]receptor.ActualLRPResponse
+}
+
+func New(client receptor.Client, clock clock.Clock) CachingClient {
+	return &cachingClient{Client: client, clock: clock}
+}
+
+func (c *cachingClient) Start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = true
+}
+
+func (c *cachingClient) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = false
+	c.invalidate()
+}
+
+func (c *cachingClient) DesiredLRPs() ([]receptor.DesiredLRPResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.sync() {
+		return c.Client.DesiredLRPs()
+	}
+
+	desiredLRPs := make([]receptor.DesiredLRPResponse, 0, len(c.desiredLRPs))
+	for _, desiredLRP := range c.desiredLRPs {
+		desiredLRPs = append(desiredLRPs, desiredLRP)
+	}
+	sort.Sort(byProcessGuid(desiredLRPs))
+	return desiredLRPs, nil
+}
+
+func (c *cachingClient) GetDesiredLRP(processGuid string) (receptor.DesiredLRPResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.sync() {
+		return c.Client.GetDesiredLRP(processGuid)
+	}
+
+	desiredLRP, ok := c.desiredLRPs[processGuid]
+	if !ok {
+		return receptor.DesiredLRPResponse{}, receptor.Error{
+			Type:    receptor.DesiredLRPNotFound,
+			Message: fmt.Sprintf("Desired LRP with guid '%s' not found", processGuid),
+		}
+	}
+	return desiredLRP, nil
+}
+
+func (c *cachingClient) ActualLRPs() ([]receptor.ActualLRPResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.sync() {
+		return c.Client.ActualLRPs()
+	}
+	return c.cachedActualLRPs(""), nil
+}
+
+func (c *cachingClient) ActualLRPsByProcessGuid(processGuid string) ([]receptor.ActualLRPResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.sync() {
+		return c.Client.ActualLRPsByProcessGuid(processGuid)
+	}
+	return c.cachedActualLRPs(processGuid), nil
+}
+
+func (c *cachingClient) cachedActualLRPs(processGuid string) []receptor.ActualLRPResponse {
+	actualLRPs := []receptor.ActualLRPResponse{}
+	for _, actualLRP := range c.actualLRPs {
+		if processGuid == "" || actualLRP.ProcessGuid == processGuid {
+			actualLRPs = append(actualLRPs, actualLRP)
+		}
+	}
+	sort.Sort(byProcessGuidAndIndex(actualLRPs))
+	return actualLRPs
+}
+
+// sync fills the cache if it is started and empty or due a resync, and
+// reports whether it can serve reads.  The events are subscribed to before
+// the LRPs are fetched, so that none are missed in between.
+func (c *cachingClient) sync() bool {
+	if !c.started {
+		return false
+	}
+	if c.eventSource != nil && c.clock.Now().Sub(c.syncedAt) < ResyncInterval {
+		return true
+	}
+	c.invalidate()
+
+	eventSource, err := c.Client.SubscribeToEvents()
+	if err != nil {
+		return false
+	}
+	desiredLRPs, err := c.Client.DesiredLRPs()
+	if err != nil {
+		eventSource.Close()
+		return false
+	}
+	actualLRPs, err := c.Client.ActualLRPs()
+	if err != nil {
+		eventSource.Close()
+		return false
+	}
+
+	c.desiredLRPs = make(map[string]receptor.DesiredLRPResponse, len(desiredLRPs))
+	for _, desiredLRP := range desiredLRPs {
+		c.desiredLRPs[desiredLRP.ProcessGuid] = desiredLRP
+	}
+	c.actualLRPs = make(map[actualLRPKey]receptor.ActualLRPResponse, len(actualLRPs))
+	for _, actualLRP := range actualLRPs {
+		c.actualLRPs[keyFor(actualLRP)] = actualLRP
+	}
+	c.eventSource = eventSource
+	c.syncedAt = c.clock.Now()
+
+	go c.applyEvents(eventSource)
+	return true
+}
+
+func (c *cachingClient) invalidate() {
+	if c.eventSource != nil {
+		c.eventSource.Close()
+	}
+	c.eventSource = nil
+	c.desiredLRPs = nil
+	c.actualLRPs = nil
+}
+
+func (c *cachingClient) applyEvents(eventSource receptor.EventSource) {
+	for {
+		event, err := eventSource.Next()
+
+		c.lock.Lock()
+		if c.eventSource != eventSource {
+			c.lock.Unlock()
+			return
+		}
+		if err != nil {
+			c.invalidate()
+			c.lock.Unlock()
+			return
+		}
+		c.apply(event)
+		c.lock.Unlock()
+	}
+}
+
+// apply updates the cache with event, unless the cache already holds a
+// newer version of the LRP, as it can for events sent while it was filled.
+func (c *cachingClient) apply(event receptor.Event) {
+	switch event := event.(type) {
+	case receptor.DesiredLRPCreatedEvent:
+		c.putDesiredLRP(event.DesiredLRPResponse)
+	case receptor.DesiredLRPChangedEvent:
+		c.putDesiredLRP(event.After)
+	case receptor.DesiredLRPRemovedEvent:
+		if current, ok := c.desiredLRPs[event.DesiredLRPResponse.ProcessGuid]; !ok || !isNewer(current.ModificationTag, event.DesiredLRPResponse.ModificationTag) {
+			delete(c.desiredLRPs, event.DesiredLRPResponse.ProcessGuid)
+		}
+	case receptor.ActualLRPCreatedEvent:
+		c.putActualLRP(event.ActualLRPResponse)
+	case receptor.ActualLRPChangedEvent:
+		c.putActualLRP(event.After)
+	case receptor.ActualLRPRemovedEvent:
+		key := keyFor(event.ActualLRPResponse)
+		if current, ok := c.actualLRPs[key]; !ok || !isNewer(current.ModificationTag, event.ActualLRPResponse.ModificationTag) {
+			delete(c.actualLRPs, key)
+		}
+	}
+}
+
+func (c *cachingClient) putDesiredLRP(desiredLRP receptor.DesiredLRPResponse) {
+	if current, ok := c.desiredLRPs[desiredLRP.ProcessGuid]; ok && isNewer(current.ModificationTag, desiredLRP.ModificationTag) {
+		return
+	}
+	c.desiredLRPs[desiredLRP.ProcessGuid] = desiredLRP
+}
+
+func (c *cachingClient) putActualLRP(actualLRP receptor.ActualLRPResponse) {
+	key := keyFor(actualLRP)
+	if current, ok := c.actualLRPs[key]; ok && isNewer(current.ModificationTag, actualLRP.ModificationTag) {
+		return
+	}
+	c.actualLRPs[key] = actualLRP
+}
+
+func isNewer(current, other receptor.ModificationTag) bool {
+	return current.Epoch != "" && current.Epoch == other.Epoch && current.Index > other.Index
+}
+
+func keyFor(actualLRP receptor.ActualLRPResponse) actualLRPKey {
+	return actualLRPKey{actualLRP.ProcessGuid, actualLRP.Index, actualLRP.Evacuating}
+}
+
+type byProcessGuid []receptor.DesiredLRPResponse
+
+func (s byProcessGuid) Len() int           { return len(s) }
+func (s byProcessGuid) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byProcessGuid) Less(i, j int) bool { return s[i].ProcessGuid < s[j].ProcessGuid }
+
+type byProcessGuidAndIndex []receptor.ActualLRPResponse
+
+func (s byProcessGuidAndIndex) Len() int      { return len(s) }
+func (s byProcessGuidAndIndex) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byProcessGuidAndIndex) Less(i, j int) bool {
+	if s[i].ProcessGuid != s[j].ProcessGuid {
+		return s[i].ProcessGuid < s[j].ProcessGuid
+	}
+	return s[i].Index < s[j].Index
+}
@@ -0,0 +1,13 @@
+package caching_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCachingReceptorClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CachingReceptorClient Suite")
+}
@@ -0,0 +1,189 @@
+package caching_receptor_client_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("CachingReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeEventSource    *fake_receptor.FakeEventSource
+		events             chan receptor.Event
+		fakeClock          *fakeclock.FakeClock
+		cachingClient      caching_receptor_client.CachingClient
+	)
+
+	tag := func(index uint) receptor.ModificationTag {
+		return receptor.ModificationTag{Epoch: "abc", Index: index}
+	}
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		events = make(chan receptor.Event, 10)
+		nextEvents := events
+		fakeEventSource = &fake_receptor.FakeEventSource{}
+		fakeEventSource.NextStub = func() (receptor.Event, error) {
+			event, ok := <-nextEvents
+			if !ok {
+				return nil, receptor.ErrSourceClosed
+			}
+			return event, nil
+		}
+		fakeReceptorClient.SubscribeToEventsReturns(fakeEventSource, nil)
+		fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+			{ProcessGuid: "web", Instances: 2, ModificationTag: tag(1)},
+		}, nil)
+		fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{
+			{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateClaimed, ModificationTag: tag(2)},
+			{ProcessGuid: "web", Index: 0, State: receptor.ActualLRPStateRunning, ModificationTag: tag(1)},
+		}, nil)
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+
+		cachingClient = caching_receptor_client.New(fakeReceptorClient, fakeClock)
+	})
+
+	Context("until started", func() {
+		It("sends reads to the receptor", func() {
+			actualLRPs, err := cachingClient.ActualLRPs()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualLRPs).To(HaveLen(2))
+			_, err = cachingClient.ActualLRPs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(2))
+			Expect(fakeReceptorClient.SubscribeToEventsCallCount()).To(BeZero())
+		})
+	})
+
+	Context("once started", func() {
+		BeforeEach(func() {
+			cachingClient.Start()
+		})
+
+		It("fetches the LRPs once and serves reads from the cache", func() {
+			for i := 0; i < 3; i++ {
+				actualLRPs, err := cachingClient.ActualLRPs()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualLRPs).To(Equal([]receptor.ActualLRPResponse{
+					{ProcessGuid: "web", Index: 0, State: receptor.ActualLRPStateRunning, ModificationTag: tag(1)},
+					{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateClaimed, ModificationTag: tag(2)},
+				}))
+			}
+
+			desiredLRP, err := cachingClient.GetDesiredLRP("web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desiredLRP.Instances).To(Equal(2))
+
+			Expect(fakeReceptorClient.SubscribeToEventsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.GetDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("keeps the cache up to date from the event stream", func() {
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			events <- receptor.NewDesiredLRPChangedEvent(
+				receptor.DesiredLRPResponse{ProcessGuid: "web", Instances: 2, ModificationTag: tag(1)},
+				receptor.DesiredLRPResponse{ProcessGuid: "web", Instances: 3, ModificationTag: tag(2)},
+			)
+			events <- receptor.NewActualLRPChangedEvent(
+				receptor.ActualLRPResponse{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateClaimed, ModificationTag: tag(2)},
+				receptor.ActualLRPResponse{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateRunning, ModificationTag: tag(3)},
+			)
+			events <- receptor.NewActualLRPCreatedEvent(receptor.ActualLRPResponse{ProcessGuid: "web", Index: 2, State: receptor.ActualLRPStateUnclaimed, ModificationTag: tag(1)})
+			events <- receptor.NewActualLRPRemovedEvent(receptor.ActualLRPResponse{ProcessGuid: "web", Index: 0, ModificationTag: tag(1)})
+			events <- receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: "worker", ModificationTag: tag(1)})
+
+			Eventually(func() []receptor.ActualLRPResponse {
+				actualLRPs, _ := cachingClient.ActualLRPsByProcessGuid("web")
+				return actualLRPs
+			}).Should(Equal([]receptor.ActualLRPResponse{
+				{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateRunning, ModificationTag: tag(3)},
+				{ProcessGuid: "web", Index: 2, State: receptor.ActualLRPStateUnclaimed, ModificationTag: tag(1)},
+			}))
+			Eventually(func() int {
+				desiredLRPs, _ := cachingClient.DesiredLRPs()
+				return len(desiredLRPs)
+			}).Should(Equal(2))
+			desiredLRP, err := cachingClient.GetDesiredLRP("web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desiredLRP.Instances).To(Equal(3))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(1))
+		})
+
+		It("ignores events older than what it fetched", func() {
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			events <- receptor.NewActualLRPChangedEvent(
+				receptor.ActualLRPResponse{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateUnclaimed, ModificationTag: tag(0)},
+				receptor.ActualLRPResponse{ProcessGuid: "web", Index: 1, State: receptor.ActualLRPStateUnclaimed, ModificationTag: tag(1)},
+			)
+			events <- receptor.NewDesiredLRPCreatedEvent(receptor.DesiredLRPResponse{ProcessGuid: "worker", ModificationTag: tag(1)})
+
+			Eventually(func() int {
+				desiredLRPs, _ := cachingClient.DesiredLRPs()
+				return len(desiredLRPs)
+			}).Should(Equal(2))
+			actualLRPs, err := cachingClient.ActualLRPsByProcessGuid("web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualLRPs[1].State).To(Equal(receptor.ActualLRPStateClaimed))
+		})
+
+		It("returns the receptor's not found error for apps it doesn't hold", func() {
+			_, err := cachingClient.GetDesiredLRP("missing")
+
+			Expect(err).To(Equal(receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "Desired LRP with guid 'missing' not found"}))
+		})
+
+		It("refetches the LRPs after the resync interval", func() {
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			fakeClock.Increment(caching_receptor_client.ResyncInterval)
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(2))
+			Expect(fakeReceptorClient.SubscribeToEventsCallCount()).To(Equal(2))
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+		})
+
+		It("refetches the LRPs once the event stream fails", func() {
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			close(events)
+
+			Eventually(fakeEventSource.CloseCallCount).Should(Equal(1))
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(2))
+		})
+
+		It("sends reads to the receptor when the cache can't be filled", func() {
+			fakeReceptorClient.DesiredLRPsReturns(nil, errors.New("receptor down"))
+
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+			_, err := cachingClient.DesiredLRPs()
+
+			Expect(err).To(MatchError("receptor down"))
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(2))
+		})
+
+		It("stops caching when stopped", func() {
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			cachingClient.Stop()
+			Expect(cachingClient.ActualLRPs()).To(HaveLen(2))
+
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+// This file was generated by counterfeiter
+package fake_cache
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+)
+
+type FakeCache struct {
+	StartStub        func()
+	startMutex       sync.RWMutex
+	startArgsForCall []struct{}
+	StopStub         func()
+	stopMutex        sync.RWMutex
+	stopArgsForCall  []struct{}
+}
+
+func (fake *FakeCache) Start() {
+	fake.startMutex.Lock()
+	fake.startArgsForCall = append(fake.startArgsForCall, struct{}{})
+	fake.startMutex.Unlock()
+	if fake.StartStub != nil {
+		fake.StartStub()
+	}
+}
+
+func (fake *FakeCache) StartCallCount() int {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	return len(fake.startArgsForCall)
+}
+
+func (fake *FakeCache) Stop() {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct{}{})
+	fake.stopMutex.Unlock()
+	if fake.StopStub != nil {
+		fake.StopStub()
+	}
+}
+
+func (fake *FakeCache) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+var _ caching_receptor_client.Cache = new(FakeCache)
@@ -0,0 +1,244 @@
+package cancelable_receptor_client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+// ErrCanceled is returned for requests made, or still in flight, once the
+// client is canceled.
+var ErrCanceled = errors.New("the receptor request was canceled")
+
+// cancelableClient gives up on receptor requests once cancel is closed, such
+// as when ltc exits on Ctrl-C.  The receptor client has no way to abort a
+// request it has sent, so a request in flight is left to finish in the
+// background while its caller returns ErrCanceled straight away.  Event
+// streams are closed when cancel is closed.
+type cancelableClient struct {
+	cancel <-chan struct{}
+	client receptor.Client
+}
+
+func New(cancel <-chan struct{}, client receptor.Client) receptor.Client {
+	return &cancelableClient{cancel, client}
+}
+
+func (c *cancelableClient) CreateTask(request receptor.TaskCreateRequest) error {
+	return c.do(func() error {
+		return c.client.CreateTask(request)
+	})
+}
+
+func (c *cancelableClient) Tasks() ([]receptor.TaskResponse, error) {
+	var tasks []receptor.TaskResponse
+	if err := c.do(func() (err error) {
+		tasks, err = c.client.Tasks()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *cancelableClient) TasksByDomain(domain string) ([]receptor.TaskResponse, error) {
+	var tasks []receptor.TaskResponse
+	if err := c.do(func() (err error) {
+		tasks, err = c.client.TasksByDomain(domain)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *cancelableClient) GetTask(taskId string) (receptor.TaskResponse, error) {
+	var task receptor.TaskResponse
+	if err := c.do(func() (err error) {
+		task, err = c.client.GetTask(taskId)
+		return err
+	}); err != nil {
+		return receptor.TaskResponse{}, err
+	}
+	return task, nil
+}
+
+func (c *cancelableClient) DeleteTask(taskId string) error {
+	return c.do(func() error {
+		return c.client.DeleteTask(taskId)
+	})
+}
+
+func (c *cancelableClient) CancelTask(taskId string) error {
+	return c.do(func() error {
+		return c.client.CancelTask(taskId)
+	})
+}
+
+func (c *cancelableClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	return c.do(func() error {
+		return c.client.CreateDesiredLRP(request)
+	})
+}
+
+func (c *cancelableClient) GetDesiredLRP(processGuid string) (receptor.DesiredLRPResponse, error) {
+	var desiredLRP receptor.DesiredLRPResponse
+	if err := c.do(func() (err error) {
+		desiredLRP, err = c.client.GetDesiredLRP(processGuid)
+		return err
+	}); err != nil {
+		return receptor.DesiredLRPResponse{}, err
+	}
+	return desiredLRP, nil
+}
+
+func (c *cancelableClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	return c.do(func() error {
+		return c.client.UpdateDesiredLRP(processGuid, update)
+	})
+}
+
+func (c *cancelableClient) DeleteDesiredLRP(processGuid string) error {
+	return c.do(func() error {
+		return c.client.DeleteDesiredLRP(processGuid)
+	})
+}
+
+func (c *cancelableClient) DesiredLRPs() ([]receptor.DesiredLRPResponse, error) {
+	var desiredLRPs []receptor.DesiredLRPResponse
+	if err := c.do(func() (err error) {
+		desiredLRPs, err = c.client.DesiredLRPs()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return desiredLRPs, nil
+}
+
+func (c *cancelableClient) DesiredLRPsByDomain(domain string) ([]receptor.DesiredLRPResponse, error) {
+	var desiredLRPs []receptor.DesiredLRPResponse
+	if err := c.do(func() (err error) {
+		desiredLRPs, err = c.client.DesiredLRPsByDomain(domain)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return desiredLRPs, nil
+}
+
+func (c *cancelableClient) ActualLRPs() ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	if err := c.do(func() (err error) {
+		actualLRPs, err = c.client.ActualLRPs()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return actualLRPs, nil
+}
+
+func (c *cancelableClient) ActualLRPsByDomain(domain string) ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	if err := c.do(func() (err error) {
+		actualLRPs, err = c.client.ActualLRPsByDomain(domain)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return actualLRPs, nil
+}
+
+func (c *cancelableClient) ActualLRPsByProcessGuid(processGuid string) ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	if err := c.do(func() (err error) {
+		actualLRPs, err = c.client.ActualLRPsByProcessGuid(processGuid)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return actualLRPs, nil
+}
+
+func (c *cancelableClient) ActualLRPByProcessGuidAndIndex(processGuid string, index int) (receptor.ActualLRPResponse, error) {
+	var actualLRP receptor.ActualLRPResponse
+	if err := c.do(func() (err error) {
+		actualLRP, err = c.client.ActualLRPByProcessGuidAndIndex(processGuid, index)
+		return err
+	}); err != nil {
+		return receptor.ActualLRPResponse{}, err
+	}
+	return actualLRP, nil
+}
+
+func (c *cancelableClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	return c.do(func() error {
+		return c.client.KillActualLRPByProcessGuidAndIndex(processGuid, index)
+	})
+}
+
+func (c *cancelableClient) SubscribeToEvents() (receptor.EventSource, error) {
+	var eventSource receptor.EventSource
+	if err := c.do(func() (err error) {
+		eventSource, err = c.client.SubscribeToEvents()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	go func() {
+		<-c.cancel
+		eventSource.Close()
+	}()
+	return eventSource, nil
+}
+
+func (c *cancelableClient) Cells() ([]receptor.CellResponse, error) {
+	var cells []receptor.CellResponse
+	if err := c.do(func() (err error) {
+		cells, err = c.client.Cells()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return cells, nil
+}
+
+func (c *cancelableClient) UpsertDomain(domain string, ttl time.Duration) error {
+	return c.do(func() error {
+		return c.client.UpsertDomain(domain, ttl)
+	})
+}
+
+func (c *cancelableClient) Domains() ([]string, error) {
+	var domains []string
+	if err := c.do(func() (err error) {
+		domains, err = c.client.Domains()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// do returns the error from request, or ErrCanceled if the client is
+// canceled first.  Results set by request may only be read when do returns
+// nil, as a canceled request may still be writing them.
+func (c *cancelableClient) do(request func() error) error {
+	select {
+	case <-c.cancel:
+		return ErrCanceled
+	default:
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- request()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-c.cancel:
+		return ErrCanceled
+	}
+}
@@ -0,0 +1,13 @@
+package cancelable_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCancelableReceptorClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CancelableReceptorClient Suite")
+}
@@ -0,0 +1,78 @@
+package cancelable_receptor_client_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cancelable_receptor_client"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("CancelableReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		cancel             chan struct{}
+		client             receptor.Client
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		cancel = make(chan struct{})
+		client = cancelable_receptor_client.New(cancel, fakeReceptorClient)
+	})
+
+	It("passes requests and their results through", func() {
+		fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{{ProcessGuid: "app"}}, nil)
+		fakeReceptorClient.DeleteDesiredLRPReturns(errors.New("not found"))
+
+		Expect(client.DesiredLRPs()).To(Equal([]receptor.DesiredLRPResponse{{ProcessGuid: "app"}}))
+		Expect(client.DeleteDesiredLRP("app")).To(MatchError("not found"))
+		Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("app"))
+	})
+
+	It("returns as soon as it is canceled", func() {
+		blockChan := make(chan struct{})
+		defer close(blockChan)
+		fakeReceptorClient.DesiredLRPsStub = func() ([]receptor.DesiredLRPResponse, error) {
+			<-blockChan
+			return []receptor.DesiredLRPResponse{{ProcessGuid: "app"}}, nil
+		}
+
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := client.DesiredLRPs()
+			errChan <- err
+		}()
+
+		Eventually(fakeReceptorClient.DesiredLRPsCallCount).Should(Equal(1))
+		Consistently(errChan).ShouldNot(Receive())
+
+		close(cancel)
+
+		Eventually(errChan).Should(Receive(Equal(cancelable_receptor_client.ErrCanceled)))
+	})
+
+	It("doesn't send requests once it is canceled", func() {
+		close(cancel)
+
+		Expect(client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{})).To(Equal(cancelable_receptor_client.ErrCanceled))
+		Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(BeZero())
+	})
+
+	It("closes event streams when it is canceled", func() {
+		fakeEventSource := &fake_receptor.FakeEventSource{}
+		fakeReceptorClient.SubscribeToEventsReturns(fakeEventSource, nil)
+
+		eventSource, err := client.SubscribeToEvents()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(eventSource).To(Equal(fakeEventSource))
+		Expect(fakeEventSource.CloseCallCount()).To(BeZero())
+
+		close(cancel)
+
+		Eventually(fakeEventSource.CloseCallCount).Should(Equal(1))
+	})
+})
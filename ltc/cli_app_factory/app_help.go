@@ -74,17 +74,53 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("create"),
+					presentCommand("inspect-image"),
 					presentCommand("remove"),
 					presentCommand("scale"),
+					presentCommand("resize"),
+					presentCommand("stop"),
+					presentCommand("start"),
+					presentCommand("restart"),
+					presentCommand("wait"),
 					presentCommand("update-routes"),
+					presentCommand("map-route"),
+					presentCommand("unmap-route"),
+					presentCommand("label"),
+					presentCommand("group"),
+					presentCommand("env"),
+					presentCommand("set-env"),
+					presentCommand("unset-env"),
+					presentCommand("rollback"),
+				},
+			},
+		}, {
+			Name: "RUN APPS FROM SOURCE",
+			CommandSubGroups: [][]cmdPresenter{
+				{
+					presentCommand("build"),
+					presentCommand("launch-droplet"),
+					presentCommand("push"),
+				},
+			},
+		}, {
+			Name: "SECRETS",
+			CommandSubGroups: [][]cmdPresenter{
+				{
+					presentCommand("set-secret"),
+					presentCommand("list-secrets"),
 				},
 			},
 		}, {
 			Name: "TASKS",
 			CommandSubGroups: [][]cmdPresenter{
 				{
+					presentCommand("run"),
 					presentCommand("submit-task"),
 					presentCommand("task"),
+					presentCommand("list-tasks"),
+					presentCommand("task-result"),
+					presentCommand("cancel-task"),
+					presentCommand("retry-task"),
 					presentCommand("delete-task"),
 				},
 			},
@@ -93,6 +129,14 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("logs"),
+					presentCommand("events"),
+					presentCommand("notify"),
+				},
+				{
+					presentCommand("bind-log-drain"),
+					presentCommand("unbind-log-drain"),
+					presentCommand("log-drains"),
+					presentCommand("drain-logs"),
 				},
 			},
 		}, {
@@ -101,8 +145,14 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 				{
 					presentCommand("cells"),
 					presentCommand("list"),
+					presentCommand("routes"),
 					presentCommand("status"),
+					presentCommand("compare"),
+					presentCommand("metrics"),
+					presentCommand("recommend"),
+					presentCommand("top"),
 					presentCommand("visualize"),
+					presentCommand("export-metrics"),
 				},
 			},
 		}, {
@@ -110,6 +160,9 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("submit-lrp"),
+					presentCommand("agent"),
+					presentCommand("diff"),
+					presentCommand("evacuate-cell"),
 				},
 			},
 		}, {
@@ -117,7 +170,15 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("debug-logs"),
+					presentCommand("doctor"),
 					presentCommand("test"),
+					presentCommand("test-cluster"),
+					presentCommand("cluster-version"),
+					presentCommand("sync"),
+					presentCommand("completion"),
+					presentCommand("alias"),
+					presentCommand("config"),
+					presentCommand("history"),
 					presentCommand("help"),
 				},
 			},
@@ -4,43 +4,155 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
-
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/agent"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_events"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory/graphical"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cancelable_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/clientpool"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/config_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier/receptor_client_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/dav_blob_store"
+	"github.com/cloudfoundry-incubator/lattice/ltc/dry_run_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/emitting_receptor_client"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/integration_test"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logging_receptor_client"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/retrying_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+	"github.com/cloudfoundry-incubator/lattice/ltc/stamping_receptor_client"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader"
-	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/version"
 	"github.com/cloudfoundry/noaa"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 
+	agent_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/agent/command_factory"
+	app_events_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/app_events/command_factory"
 	app_examiner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/command_factory"
 	app_runner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/app_runner/command_factory"
+	audit_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/audit/command_factory"
+	cluster_examiner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner/command_factory"
+	cluster_tester_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester/command_factory"
+	completion_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/completion/command_factory"
 	config_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/config/command_factory"
+	doctor_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/doctor/command_factory"
+	droplet_runner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/command_factory"
 	integration_test_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/integration_test/command_factory"
 	logs_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/logs/command_factory"
+	metrics_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/metrics/command_factory"
+	secrets_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/secrets/command_factory"
 	task_examiner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/command_factory"
 	task_runner_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/task_runner/command_factory"
+	version_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/version/command_factory"
 )
 
 var (
 	nonTargetVerifiedCommandNames = map[string]struct{}{
-		config_command_factory.TargetCommandName: {},
+		config_command_factory.AliasCommandName:          {},
+		config_command_factory.DefaultsCommandName:       {},
+		config_command_factory.TargetCommandName:         {},
+		completion_command_factory.CompletionCommandName: {},
+		audit_command_factory.HistoryCommandName:         {},
+		version_command_factory.SyncCommandName:          {},
+		doctor_command_factory.DoctorCommandName:         {},
 		"help": {},
 	}
 
+	// auditedCommandNames are the commands that change what's running on
+	// the target, which are recorded for `ltc history`.  set-secret is left
+	// out so that secret values don't show up in the history, and set-env is
+	// recorded without its values; see auditedArgs.
+	auditedCommandNames = map[string]struct{}{
+		"agent":            {},
+		"bind-log-drain":   {},
+		"build":            {},
+		"cancel-task":      {},
+		"create":           {},
+		"delete-task":      {},
+		"group":            {},
+		"label":            {},
+		"launch-droplet":   {},
+		"map-route":        {},
+		"push":             {},
+		"remove":           {},
+		"resize":           {},
+		"restart":          {},
+		"retry-task":       {},
+		"rollback":         {},
+		"run":              {},
+		"scale":            {},
+		"set-env":          {},
+		"start":            {},
+		"stop":             {},
+		"submit-lrp":       {},
+		"submit-task":      {},
+		"unbind-log-drain": {},
+		"unmap-route":      {},
+		"unset-env":        {},
+		"update-routes":    {},
+	}
+
+	// revisionedCommandNames are the audited commands whose first arg names
+	// an app, a revision of which is recorded for `ltc rollback`.
+	revisionedCommandNames = map[string]struct{}{
+		"bind-log-drain":   {},
+		"create":           {},
+		"label":            {},
+		"launch-droplet":   {},
+		"map-route":        {},
+		"resize":           {},
+		"rollback":         {},
+		"scale":            {},
+		"set-env":          {},
+		"start":            {},
+		"stop":             {},
+		"unbind-log-drain": {},
+		"unmap-route":      {},
+		"unset-env":        {},
+		"update-routes":    {},
+	}
+
+	// createCommandNames are the commands that create apps, whose
+	// --memory-mb and --disk-mb flags take the user's defaults.
+	createCommandNames = map[string]struct{}{
+		"create":         {},
+		"launch-droplet": {},
+		"push":           {},
+	}
+
 	defaultAction = func(context *cli.Context) {
 		args := context.Args()
 		if len(args) > 0 {
@@ -76,7 +188,69 @@ func MakeCliApp(latticeVersion, ltcConfigRoot string, exitHandler exit_handler.E
 	ui := terminal.NewUI(os.Stdin, cliStdout, password_reader.NewPasswordReader(exitHandler))
 	app.Writer = ui
 
+	// The recorder stands in as every command's exit handler, so that it
+	// learns how audited commands exit.
+	auditLog := audit.NewFileLog(config_helpers.AuditLogFileLocation(ltcConfigRoot))
+	recorder := audit.NewRecorder(auditLog, exitHandler, clock.NewClock())
+
+	defaults := loadDefaults(ltcConfigRoot)
+	payloadEmitter := emitting_receptor_client.NewEmitter(clock.NewClock())
+
+	// cancel is closed when a command that talks to the cluster exits, e.g.
+	// on Ctrl-C, abandoning its requests to the receptor and registries and
+	// closing its log streams.
+	cancel := make(chan struct{})
+	var closeCancel sync.Once
+
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "assume-yes, y",
+			Usage: "Answers yes to confirmations, as does LTC_ASSUME_YES=true",
+		},
+		cli.StringFlag{
+			Name:  "emit-payloads",
+			Usage: "Also writes the JSON of each request that changes lattice to a file in DIR",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disables colored output",
+		},
+		cli.BoolFlag{
+			Name:  "quiet, q",
+			Usage: "Prints only errors and results",
+		},
+		cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "Also prints the requests ltc makes to the receptor",
+		},
+	}
+
 	app.Before = func(context *cli.Context) error {
+		ui.SetColorEnabled(ui.IsTerminal() && !context.GlobalBool("no-color") && os.Getenv("NO_COLOR") == "" && defaults.Bool("color", true))
+
+		// Prompts fail fast rather than block when ltc runs without a
+		// terminal, as in CI.
+		assumeYes, _ := strconv.ParseBool(os.Getenv("LTC_ASSUME_YES"))
+		ui.ConfigurePrompts(assumeYes || context.GlobalBool("assume-yes"), recorder)
+
+		if context.GlobalBool("quiet") && context.GlobalBool("verbose") {
+			ui.SayIncorrectUsage("--quiet and --verbose cannot be combined")
+			exitHandler.Exit(exit_codes.InvalidSyntax)
+			return errors.New("--quiet and --verbose cannot be combined")
+		} else if context.GlobalBool("quiet") {
+			ui.SetVerbosity(terminal.Quiet)
+		} else if context.GlobalBool("verbose") {
+			ui.SetVerbosity(terminal.Verbose)
+		}
+
+		if payloadDir := context.GlobalString("emit-payloads"); payloadDir != "" {
+			if err := payloadEmitter.SetDir(payloadDir); err != nil {
+				ui.SayLine("Error creating the payload directory: " + err.Error())
+				exitHandler.Exit(exit_codes.FileSystemError)
+				return err
+			}
+		}
+
 		args := context.Args()
 		command := app.Command(args.First())
 
@@ -88,68 +262,220 @@ func MakeCliApp(latticeVersion, ltcConfigRoot string, exitHandler exit_handler.E
 			return nil
 		}
 
+		if _, err := config.TLSConfig(); err != nil {
+			ui.Say(fmt.Sprintf("Error loading TLS settings for the target. Please run ltc target with valid TLS options.\n\tUnderlying error: %s", err.Error()))
+			exitHandler.Exit(exit_codes.BadTarget)
+			return err
+		}
+
 		if receptorUp, authorized, err := targetVerifier.VerifyTarget(config.Receptor()); !receptorUp {
 			ui.Say(fmt.Sprintf("Error connecting to the receptor. Make sure your lattice target is set, and that lattice is up and running.\n\tUnderlying error: %s", err.Error()))
+			exitHandler.Exit(exit_codes.NetworkError)
 			return err
 		} else if !authorized {
 			ui.Say("Could not authenticate with the receptor. Please run ltc target with the correct credentials.")
+			exitHandler.Exit(exit_codes.BadTarget)
 			return errors.New("Could not authenticate with the receptor.")
 		}
+
+		exitHandler.OnExit(func() {
+			closeCancel.Do(func() { close(cancel) })
+		})
+
+		if _, ok := auditedCommandNames[command.Name]; ok {
+			recorder.Start(config.Target(), command.Name, auditedArgs(command.Name, args))
+		}
 		return nil
 	}
 
 	app.Action = defaultAction
 	app.CommandNotFound = func(c *cli.Context, command string) {
 		ui.Say(fmt.Sprintf(unknownCommand, command))
-		exitHandler.Exit(1)
+		exitHandler.Exit(exit_codes.InvalidSyntax)
 	}
-	app.Commands = cliCommands(ltcConfigRoot, exitHandler, config, logger, targetVerifier, ui)
+	app.Commands = cliCommands(cancel, app.Version, ltcConfigRoot, recorder, config, defaults, logger, targetVerifier, ui, auditLog, payloadEmitter)
 	return app
 }
 
-func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, targetVerifier target_verifier.TargetVerifier, ui terminal.UI) []cli.Command {
+// recordSuccess finishes the recording App.Before started once the command
+// returns, along with a revision of the app it changed.  Failed commands
+// have already been recorded when they exited.
+func recordSuccess(command cli.Command, recorder *audit.Recorder, appRunner docker_app_runner.AppRunner, ui terminal.UI) func(*cli.Context) {
+	_, revisioned := revisionedCommandNames[command.Name]
+	finish := func(appName string) error {
+		if revisioned {
+			if definition, err := appRunner.AppDefinition(appName); err == nil {
+				return recorder.FinishWithRevision(definition)
+			}
+		}
+		return recorder.Finish(0)
+	}
+
+	return func(context *cli.Context) {
+		command.Action(context)
+		if !recorder.Started() {
+			return
+		}
+		if err := finish(context.Args().First()); err != nil {
+			ui.SayLine("Error writing to the history: " + err.Error())
+		}
+	}
+}
 
-	receptorClient := receptor.NewClient(config.Receptor())
-	noaaConsumer := noaa.NewConsumer(LoggregatorUrl(config.Loggregator()), nil, nil)
-	appRunner := docker_app_runner.New(receptorClient, config.Target())
+func cliCommands(cancel <-chan struct{}, latticeVersion, ltcConfigRoot string, exitHandler *audit.Recorder, config *config.Config, defaults *config.Defaults, logger lager.Logger, targetVerifier target_verifier.TargetVerifier, ui terminal.UI, auditLog audit.Log, payloadEmitter *emitting_receptor_client.Emitter) []cli.Command {
+
+	tlsConfig, _ := config.TLSConfig()
+	// Payloads are emitted outside the retries, once per request whatever
+	// it took to send, and after stamping, as sent.
+	uncanceledReceptorClient := stamping_receptor_client.New(
+		emitting_receptor_client.New(
+			retrying_receptor_client.New(
+				logging_receptor_client.New(receptor_client_factory.MakePooledReceptorClient(config.Receptor(), clientpool.New(receptorPoolConfig(), tlsConfig, clock.NewClock()), tlsConfig, receptorTrace(), clock.NewClock()), ui),
+				receptorRetryConfig(),
+				clock.NewClock(),
+			),
+			payloadEmitter,
+			ui,
+		),
+		clock.NewClock(),
+		deploymentUser(),
+	)
+	receptorClient := cancelable_receptor_client.New(cancel, uncanceledReceptorClient)
+
+	loggregatorUrl := LoggregatorUrl(config.Loggregator())
+	if tlsConfig != nil {
+		loggregatorUrl = SecureLoggregatorUrl(config.Loggregator())
+	}
+	noaaConsumer := noaa.NewConsumer(loggregatorUrl, tlsConfig, nil)
+	// Apps' routes are under the target unless another domain is set.
+	domain := defaults.String("domain", config.Target())
+	reservedAppIds := reserved_app_ids.Registry(defaults.List("reserved-names"))
+	appRunner := docker_app_runner.New(receptorClient, domain, reservedAppIds)
 
 	clock := clock.NewClock()
 
-	logReader := logs.NewLogReader(noaaConsumer)
-	tailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(ui, logReader)
+	tailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(ui, func() logs.LogReader {
+		return logs.NewLogReader(cancel, noaa.NewConsumer(loggregatorUrl, tlsConfig, nil))
+	}, clock)
 
 	taskExaminer := task_examiner.New(receptorClient)
-	taskExaminerCommandFactory := task_examiner_command_factory.NewTaskExaminerCommandFactory(taskExaminer, ui, exitHandler)
+	ltcNotifier := notifier.New(&http.Client{Timeout: 10 * time.Second})
+	taskExaminerCommandFactory := task_examiner_command_factory.NewTaskExaminerCommandFactory(taskExaminer, ui, clock, exitHandler, ltcNotifier)
 
-	taskRunner := task_runner.New(receptorClient, taskExaminer)
+	taskRunner := task_runner.New(receptorClient, taskExaminer, reservedAppIds)
 	taskRunnerCommandFactory := task_runner_command_factory.NewTaskRunnerCommandFactory(taskRunner, ui, exitHandler)
 
-	appExaminer := app_examiner.New(receptorClient, app_examiner.NewNoaaConsumer(noaaConsumer))
+	secretStore := secrets.New(receptorClient, config)
+	secretsCommandFactory := secrets_command_factory.NewSecretsCommandFactory(secretStore, config, ui, exitHandler)
+
+	cachingReceptorClient := caching_receptor_client.New(receptorClient, clock)
+	appExaminer := app_examiner.New(cachingReceptorClient, app_examiner.NewNoaaConsumer(noaaConsumer))
+	clusterExaminer := cluster_examiner.New(appExaminer, app_examiner.NewNoaaConsumer(noaaConsumer))
+
 	graphicalVisualizer := graphical.NewGraphicalVisualizer(appExaminer)
-	appExaminerCommandFactory := app_examiner_command_factory.NewAppExaminerCommandFactory(appExaminer, ui, clock, exitHandler, graphicalVisualizer, taskExaminer)
+	appExaminerCommandFactory := app_examiner_command_factory.NewAppExaminerCommandFactory(appExaminer, ui, clock, exitHandler, graphicalVisualizer, taskExaminer, clusterExaminer, cachingReceptorClient)
+
+	clusterExaminerCommandFactory := cluster_examiner_command_factory.NewClusterExaminerCommandFactory(clusterExaminer, ui, clock, exitHandler, cachingReceptorClient)
 
 	appRunnerCommandFactoryConfig := app_runner_command_factory.AppRunnerCommandFactoryConfig{
 		AppRunner:             appRunner,
+		DryRunAppRunner:       docker_app_runner.New(dry_run_receptor_client.New(receptorClient, ui), domain, reservedAppIds),
+		CleanupAppRunner:      docker_app_runner.New(uncanceledReceptorClient, domain, reservedAppIds),
 		AppExaminer:           appExaminer,
-		DockerMetadataFetcher: docker_metadata_fetcher.New(docker_metadata_fetcher.NewDockerSessionFactory()),
+		DockerMetadataFetcher: docker_metadata_fetcher.New(docker_metadata_fetcher.NewDockerSessionFactory(), docker_metadata_fetcher.NewDockerRegistryV2(cancel, &http.Client{Timeout: 30 * time.Second})),
+		SecretStore:           secretStore,
 		UI:                  ui,
-		Domain:              config.Target(),
+		Domain:              domain,
 		Env:                 os.Environ(),
 		Clock:               clock,
 		Logger:              logger,
 		TailedLogsOutputter: tailedLogsOutputter,
 		ExitHandler:         exitHandler,
+		AuditLog:            auditLog,
+		PollInterval:        app_runner_command_factory.DefaultPollInterval,
+		MaxPollInterval:     5 * time.Second,
+		TaskRunner:          task_runner.New(uncanceledReceptorClient, task_examiner.New(uncanceledReceptorClient), reservedAppIds),
+		TaskExaminer:        taskExaminer,
+		ClusterExaminer:     clusterExaminer,
+		Cache:               cachingReceptorClient,
 	}
 
 	appRunnerCommandFactory := app_runner_command_factory.NewAppRunnerCommandFactory(appRunnerCommandFactoryConfig)
 
-	logsCommandFactory := logs_command_factory.NewLogsCommandFactory(appExaminer, ui, tailedLogsOutputter, exitHandler)
-
-	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, ui, targetVerifier, exitHandler)
+	blobStore := dav_blob_store.New(config.BlobStore(), &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}})
+	dropletRunner := droplet_runner.New(appRunner, taskRunner, blobStore, domain)
+	dropletRunnerCommandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(droplet_runner_command_factory.DropletRunnerCommandFactoryConfig{
+		DropletRunner:       dropletRunner,
+		TaskExaminer:        taskExaminer,
+		TaskRunner:          taskRunner,
+		AppExaminer:         appExaminer,
+		UI:                  ui,
+		Domain:              domain,
+		Clock:               clock,
+		TailedLogsOutputter: tailedLogsOutputter,
+		ExitHandler:         exitHandler,
+	})
+
+	logForwarder := log_drain.NewForwarder(func() logs.LogReader {
+		return logs.NewLogReader(cancel, noaa.NewConsumer(loggregatorUrl, tlsConfig, nil))
+	}, func(drainURL string) (log_drain.Drain, error) {
+		return log_drain.New(drainURL, nil)
+	}, func(appGuid string, err error) {
+		ui.SayLine(fmt.Sprintf("Error forwarding the logs of %s: %s", appGuid, err))
+	})
+	logsCommandFactory := logs_command_factory.NewLogsCommandFactory(appExaminer, ui, tailedLogsOutputter, logForwarder, clock, exitHandler)
+
+	metricsReader := metrics.NewMetricsReader(noaa.NewConsumer(loggregatorUrl, tlsConfig, nil))
+	metricsCommandFactory := metrics_command_factory.NewMetricsCommandFactory(appExaminer, metricsReader, ui, clock, exitHandler)
+
+	appEventSubscriber := app_events.NewAppEventSubscriber(receptorClient, clock)
+	appEventsCommandFactory := app_events_command_factory.NewAppEventsCommandFactory(appEventSubscriber, ui, exitHandler)
+	notifyCommandFactory := app_events_command_factory.NewNotifyCommandFactory(appEventSubscriber, loadWebhooks(ltcConfigRoot), ltcNotifier, ui, exitHandler)
+
+	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, ui, targetVerifier, exitHandler, latticeVersion)
+	aliasCommandFactory := config_command_factory.NewAliasCommandFactory(loadAliases(ltcConfigRoot), ui, exitHandler)
+	defaultsCommandFactory := config_command_factory.NewDefaultsCommandFactory(defaults, ui, exitHandler)
+
+	ltcAgent := agent.New(agent.AgentConfig{
+		AppRunner:      appRunner,
+		AppExaminer:    appExaminer,
+		ReservedAppIds: reservedAppIds,
+		Clock:          clock,
+	})
+	agentCommandFactory := agent_command_factory.NewAgentCommandFactory(ltcAgent, ui, clock, exitHandler)
+
+	completionCommandFactory := completion_command_factory.NewCompletionCommandFactory(appExaminer, taskExaminer, ui, exitHandler)
+
+	historyCommandFactory := audit_command_factory.NewHistoryCommandFactory(auditLog, ui, exitHandler)
+
+	ltcDoctor := doctor.New(doctor.DoctorConfig{
+		Config:         config,
+		ConfigPath:     config_helpers.ConfigFileLocation(ltcConfigRoot),
+		TargetVerifier: targetVerifier,
+		LookupHost:     net.LookupHost,
+		Clock:          clock,
+		Timeout:        10 * time.Second,
+		DockerHubURL:   doctor.DockerHubURL,
+	})
+	doctorCommandFactory := doctor_command_factory.NewDoctorCommandFactory(ltcDoctor, ui, exitHandler)
+
+	syncCommandFactory := version_command_factory.NewSyncCommandFactory(version.NewSyncer(&http.Client{}), ui, exitHandler, version.LtcPath(), runtime.GOOS)
 
 	testRunner := integration_test.NewIntegrationTestRunner(config, ltcConfigRoot)
 	integrationTestCommandFactory := integration_test_command_factory.NewIntegrationTestCommandFactory(testRunner)
 
+	clusterTester := cluster_tester.New(cluster_tester.ClusterTesterConfig{
+		AppRunner:    appRunner,
+		AppExaminer:  appExaminer,
+		LogReader:    logs.NewLogReader(cancel, noaaConsumer),
+		TaskRunner:   taskRunner,
+		TaskExaminer: taskExaminer,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		Clock:        clock,
+		Domain:       domain,
+	})
+	clusterTesterCommandFactory := cluster_tester_command_factory.NewClusterTesterCommandFactory(clusterTester, ui, exitHandler, config.Target())
+
 	helpCommand := cli.Command{
 		Name:        "help",
 		Aliases:     []string{"h"},
@@ -158,31 +484,206 @@ func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, con
 		Action:      defaultAction,
 	}
 
-	return []cli.Command{
+	commands := []cli.Command{
+		agentCommandFactory.MakeAgentCommand(),
+		aliasCommandFactory.MakeAliasCommand(),
+		appRunnerCommandFactory.MakeBindLogDrainCommand(),
+		dropletRunnerCommandFactory.MakeBuildDropletCommand(),
 		appExaminerCommandFactory.MakeCellsCommand(),
+		configCommandFactory.MakeClusterVersionCommand(),
+		appRunnerCommandFactory.MakeCompareCommand(),
+		completionCommandFactory.MakeCompletionCommand(),
+		defaultsCommandFactory.MakeConfigCommand(),
 		appRunnerCommandFactory.MakeCreateAppCommand(),
 		appRunnerCommandFactory.MakeSubmitLrpCommand(),
 		logsCommandFactory.MakeDebugLogsCommand(),
+		agentCommandFactory.MakeDiffCommand(),
+		doctorCommandFactory.MakeDoctorCommand(),
+		logsCommandFactory.MakeDrainLogsCommand(),
+		appRunnerCommandFactory.MakeEnvCommand(),
+		clusterExaminerCommandFactory.MakeEvacuateCellCommand(),
+		appEventsCommandFactory.MakeEventsCommand(),
+		clusterExaminerCommandFactory.MakeExportMetricsCommand(),
+		appRunnerCommandFactory.MakeGroupCommand(),
+		historyCommandFactory.MakeHistoryCommand(),
+		appRunnerCommandFactory.MakeInspectImageCommand(),
+		appRunnerCommandFactory.MakeLabelAppCommand(),
+		dropletRunnerCommandFactory.MakeLaunchDropletCommand(),
 		appExaminerCommandFactory.MakeListAppCommand(),
+		taskExaminerCommandFactory.MakeListTasksCommand(),
+		appRunnerCommandFactory.MakeLogDrainsCommand(),
 		logsCommandFactory.MakeLogsCommand(),
+		appRunnerCommandFactory.MakeMapRouteCommand(),
+		metricsCommandFactory.MakeMetricsCommand(),
+		metricsCommandFactory.MakeRecommendCommand(),
+		notifyCommandFactory.MakeNotifyCommand(),
+		dropletRunnerCommandFactory.MakePushCommand(),
 		appRunnerCommandFactory.MakeRemoveAppCommand(),
+		appRunnerCommandFactory.MakeResizeAppCommand(),
+		appRunnerCommandFactory.MakeRestartInstanceCommand(),
+		appRunnerCommandFactory.MakeRollbackAppCommand(),
+		appExaminerCommandFactory.MakeRoutesCommand(),
+		appRunnerCommandFactory.MakeRunCommand(),
 		appRunnerCommandFactory.MakeScaleAppCommand(),
+		appRunnerCommandFactory.MakeSetEnvCommand(),
+		secretsCommandFactory.MakeSetSecretCommand(),
+		secretsCommandFactory.MakeListSecretsCommand(),
+		appRunnerCommandFactory.MakeStartAppCommand(),
 		appExaminerCommandFactory.MakeStatusCommand(),
+		appRunnerCommandFactory.MakeStopAppCommand(),
 		taskRunnerCommandFactory.MakeSubmitTaskCommand(),
+		syncCommandFactory.MakeSyncCommand(),
 		configCommandFactory.MakeTargetCommand(),
 		taskExaminerCommandFactory.MakeTaskCommand(),
+		taskExaminerCommandFactory.MakeTaskResultCommand(),
 		taskRunnerCommandFactory.MakeDeleteTaskCommand(),
+		taskRunnerCommandFactory.MakeCancelTaskCommand(),
+		taskRunnerCommandFactory.MakeRetryTaskCommand(),
 		integrationTestCommandFactory.MakeIntegrationTestCommand(),
+		clusterTesterCommandFactory.MakeTestClusterCommand(),
+		clusterExaminerCommandFactory.MakeTopCommand(),
+		appRunnerCommandFactory.MakeUnbindLogDrainCommand(),
+		appRunnerCommandFactory.MakeUnmapRouteCommand(),
+		appRunnerCommandFactory.MakeUnsetEnvCommand(),
 		appRunnerCommandFactory.MakeUpdateRoutesCommand(),
 		appExaminerCommandFactory.MakeVisualizeCommand(),
+		appRunnerCommandFactory.MakeWaitCommand(),
 		helpCommand,
 	}
+
+	for index, command := range commands {
+		commands[index].Flags = applyDefaults(command, defaults)
+		if _, ok := auditedCommandNames[command.Name]; ok {
+			commands[index].Action = recordSuccess(command, exitHandler, appRunner, ui)
+		}
+	}
+	return commands
+}
+
+// applyDefaults returns the command's flags with the values of those the
+// user has set defaults for.  The defaults are shown in the command's help,
+// and explicit flags still take precedence.
+func applyDefaults(command cli.Command, defaults *config.Defaults) []cli.Flag {
+	flags := make([]cli.Flag, len(command.Flags))
+	for index, flag := range command.Flags {
+		flags[index] = flag
+		switch flag := flag.(type) {
+		case cli.IntFlag:
+			name := strings.Split(flag.Name, ",")[0]
+			if _, ok := createCommandNames[command.Name]; ok && (name == "memory-mb" || name == "disk-mb") {
+				flag.Value = defaults.Int(name, flag.Value)
+				flags[index] = flag
+			}
+		case cli.DurationFlag:
+			if strings.Split(flag.Name, ",")[0] == "timeout" {
+				flag.Value = defaults.Duration("timeout", flag.Value)
+				flags[index] = flag
+			}
+		}
+	}
+	return flags
+}
+
+func loadDefaults(ltcConfigRoot string) *config.Defaults {
+	defaults := config.NewDefaults(persister.NewFilePersister(config_helpers.DefaultsFileLocation(ltcConfigRoot)))
+	defaults.Load()
+	return defaults
+}
+
+func loadAliases(ltcConfigRoot string) *config.Aliases {
+	aliases := config.NewAliases(persister.NewFilePersister(config_helpers.AliasesFileLocation(ltcConfigRoot)))
+	aliases.Load()
+	return aliases
+}
+
+func loadWebhooks(ltcConfigRoot string) *config.Webhooks {
+	webhooks := config.NewWebhooks(persister.NewFilePersister(config_helpers.WebhooksFileLocation(ltcConfigRoot)))
+	webhooks.Load()
+	return webhooks
+}
+
+// auditedArgs are the args a command is recorded with: set-env's NAME=VALUE
+// args are recorded as just NAME, which rerunning it takes from ltc's
+// environment, so that their values don't show up in the history.
+func auditedArgs(commandName string, args []string) []string {
+	if commandName != "set-env" {
+		return args
+	}
+
+	recorded := make([]string, len(args))
+	for i, arg := range args {
+		if i > 1 && !strings.HasPrefix(arg, "-") {
+			arg = strings.SplitN(arg, "=", 2)[0]
+		}
+		recorded[i] = arg
+	}
+	return recorded
+}
+
+// receptorRetryConfig lets LTC_RECEPTOR_MAX_ATTEMPTS override how many
+// times a request that failed transiently is attempted.
+func receptorRetryConfig() retrying_receptor_client.Config {
+	retryConfig := retrying_receptor_client.Config{
+		MaxAttempts: retrying_receptor_client.DefaultMaxAttempts,
+		BaseDelay:   retrying_receptor_client.DefaultBaseDelay,
+		MaxDelay:    retrying_receptor_client.DefaultMaxDelay,
+	}
+	if maxAttempts, err := strconv.Atoi(os.Getenv("LTC_RECEPTOR_MAX_ATTEMPTS")); err == nil && maxAttempts > 0 {
+		retryConfig.MaxAttempts = maxAttempts
+	}
+	return retryConfig
+}
+
+// receptorPoolConfig bounds the requests in flight with
+// LTC_RECEPTOR_MAX_CONNECTIONS, and how many start each second with
+// LTC_RECEPTOR_RATE_LIMIT.
+func receptorPoolConfig() clientpool.Config {
+	poolConfig := clientpool.Config{MaxConnections: clientpool.DefaultMaxConnections}
+	if maxConnections, err := strconv.Atoi(os.Getenv("LTC_RECEPTOR_MAX_CONNECTIONS")); err == nil && maxConnections > 0 {
+		poolConfig.MaxConnections = maxConnections
+	}
+	if rateLimit, err := strconv.ParseFloat(os.Getenv("LTC_RECEPTOR_RATE_LIMIT"), 64); err == nil && rateLimit > 0 {
+		poolConfig.RequestsPerSecond = rateLimit
+	}
+	return poolConfig
+}
+
+// receptorTrace is where the receptor requests are traced: stderr when
+// LTC_TRACE is true, or the file it names, appended to.  Without LTC_TRACE,
+// requests aren't traced.
+func receptorTrace() io.Writer {
+	trace := os.Getenv("LTC_TRACE")
+	if enabled, err := strconv.ParseBool(trace); err == nil || trace == "" {
+		if enabled {
+			return os.Stderr
+		}
+		return nil
+	}
+
+	traceFile, err := os.OpenFile(trace, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening LTC_TRACE file, tracing to stderr instead: %s\n", err)
+		return os.Stderr
+	}
+	return traceFile
+}
+
+// deploymentUser is who apps are stamped as created or updated by.
+func deploymentUser() string {
+	if currentUser, err := user.Current(); err == nil && currentUser.Username != "" {
+		return currentUser.Username
+	}
+	return os.Getenv("USER")
 }
 
 func LoggregatorUrl(loggregatorTarget string) string {
 	return "ws://" + loggregatorTarget
 }
 
+func SecureLoggregatorUrl(loggregatorTarget string) string {
+	return "wss://" + loggregatorTarget
+}
+
 func defaultVersion(latticeVersion string) string {
 	if latticeVersion == "" {
 		return "development (not versioned)"
@@ -210,6 +711,11 @@ COMMANDS:
    {{range .}} {{.Name}}   {{.Description}}
    {{end}}{{end}}{{end}}
 GLOBAL OPTIONS:
+   --assume-yes, -y     Answer yes to confirmations (also set by LTC_ASSUME_YES=true)
+   --emit-payloads DIR  Also write the JSON of each request that changes lattice to a file in DIR
+   --no-color           Disable colored output (also disabled by NO_COLOR or when output is not a terminal)
+   --quiet, -q          Print only errors and results
+   --verbose            Also print the requests ltc makes to the receptor
    --version, -v        Print the version 
    --help, -h           Show help 
 `
@@ -3,21 +3,30 @@ package cli_app_factory_test
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/audit"
 	"github.com/cloudfoundry-incubator/lattice/ltc/cli_app_factory"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier/fake_target_verifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-golang/lager"
 
+	completion_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/completion/command_factory"
 	config_command_factory "github.com/cloudfoundry-incubator/lattice/ltc/config/command_factory"
 )
 
@@ -32,6 +41,7 @@ var _ = Describe("CliAppFactory", func() {
 		cliApp             *cli.App
 		cliConfig          *config.Config
 		latticeVersion     string
+		ltcConfigRoot      string
 	)
 
 	BeforeEach(func() {
@@ -42,12 +52,13 @@ var _ = Describe("CliAppFactory", func() {
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
 		cliConfig = config.New(memPersister)
 		latticeVersion = "v0.2.Test"
+		ltcConfigRoot = "~/"
 	})
 
 	JustBeforeEach(func() {
 		cliApp = cli_app_factory.MakeCliApp(
 			latticeVersion,
-			"~/",
+			ltcConfigRoot,
 			fakeExitHandler,
 			cliConfig,
 			lager.NewLogger("test"),
@@ -86,6 +97,51 @@ var _ = Describe("CliAppFactory", func() {
 			})
 		})
 
+		Context("when the user has set defaults", func() {
+			flagValue := func(commandName, flagName string) interface{} {
+				for _, flag := range cliApp.Command(commandName).Flags {
+					switch flag := flag.(type) {
+					case cli.IntFlag:
+						if flag.Name == flagName {
+							return flag.Value
+						}
+					case cli.DurationFlag:
+						if flag.Name == flagName {
+							return flag.Value
+						}
+					}
+				}
+				return nil
+			}
+
+			BeforeEach(func() {
+				var err error
+				ltcConfigRoot, err = ioutil.TempDir("", "ltc-home")
+				Expect(err).NotTo(HaveOccurred())
+
+				defaults := config.NewDefaults(persister.NewFilePersister(filepath.Join(ltcConfigRoot, ".lattice", "defaults.json")))
+				Expect(defaults.Set("memory-mb", "256")).To(Succeed())
+				Expect(defaults.Set("timeout", "5m")).To(Succeed())
+				Expect(defaults.Save()).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(ltcConfigRoot)).To(Succeed())
+			})
+
+			It("uses them as the values of the flags", func() {
+				Expect(flagValue("create", "memory-mb, m")).To(Equal(256))
+				Expect(flagValue("push", "memory-mb, m")).To(Equal(256))
+				Expect(flagValue("create", "disk-mb, d")).To(Equal(0))
+				Expect(flagValue("create", "timeout, t")).To(Equal(5 * time.Minute))
+				Expect(flagValue("scale", "timeout, t")).To(Equal(5 * time.Minute))
+			})
+
+			It("leaves flags that change a limit alone", func() {
+				Expect(flagValue("resize", "memory-mb, m")).To(Equal(0))
+			})
+		})
+
 		Describe("App.Action", func() {
 			Context("when ltc is run without argument(s)", func() {
 				It("prints app help", func() {
@@ -98,6 +154,17 @@ var _ = Describe("CliAppFactory", func() {
 
 					Expect(outputBuffer).To(test_helpers.Say("ltc - Command line interface for Lattice."))
 				})
+
+				It("lists the global options", func() {
+					flagSet := flag.NewFlagSet("flag_set", flag.ContinueOnError)
+					flagSet.Parse([]string{})
+					testContext := cli.NewContext(cliApp, flagSet, &flag.FlagSet{})
+
+					cliApp.Action(testContext)
+
+					Expect(outputBuffer).To(test_helpers.Say("GLOBAL OPTIONS:"))
+					Expect(outputBuffer).To(test_helpers.SayLine("   --assume-yes, -y     Answer yes to confirmations (also set by LTC_ASSUME_YES=true)"))
+				})
 			})
 
 			Context("when ltc is run with argument(s)", func() {
@@ -120,11 +187,193 @@ var _ = Describe("CliAppFactory", func() {
 				cliApp.CommandNotFound(testContext, "do_it")
 
 				Expect(outputBuffer).To(test_helpers.Say("ltc: 'do_it' is not a registered command. See 'ltc help'\n\n"))
-				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{1}))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 		})
 
 		Describe("App.Before", func() {
+			Context("when output is not a terminal", func() {
+				It("strips colors from the output", func() {
+					cliApp.Commands = []cli.Command{
+						cli.Command{
+							Name: config_command_factory.TargetCommandName,
+							Action: func(ctx *cli.Context) {
+								fmt.Fprint(cliApp.Writer, colors.Red("no colors here"))
+							},
+						},
+					}
+
+					err := cliApp.Run([]string{"ltc", "--no-color", config_command_factory.TargetCommandName})
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(outputBuffer.Contents())).To(Equal("no colors here"))
+				})
+			})
+
+			Context("when confirmations are assumed", func() {
+				var confirmed bool
+
+				JustBeforeEach(func() {
+					confirmed = false
+					cliApp.Commands = []cli.Command{
+						cli.Command{
+							Name: config_command_factory.TargetCommandName,
+							Action: func(ctx *cli.Context) {
+								confirmed = cliApp.Writer.(terminal.UI).PromptForConfirmation("Really?")
+							},
+						},
+					}
+				})
+
+				AfterEach(func() {
+					os.Unsetenv("LTC_ASSUME_YES")
+				})
+
+				It("answers yes with --assume-yes", func() {
+					Expect(cliApp.Run([]string{"ltc", "--assume-yes", config_command_factory.TargetCommandName})).To(Succeed())
+					Expect(confirmed).To(BeTrue())
+				})
+
+				It("answers yes with LTC_ASSUME_YES", func() {
+					os.Setenv("LTC_ASSUME_YES", "true")
+
+					Expect(cliApp.Run([]string{"ltc", config_command_factory.TargetCommandName})).To(Succeed())
+					Expect(confirmed).To(BeTrue())
+				})
+			})
+
+			Context("when --quiet or --verbose is passed", func() {
+				var verbosity terminal.Verbosity
+
+				BeforeEach(func() {
+					verbosity = terminal.Normal
+				})
+
+				JustBeforeEach(func() {
+					cliApp.Commands = []cli.Command{
+						cli.Command{
+							Name: config_command_factory.TargetCommandName,
+							Action: func(ctx *cli.Context) {
+								verbosity = cliApp.Writer.(terminal.UI).Verbosity()
+							},
+						},
+					}
+				})
+
+				It("makes the output quiet", func() {
+					Expect(cliApp.Run([]string{"ltc", "-q", config_command_factory.TargetCommandName})).To(Succeed())
+					Expect(verbosity).To(Equal(terminal.Quiet))
+				})
+
+				It("makes the output verbose", func() {
+					Expect(cliApp.Run([]string{"ltc", "--verbose", config_command_factory.TargetCommandName})).To(Succeed())
+					Expect(verbosity).To(Equal(terminal.Verbose))
+				})
+
+				It("leaves -v to --version", func() {
+					Expect(cliApp.Run([]string{"ltc", "-v"})).To(Succeed())
+
+					Expect(outputBuffer).To(test_helpers.Say("ltc version v0.2.Test"))
+					Expect(verbosity).To(Equal(terminal.Normal))
+				})
+
+				It("rejects both at once", func() {
+					err := cliApp.Run([]string{"ltc", "--quiet", "--verbose", config_command_factory.TargetCommandName})
+
+					Expect(err).To(HaveOccurred())
+					Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --quiet and --verbose cannot be combined"))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				})
+			})
+
+			Context("when --emit-payloads is passed", func() {
+				var tmpDir string
+
+				BeforeEach(func() {
+					var err error
+					tmpDir, err = ioutil.TempDir("", "payloads")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					Expect(os.RemoveAll(tmpDir)).To(Succeed())
+				})
+
+				JustBeforeEach(func() {
+					cliApp.Commands = []cli.Command{
+						cli.Command{
+							Name:   config_command_factory.TargetCommandName,
+							Action: func(ctx *cli.Context) {},
+						},
+					}
+				})
+
+				It("creates the payload directory", func() {
+					payloadDir := filepath.Join(tmpDir, "bundle")
+
+					Expect(cliApp.Run([]string{"ltc", "--emit-payloads", payloadDir, config_command_factory.TargetCommandName})).To(Succeed())
+
+					Expect(payloadDir).To(BeADirectory())
+				})
+
+				It("exits when the payload directory can't be created", func() {
+					notADir := filepath.Join(tmpDir, "file")
+					Expect(ioutil.WriteFile(notADir, []byte{}, 0600)).To(Succeed())
+
+					err := cliApp.Run([]string{"ltc", "--emit-payloads", filepath.Join(notADir, "bundle"), config_command_factory.TargetCommandName})
+
+					Expect(err).To(HaveOccurred())
+					Expect(outputBuffer).To(test_helpers.Say("Error creating the payload directory: "))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+				})
+			})
+
+			Context("when running a command that changes the target", func() {
+				BeforeEach(func() {
+					var err error
+					ltcConfigRoot, err = ioutil.TempDir("", "ltc-home")
+					Expect(err).NotTo(HaveOccurred())
+
+					fakeTargetVerifier.VerifyTargetReturns(true, true, nil)
+					cliConfig.SetTarget("my-lattice.example.com")
+					cliConfig.Save()
+				})
+
+				AfterEach(func() {
+					Expect(os.RemoveAll(ltcConfigRoot)).To(Succeed())
+				})
+
+				It("records the command and how it exited in the history", func() {
+					Expect(cliApp.Run([]string{"ltc", "scale", "some-app"})).To(Succeed())
+
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+
+					entries, err := audit.NewFileLog(filepath.Join(ltcConfigRoot, ".lattice", "audit.log")).Entries()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entries).To(HaveLen(1))
+					Expect(entries[0].Target).To(Equal("my-lattice.example.com"))
+					Expect(entries[0].Command).To(Equal("scale"))
+					Expect(entries[0].Args).To(Equal([]string{"scale", "some-app"}))
+					Expect(entries[0].ExitCode).To(Equal(exit_codes.InvalidSyntax))
+				})
+
+				It("records set-env without the values it sets", func() {
+					Expect(cliApp.Run([]string{"ltc", "set-env", "some-app", "DB_PASSWORD=hunter2", "LOG_LEVEL"})).To(Succeed())
+
+					entries, err := audit.NewFileLog(filepath.Join(ltcConfigRoot, ".lattice", "audit.log")).Entries()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entries).To(HaveLen(1))
+					Expect(entries[0].Args).To(Equal([]string{"set-env", "some-app", "DB_PASSWORD", "LOG_LEVEL"}))
+				})
+
+				It("does not record commands that only read", func() {
+					Expect(cliApp.Run([]string{"ltc", "history"})).To(Succeed())
+
+					_, err := os.Stat(filepath.Join(ltcConfigRoot, ".lattice", "audit.log"))
+					Expect(os.IsNotExist(err)).To(BeTrue())
+				})
+			})
+
 			Context("when running the target command", func() {
 				It("does not verify the current target", func() {
 					cliConfig.SetTarget("my-lattice.example.com")
@@ -151,6 +400,32 @@ var _ = Describe("CliAppFactory", func() {
 				})
 			})
 
+			Context("when running the completion command", func() {
+				It("does not verify the current target", func() {
+					cliConfig.SetTarget("my-lattice.example.com")
+					cliConfig.Save()
+
+					commandRan := false
+
+					cliApp.Commands = []cli.Command{
+						cli.Command{
+							Name: completion_command_factory.CompletionCommandName,
+							Action: func(ctx *cli.Context) {
+								commandRan = true
+							},
+						},
+					}
+
+					cliAppArgs := []string{"ltc", completion_command_factory.CompletionCommandName, "bash"}
+
+					err := cliApp.Run(cliAppArgs)
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+					Expect(commandRan).To(BeTrue())
+				})
+			})
+
 			Context("when running the help command", func() {
 				It("does not verify the current target", func() {
 					cliConfig.SetTarget("my-lattice.example.com")
@@ -266,6 +541,7 @@ var _ = Describe("CliAppFactory", func() {
 
 						Expect(err).To(HaveOccurred())
 						Expect(outputBuffer).To(test_helpers.Say("Could not authenticate with the receptor. Please run ltc target with the correct credentials."))
+						Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadTarget}))
 						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(Equal(1))
 						Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.my-borked-lattice.example.com"))
 						Expect(commandRan).To(BeFalse())
@@ -293,6 +569,7 @@ var _ = Describe("CliAppFactory", func() {
 
 						Expect(err).To(HaveOccurred())
 						Expect(outputBuffer).To(test_helpers.Say("Error connecting to the receptor. Make sure your lattice target is set, and that lattice is up and running.\n\tUnderlying error: oopsie!"))
+						Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NetworkError}))
 						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(Equal(1))
 						Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.my-borked-lattice.example.com"))
 						Expect(commandRan).To(BeFalse())
@@ -310,4 +587,11 @@ var _ = Describe("CliAppFactory", func() {
 		})
 	})
 
+	Describe("SecureLoggregatorUrl", func() {
+		It("returns loggregator url with the secure websocket scheme added", func() {
+			loggregatorUrl := cli_app_factory.SecureLoggregatorUrl("doppler.diego.io")
+			Expect(loggregatorUrl).To(Equal("wss://doppler.diego.io"))
+		})
+	})
+
 })
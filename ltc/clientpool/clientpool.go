@@ -0,0 +1,145 @@
+package clientpool
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf_http"
+	"github.com/pivotal-golang/clock"
+)
+
+// DefaultMaxConnections matches how many apps or tasks the runners send
+// requests for at once.
+const DefaultMaxConnections = 8
+
+var errRequestCanceled = errors.New("the request was canceled while waiting for a connection")
+
+type Config struct {
+	// MaxConnections bounds the requests in flight at once.
+	MaxConnections int
+	// RequestsPerSecond bounds how often requests are started.  Zero means
+	// they aren't rate limited.
+	RequestsPerSecond float64
+}
+
+// Pool shares connections to the receptor between every client made from it,
+// and spaces out the requests they make, so that commands over hundreds of
+// apps or tasks neither open a connection per request nor flood the receptor.
+type Pool struct {
+	transport *http.Transport
+	slots     chan struct{}
+	interval  time.Duration
+	clock     clock.Clock
+
+	lock        sync.Mutex
+	nextRequest time.Time
+}
+
+func New(config Config, tlsConfig *tls.Config, clock clock.Clock) *Pool {
+	if config.MaxConnections < 1 {
+		config.MaxConnections = DefaultMaxConnections
+	}
+
+	transport := cf_http.NewClient().Transport.(*http.Transport)
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConnsPerHost = config.MaxConnections
+
+	pool := &Pool{
+		transport: transport,
+		slots:     make(chan struct{}, config.MaxConnections),
+		clock:     clock,
+	}
+	if config.RequestsPerSecond > 0 {
+		pool.interval = time.Duration(float64(time.Second) / config.RequestsPerSecond)
+	}
+	return pool
+}
+
+// Transport is the pooled connections, for wrapping before they are passed
+// to Client.
+func (p *Pool) Transport() http.RoundTripper {
+	return p.transport
+}
+
+// Client returns an HTTP client that makes requests with transport, which
+// should be the pool's Transport or wrap it, once the pool has room for them.
+// Streaming requests shouldn't be made with it, since they would hold their
+// connection for as long as they stream.
+func (p *Pool) Client(transport http.RoundTripper) *http.Client {
+	client := cf_http.NewClient()
+	client.Transport = &limitedTransport{transport, p}
+	return client
+}
+
+type limitedTransport struct {
+	transport http.RoundTripper
+	pool      *Pool
+}
+
+// RoundTrip holds a slot in the pool until the response body is closed,
+// since the connection is in use until then.  It stops waiting for a slot
+// once the request's Cancel channel is closed.
+func (t *limitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := t.pool.wait(request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case t.pool.slots <- struct{}{}:
+	case <-request.Cancel:
+		return nil, errRequestCanceled
+	}
+
+	response, err := t.transport.RoundTrip(request)
+	if err != nil || response.Body == nil {
+		<-t.pool.slots
+		return response, err
+	}
+	response.Body = &releasingBody{ReadCloser: response.Body, release: func() { <-t.pool.slots }}
+	return response, nil
+}
+
+// wait blocks until the rate limit lets the request start.
+func (p *Pool) wait(request *http.Request) error {
+	if p.interval == 0 {
+		return nil
+	}
+
+	p.lock.Lock()
+	now := p.clock.Now()
+	if p.nextRequest.Before(now) {
+		p.nextRequest = now
+	}
+	delay := p.nextRequest.Sub(now)
+	p.nextRequest = p.nextRequest.Add(p.interval)
+	p.lock.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := p.clock.NewTimer(delay)
+	select {
+	case <-timer.C():
+		return nil
+	case <-request.Cancel:
+		timer.Stop()
+		return errRequestCanceled
+	}
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release  func()
+	released sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.released.Do(b.release)
+	return err
+}
@@ -0,0 +1,171 @@
+package clientpool_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/clientpool"
+)
+
+type blockingTransport struct {
+	lock    sync.Mutex
+	started int
+	err     error
+	unblock chan struct{}
+}
+
+func (t *blockingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.lock.Lock()
+	t.started++
+	err := t.err
+	t.lock.Unlock()
+
+	<-t.unblock
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func (t *blockingTransport) Started() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.started
+}
+
+var _ = Describe("Pool", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		transport *blockingTransport
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		transport = &blockingTransport{unblock: make(chan struct{})}
+	})
+
+	get := func(client *http.Client) chan *http.Response {
+		responses := make(chan *http.Response, 1)
+		go func() {
+			defer GinkgoRecover()
+			response, err := client.Get("http://receptor.example.com/v1/tasks")
+			if err == nil {
+				responses <- response
+			} else {
+				responses <- nil
+			}
+		}()
+		return responses
+	}
+
+	It("bounds the requests in flight until their responses are closed", func() {
+		client := clientpool.New(clientpool.Config{MaxConnections: 2}, nil, fakeClock).Client(transport)
+
+		responses := []chan *http.Response{get(client), get(client), get(client)}
+
+		Eventually(transport.Started).Should(Equal(2))
+		Consistently(transport.Started).Should(Equal(2))
+
+		transport.unblock <- struct{}{}
+		var response *http.Response
+		Eventually(func() *http.Response {
+			for _, responseChan := range responses {
+				select {
+				case response = <-responseChan:
+				default:
+				}
+			}
+			return response
+		}).ShouldNot(BeNil())
+		Consistently(transport.Started).Should(Equal(2))
+
+		Expect(response.Body.Close()).To(Succeed())
+		Expect(response.Body.Close()).To(Succeed())
+		Eventually(transport.Started).Should(Equal(3))
+		close(transport.unblock)
+	})
+
+	It("frees the slot of a request that failed", func() {
+		transport.err = errors.New("connection refused")
+		close(transport.unblock)
+		client := clientpool.New(clientpool.Config{MaxConnections: 1}, nil, fakeClock).Client(transport)
+
+		Eventually(get(client)).Should(Receive(BeNil()))
+		Eventually(get(client)).Should(Receive(BeNil()))
+		Expect(transport.Started()).To(Equal(2))
+	})
+
+	It("stops waiting for a slot once the request is canceled", func() {
+		client := clientpool.New(clientpool.Config{MaxConnections: 1}, nil, fakeClock).Client(transport)
+		get(client)
+		Eventually(transport.Started).Should(Equal(1))
+
+		cancel := make(chan struct{})
+		request, err := http.NewRequest("GET", "http://receptor.example.com/v1/tasks", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.Cancel = cancel
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := client.Do(request)
+			errs <- err
+		}()
+		Consistently(errs).ShouldNot(Receive())
+
+		close(cancel)
+		Eventually(errs).Should(Receive(HaveOccurred()))
+		Expect(transport.Started()).To(Equal(1))
+		close(transport.unblock)
+	})
+
+	It("spaces out requests to the rate limit", func() {
+		close(transport.unblock)
+		client := clientpool.New(clientpool.Config{RequestsPerSecond: 2}, nil, fakeClock).Client(transport)
+
+		Eventually(get(client)).Should(Receive())
+		second := get(client)
+
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		Consistently(second).ShouldNot(Receive())
+
+		fakeClock.Increment(500 * time.Millisecond)
+		Eventually(second).Should(Receive())
+		Expect(transport.Started()).To(Equal(2))
+	})
+
+	It("reuses connections to the receptor", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+
+		var remoteAddrs []string
+		handler := ghttp.CombineHandlers(
+			func(_ http.ResponseWriter, request *http.Request) {
+				remoteAddrs = append(remoteAddrs, request.RemoteAddr)
+			},
+			ghttp.RespondWith(http.StatusOK, "{}"),
+		)
+		server.AppendHandlers(handler, handler, handler)
+
+		pool := clientpool.New(clientpool.Config{}, nil, fakeClock)
+		client := pool.Client(pool.Transport())
+		for i := 0; i < 3; i++ {
+			response, err := client.Get(server.URL() + "/v1/tasks")
+			Expect(err).NotTo(HaveOccurred())
+			ioutil.ReadAll(response.Body)
+			response.Body.Close()
+		}
+
+		Expect(remoteAddrs).To(HaveLen(3))
+		Expect(remoteAddrs[1]).To(Equal(remoteAddrs[0]))
+		Expect(remoteAddrs[2]).To(Equal(remoteAddrs[0]))
+	})
+})
@@ -0,0 +1,182 @@
+package cluster_examiner
+
+import (
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+type CellUsage struct {
+	CellID           string
+	Zone             string
+	Missing          bool
+	MemoryMB         int
+	DiskMB           int
+	Containers       int
+	Instances        int
+	ClaimedInstances int
+	ReservedMemoryMB int
+	ReservedDiskMB   int
+	CpuPercentage    float64
+	MemoryBytes      uint64
+	DiskBytes        uint64
+	AppInstances     map[string]int
+}
+
+// FreeMemoryMB is the memory left on the cell once the instances placed
+// on it have made their reservations.
+func (c CellUsage) FreeMemoryMB() int {
+	return c.MemoryMB - c.ReservedMemoryMB
+}
+
+func (c CellUsage) FreeDiskMB() int {
+	return c.DiskMB - c.ReservedDiskMB
+}
+
+func (c CellUsage) FreeContainers() int {
+	return c.Containers - c.Instances
+}
+
+type AppUsage struct {
+	Name             string
+	RunningInstances int
+	DesiredInstances int
+	MemoryMB         int
+	DiskMB           int
+	CpuPercentage    float64
+	MemoryBytes      uint64
+	DiskBytes        uint64
+}
+
+type ClusterUsage struct {
+	Cells []CellUsage
+	Apps  []AppUsage
+}
+
+//go:generate counterfeiter -o fake_cluster_examiner/fake_cluster_examiner.go . ClusterExaminer
+type ClusterExaminer interface {
+	ClusterUsage() (ClusterUsage, error)
+	ClusterCapacity() (ClusterUsage, error)
+	CellInstances(cellID string) ([]InstanceRef, error)
+	EvacuationStatus(cellID string, instances []InstanceRef) (EvacuationStatus, error)
+}
+
+type clusterExaminer struct {
+	appExaminer  app_examiner.AppExaminer
+	noaaConsumer app_examiner.NoaaConsumer
+}
+
+func New(appExaminer app_examiner.AppExaminer, noaaConsumer app_examiner.NoaaConsumer) ClusterExaminer {
+	return &clusterExaminer{appExaminer, noaaConsumer}
+}
+
+// ClusterUsage reports the capacity of each cell alongside what the apps
+// placed on it have reserved and are actually using.  Apps whose metrics
+// cannot be fetched are reported without usage rather than failing.
+func (e *clusterExaminer) ClusterUsage() (ClusterUsage, error) {
+	return e.usage(true)
+}
+
+// ClusterCapacity is ClusterUsage without the metrics, which take a
+// request per app, for when only the cells' room for more instances
+// matters.
+func (e *clusterExaminer) ClusterCapacity() (ClusterUsage, error) {
+	return e.usage(false)
+}
+
+func (e *clusterExaminer) usage(withMetrics bool) (ClusterUsage, error) {
+	cells, err := e.appExaminer.ListCells()
+	if err != nil {
+		return ClusterUsage{}, err
+	}
+
+	apps, err := e.appExaminer.ListApps()
+	if err != nil {
+		return ClusterUsage{}, err
+	}
+
+	usage := ClusterUsage{
+		Cells: make([]CellUsage, 0, len(cells)),
+		Apps:  make([]AppUsage, 0, len(apps)),
+	}
+
+	cellIndices := make(map[string]int, len(cells))
+	for _, cell := range cells {
+		cellIndices[cell.CellID] = len(usage.Cells)
+		usage.Cells = append(usage.Cells, CellUsage{
+			CellID:           cell.CellID,
+			Zone:             cell.Zone,
+			Missing:          cell.Missing,
+			MemoryMB:         cell.MemoryMB,
+			DiskMB:           cell.DiskMB,
+			Containers:       cell.Containers,
+			Instances:        cell.RunningInstances + cell.ClaimedInstances,
+			ClaimedInstances: cell.ClaimedInstances,
+		})
+	}
+
+	for _, app := range apps {
+		appUsage := AppUsage{
+			Name:             app.ProcessGuid,
+			RunningInstances: app.ActualRunningInstances,
+			DesiredInstances: app.DesiredInstances,
+			MemoryMB:         app.MemoryMB,
+			DiskMB:           app.DiskMB,
+		}
+
+		metricsByIndex := map[int]app_examiner.InstanceMetrics{}
+		if withMetrics {
+			metricsByIndex = e.metricsByIndex(app.ProcessGuid)
+		}
+		for _, instance := range app.ActualInstances {
+			if instance.State != string(receptor.ActualLRPStateRunning) && instance.State != string(receptor.ActualLRPStateClaimed) {
+				continue
+			}
+
+			var cell *CellUsage
+			if cellIndex, ok := cellIndices[instance.CellID]; ok {
+				cell = &usage.Cells[cellIndex]
+				cell.ReservedMemoryMB += app.MemoryMB
+				cell.ReservedDiskMB += app.DiskMB
+				if cell.AppInstances == nil {
+					cell.AppInstances = make(map[string]int)
+				}
+				cell.AppInstances[app.ProcessGuid]++
+			}
+
+			metrics, ok := metricsByIndex[instance.Index]
+			if !ok {
+				continue
+			}
+			appUsage.CpuPercentage += metrics.CpuPercentage
+			appUsage.MemoryBytes += metrics.MemoryBytes
+			appUsage.DiskBytes += metrics.DiskBytes
+			if cell != nil {
+				cell.CpuPercentage += metrics.CpuPercentage
+				cell.MemoryBytes += metrics.MemoryBytes
+				cell.DiskBytes += metrics.DiskBytes
+			}
+		}
+
+		usage.Apps = append(usage.Apps, appUsage)
+	}
+
+	return usage, nil
+}
+
+func (e *clusterExaminer) metricsByIndex(appName string) map[int]app_examiner.InstanceMetrics {
+	metricsByIndex := make(map[int]app_examiner.InstanceMetrics)
+
+	containerMetrics, err := e.noaaConsumer.GetContainerMetrics(appName, "")
+	if err != nil {
+		return metricsByIndex
+	}
+
+	for _, metric := range containerMetrics {
+		metricsByIndex[int(metric.GetInstanceIndex())] = app_examiner.InstanceMetrics{
+			CpuPercentage: metric.GetCpuPercentage(),
+			MemoryBytes:   metric.GetMemoryBytes(),
+			DiskBytes:     metric.GetDiskBytes(),
+		}
+	}
+	return metricsByIndex
+}
@@ -0,0 +1,13 @@
+package cluster_examiner_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClusterExaminer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ClusterExaminer Suite")
+}
@@ -0,0 +1,142 @@
+package cluster_examiner_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_noaa_consumer"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/gogo/protobuf/proto"
+)
+
+var _ = Describe("ClusterExaminer", func() {
+	var (
+		fakeAppExaminer  *fake_app_examiner.FakeAppExaminer
+		fakeNoaaConsumer *fake_noaa_consumer.FakeNoaaConsumer
+		clusterExaminer  cluster_examiner.ClusterExaminer
+	)
+
+	BeforeEach(func() {
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeNoaaConsumer = &fake_noaa_consumer.FakeNoaaConsumer{}
+		clusterExaminer = cluster_examiner.New(fakeAppExaminer, fakeNoaaConsumer)
+	})
+
+	Describe("ClusterUsage", func() {
+		BeforeEach(func() {
+			fakeAppExaminer.ListCellsReturns([]app_examiner.CellInfo{
+				{CellID: "cell-1", Zone: "z1", MemoryMB: 4096, DiskMB: 8192, Containers: 256, RunningInstances: 2, ClaimedInstances: 1},
+				{CellID: "cell-2", Zone: "z2", MemoryMB: 2048, DiskMB: 4096, Containers: 128},
+			}, nil)
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{
+					ProcessGuid:            "api",
+					DesiredInstances:       3,
+					ActualRunningInstances: 2,
+					MemoryMB:               256,
+					DiskMB:                 1024,
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, CellID: "cell-1", State: "RUNNING"},
+						{Index: 1, CellID: "cell-1", State: "RUNNING"},
+						{Index: 2, CellID: "cell-1", State: "CLAIMED"},
+						{Index: 3, State: "UNCLAIMED"},
+					},
+				},
+				{
+					ProcessGuid:      "worker",
+					DesiredInstances: 1,
+					MemoryMB:         128,
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, CellID: "cell-2", State: "CRASHED"},
+					},
+				},
+			}, nil)
+			fakeNoaaConsumer.GetContainerMetricsStub = func(appGuid, token string) ([]*events.ContainerMetric, error) {
+				if appGuid != "api" {
+					return nil, errors.New("no metrics")
+				}
+				return []*events.ContainerMetric{
+					{InstanceIndex: proto.Int32(0), CpuPercentage: proto.Float64(10), MemoryBytes: proto.Uint64(100), DiskBytes: proto.Uint64(1000)},
+					{InstanceIndex: proto.Int32(1), CpuPercentage: proto.Float64(5.5), MemoryBytes: proto.Uint64(200), DiskBytes: proto.Uint64(2000)},
+				}, nil
+			}
+		})
+
+		It("reports the capacity, reservations and usage of each cell", func() {
+			usage, err := clusterExaminer.ClusterUsage()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(usage.Cells).To(Equal([]cluster_examiner.CellUsage{
+				{
+					CellID:           "cell-1",
+					Zone:             "z1",
+					MemoryMB:         4096,
+					DiskMB:           8192,
+					Containers:       256,
+					Instances:        3,
+					ClaimedInstances: 1,
+					ReservedMemoryMB: 768,
+					ReservedDiskMB:   3072,
+					CpuPercentage:    15.5,
+					MemoryBytes:      300,
+					DiskBytes:        3000,
+					AppInstances:     map[string]int{"api": 3},
+				},
+				{CellID: "cell-2", Zone: "z2", MemoryMB: 2048, DiskMB: 4096, Containers: 128},
+			}))
+		})
+
+		It("reports the free capacity of each cell", func() {
+			usage, err := clusterExaminer.ClusterUsage()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(usage.Cells[0].FreeMemoryMB()).To(Equal(3328))
+			Expect(usage.Cells[0].FreeDiskMB()).To(Equal(5120))
+			Expect(usage.Cells[0].FreeContainers()).To(Equal(253))
+			Expect(usage.Cells[1].FreeMemoryMB()).To(Equal(2048))
+		})
+
+		It("reports the usage of each app, without metrics it could not fetch", func() {
+			usage, err := clusterExaminer.ClusterUsage()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(usage.Apps).To(Equal([]cluster_examiner.AppUsage{
+				{Name: "api", RunningInstances: 2, DesiredInstances: 3, MemoryMB: 256, DiskMB: 1024, CpuPercentage: 15.5, MemoryBytes: 300, DiskBytes: 3000},
+				{Name: "worker", DesiredInstances: 1, MemoryMB: 128},
+			}))
+			Expect(fakeNoaaConsumer.GetContainerMetricsCallCount()).To(Equal(2))
+		})
+
+		It("reports the capacity without fetching metrics as ClusterCapacity", func() {
+			usage, err := clusterExaminer.ClusterCapacity()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(usage.Cells[0].FreeMemoryMB()).To(Equal(4096 - 3*256))
+			Expect(usage.Cells[0].CpuPercentage).To(BeZero())
+			Expect(usage.Apps).To(Equal([]cluster_examiner.AppUsage{
+				{Name: "api", RunningInstances: 2, DesiredInstances: 3, MemoryMB: 256, DiskMB: 1024},
+				{Name: "worker", DesiredInstances: 1, MemoryMB: 128},
+			}))
+			Expect(fakeNoaaConsumer.GetContainerMetricsCallCount()).To(BeZero())
+		})
+
+		It("returns errors listing cells", func() {
+			fakeAppExaminer.ListCellsReturns(nil, errors.New("cells failed"))
+
+			_, err := clusterExaminer.ClusterUsage()
+			Expect(err).To(MatchError("cells failed"))
+		})
+
+		It("returns errors listing apps", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("apps failed"))
+
+			_, err := clusterExaminer.ClusterUsage()
+			Expect(err).To(MatchError("apps failed"))
+		})
+	})
+})
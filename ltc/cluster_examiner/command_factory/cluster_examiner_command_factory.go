@@ -0,0 +1,421 @@
+package command_factory
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/bytefmt"
+	"github.com/pivotal-golang/clock"
+)
+
+const evacuationPollInterval = 2 * time.Second
+
+type ClusterExaminerCommandFactory struct {
+	clusterExaminer cluster_examiner.ClusterExaminer
+	ui              terminal.UI
+	clock           clock.Clock
+	exitHandler     exit_handler.ExitHandler
+	cache           caching_receptor_client.Cache
+}
+
+func NewClusterExaminerCommandFactory(clusterExaminer cluster_examiner.ClusterExaminer, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler, cache caching_receptor_client.Cache) *ClusterExaminerCommandFactory {
+	return &ClusterExaminerCommandFactory{clusterExaminer, ui, clock, exitHandler, cache}
+}
+
+func (factory *ClusterExaminerCommandFactory) MakeTopCommand() cli.Command {
+	var topFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "sort, s",
+			Usage: "Sorts apps by \"memory\" or \"cpu\" usage",
+			Value: "memory",
+		},
+		cli.DurationFlag{
+			Name:  "rate, r",
+			Usage: "Refresh rate (e.g., \"5s\"), or 0 to print once",
+			Value: 2 * time.Second,
+		},
+	}
+
+	var topCommand = cli.Command{
+		Name:    "top",
+		Aliases: []string{"tp"},
+		Usage:   "Shows the resources used by each cell and app, refreshing periodically",
+		Description: `ltc top [--sort=memory|cpu] [--rate=DELAY]
+
+   Lists each cell with its capacity, the memory and disk reserved by the
+   instances placed on it, and their actual usage, followed by the apps on
+   lattice with the heaviest users first.`,
+		Action: factory.top,
+		Flags:  topFlags,
+	}
+
+	return topCommand
+}
+
+func (factory *ClusterExaminerCommandFactory) MakeExportMetricsCommand() cli.Command {
+	var exportMetricsFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen, l",
+			Usage: "Address to serve metrics on",
+			Value: ":9090",
+		},
+		cli.DurationFlag{
+			Name:  "interval, i",
+			Usage: "How often to examine the cluster (e.g., \"15s\")",
+			Value: 15 * time.Second,
+		},
+	}
+
+	var exportMetricsCommand = cli.Command{
+		Name:    "export-metrics",
+		Aliases: []string{"em"},
+		Usage:   "Serves the resources used by each cell and app as Prometheus metrics",
+		Description: `ltc export-metrics [--listen=ADDRESS] [--interval=DELAY]
+
+   Examines the cluster every interval, as ltc top does, and serves what it
+   found at http://ADDRESS/metrics in the Prometheus text format until
+   interrupted.  ltc_up is 0 when the last examination failed, in which case
+   no other metrics are served.`,
+		Action: factory.exportMetrics,
+		Flags:  exportMetricsFlags,
+	}
+
+	return exportMetricsCommand
+}
+
+func (factory *ClusterExaminerCommandFactory) MakeEvacuateCellCommand() cli.Command {
+	var evacuateCellFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "How long to wait for the instances to be rescheduled",
+			Value: 10 * time.Minute,
+		},
+	}
+
+	var evacuateCellCommand = cli.Command{
+		Name:    "evacuate-cell",
+		Aliases: []string{"ec"},
+		Usage:   "Watches the instances on a cell being rescheduled onto other cells",
+		Description: `ltc evacuate-cell CELL_ID [--timeout=DURATION]
+
+   Lists the instances on the cell, then reports each one as lattice
+   reschedules it onto another cell or fails to place it, until every
+   instance is running elsewhere.
+
+   The receptor has no way to mark a cell for evacuation, so the evacuation
+   is started on the cell itself by stopping its rep, e.g. 'sudo stop rep'.`,
+		Action: factory.evacuateCell,
+		Flags:  evacuateCellFlags,
+	}
+
+	return evacuateCellCommand
+}
+
+func (factory *ClusterExaminerCommandFactory) top(context *cli.Context) {
+	sortFlag := context.String("sort")
+	rateFlag := context.Duration("rate")
+
+	if sortFlag != "memory" && sortFlag != "cpu" {
+		factory.ui.SayIncorrectUsage("--sort must be memory or cpu")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	usage, err := factory.clusterExaminer.ClusterUsage()
+	if err != nil {
+		factory.ui.SayLine("Error examining cluster: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if rateFlag == 0 {
+		factory.printUsage(usage, sortFlag)
+		return
+	}
+
+	// Refreshing reads the apps from a cache kept up to date by the event
+	// stream, rather than from the receptor every tick.
+	factory.cache.Start()
+	defer factory.cache.Stop()
+
+	closeChan := make(chan struct{}, 1)
+	defer factory.ui.Say(cursor.Show())
+	factory.ui.Say(cursor.Hide())
+
+	factory.exitHandler.OnExit(func() {
+		closeChan <- struct{}{}
+		factory.ui.Say(cursor.Show())
+	})
+
+	linesWritten := factory.printUsage(usage, sortFlag)
+
+	for {
+		select {
+		case <-closeChan:
+			return
+		case <-factory.clock.NewTimer(rateFlag).C():
+			factory.ui.Say(cursor.Up(linesWritten))
+
+			usage, err := factory.clusterExaminer.ClusterUsage()
+			if err != nil {
+				factory.ui.Say(cursor.ClearToEndOfDisplay())
+				factory.ui.SayLine("Error examining cluster: " + err.Error())
+				linesWritten = 1
+				continue
+			}
+			linesWritten = factory.printUsage(usage, sortFlag)
+		}
+	}
+}
+
+func (factory *ClusterExaminerCommandFactory) printUsage(usage cluster_examiner.ClusterUsage, sortBy string) int {
+	factory.ui.Say(cursor.ClearToEndOfDisplay())
+
+	w := tabwriter.NewWriter(factory.ui, 9, 8, 1, '\t', 0)
+
+	fmt.Fprintln(w, colors.Bold("Cells"))
+	fmt.Fprintln(w, "Cell\tZone\tInstances\tReserved Memory\tReserved Disk\tCPU\tMemory\tDisk")
+	for _, cell := range usage.Cells {
+		cellID := cell.CellID
+		if cell.Missing {
+			cellID += colors.Red("[MISSING]")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%dM/%dM\t%dM/%dM\t%.2f%%\t%s\t%s\n",
+			cellID,
+			cell.Zone,
+			cell.Instances, cell.Containers,
+			cell.ReservedMemoryMB, cell.MemoryMB,
+			cell.ReservedDiskMB, cell.DiskMB,
+			cell.CpuPercentage,
+			bytefmt.ByteSize(cell.MemoryBytes),
+			bytefmt.ByteSize(cell.DiskBytes),
+		)
+	}
+	fmt.Fprintln(w)
+
+	apps := sortApps(usage.Apps, sortBy)
+
+	fmt.Fprintln(w, colors.Bold("Apps"))
+	fmt.Fprintln(w, "App\tInstances\tCPU\tMemory\tDisk")
+	for _, app := range apps {
+		fmt.Fprintf(w, "%s\t%d/%d\t%.2f%%\t%s\t%s\n",
+			app.Name,
+			app.RunningInstances, app.DesiredInstances,
+			app.CpuPercentage,
+			bytefmt.ByteSize(app.MemoryBytes),
+			bytefmt.ByteSize(app.DiskBytes),
+		)
+	}
+
+	w.Flush()
+
+	return len(usage.Cells) + len(apps) + 5
+}
+
+// metricsPage holds the exposition of the last examination of the cluster,
+// which is replaced by the polling loop while being served.
+type metricsPage struct {
+	mutex sync.RWMutex
+	body  []byte
+}
+
+func (p *metricsPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(p.body)
+}
+
+func (p *metricsPage) set(body []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.body = body
+}
+
+func (factory *ClusterExaminerCommandFactory) exportMetrics(context *cli.Context) {
+	listenFlag := context.String("listen")
+	intervalFlag := context.Duration("interval")
+
+	if intervalFlag <= 0 {
+		factory.ui.SayIncorrectUsage("--interval must be greater than 0")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	listener, err := net.Listen("tcp", listenFlag)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error listening on %s: %s", listenFlag, err))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	closeChan := make(chan struct{}, 1)
+	factory.exitHandler.OnExit(func() {
+		closeChan <- struct{}{}
+	})
+
+	page := &metricsPage{}
+	factory.examineMetrics(page)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", page)
+	go http.Serve(listener, mux)
+	defer listener.Close()
+
+	factory.ui.SayLine(fmt.Sprintf("Serving metrics on http://%s/metrics every %s", listener.Addr(), intervalFlag))
+
+	for {
+		select {
+		case <-closeChan:
+			return
+		case <-factory.clock.NewTimer(intervalFlag).C():
+			factory.examineMetrics(page)
+		}
+	}
+}
+
+func (factory *ClusterExaminerCommandFactory) examineMetrics(page *metricsPage) {
+	body := &bytes.Buffer{}
+	fmt.Fprintln(body, "# HELP ltc_up Whether ltc could examine the cluster (1) or not (0).")
+	fmt.Fprintln(body, "# TYPE ltc_up gauge")
+
+	usage, err := factory.clusterExaminer.ClusterUsage()
+	if err != nil {
+		factory.ui.SayLine("Error examining cluster: " + err.Error())
+		fmt.Fprintln(body, "ltc_up 0")
+		page.set(body.Bytes())
+		return
+	}
+
+	fmt.Fprintln(body, "ltc_up 1")
+	cluster_examiner.WriteMetrics(body, usage)
+	page.set(body.Bytes())
+}
+
+func (factory *ClusterExaminerCommandFactory) evacuateCell(context *cli.Context) {
+	timeoutFlag := context.Duration("timeout")
+	cellID := context.Args().First()
+
+	if cellID == "" {
+		factory.ui.SayIncorrectUsage("CELL_ID required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if timeoutFlag <= 0 {
+		factory.ui.SayIncorrectUsage("--timeout must be greater than 0")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	instances, err := factory.clusterExaminer.CellInstances(cellID)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+	if len(instances) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("%s has no instances to evacuate.", cellID))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Waiting for %d instance(s) on %s to be rescheduled...", len(instances), cellID))
+	factory.ui.SayLine("Start the evacuation on the cell by stopping its rep, e.g. 'sudo stop rep'.")
+
+	reported := make(map[cluster_examiner.InstanceRef]string)
+	notRescheduled := instances
+	startTime := factory.clock.Now()
+	for {
+		status, err := factory.clusterExaminer.EvacuationStatus(cellID, instances)
+		if err != nil {
+			factory.ui.SayLine("Error examining cell: " + err.Error())
+		} else {
+			notRescheduled = factory.reportEvacuation(instances, status, reported)
+			if status.Done() {
+				factory.ui.SayLine(colors.Green(fmt.Sprintf("Evacuated %s: %d instance(s) rescheduled.", cellID, len(status.Rescheduled))))
+				return
+			}
+		}
+
+		if factory.clock.Now().Sub(startTime) >= timeoutFlag {
+			names := make([]string, 0, len(notRescheduled))
+			for _, ref := range notRescheduled {
+				names = append(names, ref.String())
+			}
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Timed out evacuating %s. Not rescheduled: %s", cellID, strings.Join(names, ", "))))
+			factory.exitHandler.Exit(exit_codes.Timeout)
+			return
+		}
+
+		<-factory.clock.NewTimer(evacuationPollInterval).C()
+	}
+}
+
+// reportEvacuation says where each instance has got to since it was last
+// reported, and returns those not yet running elsewhere.
+func (factory *ClusterExaminerCommandFactory) reportEvacuation(instances []cluster_examiner.InstanceRef, status cluster_examiner.EvacuationStatus, reported map[cluster_examiner.InstanceRef]string) []cluster_examiner.InstanceRef {
+	removed := make(map[cluster_examiner.InstanceRef]bool, len(status.Removed))
+	for _, ref := range status.Removed {
+		removed[ref] = true
+	}
+
+	notRescheduled := []cluster_examiner.InstanceRef{}
+	for _, ref := range instances {
+		var report string
+		if cellID, ok := status.Rescheduled[ref]; ok {
+			report = fmt.Sprintf("%s is running on %s.", ref, cellID)
+		} else if removed[ref] {
+			report = fmt.Sprintf("%s was removed.", ref)
+		} else if placementError, ok := status.Unplaced[ref]; ok {
+			report = colors.Red(fmt.Sprintf("%s could not be placed: %s", ref, placementError))
+			notRescheduled = append(notRescheduled, ref)
+		} else {
+			notRescheduled = append(notRescheduled, ref)
+			continue
+		}
+
+		if reported[ref] != report {
+			factory.ui.SayLine(report)
+			reported[ref] = report
+		}
+	}
+	return notRescheduled
+}
+
+func sortApps(apps []cluster_examiner.AppUsage, sortBy string) []cluster_examiner.AppUsage {
+	sorted := make([]cluster_examiner.AppUsage, len(apps))
+	copy(sorted, apps)
+
+	sort.Stable(appUsageSorter{sorted, func(a, b cluster_examiner.AppUsage) bool {
+		if sortBy == "cpu" {
+			return a.CpuPercentage > b.CpuPercentage
+		}
+		return a.MemoryBytes > b.MemoryBytes
+	}})
+	return sorted
+}
+
+type appUsageSorter struct {
+	apps []cluster_examiner.AppUsage
+	less func(a, b cluster_examiner.AppUsage) bool
+}
+
+func (s appUsageSorter) Len() int           { return len(s.apps) }
+func (s appUsageSorter) Less(i, j int) bool { return s.less(s.apps[i], s.apps[j]) }
+func (s appUsageSorter) Swap(i, j int)      { s.apps[i], s.apps[j] = s.apps[j], s.apps[i] }
@@ -0,0 +1,359 @@
+package command_factory_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/caching_receptor_client/fake_cache"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner/fake_cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("ClusterExaminerCommandFactory", func() {
+	var (
+		fakeClusterExaminer *fake_cluster_examiner.FakeClusterExaminer
+		outputBuffer        *gbytes.Buffer
+		fakeClock           *fakeclock.FakeClock
+		fakeExitHandler     *fake_exit_handler.FakeExitHandler
+		fakeCache           *fake_cache.FakeCache
+		commandFactory      *command_factory.ClusterExaminerCommandFactory
+		topCommand          cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeClusterExaminer = &fake_cluster_examiner.FakeClusterExaminer{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeCache = &fake_cache.FakeCache{}
+
+		fakeClusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{
+			Cells: []cluster_examiner.CellUsage{
+				{CellID: "cell-1", Zone: "z1", MemoryMB: 4096, DiskMB: 8192, Containers: 256, Instances: 3, ReservedMemoryMB: 768, ReservedDiskMB: 3072, CpuPercentage: 15.5, MemoryBytes: 300 * 1024 * 1024, DiskBytes: 2 * 1024 * 1024 * 1024},
+				{CellID: "cell-2", Missing: true},
+			},
+			Apps: []cluster_examiner.AppUsage{
+				{Name: "api", RunningInstances: 2, DesiredInstances: 3, CpuPercentage: 1, MemoryBytes: 200 * 1024 * 1024},
+				{Name: "worker", RunningInstances: 1, DesiredInstances: 1, CpuPercentage: 50, MemoryBytes: 100 * 1024 * 1024},
+			},
+		}, nil)
+
+		commandFactory = command_factory.NewClusterExaminerCommandFactory(fakeClusterExaminer, terminal.NewUI(nil, outputBuffer, nil), fakeClock, fakeExitHandler, fakeCache)
+		topCommand = commandFactory.MakeTopCommand()
+	})
+
+	Describe("TopCommand", func() {
+		It("prints the usage of each cell and app once with --rate=0", func() {
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{"--rate=0"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Bold("Cells")))
+			Expect(outputBuffer).To(test_helpers.Say("cell-1"))
+			Expect(outputBuffer).To(test_helpers.Say("z1"))
+			Expect(outputBuffer).To(test_helpers.Say("3/256"))
+			Expect(outputBuffer).To(test_helpers.Say("768M/4096M"))
+			Expect(outputBuffer).To(test_helpers.Say("3072M/8192M"))
+			Expect(outputBuffer).To(test_helpers.Say("15.50%"))
+			Expect(outputBuffer).To(test_helpers.Say("300M"))
+			Expect(outputBuffer).To(test_helpers.Say("2G"))
+			Expect(outputBuffer).To(test_helpers.Say("cell-2" + colors.Red("[MISSING]")))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Bold("Apps")))
+			Expect(outputBuffer).To(test_helpers.Say("api"))
+			Expect(outputBuffer).To(test_helpers.Say("2/3"))
+			Expect(outputBuffer).To(test_helpers.Say("worker"))
+
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			Expect(fakeCache.StartCallCount()).To(BeZero())
+		})
+
+		It("sorts apps by cpu with --sort=cpu", func() {
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{"--rate=0", "--sort=cpu"})
+
+			Expect(outputBuffer).To(test_helpers.Say("worker"))
+			Expect(outputBuffer).To(test_helpers.Say("api"))
+		})
+
+		It("rejects unknown sort orders", func() {
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{"--sort=disk"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(fakeClusterExaminer.ClusterUsageCallCount()).To(BeZero())
+		})
+
+		It("reports errors examining the cluster", func() {
+			fakeClusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{}, errors.New("receptor down"))
+
+			test_helpers.ExecuteCommandWithArgs(topCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error examining cluster: receptor down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		Context("when refreshing", func() {
+			var closeChan chan struct{}
+
+			AfterEach(func() {
+				go fakeExitHandler.Exit(exit_codes.SigInt)
+				Eventually(closeChan).Should(BeClosed())
+			})
+
+			It("redraws the usage in place at the given rate", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"--rate=5s"})
+
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
+				Eventually(outputBuffer).Should(test_helpers.Say("worker"))
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+				fakeClock.IncrementBySeconds(5)
+
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Up(9)))
+				Eventually(outputBuffer).Should(test_helpers.Say("worker"))
+				Expect(fakeClusterExaminer.ClusterUsageCallCount()).To(Equal(2))
+			})
+
+			It("reads the apps from the cache until interrupted", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{"--rate=5s"})
+
+				Eventually(fakeCache.StartCallCount).Should(Equal(1))
+				Expect(fakeCache.StopCallCount()).To(BeZero())
+
+				fakeExitHandler.Exit(exit_codes.SigInt)
+
+				Eventually(closeChan).Should(BeClosed())
+				Expect(fakeCache.StopCallCount()).To(Equal(1))
+			})
+
+			It("keeps refreshing after an error", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{})
+
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{}, errors.New("receptor down"))
+				fakeClock.IncrementBySeconds(2)
+
+				Eventually(outputBuffer).Should(test_helpers.SayLine("Error examining cluster: receptor down"))
+				Consistently(closeChan).ShouldNot(BeClosed())
+			})
+
+			It("shows the cursor again on exit", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(topCommand, []string{})
+
+				Eventually(outputBuffer).Should(test_helpers.Say(cursor.Hide()))
+
+				fakeExitHandler.Exit(exit_codes.SigInt)
+
+				Eventually(closeChan).Should(BeClosed())
+				Expect(outputBuffer).To(test_helpers.Say(cursor.Show()))
+			})
+		})
+	})
+
+	Describe("ExportMetricsCommand", func() {
+		var exportMetricsCommand cli.Command
+
+		BeforeEach(func() {
+			exportMetricsCommand = commandFactory.MakeExportMetricsCommand()
+		})
+
+		getMetrics := func(url string) string {
+			response, err := http.Get(url)
+			Expect(err).NotTo(HaveOccurred())
+			defer response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+
+			body, err := ioutil.ReadAll(response.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return string(body)
+		}
+
+		Context("when serving", func() {
+			var (
+				closeChan  chan struct{}
+				metricsURL string
+			)
+
+			BeforeEach(func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(exportMetricsCommand, []string{"--listen=127.0.0.1:0", "--interval=30s"})
+
+				Eventually(outputBuffer).Should(gbytes.Say(`Serving metrics on http://127\.0\.0\.1:\d+/metrics every 30s`))
+				metricsURL = regexp.MustCompile(`http://\S+/metrics`).FindString(string(outputBuffer.Contents()))
+			})
+
+			AfterEach(func() {
+				go fakeExitHandler.Exit(exit_codes.SigInt)
+				Eventually(closeChan).Should(BeClosed())
+			})
+
+			It("serves the usage of each cell and app", func() {
+				metrics := getMetrics(metricsURL)
+				Expect(metrics).To(ContainSubstring("ltc_up 1\n"))
+				Expect(metrics).To(ContainSubstring(`ltc_cell_reserved_memory_mb{cell="cell-1",zone="z1"} 768`))
+				Expect(metrics).To(ContainSubstring(`ltc_cell_missing{cell="cell-2",zone=""} 1`))
+				Expect(metrics).To(ContainSubstring(`ltc_app_running_instances{app="worker"} 1`))
+				Expect(fakeClusterExaminer.ClusterUsageCallCount()).To(Equal(1))
+			})
+
+			It("examines the cluster again every interval", func() {
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{
+					Apps: []cluster_examiner.AppUsage{{Name: "api", RunningInstances: 3, DesiredInstances: 3}},
+				}, nil)
+
+				fakeClock.IncrementBySeconds(30)
+
+				Eventually(func() string { return getMetrics(metricsURL) }).Should(ContainSubstring(`ltc_app_running_instances{app="api"} 3`))
+				Expect(getMetrics(metricsURL)).NotTo(ContainSubstring("cell-1"))
+			})
+
+			It("reports ltc_up 0 and keeps serving when examining the cluster fails", func() {
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClusterExaminer.ClusterUsageReturns(cluster_examiner.ClusterUsage{}, errors.New("receptor down"))
+
+				fakeClock.IncrementBySeconds(30)
+
+				Eventually(outputBuffer).Should(test_helpers.SayLine("Error examining cluster: receptor down"))
+				Eventually(func() string { return getMetrics(metricsURL) }).Should(ContainSubstring("ltc_up 0\n"))
+				Expect(getMetrics(metricsURL)).NotTo(ContainSubstring("ltc_app_"))
+				Consistently(closeChan).ShouldNot(BeClosed())
+			})
+		})
+
+		It("rejects intervals that aren't positive", func() {
+			test_helpers.ExecuteCommandWithArgs(exportMetricsCommand, []string{"--interval=0"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(fakeClusterExaminer.ClusterUsageCallCount()).To(BeZero())
+		})
+
+		It("reports addresses it can't listen on", func() {
+			test_helpers.ExecuteCommandWithArgs(exportMetricsCommand, []string{"--listen=not-an-address"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error listening on not-an-address: "))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			Expect(fakeClusterExaminer.ClusterUsageCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("EvacuateCellCommand", func() {
+		var (
+			evacuateCellCommand cli.Command
+			instances           []cluster_examiner.InstanceRef
+		)
+
+		BeforeEach(func() {
+			evacuateCellCommand = commandFactory.MakeEvacuateCellCommand()
+
+			instances = []cluster_examiner.InstanceRef{{AppName: "api", Index: 0}, {AppName: "api", Index: 1}, {AppName: "worker", Index: 0}}
+			fakeClusterExaminer.CellInstancesReturns(instances, nil)
+			fakeClusterExaminer.EvacuationStatusReturns(cluster_examiner.EvacuationStatus{
+				Rescheduled: map[cluster_examiner.InstanceRef]string{{AppName: "api", Index: 0}: "cell-2"},
+				Unplaced:    map[cluster_examiner.InstanceRef]string{{AppName: "api", Index: 1}: "insufficient resources"},
+				Pending:     []cluster_examiner.InstanceRef{{AppName: "worker", Index: 0}},
+			}, nil)
+		})
+
+		It("reports the instances as they are rescheduled until the cell is evacuated", func() {
+			closeChan := test_helpers.AsyncExecuteCommandWithArgs(evacuateCellCommand, []string{"cell-1"})
+
+			Eventually(outputBuffer).Should(test_helpers.SayLine("Waiting for 3 instance(s) on cell-1 to be rescheduled..."))
+			Eventually(outputBuffer).Should(test_helpers.SayLine("api/0 is running on cell-2."))
+			Eventually(outputBuffer).Should(test_helpers.SayLine(colors.Red("api/1 could not be placed: insufficient resources")))
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			Expect(fakeClusterExaminer.CellInstancesArgsForCall(0)).To(Equal("cell-1"))
+			cellID, examinedInstances := fakeClusterExaminer.EvacuationStatusArgsForCall(0)
+			Expect(cellID).To(Equal("cell-1"))
+			Expect(examinedInstances).To(Equal(instances))
+
+			fakeClusterExaminer.EvacuationStatusReturns(cluster_examiner.EvacuationStatus{
+				Rescheduled: map[cluster_examiner.InstanceRef]string{{AppName: "api", Index: 0}: "cell-2", {AppName: "api", Index: 1}: "cell-3"},
+				Removed:     []cluster_examiner.InstanceRef{{AppName: "worker", Index: 0}},
+			}, nil)
+			fakeClock.IncrementBySeconds(2)
+
+			Eventually(closeChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.SayLine("api/1 is running on cell-3."))
+			Expect(outputBuffer).To(test_helpers.SayLine("worker/0 was removed."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Evacuated cell-1: 2 instance(s) rescheduled.")))
+			Expect(outputBuffer.Contents()).NotTo(MatchRegexp("(?s)api/0 is running on cell-2.*api/0 is running on cell-2"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("times out when the instances aren't rescheduled in time", func() {
+			closeChan := test_helpers.AsyncExecuteCommandWithArgs(evacuateCellCommand, []string{"--timeout=3s", "cell-1"})
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClusterExaminer.EvacuationStatusReturns(cluster_examiner.EvacuationStatus{}, errors.New("receptor down"))
+			fakeClock.IncrementBySeconds(2)
+
+			Eventually(outputBuffer).Should(test_helpers.SayLine("Error examining cell: receptor down"))
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.IncrementBySeconds(2)
+
+			Eventually(closeChan).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out evacuating cell-1. Not rescheduled: api/1, worker/0")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+		})
+
+		It("says when the cell has no instances", func() {
+			fakeClusterExaminer.CellInstancesReturns([]cluster_examiner.InstanceRef{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(evacuateCellCommand, []string{"cell-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("cell-1 has no instances to evacuate."))
+			Expect(fakeClusterExaminer.EvacuationStatusCallCount()).To(BeZero())
+		})
+
+		It("reports errors listing the cell's instances", func() {
+			fakeClusterExaminer.CellInstancesReturns(nil, notFoundError("Cell cell-9 not found."))
+
+			test_helpers.ExecuteCommandWithArgs(evacuateCellCommand, []string{"cell-9"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Cell cell-9 not found."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("requires a cell ID", func() {
+			test_helpers.ExecuteCommandWithArgs(evacuateCellCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(fakeClusterExaminer.CellInstancesCallCount()).To(BeZero())
+		})
+
+		It("rejects timeouts that aren't positive", func() {
+			test_helpers.ExecuteCommandWithArgs(evacuateCellCommand, []string{"--timeout=0", "cell-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+})
+
+type notFoundError string
+
+func (err notFoundError) Error() string {
+	return string(err)
+}
+
+func (err notFoundError) NotFound() bool {
+	return true
+}
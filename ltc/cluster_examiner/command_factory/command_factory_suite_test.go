@@ -0,0 +1,13 @@
+package command_factory_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClusterExaminerCommandFactory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ClusterExaminer CommandFactory Suite")
+}
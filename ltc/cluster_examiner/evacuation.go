@@ -0,0 +1,157 @@
+package cluster_examiner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+// InstanceRef names an instance of an app by its index, which outlives
+// the instance as lattice moves it between cells.
+type InstanceRef struct {
+	AppName string
+	Index   int
+}
+
+func (ref InstanceRef) String() string {
+	return fmt.Sprintf("%s/%d", ref.AppName, ref.Index)
+}
+
+// EvacuationStatus is where the instances that were on an evacuating cell
+// have got to.
+type EvacuationStatus struct {
+	// Rescheduled maps instances running on another cell to that cell.
+	Rescheduled map[InstanceRef]string
+	// Unplaced maps instances lattice failed to place elsewhere to the
+	// reason it gave.
+	Unplaced map[InstanceRef]string
+	// Removed are instances whose app was removed or scaled down.
+	Removed []InstanceRef
+	// Pending are instances still on the cell or starting elsewhere.
+	Pending []InstanceRef
+}
+
+func (s EvacuationStatus) Done() bool {
+	return len(s.Pending) == 0 && len(s.Unplaced) == 0
+}
+
+type cellNotFoundError string
+
+func (err cellNotFoundError) Error() string {
+	return fmt.Sprintf("Cell %s not found.", string(err))
+}
+
+func (err cellNotFoundError) NotFound() bool {
+	return true
+}
+
+// CellInstances lists the running and claimed instances placed on the
+// cell, including those it is evacuating.
+func (e *clusterExaminer) CellInstances(cellID string) ([]InstanceRef, error) {
+	cells, err := e.appExaminer.ListCells()
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, cell := range cells {
+		if cell.CellID == cellID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, cellNotFoundError(cellID)
+	}
+
+	apps, err := e.appExaminer.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []InstanceRef{}
+	for _, app := range apps {
+		for _, instance := range app.ActualInstances {
+			if instance.CellID != cellID {
+				continue
+			}
+			if instance.State == string(receptor.ActualLRPStateRunning) || instance.State == string(receptor.ActualLRPStateClaimed) {
+				instances = append(instances, InstanceRef{app.ProcessGuid, instance.Index})
+			}
+		}
+	}
+
+	sortInstanceRefs(instances)
+	return instances, nil
+}
+
+// EvacuationStatus looks up where instances, which were on the cell, are
+// now.  An instance counts as rescheduled once a replacement is running on
+// another cell, while the cell may still be running its evacuating copy.
+func (e *clusterExaminer) EvacuationStatus(cellID string, instances []InstanceRef) (EvacuationStatus, error) {
+	apps, err := e.appExaminer.ListApps()
+	if err != nil {
+		return EvacuationStatus{}, err
+	}
+
+	status := EvacuationStatus{
+		Rescheduled: make(map[InstanceRef]string),
+		Unplaced:    make(map[InstanceRef]string),
+	}
+
+	appsByName := make(map[string]int, len(apps))
+	for index, app := range apps {
+		appsByName[app.ProcessGuid] = index
+	}
+
+	for _, ref := range instances {
+		appIndex, ok := appsByName[ref.AppName]
+		if !ok {
+			status.Removed = append(status.Removed, ref)
+			continue
+		}
+
+		found, placementError, runningOn := false, "", ""
+		for _, instance := range apps[appIndex].ActualInstances {
+			if instance.Index != ref.Index {
+				continue
+			}
+			found = true
+			if instance.CellID != cellID && !instance.Evacuating && instance.State == string(receptor.ActualLRPStateRunning) {
+				runningOn = instance.CellID
+			}
+			if instance.PlacementError != "" {
+				placementError = instance.PlacementError
+			}
+		}
+
+		switch {
+		case !found:
+			status.Removed = append(status.Removed, ref)
+		case runningOn != "":
+			status.Rescheduled[ref] = runningOn
+		case placementError != "":
+			status.Unplaced[ref] = placementError
+		default:
+			status.Pending = append(status.Pending, ref)
+		}
+	}
+
+	return status, nil
+}
+
+func sortInstanceRefs(instances []InstanceRef) {
+	sort.Sort(instanceRefs(instances))
+}
+
+type instanceRefs []InstanceRef
+
+func (refs instanceRefs) Len() int      { return len(refs) }
+func (refs instanceRefs) Swap(i, j int) { refs[i], refs[j] = refs[j], refs[i] }
+func (refs instanceRefs) Less(i, j int) bool {
+	if refs[i].AppName != refs[j].AppName {
+		return refs[i].AppName < refs[j].AppName
+	}
+	return refs[i].Index < refs[j].Index
+}
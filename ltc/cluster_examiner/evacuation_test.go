@@ -0,0 +1,115 @@
+package cluster_examiner_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_noaa_consumer"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+)
+
+var _ = Describe("Evacuation", func() {
+	var (
+		fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		clusterExaminer cluster_examiner.ClusterExaminer
+	)
+
+	BeforeEach(func() {
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		clusterExaminer = cluster_examiner.New(fakeAppExaminer, &fake_noaa_consumer.FakeNoaaConsumer{})
+
+		fakeAppExaminer.ListCellsReturns([]app_examiner.CellInfo{{CellID: "cell-1"}, {CellID: "cell-2"}}, nil)
+	})
+
+	Describe("CellInstances", func() {
+		It("lists the running and claimed instances on the cell", func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{
+					ProcessGuid: "worker",
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 1, CellID: "cell-1", State: "CLAIMED"},
+						{Index: 0, CellID: "cell-1", State: "RUNNING", Evacuating: true},
+					},
+				},
+				{
+					ProcessGuid: "api",
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, CellID: "cell-1", State: "RUNNING"},
+						{Index: 1, CellID: "cell-2", State: "RUNNING"},
+						{Index: 2, CellID: "cell-1", State: "CRASHED"},
+					},
+				},
+			}, nil)
+
+			instances, err := clusterExaminer.CellInstances("cell-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(instances).To(Equal([]cluster_examiner.InstanceRef{{AppName: "api", Index: 0}, {AppName: "worker", Index: 0}, {AppName: "worker", Index: 1}}))
+		})
+
+		It("returns a not found error for unknown cells", func() {
+			_, err := clusterExaminer.CellInstances("cell-9")
+			Expect(err).To(MatchError("Cell cell-9 not found."))
+			Expect(exit_codes.ForError(err, exit_codes.CommandFailed)).To(Equal(exit_codes.NotFound))
+			Expect(fakeAppExaminer.ListAppsCallCount()).To(BeZero())
+		})
+
+		It("returns errors listing the cells", func() {
+			fakeAppExaminer.ListCellsReturns(nil, errors.New("no cells"))
+
+			_, err := clusterExaminer.CellInstances("cell-1")
+			Expect(err).To(MatchError("no cells"))
+		})
+	})
+
+	Describe("EvacuationStatus", func() {
+		It("reports where the instances that were on the cell have got to", func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{
+					ProcessGuid: "api",
+					ActualInstances: []app_examiner.InstanceInfo{
+						{Index: 0, CellID: "cell-1", State: "RUNNING", Evacuating: true},
+						{Index: 0, CellID: "cell-2", State: "RUNNING"},
+						{Index: 1, CellID: "cell-1", State: "RUNNING", Evacuating: true},
+						{Index: 1, CellID: "cell-2", State: "CLAIMED"},
+						{Index: 2, State: "UNCLAIMED", PlacementError: "insufficient resources"},
+					},
+				},
+			}, nil)
+
+			status, err := clusterExaminer.EvacuationStatus("cell-1", []cluster_examiner.InstanceRef{{AppName: "api", Index: 0}, {AppName: "api", Index: 1}, {AppName: "api", Index: 2}, {AppName: "api", Index: 3}, {AppName: "gone", Index: 0}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(status.Rescheduled).To(Equal(map[cluster_examiner.InstanceRef]string{{AppName: "api", Index: 0}: "cell-2"}))
+			Expect(status.Unplaced).To(Equal(map[cluster_examiner.InstanceRef]string{{AppName: "api", Index: 2}: "insufficient resources"}))
+			Expect(status.Pending).To(Equal([]cluster_examiner.InstanceRef{{AppName: "api", Index: 1}}))
+			Expect(status.Removed).To(Equal([]cluster_examiner.InstanceRef{{AppName: "api", Index: 3}, {AppName: "gone", Index: 0}}))
+			Expect(status.Done()).To(BeFalse())
+		})
+
+		It("is done once every instance is rescheduled or removed", func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{
+					ProcessGuid:     "api",
+					ActualInstances: []app_examiner.InstanceInfo{{Index: 0, CellID: "cell-2", State: "RUNNING"}},
+				},
+			}, nil)
+
+			status, err := clusterExaminer.EvacuationStatus("cell-1", []cluster_examiner.InstanceRef{{AppName: "api", Index: 0}, {AppName: "gone", Index: 0}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Done()).To(BeTrue())
+		})
+
+		It("returns errors listing the apps", func() {
+			fakeAppExaminer.ListAppsReturns(nil, errors.New("no apps"))
+
+			_, err := clusterExaminer.EvacuationStatus("cell-1", []cluster_examiner.InstanceRef{{AppName: "api", Index: 0}})
+			Expect(err).To(MatchError("no apps"))
+		})
+	})
+})
@@ -0,0 +1,163 @@
+// This file was generated by counterfeiter
+package fake_cluster_examiner
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+)
+
+type FakeClusterExaminer struct {
+	ClusterUsageStub        func() (cluster_examiner.ClusterUsage, error)
+	clusterUsageMutex       sync.RWMutex
+	clusterUsageArgsForCall []struct{}
+	clusterUsageReturns     struct {
+		result1 cluster_examiner.ClusterUsage
+		result2 error
+	}
+	ClusterCapacityStub        func() (cluster_examiner.ClusterUsage, error)
+	clusterCapacityMutex       sync.RWMutex
+	clusterCapacityArgsForCall []struct{}
+	clusterCapacityReturns     struct {
+		result1 cluster_examiner.ClusterUsage
+		result2 error
+	}
+	CellInstancesStub        func(cellID string) ([]cluster_examiner.InstanceRef, error)
+	cellInstancesMutex       sync.RWMutex
+	cellInstancesArgsForCall []struct {
+		cellID string
+	}
+	cellInstancesReturns struct {
+		result1 []cluster_examiner.InstanceRef
+		result2 error
+	}
+	EvacuationStatusStub        func(cellID string, instances []cluster_examiner.InstanceRef) (cluster_examiner.EvacuationStatus, error)
+	evacuationStatusMutex       sync.RWMutex
+	evacuationStatusArgsForCall []struct {
+		cellID    string
+		instances []cluster_examiner.InstanceRef
+	}
+	evacuationStatusReturns struct {
+		result1 cluster_examiner.EvacuationStatus
+		result2 error
+	}
+}
+
+func (fake *FakeClusterExaminer) ClusterUsage() (cluster_examiner.ClusterUsage, error) {
+	fake.clusterUsageMutex.Lock()
+	fake.clusterUsageArgsForCall = append(fake.clusterUsageArgsForCall, struct{}{})
+	fake.clusterUsageMutex.Unlock()
+	if fake.ClusterUsageStub != nil {
+		return fake.ClusterUsageStub()
+	} else {
+		return fake.clusterUsageReturns.result1, fake.clusterUsageReturns.result2
+	}
+}
+
+func (fake *FakeClusterExaminer) ClusterUsageCallCount() int {
+	fake.clusterUsageMutex.RLock()
+	defer fake.clusterUsageMutex.RUnlock()
+	return len(fake.clusterUsageArgsForCall)
+}
+
+func (fake *FakeClusterExaminer) ClusterUsageReturns(result1 cluster_examiner.ClusterUsage, result2 error) {
+	fake.ClusterUsageStub = nil
+	fake.clusterUsageReturns = struct {
+		result1 cluster_examiner.ClusterUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClusterExaminer) ClusterCapacity() (cluster_examiner.ClusterUsage, error) {
+	fake.clusterCapacityMutex.Lock()
+	fake.clusterCapacityArgsForCall = append(fake.clusterCapacityArgsForCall, struct{}{})
+	fake.clusterCapacityMutex.Unlock()
+	if fake.ClusterCapacityStub != nil {
+		return fake.ClusterCapacityStub()
+	} else {
+		return fake.clusterCapacityReturns.result1, fake.clusterCapacityReturns.result2
+	}
+}
+
+func (fake *FakeClusterExaminer) ClusterCapacityCallCount() int {
+	fake.clusterCapacityMutex.RLock()
+	defer fake.clusterCapacityMutex.RUnlock()
+	return len(fake.clusterCapacityArgsForCall)
+}
+
+func (fake *FakeClusterExaminer) ClusterCapacityReturns(result1 cluster_examiner.ClusterUsage, result2 error) {
+	fake.ClusterCapacityStub = nil
+	fake.clusterCapacityReturns = struct {
+		result1 cluster_examiner.ClusterUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClusterExaminer) CellInstances(cellID string) ([]cluster_examiner.InstanceRef, error) {
+	fake.cellInstancesMutex.Lock()
+	fake.cellInstancesArgsForCall = append(fake.cellInstancesArgsForCall, struct {
+		cellID string
+	}{cellID})
+	fake.cellInstancesMutex.Unlock()
+	if fake.CellInstancesStub != nil {
+		return fake.CellInstancesStub(cellID)
+	} else {
+		return fake.cellInstancesReturns.result1, fake.cellInstancesReturns.result2
+	}
+}
+
+func (fake *FakeClusterExaminer) CellInstancesCallCount() int {
+	fake.cellInstancesMutex.RLock()
+	defer fake.cellInstancesMutex.RUnlock()
+	return len(fake.cellInstancesArgsForCall)
+}
+
+func (fake *FakeClusterExaminer) CellInstancesArgsForCall(i int) string {
+	fake.cellInstancesMutex.RLock()
+	defer fake.cellInstancesMutex.RUnlock()
+	return fake.cellInstancesArgsForCall[i].cellID
+}
+
+func (fake *FakeClusterExaminer) CellInstancesReturns(result1 []cluster_examiner.InstanceRef, result2 error) {
+	fake.CellInstancesStub = nil
+	fake.cellInstancesReturns = struct {
+		result1 []cluster_examiner.InstanceRef
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClusterExaminer) EvacuationStatus(cellID string, instances []cluster_examiner.InstanceRef) (cluster_examiner.EvacuationStatus, error) {
+	fake.evacuationStatusMutex.Lock()
+	fake.evacuationStatusArgsForCall = append(fake.evacuationStatusArgsForCall, struct {
+		cellID    string
+		instances []cluster_examiner.InstanceRef
+	}{cellID, instances})
+	fake.evacuationStatusMutex.Unlock()
+	if fake.EvacuationStatusStub != nil {
+		return fake.EvacuationStatusStub(cellID, instances)
+	} else {
+		return fake.evacuationStatusReturns.result1, fake.evacuationStatusReturns.result2
+	}
+}
+
+func (fake *FakeClusterExaminer) EvacuationStatusCallCount() int {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return len(fake.evacuationStatusArgsForCall)
+}
+
+func (fake *FakeClusterExaminer) EvacuationStatusArgsForCall(i int) (string, []cluster_examiner.InstanceRef) {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return fake.evacuationStatusArgsForCall[i].cellID, fake.evacuationStatusArgsForCall[i].instances
+}
+
+func (fake *FakeClusterExaminer) EvacuationStatusReturns(result1 cluster_examiner.EvacuationStatus, result2 error) {
+	fake.EvacuationStatusStub = nil
+	fake.evacuationStatusReturns = struct {
+		result1 cluster_examiner.EvacuationStatus
+		result2 error
+	}{result1, result2}
+}
+
+var _ cluster_examiner.ClusterExaminer = new(FakeClusterExaminer)
@@ -0,0 +1,93 @@
+package cluster_examiner
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type metric struct {
+	name string
+	help string
+}
+
+var cellMetrics = []struct {
+	metric
+	value func(CellUsage) float64
+}{
+	{metric{"ltc_cell_missing", "Whether the cell has stopped reporting to lattice (1) or not (0)."}, func(c CellUsage) float64 { return boolValue(c.Missing) }},
+	{metric{"ltc_cell_memory_mb", "Memory capacity of the cell in MB."}, func(c CellUsage) float64 { return float64(c.MemoryMB) }},
+	{metric{"ltc_cell_disk_mb", "Disk capacity of the cell in MB."}, func(c CellUsage) float64 { return float64(c.DiskMB) }},
+	{metric{"ltc_cell_containers", "Number of containers the cell can run."}, func(c CellUsage) float64 { return float64(c.Containers) }},
+	{metric{"ltc_cell_instances", "Number of running and claimed instances on the cell."}, func(c CellUsage) float64 { return float64(c.Instances) }},
+	{metric{"ltc_cell_reserved_memory_mb", "Memory reserved by the instances on the cell in MB."}, func(c CellUsage) float64 { return float64(c.ReservedMemoryMB) }},
+	{metric{"ltc_cell_reserved_disk_mb", "Disk reserved by the instances on the cell in MB."}, func(c CellUsage) float64 { return float64(c.ReservedDiskMB) }},
+	{metric{"ltc_cell_cpu_percentage", "CPU used by the instances on the cell, in percent of one core."}, func(c CellUsage) float64 { return c.CpuPercentage }},
+	{metric{"ltc_cell_memory_bytes", "Memory used by the instances on the cell."}, func(c CellUsage) float64 { return float64(c.MemoryBytes) }},
+	{metric{"ltc_cell_disk_bytes", "Disk used by the instances on the cell."}, func(c CellUsage) float64 { return float64(c.DiskBytes) }},
+}
+
+var appMetrics = []struct {
+	metric
+	value func(AppUsage) float64
+}{
+	{metric{"ltc_app_running_instances", "Number of running instances of the app."}, func(a AppUsage) float64 { return float64(a.RunningInstances) }},
+	{metric{"ltc_app_desired_instances", "Number of instances of the app lattice is asked to run."}, func(a AppUsage) float64 { return float64(a.DesiredInstances) }},
+	{metric{"ltc_app_cpu_percentage", "CPU used by the instances of the app, in percent of one core."}, func(a AppUsage) float64 { return a.CpuPercentage }},
+	{metric{"ltc_app_memory_bytes", "Memory used by the instances of the app."}, func(a AppUsage) float64 { return float64(a.MemoryBytes) }},
+	{metric{"ltc_app_disk_bytes", "Disk used by the instances of the app."}, func(a AppUsage) float64 { return float64(a.DiskBytes) }},
+}
+
+// WriteMetrics writes usage as gauges in the Prometheus text exposition
+// format, labelling cell metrics by cell and zone and app metrics by app.
+func WriteMetrics(w io.Writer, usage ClusterUsage) error {
+	for _, cellMetric := range cellMetrics {
+		if err := writeHeader(w, cellMetric.metric); err != nil {
+			return err
+		}
+		for _, cell := range usage.Cells {
+			labels := fmt.Sprintf(`cell="%s",zone="%s"`, escapeLabelValue(cell.CellID), escapeLabelValue(cell.Zone))
+			if err := writeSample(w, cellMetric.name, labels, cellMetric.value(cell)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, appMetric := range appMetrics {
+		if err := writeHeader(w, appMetric.metric); err != nil {
+			return err
+		}
+		for _, app := range usage.Apps {
+			labels := fmt.Sprintf(`app="%s"`, escapeLabelValue(app.Name))
+			if err := writeSample(w, appMetric.name, labels, appMetric.value(app)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeHeader(w io.Writer, m metric) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+	return err
+}
+
+func writeSample(w io.Writer, name, labels string, value float64) error {
+	_, err := fmt.Fprintf(w, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+	return err
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
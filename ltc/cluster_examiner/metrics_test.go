@@ -0,0 +1,52 @@
+package cluster_examiner_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+)
+
+var _ = Describe("WriteMetrics", func() {
+	var usage cluster_examiner.ClusterUsage
+
+	BeforeEach(func() {
+		usage = cluster_examiner.ClusterUsage{
+			Cells: []cluster_examiner.CellUsage{
+				{CellID: "cell-1", Zone: "z1", MemoryMB: 4096, Instances: 3, ReservedMemoryMB: 768, CpuPercentage: 15.5, MemoryBytes: 314572800},
+				{CellID: "cell-2", Missing: true},
+			},
+			Apps: []cluster_examiner.AppUsage{
+				{Name: "api", RunningInstances: 2, DesiredInstances: 3, MemoryBytes: 209715200},
+			},
+		}
+	})
+
+	It("writes cell and app usage as labelled gauges", func() {
+		buffer := &bytes.Buffer{}
+		Expect(cluster_examiner.WriteMetrics(buffer, usage)).To(Succeed())
+
+		output := buffer.String()
+		Expect(output).To(ContainSubstring("# HELP ltc_cell_memory_mb Memory capacity of the cell in MB.\n# TYPE ltc_cell_memory_mb gauge\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_memory_mb{cell="cell-1",zone="z1"} 4096` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_missing{cell="cell-1",zone="z1"} 0` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_missing{cell="cell-2",zone=""} 1` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_reserved_memory_mb{cell="cell-1",zone="z1"} 768` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_cpu_percentage{cell="cell-1",zone="z1"} 15.5` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_cell_memory_bytes{cell="cell-1",zone="z1"} 314572800` + "\n"))
+		Expect(output).To(ContainSubstring("# TYPE ltc_app_running_instances gauge\n"))
+		Expect(output).To(ContainSubstring(`ltc_app_running_instances{app="api"} 2` + "\n"))
+		Expect(output).To(ContainSubstring(`ltc_app_desired_instances{app="api"} 3` + "\n"))
+	})
+
+	It("escapes label values", func() {
+		usage.Apps[0].Name = `odd"name\`
+
+		buffer := &bytes.Buffer{}
+		Expect(cluster_examiner.WriteMetrics(buffer, usage)).To(Succeed())
+
+		Expect(buffer.String()).To(ContainSubstring(`ltc_app_running_instances{app="odd\"name\\"} 2`))
+	})
+})
@@ -0,0 +1,49 @@
+package cluster_examiner
+
+// Placement is a number of new instances of an app, each needing the app's
+// memory and disk.
+type Placement struct {
+	AppName   string
+	Instances int
+	MemoryMB  int
+	DiskMB    int
+}
+
+// Unplaced counts, by app, the instances that wouldn't fit in the room left
+// on the cells, placing each on the cell with the most free memory as
+// lattice balances instances across cells.  Missing cells have no room.
+// Lattice can only do worse than this, so instances it counts can't
+// possibly be placed.
+func (u ClusterUsage) Unplaced(placements []Placement) map[string]int {
+	type room struct{ memoryMB, diskMB, containers int }
+	rooms := []*room{}
+	for _, cell := range u.Cells {
+		if !cell.Missing {
+			rooms = append(rooms, &room{cell.FreeMemoryMB(), cell.FreeDiskMB(), cell.FreeContainers()})
+		}
+	}
+
+	unplaced := map[string]int{}
+	for _, placement := range placements {
+		for instance := 0; instance < placement.Instances; instance++ {
+			var best *room
+			for _, room := range rooms {
+				if room.memoryMB < placement.MemoryMB || room.diskMB < placement.DiskMB || room.containers < 1 {
+					continue
+				}
+				if best == nil || room.memoryMB > best.memoryMB {
+					best = room
+				}
+			}
+
+			if best == nil {
+				unplaced[placement.AppName] += placement.Instances - instance
+				break
+			}
+			best.memoryMB -= placement.MemoryMB
+			best.diskMB -= placement.DiskMB
+			best.containers--
+		}
+	}
+	return unplaced
+}
@@ -0,0 +1,75 @@
+package cluster_examiner_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_examiner"
+)
+
+var _ = Describe("Placement", func() {
+	Describe("Unplaced", func() {
+		var usage cluster_examiner.ClusterUsage
+
+		BeforeEach(func() {
+			usage = cluster_examiner.ClusterUsage{
+				Cells: []cluster_examiner.CellUsage{
+					{CellID: "cell-1", MemoryMB: 1024, DiskMB: 4096, Containers: 10, ReservedMemoryMB: 512, Instances: 2},
+					{CellID: "cell-2", MemoryMB: 1024, DiskMB: 4096, Containers: 10},
+					{CellID: "cell-3", MemoryMB: 8192, DiskMB: 16384, Containers: 10, Missing: true},
+				},
+			}
+		})
+
+		It("is empty when every instance fits", func() {
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "web", Instances: 3, MemoryMB: 512, DiskMB: 1024},
+			})
+
+			Expect(unplaced).To(BeEmpty())
+		})
+
+		It("counts the instances that don't fit, leaving out missing cells", func() {
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "web", Instances: 5, MemoryMB: 512, DiskMB: 1024},
+			})
+
+			Expect(unplaced).To(Equal(map[string]int{"web": 2}))
+		})
+
+		It("places each app in the room the ones before it left", func() {
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "web", Instances: 2, MemoryMB: 512},
+				{AppName: "worker", Instances: 3, MemoryMB: 256},
+			})
+
+			Expect(unplaced).To(Equal(map[string]int{"worker": 1}))
+		})
+
+		It("doesn't split an instance across cells", func() {
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "db", Instances: 1, MemoryMB: 1536},
+			})
+
+			Expect(unplaced).To(Equal(map[string]int{"db": 1}))
+		})
+
+		It("needs a free container for each instance", func() {
+			usage.Cells[1].Containers = 0
+
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "web", Instances: 9},
+			})
+
+			Expect(unplaced).To(Equal(map[string]int{"web": 1}))
+		})
+
+		It("needs room on the disk", func() {
+			unplaced := usage.Unplaced([]cluster_examiner.Placement{
+				{AppName: "web", Instances: 3, DiskMB: 3072},
+			})
+
+			Expect(unplaced).To(Equal(map[string]int{"web": 1}))
+		})
+	})
+})
@@ -0,0 +1,210 @@
+package cluster_tester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	TestAppDockerImage  = "cloudfoundry/lattice-app"
+	TestTaskDockerImage = "docker:///busybox#latest"
+	TestTaskOutput      = "ltc test-cluster task ran"
+)
+
+//go:generate counterfeiter -o fake_cluster_tester/fake_cluster_tester.go . ClusterTester
+type ClusterTester interface {
+	CreateApp(appName string, timeout time.Duration) error
+	CheckRoute(appName string, timeout time.Duration) error
+	StreamLog(appName string, timeout time.Duration) error
+	RunTask(taskName string, timeout time.Duration) error
+	RemoveApp(appName string) error
+	DeleteTask(taskName string) error
+}
+
+type ClusterTesterConfig struct {
+	AppRunner    docker_app_runner.AppRunner
+	AppExaminer  app_examiner.AppExaminer
+	LogReader    logs.LogReader
+	TaskRunner   task_runner.TaskRunner
+	TaskExaminer task_examiner.TaskExaminer
+	HTTPClient   *http.Client
+	Clock        clock.Clock
+	Domain       string
+}
+
+type clusterTester struct {
+	ClusterTesterConfig
+}
+
+func New(config ClusterTesterConfig) ClusterTester {
+	return &clusterTester{config}
+}
+
+func (t *clusterTester) CreateApp(appName string, timeout time.Duration) error {
+	err := t.AppRunner.CreateDockerApp(docker_app_runner.CreateDockerAppParams{
+		Name:                 appName,
+		DockerImagePath:      TestAppDockerImage,
+		StartCommand:         "/lattice-app",
+		AppArgs:              []string{"--message", "Hello from ltc test-cluster"},
+		EnvironmentVariables: map[string]string{"APP_NAME": appName},
+		Instances:            1,
+		MemoryMB:             128,
+		CPUWeight:            100,
+		ExposedPorts:         []uint16{8080},
+		WorkingDir:           "/",
+		Monitor: docker_app_runner.MonitorConfig{
+			Method:  docker_app_runner.PortMonitor,
+			Port:    8080,
+			Timeout: time.Second,
+		},
+		Timeout: timeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	return t.pollUntilDone(timeout, func() (bool, error) {
+		runningInstances, placementError, err := t.AppExaminer.RunningAppInstancesInfo(appName)
+		if err != nil {
+			return false, err
+		} else if placementError {
+			return true, docker_app_runner.InsufficientResourcesError{AppName: appName}
+		} else if runningInstances != 1 {
+			return false, errors.New("timed out waiting for the app to start")
+		}
+		return true, nil
+	})
+}
+
+func (t *clusterTester) CheckRoute(appName string, timeout time.Duration) error {
+	url := fmt.Sprintf("http://%s.%s", appName, t.Domain)
+
+	return t.pollUntilDone(timeout, func() (bool, error) {
+		response, err := t.HTTPClient.Get(url)
+		if err != nil {
+			return false, err
+		}
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("GET %s returned status code %d", url, response.StatusCode)
+		}
+		return true, nil
+	})
+}
+
+// StreamLog waits for a single log line from the app.  Errors from the log
+// stream are only reported if no line arrives before the timeout.
+func (t *clusterTester) StreamLog(appName string, timeout time.Duration) error {
+	receivedLog := make(chan struct{}, 1)
+	lastErr := make(chan error, 1)
+
+	go t.LogReader.TailLogs(appName, func(logMessage *events.LogMessage) {
+		if logMessage.GetAppId() != appName {
+			return
+		}
+		select {
+		case receivedLog <- struct{}{}:
+		default:
+		}
+	}, func(err error) {
+		select {
+		case <-lastErr:
+		default:
+		}
+		lastErr <- err
+	})
+	defer t.LogReader.StopTailing()
+
+	timer := t.Clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-receivedLog:
+		return nil
+	case <-timer.C():
+		select {
+		case err := <-lastErr:
+			return fmt.Errorf("timed out waiting for a log line: %s", err)
+		default:
+			return errors.New("timed out waiting for a log line")
+		}
+	}
+}
+
+func (t *clusterTester) RunTask(taskName string, timeout time.Duration) error {
+	taskJson, err := json.Marshal(receptor.TaskCreateRequest{
+		TaskGuid:   taskName,
+		Domain:     "lattice",
+		RootFS:     TestTaskDockerImage,
+		LogGuid:    taskName,
+		LogSource:  "TASK",
+		MemoryMB:   32,
+		CPUWeight:  100,
+		ResultFile: "/tmp/result",
+		Privileged: true,
+		Action: &models.RunAction{
+			Path: "sh",
+			Args: []string{"-c", fmt.Sprintf("echo -n '%s' > /tmp/result", TestTaskOutput)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.TaskRunner.SubmitTask(taskJson); err != nil {
+		return err
+	}
+
+	return t.pollUntilDone(timeout, func() (bool, error) {
+		taskInfo, err := t.TaskExaminer.TaskStatus(taskName)
+		if err != nil {
+			return false, err
+		} else if taskInfo.State != receptor.TaskStateCompleted {
+			return false, fmt.Errorf("timed out waiting for the task to complete (state: %s)", taskInfo.State)
+		} else if taskInfo.Failed {
+			return true, fmt.Errorf("task failed: %s", taskInfo.FailureReason)
+		} else if taskInfo.Result != TestTaskOutput {
+			return true, fmt.Errorf("unexpected task result: %q", taskInfo.Result)
+		}
+		return true, nil
+	})
+}
+
+func (t *clusterTester) RemoveApp(appName string) error {
+	return t.AppRunner.RemoveApp(appName)
+}
+
+func (t *clusterTester) DeleteTask(taskName string) error {
+	return t.TaskRunner.DeleteTask(taskName)
+}
+
+// pollUntilDone calls check once a second until it reports done or the
+// timeout elapses, returning the last error from check.
+func (t *clusterTester) pollUntilDone(timeout time.Duration, check func() (bool, error)) error {
+	startingTime := t.Clock.Now()
+	for {
+		done, err := check()
+		if done || !startingTime.Add(timeout).After(t.Clock.Now()) {
+			return err
+		}
+
+		t.Clock.Sleep(1 * time.Second)
+	}
+}
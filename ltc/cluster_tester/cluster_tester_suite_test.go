@@ -0,0 +1,13 @@
+package cluster_tester_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestClusterTester(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ClusterTester Suite")
+}
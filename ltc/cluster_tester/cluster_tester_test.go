@@ -0,0 +1,238 @@
+package cluster_tester_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/fake_log_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/fake_task_runner"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+type fakeRoundTripper struct {
+	requests   []*http.Request
+	statusCode int
+	err        error
+}
+
+func (f *fakeRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &http.Response{StatusCode: f.statusCode, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+var _ = Describe("ClusterTester", func() {
+	var (
+		fakeAppRunner    *fake_app_runner.FakeAppRunner
+		fakeAppExaminer  *fake_app_examiner.FakeAppExaminer
+		fakeLogReader    *fake_log_reader.FakeLogReader
+		fakeTaskRunner   *fake_task_runner.FakeTaskRunner
+		fakeTaskExaminer *fake_task_examiner.FakeTaskExaminer
+		fakeTransport    *fakeRoundTripper
+		fakeClock        *fakeclock.FakeClock
+		clusterTester    cluster_tester.ClusterTester
+	)
+
+	buildLogMessage := func(appId string) *events.LogMessage {
+		return &events.LogMessage{
+			Message: []byte("hello"),
+			AppId:   &appId,
+		}
+	}
+
+	BeforeEach(func() {
+		fakeAppRunner = &fake_app_runner.FakeAppRunner{}
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeLogReader = fake_log_reader.NewFakeLogReader()
+		fakeTaskRunner = &fake_task_runner.FakeTaskRunner{}
+		fakeTaskExaminer = &fake_task_examiner.FakeTaskExaminer{}
+		fakeTransport = &fakeRoundTripper{statusCode: http.StatusOK}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		clusterTester = cluster_tester.New(cluster_tester.ClusterTesterConfig{
+			AppRunner:    fakeAppRunner,
+			AppExaminer:  fakeAppExaminer,
+			LogReader:    fakeLogReader,
+			TaskRunner:   fakeTaskRunner,
+			TaskExaminer: fakeTaskExaminer,
+			HTTPClient:   &http.Client{Transport: fakeTransport},
+			Clock:        fakeClock,
+			Domain:       "lattice.example.com",
+		})
+	})
+
+	Describe("CreateApp", func() {
+		It("creates the test app and waits for it to run", func() {
+			fakeAppExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+
+			err := clusterTester.CreateApp("test-app", time.Minute)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeAppRunner.CreateDockerAppCallCount()).To(Equal(1))
+			params := fakeAppRunner.CreateDockerAppArgsForCall(0)
+			Expect(params.Name).To(Equal("test-app"))
+			Expect(params.DockerImagePath).To(Equal(cluster_tester.TestAppDockerImage))
+			Expect(params.Instances).To(Equal(1))
+			Expect(params.Monitor.Method).To(Equal(docker_app_runner.PortMonitor))
+			Expect(fakeAppExaminer.RunningAppInstancesInfoArgsForCall(0)).To(Equal("test-app"))
+		})
+
+		It("returns errors from creating the app", func() {
+			fakeAppRunner.CreateDockerAppReturns(errors.New("no room"))
+
+			err := clusterTester.CreateApp("test-app", time.Minute)
+
+			Expect(err).To(MatchError("no room"))
+			Expect(fakeAppExaminer.RunningAppInstancesInfoCallCount()).To(BeZero())
+		})
+
+		It("stops waiting when the app cannot be placed", func() {
+			fakeAppExaminer.RunningAppInstancesInfoReturns(0, true, nil)
+
+			err := clusterTester.CreateApp("test-app", time.Minute)
+
+			Expect(err).To(Equal(docker_app_runner.InsufficientResourcesError{AppName: "test-app"}))
+			Expect(fakeAppExaminer.RunningAppInstancesInfoCallCount()).To(Equal(1))
+		})
+
+		It("polls until the timeout elapses", func() {
+			fakeAppExaminer.RunningAppInstancesInfoReturns(0, false, nil)
+
+			errChan := make(chan error)
+			go func() {
+				errChan <- clusterTester.CreateApp("test-app", 2*time.Second)
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.IncrementBySeconds(1)
+			Eventually(fakeAppExaminer.RunningAppInstancesInfoCallCount).Should(Equal(2))
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.IncrementBySeconds(1)
+
+			Eventually(errChan).Should(Receive(MatchError("timed out waiting for the app to start")))
+			Expect(fakeAppExaminer.RunningAppInstancesInfoCallCount()).To(Equal(3))
+		})
+	})
+
+	Describe("CheckRoute", func() {
+		It("requests the app's default route", func() {
+			err := clusterTester.CheckRoute("test-app", time.Minute)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeTransport.requests).To(HaveLen(1))
+			Expect(fakeTransport.requests[0].URL.String()).To(Equal("http://test-app.lattice.example.com"))
+		})
+
+		It("returns the last failure once the timeout elapses", func() {
+			fakeTransport.statusCode = http.StatusNotFound
+
+			err := clusterTester.CheckRoute("test-app", 0)
+
+			Expect(err).To(MatchError("GET http://test-app.lattice.example.com returned status code 404"))
+		})
+	})
+
+	Describe("StreamLog", func() {
+		It("succeeds once a log line from the app arrives", func() {
+			fakeLogReader.AddLog(buildLogMessage("test-app"))
+
+			err := clusterTester.StreamLog("test-app", time.Minute)
+
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeLogReader.IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("times out when no log line from the app arrives", func() {
+			fakeLogReader.AddLog(buildLogMessage("other-app"))
+			fakeLogReader.AddError(errors.New("websocket closed"))
+
+			errChan := make(chan error)
+			go func() {
+				errChan <- clusterTester.StreamLog("test-app", time.Second)
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.IncrementBySeconds(1)
+
+			Eventually(errChan).Should(Receive(MatchError("timed out waiting for a log line: websocket closed")))
+		})
+	})
+
+	Describe("RunTask", func() {
+		It("submits a task and checks its result", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{
+				State:  receptor.TaskStateCompleted,
+				Result: cluster_tester.TestTaskOutput,
+			}, nil)
+
+			err := clusterTester.RunTask("test-task", time.Minute)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeTaskRunner.SubmitTaskCallCount()).To(Equal(1))
+			task := receptor.TaskCreateRequest{}
+			Expect(json.Unmarshal(fakeTaskRunner.SubmitTaskArgsForCall(0), &task)).To(Succeed())
+			Expect(task.TaskGuid).To(Equal("test-task"))
+			Expect(task.RootFS).To(Equal(cluster_tester.TestTaskDockerImage))
+			Expect(task.Action).To(BeAssignableToTypeOf(&models.RunAction{}))
+			Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("test-task"))
+		})
+
+		It("returns errors from submitting the task", func() {
+			fakeTaskRunner.SubmitTaskReturns("test-task", errors.New("already submitted"))
+
+			err := clusterTester.RunTask("test-task", time.Minute)
+
+			Expect(err).To(MatchError("already submitted"))
+		})
+
+		It("reports failed tasks", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{
+				State:         receptor.TaskStateCompleted,
+				Failed:        true,
+				FailureReason: "exit status 1",
+			}, nil)
+
+			err := clusterTester.RunTask("test-task", time.Minute)
+
+			Expect(err).To(MatchError("task failed: exit status 1"))
+		})
+
+		It("reports tasks that do not complete in time", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: receptor.TaskStateRunning}, nil)
+
+			err := clusterTester.RunTask("test-task", 0)
+
+			Expect(err).To(MatchError("timed out waiting for the task to complete (state: RUNNING)"))
+		})
+	})
+
+	Describe("RemoveApp and DeleteTask", func() {
+		It("cleans up with the app and task runners", func() {
+			fakeAppRunner.RemoveAppReturns(errors.New("app gone"))
+
+			Expect(clusterTester.RemoveApp("test-app")).To(MatchError("app gone"))
+			Expect(clusterTester.DeleteTask("test-task")).To(Succeed())
+
+			Expect(fakeAppRunner.RemoveAppArgsForCall(0)).To(Equal("test-app"))
+			Expect(fakeTaskRunner.DeleteTaskArgsForCall(0)).To(Equal("test-task"))
+		})
+	})
+})
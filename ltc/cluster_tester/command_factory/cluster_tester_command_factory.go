@@ -0,0 +1,111 @@
+package command_factory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+	"github.com/nu7hatch/gouuid"
+)
+
+type ClusterTesterCommandFactory struct {
+	clusterTester cluster_tester.ClusterTester
+	ui            terminal.UI
+	exitHandler   exit_handler.ExitHandler
+	target        string
+}
+
+func NewClusterTesterCommandFactory(clusterTester cluster_tester.ClusterTester, ui terminal.UI, exitHandler exit_handler.ExitHandler, target string) *ClusterTesterCommandFactory {
+	return &ClusterTesterCommandFactory{clusterTester, ui, exitHandler, target}
+}
+
+func (factory *ClusterTesterCommandFactory) MakeTestClusterCommand() cli.Command {
+	var testClusterFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Duration of time each step will wait for lattice to respond",
+			Value: time.Minute * 2,
+		},
+	}
+
+	var testClusterCommand = cli.Command{
+		Name:    "test-cluster",
+		Aliases: []string{"tc"},
+		Usage:   "Verifies the targeted lattice cluster end to end",
+		Description: `ltc test-cluster [--timeout=TIMEOUT]
+
+   Creates a small test app, checks that it is routable and that its logs
+   can be streamed, runs a task, then removes the app and task.`,
+		Action: factory.testCluster,
+		Flags:  testClusterFlags,
+	}
+
+	return testClusterCommand
+}
+
+func (factory *ClusterTesterCommandFactory) testCluster(context *cli.Context) {
+	timeout := context.Duration("timeout")
+
+	guid, err := uuid.NewV4()
+	if err != nil {
+		factory.ui.Say("Error generating test names: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	appName := "ltc-test-cluster-app-" + guid.String()
+	taskName := "ltc-test-cluster-task-" + guid.String()
+
+	factory.ui.SayLine(fmt.Sprintf("Testing lattice cluster %s", factory.target))
+
+	passed := true
+	step := func(description string, run func() error) bool {
+		factory.ui.Say(description + "... ")
+		if err := run(); err != nil {
+			factory.ui.SayLine(colors.Red("FAIL") + ": " + err.Error())
+			passed = false
+			return false
+		}
+		factory.ui.SayLine(colors.Green("PASS"))
+		return true
+	}
+	skip := func(description string) {
+		factory.ui.SayLine(description + "... " + colors.Gray("SKIPPED"))
+	}
+
+	appCreated := step("Creating app "+appName, func() error {
+		return factory.clusterTester.CreateApp(appName, timeout)
+	})
+	if appCreated {
+		step("Checking route", func() error {
+			return factory.clusterTester.CheckRoute(appName, timeout)
+		})
+		step("Streaming logs", func() error {
+			return factory.clusterTester.StreamLog(appName, timeout)
+		})
+	} else {
+		skip("Checking route")
+		skip("Streaming logs")
+	}
+	step("Running task "+taskName, func() error {
+		return factory.clusterTester.RunTask(taskName, timeout)
+	})
+
+	step("Removing app", func() error {
+		return factory.clusterTester.RemoveApp(appName)
+	})
+	step("Deleting task", func() error {
+		return factory.clusterTester.DeleteTask(taskName)
+	})
+
+	if !passed {
+		factory.ui.SayLine(colors.Red("Cluster test failed."))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	factory.ui.SayLine(colors.Green("Cluster test passed."))
+}
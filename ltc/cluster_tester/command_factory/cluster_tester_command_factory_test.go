@@ -0,0 +1,115 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester/fake_cluster_tester"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("ClusterTesterCommandFactory", func() {
+	var (
+		fakeClusterTester *fake_cluster_tester.FakeClusterTester
+		outputBuffer      *gbytes.Buffer
+		fakeExitHandler   *fake_exit_handler.FakeExitHandler
+	)
+
+	BeforeEach(func() {
+		fakeClusterTester = &fake_cluster_tester.FakeClusterTester{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+	})
+
+	Describe("TestClusterCommand", func() {
+		var testClusterCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewClusterTesterCommandFactory(fakeClusterTester, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler, "lattice.example.com")
+			testClusterCommand = commandFactory.MakeTestClusterCommand()
+		})
+
+		It("runs each step against the same app and task, then cleans up", func() {
+			test_helpers.ExecuteCommandWithArgs(testClusterCommand, []string{"--timeout=30s"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Testing lattice cluster lattice.example.com"))
+			Expect(outputBuffer).To(test_helpers.Say("Creating app ltc-test-cluster-app-"))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Checking route... "))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Streaming logs... "))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Running task ltc-test-cluster-task-"))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Removing app... "))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Deleting task... "))
+			Expect(outputBuffer).To(test_helpers.Say("PASS"))
+			Expect(outputBuffer).To(test_helpers.Say("Cluster test passed."))
+
+			appName, timeout := fakeClusterTester.CreateAppArgsForCall(0)
+			Expect(appName).To(HavePrefix("ltc-test-cluster-app-"))
+			Expect(timeout).To(Equal(30 * time.Second))
+
+			routeAppName, _ := fakeClusterTester.CheckRouteArgsForCall(0)
+			Expect(routeAppName).To(Equal(appName))
+			logsAppName, _ := fakeClusterTester.StreamLogArgsForCall(0)
+			Expect(logsAppName).To(Equal(appName))
+			Expect(fakeClusterTester.RemoveAppArgsForCall(0)).To(Equal(appName))
+
+			taskName, _ := fakeClusterTester.RunTaskArgsForCall(0)
+			Expect(taskName).To(HavePrefix("ltc-test-cluster-task-"))
+			Expect(fakeClusterTester.DeleteTaskArgsForCall(0)).To(Equal(taskName))
+
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("defaults the timeout to two minutes", func() {
+			test_helpers.ExecuteCommandWithArgs(testClusterCommand, []string{})
+
+			_, timeout := fakeClusterTester.CreateAppArgsForCall(0)
+			Expect(timeout).To(Equal(2 * time.Minute))
+		})
+
+		It("reports failing steps and exits non-zero", func() {
+			fakeClusterTester.RunTaskReturns(errors.New("task failed: exit status 1"))
+
+			test_helpers.ExecuteCommandWithArgs(testClusterCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Running task ltc-test-cluster-task-"))
+			Expect(outputBuffer).To(test_helpers.Say("FAIL"))
+			Expect(outputBuffer).To(test_helpers.Say(": task failed: exit status 1"))
+			Expect(outputBuffer).To(test_helpers.Say("Cluster test failed."))
+			Expect(fakeClusterTester.DeleteTaskCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("skips the app checks when the app cannot be created, but still cleans up", func() {
+			fakeClusterTester.CreateAppReturns(errors.New("could not place the app"))
+
+			test_helpers.ExecuteCommandWithArgs(testClusterCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("FAIL"))
+			Expect(outputBuffer).To(test_helpers.Say("could not place the app"))
+			Expect(outputBuffer).To(test_helpers.Say("Checking route... "))
+			Expect(outputBuffer).To(test_helpers.Say("SKIPPED"))
+			Expect(outputBuffer).To(test_helpers.Say("Streaming logs... "))
+			Expect(outputBuffer).To(test_helpers.Say("SKIPPED"))
+
+			Expect(fakeClusterTester.CheckRouteCallCount()).To(BeZero())
+			Expect(fakeClusterTester.StreamLogCallCount()).To(BeZero())
+			Expect(fakeClusterTester.RunTaskCallCount()).To(Equal(1))
+			Expect(fakeClusterTester.RemoveAppCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+})
@@ -0,0 +1,262 @@
+// This file was generated by counterfeiter
+package fake_cluster_tester
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/cluster_tester"
+)
+
+type FakeClusterTester struct {
+	CreateAppStub        func(appName string, timeout time.Duration) error
+	createAppMutex       sync.RWMutex
+	createAppArgsForCall []struct {
+		appName string
+		timeout time.Duration
+	}
+	createAppReturns struct {
+		result1 error
+	}
+	CheckRouteStub        func(appName string, timeout time.Duration) error
+	checkRouteMutex       sync.RWMutex
+	checkRouteArgsForCall []struct {
+		appName string
+		timeout time.Duration
+	}
+	checkRouteReturns struct {
+		result1 error
+	}
+	StreamLogStub        func(appName string, timeout time.Duration) error
+	streamLogMutex       sync.RWMutex
+	streamLogArgsForCall []struct {
+		appName string
+		timeout time.Duration
+	}
+	streamLogReturns struct {
+		result1 error
+	}
+	RunTaskStub        func(taskName string, timeout time.Duration) error
+	runTaskMutex       sync.RWMutex
+	runTaskArgsForCall []struct {
+		taskName string
+		timeout  time.Duration
+	}
+	runTaskReturns struct {
+		result1 error
+	}
+	RemoveAppStub        func(appName string) error
+	removeAppMutex       sync.RWMutex
+	removeAppArgsForCall []struct {
+		appName string
+	}
+	removeAppReturns struct {
+		result1 error
+	}
+	DeleteTaskStub        func(taskName string) error
+	deleteTaskMutex       sync.RWMutex
+	deleteTaskArgsForCall []struct {
+		taskName string
+	}
+	deleteTaskReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeClusterTester) CreateApp(appName string, timeout time.Duration) error {
+	fake.createAppMutex.Lock()
+	fake.createAppArgsForCall = append(fake.createAppArgsForCall, struct {
+		appName string
+		timeout time.Duration
+	}{appName, timeout})
+	fake.createAppMutex.Unlock()
+	if fake.CreateAppStub != nil {
+		return fake.CreateAppStub(appName, timeout)
+	} else {
+		return fake.createAppReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) CreateAppCallCount() int {
+	fake.createAppMutex.RLock()
+	defer fake.createAppMutex.RUnlock()
+	return len(fake.createAppArgsForCall)
+}
+
+func (fake *FakeClusterTester) CreateAppArgsForCall(i int) (string, time.Duration) {
+	fake.createAppMutex.RLock()
+	defer fake.createAppMutex.RUnlock()
+	return fake.createAppArgsForCall[i].appName, fake.createAppArgsForCall[i].timeout
+}
+
+func (fake *FakeClusterTester) CreateAppReturns(result1 error) {
+	fake.CreateAppStub = nil
+	fake.createAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterTester) CheckRoute(appName string, timeout time.Duration) error {
+	fake.checkRouteMutex.Lock()
+	fake.checkRouteArgsForCall = append(fake.checkRouteArgsForCall, struct {
+		appName string
+		timeout time.Duration
+	}{appName, timeout})
+	fake.checkRouteMutex.Unlock()
+	if fake.CheckRouteStub != nil {
+		return fake.CheckRouteStub(appName, timeout)
+	} else {
+		return fake.checkRouteReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) CheckRouteCallCount() int {
+	fake.checkRouteMutex.RLock()
+	defer fake.checkRouteMutex.RUnlock()
+	return len(fake.checkRouteArgsForCall)
+}
+
+func (fake *FakeClusterTester) CheckRouteArgsForCall(i int) (string, time.Duration) {
+	fake.checkRouteMutex.RLock()
+	defer fake.checkRouteMutex.RUnlock()
+	return fake.checkRouteArgsForCall[i].appName, fake.checkRouteArgsForCall[i].timeout
+}
+
+func (fake *FakeClusterTester) CheckRouteReturns(result1 error) {
+	fake.CheckRouteStub = nil
+	fake.checkRouteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterTester) StreamLog(appName string, timeout time.Duration) error {
+	fake.streamLogMutex.Lock()
+	fake.streamLogArgsForCall = append(fake.streamLogArgsForCall, struct {
+		appName string
+		timeout time.Duration
+	}{appName, timeout})
+	fake.streamLogMutex.Unlock()
+	if fake.StreamLogStub != nil {
+		return fake.StreamLogStub(appName, timeout)
+	} else {
+		return fake.streamLogReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) StreamLogCallCount() int {
+	fake.streamLogMutex.RLock()
+	defer fake.streamLogMutex.RUnlock()
+	return len(fake.streamLogArgsForCall)
+}
+
+func (fake *FakeClusterTester) StreamLogArgsForCall(i int) (string, time.Duration) {
+	fake.streamLogMutex.RLock()
+	defer fake.streamLogMutex.RUnlock()
+	return fake.streamLogArgsForCall[i].appName, fake.streamLogArgsForCall[i].timeout
+}
+
+func (fake *FakeClusterTester) StreamLogReturns(result1 error) {
+	fake.StreamLogStub = nil
+	fake.streamLogReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterTester) RunTask(taskName string, timeout time.Duration) error {
+	fake.runTaskMutex.Lock()
+	fake.runTaskArgsForCall = append(fake.runTaskArgsForCall, struct {
+		taskName string
+		timeout  time.Duration
+	}{taskName, timeout})
+	fake.runTaskMutex.Unlock()
+	if fake.RunTaskStub != nil {
+		return fake.RunTaskStub(taskName, timeout)
+	} else {
+		return fake.runTaskReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) RunTaskCallCount() int {
+	fake.runTaskMutex.RLock()
+	defer fake.runTaskMutex.RUnlock()
+	return len(fake.runTaskArgsForCall)
+}
+
+func (fake *FakeClusterTester) RunTaskArgsForCall(i int) (string, time.Duration) {
+	fake.runTaskMutex.RLock()
+	defer fake.runTaskMutex.RUnlock()
+	return fake.runTaskArgsForCall[i].taskName, fake.runTaskArgsForCall[i].timeout
+}
+
+func (fake *FakeClusterTester) RunTaskReturns(result1 error) {
+	fake.RunTaskStub = nil
+	fake.runTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterTester) RemoveApp(appName string) error {
+	fake.removeAppMutex.Lock()
+	fake.removeAppArgsForCall = append(fake.removeAppArgsForCall, struct {
+		appName string
+	}{appName})
+	fake.removeAppMutex.Unlock()
+	if fake.RemoveAppStub != nil {
+		return fake.RemoveAppStub(appName)
+	} else {
+		return fake.removeAppReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) RemoveAppCallCount() int {
+	fake.removeAppMutex.RLock()
+	defer fake.removeAppMutex.RUnlock()
+	return len(fake.removeAppArgsForCall)
+}
+
+func (fake *FakeClusterTester) RemoveAppArgsForCall(i int) string {
+	fake.removeAppMutex.RLock()
+	defer fake.removeAppMutex.RUnlock()
+	return fake.removeAppArgsForCall[i].appName
+}
+
+func (fake *FakeClusterTester) RemoveAppReturns(result1 error) {
+	fake.RemoveAppStub = nil
+	fake.removeAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterTester) DeleteTask(taskName string) error {
+	fake.deleteTaskMutex.Lock()
+	fake.deleteTaskArgsForCall = append(fake.deleteTaskArgsForCall, struct {
+		taskName string
+	}{taskName})
+	fake.deleteTaskMutex.Unlock()
+	if fake.DeleteTaskStub != nil {
+		return fake.DeleteTaskStub(taskName)
+	} else {
+		return fake.deleteTaskReturns.result1
+	}
+}
+
+func (fake *FakeClusterTester) DeleteTaskCallCount() int {
+	fake.deleteTaskMutex.RLock()
+	defer fake.deleteTaskMutex.RUnlock()
+	return len(fake.deleteTaskArgsForCall)
+}
+
+func (fake *FakeClusterTester) DeleteTaskArgsForCall(i int) string {
+	fake.deleteTaskMutex.RLock()
+	defer fake.deleteTaskMutex.RUnlock()
+	return fake.deleteTaskArgsForCall[i].taskName
+}
+
+func (fake *FakeClusterTester) DeleteTaskReturns(result1 error) {
+	fake.DeleteTaskStub = nil
+	fake.deleteTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ cluster_tester.ClusterTester = new(FakeClusterTester)
@@ -0,0 +1,116 @@
+package command_factory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/completion"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/codegangsta/cli"
+)
+
+const CompletionCommandName = "completion"
+
+var DynamicArgs = completion.DynamicArgs{
+	"events":        completion.AppNameArg,
+	"logs":          completion.AppNameArg,
+	"metrics":       completion.AppNameArg,
+	"remove":        completion.AppNameArg,
+	"scale":         completion.AppNameArg,
+	"status":        completion.AppNameArg,
+	"update-routes": completion.AppNameArg,
+	"task":          completion.TaskNameArg,
+	"task-result":   completion.TaskNameArg,
+	"cancel-task":   completion.TaskNameArg,
+	"retry-task":    completion.TaskNameArg,
+	"delete-task":   completion.TaskNameArg,
+}
+
+type CompletionCommandFactory struct {
+	appExaminer  app_examiner.AppExaminer
+	taskExaminer task_examiner.TaskExaminer
+	ui           terminal.UI
+	exitHandler  exit_handler.ExitHandler
+}
+
+func NewCompletionCommandFactory(appExaminer app_examiner.AppExaminer, taskExaminer task_examiner.TaskExaminer, ui terminal.UI, exitHandler exit_handler.ExitHandler) *CompletionCommandFactory {
+	return &CompletionCommandFactory{appExaminer, taskExaminer, ui, exitHandler}
+}
+
+func (factory *CompletionCommandFactory) MakeCompletionCommand() cli.Command {
+	var completionFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "apps",
+			Usage: "Lists app names, for use by completion scripts",
+		},
+		cli.BoolFlag{
+			Name:  "tasks",
+			Usage: "Lists task guids, for use by completion scripts",
+		},
+	}
+
+	var completionCommand = cli.Command{
+		Name:  CompletionCommandName,
+		Usage: "Generates a shell completion script",
+		Description: `ltc completion bash|zsh|fish
+
+   To enable completion in the current bash session:
+   source <(ltc completion bash)
+
+   For fish, save the script to your completions directory:
+   ltc completion fish > ~/.config/fish/completions/ltc.fish`,
+		Action: factory.completion,
+		Flags:  completionFlags,
+	}
+
+	return completionCommand
+}
+
+func (factory *CompletionCommandFactory) completion(context *cli.Context) {
+	switch {
+	case context.Bool(string(completion.AppNameArg)):
+		factory.listApps()
+		return
+	case context.Bool(string(completion.TaskNameArg)):
+		factory.listTasks()
+		return
+	}
+
+	shell := context.Args().First()
+	generator, ok := completion.Generators[shell]
+	if !ok {
+		factory.ui.SayIncorrectUsage(fmt.Sprintf("SHELL must be one of: %s", strings.Join(completion.Shells(), ", ")))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	factory.ui.Say(generator(context.App.Name, context.App.Commands, DynamicArgs))
+}
+
+// Listing errors are swallowed so that a missing or unreachable target
+// simply yields no completions.
+func (factory *CompletionCommandFactory) listApps() {
+	apps, err := factory.appExaminer.ListApps()
+	if err != nil {
+		return
+	}
+
+	for _, app := range apps {
+		factory.ui.SayLine(app.ProcessGuid)
+	}
+}
+
+func (factory *CompletionCommandFactory) listTasks() {
+	tasks, err := factory.taskExaminer.ListTasks()
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		factory.ui.SayLine(task.TaskGuid)
+	}
+}
@@ -0,0 +1,107 @@
+package command_factory_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/completion/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("CompletionCommandFactory", func() {
+	var (
+		fakeAppExaminer   *fake_app_examiner.FakeAppExaminer
+		fakeTaskExaminer  *fake_task_examiner.FakeTaskExaminer
+		outputBuffer      *gbytes.Buffer
+		fakeExitHandler   *fake_exit_handler.FakeExitHandler
+		completionCommand cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeTaskExaminer = &fake_task_examiner.FakeTaskExaminer{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		commandFactory := command_factory.NewCompletionCommandFactory(fakeAppExaminer, fakeTaskExaminer, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+		completionCommand = commandFactory.MakeCompletionCommand()
+	})
+
+	Describe("CompletionCommand", func() {
+		It("generates a bash completion script from the app's commands", func() {
+			test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"bash"})
+
+			Expect(outputBuffer).To(test_helpers.Say("# bash completion for "))
+			Expect(outputBuffer.Contents()).To(ContainSubstring("completion"))
+			Expect(outputBuffer.Contents()).To(ContainSubstring("complete -F"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("generates a fish completion script", func() {
+			test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"fish"})
+
+			Expect(outputBuffer).To(test_helpers.Say("# fish completion for "))
+		})
+
+		It("requires a supported shell", func() {
+			test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"powershell"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: SHELL must be one of: bash, fish, zsh"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("when listing apps for completion", func() {
+			It("prints one app name per line", func() {
+				fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					app_examiner.AppInfo{ProcessGuid: "app-one"},
+					app_examiner.AppInfo{ProcessGuid: "app-two"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"--apps"})
+
+				Expect(outputBuffer).To(test_helpers.Say("app-one\napp-two\n"))
+			})
+
+			It("prints nothing when the apps cannot be listed", func() {
+				fakeAppExaminer.ListAppsReturns(nil, errors.New("no target"))
+
+				test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"--apps"})
+
+				Expect(outputBuffer.Contents()).To(BeEmpty())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
+		Context("when listing tasks for completion", func() {
+			It("prints one task guid per line", func() {
+				fakeTaskExaminer.ListTasksReturns([]task_examiner.TaskInfo{
+					task_examiner.TaskInfo{TaskGuid: "task-one"},
+					task_examiner.TaskInfo{TaskGuid: "task-two"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"--tasks"})
+
+				Expect(outputBuffer).To(test_helpers.Say("task-one\ntask-two\n"))
+			})
+
+			It("prints nothing when the tasks cannot be listed", func() {
+				fakeTaskExaminer.ListTasksReturns(nil, errors.New("no target"))
+
+				test_helpers.ExecuteCommandWithArgs(completionCommand, []string{"--tasks"})
+
+				Expect(outputBuffer.Contents()).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+type ArgKind string
+
+const (
+	AppNameArg  ArgKind = "apps"
+	TaskNameArg ArgKind = "tasks"
+)
+
+// DynamicArgs maps a command name to the kind of cluster resource its
+// arguments name, so the generated scripts can complete them by asking ltc.
+type DynamicArgs map[string]ArgKind
+
+type Generator func(programName string, commands []cli.Command, dynamicArgs DynamicArgs) string
+
+var Generators = map[string]Generator{
+	"bash": GenerateBash,
+	"zsh":  GenerateZsh,
+	"fish": GenerateFish,
+}
+
+func Shells() []string {
+	shells := make([]string, 0, len(Generators))
+	for shell := range Generators {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+func GenerateBash(programName string, commands []cli.Command, dynamicArgs DynamicArgs) string {
+	funcName := "_" + strings.Replace(programName, "-", "_", -1)
+	script := &bytes.Buffer{}
+
+	fmt.Fprintf(script, "# bash completion for %s\n\n", programName)
+	fmt.Fprintf(script, "%s() {\n", funcName)
+	fmt.Fprintf(script, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(script, "    local cmd=\"${COMP_WORDS[1]}\"\n")
+	fmt.Fprintf(script, "    local flags=\"\"\n\n")
+	fmt.Fprintf(script, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(script, "        COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(commandNames(commands), " "))
+	fmt.Fprintf(script, "        return 0\n")
+	fmt.Fprintf(script, "    fi\n\n")
+
+	fmt.Fprintf(script, "    case \"$cmd\" in\n")
+	for _, command := range commands {
+		if flags := flagNames(command); len(flags) > 0 {
+			fmt.Fprintf(script, "        %s) flags=\"%s\" ;;\n", strings.Join(command.Names(), "|"), strings.Join(flags, " "))
+		}
+	}
+	fmt.Fprintf(script, "    esac\n\n")
+
+	fmt.Fprintf(script, "    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(script, "        COMPREPLY=( $(compgen -W \"$flags\" -- \"$cur\") )\n")
+	fmt.Fprintf(script, "        return 0\n")
+	fmt.Fprintf(script, "    fi\n\n")
+
+	fmt.Fprintf(script, "    case \"$cmd\" in\n")
+	for _, kind := range []ArgKind{AppNameArg, TaskNameArg} {
+		if names := dynamicCommandNames(commands, dynamicArgs, kind); len(names) > 0 {
+			fmt.Fprintf(script, "        %s) COMPREPLY=( $(compgen -W \"$(%s completion --%s 2>/dev/null)\" -- \"$cur\") ) ;;\n", strings.Join(names, "|"), programName, kind)
+		}
+	}
+	fmt.Fprintf(script, "    esac\n")
+	fmt.Fprintf(script, "}\n\n")
+	fmt.Fprintf(script, "complete -F %s %s\n", funcName, programName)
+
+	return script.String()
+}
+
+// GenerateZsh reuses the bash completion function through zsh's bashcompinit.
+func GenerateZsh(programName string, commands []cli.Command, dynamicArgs DynamicArgs) string {
+	return fmt.Sprintf("# zsh completion for %s\n\nautoload -U +X compinit && compinit\nautoload -U +X bashcompinit && bashcompinit\n\n%s",
+		programName, GenerateBash(programName, commands, dynamicArgs))
+}
+
+func GenerateFish(programName string, commands []cli.Command, dynamicArgs DynamicArgs) string {
+	script := &bytes.Buffer{}
+
+	fmt.Fprintf(script, "# fish completion for %s\n\n", programName)
+	fmt.Fprintf(script, "complete -c %s -f\n", programName)
+
+	for _, command := range commands {
+		fmt.Fprintf(script, "complete -c %s -n '__fish_use_subcommand' -a %s -d %s\n", programName, command.Name, fishQuote(command.Usage))
+	}
+
+	for _, command := range commands {
+		condition := fmt.Sprintf("'__fish_seen_subcommand_from %s'", strings.Join(command.Names(), " "))
+
+		for _, flag := range command.Flags {
+			name, usage := flagNameAndUsage(flag)
+			if name == "" {
+				continue
+			}
+
+			fmt.Fprintf(script, "complete -c %s -n %s", programName, condition)
+			for _, part := range strings.Split(name, ",") {
+				part = strings.TrimSpace(part)
+				if len(part) == 1 {
+					fmt.Fprintf(script, " -s %s", part)
+				} else {
+					fmt.Fprintf(script, " -l %s", part)
+				}
+			}
+			fmt.Fprintf(script, " -d %s\n", fishQuote(firstLine(usage)))
+		}
+
+		if kind, ok := dynamicArgs[command.Name]; ok {
+			fmt.Fprintf(script, "complete -c %s -n %s -a '(%s completion --%s 2>/dev/null)'\n", programName, condition, programName, kind)
+		}
+	}
+
+	return script.String()
+}
+
+func commandNames(commands []cli.Command) []string {
+	var names []string
+	for _, command := range commands {
+		names = append(names, command.Names()...)
+	}
+	return names
+}
+
+func dynamicCommandNames(commands []cli.Command, dynamicArgs DynamicArgs, kind ArgKind) []string {
+	var names []string
+	for _, command := range commands {
+		if dynamicArgs[command.Name] == kind {
+			names = append(names, command.Names()...)
+		}
+	}
+	return names
+}
+
+func flagNames(command cli.Command) []string {
+	var names []string
+	for _, flag := range command.Flags {
+		name, _ := flagNameAndUsage(flag)
+		for _, part := range strings.Split(name, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if len(part) == 1 {
+				names = append(names, "-"+part)
+			} else {
+				names = append(names, "--"+part)
+			}
+		}
+	}
+	return names
+}
+
+func flagNameAndUsage(flag cli.Flag) (name, usage string) {
+	switch t := flag.(type) {
+	case cli.StringSliceFlag:
+		return t.Name, t.Usage
+	case cli.IntFlag:
+		return t.Name, t.Usage
+	case cli.StringFlag:
+		return t.Name, t.Usage
+	case cli.BoolFlag:
+		return t.Name, t.Usage
+	case cli.DurationFlag:
+		return t.Name, t.Usage
+	}
+	return "", ""
+}
+
+func firstLine(text string) string {
+	return strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+}
+
+func fishQuote(text string) string {
+	return "'" + strings.Replace(text, "'", "\\'", -1) + "'"
+}
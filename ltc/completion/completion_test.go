@@ -0,0 +1,104 @@
+package completion_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/completion"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("Completion", func() {
+	var (
+		commands    []cli.Command
+		dynamicArgs completion.DynamicArgs
+	)
+
+	BeforeEach(func() {
+		commands = []cli.Command{
+			cli.Command{
+				Name:    "create",
+				Aliases: []string{"cr"},
+				Usage:   "Creates an app",
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "working-dir, w", Usage: "Working directory\n\t\tmore detail"},
+					cli.BoolFlag{Name: "run-as-root", Usage: "Runs as root"},
+				},
+			},
+			cli.Command{
+				Name:    "status",
+				Aliases: []string{"st"},
+				Usage:   "Shows an app's status",
+			},
+			cli.Command{
+				Name:  "task",
+				Usage: "Shows a task's status",
+			},
+		}
+		dynamicArgs = completion.DynamicArgs{
+			"status": completion.AppNameArg,
+			"task":   completion.TaskNameArg,
+		}
+	})
+
+	Describe("Shells", func() {
+		It("lists the supported shells", func() {
+			Expect(completion.Shells()).To(Equal([]string{"bash", "fish", "zsh"}))
+		})
+	})
+
+	Describe("GenerateBash", func() {
+		It("completes command names and aliases", func() {
+			script := completion.GenerateBash("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring(`compgen -W "create cr status st task"`))
+			Expect(script).To(ContainSubstring("complete -F _ltc ltc"))
+		})
+
+		It("completes flags for each command", func() {
+			script := completion.GenerateBash("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring(`create|cr) flags="--working-dir -w --run-as-root" ;;`))
+			Expect(script).ToNot(ContainSubstring("status|st) flags="))
+		})
+
+		It("completes app names and task guids by asking ltc", func() {
+			script := completion.GenerateBash("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring(`status|st) COMPREPLY=( $(compgen -W "$(ltc completion --apps 2>/dev/null)" -- "$cur") ) ;;`))
+			Expect(script).To(ContainSubstring(`task) COMPREPLY=( $(compgen -W "$(ltc completion --tasks 2>/dev/null)" -- "$cur") ) ;;`))
+		})
+	})
+
+	Describe("GenerateZsh", func() {
+		It("wraps the bash completion with bashcompinit", func() {
+			script := completion.GenerateZsh("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring("autoload -U +X bashcompinit && bashcompinit"))
+			Expect(script).To(ContainSubstring("complete -F _ltc ltc"))
+		})
+	})
+
+	Describe("GenerateFish", func() {
+		It("completes commands with their descriptions", func() {
+			script := completion.GenerateFish("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring("complete -c ltc -n '__fish_use_subcommand' -a create -d 'Creates an app'"))
+			Expect(script).To(ContainSubstring(`complete -c ltc -n '__fish_use_subcommand' -a status -d 'Shows an app\'s status'`))
+		})
+
+		It("completes long and short flags", func() {
+			script := completion.GenerateFish("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring("complete -c ltc -n '__fish_seen_subcommand_from create cr' -l working-dir -s w -d 'Working directory'\n"))
+			Expect(script).To(ContainSubstring("complete -c ltc -n '__fish_seen_subcommand_from create cr' -l run-as-root -d 'Runs as root'\n"))
+		})
+
+		It("completes app names and task guids by asking ltc", func() {
+			script := completion.GenerateFish("ltc", commands, dynamicArgs)
+
+			Expect(script).To(ContainSubstring("complete -c ltc -n '__fish_seen_subcommand_from status st' -a '(ltc completion --apps 2>/dev/null)'"))
+			Expect(script).To(ContainSubstring("complete -c ltc -n '__fish_seen_subcommand_from task' -a '(ltc completion --tasks 2>/dev/null)'"))
+		})
+	})
+})
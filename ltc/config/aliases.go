@@ -0,0 +1,57 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+// Aliases are user-defined shortcuts such as `ltc prodlogs` for
+// `ltc logs --prefix api`.  They are kept apart from the rest of the config
+// so that they can be expanded before the cli starts, without touching the
+// keychain.
+type Aliases struct {
+	persister persister.Persister
+	data      map[string][]string
+}
+
+func NewAliases(persister persister.Persister) *Aliases {
+	return &Aliases{persister: persister, data: make(map[string][]string)}
+}
+
+func (a *Aliases) Load() error {
+	return a.persister.Load(&a.data)
+}
+
+func (a *Aliases) Save() error {
+	return a.persister.Save(a.data)
+}
+
+func (a *Aliases) Set(name string, args []string) {
+	if a.data == nil {
+		a.data = make(map[string][]string)
+	}
+	a.data[name] = args
+}
+
+func (a *Aliases) Remove(name string) bool {
+	if _, ok := a.data[name]; !ok {
+		return false
+	}
+	delete(a.data, name)
+	return true
+}
+
+func (a *Aliases) Get(name string) ([]string, bool) {
+	args, ok := a.data[name]
+	return args, ok
+}
+
+func (a *Aliases) Names() []string {
+	names := make([]string, 0, len(a.data))
+	for name := range a.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
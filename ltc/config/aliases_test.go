@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+var _ = Describe("Aliases", func() {
+	var (
+		memPersister persister.Persister
+		aliases      *config.Aliases
+	)
+
+	BeforeEach(func() {
+		memPersister = persister.NewMemPersister()
+		aliases = config.NewAliases(memPersister)
+	})
+
+	It("sets and gets aliases", func() {
+		aliases.Set("prodlogs", []string{"logs", "--prefix", "api"})
+
+		args, ok := aliases.Get("prodlogs")
+		Expect(ok).To(BeTrue())
+		Expect(args).To(Equal([]string{"logs", "--prefix", "api"}))
+
+		_, ok = aliases.Get("devlogs")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("lists the alias names in order", func() {
+		aliases.Set("web", []string{"status", "web"})
+		aliases.Set("api", []string{"status", "api"})
+
+		Expect(aliases.Names()).To(Equal([]string{"api", "web"}))
+	})
+
+	It("removes aliases", func() {
+		aliases.Set("prodlogs", []string{"logs", "api"})
+
+		Expect(aliases.Remove("prodlogs")).To(BeTrue())
+		Expect(aliases.Remove("prodlogs")).To(BeFalse())
+		Expect(aliases.Names()).To(BeEmpty())
+	})
+
+	It("persists aliases", func() {
+		aliases.Set("prodlogs", []string{"logs", "api"})
+		Expect(aliases.Save()).To(Succeed())
+
+		reloadedAliases := config.NewAliases(memPersister)
+		Expect(reloadedAliases.Load()).To(Succeed())
+
+		args, ok := reloadedAliases.Get("prodlogs")
+		Expect(ok).To(BeTrue())
+		Expect(args).To(Equal([]string{"logs", "api"}))
+	})
+})
@@ -0,0 +1,129 @@
+package command_factory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	AliasCommandName = "alias"
+
+	aliasUsage = "Please enter 'ltc alias set NAME COMMAND [ARGS...]', 'ltc alias list' or 'ltc alias remove NAME'"
+)
+
+type AliasCommandFactory struct {
+	aliases     *config.Aliases
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewAliasCommandFactory(aliases *config.Aliases, ui terminal.UI, exitHandler exit_handler.ExitHandler) *AliasCommandFactory {
+	return &AliasCommandFactory{aliases, ui, exitHandler}
+}
+
+func (factory *AliasCommandFactory) MakeAliasCommand() cli.Command {
+	return cli.Command{
+		Name:  AliasCommandName,
+		Usage: "Manages shortcuts for ltc commands",
+		Description: `ltc alias set NAME COMMAND [ARGS...]
+   ltc alias list
+   ltc alias remove NAME
+
+   For example, after
+   ltc alias set prodlogs logs --prefix api worker
+   'ltc prodlogs' runs 'ltc logs --prefix api worker'.  Any args given to
+   the alias are appended to the command.`,
+		Action: factory.alias,
+		// The aliased command's flags are stored, not parsed.
+		SkipFlagParsing: true,
+	}
+}
+
+func (factory *AliasCommandFactory) alias(context *cli.Context) {
+	args := context.Args()
+	switch args.First() {
+	case "set":
+		factory.setAlias(context.App, args.Tail())
+	case "list":
+		factory.listAliases()
+	case "remove":
+		factory.removeAlias(args.Tail())
+	default:
+		factory.ui.SayIncorrectUsage(aliasUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *AliasCommandFactory) setAlias(app *cli.App, args []string) {
+	if len(args) < 2 {
+		factory.ui.SayIncorrectUsage(aliasUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	name, command := args[0], args[1:]
+	if app.Command(name) != nil {
+		factory.ui.SayLine(fmt.Sprintf("%s is already an ltc command.", name))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if app.Command(command[0]) == nil {
+		factory.ui.SayLine(fmt.Sprintf("%s is not an ltc command.", command[0]))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	factory.aliases.Set(name, command)
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("ltc %s now runs: ltc %s", name, strings.Join(command, " ")))
+}
+
+func (factory *AliasCommandFactory) listAliases() {
+	names := factory.aliases.Names()
+	if len(names) == 0 {
+		factory.ui.SayLine("No aliases.")
+		return
+	}
+
+	for _, name := range names {
+		command, _ := factory.aliases.Get(name)
+		factory.ui.SayLine(fmt.Sprintf("%s\tltc %s", name, strings.Join(command, " ")))
+	}
+}
+
+func (factory *AliasCommandFactory) removeAlias(args []string) {
+	if len(args) != 1 {
+		factory.ui.SayIncorrectUsage(aliasUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.aliases.Remove(args[0]) {
+		factory.ui.SayLine(fmt.Sprintf("No alias named %s.", args[0]))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Removed alias %s.", args[0]))
+}
+
+func (factory *AliasCommandFactory) save() bool {
+	if err := factory.aliases.Save(); err != nil {
+		factory.ui.SayLine("Error saving aliases: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return false
+	}
+	return true
+}
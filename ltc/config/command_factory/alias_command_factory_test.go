@@ -0,0 +1,150 @@
+package command_factory_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	config_package "github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+type failingPersister struct{}
+
+func (failingPersister) Load(interface{}) error { return nil }
+func (failingPersister) Save(interface{}) error { return errors.New("disk full") }
+
+var _ = Describe("AliasCommandFactory", func() {
+	var (
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		memPersister    persister.Persister
+		aliases         *config_package.Aliases
+		cliApp          *cli.App
+	)
+
+	runAlias := func(args ...string) {
+		Expect(cliApp.Run(append([]string{"ltc", "alias"}, args...))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		memPersister = persister.NewMemPersister()
+		aliases = config_package.NewAliases(memPersister)
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewAliasCommandFactory(aliases, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+
+		cliApp = cli.NewApp()
+		cliApp.Commands = []cli.Command{
+			commandFactory.MakeAliasCommand(),
+			{Name: "logs", Action: func(*cli.Context) {}},
+		}
+	})
+
+	Describe("alias set", func() {
+		It("saves the alias", func() {
+			runAlias("set", "prodlogs", "logs", "--prefix", "api")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc prodlogs now runs: ltc logs --prefix api"))
+
+			reloadedAliases := config_package.NewAliases(memPersister)
+			Expect(reloadedAliases.Load()).To(Succeed())
+			command, ok := reloadedAliases.Get("prodlogs")
+			Expect(ok).To(BeTrue())
+			Expect(command).To(Equal([]string{"logs", "--prefix", "api"}))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("does not let an alias shadow a command", func() {
+			runAlias("set", "logs", "logs", "api")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("logs is already an ltc command."))
+			Expect(aliases.Names()).To(BeEmpty())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("only aliases ltc commands", func() {
+			runAlias("set", "prodlogs", "tail", "api")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("tail is not an ltc command."))
+			Expect(aliases.Names()).To(BeEmpty())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("requires a name and a command", func() {
+			runAlias("set", "prodlogs")
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc alias set NAME COMMAND [ARGS...]'"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("when the aliases can't be saved", func() {
+			BeforeEach(func() {
+				aliases = config_package.NewAliases(failingPersister{})
+			})
+
+			It("exits with a file system error", func() {
+				runAlias("set", "prodlogs", "logs", "api")
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error saving aliases: disk full"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+			})
+		})
+	})
+
+	Describe("alias list", func() {
+		It("lists the aliases", func() {
+			aliases.Set("prodlogs", []string{"logs", "api"})
+			aliases.Set("devlogs", []string{"logs", "--prefix", "api-dev"})
+
+			runAlias("list")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("devlogs\tltc logs --prefix api-dev"))
+			Expect(outputBuffer).To(test_helpers.SayLine("prodlogs\tltc logs api"))
+		})
+
+		It("says so when there are no aliases", func() {
+			runAlias("list")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No aliases."))
+		})
+	})
+
+	Describe("alias remove", func() {
+		It("removes the alias", func() {
+			aliases.Set("prodlogs", []string{"logs", "api"})
+
+			runAlias("remove", "prodlogs")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed alias prodlogs."))
+			reloadedAliases := config_package.NewAliases(memPersister)
+			Expect(reloadedAliases.Load()).To(Succeed())
+			Expect(reloadedAliases.Names()).To(BeEmpty())
+		})
+
+		It("exits with NotFound for unknown aliases", func() {
+			runAlias("remove", "prodlogs")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No alias named prodlogs."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+	})
+
+	It("requires a subcommand", func() {
+		runAlias()
+
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc alias set NAME COMMAND [ARGS...]', 'ltc alias list' or 'ltc alias remove NAME'"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+})
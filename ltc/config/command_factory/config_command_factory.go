@@ -2,12 +2,15 @@ package command_factory
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/codegangsta/cli"
 )
 
@@ -18,48 +21,205 @@ type ConfigCommandFactory struct {
 	ui             terminal.UI
 	targetVerifier target_verifier.TargetVerifier
 	exitHandler    exit_handler.ExitHandler
+	latticeVersion string
 }
 
-func NewConfigCommandFactory(config *config.Config, ui terminal.UI, targetVerifier target_verifier.TargetVerifier, exitHandler exit_handler.ExitHandler) *ConfigCommandFactory {
-	return &ConfigCommandFactory{config, ui, targetVerifier, exitHandler}
+func NewConfigCommandFactory(config *config.Config, ui terminal.UI, targetVerifier target_verifier.TargetVerifier, exitHandler exit_handler.ExitHandler, latticeVersion string) *ConfigCommandFactory {
+	return &ConfigCommandFactory{config, ui, targetVerifier, exitHandler, latticeVersion}
 }
 
 func (factory *ConfigCommandFactory) MakeTargetCommand() cli.Command {
+	var targetFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "save, s",
+			Usage: "Saves the target as a named profile",
+		},
+		cli.BoolFlag{
+			Name:  "list, l",
+			Usage: "Lists the saved profiles",
+		},
+		cli.StringFlag{
+			Name:  "username, u",
+			Usage: "Logs in as the given user, prompting only for the password",
+		},
+		cli.BoolFlag{
+			Name:  "keychain",
+			Usage: "Stores the password in the OS keychain instead of the config file",
+		},
+		cli.BoolFlag{
+			Name:  "tls",
+			Usage: "Connects to the cluster over TLS (implied by the other TLS flags)",
+		},
+		cli.BoolFlag{
+			Name:  "skip-ssl-validation",
+			Usage: "Connects over TLS without verifying the cluster's certificate",
+		},
+		cli.StringFlag{
+			Name:  "ca-cert",
+			Usage: "Path to a PEM encoded CA certificate used to verify the cluster",
+		},
+		cli.StringFlag{
+			Name:  "client-cert",
+			Usage: "Path to a PEM encoded client certificate for mutual TLS",
+		},
+		cli.StringFlag{
+			Name:  "client-key",
+			Usage: "Path to the PEM encoded private key for --client-cert",
+		},
+	}
+
 	var startCommand = cli.Command{
-		Name:        TargetCommandName,
-		Aliases:     []string{"ta"},
-		Usage:       "Targets a lattice cluster",
-		Description: "ltc target TARGET (e.g., 192.168.11.11.xip.io)",
-		Action:      factory.target,
+		Name:    TargetCommandName,
+		Aliases: []string{"ta"},
+		Usage:   "Targets a lattice cluster",
+		Description: `ltc target TARGET (e.g., 192.168.11.11.xip.io)
+
+   To save a target as a named profile:
+   ltc target TARGET --save PROFILE_NAME
+   ltc target --save PROFILE_NAME (saves the current target)
+
+   To switch to a saved profile:
+   ltc target PROFILE_NAME
+
+   To log in to a password protected cluster and keep the password in the OS keychain:
+   ltc target TARGET --username USERNAME --keychain
+
+   To target a cluster fronted by TLS:
+   ltc target TARGET --ca-cert=/path/to/ca.pem [--client-cert=/path/to/cert.pem --client-key=/path/to/key.pem]`,
+		Action: factory.target,
+		Flags:  targetFlags,
 	}
 
 	return startCommand
 }
 
+func (factory *ConfigCommandFactory) MakeClusterVersionCommand() cli.Command {
+	var clusterVersionFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "check, c",
+			Usage: "Exits with an error when the target is incompatible with ltc",
+		},
+	}
+
+	var clusterVersionCommand = cli.Command{
+		Name:    "cluster-version",
+		Aliases: []string{"cv"},
+		Usage:   "Checks that ltc can work with the targeted cluster",
+		Description: `ltc cluster-version [--check]
+
+   Shows the version of ltc and of the receptor API it speaks, and checks that the
+   target serves every receptor endpoint ltc uses.
+
+   The receptor doesn't report the versions of lattice's components, so a cluster
+   too old or too new for ltc is found by the endpoints it is missing.`,
+		Action: factory.clusterVersion,
+		Flags:  clusterVersionFlags,
+	}
+
+	return clusterVersionCommand
+}
+
+func (factory *ConfigCommandFactory) clusterVersion(context *cli.Context) {
+	missingEndpoints, err := factory.targetVerifier.CheckCompatibility(factory.config.Receptor())
+	if err != nil {
+		factory.ui.SayLine("Error checking the target: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Target:\t\t%s", factory.config.Target()))
+	factory.ui.SayLine(fmt.Sprintf("ltc:\t\t%s", factory.latticeVersion))
+	factory.ui.SayLine(fmt.Sprintf("Receptor API:\t%s", target_verifier.ReceptorAPIVersion))
+	factory.ui.SayLine("Components:\tnot reported by the receptor")
+
+	if len(missingEndpoints) == 0 {
+		factory.ui.SayLine(colors.Green("Compatible: the target serves every receptor endpoint ltc uses."))
+		return
+	}
+
+	factory.ui.SayLine(colors.Red("Incompatible: the target doesn't serve these receptor endpoints:"))
+	for _, endpoint := range missingEndpoints {
+		factory.ui.SayLine("  " + endpoint)
+	}
+	factory.ui.SayLine("Upgrade the cluster, or use the ltc released with it.")
+
+	if context.Bool("check") {
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+	}
+}
+
 func (factory *ConfigCommandFactory) target(context *cli.Context) {
 	target := context.Args().First()
+	profileName := context.String("save")
+
+	if context.Bool("list") {
+		factory.printProfiles()
+		return
+	}
 
 	if target == "" {
+		if profileName != "" {
+			factory.saveCurrentTargetAsProfile(profileName)
+			return
+		}
 		factory.printTarget()
 		return
 	}
 
+	if factory.config.UseProfile(target) {
+		factory.switchToProfile(target, profileName)
+		return
+	}
+
 	factory.config.SetTarget(target)
 	factory.config.SetLogin("", "")
+	factory.config.SetUseKeychain(context.Bool("keychain"))
+
+	tlsSettings, err := tlsSettingsFromFlags(context)
+	if err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+	factory.config.SetTLS(tlsSettings)
+	if _, err := factory.config.TLSConfig(); err != nil {
+		factory.ui.Say("Error configuring TLS: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
 
+	username := context.String("username")
+	if username == "" {
+		if _, authorized, err := factory.targetVerifier.VerifyTarget(factory.config.Receptor()); err != nil {
+			factory.ui.Say("Error verifying target: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.BadTarget)
+			return
+		} else if authorized {
+			factory.warnIfIncompatible()
+			factory.save(profileName)
+			return
+		}
+
+		username = factory.ui.Prompt("Username: ")
+	}
+	password := factory.ui.PromptForPassword("Password: ")
+
+	factory.config.SetLogin(username, password)
 	if _, authorized, err := factory.targetVerifier.VerifyTarget(factory.config.Receptor()); err != nil {
 		factory.ui.Say("Error verifying target: " + err.Error())
 		factory.exitHandler.Exit(exit_codes.BadTarget)
 		return
-	} else if authorized {
-		factory.save()
+	} else if !authorized {
+		factory.ui.Say("Could not authorize target.")
+		factory.exitHandler.Exit(exit_codes.BadTarget)
 		return
 	}
 
-	username := factory.ui.Prompt("Username: ")
-	password := factory.ui.PromptForPassword("Password: ")
+	factory.warnIfIncompatible()
+	factory.save(profileName)
+}
 
-	factory.config.SetLogin(username, password)
+func (factory *ConfigCommandFactory) switchToProfile(name, profileName string) {
 	if _, authorized, err := factory.targetVerifier.VerifyTarget(factory.config.Receptor()); err != nil {
 		factory.ui.Say("Error verifying target: " + err.Error())
 		factory.exitHandler.Exit(exit_codes.BadTarget)
@@ -70,10 +230,45 @@ func (factory *ConfigCommandFactory) target(context *cli.Context) {
 		return
 	}
 
-	factory.save()
+	factory.ui.Say(fmt.Sprintf("Switched to profile %s (%s)\n", name, factory.config.Target()))
+	factory.warnIfIncompatible()
+	factory.save(profileName)
+}
+
+// warnIfIncompatible tells the user when the target doesn't serve endpoints
+// ltc relies on.  Targeting still succeeds, as most commands may well work.
+func (factory *ConfigCommandFactory) warnIfIncompatible() {
+	missingEndpoints, err := factory.targetVerifier.CheckCompatibility(factory.config.Receptor())
+	if err != nil || len(missingEndpoints) == 0 {
+		return
+	}
+
+	factory.ui.SayLine(colors.Red(fmt.Sprintf("Warning: ltc %s needs receptor endpoints the target doesn't serve: %s", factory.latticeVersion, strings.Join(missingEndpoints, ", "))))
+	factory.ui.SayLine("Run ltc cluster-version --check for details.")
+}
+
+func (factory *ConfigCommandFactory) saveCurrentTargetAsProfile(profileName string) {
+	if factory.config.Target() == "" {
+		factory.ui.Say("Target not set.")
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	factory.config.SaveProfile(profileName)
+	if err := factory.config.Save(); err != nil {
+		factory.ui.Say(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.Say(fmt.Sprintf("Saved target %s as profile %s", factory.config.Target(), profileName))
 }
 
-func (factory *ConfigCommandFactory) save() {
+func (factory *ConfigCommandFactory) save(profileName string) {
+	if profileName != "" {
+		factory.config.SaveProfile(profileName)
+	}
+
 	err := factory.config.Save()
 	if err != nil {
 		factory.ui.Say(err.Error())
@@ -93,5 +288,67 @@ func (factory *ConfigCommandFactory) printTarget() {
 
 	if factory.config.Username() != "" {
 		factory.ui.Say(fmt.Sprintf("\nUsername:\t%s", factory.config.Username()))
+		if factory.config.UseKeychain() {
+			factory.ui.Say(" (password stored in keychain)")
+		}
+	}
+
+	if factory.config.TLS().Enabled {
+		factory.ui.Say("\nTLS:\t\tenabled")
+		if factory.config.TLS().SkipSSLValidation {
+			factory.ui.Say(" (skipping SSL validation)")
+		}
+	}
+
+	if factory.config.ActiveProfile() != "" {
+		factory.ui.Say(fmt.Sprintf("\nProfile:\t%s", factory.config.ActiveProfile()))
+	}
+}
+
+func (factory *ConfigCommandFactory) printProfiles() {
+	names := factory.config.ProfileNames()
+	if len(names) == 0 {
+		factory.ui.Say("No saved profiles.")
+		return
+	}
+
+	for _, name := range names {
+		profile, _ := factory.config.Profile(name)
+
+		marker := " "
+		if name == factory.config.ActiveProfile() {
+			marker = "*"
+		}
+		factory.ui.SayLine(fmt.Sprintf("%s %s\t%s", marker, name, profile.Target))
+	}
+}
+
+func tlsSettingsFromFlags(context *cli.Context) (config.TLS, error) {
+	caCert, err := absolutePath(context.String("ca-cert"))
+	if err != nil {
+		return config.TLS{}, err
+	}
+	clientCert, err := absolutePath(context.String("client-cert"))
+	if err != nil {
+		return config.TLS{}, err
+	}
+	clientKey, err := absolutePath(context.String("client-key"))
+	if err != nil {
+		return config.TLS{}, err
+	}
+
+	return config.TLS{
+		Enabled:           context.Bool("tls"),
+		SkipSSLValidation: context.Bool("skip-ssl-validation"),
+		CACert:            caCert,
+		ClientCert:        clientCert,
+		ClientKey:         clientKey,
+	}, nil
+}
+
+func absolutePath(path string) (string, error) {
+	if path == "" {
+		return "", nil
 	}
+	return filepath.Abs(path)
 }
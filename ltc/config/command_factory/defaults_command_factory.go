@@ -0,0 +1,127 @@
+package command_factory
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	DefaultsCommandName = "config"
+
+	defaultsUsage = "Please enter 'ltc config set KEY VALUE', 'ltc config get KEY', 'ltc config unset KEY' or 'ltc config list'"
+)
+
+type DefaultsCommandFactory struct {
+	defaults    *config.Defaults
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewDefaultsCommandFactory(defaults *config.Defaults, ui terminal.UI, exitHandler exit_handler.ExitHandler) *DefaultsCommandFactory {
+	return &DefaultsCommandFactory{defaults, ui, exitHandler}
+}
+
+func (factory *DefaultsCommandFactory) MakeConfigCommand() cli.Command {
+	return cli.Command{
+		Name:  DefaultsCommandName,
+		Usage: "Sets defaults for common flags",
+		Description: `ltc config set KEY VALUE
+   ltc config get KEY
+   ltc config unset KEY
+   ltc config list
+
+   Defaults apply whenever the flag isn't passed, and are kept in
+   ~/.lattice/defaults.json.  'ltc config list' shows the keys.`,
+		Action: factory.config,
+	}
+}
+
+func (factory *DefaultsCommandFactory) config(context *cli.Context) {
+	args := context.Args()
+	switch {
+	case args.First() == "set" && len(args) == 3:
+		factory.setDefault(args.Get(1), args.Get(2))
+	case args.First() == "get" && len(args) == 2:
+		factory.getDefault(args.Get(1))
+	case args.First() == "unset" && len(args) == 2:
+		factory.unsetDefault(args.Get(1))
+	case args.First() == "list" && len(args) == 1:
+		factory.listDefaults()
+	default:
+		factory.ui.SayIncorrectUsage(defaultsUsage)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+}
+
+func (factory *DefaultsCommandFactory) setDefault(key, value string) {
+	if err := factory.defaults.Set(key, value); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("%s now defaults to %s.", key, value))
+}
+
+func (factory *DefaultsCommandFactory) getDefault(key string) {
+	value, ok := factory.defaults.Get(key)
+	if !ok {
+		factory.ui.SayLine(fmt.Sprintf("%s is not set.", key))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+
+	factory.ui.SayLine(value)
+}
+
+func (factory *DefaultsCommandFactory) unsetDefault(key string) {
+	if !factory.defaults.Unset(key) {
+		factory.ui.SayLine(fmt.Sprintf("%s is not set.", key))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+	if !factory.save() {
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Unset %s.", key))
+}
+
+// listDefaults shows every key, so that the ones that can be set are
+// discoverable.
+func (factory *DefaultsCommandFactory) listDefaults() {
+	keys := make([]string, 0, len(config.DefaultKeys))
+	for key := range config.DefaultKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(factory.ui, 0, 8, 2, ' ', 0)
+	for _, key := range keys {
+		value, ok := factory.defaults.Get(key)
+		if !ok {
+			value = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, config.DefaultKeys[key])
+	}
+	w.Flush()
+}
+
+func (factory *DefaultsCommandFactory) save() bool {
+	if err := factory.defaults.Save(); err != nil {
+		factory.ui.SayLine("Error saving defaults: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package command_factory_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	config_package "github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("DefaultsCommandFactory", func() {
+	var (
+		outputBuffer    *gbytes.Buffer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		memPersister    persister.Persister
+		defaults        *config_package.Defaults
+		cliApp          *cli.App
+	)
+
+	runConfig := func(args ...string) {
+		Expect(cliApp.Run(append([]string{"ltc", "config"}, args...))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		memPersister = persister.NewMemPersister()
+		defaults = config_package.NewDefaults(memPersister)
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewDefaultsCommandFactory(defaults, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+
+		cliApp = cli.NewApp()
+		cliApp.Commands = []cli.Command{commandFactory.MakeConfigCommand()}
+	})
+
+	Describe("config set", func() {
+		It("saves the default", func() {
+			runConfig("set", "memory-mb", "256")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("memory-mb now defaults to 256."))
+
+			reloadedDefaults := config_package.NewDefaults(memPersister)
+			Expect(reloadedDefaults.Load()).To(Succeed())
+			Expect(reloadedDefaults.Int("memory-mb", 128)).To(Equal(256))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("rejects invalid defaults", func() {
+			runConfig("set", "timeout", "soon")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("timeout must be a duration such as 5m, not soon."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("when the defaults can't be saved", func() {
+			BeforeEach(func() {
+				defaults = config_package.NewDefaults(failingPersister{})
+			})
+
+			It("exits with a file system error", func() {
+				runConfig("set", "memory-mb", "256")
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error saving defaults: disk full"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+			})
+		})
+	})
+
+	Describe("config get", func() {
+		It("prints the default", func() {
+			Expect(defaults.Set("timeout", "5m")).To(Succeed())
+
+			runConfig("get", "timeout")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("5m"))
+		})
+
+		It("exits when the default isn't set", func() {
+			runConfig("get", "timeout")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("timeout is not set."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+	})
+
+	Describe("config unset", func() {
+		It("removes the default", func() {
+			Expect(defaults.Set("timeout", "5m")).To(Succeed())
+
+			runConfig("unset", "timeout")
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Unset timeout."))
+			_, ok := defaults.Get("timeout")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("exits when the default isn't set", func() {
+			runConfig("unset", "timeout")
+
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+	})
+
+	Describe("config list", func() {
+		It("lists every key with its value", func() {
+			Expect(defaults.Set("timeout", "5m")).To(Succeed())
+
+			runConfig("list")
+
+			Expect(outputBuffer).To(gbytes.Say(`color\s+-\s+Set to false`))
+			Expect(outputBuffer).To(gbytes.Say(`memory-mb\s+-\s+--memory-mb`))
+			Expect(outputBuffer).To(gbytes.Say(`timeout\s+5m\s+--timeout`))
+		})
+	})
+
+	It("rejects unknown subcommands", func() {
+		runConfig("set", "timeout")
+
+		Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+})
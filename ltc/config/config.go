@@ -1,27 +1,41 @@
 package config
 
 import (
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/keychain"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
 )
 
 type Data struct {
-	Target   string
-	Username string
-	Password string
+	Target        string
+	Username      string
+	Password      string
+	UseKeychain   bool `json:",omitempty"`
+	TLS           TLS
+	SecretsKey    []byte             `json:",omitempty"`
+	ActiveProfile string             `json:",omitempty"`
+	Profiles      map[string]Profile `json:",omitempty"`
 }
 
 type Config struct {
 	persister persister.Persister
+	keychain  keychain.Keychain
 	data      *Data
 }
 
 func New(persister persister.Persister) *Config {
-	config := &Config{persister: persister, data: &Data{}}
+	return NewWithKeychain(persister, nil)
+}
+
+// NewWithKeychain returns a Config that can keep passwords in the given OS
+// keychain instead of the config file.
+func NewWithKeychain(persister persister.Persister, keychain keychain.Keychain) *Config {
+	config := &Config{persister: persister, keychain: keychain, data: &Data{}}
 	return config
 }
 
 func (c *Config) SetTarget(target string) {
 	c.data.Target = target
+	c.data.ActiveProfile = ""
 }
 
 func (c *Config) SetLogin(username string, password string) {
@@ -29,6 +43,10 @@ func (c *Config) SetLogin(username string, password string) {
 	c.data.Password = password
 }
 
+func (c *Config) SetSecretsKey(key []byte) {
+	c.data.SecretsKey = key
+}
+
 func (c *Config) Target() string {
 	return c.data.Target
 }
@@ -37,22 +55,65 @@ func (c *Config) Username() string {
 	return c.data.Username
 }
 
+func (c *Config) SecretsKey() []byte {
+	return c.data.SecretsKey
+}
+
 func (c *Config) Loggregator() string {
 	return "doppler." + c.data.Target
 }
 
 func (c *Config) Receptor() string {
+	scheme := "http://"
+	if c.data.TLS.Enabled {
+		scheme = "https://"
+	}
+
+	if c.data.Username == "" {
+		return scheme + "receptor." + c.data.Target
+	}
+
+	return scheme + c.data.Username + ":" + c.data.Password + "@receptor." + c.data.Target
+}
+
+// BlobStore is the WebDAV store holding uploaded source and built droplets.
+func (c *Config) BlobStore() string {
+	scheme := "http://"
+	if c.data.TLS.Enabled {
+		scheme = "https://"
+	}
+
 	if c.data.Username == "" {
-		return "http://receptor." + c.data.Target
+		return scheme + "blobstore." + c.data.Target
 	}
 
-	return "http://" + c.data.Username + ":" + c.data.Password + "@receptor." + c.data.Target
+	return scheme + c.data.Username + ":" + c.data.Password + "@blobstore." + c.data.Target
 }
 
 func (c *Config) Load() error {
-	return c.persister.Load(c.data)
+	if err := c.persister.Load(c.data); err != nil {
+		return err
+	}
+
+	if c.usesKeychain() {
+		password, err := c.keychainPassword(c.data.Username, c.data.Target)
+		if err != nil {
+			return err
+		}
+		c.data.Password = password
+	}
+
+	return nil
 }
 
 func (c *Config) Save() error {
-	return c.persister.Save(c.data)
+	if !c.usesKeychain() && !c.anyProfileUsesKeychain() {
+		return c.persister.Save(c.data)
+	}
+
+	data, err := c.moveCredentialsToKeychain()
+	if err != nil {
+		return err
+	}
+	return c.persister.Save(data)
 }
@@ -8,3 +8,23 @@ func ConfigFileLocation(homeDir string) string {
 	configDir := filepath.Join(homeDir, ".lattice")
 	return filepath.Join(configDir, "config.json")
 }
+
+func AliasesFileLocation(homeDir string) string {
+	configDir := filepath.Join(homeDir, ".lattice")
+	return filepath.Join(configDir, "aliases.json")
+}
+
+func DefaultsFileLocation(homeDir string) string {
+	configDir := filepath.Join(homeDir, ".lattice")
+	return filepath.Join(configDir, "defaults.json")
+}
+
+func WebhooksFileLocation(homeDir string) string {
+	configDir := filepath.Join(homeDir, ".lattice")
+	return filepath.Join(configDir, "webhooks.json")
+}
+
+func AuditLogFileLocation(homeDir string) string {
+	configDir := filepath.Join(homeDir, ".lattice")
+	return filepath.Join(configDir, "audit.log")
+}
@@ -15,4 +15,32 @@ var _ = Describe("ConfigHelpers", func() {
 			Expect(fileLocation).To(Equal("/home/chicago/.lattice/config.json"))
 		})
 	})
+
+	Describe("AliasesFileLocation", func() {
+		It("returns the aliases location next to the config", func() {
+			fileLocation := config_helpers.AliasesFileLocation("/home/chicago")
+			Expect(fileLocation).To(Equal("/home/chicago/.lattice/aliases.json"))
+		})
+	})
+
+	Describe("DefaultsFileLocation", func() {
+		It("returns the defaults location next to the config", func() {
+			fileLocation := config_helpers.DefaultsFileLocation("/home/chicago")
+			Expect(fileLocation).To(Equal("/home/chicago/.lattice/defaults.json"))
+		})
+	})
+
+	Describe("WebhooksFileLocation", func() {
+		It("returns the webhooks location next to the config", func() {
+			fileLocation := config_helpers.WebhooksFileLocation("/home/chicago")
+			Expect(fileLocation).To(Equal("/home/chicago/.lattice/webhooks.json"))
+		})
+	})
+
+	Describe("AuditLogFileLocation", func() {
+		It("returns the audit log location next to the config", func() {
+			fileLocation := config_helpers.AuditLogFileLocation("/home/chicago")
+			Expect(fileLocation).To(Equal("/home/chicago/.lattice/audit.log"))
+		})
+	})
 })
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/keychain"
+)
+
+// SetUseKeychain controls whether the password for the current target is
+// kept in the OS keychain rather than in the config file.
+func (c *Config) SetUseKeychain(useKeychain bool) {
+	c.data.UseKeychain = useKeychain
+}
+
+func (c *Config) UseKeychain() bool {
+	return c.data.UseKeychain
+}
+
+func (c *Config) usesKeychain() bool {
+	return c.data.UseKeychain && c.data.Username != ""
+}
+
+func (c *Config) anyProfileUsesKeychain() bool {
+	for _, profile := range c.data.Profiles {
+		if profile.UseKeychain && profile.Username != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// moveCredentialsToKeychain stores the current password in the keychain and
+// returns a copy of the config data with keychain-backed passwords removed.
+func (c *Config) moveCredentialsToKeychain() (*Data, error) {
+	if c.keychain == nil {
+		return nil, keychain.ErrUnsupported
+	}
+
+	data := *c.data
+	if c.usesKeychain() {
+		if err := c.keychain.Set(keychainAccount(data.Username, data.Target), data.Password); err != nil {
+			return nil, fmt.Errorf("Error saving password to the keychain: %s", err)
+		}
+		data.Password = ""
+	}
+
+	if len(c.data.Profiles) > 0 {
+		data.Profiles = make(map[string]Profile, len(c.data.Profiles))
+		for name, profile := range c.data.Profiles {
+			if profile.UseKeychain {
+				profile.Password = ""
+			}
+			data.Profiles[name] = profile
+		}
+	}
+
+	return &data, nil
+}
+
+func (c *Config) keychainPassword(username, target string) (string, error) {
+	if c.keychain == nil {
+		return "", keychain.ErrUnsupported
+	}
+
+	password, err := c.keychain.Get(keychainAccount(username, target))
+	if err != nil {
+		return "", fmt.Errorf("Error reading password from the keychain: %s", err)
+	}
+	return password, nil
+}
+
+func keychainAccount(username, target string) string {
+	return username + "@" + target
+}
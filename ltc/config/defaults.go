@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+// DefaultKeys are the keys of the defaults users can set, with what each
+// one sets.
+var DefaultKeys = map[string]string{
+	"memory-mb":      "--memory-mb of the commands that create apps",
+	"disk-mb":        "--disk-mb of the commands that create apps",
+	"timeout":        "--timeout of every command that polls",
+	"domain":         "Domain that app routes are created under, instead of the target",
+	"color":          "Set to false to disable colored output, as --no-color does",
+	"reserved-names": "Comma-separated app names that apps and tasks can't be created under",
+}
+
+// Defaults are user-set values for common flags, which apply whenever the
+// flag isn't passed.  Like aliases they are kept apart from the rest of the
+// config, since they aren't tied to a target.
+type Defaults struct {
+	persister persister.Persister
+	data      map[string]string
+}
+
+func NewDefaults(persister persister.Persister) *Defaults {
+	return &Defaults{persister: persister, data: make(map[string]string)}
+}
+
+func (d *Defaults) Load() error {
+	return d.persister.Load(&d.data)
+}
+
+func (d *Defaults) Save() error {
+	return d.persister.Save(d.data)
+}
+
+// Set validates value for key before setting it.
+func (d *Defaults) Set(key, value string) error {
+	if _, ok := DefaultKeys[key]; !ok {
+		return fmt.Errorf("%s is not a default ltc knows.", key)
+	}
+	if err := validateDefault(key, value); err != nil {
+		return err
+	}
+
+	if d.data == nil {
+		d.data = make(map[string]string)
+	}
+	d.data[key] = value
+	return nil
+}
+
+func (d *Defaults) Unset(key string) bool {
+	if _, ok := d.data[key]; !ok {
+		return false
+	}
+	delete(d.data, key)
+	return true
+}
+
+func (d *Defaults) Get(key string) (string, bool) {
+	value, ok := d.data[key]
+	return value, ok
+}
+
+func (d *Defaults) Keys() []string {
+	keys := make([]string, 0, len(d.data))
+	for key := range d.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Int returns the default for key, or fallback if it isn't set or was
+// edited into something that isn't a number.
+func (d *Defaults) Int(key string, fallback int) int {
+	if value, err := strconv.Atoi(d.data[key]); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func (d *Defaults) Duration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(d.data[key]); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func (d *Defaults) String(key string, fallback string) string {
+	if value, ok := d.data[key]; ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// List splits the default for key on commas, returning nil if it isn't set.
+func (d *Defaults) List(key string) []string {
+	if d.data[key] == "" {
+		return nil
+	}
+	return strings.Split(d.data[key], ",")
+}
+
+func (d *Defaults) Bool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(d.data[key]); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func validateDefault(key, value string) error {
+	switch key {
+	case "memory-mb", "disk-mb":
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s must be a number of MB, not %s.", key, value)
+		}
+	case "timeout":
+		if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout must be a duration such as 5m, not %s.", value)
+		}
+	case "domain":
+		if value == "" {
+			return fmt.Errorf("domain can't be empty.")
+		}
+	case "color":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("color must be true or false, not %s.", value)
+		}
+	case "reserved-names":
+		for _, name := range strings.Split(value, ",") {
+			if name == "" || strings.TrimSpace(name) != name {
+				return fmt.Errorf("reserved-names must be app names separated by commas, such as billing,admin, not %s.", value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+var _ = Describe("Defaults", func() {
+	var (
+		memPersister persister.Persister
+		defaults     *config.Defaults
+	)
+
+	BeforeEach(func() {
+		memPersister = persister.NewMemPersister()
+		defaults = config.NewDefaults(memPersister)
+	})
+
+	It("sets, gets and lists defaults", func() {
+		Expect(defaults.Set("timeout", "5m")).To(Succeed())
+		Expect(defaults.Set("memory-mb", "256")).To(Succeed())
+
+		value, ok := defaults.Get("timeout")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("5m"))
+
+		_, ok = defaults.Get("disk-mb")
+		Expect(ok).To(BeFalse())
+
+		Expect(defaults.Keys()).To(Equal([]string{"memory-mb", "timeout"}))
+	})
+
+	It("converts defaults, falling back when they aren't set", func() {
+		Expect(defaults.Set("memory-mb", "256")).To(Succeed())
+		Expect(defaults.Set("timeout", "5m")).To(Succeed())
+		Expect(defaults.Set("domain", "apps.example.com")).To(Succeed())
+		Expect(defaults.Set("color", "false")).To(Succeed())
+		Expect(defaults.Set("reserved-names", "billing,admin")).To(Succeed())
+
+		Expect(defaults.Int("memory-mb", 128)).To(Equal(256))
+		Expect(defaults.Int("disk-mb", 0)).To(Equal(0))
+		Expect(defaults.Duration("timeout", time.Minute)).To(Equal(5 * time.Minute))
+		Expect(defaults.String("domain", "lattice.example.com")).To(Equal("apps.example.com"))
+		Expect(defaults.Bool("color", true)).To(BeFalse())
+		Expect(defaults.List("reserved-names")).To(Equal([]string{"billing", "admin"}))
+		Expect(defaults.List("domains")).To(BeNil())
+
+		Expect(config.NewDefaults(memPersister).Bool("color", true)).To(BeTrue())
+	})
+
+	It("rejects unknown keys and invalid values", func() {
+		Expect(defaults.Set("memroy-mb", "256")).To(MatchError("memroy-mb is not a default ltc knows."))
+		Expect(defaults.Set("memory-mb", "lots")).To(MatchError("memory-mb must be a number of MB, not lots."))
+		Expect(defaults.Set("disk-mb", "-1")).To(MatchError("disk-mb must be a number of MB, not -1."))
+		Expect(defaults.Set("timeout", "5")).To(MatchError("timeout must be a duration such as 5m, not 5."))
+		Expect(defaults.Set("domain", "")).To(MatchError("domain can't be empty."))
+		Expect(defaults.Set("color", "sometimes")).To(MatchError("color must be true or false, not sometimes."))
+		Expect(defaults.Set("reserved-names", "billing, admin")).To(MatchError("reserved-names must be app names separated by commas, such as billing,admin, not billing, admin."))
+		Expect(defaults.Keys()).To(BeEmpty())
+	})
+
+	It("unsets defaults", func() {
+		Expect(defaults.Set("timeout", "5m")).To(Succeed())
+
+		Expect(defaults.Unset("timeout")).To(BeTrue())
+		Expect(defaults.Unset("timeout")).To(BeFalse())
+		Expect(defaults.Keys()).To(BeEmpty())
+	})
+
+	It("persists defaults", func() {
+		Expect(defaults.Set("timeout", "5m")).To(Succeed())
+		Expect(defaults.Save()).To(Succeed())
+
+		reloadedDefaults := config.NewDefaults(memPersister)
+		Expect(reloadedDefaults.Load()).To(Succeed())
+		Expect(reloadedDefaults.Duration("timeout", time.Minute)).To(Equal(5 * time.Minute))
+	})
+})
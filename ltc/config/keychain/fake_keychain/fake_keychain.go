@@ -0,0 +1,137 @@
+// This file was generated by counterfeiter
+package fake_keychain
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/keychain"
+)
+
+type FakeKeychain struct {
+	GetStub        func(account string) (string, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		account string
+	}
+	getReturns struct {
+		result1 string
+		result2 error
+	}
+	SetStub        func(account string, password string) error
+	setMutex       sync.RWMutex
+	setArgsForCall []struct {
+		account  string
+		password string
+	}
+	setReturns struct {
+		result1 error
+	}
+	DeleteStub        func(account string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		account string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeKeychain) Get(account string) (string, error) {
+	fake.getMutex.Lock()
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		account string
+	}{account})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(account)
+	} else {
+		return fake.getReturns.result1, fake.getReturns.result2
+	}
+}
+
+func (fake *FakeKeychain) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeKeychain) GetArgsForCall(i int) string {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.getArgsForCall[i].account
+}
+
+func (fake *FakeKeychain) GetReturns(result1 string, result2 error) {
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeKeychain) Set(account string, password string) error {
+	fake.setMutex.Lock()
+	fake.setArgsForCall = append(fake.setArgsForCall, struct {
+		account  string
+		password string
+	}{account, password})
+	fake.setMutex.Unlock()
+	if fake.SetStub != nil {
+		return fake.SetStub(account, password)
+	} else {
+		return fake.setReturns.result1
+	}
+}
+
+func (fake *FakeKeychain) SetCallCount() int {
+	fake.setMutex.RLock()
+	defer fake.setMutex.RUnlock()
+	return len(fake.setArgsForCall)
+}
+
+func (fake *FakeKeychain) SetArgsForCall(i int) (string, string) {
+	fake.setMutex.RLock()
+	defer fake.setMutex.RUnlock()
+	return fake.setArgsForCall[i].account, fake.setArgsForCall[i].password
+}
+
+func (fake *FakeKeychain) SetReturns(result1 error) {
+	fake.SetStub = nil
+	fake.setReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKeychain) Delete(account string) error {
+	fake.deleteMutex.Lock()
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		account string
+	}{account})
+	fake.deleteMutex.Unlock()
+	if fake.DeleteStub != nil {
+		return fake.DeleteStub(account)
+	} else {
+		return fake.deleteReturns.result1
+	}
+}
+
+func (fake *FakeKeychain) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeKeychain) DeleteArgsForCall(i int) string {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return fake.deleteArgsForCall[i].account
+}
+
+func (fake *FakeKeychain) DeleteReturns(result1 error) {
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ keychain.Keychain = new(FakeKeychain)
@@ -0,0 +1,104 @@
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const ServiceName = "ltc"
+
+var ErrUnsupported = errors.New("No OS keychain is available on this platform.")
+
+//go:generate counterfeiter -o fake_keychain/fake_keychain.go . Keychain
+type Keychain interface {
+	Get(account string) (string, error)
+	Set(account, password string) error
+	Delete(account string) error
+}
+
+// New returns the keychain for the current OS: the login keychain on OS X
+// (via security) or the Secret Service on Linux (via secret-tool).
+func New() Keychain {
+	switch runtime.GOOS {
+	case "darwin":
+		return NewMacKeychain("security")
+	case "linux":
+		return NewSecretServiceKeychain("secret-tool")
+	default:
+		return unsupportedKeychain{}
+	}
+}
+
+type macKeychain struct {
+	securityPath string
+}
+
+func NewMacKeychain(securityPath string) Keychain {
+	return &macKeychain{securityPath}
+}
+
+func (k *macKeychain) Get(account string) (string, error) {
+	return runTool("", k.securityPath, "find-generic-password", "-s", ServiceName, "-a", account, "-w")
+}
+
+func (k *macKeychain) Set(account, password string) error {
+	_, err := runTool("", k.securityPath, "add-generic-password", "-U", "-s", ServiceName, "-a", account, "-w", password)
+	return err
+}
+
+func (k *macKeychain) Delete(account string) error {
+	_, err := runTool("", k.securityPath, "delete-generic-password", "-s", ServiceName, "-a", account)
+	return err
+}
+
+type secretServiceKeychain struct {
+	secretToolPath string
+}
+
+func NewSecretServiceKeychain(secretToolPath string) Keychain {
+	return &secretServiceKeychain{secretToolPath}
+}
+
+func (k *secretServiceKeychain) Get(account string) (string, error) {
+	return runTool("", k.secretToolPath, "lookup", "service", ServiceName, "account", account)
+}
+
+// Set passes the password on stdin so it does not show up in the process list.
+func (k *secretServiceKeychain) Set(account, password string) error {
+	_, err := runTool(password, k.secretToolPath, "store", "--label", ServiceName+" "+account, "service", ServiceName, "account", account)
+	return err
+}
+
+func (k *secretServiceKeychain) Delete(account string) error {
+	_, err := runTool("", k.secretToolPath, "clear", "service", ServiceName, "account", account)
+	return err
+}
+
+type unsupportedKeychain struct{}
+
+func (unsupportedKeychain) Get(string) (string, error) { return "", ErrUnsupported }
+func (unsupportedKeychain) Set(string, string) error   { return ErrUnsupported }
+func (unsupportedKeychain) Delete(string) error        { return ErrUnsupported }
+
+func runTool(stdin, path string, args ...string) (string, error) {
+	if _, err := exec.LookPath(path); err != nil {
+		return "", ErrUnsupported
+	}
+
+	command := exec.Command(path, args...)
+	command.Stdin = strings.NewReader(stdin)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	command.Stdout = stdout
+	command.Stderr = stderr
+
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("%s %s failed: %s", path, args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
@@ -0,0 +1,13 @@
+package keychain_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestKeychain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Keychain Suite")
+}
@@ -0,0 +1,107 @@
+package keychain_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/keychain"
+)
+
+var _ = Describe("Keychain", func() {
+	var (
+		tmpDir   string
+		toolPath string
+	)
+
+	// writeTool writes a stand-in for security/secret-tool that records its
+	// arguments and stdin, then runs the given shell snippet.
+	writeTool := func(body string) {
+		script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(tmpDir, "args") + "\ncat > " + filepath.Join(tmpDir, "stdin") + "\n" + body + "\n"
+		Expect(ioutil.WriteFile(toolPath, []byte(script), 0755)).To(Succeed())
+	}
+
+	recorded := func(name string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(tmpDir, name))
+		Expect(err).ToNot(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "keychain-test")
+		Expect(err).ToNot(HaveOccurred())
+		toolPath = filepath.Join(tmpDir, "tool")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("SecretServiceKeychain", func() {
+		var secretServiceKeychain keychain.Keychain
+
+		BeforeEach(func() {
+			secretServiceKeychain = keychain.NewSecretServiceKeychain(toolPath)
+		})
+
+		It("looks up the password", func() {
+			writeTool("echo s3cret")
+
+			password, err := secretServiceKeychain.Get("user@lattice.example.com")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(password).To(Equal("s3cret"))
+			Expect(recorded("args")).To(Equal("lookup service ltc account user@lattice.example.com\n"))
+		})
+
+		It("stores the password from stdin", func() {
+			writeTool("")
+
+			Expect(secretServiceKeychain.Set("user@lattice.example.com", "s3cret")).To(Succeed())
+
+			Expect(recorded("args")).To(Equal("store --label ltc user@lattice.example.com service ltc account user@lattice.example.com\n"))
+			Expect(recorded("stdin")).To(Equal("s3cret"))
+		})
+
+		It("clears the password", func() {
+			writeTool("")
+
+			Expect(secretServiceKeychain.Delete("user@lattice.example.com")).To(Succeed())
+
+			Expect(recorded("args")).To(Equal("clear service ltc account user@lattice.example.com\n"))
+		})
+
+		It("returns the tool's error output", func() {
+			writeTool("echo 'no such secret' >&2; exit 1")
+
+			_, err := secretServiceKeychain.Get("user@lattice.example.com")
+
+			Expect(err).To(MatchError(toolPath + " lookup failed: no such secret"))
+		})
+
+		It("returns ErrUnsupported when the tool is not installed", func() {
+			_, err := keychain.NewSecretServiceKeychain(filepath.Join(tmpDir, "missing")).Get("user@lattice.example.com")
+
+			Expect(err).To(Equal(keychain.ErrUnsupported))
+		})
+	})
+
+	Describe("MacKeychain", func() {
+		It("stores and looks up generic passwords", func() {
+			macKeychain := keychain.NewMacKeychain(toolPath)
+			writeTool("echo s3cret")
+
+			Expect(macKeychain.Set("user@lattice.example.com", "s3cret")).To(Succeed())
+			Expect(recorded("args")).To(Equal("add-generic-password -U -s ltc -a user@lattice.example.com -w s3cret\n"))
+
+			password, err := macKeychain.Get("user@lattice.example.com")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(password).To(Equal("s3cret"))
+			Expect(recorded("args")).To(Equal("find-generic-password -s ltc -a user@lattice.example.com -w\n"))
+		})
+	})
+})
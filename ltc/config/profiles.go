@@ -0,0 +1,66 @@
+package config
+
+import "sort"
+
+// Profile is a named cluster target that can be switched to with
+// `ltc target NAME`.  The active target is kept in the top-level Data
+// fields so commands do not need to know whether a profile is in use.
+type Profile struct {
+	Target      string
+	Username    string
+	Password    string
+	UseKeychain bool `json:",omitempty"`
+	TLS         TLS
+}
+
+func (c *Config) SaveProfile(name string) {
+	if c.data.Profiles == nil {
+		c.data.Profiles = make(map[string]Profile)
+	}
+
+	c.data.Profiles[name] = Profile{
+		Target:      c.data.Target,
+		Username:    c.data.Username,
+		Password:    c.data.Password,
+		UseKeychain: c.data.UseKeychain,
+		TLS:         c.data.TLS,
+	}
+	c.data.ActiveProfile = name
+}
+
+func (c *Config) UseProfile(name string) bool {
+	profile, ok := c.data.Profiles[name]
+	if !ok {
+		return false
+	}
+
+	c.data.Target = profile.Target
+	c.data.Username = profile.Username
+	c.data.Password = profile.Password
+	c.data.UseKeychain = profile.UseKeychain
+	c.data.TLS = profile.TLS
+	c.data.ActiveProfile = name
+
+	if c.usesKeychain() && c.data.Password == "" {
+		c.data.Password, _ = c.keychainPassword(c.data.Username, c.data.Target)
+	}
+	return true
+}
+
+func (c *Config) ActiveProfile() string {
+	return c.data.ActiveProfile
+}
+
+func (c *Config) Profile(name string) (Profile, bool) {
+	profile, ok := c.data.Profiles[name]
+	return profile, ok
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.data.Profiles))
+	for name := range c.data.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+package target_verifier
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+// ReceptorAPIVersion is the version of the receptor API ltc speaks.
+const ReceptorAPIVersion = "v1"
+
+// receptorEndpoints are the receptor endpoints ltc relies on.  The receptor
+// doesn't report its version, so a cluster ltc can't work with shows up as
+// one that doesn't serve some of them.
+var receptorEndpoints = []struct {
+	path  string
+	probe func(receptor.Client) error
+}{
+	{"/v1/desired_lrps", func(client receptor.Client) error { _, err := client.DesiredLRPs(); return err }},
+	{"/v1/actual_lrps", func(client receptor.Client) error { _, err := client.ActualLRPs(); return err }},
+	{"/v1/tasks", func(client receptor.Client) error { _, err := client.Tasks(); return err }},
+	{"/v1/cells", func(client receptor.Client) error { _, err := client.Cells(); return err }},
+	{"/v1/domains", func(client receptor.Client) error { _, err := client.Domains(); return err }},
+}
+
+// The receptor answers routes it doesn't know with a plain text 404, which
+// its client reports as an invalid response.
+var endpointNotFound = receptor.Error{
+	Type:    receptor.InvalidResponse,
+	Message: fmt.Sprintf("Invalid Response with status code: %d", http.StatusNotFound),
+}
+
+func (t *targetVerifier) CheckCompatibility(target string) (missingEndpoints []string, err error) {
+	receptorClient := t.receptorClientFactory(target)
+
+	missingEndpoints = []string{}
+	for _, endpoint := range receptorEndpoints {
+		if err := endpoint.probe(receptorClient); err == endpointNotFound {
+			missingEndpoints = append(missingEndpoints, endpoint.path)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return missingEndpoints, nil
+}
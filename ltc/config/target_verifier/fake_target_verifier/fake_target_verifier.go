@@ -18,6 +18,15 @@ type FakeTargetVerifier struct {
 		result2 bool
 		result3 error
 	}
+	CheckCompatibilityStub        func(name string) (missingEndpoints []string, err error)
+	checkCompatibilityMutex       sync.RWMutex
+	checkCompatibilityArgsForCall []struct {
+		name string
+	}
+	checkCompatibilityReturns struct {
+		result1 []string
+		result2 error
+	}
 }
 
 func (fake *FakeTargetVerifier) VerifyTarget(name string) (receptorUp bool, authorized bool, err error) {
@@ -54,4 +63,37 @@ func (fake *FakeTargetVerifier) VerifyTargetReturns(result1 bool, result2 bool,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeTargetVerifier) CheckCompatibility(name string) (missingEndpoints []string, err error) {
+	fake.checkCompatibilityMutex.Lock()
+	fake.checkCompatibilityArgsForCall = append(fake.checkCompatibilityArgsForCall, struct {
+		name string
+	}{name})
+	fake.checkCompatibilityMutex.Unlock()
+	if fake.CheckCompatibilityStub != nil {
+		return fake.CheckCompatibilityStub(name)
+	} else {
+		return fake.checkCompatibilityReturns.result1, fake.checkCompatibilityReturns.result2
+	}
+}
+
+func (fake *FakeTargetVerifier) CheckCompatibilityCallCount() int {
+	fake.checkCompatibilityMutex.RLock()
+	defer fake.checkCompatibilityMutex.RUnlock()
+	return len(fake.checkCompatibilityArgsForCall)
+}
+
+func (fake *FakeTargetVerifier) CheckCompatibilityArgsForCall(i int) string {
+	fake.checkCompatibilityMutex.RLock()
+	defer fake.checkCompatibilityMutex.RUnlock()
+	return fake.checkCompatibilityArgsForCall[i].name
+}
+
+func (fake *FakeTargetVerifier) CheckCompatibilityReturns(result1 []string, result2 error) {
+	fake.CheckCompatibilityStub = nil
+	fake.checkCompatibilityReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 var _ target_verifier.TargetVerifier = new(FakeTargetVerifier)
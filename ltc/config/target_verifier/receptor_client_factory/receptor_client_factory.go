@@ -1,9 +1,59 @@
 package receptor_client_factory
 
 import (
+	"crypto/tls"
+	"io"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/cf_http"
+	"github.com/cloudfoundry-incubator/lattice/ltc/clientpool"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_tracer"
 	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
 )
 
 func MakeReceptorClient(target string) receptor.Client {
 	return receptor.NewClient(target)
 }
+
+func MakeTLSReceptorClient(target string, tlsConfig *tls.Config) receptor.Client {
+	if tlsConfig == nil {
+		return MakeReceptorClient(target)
+	}
+
+	httpClient := cf_http.NewClient()
+	httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	streamingHTTPClient := cf_http.NewStreamingClient()
+	streamingHTTPClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	return http_receptor_client.New(target, httpClient, streamingHTTPClient)
+}
+
+// MakePooledReceptorClient returns a client that makes its requests through
+// pool, writing each of them, and the response, to trace unless it is nil.
+// Event streams get a connection of their own, so they don't hold up the
+// pool.
+func MakePooledReceptorClient(target string, pool *clientpool.Pool, tlsConfig *tls.Config, trace io.Writer, clock clock.Clock) receptor.Client {
+	transport := pool.Transport()
+	streamingHTTPClient := cf_http.NewStreamingClient()
+	streamingHTTPClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	if trace != nil {
+		transport = http_tracer.New(transport, trace, clock)
+		streamingHTTPClient.Transport = http_tracer.New(streamingHTTPClient.Transport, trace, clock)
+	}
+	return http_receptor_client.New(target, pool.Client(transport), streamingHTTPClient)
+}
+
+// NewConfiguredReceptorClientFactory returns a factory that picks up the TLS
+// settings in config at the time each client is made, so that targeting a new
+// cluster verifies it with the new settings.
+func NewConfiguredReceptorClientFactory(config *config.Config) func(target string) receptor.Client {
+	return func(target string) receptor.Client {
+		tlsConfig, err := config.TLSConfig()
+		if err != nil {
+			return MakeReceptorClient(target)
+		}
+		return MakeTLSReceptorClient(target, tlsConfig)
+	}
+}
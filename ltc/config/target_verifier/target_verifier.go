@@ -7,6 +7,10 @@ import (
 //go:generate counterfeiter -o fake_target_verifier/fake_target_verifier.go . TargetVerifier
 type TargetVerifier interface {
 	VerifyTarget(name string) (receptorUp bool, authorized bool, err error)
+
+	// CheckCompatibility lists the receptor endpoints ltc relies on that
+	// the target doesn't serve.
+	CheckCompatibility(name string) (missingEndpoints []string, err error)
 }
 
 func New(receptorClientFactory func(target string) receptor.Client) TargetVerifier {
@@ -76,4 +76,52 @@ var _ = Describe("TargetVerifier", func() {
 			Expect(authorized).To(BeFalse())
 		})
 	})
+
+	Describe("CheckCompatibility", func() {
+		var (
+			fakeReceptorClient *fake_receptor.FakeClient
+			targetVerifier     target_verifier.TargetVerifier
+		)
+
+		notFound := receptor.Error{Type: receptor.InvalidResponse, Message: "Invalid Response with status code: 404"}
+
+		BeforeEach(func() {
+			fakeReceptorClient = &fake_receptor.FakeClient{}
+			targetVerifier = target_verifier.New(func(target string) receptor.Client {
+				Expect(target).To(Equal("http://receptor.mylattice.com"))
+				return fakeReceptorClient
+			})
+		})
+
+		It("returns no endpoints when the target serves all of them", func() {
+			missingEndpoints, err := targetVerifier.CheckCompatibility("http://receptor.mylattice.com")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missingEndpoints).To(BeEmpty())
+			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.TasksCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.CellsCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DomainsCallCount()).To(Equal(1))
+		})
+
+		It("lists the endpoints the target answers with a 404", func() {
+			fakeReceptorClient.CellsReturns(nil, notFound)
+			fakeReceptorClient.DomainsReturns(nil, notFound)
+
+			missingEndpoints, err := targetVerifier.CheckCompatibility("http://receptor.mylattice.com")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missingEndpoints).To(Equal([]string{"/v1/cells", "/v1/domains"}))
+		})
+
+		It("returns other errors", func() {
+			fakeReceptorClient.TasksReturns(nil, receptor.Error{Type: receptor.InvalidResponse, Message: "Invalid Response with status code: 500"})
+
+			_, err := targetVerifier.CheckCompatibility("http://receptor.mylattice.com")
+
+			Expect(err).To(MatchError("Invalid Response with status code: 500"))
+			Expect(fakeReceptorClient.CellsCallCount()).To(BeZero())
+		})
+	})
 })
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLS holds the settings used to talk to clusters fronted by TLS.  Cert and
+// key settings are file paths, read each time a *tls.Config is built.
+type TLS struct {
+	Enabled           bool   `json:",omitempty"`
+	SkipSSLValidation bool   `json:",omitempty"`
+	CACert            string `json:",omitempty"`
+	ClientCert        string `json:",omitempty"`
+	ClientKey         string `json:",omitempty"`
+}
+
+func (c *Config) SetTLS(tlsSettings TLS) {
+	if tlsSettings.SkipSSLValidation || tlsSettings.CACert != "" || tlsSettings.ClientCert != "" {
+		tlsSettings.Enabled = true
+	}
+	c.data.TLS = tlsSettings
+}
+
+func (c *Config) TLS() TLS {
+	return c.data.TLS
+}
+
+// TLSConfig returns nil when TLS is not enabled for the current target.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	settings := c.data.TLS
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.SkipSSLValidation}
+
+	if settings.CACert != "" {
+		caCertBytes, err := ioutil.ReadFile(settings.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA certificate: %s", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCertBytes) {
+			return nil, fmt.Errorf("No certificates found in %s", settings.CACert)
+		}
+	}
+
+	if settings.ClientCert != "" || settings.ClientKey != "" {
+		if settings.ClientCert == "" || settings.ClientKey == "" {
+			return nil, errors.New("Both a client certificate and a client key are required.")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+// Webhooks are the URLs that `ltc notify watch` posts app events to, each
+// with the names of the events it wants.  No event names means every event.
+type Webhooks struct {
+	persister persister.Persister
+	data      map[string][]string
+}
+
+func NewWebhooks(persister persister.Persister) *Webhooks {
+	return &Webhooks{persister: persister, data: make(map[string][]string)}
+}
+
+func (w *Webhooks) Load() error {
+	return w.persister.Load(&w.data)
+}
+
+func (w *Webhooks) Save() error {
+	return w.persister.Save(w.data)
+}
+
+func (w *Webhooks) Set(url string, events []string) {
+	if w.data == nil {
+		w.data = make(map[string][]string)
+	}
+	w.data[url] = events
+}
+
+func (w *Webhooks) Remove(url string) bool {
+	if _, ok := w.data[url]; !ok {
+		return false
+	}
+	delete(w.data, url)
+	return true
+}
+
+func (w *Webhooks) Get(url string) ([]string, bool) {
+	events, ok := w.data[url]
+	return events, ok
+}
+
+func (w *Webhooks) URLs() []string {
+	urls := make([]string, 0, len(w.data))
+	for url := range w.data {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
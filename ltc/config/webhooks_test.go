@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+)
+
+var _ = Describe("Webhooks", func() {
+	var (
+		memPersister persister.Persister
+		webhooks     *config.Webhooks
+	)
+
+	BeforeEach(func() {
+		memPersister = persister.NewMemPersister()
+		webhooks = config.NewWebhooks(memPersister)
+	})
+
+	It("sets and gets webhooks", func() {
+		webhooks.Set("https://hooks.example.com/ltc", []string{"crash", "scale"})
+
+		events, ok := webhooks.Get("https://hooks.example.com/ltc")
+		Expect(ok).To(BeTrue())
+		Expect(events).To(Equal([]string{"crash", "scale"}))
+
+		_, ok = webhooks.Get("https://pager.example.com")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("lists the webhook URLs in order", func() {
+		webhooks.Set("https://pager.example.com", nil)
+		webhooks.Set("https://hooks.example.com/ltc", nil)
+
+		Expect(webhooks.URLs()).To(Equal([]string{"https://hooks.example.com/ltc", "https://pager.example.com"}))
+	})
+
+	It("removes webhooks", func() {
+		webhooks.Set("https://hooks.example.com/ltc", nil)
+
+		Expect(webhooks.Remove("https://hooks.example.com/ltc")).To(BeTrue())
+		Expect(webhooks.Remove("https://hooks.example.com/ltc")).To(BeFalse())
+		Expect(webhooks.URLs()).To(BeEmpty())
+	})
+
+	It("persists webhooks", func() {
+		webhooks.Set("https://hooks.example.com/ltc", []string{"crash"})
+		Expect(webhooks.Save()).To(Succeed())
+
+		reloadedWebhooks := config.NewWebhooks(memPersister)
+		Expect(reloadedWebhooks.Load()).To(Succeed())
+
+		events, ok := reloadedWebhooks.Get("https://hooks.example.com/ltc")
+		Expect(ok).To(BeTrue())
+		Expect(events).To(Equal([]string{"crash"}))
+	})
+})
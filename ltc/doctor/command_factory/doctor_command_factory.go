@@ -0,0 +1,81 @@
+package command_factory
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+)
+
+const DoctorCommandName = "doctor"
+
+type DoctorCommandFactory struct {
+	doctor      doctor.Doctor
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewDoctorCommandFactory(doctor doctor.Doctor, ui terminal.UI, exitHandler exit_handler.ExitHandler) *DoctorCommandFactory {
+	return &DoctorCommandFactory{doctor, ui, exitHandler}
+}
+
+func (factory *DoctorCommandFactory) MakeDoctorCommand() cli.Command {
+	var doctorCommand = cli.Command{
+		Name:    DoctorCommandName,
+		Aliases: []string{"dr"},
+		Usage:   "Diagnoses problems between ltc and the targeted cluster",
+		Description: `ltc doctor
+
+   Checks ltc's config file, that the target resolves and its TLS certificate
+   is trusted, that the receptor accepts ltc's credentials, that the local
+   clock agrees with the cluster's, and that Docker Hub can be reached.
+   Each failed check says what to do about it.`,
+		Action: factory.diagnose,
+	}
+
+	return doctorCommand
+}
+
+func (factory *DoctorCommandFactory) diagnose(context *cli.Context) {
+	checks := []struct {
+		description string
+		check       func() error
+	}{
+		{"Checking config file", factory.doctor.CheckConfig},
+		{"Resolving the target", factory.doctor.CheckDNS},
+		{"Verifying TLS", factory.doctor.CheckTLS},
+		{"Connecting to the receptor", factory.doctor.CheckReceptor},
+		{"Comparing clocks", factory.doctor.CheckClockSkew},
+		{"Connecting to Docker Hub", factory.doctor.CheckDockerHub},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		factory.ui.Say(check.description + "... ")
+
+		switch err := check.check().(type) {
+		case nil:
+			factory.ui.SayLine(colors.Green("PASS"))
+		case doctor.Skipped:
+			factory.ui.SayLine(colors.Gray(fmt.Sprintf("SKIPPED (%s)", err)))
+		case doctor.Problem:
+			failed++
+			factory.ui.SayLine(colors.Red("FAIL") + ": " + err.Message)
+			factory.ui.SayLine("    " + err.Remedy)
+		default:
+			failed++
+			factory.ui.SayLine(colors.Red("FAIL") + ": " + err.Error())
+		}
+	}
+
+	if failed > 0 {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%d check(s) failed.", failed)))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	factory.ui.SayLine(colors.Green("All checks passed."))
+}
@@ -0,0 +1,78 @@
+package command_factory_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor/fake_doctor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader/fake_password_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("DoctorCommandFactory", func() {
+	var (
+		outputBuffer    *gbytes.Buffer
+		fakeDoctor      *fake_doctor.FakeDoctor
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		doctorCommand   cli.Command
+	)
+
+	BeforeEach(func() {
+		stdinReader, _ := io.Pipe()
+		outputBuffer = gbytes.NewBuffer()
+		terminalUI := terminal.NewUI(stdinReader, outputBuffer, &fake_password_reader.FakePasswordReader{})
+		fakeDoctor = &fake_doctor.FakeDoctor{}
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		commandFactory := command_factory.NewDoctorCommandFactory(fakeDoctor, terminalUI, fakeExitHandler)
+		doctorCommand = commandFactory.MakeDoctorCommand()
+	})
+
+	Describe("DoctorCommand", func() {
+		It("runs every check", func() {
+			fakeDoctor.CheckTLSReturns(doctor.Skipped("the target doesn't use TLS"))
+
+			test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Checking config file... " + colors.Green("PASS") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Resolving the target... " + colors.Green("PASS") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Verifying TLS... " + colors.Gray("SKIPPED (the target doesn't use TLS)") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Connecting to the receptor... " + colors.Green("PASS") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Comparing clocks... " + colors.Green("PASS") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Connecting to Docker Hub... " + colors.Green("PASS") + "\n"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("All checks passed.")))
+
+			Expect(fakeDoctor.CheckConfigCallCount()).To(Equal(1))
+			Expect(fakeDoctor.CheckDNSCallCount()).To(Equal(1))
+			Expect(fakeDoctor.CheckTLSCallCount()).To(Equal(1))
+			Expect(fakeDoctor.CheckReceptorCallCount()).To(Equal(1))
+			Expect(fakeDoctor.CheckClockSkewCallCount()).To(Equal(1))
+			Expect(fakeDoctor.CheckDockerHubCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("reports failed checks with their remedies", func() {
+			fakeDoctor.CheckDNSReturns(doctor.Problem{Message: "Can't resolve receptor.example.com", Remedy: "Try another DNS server."})
+			fakeDoctor.CheckDockerHubReturns(errors.New("boom"))
+
+			test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Resolving the target... " + colors.Red("FAIL") + ": Can't resolve receptor.example.com\n"))
+			Expect(outputBuffer).To(test_helpers.Say("    Try another DNS server.\n"))
+			Expect(outputBuffer).To(test_helpers.Say("Connecting to Docker Hub... " + colors.Red("FAIL") + ": boom\n"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("2 check(s) failed.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+})
@@ -0,0 +1,241 @@
+package doctor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	DockerHubURL = "https://registry-1.docker.io/v2/"
+
+	// MaxClockSkew is how far the local clock may drift from the cluster's
+	// before log timestamps and certificate validity become misleading.
+	MaxClockSkew = 30 * time.Second
+)
+
+//go:generate counterfeiter -o fake_doctor/fake_doctor.go . Doctor
+type Doctor interface {
+	CheckConfig() error
+	CheckDNS() error
+	CheckTLS() error
+	CheckReceptor() error
+	CheckClockSkew() error
+	CheckDockerHub() error
+}
+
+// Problem is a failed check, with what the user can do about it.
+type Problem struct {
+	Message string
+	Remedy  string
+}
+
+func (p Problem) Error() string {
+	return p.Message
+}
+
+// Skipped is returned by checks that don't apply, saying why.
+type Skipped string
+
+func (s Skipped) Error() string {
+	return string(s)
+}
+
+type DoctorConfig struct {
+	Config         *config.Config
+	ConfigPath     string
+	TargetVerifier target_verifier.TargetVerifier
+	LookupHost     func(host string) ([]string, error)
+	Clock          clock.Clock
+	Timeout        time.Duration
+	DockerHubURL   string
+
+	// Dial, when set, replaces how connections to the cluster are made.
+	Dial func(network, address string) (net.Conn, error)
+}
+
+type doctor struct {
+	DoctorConfig
+}
+
+func New(config DoctorConfig) Doctor {
+	return &doctor{config}
+}
+
+func (d *doctor) CheckConfig() error {
+	if err := d.Config.Load(); err != nil {
+		return Problem{
+			Message: fmt.Sprintf("Can't read %s: %s", d.ConfigPath, err),
+			Remedy:  fmt.Sprintf("Fix the JSON in %s, or remove it and run ltc target again.", d.ConfigPath),
+		}
+	}
+
+	if info, err := os.Stat(d.ConfigPath); err == nil && info.Mode().Perm()&0077 != 0 {
+		return Problem{
+			Message: fmt.Sprintf("%s can be read by other users, and may hold your password.", d.ConfigPath),
+			Remedy:  fmt.Sprintf("Run chmod 600 %s", d.ConfigPath),
+		}
+	}
+
+	if d.Config.Target() == "" {
+		return Problem{
+			Message: "No target is set.",
+			Remedy:  "Run ltc target TARGET, e.g. ltc target 192.168.11.11.xip.io",
+		}
+	}
+
+	return nil
+}
+
+func (d *doctor) CheckDNS() error {
+	if d.Config.Target() == "" {
+		return Skipped("no target is set")
+	}
+
+	host := "receptor." + d.Config.Target()
+	if _, err := d.LookupHost(host); err != nil {
+		return Problem{
+			Message: fmt.Sprintf("Can't resolve %s: %s", host, err),
+			Remedy:  "Check the target's domain.  xip.io domains resolve to private addresses, which some DNS servers and routers refuse to return: try another DNS server, e.g. 8.8.8.8.",
+		}
+	}
+	return nil
+}
+
+func (d *doctor) CheckTLS() error {
+	if !d.Config.TLS().Enabled {
+		return Skipped("the target doesn't use TLS")
+	}
+
+	tlsConfig, err := d.Config.TLSConfig()
+	if err != nil {
+		return Problem{
+			Message: err.Error(),
+			Remedy:  "Run ltc target TARGET with valid --ca-cert, --client-cert and --client-key files.",
+		}
+	}
+
+	response, err := d.clusterClient(tlsConfig).Get(d.Config.Receptor())
+	if err != nil {
+		if isCertificateError(err) {
+			return Problem{
+				Message: fmt.Sprintf("The receptor's certificate can't be verified: %s", err),
+				Remedy:  "Run ltc target TARGET --ca-cert=/path/to/ca.pem with the CA that signed the cluster's certificate, or --skip-ssl-validation for a test cluster.",
+			}
+		}
+		// Failures to connect at all are reported by CheckReceptor.
+		return nil
+	}
+	discard(response)
+	return nil
+}
+
+func (d *doctor) CheckReceptor() error {
+	if d.Config.Target() == "" {
+		return Skipped("no target is set")
+	}
+
+	receptorUp, authorized, err := d.TargetVerifier.VerifyTarget(d.Config.Receptor())
+	if !receptorUp {
+		return Problem{
+			Message: fmt.Sprintf("Can't reach the receptor: %s", err),
+			Remedy:  "Check that the cluster is up (vagrant status, or your cloud's console) and that no firewall blocks port 80 or 443 to it.",
+		}
+	} else if err != nil {
+		return Problem{
+			Message: fmt.Sprintf("The receptor returned an error: %s", err),
+			Remedy:  "Check the receptor's log on the brain, /var/lattice/log/receptor-service.log",
+		}
+	} else if !authorized {
+		return Problem{
+			Message: "The receptor rejected ltc's credentials.",
+			Remedy:  "Run ltc target TARGET --username USERNAME with the cluster's credentials.",
+		}
+	}
+	return nil
+}
+
+func (d *doctor) CheckClockSkew() error {
+	if d.Config.Target() == "" {
+		return Skipped("no target is set")
+	}
+
+	tlsConfig, err := d.Config.TLSConfig()
+	if err != nil {
+		return Skipped("the TLS settings are invalid")
+	}
+
+	sent := d.Clock.Now()
+	response, err := d.clusterClient(tlsConfig).Get(d.Config.Receptor())
+	if err != nil {
+		return Skipped("the receptor can't be reached")
+	}
+	received := d.Clock.Now()
+	discard(response)
+
+	clusterTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return Skipped("the receptor doesn't report its time")
+	}
+
+	// The cluster's Date is somewhere between sending and receiving, and
+	// only accurate to the second.
+	skew := sent.Add(received.Sub(sent) / 2).Sub(clusterTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > MaxClockSkew {
+		return Problem{
+			Message: fmt.Sprintf("The local clock is %s off the cluster's.", skew-skew%time.Second),
+			Remedy:  "Sync your clock, e.g. with NTP.  A skewed clock makes log timestamps misleading and can fail TLS certificate checks.",
+		}
+	}
+	return nil
+}
+
+func (d *doctor) CheckDockerHub() error {
+	client := &http.Client{Timeout: d.Timeout}
+	response, err := client.Get(d.DockerHubURL)
+	if err != nil {
+		return Problem{
+			Message: fmt.Sprintf("Can't reach Docker Hub: %s", err),
+			Remedy:  "ltc create reads image metadata from Docker Hub.  Check your connection, and set HTTPS_PROXY if you're behind a proxy.",
+		}
+	}
+	discard(response)
+
+	// The registry asks for credentials before anything else, which is
+	// enough to know it can be reached.
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusUnauthorized {
+		return Problem{
+			Message: fmt.Sprintf("Docker Hub returned %s", response.Status),
+			Remedy:  "Check https://status.docker.com, or whether a proxy is rewriting the response.",
+		}
+	}
+	return nil
+}
+
+func (d *doctor) clusterClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{Timeout: d.Timeout, Transport: &http.Transport{TLSClientConfig: tlsConfig, Dial: d.Dial}}
+}
+
+// isCertificateError matches on the message, as the crypto/x509 errors
+// reach us wrapped differently depending on where verification failed.
+func isCertificateError(err error) bool {
+	return strings.Contains(err.Error(), "x509: ")
+}
+
+func discard(response *http.Response) {
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+}
@@ -0,0 +1,286 @@
+package doctor_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	config_package "github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier/fake_target_verifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		config             *config_package.Config
+		fakeTargetVerifier *fake_target_verifier.FakeTargetVerifier
+		fakeClock          *fakeclock.FakeClock
+		doctorConfig       doctor.DoctorConfig
+		tmpDir             string
+		lookedUpHosts      []string
+		lookupErr          error
+		dialedAddress      string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "ltc-doctor")
+		Expect(err).NotTo(HaveOccurred())
+
+		config = config_package.New(persister.NewMemPersister())
+		config.SetTarget("mylattice.com")
+		Expect(config.Save()).To(Succeed())
+
+		fakeTargetVerifier = &fake_target_verifier.FakeTargetVerifier{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		lookedUpHosts = []string{}
+		lookupErr = nil
+		dialedAddress = ""
+
+		doctorConfig = doctor.DoctorConfig{
+			Config:         config,
+			ConfigPath:     filepath.Join(tmpDir, "config.json"),
+			TargetVerifier: fakeTargetVerifier,
+			LookupHost: func(host string) ([]string, error) {
+				lookedUpHosts = append(lookedUpHosts, host)
+				return []string{"192.168.11.11"}, lookupErr
+			},
+			Clock:   fakeClock,
+			Timeout: 5 * time.Second,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	// dialTo sends the doctor's connections to the cluster to server.
+	dialTo := func(server *httptest.Server) {
+		doctorConfig.Dial = func(network, address string) (net.Conn, error) {
+			dialedAddress = address
+			return net.Dial(network, server.Listener.Addr().String())
+		}
+	}
+
+	Describe("CheckConfig", func() {
+		It("passes when the config loads and sets a target", func() {
+			Expect(ioutil.WriteFile(doctorConfig.ConfigPath, []byte("{}"), 0600)).To(Succeed())
+
+			Expect(doctor.New(doctorConfig).CheckConfig()).To(Succeed())
+		})
+
+		It("fails when the config can't be loaded", func() {
+			doctorConfig.Config = config_package.New(errorPersister("invalid character"))
+
+			err := doctor.New(doctorConfig).CheckConfig()
+			Expect(err).To(BeAssignableToTypeOf(doctor.Problem{}))
+			Expect(err).To(MatchError("Can't read " + doctorConfig.ConfigPath + ": invalid character"))
+			Expect(err.(doctor.Problem).Remedy).To(Equal("Fix the JSON in " + doctorConfig.ConfigPath + ", or remove it and run ltc target again."))
+		})
+
+		It("fails when other users can read the config file", func() {
+			Expect(ioutil.WriteFile(doctorConfig.ConfigPath, []byte("{}"), 0644)).To(Succeed())
+
+			err := doctor.New(doctorConfig).CheckConfig()
+			Expect(err).To(MatchError(doctorConfig.ConfigPath + " can be read by other users, and may hold your password."))
+			Expect(err.(doctor.Problem).Remedy).To(Equal("Run chmod 600 " + doctorConfig.ConfigPath))
+		})
+
+		It("fails when no target is set", func() {
+			config.SetTarget("")
+			Expect(config.Save()).To(Succeed())
+
+			err := doctor.New(doctorConfig).CheckConfig()
+			Expect(err).To(MatchError("No target is set."))
+		})
+	})
+
+	Describe("CheckDNS", func() {
+		It("looks up the receptor's host", func() {
+			Expect(doctor.New(doctorConfig).CheckDNS()).To(Succeed())
+			Expect(lookedUpHosts).To(Equal([]string{"receptor.mylattice.com"}))
+		})
+
+		It("fails when the host doesn't resolve", func() {
+			lookupErr = errors.New("no such host")
+
+			err := doctor.New(doctorConfig).CheckDNS()
+			Expect(err).To(MatchError("Can't resolve receptor.mylattice.com: no such host"))
+			Expect(err.(doctor.Problem).Remedy).To(ContainSubstring("try another DNS server"))
+		})
+
+		It("is skipped without a target", func() {
+			config.SetTarget("")
+
+			Expect(doctor.New(doctorConfig).CheckDNS()).To(Equal(doctor.Skipped("no target is set")))
+		})
+	})
+
+	Describe("CheckTLS", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			dialTo(server)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("is skipped when the target doesn't use TLS", func() {
+			Expect(doctor.New(doctorConfig).CheckTLS()).To(Equal(doctor.Skipped("the target doesn't use TLS")))
+		})
+
+		It("fails when the receptor's certificate isn't trusted", func() {
+			config.SetTLS(config_package.TLS{Enabled: true})
+
+			err := doctor.New(doctorConfig).CheckTLS()
+			Expect(err).To(BeAssignableToTypeOf(doctor.Problem{}))
+			Expect(err.Error()).To(HavePrefix("The receptor's certificate can't be verified: "))
+			Expect(err.(doctor.Problem).Remedy).To(ContainSubstring("--ca-cert"))
+			Expect(dialedAddress).To(Equal("receptor.mylattice.com:443"))
+		})
+
+		It("passes when the certificate check is skipped", func() {
+			config.SetTLS(config_package.TLS{SkipSSLValidation: true})
+
+			Expect(doctor.New(doctorConfig).CheckTLS()).To(Succeed())
+		})
+
+		It("fails when the TLS settings are invalid", func() {
+			config.SetTLS(config_package.TLS{CACert: filepath.Join(tmpDir, "missing.pem")})
+
+			err := doctor.New(doctorConfig).CheckTLS()
+			Expect(err.Error()).To(HavePrefix("Error reading CA certificate: "))
+		})
+	})
+
+	Describe("CheckReceptor", func() {
+		It("passes when the receptor accepts ltc's credentials", func() {
+			fakeTargetVerifier.VerifyTargetReturns(true, true, nil)
+
+			Expect(doctor.New(doctorConfig).CheckReceptor()).To(Succeed())
+			Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.mylattice.com"))
+		})
+
+		It("fails when the receptor can't be reached", func() {
+			fakeTargetVerifier.VerifyTargetReturns(false, false, errors.New("connection refused"))
+
+			err := doctor.New(doctorConfig).CheckReceptor()
+			Expect(err).To(MatchError("Can't reach the receptor: connection refused"))
+		})
+
+		It("fails when the receptor rejects ltc's credentials", func() {
+			fakeTargetVerifier.VerifyTargetReturns(true, false, nil)
+
+			err := doctor.New(doctorConfig).CheckReceptor()
+			Expect(err).To(MatchError("The receptor rejected ltc's credentials."))
+			Expect(err.(doctor.Problem).Remedy).To(Equal("Run ltc target TARGET --username USERNAME with the cluster's credentials."))
+		})
+
+		It("fails when the receptor returns an error", func() {
+			fakeTargetVerifier.VerifyTargetReturns(true, false, errors.New("etcd is down"))
+
+			err := doctor.New(doctorConfig).CheckReceptor()
+			Expect(err).To(MatchError("The receptor returned an error: etcd is down"))
+		})
+	})
+
+	Describe("CheckClockSkew", func() {
+		var (
+			server      *httptest.Server
+			clusterTime time.Time
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", clusterTime.Format(http.TimeFormat))
+			}))
+			dialTo(server)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("passes when the clocks roughly agree", func() {
+			clusterTime = fakeClock.Now().Add(-10 * time.Second)
+
+			Expect(doctor.New(doctorConfig).CheckClockSkew()).To(Succeed())
+			Expect(dialedAddress).To(Equal("receptor.mylattice.com:80"))
+		})
+
+		It("fails when the clocks are too far apart", func() {
+			clusterTime = fakeClock.Now().Add(90 * time.Second)
+
+			err := doctor.New(doctorConfig).CheckClockSkew()
+			Expect(err).To(MatchError("The local clock is 1m30s off the cluster's."))
+			Expect(err.(doctor.Problem).Remedy).To(ContainSubstring("NTP"))
+		})
+
+		It("is skipped when the receptor can't be reached", func() {
+			server.Close()
+
+			Expect(doctor.New(doctorConfig).CheckClockSkew()).To(Equal(doctor.Skipped("the receptor can't be reached")))
+		})
+	})
+
+	Describe("CheckDockerHub", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			doctorConfig.DockerHubURL = server.URL() + "/v2/"
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("passes when the registry asks for credentials", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/"),
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+			))
+
+			Expect(doctor.New(doctorConfig).CheckDockerHub()).To(Succeed())
+		})
+
+		It("fails when the registry returns an error", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, ""))
+
+			err := doctor.New(doctorConfig).CheckDockerHub()
+			Expect(err).To(MatchError("Docker Hub returned 502 Bad Gateway"))
+		})
+
+		It("fails when the registry can't be reached", func() {
+			doctorConfig.DockerHubURL = "http://127.0.0.1:1/v2/"
+
+			err := doctor.New(doctorConfig).CheckDockerHub()
+			Expect(err.Error()).To(HavePrefix("Can't reach Docker Hub: "))
+			Expect(err.(doctor.Problem).Remedy).To(ContainSubstring("HTTPS_PROXY"))
+		})
+	})
+})
+
+type errorPersister string
+
+func (f errorPersister) Load(i interface{}) error {
+	return errors.New(string(f))
+}
+
+func (f errorPersister) Save(i interface{}) error {
+	return errors.New(string(f))
+}
@@ -0,0 +1,193 @@
+// This file was generated by counterfeiter
+package fake_doctor
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/doctor"
+)
+
+type FakeDoctor struct {
+	CheckConfigStub        func() error
+	checkConfigMutex       sync.RWMutex
+	checkConfigArgsForCall []struct{}
+	checkConfigReturns     struct {
+		result1 error
+	}
+	CheckDNSStub        func() error
+	checkDNSMutex       sync.RWMutex
+	checkDNSArgsForCall []struct{}
+	checkDNSReturns     struct {
+		result1 error
+	}
+	CheckTLSStub        func() error
+	checkTLSMutex       sync.RWMutex
+	checkTLSArgsForCall []struct{}
+	checkTLSReturns     struct {
+		result1 error
+	}
+	CheckReceptorStub        func() error
+	checkReceptorMutex       sync.RWMutex
+	checkReceptorArgsForCall []struct{}
+	checkReceptorReturns     struct {
+		result1 error
+	}
+	CheckClockSkewStub        func() error
+	checkClockSkewMutex       sync.RWMutex
+	checkClockSkewArgsForCall []struct{}
+	checkClockSkewReturns     struct {
+		result1 error
+	}
+	CheckDockerHubStub        func() error
+	checkDockerHubMutex       sync.RWMutex
+	checkDockerHubArgsForCall []struct{}
+	checkDockerHubReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeDoctor) CheckConfig() error {
+	fake.checkConfigMutex.Lock()
+	fake.checkConfigArgsForCall = append(fake.checkConfigArgsForCall, struct{}{})
+	fake.checkConfigMutex.Unlock()
+	if fake.CheckConfigStub != nil {
+		return fake.CheckConfigStub()
+	} else {
+		return fake.checkConfigReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckConfigCallCount() int {
+	fake.checkConfigMutex.RLock()
+	defer fake.checkConfigMutex.RUnlock()
+	return len(fake.checkConfigArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckConfigReturns(result1 error) {
+	fake.CheckConfigStub = nil
+	fake.checkConfigReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDoctor) CheckDNS() error {
+	fake.checkDNSMutex.Lock()
+	fake.checkDNSArgsForCall = append(fake.checkDNSArgsForCall, struct{}{})
+	fake.checkDNSMutex.Unlock()
+	if fake.CheckDNSStub != nil {
+		return fake.CheckDNSStub()
+	} else {
+		return fake.checkDNSReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckDNSCallCount() int {
+	fake.checkDNSMutex.RLock()
+	defer fake.checkDNSMutex.RUnlock()
+	return len(fake.checkDNSArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckDNSReturns(result1 error) {
+	fake.CheckDNSStub = nil
+	fake.checkDNSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDoctor) CheckTLS() error {
+	fake.checkTLSMutex.Lock()
+	fake.checkTLSArgsForCall = append(fake.checkTLSArgsForCall, struct{}{})
+	fake.checkTLSMutex.Unlock()
+	if fake.CheckTLSStub != nil {
+		return fake.CheckTLSStub()
+	} else {
+		return fake.checkTLSReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckTLSCallCount() int {
+	fake.checkTLSMutex.RLock()
+	defer fake.checkTLSMutex.RUnlock()
+	return len(fake.checkTLSArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckTLSReturns(result1 error) {
+	fake.CheckTLSStub = nil
+	fake.checkTLSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDoctor) CheckReceptor() error {
+	fake.checkReceptorMutex.Lock()
+	fake.checkReceptorArgsForCall = append(fake.checkReceptorArgsForCall, struct{}{})
+	fake.checkReceptorMutex.Unlock()
+	if fake.CheckReceptorStub != nil {
+		return fake.CheckReceptorStub()
+	} else {
+		return fake.checkReceptorReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckReceptorCallCount() int {
+	fake.checkReceptorMutex.RLock()
+	defer fake.checkReceptorMutex.RUnlock()
+	return len(fake.checkReceptorArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckReceptorReturns(result1 error) {
+	fake.CheckReceptorStub = nil
+	fake.checkReceptorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDoctor) CheckClockSkew() error {
+	fake.checkClockSkewMutex.Lock()
+	fake.checkClockSkewArgsForCall = append(fake.checkClockSkewArgsForCall, struct{}{})
+	fake.checkClockSkewMutex.Unlock()
+	if fake.CheckClockSkewStub != nil {
+		return fake.CheckClockSkewStub()
+	} else {
+		return fake.checkClockSkewReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckClockSkewCallCount() int {
+	fake.checkClockSkewMutex.RLock()
+	defer fake.checkClockSkewMutex.RUnlock()
+	return len(fake.checkClockSkewArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckClockSkewReturns(result1 error) {
+	fake.CheckClockSkewStub = nil
+	fake.checkClockSkewReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDoctor) CheckDockerHub() error {
+	fake.checkDockerHubMutex.Lock()
+	fake.checkDockerHubArgsForCall = append(fake.checkDockerHubArgsForCall, struct{}{})
+	fake.checkDockerHubMutex.Unlock()
+	if fake.CheckDockerHubStub != nil {
+		return fake.CheckDockerHubStub()
+	} else {
+		return fake.checkDockerHubReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) CheckDockerHubCallCount() int {
+	fake.checkDockerHubMutex.RLock()
+	defer fake.checkDockerHubMutex.RUnlock()
+	return len(fake.checkDockerHubArgsForCall)
+}
+
+func (fake *FakeDoctor) CheckDockerHubReturns(result1 error) {
+	fake.CheckDockerHubStub = nil
+	fake.checkDockerHubReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ doctor.Doctor = new(FakeDoctor)
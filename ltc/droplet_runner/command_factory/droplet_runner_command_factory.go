@@ -0,0 +1,566 @@
+package command_factory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/progress"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	DefaultBuildTimeout  time.Duration = 10 * time.Minute
+	DefaultLaunchTimeout time.Duration = 2 * time.Minute
+)
+
+// knownBuildpacks lets users name the Cloud Foundry buildpacks instead of
+// spelling out where to download them from.
+var knownBuildpacks = map[string]string{
+	"go_buildpack":         "https://github.com/cloudfoundry/go-buildpack/archive/master.zip",
+	"java_buildpack":       "https://github.com/cloudfoundry/java-buildpack/archive/master.zip",
+	"nodejs_buildpack":     "https://github.com/cloudfoundry/nodejs-buildpack/archive/master.zip",
+	"php_buildpack":        "https://github.com/cloudfoundry/php-buildpack/archive/master.zip",
+	"python_buildpack":     "https://github.com/cloudfoundry/python-buildpack/archive/master.zip",
+	"ruby_buildpack":       "https://github.com/cloudfoundry/ruby-buildpack/archive/master.zip",
+	"staticfile_buildpack": "https://github.com/cloudfoundry/staticfile-buildpack/archive/master.zip",
+	"binary_buildpack":     "https://github.com/cloudfoundry/binary-buildpack/archive/master.zip",
+}
+
+type DropletRunnerCommandFactory struct {
+	dropletRunner       droplet_runner.DropletRunner
+	taskExaminer        task_examiner.TaskExaminer
+	taskRunner          task_runner.TaskRunner
+	appExaminer         app_examiner.AppExaminer
+	ui                  terminal.UI
+	domain              string
+	clock               clock.Clock
+	tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter
+	exitHandler         exit_handler.ExitHandler
+}
+
+type DropletRunnerCommandFactoryConfig struct {
+	DropletRunner       droplet_runner.DropletRunner
+	TaskExaminer        task_examiner.TaskExaminer
+	TaskRunner          task_runner.TaskRunner
+	AppExaminer         app_examiner.AppExaminer
+	UI                  terminal.UI
+	Domain              string
+	Clock               clock.Clock
+	TailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter
+	ExitHandler         exit_handler.ExitHandler
+}
+
+func NewDropletRunnerCommandFactory(config DropletRunnerCommandFactoryConfig) *DropletRunnerCommandFactory {
+	return &DropletRunnerCommandFactory{
+		dropletRunner:       config.DropletRunner,
+		taskExaminer:        config.TaskExaminer,
+		taskRunner:          config.TaskRunner,
+		appExaminer:         config.AppExaminer,
+		ui:                  config.UI,
+		domain:              config.Domain,
+		clock:               config.Clock,
+		tailedLogsOutputter: config.TailedLogsOutputter,
+		exitHandler:         config.ExitHandler,
+	}
+}
+
+func (factory *DropletRunnerCommandFactory) MakeBuildDropletCommand() cli.Command {
+	var buildDropletFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "buildpack, b",
+			Usage: "Buildpack to stage with, by name (e.g. go_buildpack) or URL of a zip",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "Environment variables for staging (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight, c",
+			Usage: "Relative CPU weight for the build (valid values: 1-100)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "Memory limit for the build in MB",
+			Value: 512,
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "Disk limit for the build in MB",
+			Value: 0,
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for the build to complete",
+			Value: DefaultBuildTimeout,
+		},
+	}
+
+	var buildDropletCommand = cli.Command{
+		Name:    "build",
+		Aliases: []string{"bd"},
+		Usage:   "Builds a droplet from source with a buildpack",
+		Description: `ltc build DROPLET_NAME [SOURCE_DIR] --buildpack=BUILDPACK
+
+   Uploads SOURCE_DIR (by default the current directory) and stages it on
+   lattice with the buildpack.  Run the droplet with ltc launch-droplet.
+
+   Known buildpacks: ` + strings.Join(knownBuildpackNames(), ", "),
+		Action: factory.buildDroplet,
+		Flags:  buildDropletFlags,
+	}
+
+	return buildDropletCommand
+}
+
+func (factory *DropletRunnerCommandFactory) MakeLaunchDropletCommand() cli.Command {
+	var launchDropletFlags = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "Environment variables (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "instances, i",
+			Usage: "Number of application instances to spawn on launch",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight, c",
+			Usage: "Relative CPU weight for the container (valid values: 1-100)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "Memory limit for container in MB",
+			Value: 128,
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "Disk limit for container in MB",
+			Value: 0,
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for app to start",
+			Value: DefaultLaunchTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the app is submitted, without waiting for it to start",
+		},
+	}
+
+	var launchDropletCommand = cli.Command{
+		Name:    "launch-droplet",
+		Aliases: []string{"ld"},
+		Usage:   "Launches an app from a droplet built with ltc build",
+		Description: `ltc launch-droplet APP_NAME DROPLET_NAME [-- START_COMMAND [ARGS...]]
+
+   Without START_COMMAND, the app starts with the command the buildpack
+   detected.  The app listens on $PORT (8080) and is routed at
+   APP_NAME.DOMAIN.`,
+		Action: factory.launchDroplet,
+		Flags:  launchDropletFlags,
+	}
+
+	return launchDropletCommand
+}
+
+func (factory *DropletRunnerCommandFactory) MakePushCommand() cli.Command {
+	var pushFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "image",
+			Usage: "Docker image to run the app in, instead of the cflinuxfs2 rootfs",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "Environment variables (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.IntFlag{
+			Name:  "instances, i",
+			Usage: "Number of application instances to spawn on launch",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  "cpu-weight, c",
+			Usage: "Relative CPU weight for the container (valid values: 1-100)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "memory-mb, m",
+			Usage: "Memory limit for container in MB",
+			Value: 128,
+		},
+		cli.IntFlag{
+			Name:  "disk-mb, d",
+			Usage: "Disk limit for container in MB",
+			Value: 0,
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for app to start",
+			Value: DefaultLaunchTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-wait",
+			Usage: "Returns once the app is submitted, without waiting for it to start",
+		},
+	}
+
+	var pushCommand = cli.Command{
+		Name:    "push",
+		Aliases: []string{"pu"},
+		Usage:   "Uploads a local directory and runs it as an app",
+		Description: `ltc push APP_NAME [SOURCE_DIR] -- START_COMMAND [ARGS...]
+
+   Uploads SOURCE_DIR (by default the current directory) and runs
+   START_COMMAND from it, without building a droplet or docker image.
+   The directory is unpacked at /home/vcap/app.  The app listens on
+   $PORT (8080) and is routed at APP_NAME.DOMAIN.`,
+		Action: factory.push,
+		Flags:  pushFlags,
+	}
+
+	return pushCommand
+}
+
+func (factory *DropletRunnerCommandFactory) buildDroplet(context *cli.Context) {
+	buildpackFlag := context.String("buildpack")
+	envFlag := context.StringSlice("env")
+	cpuWeightFlag := uint(context.Int("cpu-weight"))
+	memoryMBFlag := context.Int("memory-mb")
+	diskMBFlag := context.Int("disk-mb")
+	timeoutFlag := context.Duration("timeout")
+	dropletName := context.Args().First()
+	sourceDir := context.Args().Get(1)
+
+	switch {
+	case dropletName == "":
+		factory.ui.SayIncorrectUsage("DROPLET_NAME is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case buildpackFlag == "":
+		factory.ui.SayIncorrectUsage("--buildpack is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case cpuWeightFlag < 1 || cpuWeightFlag > 100:
+		factory.ui.SayIncorrectUsage("Invalid CPU Weight")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	buildpackUrl, ok := knownBuildpacks[buildpackFlag]
+	if !ok && strings.Contains(buildpackFlag, "://") {
+		buildpackUrl, ok = buildpackFlag, true
+	}
+	if !ok {
+		factory.ui.SayIncorrectUsage(fmt.Sprintf("Unknown buildpack %s. Pass the URL of a zip or one of: %s", buildpackFlag, strings.Join(knownBuildpackNames(), ", ")))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if sourceDir == "" {
+		sourceDir = "."
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Uploading %s...\n", sourceDir))
+	if err := factory.dropletRunner.UploadBits(dropletName, sourceDir); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error uploading %s: %s", sourceDir, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	taskName := "build-droplet-" + dropletName
+	err := factory.dropletRunner.BuildDroplet(droplet_runner.BuildDropletParams{
+		TaskName:             taskName,
+		DropletName:          dropletName,
+		BuildpackUrl:         buildpackUrl,
+		EnvironmentVariables: parseEnvVars(envFlag),
+		CPUWeight:            cpuWeightFlag,
+		MemoryMB:             memoryMBFlag,
+		DiskMB:               diskMBFlag,
+	})
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error submitting build of %s: %s", dropletName, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Building %s with %s...\n", dropletName, buildpackFlag))
+
+	go factory.tailedLogsOutputter.OutputTailedLogs(taskName)
+	taskInfo, ok := factory.waitForBuild(timeoutFlag, taskName)
+	factory.tailedLogsOutputter.StopOutputting()
+
+	if !ok {
+		factory.ui.SayLine(colors.Red("Timed out waiting for the build to complete."))
+		factory.ui.SayLine(fmt.Sprintf("Lattice is still building %s in the background.", dropletName))
+		factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc task %s", taskName))
+		factory.exitHandler.Exit(exit_codes.Timeout)
+		return
+	}
+
+	factory.taskRunner.DeleteTask(taskName)
+
+	if taskInfo.Failed {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("Build of %s failed: %s", dropletName, taskInfo.FailureReason)))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("Built droplet %s.", dropletName)))
+	factory.ui.SayLine(fmt.Sprintf("To launch it:\n\tltc launch-droplet APP_NAME %s", dropletName))
+}
+
+func (factory *DropletRunnerCommandFactory) launchDroplet(context *cli.Context) {
+	envFlag := context.StringSlice("env")
+	instancesFlag := context.Int("instances")
+	cpuWeightFlag := uint(context.Int("cpu-weight"))
+	memoryMBFlag := context.Int("memory-mb")
+	diskMBFlag := context.Int("disk-mb")
+	timeoutFlag := context.Duration("timeout")
+	noWaitFlag := context.Bool("no-wait")
+	appName := context.Args().Get(0)
+	dropletName := context.Args().Get(1)
+	terminator := context.Args().Get(2)
+	startCommand := context.Args().Get(3)
+
+	var appArgs []string
+
+	switch {
+	case len(context.Args()) < 2:
+		factory.ui.SayIncorrectUsage("APP_NAME and DROPLET_NAME are required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case terminator != "" && (terminator != "--" || startCommand == ""):
+		factory.ui.SayIncorrectUsage("'--' Required before start command")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case cpuWeightFlag < 1 || cpuWeightFlag > 100:
+		factory.ui.SayIncorrectUsage("Invalid CPU Weight")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case len(context.Args()) > 4:
+		appArgs = context.Args()[4:]
+	}
+
+	if err := docker_app_runner.ValidateAppName(appName); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	environment := parseEnvVars(envFlag)
+	environment["PROCESS_GUID"] = appName
+
+	err := factory.dropletRunner.LaunchDroplet(droplet_runner.LaunchDropletParams{
+		Name:                 appName,
+		DropletName:          dropletName,
+		StartCommand:         startCommand,
+		AppArgs:              appArgs,
+		EnvironmentVariables: environment,
+		Instances:            instancesFlag,
+		CPUWeight:            cpuWeightFlag,
+		MemoryMB:             memoryMBFlag,
+		DiskMB:               diskMBFlag,
+	})
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error launching %s: %s", appName, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Launching %s from droplet %s...\n", appName, dropletName))
+	factory.followLaunch(appName, instancesFlag, timeoutFlag, noWaitFlag)
+}
+
+func (factory *DropletRunnerCommandFactory) push(context *cli.Context) {
+	imageFlag := context.String("image")
+	envFlag := context.StringSlice("env")
+	instancesFlag := context.Int("instances")
+	cpuWeightFlag := uint(context.Int("cpu-weight"))
+	memoryMBFlag := context.Int("memory-mb")
+	diskMBFlag := context.Int("disk-mb")
+	timeoutFlag := context.Duration("timeout")
+	noWaitFlag := context.Bool("no-wait")
+	args := context.Args()
+	appName := args.First()
+
+	terminatorIndex := -1
+	for index, arg := range args {
+		if arg == "--" {
+			terminatorIndex = index
+			break
+		}
+	}
+
+	switch {
+	case appName == "" || appName == "--":
+		factory.ui.SayIncorrectUsage("APP_NAME is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case terminatorIndex == -1 || terminatorIndex > 2 || terminatorIndex == len(args)-1:
+		factory.ui.SayIncorrectUsage("START_COMMAND is required after '--'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case cpuWeightFlag < 1 || cpuWeightFlag > 100:
+		factory.ui.SayIncorrectUsage("Invalid CPU Weight")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := docker_app_runner.ValidateAppName(appName); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	sourceDir := "."
+	if terminatorIndex == 2 {
+		sourceDir = args[1]
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Uploading %s...\n", sourceDir))
+	if err := factory.dropletRunner.UploadBits(appName, sourceDir); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error uploading %s: %s", sourceDir, err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	environment := parseEnvVars(envFlag)
+	environment["PROCESS_GUID"] = appName
+
+	err := factory.dropletRunner.LaunchBits(droplet_runner.LaunchBitsParams{
+		Name:                 appName,
+		DockerImage:          imageFlag,
+		StartCommand:         args[terminatorIndex+1],
+		AppArgs:              args[terminatorIndex+2:],
+		EnvironmentVariables: environment,
+		Instances:            instancesFlag,
+		CPUWeight:            cpuWeightFlag,
+		MemoryMB:             memoryMBFlag,
+		DiskMB:               diskMBFlag,
+	})
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error launching %s: %s", appName, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.ui.SayInfo(fmt.Sprintf("Launching %s...\n", appName))
+	factory.followLaunch(appName, instancesFlag, timeoutFlag, noWaitFlag)
+}
+
+// followLaunch tails the logs of a newly submitted app until all its
+// instances are running, then says where to reach it.
+func (factory *DropletRunnerCommandFactory) followLaunch(appName string, instances int, timeout time.Duration, noWait bool) {
+	if noWait {
+		factory.ui.SayInfo(fmt.Sprintf("To view status:\n\tltc status %s\n", appName))
+		return
+	}
+
+	go factory.tailedLogsOutputter.OutputTailedLogs(appName)
+	exitCode := factory.waitForInstances(timeout, appName, instances)
+	factory.tailedLogsOutputter.StopOutputting()
+
+	if exitCode != 0 {
+		factory.exitHandler.Exit(exitCode)
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(appName + " is now running."))
+	factory.ui.SayLine("App is reachable at:")
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("http://%s.%s", appName, factory.domain)))
+}
+
+func (factory *DropletRunnerCommandFactory) waitForBuild(timeout time.Duration, taskName string) (task_examiner.TaskInfo, bool) {
+	startingTime := factory.clock.Now()
+	for startingTime.Add(timeout).After(factory.clock.Now()) {
+		taskInfo, err := factory.taskExaminer.TaskStatus(taskName)
+		if err == nil && taskInfo.State == receptor.TaskStateCompleted {
+			return taskInfo, true
+		}
+
+		factory.clock.Sleep(1 * time.Second)
+	}
+	return task_examiner.TaskInfo{}, false
+}
+
+// waitForInstances returns 0 once all instances are running, or the exit
+// code the command should finish with if they never come up.
+func (factory *DropletRunnerCommandFactory) waitForInstances(timeout time.Duration, appName string, instances int) int {
+	progressBar := progress.NewBar(factory.ui, instances, "instances running")
+	estimator := progress.NewEstimator(factory.clock, instances)
+
+	startingTime := factory.clock.Now()
+	for startingTime.Add(timeout).After(factory.clock.Now()) {
+		counts, _ := factory.appExaminer.AppInstanceCounts(appName)
+		if counts.PlacementError {
+			progressBar.Finish()
+			err := docker_app_runner.InsufficientResourcesError{AppName: appName}
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("Error, %s", err)))
+			factory.ui.SayRemedy(err)
+			return exit_codes.PlacementError
+		}
+
+		progressBar.SetCurrent(counts.Running)
+		progressBar.SetDetail(counts.NotRunning())
+		progressBar.SetRemaining(estimator.Remaining(counts.Running))
+		if counts.Running == instances {
+			progressBar.Finish()
+			return 0
+		}
+		progressBar.Tick()
+
+		factory.clock.Sleep(1 * time.Second)
+	}
+	progressBar.Finish()
+
+	factory.ui.SayLine(colors.Red("Timed out waiting for the container to come up."))
+	factory.ui.SayLine(fmt.Sprintf("Lattice is still launching %s in the background.", appName))
+	factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", appName))
+	return exit_codes.Timeout
+}
+
+func parseEnvVars(envVars []string) map[string]string {
+	environment := make(map[string]string)
+	for _, envVarPair := range envVars {
+		pair := strings.SplitN(envVarPair, "=", 2)
+		if len(pair) > 1 {
+			environment[pair[0]] = pair[1]
+		} else {
+			environment[pair[0]] = ""
+		}
+	}
+	return environment
+}
+
+func knownBuildpackNames() []string {
+	names := make([]string, 0, len(knownBuildpacks))
+	for name := range knownBuildpacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
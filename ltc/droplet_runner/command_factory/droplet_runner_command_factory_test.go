@@ -0,0 +1,356 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/fake_droplet_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/fake_task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("DropletRunnerCommandFactory", func() {
+	var (
+		fakeDropletRunner       *fake_droplet_runner.FakeDropletRunner
+		fakeTaskExaminer        *fake_task_examiner.FakeTaskExaminer
+		fakeTaskRunner          *fake_task_runner.FakeTaskRunner
+		fakeAppExaminer         *fake_app_examiner.FakeAppExaminer
+		fakeTailedLogsOutputter *fake_tailed_logs_outputter.FakeTailedLogsOutputter
+		fakeExitHandler         *fake_exit_handler.FakeExitHandler
+		fakeClock               *fakeclock.FakeClock
+		outputBuffer            *gbytes.Buffer
+		commandFactory          *command_factory.DropletRunnerCommandFactory
+	)
+
+	BeforeEach(func() {
+		fakeDropletRunner = &fake_droplet_runner.FakeDropletRunner{}
+		fakeTaskExaminer = &fake_task_examiner.FakeTaskExaminer{}
+		fakeTaskRunner = &fake_task_runner.FakeTaskRunner{}
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		outputBuffer = gbytes.NewBuffer()
+
+		commandFactory = command_factory.NewDropletRunnerCommandFactory(command_factory.DropletRunnerCommandFactoryConfig{
+			DropletRunner:       fakeDropletRunner,
+			TaskExaminer:        fakeTaskExaminer,
+			TaskRunner:          fakeTaskRunner,
+			AppExaminer:         fakeAppExaminer,
+			UI:                  terminal.NewUI(nil, outputBuffer, nil),
+			Domain:              "192.168.11.11.xip.io",
+			Clock:               fakeClock,
+			TailedLogsOutputter: fakeTailedLogsOutputter,
+			ExitHandler:         fakeExitHandler,
+		})
+	})
+
+	Describe("BuildDropletCommand", func() {
+		var buildDropletCommand cli.Command
+
+		BeforeEach(func() {
+			buildDropletCommand = commandFactory.MakeBuildDropletCommand()
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED"}, nil)
+		})
+
+		It("uploads the source and builds a droplet with the buildpack", func() {
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "./src", "--buildpack", "go_buildpack", "--env", "GOPACKAGENAME=app"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Uploading ./src..."))
+			Expect(fakeDropletRunner.UploadBitsCallCount()).To(Equal(1))
+			dropletName, sourceDir := fakeDropletRunner.UploadBitsArgsForCall(0)
+			Expect(dropletName).To(Equal("droplet-name"))
+			Expect(sourceDir).To(Equal("./src"))
+
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(1))
+			Expect(fakeDropletRunner.BuildDropletArgsForCall(0)).To(Equal(droplet_runner.BuildDropletParams{
+				TaskName:             "build-droplet-droplet-name",
+				DropletName:          "droplet-name",
+				BuildpackUrl:         "https://github.com/cloudfoundry/go-buildpack/archive/master.zip",
+				EnvironmentVariables: map[string]string{"GOPACKAGENAME": "app"},
+				CPUWeight:            100,
+				MemoryMB:             512,
+				DiskMB:               0,
+			}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Building droplet-name with go_buildpack..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Built droplet droplet-name.")))
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc launch-droplet APP_NAME droplet-name"))
+
+			Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("build-droplet-droplet-name"))
+			Expect(fakeTaskRunner.DeleteTaskCallCount()).To(Equal(1))
+			Expect(fakeTaskRunner.DeleteTaskArgsForCall(0)).To(Equal("build-droplet-droplet-name"))
+			Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("uploads the current directory by default and accepts buildpack URLs", func() {
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "-b", "https://example.com/buildpack.zip"})
+
+			_, sourceDir := fakeDropletRunner.UploadBitsArgsForCall(0)
+			Expect(sourceDir).To(Equal("."))
+			Expect(fakeDropletRunner.BuildDropletArgsForCall(0).BuildpackUrl).To(Equal("https://example.com/buildpack.zip"))
+		})
+
+		It("reports failed builds", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED", Failed: true, FailureReason: "no go files"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "-b", "go_buildpack"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Build of droplet-name failed: no go files")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("times out waiting for the build", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "RUNNING"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "-b", "go_buildpack", "--timeout", "0"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for the build to complete.")))
+			Expect(outputBuffer).To(test_helpers.SayLine("Lattice is still building droplet-name in the background."))
+			Expect(fakeTaskRunner.DeleteTaskCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+		})
+
+		It("reports errors uploading the source", func() {
+			fakeDropletRunner.UploadBitsReturns(errors.New("no such directory"))
+
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "./src", "-b", "go_buildpack"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error uploading ./src: no such directory"))
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("reports errors submitting the build", func() {
+			fakeDropletRunner.BuildDropletReturns(errors.New("already submitted"))
+
+			test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "-b", "go_buildpack"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error submitting build of droplet-name: already submitted"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		Context("invalid syntax", func() {
+			It("requires a droplet name", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"-b", "go_buildpack"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(outputBuffer).To(test_helpers.Say("DROPLET_NAME is required"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("requires a buildpack", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.Say("--buildpack is required"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects unknown buildpack names", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "-b", "cobol_buildpack"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Unknown buildpack cobol_buildpack."))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+
+	Describe("LaunchDropletCommand", func() {
+		var launchDropletCommand cli.Command
+
+		BeforeEach(func() {
+			launchDropletCommand = commandFactory.MakeLaunchDropletCommand()
+			fakeAppExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 2}, nil)
+		})
+
+		It("launches the droplet and waits for it to run", func() {
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name", "-i", "2", "-e", "TIMEZONE=UTC", "--", "bin/server", "-port", "8080"})
+
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(1))
+			Expect(fakeDropletRunner.LaunchDropletArgsForCall(0)).To(Equal(droplet_runner.LaunchDropletParams{
+				Name:                 "app-name",
+				DropletName:          "droplet-name",
+				StartCommand:         "bin/server",
+				AppArgs:              []string{"-port", "8080"},
+				EnvironmentVariables: map[string]string{"TIMEZONE": "UTC", "PROCESS_GUID": "app-name"},
+				Instances:            2,
+				CPUWeight:            100,
+				MemoryMB:             128,
+				DiskMB:               0,
+			}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Launching app-name from droplet droplet-name..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("app-name is now running.")))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("http://app-name.192.168.11.11.xip.io")))
+			Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("returns once the app is submitted with --no-wait", func() {
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name", "--no-wait"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc status app-name"))
+			Expect(fakeAppExaminer.AppInstanceCountsCallCount()).To(BeZero())
+		})
+
+		It("reports placement errors", func() {
+			fakeAppExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{PlacementError: true}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error, could not place all instances"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.PlacementError}))
+		})
+
+		It("times out waiting for instances", func() {
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name", "-i", "3", "--timeout", "0"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for the container to come up.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.Timeout}))
+		})
+
+		It("reports droplets that have not been built", func() {
+			fakeDropletRunner.LaunchDropletReturns(droplet_runner.DropletNotFoundError{DropletName: "droplet-name"})
+
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error launching app-name: Droplet droplet-name has not been built."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		Context("invalid syntax", func() {
+			It("requires an app name and droplet name", func() {
+				test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name"})
+
+				Expect(outputBuffer).To(test_helpers.Say("APP_NAME and DROPLET_NAME are required"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("requires -- before the start command", func() {
+				test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app-name", "droplet-name", "bin/server"})
+
+				Expect(outputBuffer).To(test_helpers.Say("'--' Required before start command"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects app names that can't be used in hostnames", func() {
+				test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"app_name", "droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(outputBuffer).To(test_helpers.Say("app_name is not a valid app name: it contains '_'."))
+				Expect(fakeDropletRunner.LaunchDropletCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+
+	Describe("PushCommand", func() {
+		var pushCommand cli.Command
+
+		BeforeEach(func() {
+			pushCommand = commandFactory.MakePushCommand()
+			fakeAppExaminer.AppInstanceCountsReturns(app_examiner.InstanceCounts{Running: 1}, nil)
+		})
+
+		It("uploads the directory and runs the start command from it", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"app-name", "./bin", "--image", "ubuntu", "-e", "TIMEZONE=UTC", "--", "./server", "-port", "8080"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Uploading ./bin..."))
+			Expect(fakeDropletRunner.UploadBitsCallCount()).To(Equal(1))
+			bitsName, sourceDir := fakeDropletRunner.UploadBitsArgsForCall(0)
+			Expect(bitsName).To(Equal("app-name"))
+			Expect(sourceDir).To(Equal("./bin"))
+
+			Expect(fakeDropletRunner.LaunchBitsCallCount()).To(Equal(1))
+			Expect(fakeDropletRunner.LaunchBitsArgsForCall(0)).To(Equal(droplet_runner.LaunchBitsParams{
+				Name:                 "app-name",
+				DockerImage:          "ubuntu",
+				StartCommand:         "./server",
+				AppArgs:              []string{"-port", "8080"},
+				EnvironmentVariables: map[string]string{"TIMEZONE": "UTC", "PROCESS_GUID": "app-name"},
+				Instances:            1,
+				CPUWeight:            100,
+				MemoryMB:             128,
+				DiskMB:               0,
+			}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Launching app-name..."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("app-name is now running.")))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("http://app-name.192.168.11.11.xip.io")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("uploads the current directory by default", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"app-name", "--", "./server"})
+
+			_, sourceDir := fakeDropletRunner.UploadBitsArgsForCall(0)
+			Expect(sourceDir).To(Equal("."))
+			launchBitsParams := fakeDropletRunner.LaunchBitsArgsForCall(0)
+			Expect(launchBitsParams.StartCommand).To(Equal("./server"))
+			Expect(launchBitsParams.AppArgs).To(BeEmpty())
+		})
+
+		It("reports errors uploading the directory", func() {
+			fakeDropletRunner.UploadBitsReturns(errors.New("no such directory"))
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"app-name", "./bin", "--", "./server"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error uploading ./bin: no such directory"))
+			Expect(fakeDropletRunner.LaunchBitsCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("reports errors launching the app", func() {
+			fakeDropletRunner.LaunchBitsReturns(errors.New("app-name already exists"))
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"app-name", "--", "./server"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error launching app-name: app-name already exists"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		Context("invalid syntax", func() {
+			It("requires an app name", func() {
+				test_helpers.ExecuteCommandWithArgs(pushCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("APP_NAME is required"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("requires a start command after --", func() {
+				test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"app-name", "./bin", "./server"})
+
+				Expect(outputBuffer).To(test_helpers.Say("START_COMMAND is required after '--'"))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects app names that can't be used in hostnames", func() {
+				test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"MyApp", "--", "./server"})
+
+				Expect(outputBuffer).To(test_helpers.Say("MyApp is not a valid app name: it contains uppercase letters."))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+})
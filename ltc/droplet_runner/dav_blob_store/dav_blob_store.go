@@ -0,0 +1,88 @@
+package dav_blob_store
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type BlobNotFoundError struct {
+	Path string
+}
+
+func (e BlobNotFoundError) Error() string {
+	return fmt.Sprintf("%s not found in the blob store", e.Path)
+}
+
+func (BlobNotFoundError) NotFound() bool {
+	return true
+}
+
+// BlobStore keeps blobs on a WebDAV server under /blobs.  Containers fetch
+// and store blobs through the URLs it hands out, so those URLs carry any
+// credentials in the base URL.
+type BlobStore struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func New(baseURL string, httpClient *http.Client) *BlobStore {
+	return &BlobStore{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (b *BlobStore) URL(path string) string {
+	return b.baseURL + "/blobs/" + path
+}
+
+func (b *BlobStore) Upload(path string, contents io.Reader) error {
+	response, err := b.do("PUT", path, contents)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
+	response, err := b.do("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (b *BlobStore) Delete(path string) error {
+	response, err := b.do("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+func (b *BlobStore) do(method, path string, body io.Reader) (*http.Response, error) {
+	request, err := http.NewRequest(method, b.URL(path), body)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := b.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, BlobNotFoundError{path}
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, fmt.Errorf("blob store returned %s for %s %s", response.Status, method, path)
+	}
+
+	return response, nil
+}
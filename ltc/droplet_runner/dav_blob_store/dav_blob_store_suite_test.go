@@ -0,0 +1,13 @@
+package dav_blob_store_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDavBlobStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DavBlobStore Suite")
+}
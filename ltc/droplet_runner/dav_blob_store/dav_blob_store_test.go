@@ -0,0 +1,102 @@
+package dav_blob_store_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/dav_blob_store"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+)
+
+var _ = Describe("BlobStore", func() {
+	var (
+		fakeServer *ghttp.Server
+		blobStore  *dav_blob_store.BlobStore
+		baseURL    string
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		serverURL, err := url.Parse(fakeServer.URL())
+		Expect(err).NotTo(HaveOccurred())
+		serverURL.User = url.UserPassword("user", "pass")
+		baseURL = serverURL.String()
+
+		blobStore = dav_blob_store.New(baseURL+"/", &http.Client{})
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Describe("URL", func() {
+		It("includes the credentials of the base URL", func() {
+			Expect(blobStore.URL("droplet/bits.tgz")).To(Equal(baseURL + "/blobs/droplet/bits.tgz"))
+		})
+	})
+
+	Describe("Upload", func() {
+		It("PUTs the contents to the blob's path", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/blobs/droplet/bits.tgz"),
+				ghttp.VerifyBasicAuth("user", "pass"),
+				func(w http.ResponseWriter, r *http.Request) {
+					body, err := ioutil.ReadAll(r.Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(body)).To(Equal("some bits"))
+				},
+				ghttp.RespondWith(http.StatusCreated, ""),
+			))
+
+			Expect(blobStore.Upload("droplet/bits.tgz", strings.NewReader("some bits"))).To(Succeed())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns an error when the server rejects the upload", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+
+			err := blobStore.Upload("droplet/bits.tgz", strings.NewReader("some bits"))
+			Expect(err).To(MatchError("blob store returned 403 Forbidden for PUT droplet/bits.tgz"))
+		})
+	})
+
+	Describe("Download", func() {
+		It("returns the contents of the blob", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/blobs/droplet/result.json"),
+				ghttp.RespondWith(http.StatusOK, "{}"),
+			))
+
+			contents, err := blobStore.Download("droplet/result.json")
+			Expect(err).NotTo(HaveOccurred())
+			defer contents.Close()
+
+			Expect(ioutil.ReadAll(contents)).To(Equal([]byte("{}")))
+		})
+
+		It("returns a not found error for missing blobs", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+			_, err := blobStore.Download("droplet/result.json")
+			Expect(err).To(MatchError("droplet/result.json not found in the blob store"))
+			Expect(exit_codes.ForError(err, exit_codes.CommandFailed)).To(Equal(exit_codes.NotFound))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("DELETEs the blob", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/blobs/droplet/bits.tgz"),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			))
+
+			Expect(blobStore.Delete("droplet/bits.tgz")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,361 @@
+package droplet_runner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_repository_name_formatter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+const (
+	lifecycleDownloadUrl   = "http://file_server.service.dc1.consul:8080/v1/static/lifecycle.tgz"
+	healthcheckDownloadUrl = "http://file_server.service.dc1.consul:8080/v1/static/healthcheck.tgz"
+	dropletRootFS          = "preloaded:cflinuxfs2"
+	lrpDomain              = "lattice"
+	appPort                = 8080
+)
+
+type DropletNotFoundError struct {
+	DropletName string
+}
+
+func (e DropletNotFoundError) Error() string {
+	return fmt.Sprintf("Droplet %s has not been built.", e.DropletName)
+}
+
+func (DropletNotFoundError) NotFound() bool {
+	return true
+}
+
+//go:generate counterfeiter -o fake_blob_store/fake_blob_store.go . BlobStore
+type BlobStore interface {
+	URL(path string) string
+	Upload(path string, contents io.Reader) error
+	Download(path string) (io.ReadCloser, error)
+	Delete(path string) error
+}
+
+type BuildDropletParams struct {
+	TaskName             string
+	DropletName          string
+	BuildpackUrl         string
+	EnvironmentVariables map[string]string
+	CPUWeight            uint
+	MemoryMB             int
+	DiskMB               int
+}
+
+type LaunchDropletParams struct {
+	Name                 string
+	DropletName          string
+	StartCommand         string
+	AppArgs              []string
+	EnvironmentVariables map[string]string
+	Instances            int
+	CPUWeight            uint
+	MemoryMB             int
+	DiskMB               int
+}
+
+type LaunchBitsParams struct {
+	Name                 string
+	DockerImage          string
+	StartCommand         string
+	AppArgs              []string
+	EnvironmentVariables map[string]string
+	Instances            int
+	CPUWeight            uint
+	MemoryMB             int
+	DiskMB               int
+}
+
+//go:generate counterfeiter -o fake_droplet_runner/fake_droplet_runner.go . DropletRunner
+type DropletRunner interface {
+	UploadBits(dropletName, sourceDir string) error
+	BuildDroplet(params BuildDropletParams) error
+	LaunchDroplet(params LaunchDropletParams) error
+	LaunchBits(params LaunchBitsParams) error
+}
+
+type dropletRunner struct {
+	appRunner    docker_app_runner.AppRunner
+	taskRunner   task_runner.TaskRunner
+	blobStore    BlobStore
+	systemDomain string
+}
+
+func New(appRunner docker_app_runner.AppRunner, taskRunner task_runner.TaskRunner, blobStore BlobStore, systemDomain string) DropletRunner {
+	return &dropletRunner{appRunner, taskRunner, blobStore, systemDomain}
+}
+
+// UploadBits stores sourceDir in the blob store as a gzipped tarball for
+// BuildDroplet to stage.
+func (d *dropletRunner) UploadBits(dropletName, sourceDir string) error {
+	if info, err := os.Stat(sourceDir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", sourceDir)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(tarDirectory(sourceDir, writer))
+	}()
+
+	err := d.blobStore.Upload(bitsPath(dropletName), reader)
+	reader.Close()
+	return err
+}
+
+// BuildDroplet submits a task that stages the uploaded bits with the
+// buildpack and stores the droplet, along with the staging result, next
+// to them in the blob store.
+func (d *dropletRunner) BuildDroplet(params BuildDropletParams) error {
+	buildpackDir := fmt.Sprintf("/tmp/buildpacks/%x", md5.Sum([]byte(params.BuildpackUrl)))
+
+	task := receptor.TaskCreateRequest{
+		TaskGuid:             params.TaskName,
+		Domain:               lrpDomain,
+		RootFS:               dropletRootFS,
+		CPUWeight:            params.CPUWeight,
+		MemoryMB:             params.MemoryMB,
+		DiskMB:               params.DiskMB,
+		LogGuid:              params.TaskName,
+		LogSource:            "BUILD",
+		MetricsGuid:          params.TaskName,
+		ResultFile:           "/tmp/result.json",
+		EnvironmentVariables: buildEnvironmentVariables(params.EnvironmentVariables),
+		Action: models.Serial(
+			&models.DownloadAction{From: lifecycleDownloadUrl, To: "/tmp/lifecycle"},
+			&models.DownloadAction{From: d.blobStore.URL(bitsPath(params.DropletName)), To: "/tmp/app"},
+			&models.DownloadAction{From: params.BuildpackUrl, To: buildpackDir},
+			&models.RunAction{
+				Path: "/tmp/lifecycle/builder",
+				Args: []string{
+					"-buildDir=/tmp/app",
+					"-buildpacksDir=/tmp/buildpacks",
+					"-buildpackOrder=" + params.BuildpackUrl,
+					"-buildArtifactsCacheDir=/tmp/cache",
+					"-outputBuildArtifactsCache=/tmp/output-cache",
+					"-outputDroplet=/tmp/droplet",
+					"-outputMetadata=/tmp/result.json",
+					"-skipDetect=true",
+				},
+			},
+			&models.RunAction{Path: "curl", Args: []string{"-s", "-f", "-T", "/tmp/droplet", d.blobStore.URL(dropletPath(params.DropletName))}},
+			&models.RunAction{Path: "curl", Args: []string{"-s", "-f", "-T", "/tmp/result.json", d.blobStore.URL(resultPath(params.DropletName))}},
+		),
+	}
+
+	taskJson, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.taskRunner.SubmitTask(taskJson)
+	return err
+}
+
+// LaunchDroplet runs a built droplet as an app, starting it with the
+// command the buildpack detected unless params.StartCommand is set.
+func (d *dropletRunner) LaunchDroplet(params LaunchDropletParams) error {
+	executionMetadata, err := d.executionMetadata(params.DropletName)
+	if err != nil {
+		return err
+	}
+
+	var startCommand string
+	if params.StartCommand != "" {
+		startCommand = strings.Join(append([]string{params.StartCommand}, params.AppArgs...), " ")
+	}
+
+	lrp := d.desiredApp(params.Name, dropletRootFS, params.EnvironmentVariables, params.Instances, params.CPUWeight, params.MemoryMB, params.DiskMB)
+	lrp.Setup = models.Serial(
+		&models.DownloadAction{From: healthcheckDownloadUrl, To: "/tmp"},
+		&models.DownloadAction{From: lifecycleDownloadUrl, To: "/tmp/lifecycle"},
+		&models.DownloadAction{From: d.blobStore.URL(dropletPath(params.DropletName)), To: "/home/vcap"},
+	)
+	lrp.Action = &models.RunAction{
+		Path: "/tmp/lifecycle/launcher",
+		Args: []string{"app", startCommand, executionMetadata},
+		Dir:  "/home/vcap",
+	}
+
+	return d.submitLrp(lrp)
+}
+
+// LaunchBits runs the bits uploaded under params.Name as they are, with
+// no staging, in params.DockerImage or the preloaded cflinuxfs2 rootfs.
+func (d *dropletRunner) LaunchBits(params LaunchBitsParams) error {
+	rootFS := dropletRootFS
+	if params.DockerImage != "" {
+		var err error
+		if rootFS, err = docker_repository_name_formatter.FormatForReceptor(params.DockerImage); err != nil {
+			return err
+		}
+	}
+
+	lrp := d.desiredApp(params.Name, rootFS, params.EnvironmentVariables, params.Instances, params.CPUWeight, params.MemoryMB, params.DiskMB)
+	lrp.Setup = models.Serial(
+		&models.DownloadAction{From: healthcheckDownloadUrl, To: "/tmp"},
+		&models.DownloadAction{From: d.blobStore.URL(bitsPath(params.Name)), To: "/home/vcap/app"},
+	)
+	lrp.Action = &models.RunAction{
+		Path: params.StartCommand,
+		Args: params.AppArgs,
+		Dir:  "/home/vcap/app",
+	}
+
+	return d.submitLrp(lrp)
+}
+
+// desiredApp describes an app listening on appPort and routed at
+// name.systemDomain, leaving its setup and action to the caller.
+func (d *dropletRunner) desiredApp(name, rootFS string, environmentVariables map[string]string, instances int, cpuWeight uint, memoryMB, diskMB int) receptor.DesiredLRPCreateRequest {
+	envVars := buildEnvironmentVariables(environmentVariables)
+	envVars = append(envVars, receptor.EnvironmentVariable{Name: "PORT", Value: fmt.Sprint(appPort)})
+
+	appRoutes := route_helpers.AppRoutes{
+		{Hostnames: []string{fmt.Sprintf("%s.%s", name, d.systemDomain)}, Port: appPort},
+	}
+
+	return receptor.DesiredLRPCreateRequest{
+		ProcessGuid:          name,
+		Domain:               lrpDomain,
+		RootFS:               rootFS,
+		Instances:            instances,
+		Routes:               appRoutes.RoutingInfo(),
+		CPUWeight:            cpuWeight,
+		MemoryMB:             memoryMB,
+		DiskMB:               diskMB,
+		Ports:                []uint16{appPort},
+		LogGuid:              name,
+		LogSource:            "APP",
+		MetricsGuid:          name,
+		EnvironmentVariables: envVars,
+		Monitor: &models.RunAction{
+			Path:      "/tmp/healthcheck",
+			Args:      []string{"-port", fmt.Sprint(appPort)},
+			LogSource: "HEALTH",
+		},
+	}
+}
+
+func (d *dropletRunner) submitLrp(lrp receptor.DesiredLRPCreateRequest) error {
+	lrpJson, err := json.Marshal(lrp)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.appRunner.SubmitLrp(lrpJson)
+	return err
+}
+
+func (d *dropletRunner) executionMetadata(dropletName string) (string, error) {
+	result, err := d.blobStore.Download(resultPath(dropletName))
+	if err != nil {
+		if notFoundErr, ok := err.(interface {
+			NotFound() bool
+		}); ok && notFoundErr.NotFound() {
+			return "", DropletNotFoundError{dropletName}
+		}
+		return "", err
+	}
+	defer result.Close()
+
+	var stagingResult struct {
+		ExecutionMetadata string `json:"execution_metadata"`
+	}
+	if err := json.NewDecoder(result).Decode(&stagingResult); err != nil {
+		return "", fmt.Errorf("Invalid staging result for droplet %s: %s", dropletName, err)
+	}
+	return stagingResult.ExecutionMetadata, nil
+}
+
+func tarDirectory(sourceDir string, writer io.Writer) error {
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(sourceDir, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func buildEnvironmentVariables(environmentVariables map[string]string) []receptor.EnvironmentVariable {
+	envVars := make([]receptor.EnvironmentVariable, 0, len(environmentVariables)+1)
+	for name, value := range environmentVariables {
+		envVars = append(envVars, receptor.EnvironmentVariable{Name: name, Value: value})
+	}
+	return envVars
+}
+
+func bitsPath(dropletName string) string {
+	return dropletName + "/bits.tgz"
+}
+
+func dropletPath(dropletName string) string {
+	return dropletName + "/droplet.tgz"
+}
+
+func resultPath(dropletName string) string {
+	return dropletName + "/result.json"
+}
@@ -0,0 +1,305 @@
+package droplet_runner_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/dav_blob_store"
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner/fake_blob_store"
+	"github.com/cloudfoundry-incubator/lattice/ltc/route_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/fake_task_runner"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+var _ = Describe("DropletRunner", func() {
+	var (
+		fakeAppRunner  *fake_app_runner.FakeAppRunner
+		fakeTaskRunner *fake_task_runner.FakeTaskRunner
+		fakeBlobStore  *fake_blob_store.FakeBlobStore
+		dropletRunner  droplet_runner.DropletRunner
+	)
+
+	BeforeEach(func() {
+		fakeAppRunner = &fake_app_runner.FakeAppRunner{}
+		fakeTaskRunner = &fake_task_runner.FakeTaskRunner{}
+		fakeBlobStore = &fake_blob_store.FakeBlobStore{}
+		fakeBlobStore.URLStub = func(path string) string {
+			return "http://blobstore.example.com/blobs/" + path
+		}
+		dropletRunner = droplet_runner.New(fakeAppRunner, fakeTaskRunner, fakeBlobStore, "example.com")
+	})
+
+	Describe("UploadBits", func() {
+		var sourceDir string
+
+		BeforeEach(func() {
+			var err error
+			sourceDir, err = ioutil.TempDir("", "droplet-source")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Mkdir(filepath.Join(sourceDir, "lib"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "main.go"), []byte("package main"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "lib", "lib.go"), []byte("package lib"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(sourceDir)
+		})
+
+		It("uploads the directory as a gzipped tarball", func() {
+			var uploaded []byte
+			fakeBlobStore.UploadStub = func(path string, contents io.Reader) error {
+				var err error
+				uploaded, err = ioutil.ReadAll(contents)
+				return err
+			}
+
+			Expect(dropletRunner.UploadBits("droplet-name", sourceDir)).To(Succeed())
+
+			Expect(fakeBlobStore.UploadCallCount()).To(Equal(1))
+			path, _ := fakeBlobStore.UploadArgsForCall(0)
+			Expect(path).To(Equal("droplet-name/bits.tgz"))
+
+			gzipReader, err := gzip.NewReader(bytes.NewReader(uploaded))
+			Expect(err).NotTo(HaveOccurred())
+			tarReader := tar.NewReader(gzipReader)
+
+			files := map[string]string{}
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).NotTo(HaveOccurred())
+				contents, err := ioutil.ReadAll(tarReader)
+				Expect(err).NotTo(HaveOccurred())
+				files[header.Name] = string(contents)
+			}
+			Expect(files).To(Equal(map[string]string{
+				"lib/":       "",
+				"lib/lib.go": "package lib",
+				"main.go":    "package main",
+			}))
+		})
+
+		It("returns errors from the blob store", func() {
+			fakeBlobStore.UploadStub = func(path string, contents io.Reader) error {
+				return errors.New("upload failed")
+			}
+
+			Expect(dropletRunner.UploadBits("droplet-name", sourceDir)).To(MatchError("upload failed"))
+		})
+
+		It("returns an error when the source is not a directory", func() {
+			err := dropletRunner.UploadBits("droplet-name", filepath.Join(sourceDir, "main.go"))
+			Expect(err).To(MatchError(filepath.Join(sourceDir, "main.go") + " is not a directory"))
+			Expect(fakeBlobStore.UploadCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("BuildDroplet", func() {
+		It("submits a task that stages the bits with the buildpack", func() {
+			err := dropletRunner.BuildDroplet(droplet_runner.BuildDropletParams{
+				TaskName:             "build-droplet-droplet-name",
+				DropletName:          "droplet-name",
+				BuildpackUrl:         "https://github.com/cloudfoundry/go-buildpack.git",
+				EnvironmentVariables: map[string]string{"GOPACKAGENAME": "app"},
+				CPUWeight:            100,
+				MemoryMB:             512,
+				DiskMB:               1024,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTaskRunner.SubmitTaskCallCount()).To(Equal(1))
+			task := receptor.TaskCreateRequest{}
+			Expect(json.Unmarshal(fakeTaskRunner.SubmitTaskArgsForCall(0), &task)).To(Succeed())
+
+			Expect(task.TaskGuid).To(Equal("build-droplet-droplet-name"))
+			Expect(task.RootFS).To(Equal("preloaded:cflinuxfs2"))
+			Expect(task.LogGuid).To(Equal("build-droplet-droplet-name"))
+			Expect(task.LogSource).To(Equal("BUILD"))
+			Expect(task.ResultFile).To(Equal("/tmp/result.json"))
+			Expect(task.CPUWeight).To(Equal(uint(100)))
+			Expect(task.MemoryMB).To(Equal(512))
+			Expect(task.DiskMB).To(Equal(1024))
+			Expect(task.EnvironmentVariables).To(ConsistOf(receptor.EnvironmentVariable{Name: "GOPACKAGENAME", Value: "app"}))
+
+			actions := task.Action.(*models.SerialAction).Actions
+			Expect(actions).To(HaveLen(6))
+			Expect(actions[1]).To(Equal(&models.DownloadAction{From: "http://blobstore.example.com/blobs/droplet-name/bits.tgz", To: "/tmp/app"}))
+			Expect(actions[2].(*models.DownloadAction).From).To(Equal("https://github.com/cloudfoundry/go-buildpack.git"))
+			builder := actions[3].(*models.RunAction)
+			Expect(builder.Path).To(Equal("/tmp/lifecycle/builder"))
+			Expect(builder.Args).To(ContainElement("-buildpackOrder=https://github.com/cloudfoundry/go-buildpack.git"))
+			Expect(actions[4].(*models.RunAction).Args).To(Equal([]string{"-s", "-f", "-T", "/tmp/droplet", "http://blobstore.example.com/blobs/droplet-name/droplet.tgz"}))
+			Expect(actions[5].(*models.RunAction).Args).To(Equal([]string{"-s", "-f", "-T", "/tmp/result.json", "http://blobstore.example.com/blobs/droplet-name/result.json"}))
+		})
+
+		It("returns errors submitting the task", func() {
+			fakeTaskRunner.SubmitTaskReturns("", errors.New("already submitted"))
+
+			err := dropletRunner.BuildDroplet(droplet_runner.BuildDropletParams{TaskName: "build-droplet-droplet-name"})
+			Expect(err).To(MatchError("already submitted"))
+		})
+	})
+
+	Describe("LaunchDroplet", func() {
+		BeforeEach(func() {
+			fakeBlobStore.DownloadReturns(ioutil.NopCloser(bytes.NewBufferString(`{"execution_metadata":"{\"start_command\":\"app\"}"}`)), nil)
+		})
+
+		It("desires an LRP that runs the droplet", func() {
+			err := dropletRunner.LaunchDroplet(droplet_runner.LaunchDropletParams{
+				Name:                 "app-name",
+				DropletName:          "droplet-name",
+				EnvironmentVariables: map[string]string{"TIMEZONE": "UTC"},
+				Instances:            2,
+				CPUWeight:            50,
+				MemoryMB:             128,
+				DiskMB:               256,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("droplet-name/result.json"))
+
+			Expect(fakeAppRunner.SubmitLrpCallCount()).To(Equal(1))
+			lrp := receptor.DesiredLRPCreateRequest{}
+			Expect(json.Unmarshal(fakeAppRunner.SubmitLrpArgsForCall(0), &lrp)).To(Succeed())
+
+			Expect(lrp.ProcessGuid).To(Equal("app-name"))
+			Expect(lrp.RootFS).To(Equal("preloaded:cflinuxfs2"))
+			Expect(lrp.Instances).To(Equal(2))
+			Expect(lrp.CPUWeight).To(Equal(uint(50)))
+			Expect(lrp.MemoryMB).To(Equal(128))
+			Expect(lrp.DiskMB).To(Equal(256))
+			Expect(lrp.Ports).To(Equal([]uint16{8080}))
+			Expect(route_helpers.AppRoutesFromRoutingInfo(lrp.Routes)).To(Equal(route_helpers.AppRoutes{
+				{Hostnames: []string{"app-name.example.com"}, Port: 8080},
+			}))
+			Expect(lrp.EnvironmentVariables).To(ConsistOf(
+				receptor.EnvironmentVariable{Name: "TIMEZONE", Value: "UTC"},
+				receptor.EnvironmentVariable{Name: "PORT", Value: "8080"},
+			))
+
+			setup := lrp.Setup.(*models.SerialAction).Actions
+			Expect(setup[2]).To(Equal(&models.DownloadAction{From: "http://blobstore.example.com/blobs/droplet-name/droplet.tgz", To: "/home/vcap"}))
+			Expect(lrp.Action).To(Equal(&models.RunAction{
+				Path: "/tmp/lifecycle/launcher",
+				Args: []string{"app", "", `{"start_command":"app"}`},
+				Dir:  "/home/vcap",
+			}))
+		})
+
+		It("passes an explicit start command to the launcher", func() {
+			err := dropletRunner.LaunchDroplet(droplet_runner.LaunchDropletParams{
+				Name:         "app-name",
+				DropletName:  "droplet-name",
+				StartCommand: "bin/server",
+				AppArgs:      []string{"-port", "8080"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			lrp := receptor.DesiredLRPCreateRequest{}
+			Expect(json.Unmarshal(fakeAppRunner.SubmitLrpArgsForCall(0), &lrp)).To(Succeed())
+			Expect(lrp.Action.(*models.RunAction).Args[1]).To(Equal("bin/server -port 8080"))
+		})
+
+		It("reports droplets that have not been built", func() {
+			fakeBlobStore.DownloadReturns(nil, dav_blob_store.BlobNotFoundError{Path: "droplet-name/result.json"})
+
+			err := dropletRunner.LaunchDroplet(droplet_runner.LaunchDropletParams{Name: "app-name", DropletName: "droplet-name"})
+			Expect(err).To(MatchError("Droplet droplet-name has not been built."))
+			Expect(err).To(BeAssignableToTypeOf(droplet_runner.DropletNotFoundError{}))
+			Expect(fakeAppRunner.SubmitLrpCallCount()).To(BeZero())
+		})
+
+		It("returns errors submitting the LRP", func() {
+			fakeAppRunner.SubmitLrpReturns("app-name", errors.New("app-name already exists"))
+
+			err := dropletRunner.LaunchDroplet(droplet_runner.LaunchDropletParams{Name: "app-name", DropletName: "droplet-name"})
+			Expect(err).To(MatchError("app-name already exists"))
+		})
+	})
+
+	Describe("LaunchBits", func() {
+		It("desires an LRP that runs the start command in the uploaded bits", func() {
+			err := dropletRunner.LaunchBits(droplet_runner.LaunchBitsParams{
+				Name:                 "app-name",
+				StartCommand:         "./server",
+				AppArgs:              []string{"-port", "8080"},
+				EnvironmentVariables: map[string]string{"TIMEZONE": "UTC"},
+				Instances:            2,
+				CPUWeight:            50,
+				MemoryMB:             128,
+				DiskMB:               256,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAppRunner.SubmitLrpCallCount()).To(Equal(1))
+			lrp := receptor.DesiredLRPCreateRequest{}
+			Expect(json.Unmarshal(fakeAppRunner.SubmitLrpArgsForCall(0), &lrp)).To(Succeed())
+
+			Expect(lrp.ProcessGuid).To(Equal("app-name"))
+			Expect(lrp.RootFS).To(Equal("preloaded:cflinuxfs2"))
+			Expect(lrp.Instances).To(Equal(2))
+			Expect(route_helpers.AppRoutesFromRoutingInfo(lrp.Routes)).To(Equal(route_helpers.AppRoutes{
+				{Hostnames: []string{"app-name.example.com"}, Port: 8080},
+			}))
+			Expect(lrp.EnvironmentVariables).To(ConsistOf(
+				receptor.EnvironmentVariable{Name: "TIMEZONE", Value: "UTC"},
+				receptor.EnvironmentVariable{Name: "PORT", Value: "8080"},
+			))
+
+			setup := lrp.Setup.(*models.SerialAction).Actions
+			Expect(setup[1]).To(Equal(&models.DownloadAction{From: "http://blobstore.example.com/blobs/app-name/bits.tgz", To: "/home/vcap/app"}))
+			Expect(lrp.Action).To(Equal(&models.RunAction{
+				Path: "./server",
+				Args: []string{"-port", "8080"},
+				Dir:  "/home/vcap/app",
+			}))
+		})
+
+		It("runs the bits in a docker image", func() {
+			err := dropletRunner.LaunchBits(droplet_runner.LaunchBitsParams{
+				Name:         "app-name",
+				DockerImage:  "ubuntu",
+				StartCommand: "./server",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			lrp := receptor.DesiredLRPCreateRequest{}
+			Expect(json.Unmarshal(fakeAppRunner.SubmitLrpArgsForCall(0), &lrp)).To(Succeed())
+			Expect(lrp.RootFS).To(Equal("docker:///library/ubuntu#latest"))
+		})
+
+		It("returns errors for invalid docker images", func() {
+			err := dropletRunner.LaunchBits(droplet_runner.LaunchBitsParams{
+				Name:         "app-name",
+				DockerImage:  "Bad/Image",
+				StartCommand: "./server",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeAppRunner.SubmitLrpCallCount()).To(BeZero())
+		})
+
+		It("returns errors submitting the LRP", func() {
+			fakeAppRunner.SubmitLrpReturns("app-name", errors.New("app-name already exists"))
+
+			err := dropletRunner.LaunchBits(droplet_runner.LaunchBitsParams{Name: "app-name", StartCommand: "./server"})
+			Expect(err).To(MatchError("app-name already exists"))
+		})
+	})
+})
@@ -0,0 +1,175 @@
+// This file was generated by counterfeiter
+package fake_droplet_runner
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/droplet_runner"
+)
+
+type FakeDropletRunner struct {
+	UploadBitsStub        func(dropletName string, sourceDir string) error
+	uploadBitsMutex       sync.RWMutex
+	uploadBitsArgsForCall []struct {
+		dropletName string
+		sourceDir   string
+	}
+	uploadBitsReturns struct {
+		result1 error
+	}
+	BuildDropletStub        func(params droplet_runner.BuildDropletParams) error
+	buildDropletMutex       sync.RWMutex
+	buildDropletArgsForCall []struct {
+		params droplet_runner.BuildDropletParams
+	}
+	buildDropletReturns struct {
+		result1 error
+	}
+	LaunchDropletStub        func(params droplet_runner.LaunchDropletParams) error
+	launchDropletMutex       sync.RWMutex
+	launchDropletArgsForCall []struct {
+		params droplet_runner.LaunchDropletParams
+	}
+	launchDropletReturns struct {
+		result1 error
+	}
+	LaunchBitsStub        func(params droplet_runner.LaunchBitsParams) error
+	launchBitsMutex       sync.RWMutex
+	launchBitsArgsForCall []struct {
+		params droplet_runner.LaunchBitsParams
+	}
+	launchBitsReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeDropletRunner) UploadBits(dropletName string, sourceDir string) error {
+	fake.uploadBitsMutex.Lock()
+	fake.uploadBitsArgsForCall = append(fake.uploadBitsArgsForCall, struct {
+		dropletName string
+		sourceDir   string
+	}{dropletName, sourceDir})
+	fake.uploadBitsMutex.Unlock()
+	if fake.UploadBitsStub != nil {
+		return fake.UploadBitsStub(dropletName, sourceDir)
+	} else {
+		return fake.uploadBitsReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) UploadBitsCallCount() int {
+	fake.uploadBitsMutex.RLock()
+	defer fake.uploadBitsMutex.RUnlock()
+	return len(fake.uploadBitsArgsForCall)
+}
+
+func (fake *FakeDropletRunner) UploadBitsArgsForCall(i int) (string, string) {
+	fake.uploadBitsMutex.RLock()
+	defer fake.uploadBitsMutex.RUnlock()
+	return fake.uploadBitsArgsForCall[i].dropletName, fake.uploadBitsArgsForCall[i].sourceDir
+}
+
+func (fake *FakeDropletRunner) UploadBitsReturns(result1 error) {
+	fake.UploadBitsStub = nil
+	fake.uploadBitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) BuildDroplet(params droplet_runner.BuildDropletParams) error {
+	fake.buildDropletMutex.Lock()
+	fake.buildDropletArgsForCall = append(fake.buildDropletArgsForCall, struct {
+		params droplet_runner.BuildDropletParams
+	}{params})
+	fake.buildDropletMutex.Unlock()
+	if fake.BuildDropletStub != nil {
+		return fake.BuildDropletStub(params)
+	} else {
+		return fake.buildDropletReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) BuildDropletCallCount() int {
+	fake.buildDropletMutex.RLock()
+	defer fake.buildDropletMutex.RUnlock()
+	return len(fake.buildDropletArgsForCall)
+}
+
+func (fake *FakeDropletRunner) BuildDropletArgsForCall(i int) droplet_runner.BuildDropletParams {
+	fake.buildDropletMutex.RLock()
+	defer fake.buildDropletMutex.RUnlock()
+	return fake.buildDropletArgsForCall[i].params
+}
+
+func (fake *FakeDropletRunner) BuildDropletReturns(result1 error) {
+	fake.BuildDropletStub = nil
+	fake.buildDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) LaunchDroplet(params droplet_runner.LaunchDropletParams) error {
+	fake.launchDropletMutex.Lock()
+	fake.launchDropletArgsForCall = append(fake.launchDropletArgsForCall, struct {
+		params droplet_runner.LaunchDropletParams
+	}{params})
+	fake.launchDropletMutex.Unlock()
+	if fake.LaunchDropletStub != nil {
+		return fake.LaunchDropletStub(params)
+	} else {
+		return fake.launchDropletReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) LaunchDropletCallCount() int {
+	fake.launchDropletMutex.RLock()
+	defer fake.launchDropletMutex.RUnlock()
+	return len(fake.launchDropletArgsForCall)
+}
+
+func (fake *FakeDropletRunner) LaunchDropletArgsForCall(i int) droplet_runner.LaunchDropletParams {
+	fake.launchDropletMutex.RLock()
+	defer fake.launchDropletMutex.RUnlock()
+	return fake.launchDropletArgsForCall[i].params
+}
+
+func (fake *FakeDropletRunner) LaunchDropletReturns(result1 error) {
+	fake.LaunchDropletStub = nil
+	fake.launchDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) LaunchBits(params droplet_runner.LaunchBitsParams) error {
+	fake.launchBitsMutex.Lock()
+	fake.launchBitsArgsForCall = append(fake.launchBitsArgsForCall, struct {
+		params droplet_runner.LaunchBitsParams
+	}{params})
+	fake.launchBitsMutex.Unlock()
+	if fake.LaunchBitsStub != nil {
+		return fake.LaunchBitsStub(params)
+	} else {
+		return fake.launchBitsReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) LaunchBitsCallCount() int {
+	fake.launchBitsMutex.RLock()
+	defer fake.launchBitsMutex.RUnlock()
+	return len(fake.launchBitsArgsForCall)
+}
+
+func (fake *FakeDropletRunner) LaunchBitsArgsForCall(i int) droplet_runner.LaunchBitsParams {
+	fake.launchBitsMutex.RLock()
+	defer fake.launchBitsMutex.RUnlock()
+	return fake.launchBitsArgsForCall[i].params
+}
+
+func (fake *FakeDropletRunner) LaunchBitsReturns(result1 error) {
+	fake.LaunchBitsStub = nil
+	fake.launchBitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ droplet_runner.DropletRunner = new(FakeDropletRunner)
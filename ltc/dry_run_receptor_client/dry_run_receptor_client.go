@@ -0,0 +1,74 @@
+package dry_run_receptor_client
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/tedsuo/rata"
+)
+
+// dryRunClient passes reads through to the receptor, but prints the request
+// for anything that would change lattice instead of sending it.
+type dryRunClient struct {
+	receptor.Client
+	ui terminal.UI
+}
+
+func New(client receptor.Client, ui terminal.UI) receptor.Client {
+	return &dryRunClient{client, ui}
+}
+
+func (c *dryRunClient) CreateTask(request receptor.TaskCreateRequest) error {
+	return c.sayRequest(receptor.CreateTaskRoute, nil, request)
+}
+
+func (c *dryRunClient) DeleteTask(taskId string) error {
+	return c.sayRequest(receptor.DeleteTaskRoute, rata.Params{"task_guid": taskId}, nil)
+}
+
+func (c *dryRunClient) CancelTask(taskId string) error {
+	return c.sayRequest(receptor.CancelTaskRoute, rata.Params{"task_guid": taskId}, nil)
+}
+
+func (c *dryRunClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	return c.sayRequest(receptor.CreateDesiredLRPRoute, nil, request)
+}
+
+func (c *dryRunClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	return c.sayRequest(receptor.UpdateDesiredLRPRoute, rata.Params{"process_guid": processGuid}, update)
+}
+
+func (c *dryRunClient) DeleteDesiredLRP(processGuid string) error {
+	return c.sayRequest(receptor.DeleteDesiredLRPRoute, rata.Params{"process_guid": processGuid}, nil)
+}
+
+func (c *dryRunClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	return c.sayRequest(receptor.KillActualLRPByProcessGuidAndIndexRoute, rata.Params{"process_guid": processGuid, "index": strconv.Itoa(index)}, nil)
+}
+
+func (c *dryRunClient) UpsertDomain(domain string, ttl time.Duration) error {
+	return c.sayRequest(receptor.UpsertDomainRoute, rata.Params{"domain": domain}, nil)
+}
+
+// sayRequest prints the method, path and JSON body the receptor client
+// would send.
+func (c *dryRunClient) sayRequest(routeName string, params rata.Params, body interface{}) error {
+	route, _ := receptor.Routes.FindRouteByName(routeName)
+	path, err := route.CreatePath(params)
+	if err != nil {
+		return err
+	}
+	c.ui.SayLine(route.Method + " " + path)
+
+	if body != nil {
+		encoded, err := json.MarshalIndent(body, "", "  ")
+		if err != nil {
+			return err
+		}
+		c.ui.SayLine(string(encoded))
+	}
+	return nil
+}
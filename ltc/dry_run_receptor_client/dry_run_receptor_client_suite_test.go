@@ -0,0 +1,13 @@
+package dry_run_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDryRunReceptorClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DryRunReceptorClient Suite")
+}
@@ -0,0 +1,70 @@
+package dry_run_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/dry_run_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("DryRunReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		outputBuffer       *gbytes.Buffer
+		client             receptor.Client
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		outputBuffer = gbytes.NewBuffer()
+		client = dry_run_receptor_client.New(fakeReceptorClient, terminal.NewUI(nil, outputBuffer, nil))
+	})
+
+	It("prints requests that would change lattice instead of sending them", func() {
+		err := client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "app", Instances: 2})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(BeZero())
+		Expect(outputBuffer).To(test_helpers.SayLine("POST /v1/desired_lrps"))
+		Expect(outputBuffer).To(test_helpers.SayLine("{"))
+		Expect(outputBuffer).To(test_helpers.SayLine(`  "process_guid": "app",`))
+		Expect(outputBuffer).To(test_helpers.Say(`  "instances": 2,`))
+	})
+
+	It("prints the path of the resource being changed", func() {
+		instances := 3
+		client.UpdateDesiredLRP("app", receptor.DesiredLRPUpdateRequest{Instances: &instances})
+		client.DeleteDesiredLRP("app")
+		client.UpsertDomain("lattice", 0)
+		client.KillActualLRPByProcessGuidAndIndex("app", 1)
+		client.CancelTask("task")
+
+		Expect(outputBuffer).To(test_helpers.SayLine("PUT /v1/desired_lrps/app"))
+		Expect(outputBuffer).To(test_helpers.SayLine(`  "instances": 3`))
+		Expect(outputBuffer).To(test_helpers.SayLine("DELETE /v1/desired_lrps/app"))
+		Expect(outputBuffer).To(test_helpers.SayLine("PUT /v1/domains/lattice"))
+		Expect(outputBuffer).To(test_helpers.SayLine("DELETE /v1/actual_lrps/app/index/1"))
+		Expect(outputBuffer).To(test_helpers.SayLine("POST /v1/tasks/task/cancel"))
+
+		Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(BeZero())
+		Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+		Expect(fakeReceptorClient.UpsertDomainCallCount()).To(BeZero())
+		Expect(fakeReceptorClient.KillActualLRPByProcessGuidAndIndexCallCount()).To(BeZero())
+		Expect(fakeReceptorClient.CancelTaskCallCount()).To(BeZero())
+	})
+
+	It("passes reads through to the receptor", func() {
+		fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{{ProcessGuid: "app"}}, nil)
+
+		desiredLRPs, err := client.DesiredLRPs()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desiredLRPs).To(HaveLen(1))
+		Expect(outputBuffer.Contents()).To(BeEmpty())
+	})
+})
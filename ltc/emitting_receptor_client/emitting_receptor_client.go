@@ -0,0 +1,149 @@
+package emitting_receptor_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+	"github.com/tedsuo/rata"
+)
+
+// Payload is what's written for each request that changed lattice: enough
+// to send it again, e.g. to another cluster.  Header has the headers that
+// carry part of the request, such as the Cache-Control that sets a domain's
+// TTL.
+type Payload struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+}
+
+// Emitter writes payloads to a directory once one is set, each to its own
+// file named for when it was sent, so that they sort in the order they were
+// sent.
+type Emitter struct {
+	clock clock.Clock
+	lock  sync.Mutex
+	dir   string
+	count int
+}
+
+func NewEmitter(clock clock.Clock) *Emitter {
+	return &Emitter{clock: clock}
+}
+
+// SetDir starts writing payloads to dir, creating it if need be.
+func (e *Emitter) SetDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.dir = dir
+	return nil
+}
+
+func (e *Emitter) Emit(routeName string, params rata.Params, header map[string]string, body interface{}) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.dir == "" {
+		return nil
+	}
+
+	route, _ := receptor.Routes.FindRouteByName(routeName)
+	path, err := route.CreatePath(params)
+	if err != nil {
+		return err
+	}
+	payload := Payload{Method: route.Method, Path: path, Header: header}
+	if body != nil {
+		if payload.Body, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	payloadJSON, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	e.count++
+	fileName := fmt.Sprintf("%s-%04d-%s.json", e.clock.Now().UTC().Format("20060102T150405.000Z"), e.count, routeName)
+	return ioutil.WriteFile(filepath.Join(e.dir, fileName), append(payloadJSON, '\n'), 0600)
+}
+
+// emittingClient emits the payload of each request that changed lattice
+// once the receptor has accepted it, so that the payloads can be replayed
+// as they are.  A payload that can't be written is warned about rather than
+// failing the request, which has already changed lattice, so that running
+// the command again doesn't repeat the change.
+type emittingClient struct {
+	receptor.Client
+	emitter *Emitter
+	ui      terminal.UI
+}
+
+func New(client receptor.Client, emitter *Emitter, ui terminal.UI) receptor.Client {
+	return &emittingClient{client, emitter, ui}
+}
+
+func (c *emittingClient) CreateTask(request receptor.TaskCreateRequest) error {
+	return c.emit(c.Client.CreateTask(request), receptor.CreateTaskRoute, nil, request)
+}
+
+func (c *emittingClient) DeleteTask(taskId string) error {
+	return c.emit(c.Client.DeleteTask(taskId), receptor.DeleteTaskRoute, rata.Params{"task_guid": taskId}, nil)
+}
+
+func (c *emittingClient) CancelTask(taskId string) error {
+	return c.emit(c.Client.CancelTask(taskId), receptor.CancelTaskRoute, rata.Params{"task_guid": taskId}, nil)
+}
+
+func (c *emittingClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	return c.emit(c.Client.CreateDesiredLRP(request), receptor.CreateDesiredLRPRoute, nil, request)
+}
+
+func (c *emittingClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	return c.emit(c.Client.UpdateDesiredLRP(processGuid, update), receptor.UpdateDesiredLRPRoute, rata.Params{"process_guid": processGuid}, update)
+}
+
+func (c *emittingClient) DeleteDesiredLRP(processGuid string) error {
+	return c.emit(c.Client.DeleteDesiredLRP(processGuid), receptor.DeleteDesiredLRPRoute, rata.Params{"process_guid": processGuid}, nil)
+}
+
+func (c *emittingClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	return c.emit(c.Client.KillActualLRPByProcessGuidAndIndex(processGuid, index), receptor.KillActualLRPByProcessGuidAndIndexRoute, rata.Params{"process_guid": processGuid, "index": strconv.Itoa(index)}, nil)
+}
+
+// UpsertDomain's payload sets the domain's TTL as the receptor client does,
+// with a Cache-Control header, unless it has none.
+func (c *emittingClient) UpsertDomain(domain string, ttl time.Duration) error {
+	var header map[string]string
+	if ttl != 0 {
+		header = map[string]string{"Cache-Control": fmt.Sprintf("max-age=%d", int(ttl.Seconds()))}
+	}
+	return c.emitWithHeader(c.Client.UpsertDomain(domain, ttl), receptor.UpsertDomainRoute, rata.Params{"domain": domain}, header, nil)
+}
+
+func (c *emittingClient) emit(requestErr error, routeName string, params rata.Params, body interface{}) error {
+	return c.emitWithHeader(requestErr, routeName, params, nil, body)
+}
+
+func (c *emittingClient) emitWithHeader(requestErr error, routeName string, params rata.Params, header map[string]string, body interface{}) error {
+	if requestErr != nil {
+		return requestErr
+	}
+	if err := c.emitter.Emit(routeName, params, header, body); err != nil {
+		c.ui.SayLine(fmt.Sprintf("Warning: the %s request succeeded, but its payload couldn't be written: %s", routeName, err))
+	}
+	return nil
+}
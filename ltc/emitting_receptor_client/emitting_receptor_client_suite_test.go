@@ -0,0 +1,13 @@
+package emitting_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestEmittingReceptorClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EmittingReceptorClient Suite")
+}
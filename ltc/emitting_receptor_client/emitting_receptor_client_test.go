@@ -0,0 +1,166 @@
+package emitting_receptor_client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/emitting_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("EmittingReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeClock          *fakeclock.FakeClock
+		emitter            *emitting_receptor_client.Emitter
+		client             receptor.Client
+		outputBuffer       *gbytes.Buffer
+		tmpDir             string
+		payloadDir         string
+	)
+
+	payloadFiles := func() []string {
+		fileInfos, err := ioutil.ReadDir(payloadDir)
+		Expect(err).NotTo(HaveOccurred())
+		fileNames := []string{}
+		for _, fileInfo := range fileInfos {
+			fileNames = append(fileNames, fileInfo.Name())
+		}
+		return fileNames
+	}
+
+	readPayload := func(fileName string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(payloadDir, fileName))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "payloads")
+		Expect(err).NotTo(HaveOccurred())
+		payloadDir = filepath.Join(tmpDir, "bundle")
+
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		emitter = emitting_receptor_client.NewEmitter(fakeClock)
+		outputBuffer = gbytes.NewBuffer()
+		client = emitting_receptor_client.New(fakeReceptorClient, emitter, terminal.NewUI(nil, outputBuffer, nil))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Context("when a payload directory is set", func() {
+		BeforeEach(func() {
+			Expect(emitter.SetDir(payloadDir)).To(Succeed())
+		})
+
+		It("writes the payload of each request that changed lattice, in order", func() {
+			instances := 3
+			Expect(client.UpsertDomain("lattice", 0)).To(Succeed())
+			Expect(client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "app", Instances: 2})).To(Succeed())
+			fakeClock.Increment(1500 * time.Millisecond)
+			Expect(client.UpdateDesiredLRP("app", receptor.DesiredLRPUpdateRequest{Instances: &instances})).To(Succeed())
+			Expect(client.KillActualLRPByProcessGuidAndIndex("app", 1)).To(Succeed())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+			Expect(payloadFiles()).To(Equal([]string{
+				"20150704T120000.000Z-0001-UpsertDomain.json",
+				"20150704T120000.000Z-0002-CreateDesiredLRP.json",
+				"20150704T120001.500Z-0003-UpdateDesiredLRP.json",
+				"20150704T120001.500Z-0004-KillActualLRPByProcessGuidAndIndex.json",
+			}))
+
+			Expect(readPayload("20150704T120000.000Z-0001-UpsertDomain.json")).To(MatchJSON(`{"method":"PUT","path":"/v1/domains/lattice"}`))
+
+			var payload emitting_receptor_client.Payload
+			Expect(json.Unmarshal([]byte(readPayload("20150704T120000.000Z-0002-CreateDesiredLRP.json")), &payload)).To(Succeed())
+			Expect(payload.Method).To(Equal("POST"))
+			Expect(payload.Path).To(Equal("/v1/desired_lrps"))
+			var desiredLRP receptor.DesiredLRPCreateRequest
+			Expect(json.Unmarshal(payload.Body, &desiredLRP)).To(Succeed())
+			Expect(desiredLRP).To(Equal(receptor.DesiredLRPCreateRequest{ProcessGuid: "app", Instances: 2}))
+
+			Expect(readPayload("20150704T120001.500Z-0003-UpdateDesiredLRP.json")).To(MatchJSON(`{"method":"PUT","path":"/v1/desired_lrps/app","body":{"instances":3}}`))
+			Expect(readPayload("20150704T120001.500Z-0004-KillActualLRPByProcessGuidAndIndex.json")).To(MatchJSON(`{"method":"DELETE","path":"/v1/actual_lrps/app/index/1"}`))
+		})
+
+		It("writes the TTL of a domain in the payload's header", func() {
+			Expect(client.UpsertDomain("lattice", 2*time.Minute)).To(Succeed())
+
+			Expect(fakeReceptorClient.UpsertDomainCallCount()).To(Equal(1))
+			Expect(readPayload("20150704T120000.000Z-0001-UpsertDomain.json")).To(MatchJSON(`{"method":"PUT","path":"/v1/domains/lattice","header":{"Cache-Control":"max-age=120"}}`))
+		})
+
+		It("writes the payloads of task requests", func() {
+			Expect(client.CreateTask(receptor.TaskCreateRequest{TaskGuid: "task"})).To(Succeed())
+			Expect(client.CancelTask("task")).To(Succeed())
+			Expect(client.DeleteTask("task")).To(Succeed())
+			Expect(client.DeleteDesiredLRP("app")).To(Succeed())
+
+			Expect(payloadFiles()).To(Equal([]string{
+				"20150704T120000.000Z-0001-CreateTask.json",
+				"20150704T120000.000Z-0002-CancelTask.json",
+				"20150704T120000.000Z-0003-DeleteTask.json",
+				"20150704T120000.000Z-0004-DeleteDesiredLRP.json",
+			}))
+			Expect(readPayload("20150704T120000.000Z-0002-CancelTask.json")).To(MatchJSON(`{"method":"POST","path":"/v1/tasks/task/cancel"}`))
+		})
+
+		It("doesn't write the payloads of failed requests", func() {
+			fakeReceptorClient.CreateDesiredLRPReturns(errors.New("invalid LRP"))
+
+			err := client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "app"})
+
+			Expect(err).To(MatchError("invalid LRP"))
+			Expect(payloadFiles()).To(BeEmpty())
+		})
+
+		It("doesn't write the payloads of reads", func() {
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{{ProcessGuid: "app"}}, nil)
+
+			desiredLRPs, err := client.DesiredLRPs()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desiredLRPs).To(HaveLen(1))
+			Expect(payloadFiles()).To(BeEmpty())
+		})
+
+		It("warns, without failing the request, when the payload can't be written", func() {
+			Expect(os.RemoveAll(payloadDir)).To(Succeed())
+
+			Expect(client.DeleteDesiredLRP("app")).To(Succeed())
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+			Expect(outputBuffer).To(test_helpers.Say("Warning: the DeleteDesiredLRP request succeeded, but its payload couldn't be written: "))
+		})
+	})
+
+	It("writes nothing until a payload directory is set", func() {
+		Expect(client.DeleteDesiredLRP("app")).To(Succeed())
+
+		Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+		_, err := os.Stat(payloadDir)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("returns an error when the payload directory can't be created", func() {
+		Expect(ioutil.WriteFile(payloadDir, []byte{}, 0600)).To(Succeed())
+
+		Expect(emitter.SetDir(payloadDir)).NotTo(Succeed())
+	})
+})
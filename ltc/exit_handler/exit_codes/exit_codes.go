@@ -1,11 +1,74 @@
 package exit_codes
 
+import (
+	"net"
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+// Exit codes returned by ltc.  These are part of ltc's scripting interface,
+// so existing values must never change.
 const (
-	BadTarget       = 10
-	PlacementError  = 11
-	FileSystemError = 12
-	InvalidSyntax   = 13
-	CommandFailed   = 14
-	BadDocker       = 15
+	BadTarget       = 10 // the target is unset, unreachable or rejected our credentials
+	PlacementError  = 11 // lattice could not place all instances
+	FileSystemError = 12 // a local file could not be read or written
+	InvalidSyntax   = 13 // usage error: missing or malformed arguments or flags
+	CommandFailed   = 14 // any other failure
+	BadDocker       = 15 // the docker image or its metadata could not be used
+	Timeout         = 16 // gave up waiting for lattice to converge
+	NotFound        = 17 // the named app, task or secret does not exist
+	NetworkError    = 18 // the receptor could not be reached
+	AlreadyExists   = 19 // an app or task with that name already exists
+	AppDegraded     = 20 // ltc status --health: some of the app's instances aren't running
+	AppUnhealthy    = 21 // ltc status --health: none of the app's instances are running
 	SigInt          = 130
 )
+
+// ForError picks the exit code for an error returned while talking to
+// lattice, falling back to defaultCode when the error is not recognized.
+func ForError(err error, defaultCode int) int {
+	switch err := err.(type) {
+	case interface {
+		NotFound() bool
+	}:
+		if err.NotFound() {
+			return NotFound
+		}
+	case interface {
+		AlreadyExists() bool
+	}:
+		if err.AlreadyExists() {
+			return AlreadyExists
+		}
+	case interface {
+		PlacementError() bool
+	}:
+		if err.PlacementError() {
+			return PlacementError
+		}
+	case interface {
+		InvalidManifest() bool
+	}:
+		if err.InvalidManifest() {
+			return InvalidSyntax
+		}
+	case interface {
+		InvalidName() bool
+	}:
+		if err.InvalidName() {
+			return InvalidSyntax
+		}
+	case receptor.Error:
+		switch err.Type {
+		case receptor.DesiredLRPNotFound, receptor.TaskNotFound, receptor.ActualLRPIndexNotFound, receptor.ResourceNotFound:
+			return NotFound
+		case receptor.DesiredLRPAlreadyExists, receptor.TaskGuidAlreadyExists:
+			return AlreadyExists
+		}
+	case *url.Error, net.Error:
+		return NetworkError
+	}
+
+	return defaultCode
+}
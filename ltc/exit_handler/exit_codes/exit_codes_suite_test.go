@@ -0,0 +1,13 @@
+package exit_codes_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestExitCodes(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ExitCodes Suite")
+}
@@ -0,0 +1,72 @@
+package exit_codes_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_app_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+type notFoundError bool
+
+func (err notFoundError) Error() string  { return "not found" }
+func (err notFoundError) NotFound() bool { return bool(err) }
+
+var _ = Describe("ExitCodes", func() {
+	Describe("ForError", func() {
+		It("returns NotFound for receptor not found errors", func() {
+			for _, errorType := range []string{receptor.DesiredLRPNotFound, receptor.TaskNotFound, receptor.ActualLRPIndexNotFound, receptor.ResourceNotFound} {
+				err := receptor.Error{Type: errorType, Message: "gone"}
+				Expect(exit_codes.ForError(err, exit_codes.CommandFailed)).To(Equal(exit_codes.NotFound))
+			}
+		})
+
+		It("returns NotFound for errors that report themselves as not found", func() {
+			Expect(exit_codes.ForError(notFoundError(true), exit_codes.CommandFailed)).To(Equal(exit_codes.NotFound))
+			Expect(exit_codes.ForError(notFoundError(false), exit_codes.CommandFailed)).To(Equal(exit_codes.CommandFailed))
+		})
+
+		It("returns AlreadyExists for receptor conflicts and errors that report themselves as existing", func() {
+			for _, errorType := range []string{receptor.DesiredLRPAlreadyExists, receptor.TaskGuidAlreadyExists} {
+				err := receptor.Error{Type: errorType, Message: "taken"}
+				Expect(exit_codes.ForError(err, exit_codes.CommandFailed)).To(Equal(exit_codes.AlreadyExists))
+			}
+			Expect(exit_codes.ForError(docker_app_runner.AppAlreadyExistsError{AppName: "app"}, exit_codes.CommandFailed)).To(Equal(exit_codes.AlreadyExists))
+			Expect(exit_codes.ForError(task_runner.TaskAlreadyExistsError{TaskGuid: "task"}, exit_codes.CommandFailed)).To(Equal(exit_codes.AlreadyExists))
+		})
+
+		It("returns PlacementError for insufficient resources", func() {
+			Expect(exit_codes.ForError(docker_app_runner.InsufficientResourcesError{AppName: "app"}, exit_codes.CommandFailed)).To(Equal(exit_codes.PlacementError))
+		})
+
+		It("returns InvalidSyntax for invalid manifests", func() {
+			Expect(exit_codes.ForError(docker_app_runner.InvalidManifestError{Err: errors.New("bad")}, exit_codes.CommandFailed)).To(Equal(exit_codes.InvalidSyntax))
+			Expect(exit_codes.ForError(task_runner.InvalidManifestError{Err: errors.New("bad")}, exit_codes.CommandFailed)).To(Equal(exit_codes.InvalidSyntax))
+		})
+
+		It("returns InvalidSyntax for invalid app names and routes", func() {
+			Expect(exit_codes.ForError(docker_app_runner.InvalidAppNameError{AppName: "my_app"}, exit_codes.CommandFailed)).To(Equal(exit_codes.InvalidSyntax))
+			Expect(exit_codes.ForError(docker_app_runner.InvalidRouteError{Err: errors.New("bad")}, exit_codes.CommandFailed)).To(Equal(exit_codes.InvalidSyntax))
+		})
+
+		It("returns NetworkError when the receptor cannot be reached", func() {
+			urlErr := &url.Error{Op: "Get", URL: "http://receptor.example.com/v1/desired_lrps", Err: errors.New("connection refused")}
+			Expect(exit_codes.ForError(urlErr, exit_codes.CommandFailed)).To(Equal(exit_codes.NetworkError))
+
+			opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			Expect(exit_codes.ForError(opErr, exit_codes.CommandFailed)).To(Equal(exit_codes.NetworkError))
+		})
+
+		It("returns the default code for any other error", func() {
+			Expect(exit_codes.ForError(errors.New("boom"), exit_codes.CommandFailed)).To(Equal(exit_codes.CommandFailed))
+			Expect(exit_codes.ForError(receptor.Error{Type: receptor.InvalidJSON}, exit_codes.FileSystemError)).To(Equal(exit_codes.FileSystemError))
+		})
+	})
+})
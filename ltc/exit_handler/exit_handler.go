@@ -22,6 +22,17 @@ type ExitHandler interface {
 	Exit(code int)
 }
 
+// Done returns a channel that is closed when exitHandler exits, e.g. on
+// Ctrl-C, so that requests and polling in flight can be abandoned.
+func Done(exitHandler ExitHandler) <-chan struct{} {
+	done := make(chan struct{})
+	var closeDone sync.Once
+	exitHandler.OnExit(func() {
+		closeDone.Do(func() { close(done) })
+	})
+	return done
+}
+
 type exitHandler struct {
 	onExitFuncs []func()
 	signalChan  chan os.Signal
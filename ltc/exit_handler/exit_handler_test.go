@@ -72,4 +72,19 @@ var _ = Describe("ExitHandler", func() {
 			Eventually(buffer).Should(gbytes.Say("Exit-Code=222"))
 		})
 	})
+
+	Describe("Done", func() {
+		It("is closed when the handler exits", func() {
+			exitHandler := exit_handler.New(make(chan os.Signal), func(code int) {})
+
+			done := exit_handler.Done(exitHandler)
+			Expect(done).NotTo(BeClosed())
+
+			exitHandler.Exit(130)
+			Expect(done).To(BeClosed())
+
+			exitHandler.Exit(130)
+			Expect(done).To(BeClosed())
+		})
+	})
 })
@@ -4,14 +4,14 @@ import "sync"
 
 type FakeExitHandler struct {
 	sync.RWMutex
-	exitFunc       func()
+	exitFuncs      []func()
 	ExitCalledWith []int
 }
 
 func (f *FakeExitHandler) OnExit(exitHandler func()) {
 	f.Lock()
 	defer f.Unlock()
-	f.exitFunc = exitHandler
+	f.exitFuncs = append(f.exitFuncs, exitHandler)
 }
 
 func (f *FakeExitHandler) Run() {
@@ -22,7 +22,7 @@ func (f *FakeExitHandler) Exit(code int) {
 	f.Lock()
 	defer f.Unlock()
 	f.ExitCalledWith = append(f.ExitCalledWith, code)
-	if f.exitFunc != nil {
-		f.exitFunc()
+	for _, exitFunc := range f.exitFuncs {
+		exitFunc()
 	}
 }
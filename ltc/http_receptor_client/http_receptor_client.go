@@ -0,0 +1,231 @@
+package http_receptor_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/tedsuo/rata"
+	"github.com/vito/go-sse/sse"
+)
+
+// httpClient talks to the receptor as receptor.NewClient's client does, but
+// with HTTP clients of ltc's choosing, e.g. with its TLS settings or going
+// through its connection pool.  receptor.NewClient always makes its own.
+type httpClient struct {
+	httpClient          *http.Client
+	streamingHTTPClient *http.Client
+	reqGen              *rata.RequestGenerator
+}
+
+// New returns a client for the receptor at url that makes its requests with
+// client, and subscribes to events with streamingClient, which shouldn't
+// time out.
+func New(url string, client, streamingClient *http.Client) receptor.Client {
+	return &httpClient{
+		httpClient:          client,
+		streamingHTTPClient: streamingClient,
+		reqGen:              rata.NewRequestGenerator(url, receptor.Routes),
+	}
+}
+
+func (c *httpClient) CreateTask(request receptor.TaskCreateRequest) error {
+	return c.doRequest(receptor.CreateTaskRoute, nil, nil, request, nil)
+}
+
+func (c *httpClient) Tasks() ([]receptor.TaskResponse, error) {
+	tasks := []receptor.TaskResponse{}
+	err := c.doRequest(receptor.TasksRoute, nil, nil, nil, &tasks)
+	return tasks, err
+}
+
+func (c *httpClient) TasksByDomain(domain string) ([]receptor.TaskResponse, error) {
+	tasks := []receptor.TaskResponse{}
+	err := c.doRequest(receptor.TasksRoute, nil, url.Values{"domain": []string{domain}}, nil, &tasks)
+	return tasks, err
+}
+
+func (c *httpClient) GetTask(taskId string) (receptor.TaskResponse, error) {
+	task := receptor.TaskResponse{}
+	err := c.doRequest(receptor.GetTaskRoute, rata.Params{"task_guid": taskId}, nil, nil, &task)
+	return task, err
+}
+
+func (c *httpClient) DeleteTask(taskId string) error {
+	return c.doRequest(receptor.DeleteTaskRoute, rata.Params{"task_guid": taskId}, nil, nil, nil)
+}
+
+func (c *httpClient) CancelTask(taskId string) error {
+	return c.doRequest(receptor.CancelTaskRoute, rata.Params{"task_guid": taskId}, nil, nil, nil)
+}
+
+func (c *httpClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	return c.doRequest(receptor.CreateDesiredLRPRoute, nil, nil, request, nil)
+}
+
+func (c *httpClient) GetDesiredLRP(processGuid string) (receptor.DesiredLRPResponse, error) {
+	var desiredLRP receptor.DesiredLRPResponse
+	err := c.doRequest(receptor.GetDesiredLRPRoute, rata.Params{"process_guid": processGuid}, nil, nil, &desiredLRP)
+	return desiredLRP, err
+}
+
+func (c *httpClient) UpdateDesiredLRP(processGuid string, request receptor.DesiredLRPUpdateRequest) error {
+	return c.doRequest(receptor.UpdateDesiredLRPRoute, rata.Params{"process_guid": processGuid}, nil, request, nil)
+}
+
+func (c *httpClient) DeleteDesiredLRP(processGuid string) error {
+	return c.doRequest(receptor.DeleteDesiredLRPRoute, rata.Params{"process_guid": processGuid}, nil, nil, nil)
+}
+
+func (c *httpClient) DesiredLRPs() ([]receptor.DesiredLRPResponse, error) {
+	var desiredLRPs []receptor.DesiredLRPResponse
+	err := c.doRequest(receptor.DesiredLRPsRoute, nil, nil, nil, &desiredLRPs)
+	return desiredLRPs, err
+}
+
+func (c *httpClient) DesiredLRPsByDomain(domain string) ([]receptor.DesiredLRPResponse, error) {
+	var desiredLRPs []receptor.DesiredLRPResponse
+	err := c.doRequest(receptor.DesiredLRPsRoute, nil, url.Values{"domain": []string{domain}}, nil, &desiredLRPs)
+	return desiredLRPs, err
+}
+
+func (c *httpClient) ActualLRPs() ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	err := c.doRequest(receptor.ActualLRPsRoute, nil, nil, nil, &actualLRPs)
+	return actualLRPs, err
+}
+
+func (c *httpClient) ActualLRPsByDomain(domain string) ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	err := c.doRequest(receptor.ActualLRPsRoute, nil, url.Values{"domain": []string{domain}}, nil, &actualLRPs)
+	return actualLRPs, err
+}
+
+func (c *httpClient) ActualLRPsByProcessGuid(processGuid string) ([]receptor.ActualLRPResponse, error) {
+	var actualLRPs []receptor.ActualLRPResponse
+	err := c.doRequest(receptor.ActualLRPsByProcessGuidRoute, rata.Params{"process_guid": processGuid}, nil, nil, &actualLRPs)
+	return actualLRPs, err
+}
+
+func (c *httpClient) ActualLRPByProcessGuidAndIndex(processGuid string, index int) (receptor.ActualLRPResponse, error) {
+	var actualLRP receptor.ActualLRPResponse
+	err := c.doRequest(receptor.ActualLRPByProcessGuidAndIndexRoute, rata.Params{"process_guid": processGuid, "index": strconv.Itoa(index)}, nil, nil, &actualLRP)
+	return actualLRP, err
+}
+
+func (c *httpClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	return c.doRequest(receptor.KillActualLRPByProcessGuidAndIndexRoute, rata.Params{"process_guid": processGuid, "index": strconv.Itoa(index)}, nil, nil, nil)
+}
+
+func (c *httpClient) SubscribeToEvents() (receptor.EventSource, error) {
+	eventSource, err := sse.Connect(c.streamingHTTPClient, time.Second, func() *http.Request {
+		request, err := c.reqGen.CreateRequest(receptor.EventStream, nil, nil)
+		if err != nil {
+			panic(err) // the event stream route takes no params
+		}
+		return request
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receptor.NewEventSource(eventSource), nil
+}
+
+func (c *httpClient) Cells() ([]receptor.CellResponse, error) {
+	var cells []receptor.CellResponse
+	err := c.doRequest(receptor.CellsRoute, nil, nil, nil, &cells)
+	return cells, err
+}
+
+func (c *httpClient) UpsertDomain(domain string, ttl time.Duration) error {
+	request, err := c.createRequest(receptor.UpsertDomainRoute, rata.Params{"domain": domain}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if ttl != 0 {
+		request.Header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(ttl.Seconds())))
+	}
+
+	return c.do(request, nil)
+}
+
+func (c *httpClient) Domains() ([]string, error) {
+	var domains []string
+	err := c.doRequest(receptor.DomainsRoute, nil, nil, nil, &domains)
+	return domains, err
+}
+
+func (c *httpClient) createRequest(requestName string, params rata.Params, queryParams url.Values, body interface{}) (*http.Request, error) {
+	requestJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := c.reqGen.CreateRequest(requestName, params, bytes.NewReader(requestJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	request.URL.RawQuery = queryParams.Encode()
+	request.ContentLength = int64(len(requestJSON))
+	request.Header.Set("Content-Type", "application/json")
+	return request, nil
+}
+
+func (c *httpClient) doRequest(requestName string, params rata.Params, queryParams url.Values, body, responseObject interface{}) error {
+	request, err := c.createRequest(requestName, params, queryParams, body)
+	if err != nil {
+		return err
+	}
+	return c.do(request, responseObject)
+}
+
+// do decodes the receptor's JSON responses, and its errors, which it
+// answers with as JSON too.  The router's errors are reported by header.
+func (c *httpClient) do(request *http.Request, responseObject interface{}) error {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if routerError, ok := response.Header[receptor.XCfRouterErrorHeader]; ok {
+		return receptor.Error{Type: receptor.RouterError, Message: routerError[0]}
+	}
+
+	var contentType string
+	if header, ok := response.Header[receptor.ContentTypeHeader]; ok {
+		contentType, _, _ = mime.ParseMediaType(header[0])
+	}
+
+	if contentType != receptor.JSONContentType {
+		if response.StatusCode > 299 {
+			return receptor.Error{
+				Type:    receptor.InvalidResponse,
+				Message: fmt.Sprintf("Invalid Response with status code: %d", response.StatusCode),
+			}
+		}
+		return nil
+	}
+
+	if response.StatusCode > 299 {
+		errResponse := receptor.Error{}
+		if err := json.NewDecoder(response.Body).Decode(&errResponse); err != nil {
+			return receptor.Error{Type: receptor.InvalidJSON, Message: err.Error()}
+		}
+		return errResponse
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(responseObject); err != nil {
+		return receptor.Error{Type: receptor.InvalidJSON, Message: err.Error()}
+	}
+	return nil
+}
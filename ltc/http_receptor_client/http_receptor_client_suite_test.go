@@ -0,0 +1,13 @@
+package http_receptor_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHttpReceptorClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HttpReceptorClient Suite")
+}
@@ -0,0 +1,138 @@
+package http_receptor_client_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_receptor_client"
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func (t *countingTransport) Requests() int {
+	return int(atomic.LoadInt32(&t.requests))
+}
+
+var _ = Describe("HttpReceptorClient", func() {
+	var (
+		server             *ghttp.Server
+		transport          *countingTransport
+		streamingTransport *countingTransport
+		client             receptor.Client
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		transport = &countingTransport{}
+		streamingTransport = &countingTransport{}
+		client = http_receptor_client.New(server.URL(), &http.Client{Transport: transport}, &http.Client{Transport: streamingTransport})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("makes requests with the given client", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v1/desired_lrps/app-1"),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, receptor.DesiredLRPResponse{ProcessGuid: "app-1", Instances: 3}),
+		))
+
+		desiredLRP, err := client.GetDesiredLRP("app-1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(desiredLRP.ProcessGuid).To(Equal("app-1"))
+		Expect(desiredLRP.Instances).To(Equal(3))
+		Expect(transport.Requests()).To(Equal(1))
+		Expect(streamingTransport.Requests()).To(BeZero())
+	})
+
+	It("sends request bodies as JSON", func() {
+		instances := 5
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("PUT", "/v1/desired_lrps/app-1"),
+			ghttp.VerifyContentType("application/json"),
+			ghttp.VerifyJSONRepresenting(receptor.DesiredLRPUpdateRequest{Instances: &instances}),
+			ghttp.RespondWith(http.StatusNoContent, nil),
+		))
+
+		Expect(client.UpdateDesiredLRP("app-1", receptor.DesiredLRPUpdateRequest{Instances: &instances})).To(Succeed())
+	})
+
+	It("passes domains as a query parameter", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v1/actual_lrps", "domain=lattice"),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, []receptor.ActualLRPResponse{{ProcessGuid: "app-1"}}),
+		))
+
+		actualLRPs, err := client.ActualLRPsByDomain("lattice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actualLRPs).To(HaveLen(1))
+	})
+
+	It("sets the domain's ttl as its max age", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("PUT", "/v1/domains/lattice"),
+			ghttp.VerifyHeaderKV("Cache-Control", "max-age=60"),
+			ghttp.RespondWith(http.StatusNoContent, nil),
+		))
+
+		Expect(client.UpsertDomain("lattice", time.Minute)).To(Succeed())
+	})
+
+	It("returns the receptor's errors", func() {
+		server.AppendHandlers(ghttp.RespondWithJSONEncoded(http.StatusNotFound, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"}))
+
+		_, err := client.GetDesiredLRP("app-1")
+		Expect(err).To(Equal(receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"}))
+	})
+
+	It("returns the router's errors", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, "", http.Header{receptor.XCfRouterErrorHeader: []string{"unknown_route"}}))
+
+		_, err := client.Cells()
+		Expect(err).To(Equal(receptor.Error{Type: receptor.RouterError, Message: "unknown_route"}))
+	})
+
+	It("returns an error for other responses that fail", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, "oops"))
+
+		err := client.DeleteDesiredLRP("app-1")
+		Expect(err).To(Equal(receptor.Error{Type: receptor.InvalidResponse, Message: "Invalid Response with status code: 502"}))
+	})
+
+	It("returns an error for JSON it can't decode", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "{", http.Header{"Content-Type": []string{"application/json"}}))
+
+		_, err := client.Tasks()
+		Expect(err).To(BeAssignableToTypeOf(receptor.Error{}))
+		Expect(err.(receptor.Error).Type).To(Equal(receptor.InvalidJSON))
+	})
+
+	It("subscribes to events with the streaming client", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v1/events"),
+			ghttp.RespondWith(http.StatusOK, "", http.Header{"Content-Type": []string{"text/event-stream"}}),
+		))
+
+		eventSource, err := client.SubscribeToEvents()
+		Expect(err).NotTo(HaveOccurred())
+		defer eventSource.Close()
+
+		Expect(streamingTransport.Requests()).To(Equal(1))
+		Expect(transport.Requests()).To(BeZero())
+	})
+})
@@ -0,0 +1,182 @@
+package http_tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyPattern matches the headers and JSON keys whose values are
+// never traced.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)authorization|password|secret|token|credential|private_key|cookie`)
+
+type tracer struct {
+	transport http.RoundTripper
+	out       io.Writer
+	clock     clock.Clock
+	lock      sync.Mutex
+}
+
+// New returns a RoundTripper that makes requests with transport, writing
+// each request and its response to out: the method, URL, status, latency,
+// headers and body.  Credentials, secret-looking JSON fields and the values
+// of environment variables, which may hold secrets set with --secret-env,
+// are redacted.  Event streams are traced without their bodies, so that
+// tracing doesn't hold them up.
+func New(transport http.RoundTripper, out io.Writer, clock clock.Clock) http.RoundTripper {
+	return &tracer{transport: transport, out: out, clock: clock}
+}
+
+func (t *tracer) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	startTime := t.clock.Now()
+	response, err := t.transport.RoundTrip(request)
+	latency := t.clock.Now().Sub(startTime)
+
+	trace := &bytes.Buffer{}
+	fmt.Fprintf(trace, "REQUEST [%s] %s %s\n", startTime.UTC().Format(time.RFC3339), request.Method, redactURL(request))
+	writeHeaders(trace, request.Header)
+	writeBody(trace, requestBody)
+
+	if err != nil {
+		fmt.Fprintf(trace, "RESPONSE [%s] error: %s (%s)\n\n", t.clock.Now().UTC().Format(time.RFC3339), err, latency)
+		t.write(trace.Bytes())
+		return response, err
+	}
+
+	fmt.Fprintf(trace, "RESPONSE [%s] %s (%s)\n", t.clock.Now().UTC().Format(time.RFC3339), response.Status, latency)
+	writeHeaders(trace, response.Header)
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		fmt.Fprint(trace, "[EVENT STREAM]\n\n")
+	} else if response.Body != nil {
+		responseBody, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+		if err != nil {
+			t.write(trace.Bytes())
+			return nil, err
+		}
+		writeBody(trace, responseBody)
+	}
+
+	t.write(trace.Bytes())
+	return response, nil
+}
+
+// write writes a whole trace at once, so that those of concurrent requests
+// aren't interleaved.
+func (t *tracer) write(trace []byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.out.Write(trace)
+}
+
+func redactURL(request *http.Request) string {
+	redacted := *request.URL
+	if redacted.User != nil {
+		if _, hasPassword := redacted.User.Password(); hasPassword {
+			redacted.User = url.UserPassword(redacted.User.Username(), "REDACTED")
+		}
+	}
+	return redacted.String()
+}
+
+func writeHeaders(trace io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if sensitiveKeyPattern.MatchString(name) {
+			value = Redacted
+		}
+		fmt.Fprintf(trace, "%s: %s\n", name, value)
+	}
+	fmt.Fprintln(trace)
+}
+
+func writeBody(trace io.Writer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	fmt.Fprintf(trace, "%s\n\n", RedactJSON(body))
+}
+
+// RedactJSON returns a JSON body with the values of its sensitive fields
+// and of its environment variables redacted.  Other bodies are returned as
+// they are.
+func RedactJSON(body []byte) []byte {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			switch {
+			case sensitiveKeyPattern.MatchString(key):
+				value[key] = Redacted
+			case key == "env":
+				value[key] = redactEnv(field)
+			default:
+				value[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, element := range value {
+			value[i] = redactValue(element)
+		}
+	}
+	return value
+}
+
+func redactEnv(env interface{}) interface{} {
+	envVars, ok := env.([]interface{})
+	if !ok {
+		return redactValue(env)
+	}
+
+	for _, envVar := range envVars {
+		if envVar, ok := envVar.(map[string]interface{}); ok {
+			if _, hasValue := envVar["value"]; hasValue {
+				envVar["value"] = Redacted
+			}
+		}
+	}
+	return envVars
+}
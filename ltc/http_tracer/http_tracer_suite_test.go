@@ -0,0 +1,13 @@
+package http_tracer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHttpTracer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HttpTracer Suite")
+}
@@ -0,0 +1,111 @@
+package http_tracer_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_tracer"
+)
+
+var _ = Describe("HttpTracer", func() {
+	var (
+		server     *ghttp.Server
+		traceOut   *bytes.Buffer
+		fakeClock  *fakeclock.FakeClock
+		httpClient *http.Client
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		traceOut = &bytes.Buffer{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		httpClient = &http.Client{Transport: http_tracer.New(http.DefaultTransport, traceOut, fakeClock)}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("traces the request and its response", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v1/desired_lrps"),
+			ghttp.VerifyJSON(`{"process_guid":"web","instances":2}`),
+			func(http.ResponseWriter, *http.Request) {
+				fakeClock.Increment(25 * time.Millisecond)
+			},
+			ghttp.RespondWith(http.StatusCreated, `{"process_guid":"web"}`, http.Header{"Content-Type": {"application/json"}}),
+		))
+
+		response, err := httpClient.Post(server.URL()+"/v1/desired_lrps", "application/json", strings.NewReader(`{"process_guid":"web","instances":2}`))
+		Expect(err).NotTo(HaveOccurred())
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal(`{"process_guid":"web"}`))
+
+		trace := traceOut.String()
+		Expect(trace).To(HavePrefix("REQUEST [2015-07-04T12:00:00Z] POST " + server.URL() + "/v1/desired_lrps\n"))
+		Expect(trace).To(ContainSubstring("Content-Type: application/json\n"))
+		Expect(trace).To(ContainSubstring("\n" + `{"instances":2,"process_guid":"web"}` + "\n\n"))
+		Expect(trace).To(ContainSubstring("RESPONSE [2015-07-04T12:00:00Z] 201 Created (25ms)\n"))
+		Expect(trace).To(HaveSuffix("\n" + `{"process_guid":"web"}` + "\n\n"))
+	})
+
+	It("redacts credentials, secret fields and environment variable values", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyBasicAuth("admin", "hunter2"),
+			ghttp.VerifyJSON(`{"process_guid":"web","env":[{"name":"DB_PASSWORD","value":"s3cret"}],"annotation":"{}"}`),
+			ghttp.RespondWith(http.StatusOK, `{"password":"hunter2","token":"abc","cells":[{"cell_id":"cell-01"}]}`),
+		))
+
+		_, err := httpClient.Post("http://admin:hunter2@"+strings.TrimPrefix(server.URL(), "http://")+"/v1/desired_lrps", "application/json", strings.NewReader(`{"process_guid":"web","env":[{"name":"DB_PASSWORD","value":"s3cret"}],"annotation":"{}"}`))
+		Expect(err).NotTo(HaveOccurred())
+
+		trace := traceOut.String()
+		Expect(trace).NotTo(ContainSubstring("hunter2"))
+		Expect(trace).NotTo(ContainSubstring("s3cret"))
+		Expect(trace).NotTo(ContainSubstring(`"abc"`))
+		Expect(trace).To(ContainSubstring("POST http://admin:REDACTED@"))
+		Expect(trace).To(ContainSubstring("Authorization: [REDACTED]\n"))
+		Expect(trace).To(ContainSubstring(`{"annotation":"{}","env":[{"name":"DB_PASSWORD","value":"[REDACTED]"}],"process_guid":"web"}`))
+		Expect(trace).To(ContainSubstring(`{"cells":[{"cell_id":"cell-01"}],"password":"[REDACTED]","token":"[REDACTED]"}`))
+	})
+
+	It("traces bodies that aren't JSON as they are", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, "upstream unavailable"))
+
+		_, err := httpClient.Get(server.URL() + "/v1/tasks")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(traceOut.String()).To(ContainSubstring("RESPONSE [2015-07-04T12:00:00Z] 502 Bad Gateway (0s)\n"))
+		Expect(traceOut.String()).To(HaveSuffix("\nupstream unavailable\n\n"))
+	})
+
+	It("doesn't read event streams", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "event: desired_lrp_created\n", http.Header{"Content-Type": {"text/event-stream"}}))
+
+		response, err := httpClient.Get(server.URL() + "/v1/events")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(traceOut.String()).To(ContainSubstring("Content-Type: text/event-stream\n"))
+		Expect(traceOut.String()).To(HaveSuffix("\n\n[EVENT STREAM]\n\n"))
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("event: desired_lrp_created\n"))
+	})
+
+	It("traces requests that fail", func() {
+		_, err := httpClient.Get("http://127.0.0.1:1/v1/tasks")
+		Expect(err).To(HaveOccurred())
+
+		Expect(traceOut.String()).To(HavePrefix("REQUEST [2015-07-04T12:00:00Z] GET http://127.0.0.1:1/v1/tasks\n"))
+		Expect(traceOut.String()).To(ContainSubstring("RESPONSE [2015-07-04T12:00:00Z] error: "))
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"github.com/onsi/gomega/gexec"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/nu7hatch/gouuid"
 )
@@ -282,14 +283,14 @@ func defineTheMainTests(runner *integrationTestRunner) {
 			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(session, 3*time.Second).Should(gbytes.Say("not a registered command"))
-			Eventually(session).Should(gexec.Exit(1))
+			Eventually(session).Should(gexec.Exit(exit_codes.InvalidSyntax))
 		})
 
 		It("exits non-zero when known command is invoked with invalid option", func() {
 			command := runner.command("status", "--badFlag")
 			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(session, 3*time.Second).Should(gexec.Exit(1))
+			Eventually(session, 3*time.Second).Should(gexec.Exit(exit_codes.InvalidSyntax))
 		})
 	})
 }
@@ -0,0 +1,209 @@
+package logging_receptor_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_tracer"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+// loggingClient echoes every receptor request and its response to the UI
+// when the UI is verbose.  Requests are logged as the method called and its
+// arguments; LTC_TRACE traces them as raw HTTP.
+type loggingClient struct {
+	client receptor.Client
+	ui     terminal.UI
+}
+
+func New(client receptor.Client, ui terminal.UI) receptor.Client {
+	return &loggingClient{client, ui}
+}
+
+func (c *loggingClient) CreateTask(request receptor.TaskCreateRequest) error {
+	c.logRequest("CreateTask", request)
+	err := c.client.CreateTask(request)
+	c.logResponse("CreateTask", nil, err)
+	return err
+}
+
+func (c *loggingClient) Tasks() ([]receptor.TaskResponse, error) {
+	c.logRequest("Tasks")
+	tasks, err := c.client.Tasks()
+	c.logResponse("Tasks", tasks, err)
+	return tasks, err
+}
+
+func (c *loggingClient) TasksByDomain(domain string) ([]receptor.TaskResponse, error) {
+	c.logRequest("TasksByDomain", domain)
+	tasks, err := c.client.TasksByDomain(domain)
+	c.logResponse("TasksByDomain", tasks, err)
+	return tasks, err
+}
+
+func (c *loggingClient) GetTask(taskId string) (receptor.TaskResponse, error) {
+	c.logRequest("GetTask", taskId)
+	task, err := c.client.GetTask(taskId)
+	c.logResponse("GetTask", task, err)
+	return task, err
+}
+
+func (c *loggingClient) DeleteTask(taskId string) error {
+	c.logRequest("DeleteTask", taskId)
+	err := c.client.DeleteTask(taskId)
+	c.logResponse("DeleteTask", nil, err)
+	return err
+}
+
+func (c *loggingClient) CancelTask(taskId string) error {
+	c.logRequest("CancelTask", taskId)
+	err := c.client.CancelTask(taskId)
+	c.logResponse("CancelTask", nil, err)
+	return err
+}
+
+func (c *loggingClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	c.logRequest("CreateDesiredLRP", request)
+	err := c.client.CreateDesiredLRP(request)
+	c.logResponse("CreateDesiredLRP", nil, err)
+	return err
+}
+
+func (c *loggingClient) GetDesiredLRP(processGuid string) (receptor.DesiredLRPResponse, error) {
+	c.logRequest("GetDesiredLRP", processGuid)
+	desiredLRP, err := c.client.GetDesiredLRP(processGuid)
+	c.logResponse("GetDesiredLRP", desiredLRP, err)
+	return desiredLRP, err
+}
+
+func (c *loggingClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	c.logRequest("UpdateDesiredLRP", processGuid, update)
+	err := c.client.UpdateDesiredLRP(processGuid, update)
+	c.logResponse("UpdateDesiredLRP", nil, err)
+	return err
+}
+
+func (c *loggingClient) DeleteDesiredLRP(processGuid string) error {
+	c.logRequest("DeleteDesiredLRP", processGuid)
+	err := c.client.DeleteDesiredLRP(processGuid)
+	c.logResponse("DeleteDesiredLRP", nil, err)
+	return err
+}
+
+func (c *loggingClient) DesiredLRPs() ([]receptor.DesiredLRPResponse, error) {
+	c.logRequest("DesiredLRPs")
+	desiredLRPs, err := c.client.DesiredLRPs()
+	c.logResponse("DesiredLRPs", desiredLRPs, err)
+	return desiredLRPs, err
+}
+
+func (c *loggingClient) DesiredLRPsByDomain(domain string) ([]receptor.DesiredLRPResponse, error) {
+	c.logRequest("DesiredLRPsByDomain", domain)
+	desiredLRPs, err := c.client.DesiredLRPsByDomain(domain)
+	c.logResponse("DesiredLRPsByDomain", desiredLRPs, err)
+	return desiredLRPs, err
+}
+
+func (c *loggingClient) ActualLRPs() ([]receptor.ActualLRPResponse, error) {
+	c.logRequest("ActualLRPs")
+	actualLRPs, err := c.client.ActualLRPs()
+	c.logResponse("ActualLRPs", actualLRPs, err)
+	return actualLRPs, err
+}
+
+func (c *loggingClient) ActualLRPsByDomain(domain string) ([]receptor.ActualLRPResponse, error) {
+	c.logRequest("ActualLRPsByDomain", domain)
+	actualLRPs, err := c.client.ActualLRPsByDomain(domain)
+	c.logResponse("ActualLRPsByDomain", actualLRPs, err)
+	return actualLRPs, err
+}
+
+func (c *loggingClient) ActualLRPsByProcessGuid(processGuid string) ([]receptor.ActualLRPResponse, error) {
+	c.logRequest("ActualLRPsByProcessGuid", processGuid)
+	actualLRPs, err := c.client.ActualLRPsByProcessGuid(processGuid)
+	c.logResponse("ActualLRPsByProcessGuid", actualLRPs, err)
+	return actualLRPs, err
+}
+
+func (c *loggingClient) ActualLRPByProcessGuidAndIndex(processGuid string, index int) (receptor.ActualLRPResponse, error) {
+	c.logRequest("ActualLRPByProcessGuidAndIndex", processGuid, index)
+	actualLRP, err := c.client.ActualLRPByProcessGuidAndIndex(processGuid, index)
+	c.logResponse("ActualLRPByProcessGuidAndIndex", actualLRP, err)
+	return actualLRP, err
+}
+
+func (c *loggingClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	c.logRequest("KillActualLRPByProcessGuidAndIndex", processGuid, index)
+	err := c.client.KillActualLRPByProcessGuidAndIndex(processGuid, index)
+	c.logResponse("KillActualLRPByProcessGuidAndIndex", nil, err)
+	return err
+}
+
+func (c *loggingClient) SubscribeToEvents() (receptor.EventSource, error) {
+	c.logRequest("SubscribeToEvents")
+	eventSource, err := c.client.SubscribeToEvents()
+	c.logResponse("SubscribeToEvents", nil, err)
+	return eventSource, err
+}
+
+func (c *loggingClient) Cells() ([]receptor.CellResponse, error) {
+	c.logRequest("Cells")
+	cells, err := c.client.Cells()
+	c.logResponse("Cells", cells, err)
+	return cells, err
+}
+
+func (c *loggingClient) UpsertDomain(domain string, ttl time.Duration) error {
+	c.logRequest("UpsertDomain", domain, ttl)
+	err := c.client.UpsertDomain(domain, ttl)
+	c.logResponse("UpsertDomain", nil, err)
+	return err
+}
+
+func (c *loggingClient) Domains() ([]string, error) {
+	c.logRequest("Domains")
+	domains, err := c.client.Domains()
+	c.logResponse("Domains", domains, err)
+	return domains, err
+}
+
+func (c *loggingClient) logRequest(method string, args ...interface{}) {
+	if c.ui.Verbosity() < terminal.Verbose {
+		return
+	}
+
+	encodedArgs := make([]string, len(args))
+	for i, arg := range args {
+		encodedArgs[i] = encode(arg)
+	}
+	c.ui.SayDebug(colors.Gray(fmt.Sprintf("REQUEST %s %s", method, strings.Join(encodedArgs, " "))) + "\n")
+}
+
+func (c *loggingClient) logResponse(method string, response interface{}, err error) {
+	if c.ui.Verbosity() < terminal.Verbose {
+		return
+	}
+
+	switch {
+	case err != nil:
+		c.ui.SayDebug(colors.Gray(fmt.Sprintf("RESPONSE %s error: %s", method, err)) + "\n")
+	case response == nil:
+		c.ui.SayDebug(colors.Gray(fmt.Sprintf("RESPONSE %s ok", method)) + "\n")
+	default:
+		c.ui.SayDebug(colors.Gray(fmt.Sprintf("RESPONSE %s %s", method, encode(response))) + "\n")
+	}
+}
+
+// encode redacts what LTC_TRACE would, such as env var values that may
+// hold secrets set with --secret-env.
+func encode(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(http_tracer.RedactJSON(encoded))
+}
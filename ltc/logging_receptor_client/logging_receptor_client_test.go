@@ -0,0 +1,100 @@
+package logging_receptor_client_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/http_tracer"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logging_receptor_client"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("LoggingReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		outputBuffer       *gbytes.Buffer
+		terminalUI         terminal.UI
+		client             receptor.Client
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		outputBuffer = gbytes.NewBuffer()
+		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
+		terminalUI.SetColorEnabled(false)
+		client = logging_receptor_client.New(fakeReceptorClient, terminalUI)
+	})
+
+	Context("when the UI is verbose", func() {
+		BeforeEach(func() {
+			terminalUI.SetVerbosity(terminal.Verbose)
+		})
+
+		It("echoes requests and their responses", func() {
+			fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{ProcessGuid: "app", Instances: 2}, nil)
+
+			desiredLRP, err := client.GetDesiredLRP("app")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desiredLRP.Instances).To(Equal(2))
+			Expect(fakeReceptorClient.GetDesiredLRPArgsForCall(0)).To(Equal("app"))
+			Expect(outputBuffer).To(test_helpers.SayLine(`REQUEST GetDesiredLRP "app"`))
+			Expect(outputBuffer).To(test_helpers.Say("RESPONSE GetDesiredLRP {"))
+			Expect(outputBuffer.Contents()).To(ContainSubstring(`"process_guid":"app"`))
+			Expect(outputBuffer.Contents()).To(ContainSubstring(`"instances":2`))
+		})
+
+		It("echoes each argument of the request", func() {
+			client.UpdateDesiredLRP("app", receptor.DesiredLRPUpdateRequest{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(`REQUEST UpdateDesiredLRP "app" {}`))
+			Expect(outputBuffer).To(test_helpers.SayLine("RESPONSE UpdateDesiredLRP ok"))
+		})
+
+		It("redacts env var values, which may hold secrets", func() {
+			fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{
+				ProcessGuid:          "app",
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "DB_PASSWORD", Value: "hunter2"}},
+			}, nil)
+
+			client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+				ProcessGuid:          "app",
+				EnvironmentVariables: []receptor.EnvironmentVariable{{Name: "DB_PASSWORD", Value: "hunter2"}},
+			})
+			client.GetDesiredLRP("app")
+
+			Expect(outputBuffer).To(test_helpers.Say("REQUEST CreateDesiredLRP"))
+			Expect(outputBuffer).To(test_helpers.Say("RESPONSE GetDesiredLRP"))
+			Expect(outputBuffer.Contents()).To(ContainSubstring(`"name":"DB_PASSWORD"`))
+			Expect(outputBuffer.Contents()).To(ContainSubstring(http_tracer.Redacted))
+			Expect(outputBuffer.Contents()).NotTo(ContainSubstring("hunter2"))
+		})
+
+		It("echoes errors", func() {
+			fakeReceptorClient.DeleteDesiredLRPReturns(errors.New("no such app"))
+
+			err := client.DeleteDesiredLRP("app")
+
+			Expect(err).To(MatchError("no such app"))
+			Expect(outputBuffer).To(test_helpers.SayLine("RESPONSE DeleteDesiredLRP error: no such app"))
+		})
+	})
+
+	Context("when the UI is not verbose", func() {
+		It("says nothing", func() {
+			fakeReceptorClient.ActualLRPsReturns([]receptor.ActualLRPResponse{{ProcessGuid: "app"}}, nil)
+
+			actualLRPs, err := client.ActualLRPs()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualLRPs).To(HaveLen(1))
+			Expect(outputBuffer.Contents()).To(BeEmpty())
+		})
+	})
+})
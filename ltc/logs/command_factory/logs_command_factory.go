@@ -2,39 +2,113 @@ package command_factory
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/rotating_file_writer"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/bytefmt"
+	"github.com/pivotal-golang/clock"
 )
 
 type logsCommandFactory struct {
 	appExaminer         app_examiner.AppExaminer
 	ui                  terminal.UI
 	tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter
+	logForwarder        log_drain.Forwarder
+	clock               clock.Clock
 	exitHandler         exit_handler.ExitHandler
 }
 
-func NewLogsCommandFactory(appExaminer app_examiner.AppExaminer, ui terminal.UI, tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter, exitHandler exit_handler.ExitHandler) *logsCommandFactory {
+func NewLogsCommandFactory(appExaminer app_examiner.AppExaminer, ui terminal.UI, tailedLogsOutputter console_tailed_logs_outputter.TailedLogsOutputter, logForwarder log_drain.Forwarder, clock clock.Clock, exitHandler exit_handler.ExitHandler) *logsCommandFactory {
 	return &logsCommandFactory{
 		appExaminer:         appExaminer,
 		ui:                  ui,
 		tailedLogsOutputter: tailedLogsOutputter,
+		logForwarder:        logForwarder,
+		clock:               clock,
 		exitHandler:         exitHandler,
 	}
 }
 
 func (factory *logsCommandFactory) MakeLogsCommand() cli.Command {
 	var logsCommand = cli.Command{
-		Name:        "logs",
-		Aliases:     []string{"lg", "lo"},
-		Usage:       "Streams logs from the specified application",
-		Description: "ltc logs APP_NAME",
-		Action:      factory.tailLogs,
-		Flags:       []cli.Flag{},
+		Name:    "logs",
+		Aliases: []string{"lg", "lo"},
+		Usage:   "Streams logs from the specified application",
+		Description: `ltc logs APP_NAME[/INDEX] [APP_NAME[/INDEX]...] [--instance=INDEX] [--prefix] [--timestamps=STYLE] [--source-colors] [--grep=PATTERN] [--json] [--file=FILE [--max-size=SIZE] [--max-files=COUNT]]
+   ltc logs --selector KEY=VALUE[,KEY=VALUE...] [flags]
+
+   Passing APP_NAME/INDEX, or --instance for every app, streams only the logs
+   of the instance at INDEX.
+
+   --selector streams the logs of the apps whose labels match, as they are
+   when ltc logs starts.
+
+   --timestamps shows when each log was written as local times (the default),
+   utc times, or relative seconds since the first log shown.
+
+   --grep only shows logs whose message matches the regular expression
+   PATTERN, highlighting the matches.
+
+   With --json, each log is a JSON object on a line of its own, with the
+   timestamp, app, source, instance and message of the log.
+
+   On exit, ltc logs shows how many logs it received, how many a second,
+   and how many loggregator dropped because they came in too fast.`,
+		Action: factory.tailLogs,
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "instance, i",
+				Usage: "Only streams logs from the instance at this index",
+			},
+			cli.StringFlag{
+				Name:  "selector, l",
+				Usage: "Streams logs from the apps whose labels match KEY=VALUE[,KEY=VALUE...]",
+			},
+			cli.BoolFlag{
+				Name:  "prefix, p",
+				Usage: "Starts each line with the name of its app",
+			},
+			cli.StringFlag{
+				Name:  "timestamps, t",
+				Usage: "Shows times as local, utc or relative",
+				Value: "local",
+			},
+			cli.BoolFlag{
+				Name:  "source-colors",
+				Usage: "Gives each source of logs, such as APP or RTR, a color of its own",
+			},
+			cli.StringFlag{
+				Name:  "grep, g",
+				Usage: "Only shows logs matching this regular expression",
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Prints each log as a line of JSON",
+			},
+			cli.StringFlag{
+				Name:  "file, f",
+				Usage: "Appends the logs to a file instead of printing them",
+			},
+			cli.StringFlag{
+				Name:  "max-size",
+				Usage: "Rotates the file once it reaches this size, e.g. 50MB",
+			},
+			cli.IntFlag{
+				Name:  "max-files",
+				Usage: "Number of rotated files to keep",
+				Value: 5,
+			},
+		},
 	}
 
 	return logsCommand
@@ -46,12 +120,20 @@ func (factory *logsCommandFactory) MakeDebugLogsCommand() cli.Command {
 			Name:  "raw, r",
 			Usage: "Removes pretty formatting",
 		},
+		cli.StringFlag{
+			Name:  "components, c",
+			Usage: "Only shows logs from these comma-separated components, e.g. rep,auctioneer",
+		},
+		cli.StringFlag{
+			Name:  "level, l",
+			Usage: "Only shows logs at or above this level: debug, info, error or fatal",
+		},
 	}
 	return cli.Command{
 		Name:    "debug-logs",
 		Aliases: []string{"dl"},
 		Usage:   "Streams logs from the lattice cluster components",
-		Description: `ltc debug-logs [--raw]
+		Description: `ltc debug-logs [--raw] [--components=rep,auctioneer] [--level=error]
 
    Output format is:
 
@@ -63,29 +145,255 @@ func (factory *logsCommandFactory) MakeDebugLogsCommand() cli.Command {
 	}
 }
 
+func (factory *logsCommandFactory) MakeDrainLogsCommand() cli.Command {
+	var drainLogsFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "interval, i",
+			Usage: "How often to check which drains are bound (e.g., \"30s\")",
+			Value: 30 * time.Second,
+		},
+	}
+
+	return cli.Command{
+		Name:  "drain-logs",
+		Usage: "Forwards the logs of apps to the drains bound to them",
+		Description: `ltc drain-logs [--interval=DELAY]
+
+   Tails the logs of every app with drains bound by 'ltc bind-log-drain' and
+   sends each line to the app's drains until interrupted.  Drains bound or
+   unbound while it runs are picked up every interval.`,
+		Action: factory.drainLogs,
+		Flags:  drainLogsFlags,
+	}
+}
+
 func (factory *logsCommandFactory) tailLogs(context *cli.Context) {
-	appGuid := context.Args().First()
+	prefixFlag := context.Bool("prefix")
+	jsonFlag := context.Bool("json")
+	timestampsFlag := context.String("timestamps")
+	sourceColorsFlag := context.Bool("source-colors")
+	grepFlag := context.String("grep")
+	fileFlag := context.String("file")
+	maxSizeFlag := context.String("max-size")
+	maxFilesFlag := context.Int("max-files")
+	instanceFlag := context.Int("instance")
+	instanceSet := context.IsSet("instance") || context.IsSet("i")
+	selectorFlag := context.String("selector")
 
-	if appGuid == "" {
+	if selectorFlag != "" && len(context.Args()) > 0 {
+		factory.ui.SayIncorrectUsage("Pass either app names or --selector, not both")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if selectorFlag == "" && len(context.Args()) == 0 {
 		factory.ui.SayIncorrectUsage("APP_NAME required")
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
 
-	if appExists, err := factory.appExaminer.AppExists(appGuid); err != nil {
-		factory.ui.SayLine(fmt.Sprintf("Error: %s", err.Error()))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+	var maxSize int64
+	if maxSizeFlag != "" {
+		maxSizeMB, err := bytefmt.ToMegabytes(maxSizeFlag)
+		if err != nil {
+			factory.ui.SayIncorrectUsage("Invalid --max-size: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		maxSize = int64(maxSizeMB * bytefmt.MEGABYTE)
+	}
+	if maxFilesFlag < 0 {
+		factory.ui.SayIncorrectUsage("--max-files must not be negative")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	timestamps, err := console_tailed_logs_outputter.ParseTimestampStyle(timestampsFlag)
+	if err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	var grep *regexp.Regexp
+	if grepFlag != "" {
+		if grep, err = regexp.Compile(grepFlag); err != nil {
+			factory.ui.SayIncorrectUsage("Invalid --grep: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+	if instanceSet && instanceFlag < 0 {
+		factory.ui.SayIncorrectUsage("--instance must not be negative")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	nameResolver := app_examiner.NewNameResolver(factory.appExaminer)
+	instances := make(map[string]int)
+	patterns := make([]string, 0, len(context.Args()))
+	for _, arg := range context.Args() {
+		appName, index, err := app_examiner.ParseInstance(arg)
+		if err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
 
+		if index != app_examiner.NoInstance {
+			if appName, err = nameResolver.ResolveOne(appName); err != nil {
+				factory.ui.SayLine(err.Error())
+				factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+				return
+			}
+			instances[appName] = index
+		}
+		patterns = append(patterns, appName)
+	}
+
+	var appGuids []string
+	if selectorFlag != "" {
+		var selector app_examiner.Selector
+		if selector, err = app_examiner.ParseSelector(selectorFlag); err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		appGuids, err = nameResolver.ResolveSelector(selector)
+	} else {
+		appGuids, err = nameResolver.Resolve(patterns...)
+	}
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 		return
-	} else if !appExists {
-		factory.ui.SayLine(fmt.Sprintf("Application %s not found.", appGuid))
-		factory.ui.SayLine(fmt.Sprintf("Tailing logs and waiting for %s to appear...", appGuid))
 	}
 
-	factory.tailedLogsOutputter.OutputTailedLogs(appGuid)
+	if instanceSet {
+		for _, appGuid := range appGuids {
+			if _, ok := instances[appGuid]; !ok {
+				instances[appGuid] = instanceFlag
+			}
+		}
+	}
+	if len(instances) > 0 {
+		factory.tailedLogsOutputter.SetInstances(instances)
+	}
+
+	for _, appGuid := range appGuids {
+		if appExists, err := factory.appExaminer.AppExists(appGuid); err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error: %s", err.Error()))
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+
+			return
+		} else if !appExists && !jsonFlag {
+			factory.ui.SayLine(fmt.Sprintf("Application %s not found.", appGuid))
+			factory.ui.SayLine(fmt.Sprintf("Tailing logs and waiting for %s to appear...", appGuid))
+		}
+	}
+
+	// Registered before the file is, so that the stats are written to it
+	// before it is closed.
+	factory.exitHandler.OnExit(factory.tailedLogsOutputter.OutputStats)
+
+	if fileFlag != "" {
+		logFile, err := rotating_file_writer.New(fileFlag, maxSize, maxFilesFlag)
+		if err != nil {
+			factory.ui.SayLine(fmt.Sprintf("Error opening %s: %s", fileFlag, err))
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+		factory.exitHandler.OnExit(func() {
+			logFile.Close()
+		})
+
+		factory.tailedLogsOutputter.SetSink(logFile)
+		factory.ui.SayLine(fmt.Sprintf("Writing logs to %s...", fileFlag))
+	}
+
+	if jsonFlag {
+		factory.tailedLogsOutputter.SetFormat(console_tailed_logs_outputter.JSONFormat)
+	}
+	factory.tailedLogsOutputter.SetTimestamps(timestamps)
+	factory.tailedLogsOutputter.SetSourceColors(sourceColorsFlag)
+	if grep != nil {
+		factory.tailedLogsOutputter.SetGrep(grep)
+	}
+
+	if len(appGuids) == 1 && !prefixFlag {
+		factory.tailedLogsOutputter.OutputTailedLogs(appGuids[0])
+	} else {
+		factory.tailedLogsOutputter.OutputTailedLogsForApps(appGuids, prefixFlag)
+	}
 }
 
 func (factory *logsCommandFactory) tailDebugLogs(context *cli.Context) {
 	rawFlag := context.Bool("raw")
-	factory.tailedLogsOutputter.OutputDebugLogs(!rawFlag)
+	componentsFlag := context.String("components")
+	levelFlag := context.String("level")
+
+	var filter console_tailed_logs_outputter.DebugLogFilter
+	for _, component := range strings.Split(componentsFlag, ",") {
+		if component = strings.TrimSpace(component); component != "" {
+			filter.Components = append(filter.Components, component)
+		}
+	}
+	if levelFlag != "" {
+		var err error
+		if filter.MinLevel, err = console_tailed_logs_outputter.ParseDebugLogLevel(levelFlag); err != nil {
+			factory.ui.SayIncorrectUsage(err.Error())
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+
+	factory.tailedLogsOutputter.OutputDebugLogs(!rawFlag, filter)
+}
+
+func (factory *logsCommandFactory) drainLogs(context *cli.Context) {
+	intervalFlag := context.Duration("interval")
+
+	if intervalFlag <= 0 {
+		factory.ui.SayIncorrectUsage("--interval must be greater than 0")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	closeChan := make(chan struct{}, 1)
+	factory.exitHandler.OnExit(func() {
+		factory.logForwarder.Stop()
+		closeChan <- struct{}{}
+	})
+
+	var forwarding map[string][]string
+	for {
+		forwarding = factory.forwardLogDrains(forwarding)
+
+		select {
+		case <-closeChan:
+			return
+		case <-factory.clock.NewTimer(intervalFlag).C():
+		}
+	}
+}
+
+// forwardLogDrains has the forwarder follow the drains now bound to apps,
+// and returns them.  Errors listing apps leave the forwarder as it was.
+func (factory *logsCommandFactory) forwardLogDrains(forwarding map[string][]string) map[string][]string {
+	apps, err := factory.appExaminer.ListApps()
+	if err != nil {
+		factory.ui.SayLine("Error listing apps: " + err.Error())
+		return forwarding
+	}
+
+	drainURLsByApp := make(map[string][]string)
+	drainCount := 0
+	for _, app := range apps {
+		if len(app.LogDrains) > 0 {
+			drainURLsByApp[app.ProcessGuid] = app.LogDrains
+			drainCount += len(app.LogDrains)
+		}
+	}
+
+	factory.logForwarder.Forward(drainURLsByApp)
+	if !reflect.DeepEqual(drainURLsByApp, forwarding) {
+		factory.ui.SayLine(fmt.Sprintf("Forwarding logs from %d app(s) to %d drain(s).", len(drainURLsByApp), drainCount))
+	}
+	return drainURLsByApp
 }
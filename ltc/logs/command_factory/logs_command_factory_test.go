@@ -2,20 +2,28 @@ package command_factory_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/fake_tailed_logs_outputter"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain/fake_forwarder"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
 )
 
 var _ = Describe("CommandFactory", func() {
@@ -24,7 +32,8 @@ var _ = Describe("CommandFactory", func() {
 		outputBuffer            *gbytes.Buffer
 		terminalUI              terminal.UI
 		fakeTailedLogsOutputter *fake_tailed_logs_outputter.FakeTailedLogsOutputter
-		signalChan              chan os.Signal
+		fakeLogForwarder        *fake_forwarder.FakeForwarder
+		fakeClock               *fakeclock.FakeClock
 		fakeExitHandler         *fake_exit_handler.FakeExitHandler
 	)
 
@@ -33,7 +42,8 @@ var _ = Describe("CommandFactory", func() {
 		outputBuffer = gbytes.NewBuffer()
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
 		fakeTailedLogsOutputter = fake_tailed_logs_outputter.NewFakeTailedLogsOutputter()
-		signalChan = make(chan os.Signal)
+		fakeLogForwarder = &fake_forwarder.FakeForwarder{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
 		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
 	})
 
@@ -42,7 +52,7 @@ var _ = Describe("CommandFactory", func() {
 		var logsCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, terminalUI, fakeTailedLogsOutputter, fakeExitHandler)
+			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, terminalUI, fakeTailedLogsOutputter, fakeLogForwarder, fakeClock, fakeExitHandler)
 			logsCommand = commandFactory.MakeLogsCommand()
 		})
 
@@ -53,6 +63,232 @@ var _ = Describe("CommandFactory", func() {
 
 			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
 			Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("my-app-guid"))
+			Expect(fakeTailedLogsOutputter.SetInstancesCallCount()).To(BeZero())
+			Expect(fakeTailedLogsOutputter.SetTimestampsArgsForCall(0)).To(Equal(console_tailed_logs_outputter.LocalTimestamps))
+			Expect(fakeTailedLogsOutputter.SetSourceColorsArgsForCall(0)).To(BeFalse())
+			Expect(fakeTailedLogsOutputter.SetGrepCallCount()).To(BeZero())
+		})
+
+		It("outputs the log stats on exit", func() {
+			appExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"my-app-guid"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+			Expect(fakeTailedLogsOutputter.OutputStatsCallCount()).To(BeZero())
+
+			fakeExitHandler.Exit(exit_codes.SigInt)
+
+			Expect(fakeTailedLogsOutputter.OutputStatsCallCount()).To(Equal(1))
+		})
+
+		It("tails logs for several apps at once", func() {
+			appExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--prefix", "api", "worker"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+			appGuids, prefix := fakeTailedLogsOutputter.OutputTailedLogsForAppsArgsForCall(0)
+			Expect(appGuids).To(Equal([]string{"api", "worker"}))
+			Expect(prefix).To(BeTrue())
+			Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+		})
+
+		Context("with --selector", func() {
+			BeforeEach(func() {
+				appExaminer.AppExistsReturns(true, nil)
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "api", Labels: map[string]string{"team": "payments"}},
+					{ProcessGuid: "blog", Labels: map[string]string{"team": "marketing"}},
+					{ProcessGuid: "worker", Labels: map[string]string{"team": "payments"}},
+				}, nil)
+			})
+
+			It("tails logs for the apps whose labels match", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--selector", "team=payments", "--prefix"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+				appGuids, prefix := fakeTailedLogsOutputter.OutputTailedLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"api", "worker"}))
+				Expect(prefix).To(BeTrue())
+			})
+
+			It("exits with NotFound when no apps match", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--selector", "team=data"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match team=data."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+
+			It("can't be combined with app names", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--selector", "team=payments", "api"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Pass either app names or --selector, not both"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("sets the timestamp style and source colors", func() {
+			appExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--timestamps=relative", "--source-colors", "my-app-guid"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+			Expect(fakeTailedLogsOutputter.SetTimestampsArgsForCall(0)).To(Equal(console_tailed_logs_outputter.RelativeTimestamps))
+			Expect(fakeTailedLogsOutputter.SetSourceColorsArgsForCall(0)).To(BeTrue())
+		})
+
+		It("rejects unknown timestamp styles", func() {
+			test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--timestamps=gmt", "my-app-guid"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage"))
+			Expect(outputBuffer).To(test_helpers.Say("Invalid timestamps gmt: must be one of local, utc or relative"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+		})
+
+		It("only shows logs matching --grep", func() {
+			appExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--grep", "ERR(OR)?", "my-app-guid"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+			Expect(fakeTailedLogsOutputter.SetGrepCallCount()).To(Equal(1))
+			Expect(fakeTailedLogsOutputter.SetGrepArgsForCall(0).String()).To(Equal("ERR(OR)?"))
+		})
+
+		It("rejects invalid --grep patterns", func() {
+			test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--grep", "ERR(", "my-app-guid"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid --grep: "))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+		})
+
+		It("tails logs as JSON", func() {
+			appExaminer.AppExistsReturns(false, nil)
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--json", "my-app-guid"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+			Expect(fakeTailedLogsOutputter.SetFormatCallCount()).To(Equal(1))
+			Expect(fakeTailedLogsOutputter.SetFormatArgsForCall(0)).To(Equal(console_tailed_logs_outputter.JSONFormat))
+			Expect(outputBuffer.Contents()).To(BeEmpty())
+		})
+
+		Context("when instances are specified", func() {
+			BeforeEach(func() {
+				appExaminer.AppExistsReturns(true, nil)
+			})
+
+			It("tails only the logs of the instance after the app name", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"api/2", "worker"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+				appGuids, _ := fakeTailedLogsOutputter.OutputTailedLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"api", "worker"}))
+				Expect(fakeTailedLogsOutputter.SetInstancesCallCount()).To(Equal(1))
+				Expect(fakeTailedLogsOutputter.SetInstancesArgsForCall(0)).To(Equal(map[string]int{"api": 2}))
+			})
+
+			It("applies --instance to the apps without one", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--instance", "1", "api/2", "worker"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+				Expect(fakeTailedLogsOutputter.SetInstancesArgsForCall(0)).To(Equal(map[string]int{"api": 2, "worker": 1}))
+			})
+
+			It("resolves a pattern before the index to a single app", func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{{ProcessGuid: "api"}, {ProcessGuid: "worker"}}, nil)
+
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"wor*/0"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("worker"))
+				Expect(fakeTailedLogsOutputter.SetInstancesArgsForCall(0)).To(Equal(map[string]int{"worker": 0}))
+			})
+
+			It("rejects malformed instances", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"api/two"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				Expect(fakeTailedLogsOutputter.SetInstancesCallCount()).To(BeZero())
+			})
+
+			It("rejects a negative --instance", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--instance", "-1", "api"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("waits for each app that does not exist yet", func() {
+			appExaminer.AppExistsStub = func(appGuid string) (bool, error) {
+				return appGuid == "api", nil
+			}
+
+			test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"api", "worker"})
+
+			Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+			Expect(outputBuffer).To(test_helpers.SayLine("Application worker not found."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Tailing logs and waiting for worker to appear..."))
+			Expect(outputBuffer.Contents()).NotTo(ContainSubstring("Application api not found."))
+		})
+
+		Context("when the --file flag is passed", func() {
+			var tmpDir string
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "logs_command")
+				Expect(err).NotTo(HaveOccurred())
+				appExaminer.AppExistsReturns(true, nil)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(tmpDir)).To(Succeed())
+			})
+
+			It("sends the logs to the file", func() {
+				logPath := filepath.Join(tmpDir, "app.log")
+
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"--file", logPath, "--max-size=1MB", "my-app-guid"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+				Expect(outputBuffer).To(test_helpers.SayLine("Writing logs to " + logPath + "..."))
+				Expect(fakeTailedLogsOutputter.SetSinkCallCount()).To(Equal(1))
+
+				_, err := fakeTailedLogsOutputter.SetSinkArgsForCall(0).Write([]byte("a log line\n"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ioutil.ReadFile(logPath)).To(Equal([]byte("a log line\n")))
+			})
+
+			It("exits when the file cannot be opened", func() {
+				logPath := filepath.Join(tmpDir, "missing", "app.log")
+
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--file", logPath, "my-app-guid"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error opening " + logPath))
+				Expect(fakeTailedLogsOutputter.SetSinkCallCount()).To(BeZero())
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+			})
+
+			It("rejects a malformed --max-size", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--file", filepath.Join(tmpDir, "app.log"), "--max-size=lots", "my-app-guid"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid --max-size"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects a negative --max-files", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"--file", filepath.Join(tmpDir, "app.log"), "--max-files=-1", "my-app-guid"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --max-files must not be negative"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
 		})
 
 		It("handles invalid appguids", func() {
@@ -71,6 +307,41 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("Tailing logs and waiting for non_existent_app to appear..."))
 		})
 
+		Context("when the app name is a pattern", func() {
+			BeforeEach(func() {
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "worker-a"},
+					{ProcessGuid: "worker-b"},
+					{ProcessGuid: "web"},
+				}, nil)
+				appExaminer.AppExistsReturns(true, nil)
+			})
+
+			It("tails logs for the matching app", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"we*"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("web"))
+			})
+
+			It("tails logs for every app when the pattern matches several", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(logsCommand, []string{"worker-*"})
+
+				Eventually(fakeTailedLogsOutputter.OutputTailedLogsForAppsCallCount).Should(Equal(1))
+				appGuids, prefix := fakeTailedLogsOutputter.OutputTailedLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"worker-a", "worker-b"}))
+				Expect(prefix).To(BeFalse())
+			})
+
+			It("exits with NotFound when nothing matches", func() {
+				test_helpers.ExecuteCommandWithArgs(logsCommand, []string{"tmp-*"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No apps match tmp-*."))
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+			})
+		})
+
 		Context("when the receptor returns an error", func() {
 			It("displays an error and exits", func() {
 				appExaminer.AppExistsReturns(false, errors.New("can't log this"))
@@ -90,7 +361,7 @@ var _ = Describe("CommandFactory", func() {
 		var debugLogsCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, terminalUI, fakeTailedLogsOutputter, fakeExitHandler)
+			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, terminalUI, fakeTailedLogsOutputter, fakeLogForwarder, fakeClock, fakeExitHandler)
 			debugLogsCommand = commandFactory.MakeDebugLogsCommand()
 		})
 
@@ -98,7 +369,9 @@ var _ = Describe("CommandFactory", func() {
 			test_helpers.AsyncExecuteCommandWithArgs(debugLogsCommand, []string{})
 
 			Eventually(fakeTailedLogsOutputter.OutputDebugLogsCallCount).Should(Equal(1))
-			Expect(fakeTailedLogsOutputter.OutputDebugLogsArgsForCall(0)).To(BeTrue())
+			pretty, filter := fakeTailedLogsOutputter.OutputDebugLogsArgsForCall(0)
+			Expect(pretty).To(BeTrue())
+			Expect(filter).To(BeZero())
 		})
 
 		Context("when the --raw flag is passed", func() {
@@ -106,10 +379,103 @@ var _ = Describe("CommandFactory", func() {
 				test_helpers.AsyncExecuteCommandWithArgs(debugLogsCommand, []string{"--raw"})
 
 				Eventually(fakeTailedLogsOutputter.OutputDebugLogsCallCount).Should(Equal(1))
-				Expect(fakeTailedLogsOutputter.OutputDebugLogsArgsForCall(0)).To(BeFalse())
+				pretty, _ := fakeTailedLogsOutputter.OutputDebugLogsArgsForCall(0)
+				Expect(pretty).To(BeFalse())
+			})
+		})
+
+		Context("when filters are passed", func() {
+			It("tails only the chosen components at or above the level", func() {
+				test_helpers.AsyncExecuteCommandWithArgs(debugLogsCommand, []string{"--components=rep, auctioneer", "--level=ERROR"})
+
+				Eventually(fakeTailedLogsOutputter.OutputDebugLogsCallCount).Should(Equal(1))
+				_, filter := fakeTailedLogsOutputter.OutputDebugLogsArgsForCall(0)
+				Expect(filter).To(Equal(console_tailed_logs_outputter.DebugLogFilter{
+					Components: []string{"rep", "auctioneer"},
+					MinLevel:   lager.ERROR,
+				}))
+			})
+
+			It("rejects unknown levels", func() {
+				test_helpers.ExecuteCommandWithArgs(debugLogsCommand, []string{"--level=loud"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Invalid log level loud: must be one of debug, info, error or fatal"))
+				Expect(fakeTailedLogsOutputter.OutputDebugLogsCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 		})
 
 	})
 
+	Describe("DrainLogsCommand", func() {
+		var drainLogsCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewLogsCommandFactory(appExaminer, terminalUI, fakeTailedLogsOutputter, fakeLogForwarder, fakeClock, fakeExitHandler)
+			drainLogsCommand = commandFactory.MakeDrainLogsCommand()
+
+			appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "api", LogDrains: []string{"syslog://logs.example.com:514", "https://logs.example.com/drain"}},
+				{ProcessGuid: "worker"},
+			}, nil)
+		})
+
+		Context("while forwarding", func() {
+			var closeChan chan struct{}
+
+			AfterEach(func() {
+				go fakeExitHandler.Exit(exit_codes.SigInt)
+				Eventually(closeChan).Should(BeClosed())
+				Expect(fakeLogForwarder.StopCallCount()).To(Equal(1))
+			})
+
+			It("forwards the logs of apps with drains bound", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(drainLogsCommand, []string{})
+
+				Eventually(outputBuffer).Should(test_helpers.SayLine("Forwarding logs from 1 app(s) to 2 drain(s)."))
+				Expect(fakeLogForwarder.ForwardCallCount()).To(Equal(1))
+				Expect(fakeLogForwarder.ForwardArgsForCall(0)).To(Equal(map[string][]string{
+					"api": {"syslog://logs.example.com:514", "https://logs.example.com/drain"},
+				}))
+			})
+
+			It("picks up drains bound and unbound every interval", func() {
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(drainLogsCommand, []string{"--interval=10s"})
+
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClock.IncrementBySeconds(10)
+				Eventually(fakeLogForwarder.ForwardCallCount).Should(Equal(2))
+
+				appExaminer.ListAppsReturns([]app_examiner.AppInfo{
+					{ProcessGuid: "worker", LogDrains: []string{"syslog://logs.example.com:514"}},
+				}, nil)
+				fakeClock.IncrementBySeconds(10)
+
+				Eventually(fakeLogForwarder.ForwardCallCount).Should(Equal(3))
+				Expect(fakeLogForwarder.ForwardArgsForCall(2)).To(Equal(map[string][]string{
+					"worker": {"syslog://logs.example.com:514"},
+				}))
+				Expect(outputBuffer).To(test_helpers.SayLine("Forwarding logs from 1 app(s) to 2 drain(s)."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Forwarding logs from 1 app(s) to 1 drain(s)."))
+			})
+
+			It("keeps forwarding when listing apps fails", func() {
+				appExaminer.ListAppsReturns(nil, errors.New("receptor down"))
+
+				closeChan = test_helpers.AsyncExecuteCommandWithArgs(drainLogsCommand, []string{})
+
+				Eventually(outputBuffer).Should(test_helpers.SayLine("Error listing apps: receptor down"))
+				Expect(fakeLogForwarder.ForwardCallCount()).To(BeZero())
+				Consistently(closeChan).ShouldNot(BeClosed())
+			})
+		})
+
+		It("rejects intervals that aren't positive", func() {
+			test_helpers.ExecuteCommandWithArgs(drainLogsCommand, []string{"--interval=0"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			Expect(appExaminer.ListAppsCallCount()).To(BeZero())
+		})
+	})
 })
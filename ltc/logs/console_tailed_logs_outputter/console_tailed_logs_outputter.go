@@ -1,69 +1,356 @@
 package console_tailed_logs_outputter
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/chug"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter/prettify"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
 )
 
+var prefixColors = []func(string) string{colors.Green, colors.Purple, colors.Blue, colors.Cyan, colors.Yellow}
+
+var debugLogLevels = map[string]lager.LogLevel{
+	"debug": lager.DEBUG,
+	"info":  lager.INFO,
+	"error": lager.ERROR,
+	"fatal": lager.FATAL,
+}
+
+// DebugLogFilter picks which lines of the debug log stream are shown.  The
+// zero value shows everything.
+type DebugLogFilter struct {
+	Components []string
+	MinLevel   lager.LogLevel
+}
+
+// ParseDebugLogLevel turns a level name such as "error" into the level
+// DebugLogFilter expects.
+func ParseDebugLogLevel(level string) (lager.LogLevel, error) {
+	logLevel, ok := debugLogLevels[strings.ToLower(level)]
+	if !ok {
+		return lager.DEBUG, fmt.Errorf("Invalid log level %s: must be one of debug, info, error or fatal", level)
+	}
+	return logLevel, nil
+}
+
+// allows matches components against the source of lager logs, falling back
+// to the source type of the log message, such as "rep" for "rep:cell-1".
+// Lines that aren't lager logs carry no level and are treated as info.
+func (f DebugLogFilter) allows(entry chug.Entry) bool {
+	level := lager.INFO
+	component := strings.Split(entry.LogMessage.GetSourceType(), ":")[0]
+	if entry.IsLager {
+		level = entry.Log.LogLevel
+		if entry.Log.Source != "" {
+			component = entry.Log.Source
+		}
+	}
+
+	if level < f.MinLevel {
+		return false
+	}
+	if len(f.Components) == 0 {
+		return true
+	}
+	for _, allowed := range f.Components {
+		if allowed == component {
+			return true
+		}
+	}
+	return false
+}
+
+// LogFormat picks how OutputTailedLogsForApps writes each log.
+type LogFormat int
+
+const (
+	// TextFormat writes a line of text per log, as a person would read it.
+	TextFormat LogFormat = iota
+	// JSONFormat writes a JSON object per log, one to a line, for scripts
+	// shipping the logs elsewhere.
+	JSONFormat
+)
+
+type jsonLog struct {
+	Timestamp string `json:"timestamp"`
+	App       string `json:"app"`
+	Source    string `json:"source"`
+	Instance  string `json:"instance"`
+	Message   string `json:"message"`
+}
+
+type jsonError struct {
+	App   string `json:"app"`
+	Error string `json:"error"`
+}
+
 type TailedLogsOutputter interface {
-	OutputDebugLogs(pretty bool)
+	OutputDebugLogs(pretty bool, filter DebugLogFilter)
 	OutputTailedLogs(appGuid string)
+	OutputTailedLogsForApps(appGuids []string, prefix bool)
+	SetSink(sink io.Writer)
+	SetFormat(format LogFormat)
+	SetTimestamps(timestamps TimestampStyle)
+	SetSourceColors(colorSources bool)
+	SetGrep(pattern *regexp.Regexp)
+	SetInstances(instances map[string]int)
+	OutputStats()
 	StopOutputting()
 }
 
 type ConsoleTailedLogsOutputter struct {
-	outputChan chan string
-	ui         terminal.UI
-	logReader  logs.LogReader
+	outputChan   chan string
+	ui           terminal.UI
+	sink         io.Writer
+	format       LogFormat
+	timestamps   TimestampStyle
+	colorSources bool
+	grep         *regexp.Regexp
+	instances    map[string]int
+	newLogReader func() logs.LogReader
+	clock        clock.Clock
+	stats        logStats
+
+	logReadersMutex sync.Mutex
+	logReaders      []logs.LogReader
+	stopped         bool
 }
 
-func NewConsoleTailedLogsOutputter(ui terminal.UI, logReader logs.LogReader) *ConsoleTailedLogsOutputter {
+// NewConsoleTailedLogsOutputter takes a func making a log reader for each
+// stream it tails, since a log reader can only follow one app at a time.
+func NewConsoleTailedLogsOutputter(ui terminal.UI, newLogReader func() logs.LogReader, clock clock.Clock) *ConsoleTailedLogsOutputter {
 	return &ConsoleTailedLogsOutputter{
-		outputChan: make(chan string, 10),
-		ui:         ui,
-		logReader:  logReader,
+		outputChan:   make(chan string, 10),
+		ui:           ui,
+		newLogReader: newLogReader,
+		clock:        clock,
 	}
 
 }
 
-func (ctlo *ConsoleTailedLogsOutputter) OutputDebugLogs(pretty bool) {
+func (ctlo *ConsoleTailedLogsOutputter) OutputDebugLogs(pretty bool, filter DebugLogFilter) {
 	if pretty {
-		go ctlo.logReader.TailLogs(reserved_app_ids.LatticeDebugLogStreamAppId, ctlo.prettyDebugLogCallback, ctlo.prettyDebugErrorCallback)
+		ctlo.tailLogs(reserved_app_ids.LatticeDebugLogStreamAppId, filteredDebugLogCallback(filter, ctlo.prettyDebugLogCallback), ctlo.prettyDebugErrorCallback)
 	} else {
-		go ctlo.logReader.TailLogs(reserved_app_ids.LatticeDebugLogStreamAppId, ctlo.rawDebugLogCallback, ctlo.rawDebugErrorCallback)
+		ctlo.tailLogs(reserved_app_ids.LatticeDebugLogStreamAppId, filteredDebugLogCallback(filter, ctlo.rawDebugLogCallback), ctlo.rawDebugErrorCallback)
 	}
 	for log := range ctlo.outputChan {
-		ctlo.ui.Say(log + "\n")
+		ctlo.output(log)
 	}
 }
 
 func (ctlo *ConsoleTailedLogsOutputter) OutputTailedLogs(appGuid string) {
-	go ctlo.logReader.TailLogs(appGuid, ctlo.logCallback, ctlo.errorCallback)
+	ctlo.OutputTailedLogsForApps([]string{appGuid}, false)
+}
+
+// OutputTailedLogsForApps merges the log streams of several apps line by
+// line.  With prefix set, each line starts with the name of its app in a
+// color of its own; JSON logs name their app regardless.
+func (ctlo *ConsoleTailedLogsOutputter) OutputTailedLogsForApps(appGuids []string, prefix bool) {
+	prefixWidth := 0
+	for _, appGuid := range appGuids {
+		if len(appGuid) > prefixWidth {
+			prefixWidth = len(appGuid)
+		}
+	}
+
+	ctlo.stats.start(ctlo.clock.Now())
+	formatter := newTextFormatter(ctlo.timestamps, ctlo.colorSources, ctlo.grep)
+	for index, appGuid := range appGuids {
+		linePrefix := ""
+		if prefix && ctlo.format == TextFormat {
+			color := prefixColors[index%len(prefixColors)]
+			linePrefix = color(fmt.Sprintf("%-*s |", prefixWidth, appGuid)) + " "
+		}
+		logCallback, errorCallback := ctlo.logCallback(formatter, linePrefix), ctlo.errorCallback(linePrefix)
+		if ctlo.format == JSONFormat {
+			logCallback, errorCallback = ctlo.jsonLogCallback(appGuid), ctlo.jsonErrorCallback(appGuid)
+		}
+		if index, ok := ctlo.instances[appGuid]; ok {
+			logCallback = instanceLogCallback(strconv.Itoa(index), logCallback)
+		}
+		if ctlo.grep != nil {
+			logCallback = grepLogCallback(ctlo.grep, logCallback)
+		}
+		logCallback = ctlo.statsLogCallback(logCallback)
+		ctlo.tailLogs(appGuid, logCallback, errorCallback)
+	}
 
 	for log := range ctlo.outputChan {
-		ctlo.ui.Say(log + "\n")
+		ctlo.output(log)
 	}
 }
 
+// SetSink sends logs to sink rather than the terminal, without colors.
+func (ctlo *ConsoleTailedLogsOutputter) SetSink(sink io.Writer) {
+	ctlo.sink = sink
+}
+
+// SetFormat picks how OutputTailedLogsForApps writes the logs.  Text is
+// the default.
+func (ctlo *ConsoleTailedLogsOutputter) SetFormat(format LogFormat) {
+	ctlo.format = format
+}
+
+// SetTimestamps picks how text logs show when they were written.  Local
+// times are the default.
+func (ctlo *ConsoleTailedLogsOutputter) SetTimestamps(timestamps TimestampStyle) {
+	ctlo.timestamps = timestamps
+}
+
+// SetSourceColors gives each source of text logs, such as APP or RTR, a
+// color of its own.
+func (ctlo *ConsoleTailedLogsOutputter) SetSourceColors(colorSources bool) {
+	ctlo.colorSources = colorSources
+}
+
+// SetGrep limits the logs of OutputTailedLogsForApps to those whose message
+// matches pattern, highlighting the matches in text logs.
+func (ctlo *ConsoleTailedLogsOutputter) SetGrep(pattern *regexp.Regexp) {
+	ctlo.grep = pattern
+}
+
+// SetInstances limits the logs of each app in instances to those from the
+// instance with the given index.
+func (ctlo *ConsoleTailedLogsOutputter) SetInstances(instances map[string]int) {
+	ctlo.instances = instances
+}
+
+// OutputStats writes how many logs OutputTailedLogsForApps received, how
+// many a second, and how many loggregator dropped under load, as a line of
+// text or JSON.
+func (ctlo *ConsoleTailedLogsOutputter) OutputStats() {
+	if ctlo.format == JSONFormat {
+		encoded, err := json.Marshal(ctlo.stats.json(ctlo.clock.Now()))
+		if err != nil {
+			ctlo.output(err.Error())
+			return
+		}
+		ctlo.output(string(encoded))
+		return
+	}
+	ctlo.output(ctlo.stats.text(ctlo.clock.Now()))
+}
+
 func (ctlo *ConsoleTailedLogsOutputter) StopOutputting() {
-	ctlo.logReader.StopTailing()
+	ctlo.logReadersMutex.Lock()
+	defer ctlo.logReadersMutex.Unlock()
+
+	if ctlo.stopped {
+		return
+	}
+	ctlo.stopped = true
+	for _, logReader := range ctlo.logReaders {
+		logReader.StopTailing()
+	}
 }
 
-func (ctlo *ConsoleTailedLogsOutputter) logCallback(log *events.LogMessage) {
-	timeString := time.Unix(0, log.GetTimestamp()).Format("01/02 15:04:05.00")
-	logOutput := fmt.Sprintf("%s [%s|%s] %s", colors.Cyan(timeString), colors.Yellow(log.GetSourceType()), colors.Yellow(log.GetSourceInstance()), log.GetMessage())
-	ctlo.outputChan <- logOutput
+func (ctlo *ConsoleTailedLogsOutputter) tailLogs(appGuid string, logCallback func(*events.LogMessage), errorCallback func(error)) {
+	ctlo.logReadersMutex.Lock()
+	defer ctlo.logReadersMutex.Unlock()
+
+	if ctlo.stopped {
+		return
+	}
+
+	logReader := ctlo.newLogReader()
+	ctlo.logReaders = append(ctlo.logReaders, logReader)
+	go logReader.TailLogs(appGuid, logCallback, errorCallback)
 }
 
-func (ctlo *ConsoleTailedLogsOutputter) errorCallback(err error) {
-	ctlo.outputChan <- err.Error()
+func (ctlo *ConsoleTailedLogsOutputter) output(log string) {
+	if ctlo.sink == nil {
+		ctlo.ui.Say(log + "\n")
+		return
+	}
+
+	if _, err := fmt.Fprintln(ctlo.sink, colors.Strip(log)); err != nil {
+		ctlo.ui.SayLine("Error writing logs: " + err.Error())
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) logCallback(formatter *textFormatter, linePrefix string) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		ctlo.outputChan <- linePrefix + formatter.format(log)
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) errorCallback(linePrefix string) func(error) {
+	return func(err error) {
+		ctlo.outputChan <- linePrefix + err.Error()
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) jsonLogCallback(appGuid string) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		ctlo.outputJSON(jsonLog{
+			Timestamp: time.Unix(0, log.GetTimestamp()).UTC().Format(time.RFC3339Nano),
+			App:       appGuid,
+			Source:    log.GetSourceType(),
+			Instance:  log.GetSourceInstance(),
+			Message:   string(log.GetMessage()),
+		})
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) jsonErrorCallback(appGuid string) func(error) {
+	return func(err error) {
+		ctlo.outputJSON(jsonError{App: appGuid, Error: err.Error()})
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) outputJSON(value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		ctlo.outputChan <- err.Error()
+		return
+	}
+	ctlo.outputChan <- string(encoded)
+}
+
+func instanceLogCallback(instance string, logCallback func(*events.LogMessage)) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		if log.GetSourceInstance() == instance {
+			logCallback(log)
+		}
+	}
+}
+
+func (ctlo *ConsoleTailedLogsOutputter) statsLogCallback(logCallback func(*events.LogMessage)) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		ctlo.stats.count(log)
+		logCallback(log)
+	}
+}
+
+func grepLogCallback(pattern *regexp.Regexp, logCallback func(*events.LogMessage)) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		if pattern.Match(log.GetMessage()) {
+			logCallback(log)
+		}
+	}
+}
+
+func filteredDebugLogCallback(filter DebugLogFilter, logCallback func(*events.LogMessage)) func(*events.LogMessage) {
+	return func(log *events.LogMessage) {
+		if filter.allows(chug.ChugLogMessage(log)) {
+			logCallback(log)
+		}
+	}
 }
 
 func (ctlo *ConsoleTailedLogsOutputter) prettyDebugLogCallback(log *events.LogMessage) {
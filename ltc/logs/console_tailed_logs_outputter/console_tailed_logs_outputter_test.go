@@ -3,12 +3,14 @@ package console_tailed_logs_outputter_test
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/fake_log_reader"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
@@ -16,6 +18,8 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
 	"github.com/cloudfoundry/noaa/events"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
 )
 
 var _ = Describe("ConsoleTailedLogsOutputter", func() {
@@ -23,6 +27,8 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 		outputBuffer               *gbytes.Buffer
 		terminalUI                 terminal.UI
 		logReader                  *fake_log_reader.FakeLogReader
+		otherLogReader             *fake_log_reader.FakeLogReader
+		fakeClock                  *fakeclock.FakeClock
 		consoleTailedLogsOutputter *console_tailed_logs_outputter.ConsoleTailedLogsOutputter
 	)
 
@@ -40,7 +46,14 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 		outputBuffer = gbytes.NewBuffer()
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
 		logReader = fake_log_reader.NewFakeLogReader()
-		consoleTailedLogsOutputter = console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(terminalUI, logReader)
+		otherLogReader = fake_log_reader.NewFakeLogReader()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		logReaders := []*fake_log_reader.FakeLogReader{logReader, otherLogReader}
+		consoleTailedLogsOutputter = console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(terminalUI, func() logs.LogReader {
+			nextLogReader := logReaders[0]
+			logReaders = logReaders[1:]
+			return nextLogReader
+		}, fakeClock)
 	})
 
 	Describe("OutputTailedLogs", func() {
@@ -59,6 +72,169 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 		})
 	})
 
+	Describe("OutputTailedLogsForApps", func() {
+		It("merges the logs of every app", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("from api")))
+			otherLogReader.AddLog(buildLogMessage("APP", "1", now, []byte("from worker")))
+
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, false)
+
+			Eventually(logReader.GetAppGuid).Should(Equal("api"))
+			Eventually(otherLogReader.GetAppGuid).Should(Equal("worker"))
+
+			timeString := colors.Cyan(now.Format("01/02 15:04:05.00"))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(fmt.Sprintf("%s [%s|%s] from api\n", timeString, colors.Yellow("APP"), colors.Yellow("0"))))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(fmt.Sprintf("%s [%s|%s] from worker\n", timeString, colors.Yellow("APP"), colors.Yellow("1"))))
+		})
+
+		It("prefixes each line with its app name when asked to", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("from api")))
+			otherLogReader.AddError(errors.New("worker error"))
+
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, true)
+
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.Green("api    |") + " " + colors.Cyan(now.Format("01/02 15:04:05.00"))))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.Purple("worker |") + " worker error\n"))
+		})
+	})
+
+	Describe("SetInstances", func() {
+		It("only outputs the logs of the given instance of an app", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("from api 0")))
+			logReader.AddLog(buildLogMessage("APP", "2", now, []byte("from api 2")))
+			otherLogReader.AddLog(buildLogMessage("APP", "0", now, []byte("from worker 0")))
+
+			consoleTailedLogsOutputter.SetInstances(map[string]int{"api": 2})
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, false)
+
+			Eventually(outputBuffer.Contents).Should(ContainSubstring("from api 2\n"))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring("from worker 0\n"))
+			Consistently(outputBuffer.Contents).ShouldNot(ContainSubstring("from api 0"))
+		})
+	})
+
+	Describe("SetSink", func() {
+		It("writes the logs to the sink without colors", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("RTR", "1", now, []byte("First log")))
+			sink := gbytes.NewBuffer()
+
+			consoleTailedLogsOutputter.SetSink(sink)
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(sink).Should(test_helpers.Say(fmt.Sprintf("%s [RTR|1] First log\n", now.Format("01/02 15:04:05.00"))))
+			Expect(outputBuffer.Contents()).To(BeEmpty())
+		})
+	})
+
+	Describe("SetTimestamps", func() {
+		var timestamp time.Time
+
+		BeforeEach(func() {
+			timestamp = time.Date(2015, 7, 1, 12, 30, 45, 120000000, time.UTC)
+			logReader.AddLog(buildLogMessage("APP", "0", timestamp, []byte("first")))
+			logReader.AddLog(buildLogMessage("APP", "0", timestamp.Add(1500*time.Millisecond), []byte("second")))
+		})
+
+		It("shows times in UTC", func() {
+			consoleTailedLogsOutputter.SetTimestamps(console_tailed_logs_outputter.UTCTimestamps)
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Cyan("07/01 12:30:45.12") + " [" + colors.Yellow("APP") + "|" + colors.Yellow("0") + "] first\n"))
+		})
+
+		It("shows the seconds since the first log", func() {
+			consoleTailedLogsOutputter.SetTimestamps(console_tailed_logs_outputter.RelativeTimestamps)
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Cyan("    +0.000s") + " [" + colors.Yellow("APP") + "|" + colors.Yellow("0") + "] first\n"))
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Cyan("    +1.500s") + " [" + colors.Yellow("APP") + "|" + colors.Yellow("0") + "] second\n"))
+		})
+	})
+
+	Describe("ParseTimestampStyle", func() {
+		It("parses style names in any case", func() {
+			Expect(console_tailed_logs_outputter.ParseTimestampStyle("UTC")).To(Equal(console_tailed_logs_outputter.UTCTimestamps))
+			Expect(console_tailed_logs_outputter.ParseTimestampStyle("relative")).To(Equal(console_tailed_logs_outputter.RelativeTimestamps))
+		})
+
+		It("rejects unknown styles", func() {
+			_, err := console_tailed_logs_outputter.ParseTimestampStyle("gmt")
+			Expect(err).To(MatchError("Invalid timestamps gmt: must be one of local, utc or relative"))
+		})
+	})
+
+	Describe("SetSourceColors", func() {
+		It("gives each source a color of its own", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("from app")))
+			logReader.AddLog(buildLogMessage("RTR", "1", now, []byte("from router")))
+			logReader.AddLog(buildLogMessage("APP", "1", now, []byte("from app again")))
+
+			consoleTailedLogsOutputter.SetSourceColors(true)
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(outputBuffer).Should(test_helpers.Say("[" + colors.Yellow("APP") + "|" + colors.Yellow("0") + "] from app\n"))
+			Eventually(outputBuffer).Should(test_helpers.Say("[" + colors.Purple("RTR") + "|" + colors.Purple("1") + "] from router\n"))
+			Eventually(outputBuffer).Should(test_helpers.Say("[" + colors.Yellow("APP") + "|" + colors.Yellow("1") + "] from app again\n"))
+		})
+	})
+
+	It("lines up the messages of instances with longer sources or indices", func() {
+		now := time.Now()
+		logReader.AddLog(buildLogMessage("APP", "10", now, []byte("from instance 10")))
+		logReader.AddLog(buildLogMessage("APP", "2", now, []byte("from instance 2")))
+
+		go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+		Eventually(outputBuffer).Should(test_helpers.Say(colors.Yellow("10") + "] from instance 10\n"))
+		Eventually(outputBuffer).Should(test_helpers.Say(colors.Yellow("2") + "]  from instance 2\n"))
+	})
+
+	Describe("SetGrep", func() {
+		It("only outputs logs matching the pattern, highlighting the matches", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("GET /health 200")))
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("ERROR: db timeout, ERROR: retrying")))
+
+			consoleTailedLogsOutputter.SetGrep(regexp.MustCompile("ERR(OR)?"))
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Red("ERROR") + ": db timeout, " + colors.Red("ERROR") + ": retrying\n"))
+			Consistently(outputBuffer.Contents).ShouldNot(ContainSubstring("GET /health"))
+		})
+
+		It("filters JSON logs too", func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("GET /health 200")))
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("ERROR: db timeout")))
+
+			consoleTailedLogsOutputter.SetFormat(console_tailed_logs_outputter.JSONFormat)
+			consoleTailedLogsOutputter.SetGrep(regexp.MustCompile("ERROR"))
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(`"message":"ERROR: db timeout"`))
+			Consistently(outputBuffer.Contents).ShouldNot(ContainSubstring("GET /health"))
+		})
+	})
+
+	Describe("SetFormat", func() {
+		It("writes each log and error as a line of JSON naming its app", func() {
+			timestamp := time.Date(2015, 7, 1, 12, 30, 45, 123000000, time.UTC)
+			logReader.AddLog(buildLogMessage("APP", "0", timestamp, []byte(`said "hi"`)))
+			otherLogReader.AddError(errors.New("worker error"))
+
+			consoleTailedLogsOutputter.SetFormat(console_tailed_logs_outputter.JSONFormat)
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, true)
+
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(`{"timestamp":"2015-07-01T12:30:45.123Z","app":"api","source":"APP","instance":"0","message":"said \"hi\""}` + "\n"))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(`{"app":"worker","error":"worker error"}` + "\n"))
+		})
+	})
+
 	Describe("OutputDebugLogs", func() {
 
 		It("tails logs with pretty formatting", func() {
@@ -66,7 +242,7 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 			logReader.AddLog(buildLogMessage("rep", "cell-1", now, []byte("First log")))
 			logReader.AddError(errors.New("First Error"))
 
-			go consoleTailedLogsOutputter.OutputDebugLogs(true)
+			go consoleTailedLogsOutputter.OutputDebugLogs(true, console_tailed_logs_outputter.DebugLogFilter{})
 
 			Eventually(logReader.GetAppGuid).Should(Equal(reserved_app_ids.LatticeDebugLogStreamAppId))
 
@@ -82,7 +258,7 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 			logReader.AddLog(buildLogMessage("rep", "cell-1", now, []byte("First log")))
 			logReader.AddError(errors.New("First Error"))
 
-			go consoleTailedLogsOutputter.OutputDebugLogs(false)
+			go consoleTailedLogsOutputter.OutputDebugLogs(false, console_tailed_logs_outputter.DebugLogFilter{})
 
 			Eventually(logReader.GetAppGuid).Should(Equal(reserved_app_ids.LatticeDebugLogStreamAppId))
 
@@ -92,15 +268,118 @@ var _ = Describe("ConsoleTailedLogsOutputter", func() {
 			Eventually(outputBuffer).Should(test_helpers.Say("First log\n"))
 			Eventually(outputBuffer).Should(test_helpers.Say("First Error\n"))
 		})
+
+		Context("with a filter", func() {
+			lagerLog := func(source, level string, message string) []byte {
+				return []byte(fmt.Sprintf(`{"timestamp":"1429296198.620077372","source":"%s","message":"%s","log_level":%s,"data":{}}`, source, message, level))
+			}
+
+			It("shows only the chosen components", func() {
+				now := time.Now()
+				logReader.AddLog(buildLogMessage("rep", "cell-1", now, lagerLog("rep", "1", "rep-log")))
+				logReader.AddLog(buildLogMessage("garden-linux", "cell-1", now, []byte("garden-log")))
+				logReader.AddLog(buildLogMessage("auctioneer:0", "0", now, []byte("auctioneer-log")))
+
+				go consoleTailedLogsOutputter.OutputDebugLogs(false, console_tailed_logs_outputter.DebugLogFilter{
+					Components: []string{"rep", "auctioneer"},
+				})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("rep-log"))
+				Eventually(outputBuffer).Should(test_helpers.Say("auctioneer-log"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("garden-log"))
+			})
+
+			It("shows only logs at or above the minimum level", func() {
+				now := time.Now()
+				logReader.AddLog(buildLogMessage("rep", "cell-1", now, lagerLog("rep", "0", "debug-log")))
+				logReader.AddLog(buildLogMessage("rep", "cell-1", now, []byte("plain-log")))
+				logReader.AddLog(buildLogMessage("rep", "cell-1", now, lagerLog("rep", "2", "error-log")))
+
+				go consoleTailedLogsOutputter.OutputDebugLogs(true, console_tailed_logs_outputter.DebugLogFilter{
+					MinLevel: lager.INFO,
+				})
+
+				Eventually(outputBuffer).Should(test_helpers.Say("plain-log"))
+				Eventually(outputBuffer).Should(test_helpers.Say("error-log"))
+				Expect(outputBuffer.Contents()).NotTo(ContainSubstring("debug-log"))
+			})
+		})
+	})
+
+	Describe("ParseDebugLogLevel", func() {
+		It("parses level names in any case", func() {
+			Expect(console_tailed_logs_outputter.ParseDebugLogLevel("Error")).To(Equal(lager.ERROR))
+			Expect(console_tailed_logs_outputter.ParseDebugLogLevel("debug")).To(Equal(lager.DEBUG))
+		})
+
+		It("rejects unknown levels", func() {
+			_, err := console_tailed_logs_outputter.ParseDebugLogLevel("loud")
+			Expect(err).To(MatchError("Invalid log level loud: must be one of debug, info, error or fatal"))
+		})
+	})
+
+	Describe("OutputStats", func() {
+		BeforeEach(func() {
+			now := time.Now()
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("first")))
+			logReader.AddLog(buildLogMessage("DOP", "", now, []byte("Log message output too high. We've dropped 100 messages")))
+			logReader.AddLog(buildLogMessage("APP", "0", now, []byte("second")))
+			otherLogReader.AddLog(buildLogMessage("APP", "0", now, []byte("from worker")))
+		})
+
+		It("outputs the logs received, their rate and the logs loggregator dropped", func() {
+			consoleTailedLogsOutputter.SetGrep(regexp.MustCompile("second|worker"))
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, false)
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.Red("second")))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(colors.Red("worker")))
+
+			fakeClock.IncrementBySeconds(2)
+			consoleTailedLogsOutputter.OutputStats()
+
+			Expect(outputBuffer).To(test_helpers.Say("Received 3 logs in 2s (1.5/s), 100 dropped by loggregator.\n"))
+		})
+
+		It("outputs the stats as JSON", func() {
+			consoleTailedLogsOutputter.SetFormat(console_tailed_logs_outputter.JSONFormat)
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, false)
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(`"message":"second"`))
+			Eventually(outputBuffer.Contents).Should(ContainSubstring(`"message":"from worker"`))
+
+			fakeClock.IncrementBySeconds(2)
+			consoleTailedLogsOutputter.OutputStats()
+
+			Expect(outputBuffer).To(test_helpers.Say(`{"stats":{"received":3,"dropped":100,"seconds":2,"per_second":1.5}}` + "\n"))
+		})
 	})
 
 	Describe("StopOutputting", func() {
 		It("stops outputting logs", func() {
 			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+			Eventually(logReader.GetAppGuid).Should(Equal("my-app-guid"))
 
 			consoleTailedLogsOutputter.StopOutputting()
 
-			Expect(logReader.IsLogTailStopped()).To(BeTrue())
+			Eventually(logReader.IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("stops every app's logs", func() {
+			go consoleTailedLogsOutputter.OutputTailedLogsForApps([]string{"api", "worker"}, true)
+			Eventually(otherLogReader.GetAppGuid).Should(Equal("worker"))
+
+			consoleTailedLogsOutputter.StopOutputting()
+
+			Eventually(logReader.IsLogTailStopped).Should(BeTrue())
+			Eventually(otherLogReader.IsLogTailStopped).Should(BeTrue())
+		})
+
+		It("can be called more than once", func() {
+			go consoleTailedLogsOutputter.OutputTailedLogs("my-app-guid")
+			Eventually(logReader.GetAppGuid).Should(Equal("my-app-guid"))
+
+			consoleTailedLogsOutputter.StopOutputting()
+			consoleTailedLogsOutputter.StopOutputting()
+
+			Eventually(logReader.IsLogTailStopped).Should(BeTrue())
 		})
 	})
 })
@@ -2,26 +2,69 @@
 package fake_tailed_logs_outputter
 
 import (
+	"io"
+	"regexp"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/console_tailed_logs_outputter"
 )
 
 type FakeTailedLogsOutputter struct {
-	OutputDebugLogsStub        func(pretty bool)
+	OutputDebugLogsStub        func(pretty bool, filter console_tailed_logs_outputter.DebugLogFilter)
 	outputDebugLogsMutex       sync.RWMutex
 	outputDebugLogsArgsForCall []struct {
 		pretty bool
+		filter console_tailed_logs_outputter.DebugLogFilter
 	}
 	OutputTailedLogsStub        func(appGuid string)
 	outputTailedLogsMutex       sync.RWMutex
 	outputTailedLogsArgsForCall []struct {
 		appGuid string
 	}
+	OutputTailedLogsForAppsStub        func(appGuids []string, prefix bool)
+	outputTailedLogsForAppsMutex       sync.RWMutex
+	outputTailedLogsForAppsArgsForCall []struct {
+		appGuids []string
+		prefix   bool
+	}
+	SetSinkStub        func(sink io.Writer)
+	setSinkMutex       sync.RWMutex
+	setSinkArgsForCall []struct {
+		sink io.Writer
+	}
+	SetFormatStub        func(format console_tailed_logs_outputter.LogFormat)
+	setFormatMutex       sync.RWMutex
+	setFormatArgsForCall []struct {
+		format console_tailed_logs_outputter.LogFormat
+	}
+	SetTimestampsStub        func(timestamps console_tailed_logs_outputter.TimestampStyle)
+	setTimestampsMutex       sync.RWMutex
+	setTimestampsArgsForCall []struct {
+		timestamps console_tailed_logs_outputter.TimestampStyle
+	}
+	SetSourceColorsStub        func(colorSources bool)
+	setSourceColorsMutex       sync.RWMutex
+	setSourceColorsArgsForCall []struct {
+		colorSources bool
+	}
+	SetGrepStub        func(pattern *regexp.Regexp)
+	setGrepMutex       sync.RWMutex
+	setGrepArgsForCall []struct {
+		pattern *regexp.Regexp
+	}
+	SetInstancesStub        func(instances map[string]int)
+	setInstancesMutex       sync.RWMutex
+	setInstancesArgsForCall []struct {
+		instances map[string]int
+	}
+	OutputStatsStub           func()
+	outputStatsMutex          sync.RWMutex
+	outputStatsArgsForCall    []struct{}
 	StopOutputtingStub        func()
 	stopOutputtingMutex       sync.RWMutex
 	stopOutputtingArgsForCall []struct{}
 	stopChan                  chan struct{}
+	stopOnce                  sync.Once
 }
 
 func NewFakeTailedLogsOutputter() *FakeTailedLogsOutputter {
@@ -30,14 +73,15 @@ func NewFakeTailedLogsOutputter() *FakeTailedLogsOutputter {
 	}
 }
 
-func (fake *FakeTailedLogsOutputter) OutputDebugLogs(pretty bool) {
+func (fake *FakeTailedLogsOutputter) OutputDebugLogs(pretty bool, filter console_tailed_logs_outputter.DebugLogFilter) {
 	fake.outputDebugLogsMutex.Lock()
 	fake.outputDebugLogsArgsForCall = append(fake.outputDebugLogsArgsForCall, struct {
 		pretty bool
-	}{pretty})
+		filter console_tailed_logs_outputter.DebugLogFilter
+	}{pretty, filter})
 	fake.outputDebugLogsMutex.Unlock()
 	if fake.OutputDebugLogsStub != nil {
-		fake.OutputDebugLogsStub(pretty)
+		fake.OutputDebugLogsStub(pretty, filter)
 	}
 	<-fake.stopChan
 }
@@ -48,10 +92,10 @@ func (fake *FakeTailedLogsOutputter) OutputDebugLogsCallCount() int {
 	return len(fake.outputDebugLogsArgsForCall)
 }
 
-func (fake *FakeTailedLogsOutputter) OutputDebugLogsArgsForCall(i int) bool {
+func (fake *FakeTailedLogsOutputter) OutputDebugLogsArgsForCall(i int) (bool, console_tailed_logs_outputter.DebugLogFilter) {
 	fake.outputDebugLogsMutex.RLock()
 	defer fake.outputDebugLogsMutex.RUnlock()
-	return fake.outputDebugLogsArgsForCall[i].pretty
+	return fake.outputDebugLogsArgsForCall[i].pretty, fake.outputDebugLogsArgsForCall[i].filter
 }
 
 func (fake *FakeTailedLogsOutputter) OutputTailedLogs(appGuid string) {
@@ -78,6 +122,184 @@ func (fake *FakeTailedLogsOutputter) OutputTailedLogsArgsForCall(i int) string {
 	return fake.outputTailedLogsArgsForCall[i].appGuid
 }
 
+func (fake *FakeTailedLogsOutputter) OutputTailedLogsForApps(appGuids []string, prefix bool) {
+	fake.outputTailedLogsForAppsMutex.Lock()
+	fake.outputTailedLogsForAppsArgsForCall = append(fake.outputTailedLogsForAppsArgsForCall, struct {
+		appGuids []string
+		prefix   bool
+	}{appGuids, prefix})
+	fake.outputTailedLogsForAppsMutex.Unlock()
+	if fake.OutputTailedLogsForAppsStub != nil {
+		fake.OutputTailedLogsForAppsStub(appGuids, prefix)
+	}
+	<-fake.stopChan
+}
+
+func (fake *FakeTailedLogsOutputter) OutputTailedLogsForAppsCallCount() int {
+	fake.outputTailedLogsForAppsMutex.RLock()
+	defer fake.outputTailedLogsForAppsMutex.RUnlock()
+	return len(fake.outputTailedLogsForAppsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) OutputTailedLogsForAppsArgsForCall(i int) ([]string, bool) {
+	fake.outputTailedLogsForAppsMutex.RLock()
+	defer fake.outputTailedLogsForAppsMutex.RUnlock()
+	return fake.outputTailedLogsForAppsArgsForCall[i].appGuids, fake.outputTailedLogsForAppsArgsForCall[i].prefix
+}
+
+func (fake *FakeTailedLogsOutputter) SetSink(sink io.Writer) {
+	fake.setSinkMutex.Lock()
+	fake.setSinkArgsForCall = append(fake.setSinkArgsForCall, struct {
+		sink io.Writer
+	}{sink})
+	fake.setSinkMutex.Unlock()
+	if fake.SetSinkStub != nil {
+		fake.SetSinkStub(sink)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetSinkCallCount() int {
+	fake.setSinkMutex.RLock()
+	defer fake.setSinkMutex.RUnlock()
+	return len(fake.setSinkArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetSinkArgsForCall(i int) io.Writer {
+	fake.setSinkMutex.RLock()
+	defer fake.setSinkMutex.RUnlock()
+	return fake.setSinkArgsForCall[i].sink
+}
+
+func (fake *FakeTailedLogsOutputter) SetFormat(format console_tailed_logs_outputter.LogFormat) {
+	fake.setFormatMutex.Lock()
+	fake.setFormatArgsForCall = append(fake.setFormatArgsForCall, struct {
+		format console_tailed_logs_outputter.LogFormat
+	}{format})
+	fake.setFormatMutex.Unlock()
+	if fake.SetFormatStub != nil {
+		fake.SetFormatStub(format)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetFormatCallCount() int {
+	fake.setFormatMutex.RLock()
+	defer fake.setFormatMutex.RUnlock()
+	return len(fake.setFormatArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetFormatArgsForCall(i int) console_tailed_logs_outputter.LogFormat {
+	fake.setFormatMutex.RLock()
+	defer fake.setFormatMutex.RUnlock()
+	return fake.setFormatArgsForCall[i].format
+}
+
+func (fake *FakeTailedLogsOutputter) SetTimestamps(timestamps console_tailed_logs_outputter.TimestampStyle) {
+	fake.setTimestampsMutex.Lock()
+	fake.setTimestampsArgsForCall = append(fake.setTimestampsArgsForCall, struct {
+		timestamps console_tailed_logs_outputter.TimestampStyle
+	}{timestamps})
+	fake.setTimestampsMutex.Unlock()
+	if fake.SetTimestampsStub != nil {
+		fake.SetTimestampsStub(timestamps)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetTimestampsCallCount() int {
+	fake.setTimestampsMutex.RLock()
+	defer fake.setTimestampsMutex.RUnlock()
+	return len(fake.setTimestampsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetTimestampsArgsForCall(i int) console_tailed_logs_outputter.TimestampStyle {
+	fake.setTimestampsMutex.RLock()
+	defer fake.setTimestampsMutex.RUnlock()
+	return fake.setTimestampsArgsForCall[i].timestamps
+}
+
+func (fake *FakeTailedLogsOutputter) SetSourceColors(colorSources bool) {
+	fake.setSourceColorsMutex.Lock()
+	fake.setSourceColorsArgsForCall = append(fake.setSourceColorsArgsForCall, struct {
+		colorSources bool
+	}{colorSources})
+	fake.setSourceColorsMutex.Unlock()
+	if fake.SetSourceColorsStub != nil {
+		fake.SetSourceColorsStub(colorSources)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetSourceColorsCallCount() int {
+	fake.setSourceColorsMutex.RLock()
+	defer fake.setSourceColorsMutex.RUnlock()
+	return len(fake.setSourceColorsArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetSourceColorsArgsForCall(i int) bool {
+	fake.setSourceColorsMutex.RLock()
+	defer fake.setSourceColorsMutex.RUnlock()
+	return fake.setSourceColorsArgsForCall[i].colorSources
+}
+
+func (fake *FakeTailedLogsOutputter) SetGrep(pattern *regexp.Regexp) {
+	fake.setGrepMutex.Lock()
+	fake.setGrepArgsForCall = append(fake.setGrepArgsForCall, struct {
+		pattern *regexp.Regexp
+	}{pattern})
+	fake.setGrepMutex.Unlock()
+	if fake.SetGrepStub != nil {
+		fake.SetGrepStub(pattern)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetGrepCallCount() int {
+	fake.setGrepMutex.RLock()
+	defer fake.setGrepMutex.RUnlock()
+	return len(fake.setGrepArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetGrepArgsForCall(i int) *regexp.Regexp {
+	fake.setGrepMutex.RLock()
+	defer fake.setGrepMutex.RUnlock()
+	return fake.setGrepArgsForCall[i].pattern
+}
+
+func (fake *FakeTailedLogsOutputter) SetInstances(instances map[string]int) {
+	fake.setInstancesMutex.Lock()
+	fake.setInstancesArgsForCall = append(fake.setInstancesArgsForCall, struct {
+		instances map[string]int
+	}{instances})
+	fake.setInstancesMutex.Unlock()
+	if fake.SetInstancesStub != nil {
+		fake.SetInstancesStub(instances)
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) SetInstancesCallCount() int {
+	fake.setInstancesMutex.RLock()
+	defer fake.setInstancesMutex.RUnlock()
+	return len(fake.setInstancesArgsForCall)
+}
+
+func (fake *FakeTailedLogsOutputter) SetInstancesArgsForCall(i int) map[string]int {
+	fake.setInstancesMutex.RLock()
+	defer fake.setInstancesMutex.RUnlock()
+	return fake.setInstancesArgsForCall[i].instances
+}
+
+func (fake *FakeTailedLogsOutputter) OutputStats() {
+	fake.outputStatsMutex.Lock()
+	fake.outputStatsArgsForCall = append(fake.outputStatsArgsForCall, struct{}{})
+	fake.outputStatsMutex.Unlock()
+	if fake.OutputStatsStub != nil {
+		fake.OutputStatsStub()
+	}
+}
+
+func (fake *FakeTailedLogsOutputter) OutputStatsCallCount() int {
+	fake.outputStatsMutex.RLock()
+	defer fake.outputStatsMutex.RUnlock()
+	return len(fake.outputStatsArgsForCall)
+}
+
 func (fake *FakeTailedLogsOutputter) StopOutputting() {
 	fake.stopOutputtingMutex.Lock()
 	fake.stopOutputtingArgsForCall = append(fake.stopOutputtingArgsForCall, struct{}{})
@@ -85,7 +307,9 @@ func (fake *FakeTailedLogsOutputter) StopOutputting() {
 	if fake.StopOutputtingStub != nil {
 		fake.StopOutputtingStub()
 	}
-	close(fake.stopChan)
+	fake.stopOnce.Do(func() {
+		close(fake.stopChan)
+	})
 }
 
 func (fake *FakeTailedLogsOutputter) StopOutputtingCallCount() int {
@@ -0,0 +1,80 @@
+package console_tailed_logs_outputter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+// Loggregator drops logs it can't keep up with rather than slowing apps
+// down, and says so with a log of its own from one of these sources, e.g.
+// "Log message output too high. We've dropped 100 messages".
+var (
+	dropNoticeSources = map[string]bool{"DOP": true, "LGR": true}
+	dropNoticePattern = regexp.MustCompile(`dropped (\d+) messages`)
+)
+
+type jsonStats struct {
+	Stats struct {
+		Received  int     `json:"received"`
+		Dropped   int     `json:"dropped"`
+		Seconds   float64 `json:"seconds"`
+		PerSecond float64 `json:"per_second"`
+	} `json:"stats"`
+}
+
+// logStats counts the logs tailed since started, and those loggregator
+// reports dropping.
+type logStats struct {
+	mutex    sync.Mutex
+	started  time.Time
+	received int
+	dropped  int
+}
+
+func (s *logStats) start(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.started = now
+	s.received = 0
+	s.dropped = 0
+}
+
+func (s *logStats) count(log *events.LogMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if dropNoticeSources[log.GetSourceType()] {
+		if match := dropNoticePattern.FindSubmatch(log.GetMessage()); match != nil {
+			dropped, _ := strconv.Atoi(string(match[1]))
+			s.dropped += dropped
+			return
+		}
+	}
+	s.received++
+}
+
+func (s *logStats) json(now time.Time) jsonStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var stats jsonStats
+	stats.Stats.Received = s.received
+	stats.Stats.Dropped = s.dropped
+	stats.Stats.Seconds = now.Sub(s.started).Seconds()
+	if stats.Stats.Seconds > 0 {
+		stats.Stats.PerSecond = float64(s.received) / stats.Stats.Seconds
+	}
+	return stats
+}
+
+func (s *logStats) text(now time.Time) string {
+	stats := s.json(now).Stats
+	elapsed := time.Duration(stats.Seconds) * time.Second
+	return fmt.Sprintf("Received %d logs in %s (%.1f/s), %d dropped by loggregator.", stats.Received, elapsed, stats.PerSecond, stats.Dropped)
+}
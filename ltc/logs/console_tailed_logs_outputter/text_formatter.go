@@ -0,0 +1,122 @@
+package console_tailed_logs_outputter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry/noaa/events"
+)
+
+var sourceColors = []func(string) string{colors.Yellow, colors.Purple, colors.Blue, colors.Green}
+
+// TimestampStyle picks how text logs show when they were written.
+type TimestampStyle int
+
+const (
+	// LocalTimestamps shows the date and time in the local time zone.
+	LocalTimestamps TimestampStyle = iota
+	// UTCTimestamps shows the date and time in UTC.
+	UTCTimestamps
+	// RelativeTimestamps shows the seconds since the first log shown.
+	RelativeTimestamps
+)
+
+var timestampStyles = map[string]TimestampStyle{
+	"local":    LocalTimestamps,
+	"utc":      UTCTimestamps,
+	"relative": RelativeTimestamps,
+}
+
+// ParseTimestampStyle turns a style name such as "utc" into the style
+// SetTimestamps expects.
+func ParseTimestampStyle(style string) (TimestampStyle, error) {
+	timestampStyle, ok := timestampStyles[strings.ToLower(style)]
+	if !ok {
+		return LocalTimestamps, fmt.Errorf("Invalid timestamps %s: must be one of local, utc or relative", style)
+	}
+	return timestampStyle, nil
+}
+
+// textFormatter formats the logs of every app tailed at once, so that its
+// state is shared across their streams: the first timestamp relative
+// timestamps count from, the color given to each source, and the width the
+// [SOURCE|INSTANCE] tags are padded to so that messages line up.  Matches
+// of highlight, when set, are colored in the messages.
+type textFormatter struct {
+	timestamps   TimestampStyle
+	colorSources bool
+	highlight    *regexp.Regexp
+
+	mutex          sync.Mutex
+	firstTimestamp *int64
+	colorsBySource map[string]func(string) string
+	tagWidth       int
+}
+
+func newTextFormatter(timestamps TimestampStyle, colorSources bool, highlight *regexp.Regexp) *textFormatter {
+	return &textFormatter{
+		timestamps:     timestamps,
+		colorSources:   colorSources,
+		highlight:      highlight,
+		colorsBySource: make(map[string]func(string) string),
+	}
+}
+
+func (f *textFormatter) format(log *events.LogMessage) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	source, instance := log.GetSourceType(), log.GetSourceInstance()
+	tagWidth := len(source) + len(instance) + 3
+	if tagWidth > f.tagWidth {
+		f.tagWidth = tagWidth
+	}
+
+	color := f.sourceColor(source)
+	padding := strings.Repeat(" ", f.tagWidth-tagWidth)
+	return fmt.Sprintf("%s [%s|%s]%s %s", colors.Cyan(f.timestamp(log.GetTimestamp())), color(source), color(instance), padding, f.message(log))
+}
+
+func (f *textFormatter) message(log *events.LogMessage) string {
+	message := string(log.GetMessage())
+	if f.highlight == nil {
+		return message
+	}
+	return f.highlight.ReplaceAllStringFunc(message, func(match string) string {
+		if match == "" {
+			return match
+		}
+		return colors.Red(match)
+	})
+}
+
+func (f *textFormatter) timestamp(timestamp int64) string {
+	switch f.timestamps {
+	case UTCTimestamps:
+		return time.Unix(0, timestamp).UTC().Format("01/02 15:04:05.00")
+	case RelativeTimestamps:
+		if f.firstTimestamp == nil {
+			f.firstTimestamp = &timestamp
+		}
+		return fmt.Sprintf("%+10.3fs", time.Duration(timestamp-*f.firstTimestamp).Seconds())
+	default:
+		return time.Unix(0, timestamp).Format("01/02 15:04:05.00")
+	}
+}
+
+func (f *textFormatter) sourceColor(source string) func(string) string {
+	if !f.colorSources {
+		return colors.Yellow
+	}
+
+	color, ok := f.colorsBySource[source]
+	if !ok {
+		color = sourceColors[len(f.colorsBySource)%len(sourceColors)]
+		f.colorsBySource[source] = color
+	}
+	return color
+}
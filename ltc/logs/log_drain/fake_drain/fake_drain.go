@@ -0,0 +1,86 @@
+// This file was generated by counterfeiter
+package fake_drain
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry/noaa/events"
+)
+
+type FakeDrain struct {
+	SendStub        func(appGuid string, log *events.LogMessage) error
+	sendMutex       sync.RWMutex
+	sendArgsForCall []struct {
+		appGuid string
+		log     *events.LogMessage
+	}
+	sendReturns struct {
+		result1 error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct{}
+	closeReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeDrain) Send(appGuid string, log *events.LogMessage) error {
+	fake.sendMutex.Lock()
+	fake.sendArgsForCall = append(fake.sendArgsForCall, struct {
+		appGuid string
+		log     *events.LogMessage
+	}{appGuid, log})
+	fake.sendMutex.Unlock()
+	if fake.SendStub != nil {
+		return fake.SendStub(appGuid, log)
+	} else {
+		return fake.sendReturns.result1
+	}
+}
+
+func (fake *FakeDrain) SendCallCount() int {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	return len(fake.sendArgsForCall)
+}
+
+func (fake *FakeDrain) SendArgsForCall(i int) (string, *events.LogMessage) {
+	fake.sendMutex.RLock()
+	defer fake.sendMutex.RUnlock()
+	return fake.sendArgsForCall[i].appGuid, fake.sendArgsForCall[i].log
+}
+
+func (fake *FakeDrain) SendReturns(result1 error) {
+	fake.SendStub = nil
+	fake.sendReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDrain) Close() error {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		return fake.CloseStub()
+	} else {
+		return fake.closeReturns.result1
+	}
+}
+
+func (fake *FakeDrain) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeDrain) CloseReturns(result1 error) {
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ log_drain.Drain = new(FakeDrain)
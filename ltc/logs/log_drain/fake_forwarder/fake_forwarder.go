@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fake_forwarder
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+)
+
+type FakeForwarder struct {
+	ForwardStub        func(drainURLsByApp map[string][]string)
+	forwardMutex       sync.RWMutex
+	forwardArgsForCall []struct {
+		drainURLsByApp map[string][]string
+	}
+	StopStub        func()
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct{}
+}
+
+func (fake *FakeForwarder) Forward(drainURLsByApp map[string][]string) {
+	fake.forwardMutex.Lock()
+	fake.forwardArgsForCall = append(fake.forwardArgsForCall, struct {
+		drainURLsByApp map[string][]string
+	}{drainURLsByApp})
+	fake.forwardMutex.Unlock()
+	if fake.ForwardStub != nil {
+		fake.ForwardStub(drainURLsByApp)
+	}
+}
+
+func (fake *FakeForwarder) ForwardCallCount() int {
+	fake.forwardMutex.RLock()
+	defer fake.forwardMutex.RUnlock()
+	return len(fake.forwardArgsForCall)
+}
+
+func (fake *FakeForwarder) ForwardArgsForCall(i int) map[string][]string {
+	fake.forwardMutex.RLock()
+	defer fake.forwardMutex.RUnlock()
+	return fake.forwardArgsForCall[i].drainURLsByApp
+}
+
+func (fake *FakeForwarder) Stop() {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct{}{})
+	fake.stopMutex.Unlock()
+	if fake.StopStub != nil {
+		fake.StopStub()
+	}
+}
+
+func (fake *FakeForwarder) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+var _ log_drain.Forwarder = new(FakeForwarder)
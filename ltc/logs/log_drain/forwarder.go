@@ -0,0 +1,156 @@
+package log_drain
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
+	"github.com/cloudfoundry/noaa/events"
+)
+
+//go:generate counterfeiter -o fake_forwarder/fake_forwarder.go . Forwarder
+type Forwarder interface {
+	Forward(drainURLsByApp map[string][]string)
+	Stop()
+}
+
+type forwarder struct {
+	newLogReader  func() logs.LogReader
+	newDrain      func(drainURL string) (Drain, error)
+	errorCallback func(appGuid string, err error)
+
+	mutex   sync.Mutex
+	streams map[string]*stream
+	stopped bool
+}
+
+// NewForwarder takes a func making a log reader for each app it tails, as
+// a log reader can only follow one app at a time.  Errors tailing an app or
+// sending its logs are passed to errorCallback, and forwarding carries on.
+func NewForwarder(newLogReader func() logs.LogReader, newDrain func(drainURL string) (Drain, error), errorCallback func(appGuid string, err error)) Forwarder {
+	return &forwarder{
+		newLogReader:  newLogReader,
+		newDrain:      newDrain,
+		errorCallback: errorCallback,
+		streams:       make(map[string]*stream),
+	}
+}
+
+// Forward tails the logs of each app in drainURLsByApp and sends them to
+// the app's drains.  It can be called again as drains are bound and
+// unbound: apps that are left out stop being tailed, and apps whose drains
+// changed keep being tailed with their new drains.
+func (f *forwarder) Forward(drainURLsByApp map[string][]string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.stopped {
+		return
+	}
+
+	for appGuid, appStream := range f.streams {
+		if len(drainURLsByApp[appGuid]) == 0 {
+			appStream.stop()
+			delete(f.streams, appGuid)
+		}
+	}
+
+	for appGuid, drainURLs := range drainURLsByApp {
+		if len(drainURLs) == 0 {
+			continue
+		}
+
+		appStream, ok := f.streams[appGuid]
+		if !ok {
+			appStream = &stream{appGuid: appGuid, logReader: f.newLogReader(), errorCallback: f.errorCallback}
+			f.streams[appGuid] = appStream
+			appStream.setDrains(drainURLs, f.newDrains(appGuid, drainURLs))
+			go appStream.logReader.TailLogs(appGuid, appStream.send, func(err error) {
+				f.errorCallback(appGuid, err)
+			})
+		} else if !sameURLs(appStream.drainURLs, drainURLs) {
+			appStream.setDrains(drainURLs, f.newDrains(appGuid, drainURLs))
+		}
+	}
+}
+
+func (f *forwarder) Stop() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.stopped = true
+	for appGuid, appStream := range f.streams {
+		appStream.stop()
+		delete(f.streams, appGuid)
+	}
+}
+
+func (f *forwarder) newDrains(appGuid string, drainURLs []string) []Drain {
+	drains := make([]Drain, 0, len(drainURLs))
+	for _, drainURL := range drainURLs {
+		drain, err := f.newDrain(drainURL)
+		if err != nil {
+			f.errorCallback(appGuid, err)
+			continue
+		}
+		drains = append(drains, drain)
+	}
+	return drains
+}
+
+type stream struct {
+	appGuid       string
+	logReader     logs.LogReader
+	errorCallback func(appGuid string, err error)
+
+	mutex     sync.Mutex
+	drainURLs []string
+	drains    []Drain
+}
+
+func (s *stream) send(log *events.LogMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, drain := range s.drains {
+		if err := drain.Send(s.appGuid, log); err != nil {
+			s.errorCallback(s.appGuid, err)
+		}
+	}
+}
+
+func (s *stream) setDrains(drainURLs []string, drains []Drain) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closeDrains()
+	s.drainURLs = drainURLs
+	s.drains = drains
+}
+
+func (s *stream) stop() {
+	s.logReader.StopTailing()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closeDrains()
+	s.drains = nil
+}
+
+func (s *stream) closeDrains() {
+	for _, drain := range s.drains {
+		drain.Close()
+	}
+}
+
+func sameURLs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
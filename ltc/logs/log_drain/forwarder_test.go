@@ -0,0 +1,138 @@
+package log_drain_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/fake_log_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain/fake_drain"
+	"github.com/cloudfoundry/noaa/events"
+)
+
+var _ = Describe("Forwarder", func() {
+	var (
+		mutex      sync.Mutex
+		logReaders []*fake_log_reader.FakeLogReader
+		drains     map[string][]*fake_drain.FakeDrain
+		errs       []string
+		forwarder  log_drain.Forwarder
+		log        *events.LogMessage
+	)
+
+	drainsFor := func(drainURL string) []*fake_drain.FakeDrain {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return drains[drainURL]
+	}
+
+	loggedErrors := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return errs
+	}
+
+	BeforeEach(func() {
+		logReaders = nil
+		drains = make(map[string][]*fake_drain.FakeDrain)
+		errs = nil
+		log = logMessage("hello", events.LogMessage_OUT)
+
+		newLogReader := func() logs.LogReader {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			logReader := fake_log_reader.NewFakeLogReader()
+			logReader.AddLog(log)
+			logReaders = append(logReaders, logReader)
+			return logReader
+		}
+		newDrain := func(drainURL string) (log_drain.Drain, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if drainURL == "broken" {
+				return nil, errors.New("Invalid log drain broken")
+			}
+			drain := &fake_drain.FakeDrain{}
+			if drainURL == "syslog://refusing:514" {
+				drain.SendReturns(errors.New("connection refused"))
+			}
+			drains[drainURL] = append(drains[drainURL], drain)
+			return drain, nil
+		}
+		errorCallback := func(appGuid string, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, appGuid+": "+err.Error())
+		}
+
+		forwarder = log_drain.NewForwarder(newLogReader, newDrain, errorCallback)
+	})
+
+	It("sends the logs of each app to its drains", func() {
+		forwarder.Forward(map[string][]string{
+			"app-1": {"syslog://one:514", "syslog://two:514"},
+			"app-2": {"syslog://three:514"},
+			"app-3": {},
+		})
+
+		Eventually(func() int { return drainsFor("syslog://one:514")[0].SendCallCount() }).Should(Equal(1))
+		Eventually(func() int { return drainsFor("syslog://two:514")[0].SendCallCount() }).Should(Equal(1))
+		Eventually(func() int { return drainsFor("syslog://three:514")[0].SendCallCount() }).Should(Equal(1))
+
+		appGuid, sentLog := drainsFor("syslog://three:514")[0].SendArgsForCall(0)
+		Expect(appGuid).To(Equal("app-2"))
+		Expect(sentLog).To(Equal(log))
+		Expect(logReaders).To(HaveLen(2))
+	})
+
+	It("keeps tailing an app whose drains changed, sending to the new drains", func() {
+		forwarder.Forward(map[string][]string{"app-1": {"syslog://one:514"}})
+		Eventually(func() int { return drainsFor("syslog://one:514")[0].SendCallCount() }).Should(Equal(1))
+
+		forwarder.Forward(map[string][]string{"app-1": {"syslog://one:514"}})
+		Expect(drainsFor("syslog://one:514")).To(HaveLen(1))
+
+		forwarder.Forward(map[string][]string{"app-1": {"syslog://two:514"}})
+
+		Expect(drainsFor("syslog://one:514")[0].CloseCallCount()).To(Equal(1))
+		Expect(drainsFor("syslog://two:514")).To(HaveLen(1))
+		Expect(logReaders).To(HaveLen(1))
+		Expect(logReaders[0].IsLogTailStopped()).To(BeFalse())
+	})
+
+	It("stops tailing apps that are left out", func() {
+		forwarder.Forward(map[string][]string{"app-1": {"syslog://one:514"}})
+		Eventually(func() int { return drainsFor("syslog://one:514")[0].SendCallCount() }).Should(Equal(1))
+
+		forwarder.Forward(map[string][]string{})
+
+		Eventually(logReaders[0].IsLogTailStopped).Should(BeTrue())
+		Expect(drainsFor("syslog://one:514")[0].CloseCallCount()).To(Equal(1))
+	})
+
+	It("reports drains it can't make or send to, and forwards to the rest", func() {
+		forwarder.Forward(map[string][]string{"app-1": {"broken", "syslog://refusing:514", "syslog://one:514"}})
+
+		Eventually(func() int { return drainsFor("syslog://one:514")[0].SendCallCount() }).Should(Equal(1))
+		Expect(loggedErrors()).To(Equal([]string{"app-1: Invalid log drain broken", "app-1: connection refused"}))
+	})
+
+	It("stops tailing every app when stopped", func() {
+		forwarder.Forward(map[string][]string{"app-1": {"syslog://one:514"}, "app-2": {"syslog://two:514"}})
+		Eventually(func() int { return drainsFor("syslog://two:514")[0].SendCallCount() }).Should(Equal(1))
+
+		forwarder.Stop()
+
+		Eventually(logReaders[0].IsLogTailStopped).Should(BeTrue())
+		Eventually(logReaders[1].IsLogTailStopped).Should(BeTrue())
+
+		forwarder.Forward(map[string][]string{"app-3": {"syslog://three:514"}})
+		Expect(logReaders).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,161 @@
+package log_drain
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+const drainTimeout = 10 * time.Second
+
+//go:generate counterfeiter -o fake_drain/fake_drain.go . Drain
+type Drain interface {
+	Send(appGuid string, log *events.LogMessage) error
+	Close() error
+}
+
+// ParseURL checks that drainURL is one ltc can forward logs to: a syslog
+// server over TCP (syslog://HOST:PORT) or TLS (syslog-tls://HOST:PORT), or
+// an HTTPS endpoint logs are POSTed to.
+func ParseURL(drainURL string) (*url.URL, error) {
+	parsed, err := url.Parse(drainURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid log drain %s: %s", drainURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "syslog", "syslog-tls":
+		if _, port, err := net.SplitHostPort(parsed.Host); err != nil || port == "" {
+			return nil, fmt.Errorf("Invalid log drain %s: syslog drains must be of the format %s://HOST:PORT", drainURL, parsed.Scheme)
+		}
+	case "https":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("Invalid log drain %s: https drains must have a host", drainURL)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid log drain %s: the scheme must be syslog, syslog-tls or https", drainURL)
+	}
+	return parsed, nil
+}
+
+// New returns a Drain for drainURL.  tlsConfig is used for syslog-tls and
+// https drains, and may be nil to verify them against the system's roots.
+func New(drainURL string, tlsConfig *tls.Config) (Drain, error) {
+	parsed, err := ParseURL(drainURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parsed.Scheme {
+	case "syslog-tls":
+		return &syslogDrain{address: parsed.Host, useTLS: true, tlsConfig: tlsConfig}, nil
+	case "https":
+		return &httpsDrain{
+			url:    drainURL,
+			client: &http.Client{Timeout: drainTimeout, Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+		}, nil
+	default:
+		return &syslogDrain{address: parsed.Host}, nil
+	}
+}
+
+// FormatSyslog formats log as an RFC 5424 message from appGuid, marking
+// its source as the PROCID, e.g. "[APP/0]".  Logs the app wrote to stderr
+// have severity error, and the rest info.
+func FormatSyslog(appGuid string, log *events.LogMessage) string {
+	priority := 14
+	if log.GetMessageType() == events.LogMessage_ERR {
+		priority = 11
+	}
+
+	timestamp := time.Unix(0, log.GetTimestamp()).UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	procID := fmt.Sprintf("[%s/%s]", log.GetSourceType(), log.GetSourceInstance())
+	message := strings.TrimRight(string(log.GetMessage()), "\r\n")
+
+	return fmt.Sprintf("<%d>1 %s - %s %s - - %s", priority, timestamp, appGuid, procID, message)
+}
+
+// syslogDrain connects on the first log it sends, and again on the next
+// one after a failed write.  Messages are framed by octet counting, as
+// RFC 6587 describes, so that they may span lines.
+type syslogDrain struct {
+	address   string
+	useTLS    bool
+	tlsConfig *tls.Config
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+func (d *syslogDrain) Send(appGuid string, log *events.LogMessage) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.conn == nil {
+		conn, err := d.dial()
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+	}
+
+	message := FormatSyslog(appGuid, log)
+	d.conn.SetWriteDeadline(time.Now().Add(drainTimeout))
+	if _, err := fmt.Fprintf(d.conn, "%d %s", len(message), message); err != nil {
+		d.conn.Close()
+		d.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (d *syslogDrain) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: drainTimeout}
+	if d.useTLS {
+		return tls.DialWithDialer(dialer, "tcp", d.address, d.tlsConfig)
+	}
+	return dialer.Dial("tcp", d.address)
+}
+
+func (d *syslogDrain) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// httpsDrain POSTs each log as a syslog message of its own.
+type httpsDrain struct {
+	url    string
+	client *http.Client
+}
+
+func (d *httpsDrain) Send(appGuid string, log *events.LogMessage) error {
+	response, err := d.client.Post(d.url, "text/plain", strings.NewReader(FormatSyslog(appGuid, log)))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return errors.New(d.url + " responded with " + response.Status)
+	}
+	return nil
+}
+
+func (d *httpsDrain) Close() error {
+	return nil
+}
@@ -0,0 +1,13 @@
+package log_drain_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogDrain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LogDrain Suite")
+}
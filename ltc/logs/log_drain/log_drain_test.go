@@ -0,0 +1,157 @@
+package log_drain_test
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/log_drain"
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/gogo/protobuf/proto"
+)
+
+func logMessage(message string, messageType events.LogMessage_MessageType) *events.LogMessage {
+	return &events.LogMessage{
+		Message:        []byte(message),
+		MessageType:    messageType.Enum(),
+		Timestamp:      proto.Int64(time.Date(2015, 7, 4, 12, 30, 0, 123456789, time.UTC).UnixNano()),
+		SourceType:     proto.String("APP"),
+		SourceInstance: proto.String("0"),
+	}
+}
+
+var _ = Describe("LogDrain", func() {
+	Describe("ParseURL", func() {
+		It("accepts syslog, syslog-tls and https drains", func() {
+			for _, drainURL := range []string{"syslog://logs.example.com:514", "syslog-tls://logs.example.com:6514", "https://logs.example.com/drain"} {
+				_, err := log_drain.ParseURL(drainURL)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("rejects syslog drains without a port", func() {
+			_, err := log_drain.ParseURL("syslog://logs.example.com")
+			Expect(err).To(MatchError("Invalid log drain syslog://logs.example.com: syslog drains must be of the format syslog://HOST:PORT"))
+		})
+
+		It("rejects https drains without a host", func() {
+			_, err := log_drain.ParseURL("https:///drain")
+			Expect(err).To(MatchError("Invalid log drain https:///drain: https drains must have a host"))
+		})
+
+		It("rejects other schemes", func() {
+			_, err := log_drain.ParseURL("http://logs.example.com")
+			Expect(err).To(MatchError("Invalid log drain http://logs.example.com: the scheme must be syslog, syslog-tls or https"))
+		})
+	})
+
+	Describe("FormatSyslog", func() {
+		It("formats logs as RFC 5424 messages", func() {
+			Expect(log_drain.FormatSyslog("my-app", logMessage("hello\n", events.LogMessage_OUT))).To(Equal("<14>1 2015-07-04T12:30:00.123456Z - my-app [APP/0] - - hello"))
+		})
+
+		It("gives logs from stderr severity error", func() {
+			Expect(log_drain.FormatSyslog("my-app", logMessage("oops", events.LogMessage_ERR))).To(HavePrefix("<11>1 "))
+		})
+	})
+
+	Describe("syslog drains", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("sends logs framed by their length over one connection", func() {
+			drain, err := log_drain.New("syslog://"+listener.Addr().String(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			defer drain.Close()
+
+			expected := "60 <14>1 2015-07-04T12:30:00.123456Z - my-app [APP/0] - - first" +
+				"61 <14>1 2015-07-04T12:30:00.123456Z - my-app [APP/0] - - second"
+
+			received := make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+
+				conn, err := listener.Accept()
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+
+				buffer := make([]byte, len(expected))
+				_, err = io.ReadFull(conn, buffer)
+				Expect(err).NotTo(HaveOccurred())
+				received <- string(buffer)
+			}()
+
+			Expect(drain.Send("my-app", logMessage("first", events.LogMessage_OUT))).To(Succeed())
+			Expect(drain.Send("my-app", logMessage("second", events.LogMessage_OUT))).To(Succeed())
+
+			Eventually(received).Should(Receive(Equal(expected)))
+		})
+
+		It("returns errors connecting", func() {
+			address := listener.Addr().String()
+			listener.Close()
+
+			drain, err := log_drain.New("syslog://"+address, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(drain.Send("my-app", logMessage("lost", events.LogMessage_OUT))).NotTo(Succeed())
+		})
+	})
+
+	Describe("https drains", func() {
+		var (
+			server *ghttp.Server
+			drain  log_drain.Drain
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewTLSServer()
+
+			var err error
+			drain, err = log_drain.New(server.URL()+"/drain", &tls.Config{InsecureSkipVerify: true})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts each log as a syslog message", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/drain"),
+				ghttp.VerifyHeader(http.Header{"Content-Type": []string{"text/plain"}}),
+				func(w http.ResponseWriter, r *http.Request) {
+					body, err := ioutil.ReadAll(r.Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(body)).To(Equal("<14>1 2015-07-04T12:30:00.123456Z - my-app [APP/0] - - hello"))
+				},
+			))
+
+			Expect(drain.Send("my-app", logMessage("hello", events.LogMessage_OUT))).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns an error when the endpoint rejects a log", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, ""))
+
+			err := drain.Send("my-app", logMessage("hello", events.LogMessage_OUT))
+			Expect(err).To(MatchError(server.URL() + "/drain responded with 503 Service Unavailable"))
+		})
+	})
+})
@@ -1,8 +1,6 @@
 package logs
 
-import (
-	"github.com/cloudfoundry/noaa/events"
-)
+import "github.com/cloudfoundry/noaa/events"
 
 type LogReader interface {
 	TailLogs(appGuid string, logCallback func(*events.LogMessage), errorCallback func(error))
@@ -14,12 +12,16 @@ type logConsumer interface {
 }
 
 type logReader struct {
+	cancel   <-chan struct{}
 	consumer logConsumer
 	stopChan chan struct{}
 }
 
-func NewLogReader(consumer logConsumer) LogReader {
+// NewLogReader returns a LogReader that also stops tailing, closing its
+// connection to loggregator, once cancel is closed.
+func NewLogReader(cancel <-chan struct{}, consumer logConsumer) LogReader {
 	return &logReader{
+		cancel:   cancel,
 		consumer: consumer,
 		stopChan: make(chan struct{}),
 	}
@@ -38,7 +40,12 @@ func (l *logReader) TailLogs(appGuid string, logCallback func(*events.LogMessage
 }
 
 func (l *logReader) StopTailing() {
-	l.stopChan <- struct{}{}
+	// TailLogs stops by itself once cancel is closed, and nothing is left
+	// to receive from stopChan.
+	select {
+	case <-l.cancel:
+	case l.stopChan <- struct{}{}:
+	}
 }
 
 func (l *logReader) readChannels(outputChan <-chan *events.LogMessage, errorChan <-chan error, logCallback func(*events.LogMessage), errorCallback func(error)) {
@@ -46,6 +53,8 @@ func (l *logReader) readChannels(outputChan <-chan *events.LogMessage, errorChan
 		select {
 		case <-l.stopChan:
 			return
+		case <-l.cancel:
+			return
 		case err := <-errorChan:
 			errorCallback(err)
 		case logMessage := <-outputChan:
@@ -88,7 +88,7 @@ var _ = Describe("logs", func() {
 
 		BeforeEach(func() {
 			consumer = NewFakeConsumer()
-			logReader = logs.NewLogReader(consumer)
+			logReader = logs.NewLogReader(nil, consumer)
 		})
 
 		It("provides the logCallback with logs until StopTailing is called", func() {
@@ -152,7 +152,7 @@ var _ = Describe("logs", func() {
 
 		BeforeEach(func() {
 			consumer = NewFakeConsumer()
-			logReader = logs.NewLogReader(consumer)
+			logReader = logs.NewLogReader(nil, consumer)
 		})
 
 		It("stops tailing logs when requested", func() {
@@ -168,6 +168,25 @@ var _ = Describe("logs", func() {
 
 			Expect(doneChan).To(BeClosed())
 		})
+
+		It("stops tailing logs when it is canceled", func() {
+			cancel := make(chan struct{})
+			logReader = logs.NewLogReader(cancel, consumer)
+
+			doneChan := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+
+				logReader.TailLogs("app-guid", func(*events.LogMessage) {}, func(error) {})
+				close(doneChan)
+			}()
+
+			Consistently(doneChan).ShouldNot(BeClosed())
+			close(cancel)
+
+			Eventually(doneChan).Should(BeClosed())
+			logReader.StopTailing()
+		})
 	})
 
 })
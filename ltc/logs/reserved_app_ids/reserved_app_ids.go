@@ -1,3 +1,50 @@
 package reserved_app_ids
 
-const LatticeDebugLogStreamAppId = "lattice-debug"
+import "fmt"
+
+const (
+	LatticeDebugLogStreamAppId = "lattice-debug"
+	LatticeSecretsAppId        = "lattice-secrets"
+)
+
+// latticeAppIds are the app ids lattice uses internally, with what for.
+var latticeAppIds = map[string]string{
+	LatticeDebugLogStreamAppId: "It is used internally to stream debug logs for lattice components.",
+	LatticeSecretsAppId:        "It is used internally to store secrets.",
+}
+
+// ReservedAppIdError is returned when creating an app or task under a
+// reserved id.
+type ReservedAppIdError struct {
+	AppId  string
+	Reason string
+}
+
+func (err ReservedAppIdError) Error() string {
+	return fmt.Sprintf("%s is a reserved app name. %s", err.AppId, err.Reason)
+}
+
+func (err ReservedAppIdError) InvalidName() bool {
+	return true
+}
+
+func (err ReservedAppIdError) Remedy() string {
+	return "Choose another name."
+}
+
+// Registry holds the app ids the cluster's operators have reserved, which
+// are refused along with those lattice uses internally.
+type Registry []string
+
+// Check returns a ReservedAppIdError if appId is reserved.
+func (registry Registry) Check(appId string) error {
+	if reason, ok := latticeAppIds[appId]; ok {
+		return ReservedAppIdError{AppId: appId, Reason: reason}
+	}
+	for _, reserved := range registry {
+		if appId == reserved {
+			return ReservedAppIdError{AppId: appId, Reason: "It is reserved for this cluster; see ltc config get reserved-names."}
+		}
+	}
+	return nil
+}
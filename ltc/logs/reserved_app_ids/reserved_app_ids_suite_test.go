@@ -0,0 +1,13 @@
+package reserved_app_ids_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestReservedAppIds(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReservedAppIds Suite")
+}
@@ -0,0 +1,32 @@
+package reserved_app_ids_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+)
+
+var _ = Describe("ReservedAppIds", func() {
+	Describe("Registry", func() {
+		It("refuses the app ids lattice uses internally", func() {
+			registry := reserved_app_ids.Registry{"billing"}
+
+			Expect(registry.Check("lattice-debug")).To(MatchError("lattice-debug is a reserved app name. It is used internally to stream debug logs for lattice components."))
+			Expect(registry.Check("lattice-secrets")).To(MatchError("lattice-secrets is a reserved app name. It is used internally to store secrets."))
+		})
+
+		It("refuses the app ids reserved for the cluster", func() {
+			registry := reserved_app_ids.Registry{"billing", "admin"}
+
+			err := registry.Check("admin")
+			Expect(err).To(MatchError("admin is a reserved app name. It is reserved for this cluster; see ltc config get reserved-names."))
+			Expect(err).To(BeAssignableToTypeOf(reserved_app_ids.ReservedAppIdError{}))
+		})
+
+		It("accepts other app ids", func() {
+			Expect(reserved_app_ids.Registry{"billing"}.Check("my-app")).To(Succeed())
+			Expect(reserved_app_ids.Registry(nil).Check("billing")).To(Succeed())
+		})
+	})
+})
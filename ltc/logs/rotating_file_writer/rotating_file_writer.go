@@ -0,0 +1,94 @@
+package rotating_file_writer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFileWriter appends to a file, moving it aside to FILE.1 once it
+// would grow past maxSize bytes.  Older files shift along to FILE.2 and so on,
+// keeping at most maxBackups of them.  A maxSize of 0 never rotates.
+type RotatingFileWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func New(path string, maxSize int64, maxBackups int) (*RotatingFileWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &RotatingFileWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		file:       file,
+		size:       fileInfo.Size(),
+	}, nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingFileWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	if w.maxBackups > 0 {
+		for backup := w.maxBackups - 1; backup > 0; backup-- {
+			err := os.Rename(w.backupPath(backup), w.backupPath(backup+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(w.path, w.backupPath(1)); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.size = 0
+	return nil
+}
+
+func (w *RotatingFileWriter) backupPath(backup int) string {
+	return fmt.Sprintf("%s.%d", w.path, backup)
+}
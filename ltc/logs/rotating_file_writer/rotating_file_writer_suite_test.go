@@ -0,0 +1,13 @@
+package rotating_file_writer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RotatingFileWriter Suite")
+}
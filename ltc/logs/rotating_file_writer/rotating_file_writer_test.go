@@ -0,0 +1,98 @@
+package rotating_file_writer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/rotating_file_writer"
+)
+
+var _ = Describe("RotatingFileWriter", func() {
+	var (
+		tmpDir  string
+		logPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "rotating_file_writer")
+		Expect(err).NotTo(HaveOccurred())
+		logPath = filepath.Join(tmpDir, "app.log")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	readFile := func(path string) string {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	write := func(writer *rotating_file_writer.RotatingFileWriter, line string) {
+		_, err := writer.Write([]byte(line))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("appends to the file", func() {
+		Expect(ioutil.WriteFile(logPath, []byte("old\n"), 0644)).To(Succeed())
+
+		writer, err := rotating_file_writer.New(logPath, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		write(writer, "new\n")
+		Expect(writer.Close()).To(Succeed())
+
+		Expect(readFile(logPath)).To(Equal("old\nnew\n"))
+	})
+
+	It("rotates the file once it would grow past the max size", func() {
+		writer, err := rotating_file_writer.New(logPath, 10, 2)
+		Expect(err).NotTo(HaveOccurred())
+		defer writer.Close()
+
+		write(writer, "line 1\n")
+		write(writer, "line 2\n")
+		Expect(readFile(logPath)).To(Equal("line 2\n"))
+		Expect(readFile(logPath + ".1")).To(Equal("line 1\n"))
+
+		write(writer, "line 3\n")
+		write(writer, "line 4\n")
+		Expect(readFile(logPath)).To(Equal("line 4\n"))
+		Expect(readFile(logPath + ".1")).To(Equal("line 3\n"))
+		Expect(readFile(logPath + ".2")).To(Equal("line 2\n"))
+		Expect(filepath.Join(tmpDir, "app.log.3")).NotTo(BeAnExistingFile())
+	})
+
+	It("counts existing contents towards the max size", func() {
+		Expect(ioutil.WriteFile(logPath, []byte("old line\n"), 0644)).To(Succeed())
+
+		writer, err := rotating_file_writer.New(logPath, 10, 1)
+		Expect(err).NotTo(HaveOccurred())
+		defer writer.Close()
+
+		write(writer, "new\n")
+		Expect(readFile(logPath)).To(Equal("new\n"))
+		Expect(readFile(logPath + ".1")).To(Equal("old line\n"))
+	})
+
+	It("truncates in place when no backups are kept", func() {
+		writer, err := rotating_file_writer.New(logPath, 10, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer writer.Close()
+
+		write(writer, "line 1\n")
+		write(writer, "line 2\n")
+		Expect(readFile(logPath)).To(Equal("line 2\n"))
+		Expect(logPath + ".1").NotTo(BeAnExistingFile())
+	})
+
+	It("returns an error when the file cannot be opened", func() {
+		_, err := rotating_file_writer.New(filepath.Join(tmpDir, "missing", "app.log"), 0, 0)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,94 @@
+package lrp_annotation
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// The keys ltc keeps its state under in a desired LRP's annotation.
+const (
+	StoppedInstancesKey = "stopped_instances"
+	UDPPortsKey         = "udp_ports"
+	LabelsKey           = "labels"
+	LogDrainsKey        = "log_drains"
+	DeploymentKey       = "deployment"
+)
+
+var errNotAnObject = errors.New("the annotation is not a JSON object")
+
+// Annotation is the JSON object ltc keeps in a desired LRP's annotation, by
+// key, so that changing one key leaves the others, including any ltc
+// doesn't know about, as they are.
+type Annotation map[string]json.RawMessage
+
+// Parse reads an annotation, failing unless it is a JSON object.  An empty
+// annotation is an empty object.
+func Parse(data string) (Annotation, error) {
+	annotation := Annotation{}
+	if data == "" {
+		return annotation, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &annotation); err != nil {
+		return nil, err
+	}
+	if annotation == nil {
+		return nil, errNotAnObject
+	}
+	return annotation, nil
+}
+
+// String is the annotation as JSON, or empty when it has no keys.
+func (annotation Annotation) String() string {
+	if len(annotation) == 0 {
+		return ""
+	}
+
+	data, _ := json.Marshal(annotation)
+	return string(data)
+}
+
+// Deployment is what the annotation says about when the app was created and
+// last updated, and by whom.
+func (annotation Annotation) Deployment() Deployment {
+	var deployment Deployment
+	if data, ok := annotation[DeploymentKey]; ok {
+		json.Unmarshal(data, &deployment)
+	}
+	return deployment
+}
+
+// SetDeployment replaces what the annotation says about the deployment.
+func (annotation Annotation) SetDeployment(deployment Deployment) {
+	annotation[DeploymentKey], _ = json.Marshal(deployment)
+}
+
+// Deployment is kept under DeploymentKey: when the app was created and last
+// updated, and by whom.
+type Deployment struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// Fields is what ltc reads back from an app's annotation: its labels, the
+// drains its logs are forwarded to, which of its ports are udp, since a
+// desired LRP's ports have no protocol, and when it was deployed.
+type Fields struct {
+	Labels     map[string]string `json:"labels"`
+	LogDrains  []string          `json:"log_drains"`
+	UDPPorts   []uint16          `json:"udp_ports"`
+	Deployment Deployment        `json:"deployment"`
+}
+
+// ParseFields reads the fields of an annotation.  Annotations ltc didn't
+// write have none.
+func ParseFields(data string) Fields {
+	var fields Fields
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return Fields{}
+	}
+	return fields
+}
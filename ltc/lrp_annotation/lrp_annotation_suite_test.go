@@ -0,0 +1,13 @@
+package lrp_annotation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLrpAnnotation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LrpAnnotation Suite")
+}
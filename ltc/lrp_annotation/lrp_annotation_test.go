@@ -0,0 +1,72 @@
+package lrp_annotation_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/lrp_annotation"
+)
+
+var _ = Describe("LrpAnnotation", func() {
+	Describe("Parse", func() {
+		It("keeps keys ltc doesn't know about", func() {
+			annotation, err := lrp_annotation.Parse(`{"labels":{"tier":"web"},"build":42}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			delete(annotation, lrp_annotation.LabelsKey)
+			Expect(annotation.String()).To(MatchJSON(`{"build":42}`))
+		})
+
+		It("reads an empty annotation as an empty object", func() {
+			annotation, err := lrp_annotation.Parse("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotation).To(BeEmpty())
+			Expect(annotation.String()).To(BeEmpty())
+		})
+
+		It("fails for annotations that aren't JSON objects", func() {
+			_, err := lrp_annotation.Parse("some notes")
+			Expect(err).To(HaveOccurred())
+
+			_, err = lrp_annotation.Parse("null")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Deployment", func() {
+		It("reads and replaces the deployment", func() {
+			annotation, err := lrp_annotation.Parse(`{"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice"}}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := annotation.Deployment()
+			Expect(deployment.CreatedAt.Equal(time.Date(2015, 7, 1, 9, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(deployment.CreatedBy).To(Equal("alice"))
+
+			updatedAt := time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC)
+			deployment.UpdatedAt, deployment.UpdatedBy = &updatedAt, "bob"
+			annotation.SetDeployment(deployment)
+			Expect(annotation.String()).To(MatchJSON(`{"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice","updated_at":"2015-07-04T12:00:00Z","updated_by":"bob"}}`))
+		})
+
+		It("is zero when the annotation has none", func() {
+			Expect(lrp_annotation.Annotation{}.Deployment()).To(BeZero())
+		})
+	})
+
+	Describe("ParseFields", func() {
+		It("reads the fields ltc writes", func() {
+			fields := lrp_annotation.ParseFields(`{"labels":{"tier":"web"},"log_drains":["syslog://logs.example.com:514"],"udp_ports":[53],"deployment":{"created_by":"alice"}}`)
+
+			Expect(fields.Labels).To(Equal(map[string]string{"tier": "web"}))
+			Expect(fields.LogDrains).To(Equal([]string{"syslog://logs.example.com:514"}))
+			Expect(fields.UDPPorts).To(Equal([]uint16{53}))
+			Expect(fields.Deployment.CreatedBy).To(Equal("alice"))
+		})
+
+		It("has none for annotations ltc didn't write", func() {
+			Expect(lrp_annotation.ParseFields("some notes")).To(BeZero())
+		})
+	})
+})
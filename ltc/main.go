@@ -10,10 +10,12 @@ func main() {
 	defer os.Stdout.Write([]byte("\n"))
 	var badFlags string
 	cliApp := setup_cli.NewCliApp()
+	args := setup_cli.ExpandAlias(cliApp, setup_cli.LoadAliases(), os.Args)
 
-	if len(os.Args) > 1 {
-		flags := setup_cli.GetCommandFlags(cliApp, os.Args[1])
-		badFlags = setup_cli.MatchArgAndFlags(flags, os.Args[2:])
+	commandArgs := setup_cli.SkipGlobalFlags(cliApp, args[1:])
+	if len(commandArgs) > 0 {
+		flags := setup_cli.GetCommandFlags(cliApp, commandArgs[0])
+		badFlags = setup_cli.MatchArgAndFlags(flags, commandArgs[1:])
 		if badFlags != "" {
 			badFlags = badFlags + "\n\n"
 		}
@@ -21,9 +23,9 @@ func main() {
 
 	setup_cli.InjectHelpTemplate(badFlags)
 
-	if len(os.Args) == 1 || os.Args[1] == "help" || os.Args[1] == "h" || setup_cli.RequestHelp(os.Args[1:]) {
-		cliApp.Run(os.Args)
+	if len(commandArgs) == 0 || commandArgs[0] == "help" || commandArgs[0] == "h" || setup_cli.RequestHelp(commandArgs) {
+		cliApp.Run(args)
 	} else {
-		setup_cli.CallCoreCommand(os.Args[0:], cliApp)
+		setup_cli.CallCoreCommand(args, cliApp)
 	}
 }
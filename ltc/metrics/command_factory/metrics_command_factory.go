@@ -0,0 +1,160 @@
+package command_factory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/bytefmt"
+	"github.com/pivotal-golang/clock"
+)
+
+type MetricsCommandFactory struct {
+	appExaminer   app_examiner.AppExaminer
+	metricsReader metrics.MetricsReader
+	ui            terminal.UI
+	clock         clock.Clock
+	exitHandler   exit_handler.ExitHandler
+}
+
+func NewMetricsCommandFactory(appExaminer app_examiner.AppExaminer, metricsReader metrics.MetricsReader, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler) *MetricsCommandFactory {
+	return &MetricsCommandFactory{appExaminer, metricsReader, ui, clock, exitHandler}
+}
+
+func (factory *MetricsCommandFactory) MakeMetricsCommand() cli.Command {
+	var metricsFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json, j",
+			Usage: "Prints each metric as a line of JSON",
+		},
+	}
+
+	var metricsCommand = cli.Command{
+		Name:    "metrics",
+		Aliases: []string{"mt"},
+		Usage:   "Streams CPU, memory and disk usage for each instance of an app",
+		Description: `ltc metrics APP_NAME [--json]
+
+   On a terminal, shows a table of the latest usage of every instance that
+   updates as lattice reports new metrics.  Otherwise, or with --json,
+   prints one line per report.`,
+		Action: factory.streamMetrics,
+		Flags:  metricsFlags,
+	}
+
+	return metricsCommand
+}
+
+func (factory *MetricsCommandFactory) streamMetrics(context *cli.Context) {
+	jsonFlag := context.Bool("json")
+
+	if len(context.Args()) == 0 {
+		factory.ui.SayIncorrectUsage("APP_NAME required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appName, err := app_examiner.NewNameResolver(factory.appExaminer).ResolveOne(context.Args().First())
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if appExists, err := factory.appExaminer.AppExists(appName); err != nil {
+		factory.ui.SayLine("Error: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	} else if !appExists {
+		factory.ui.SayLine(fmt.Sprintf("Application %s not found.", appName))
+		factory.exitHandler.Exit(exit_codes.NotFound)
+		return
+	}
+
+	factory.exitHandler.OnExit(func() {
+		factory.metricsReader.StopStreaming()
+	})
+
+	var metricsCallback func(metrics.InstanceMetrics)
+	errorCallback := func(err error) {
+		factory.ui.SayLine("Error streaming metrics: " + err.Error())
+	}
+
+	switch {
+	case jsonFlag:
+		metricsCallback = factory.sayJSON
+	case factory.ui.IsTerminal():
+		table := &metricsTable{factory: factory, latest: make(map[int]metrics.InstanceMetrics)}
+		metricsCallback = table.update
+		errorCallback = func(err error) {
+			factory.ui.SayLine("Error streaming metrics: " + err.Error())
+			table.linesWritten = 0
+		}
+	default:
+		metricsCallback = factory.sayMetrics
+	}
+
+	factory.metricsReader.StreamMetrics(appName, metricsCallback, errorCallback)
+}
+
+func (factory *MetricsCommandFactory) sayJSON(instanceMetrics metrics.InstanceMetrics) {
+	metricsJson, err := json.Marshal(instanceMetrics)
+	if err != nil {
+		return
+	}
+	factory.ui.SayLine(string(metricsJson))
+}
+
+func (factory *MetricsCommandFactory) sayMetrics(instanceMetrics metrics.InstanceMetrics) {
+	timeString := instanceMetrics.Timestamp.Format("01/02 15:04:05.00")
+	source := fmt.Sprintf("%s|%d", instanceMetrics.AppName, instanceMetrics.Index)
+	factory.ui.SayLine(fmt.Sprintf("%s [%s] CPU: %s Memory: %s Disk: %s", colors.Cyan(timeString), colors.Yellow(source),
+		formatCpu(instanceMetrics), bytefmt.ByteSize(instanceMetrics.MemoryBytes), bytefmt.ByteSize(instanceMetrics.DiskBytes)))
+}
+
+// metricsTable keeps the latest metrics reported for each instance and
+// redraws them in place whenever one changes.
+type metricsTable struct {
+	factory      *MetricsCommandFactory
+	latest       map[int]metrics.InstanceMetrics
+	linesWritten int
+}
+
+func (t *metricsTable) update(instanceMetrics metrics.InstanceMetrics) {
+	t.latest[instanceMetrics.Index] = instanceMetrics
+
+	indices := make([]int, 0, len(t.latest))
+	for index := range t.latest {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	ui := t.factory.ui
+	if t.linesWritten > 0 {
+		ui.Say(cursor.Up(t.linesWritten))
+	}
+	ui.Say(cursor.ClearToEndOfDisplay())
+
+	w := tabwriter.NewWriter(ui, 10, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "Instance", "CPU", "Memory", "Disk")
+	for _, index := range indices {
+		instanceMetrics := t.latest[index]
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", index, formatCpu(instanceMetrics), bytefmt.ByteSize(instanceMetrics.MemoryBytes), bytefmt.ByteSize(instanceMetrics.DiskBytes))
+	}
+	w.Flush()
+
+	t.linesWritten = len(indices) + 1
+}
+
+func formatCpu(instanceMetrics metrics.InstanceMetrics) string {
+	return fmt.Sprintf("%.2f%%", instanceMetrics.CpuPercentage)
+}
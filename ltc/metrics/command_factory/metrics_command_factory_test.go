@@ -0,0 +1,151 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics/fake_metrics_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+type ttyUI struct {
+	terminal.UI
+}
+
+func (ttyUI) IsTerminal() bool {
+	return true
+}
+
+var _ = Describe("MetricsCommandFactory", func() {
+	var (
+		appExaminer       *fake_app_examiner.FakeAppExaminer
+		fakeMetricsReader *fake_metrics_reader.FakeMetricsReader
+		outputBuffer      *gbytes.Buffer
+		terminalUI        terminal.UI
+		fakeExitHandler   *fake_exit_handler.FakeExitHandler
+		reported          []metrics.InstanceMetrics
+		metricsCommand    cli.Command
+	)
+
+	BeforeEach(func() {
+		appExaminer = &fake_app_examiner.FakeAppExaminer{}
+		appExaminer.AppExistsReturns(true, nil)
+		fakeMetricsReader = &fake_metrics_reader.FakeMetricsReader{}
+		outputBuffer = gbytes.NewBuffer()
+		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		timestamp := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+		reported = []metrics.InstanceMetrics{
+			{AppName: "cool-app", Index: 1, CpuPercentage: 12.5, MemoryBytes: 64 * 1024 * 1024, DiskBytes: 1024 * 1024 * 1024, Timestamp: timestamp},
+			{AppName: "cool-app", Index: 0, CpuPercentage: 3, MemoryBytes: 32 * 1024 * 1024, DiskBytes: 512 * 1024 * 1024, Timestamp: timestamp},
+		}
+		fakeMetricsReader.StreamMetricsStub = func(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error)) {
+			for _, instanceMetrics := range reported {
+				metricsCallback(instanceMetrics)
+			}
+		}
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewMetricsCommandFactory(appExaminer, fakeMetricsReader, terminalUI, fakeclock.NewFakeClock(time.Now()), fakeExitHandler)
+		metricsCommand = commandFactory.MakeMetricsCommand()
+	})
+
+	It("prints a line for each metric reported", func() {
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+		Expect(fakeMetricsReader.StreamMetricsCallCount()).To(Equal(1))
+		appGuid, _, _ := fakeMetricsReader.StreamMetricsArgsForCall(0)
+		Expect(appGuid).To(Equal("cool-app"))
+
+		Expect(outputBuffer).To(test_helpers.Say("06/01 12:30:00.00"))
+		Expect(outputBuffer).To(test_helpers.Say("cool-app|1"))
+		Expect(outputBuffer).To(test_helpers.SayLine("CPU: 12.50% Memory: 64M Disk: 1G"))
+		Expect(outputBuffer).To(test_helpers.Say("cool-app|0"))
+		Expect(outputBuffer).To(test_helpers.SayLine("CPU: 3.00% Memory: 32M Disk: 512M"))
+	})
+
+	It("prints each metric as JSON with --json", func() {
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"--json", "cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine(`{"app_name":"cool-app","index":1,"cpu_percentage":12.5,"memory_bytes":67108864,"disk_bytes":1073741824,"timestamp":"2015-06-01T12:30:00Z"}`))
+		Expect(outputBuffer).To(test_helpers.SayLine(`{"app_name":"cool-app","index":0,"cpu_percentage":3,"memory_bytes":33554432,"disk_bytes":536870912,"timestamp":"2015-06-01T12:30:00Z"}`))
+	})
+
+	Context("on a terminal", func() {
+		BeforeEach(func() {
+			terminalUI = ttyUI{terminalUI}
+		})
+
+		It("redraws a table of the latest metrics for each instance", func() {
+			test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+			Expect(outputBuffer).To(test_helpers.Say(cursor.ClearToEndOfDisplay()))
+			Expect(outputBuffer).To(test_helpers.Say("Instance"))
+			Expect(outputBuffer).To(test_helpers.Say("12.50%"))
+			Expect(outputBuffer).To(test_helpers.Say(cursor.Up(2)))
+			Expect(outputBuffer).To(test_helpers.Say("Instance"))
+			Expect(outputBuffer).To(test_helpers.Say("3.00%"))
+			Expect(outputBuffer).To(test_helpers.Say("12.50%"))
+		})
+	})
+
+	It("prints errors from the metrics stream", func() {
+		fakeMetricsReader.StreamMetricsStub = func(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error)) {
+			errorCallback(errors.New("websocket closed"))
+		}
+
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error streaming metrics: websocket closed"))
+	})
+
+	It("stops streaming on exit", func() {
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+		fakeExitHandler.Exit(exit_codes.SigInt)
+
+		Expect(fakeMetricsReader.StopStreamingCallCount()).To(Equal(1))
+	})
+
+	It("requires an app name", func() {
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		Expect(fakeMetricsReader.StreamMetricsCallCount()).To(BeZero())
+	})
+
+	It("reports apps that do not exist", func() {
+		appExaminer.AppExistsReturns(false, nil)
+
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Application cool-app not found."))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		Expect(fakeMetricsReader.StreamMetricsCallCount()).To(BeZero())
+	})
+
+	It("reports errors checking whether the app exists", func() {
+		appExaminer.AppExistsReturns(false, errors.New("receptor down"))
+
+		test_helpers.ExecuteCommandWithArgs(metricsCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error: receptor down"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+})
@@ -0,0 +1,203 @@
+package command_factory
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/bytefmt"
+)
+
+const (
+	DefaultRecommendWindow = 5 * time.Minute
+
+	// recommendHeadroom is how much more than the peak usage an app is
+	// given, for busier times than the window saw.
+	recommendHeadroom = 1.25
+
+	memoryStepMB = 32
+	diskStepMB   = 64
+)
+
+func (factory *MetricsCommandFactory) MakeRecommendCommand() cli.Command {
+	var recommendFlags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  "window, w",
+			Usage: "How long to collect metrics for",
+			Value: DefaultRecommendWindow,
+		},
+	}
+
+	var recommendCommand = cli.Command{
+		Name:  "recommend",
+		Usage: "Suggests memory, disk and CPU weight for an app from its usage",
+		Description: `ltc recommend APP_NAME [--window=5m]
+
+   Collects the app's metrics for the window, then suggests limits 25%
+   above the peak memory and disk usage of any instance, and a CPU weight
+   in proportion to its peak CPU usage, along with the 'ltc resize'
+   command that applies them.  Run it while the app is under its usual
+   load, with a longer window to cover busier periods.`,
+		Action: factory.recommend,
+		Flags:  recommendFlags,
+	}
+
+	return recommendCommand
+}
+
+func (factory *MetricsCommandFactory) recommend(context *cli.Context) {
+	window := context.Duration("window")
+
+	if len(context.Args()) != 1 {
+		factory.ui.SayIncorrectUsage("Please enter 'ltc recommend APP_NAME [--window=5m]'")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	} else if window <= 0 {
+		factory.ui.SayIncorrectUsage("--window must be positive")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	appName, err := app_examiner.NewNameResolver(factory.appExaminer).ResolveOne(context.Args().First())
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	appInfo, err := factory.appExaminer.AppStatus(appName)
+	if err != nil {
+		factory.ui.SayLine("Error: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	factory.exitHandler.OnExit(func() {
+		factory.metricsReader.StopStreaming()
+	})
+
+	factory.ui.SayLine(fmt.Sprintf("Collecting metrics for %s for %s...", appName, window))
+
+	done := make(chan struct{})
+	defer close(done)
+	timer := factory.clock.NewTimer(window)
+	go func() {
+		select {
+		case <-timer.C():
+			factory.metricsReader.StopStreaming()
+		case <-done:
+			timer.Stop()
+		}
+	}()
+
+	peak := peakUsage{instances: make(map[int]struct{})}
+	factory.metricsReader.StreamMetrics(appName, peak.add, func(err error) {
+		factory.ui.SayLine("Error streaming metrics: " + err.Error())
+	})
+
+	if peak.reports == 0 {
+		factory.ui.SayLine(fmt.Sprintf("No metrics were reported for %s.  Recommendations need running instances.", appName))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	recommended := peak.recommend()
+	factory.ui.SayNewLine()
+	factory.ui.SayLine(fmt.Sprintf("Peak usage of %s over %s, from %d reports by %d instances:", appName, window, peak.reports, len(peak.instances)))
+	factory.ui.SayNewLine()
+
+	w := tabwriter.NewWriter(factory.ui, 10, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "", "Peak", "Current", "Recommended")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d MB\n", "Memory", bytefmt.ByteSize(peak.memoryBytes), formatLimitMB(appInfo.MemoryMB), recommended.memoryMB)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d MB\n", "Disk", bytefmt.ByteSize(peak.diskBytes), formatLimitMB(appInfo.DiskMB), recommended.diskMB)
+	fmt.Fprintf(w, "%s\t%.2f%%\t%d\t%d\n", "CPU weight", peak.cpuPercentage, appInfo.CPUWeight, recommended.cpuWeight)
+	w.Flush()
+	factory.ui.SayNewLine()
+
+	resizeFlags := []string{}
+	if recommended.memoryMB != appInfo.MemoryMB {
+		resizeFlags = append(resizeFlags, fmt.Sprintf("--memory-mb=%d", recommended.memoryMB))
+	}
+	if recommended.diskMB != appInfo.DiskMB {
+		resizeFlags = append(resizeFlags, fmt.Sprintf("--disk-mb=%d", recommended.diskMB))
+	}
+	if recommended.cpuWeight != appInfo.CPUWeight {
+		resizeFlags = append(resizeFlags, fmt.Sprintf("--cpu-weight=%d", recommended.cpuWeight))
+	}
+
+	if len(resizeFlags) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("%s already has the recommended limits.", appName))
+		return
+	}
+	factory.ui.SayLine(fmt.Sprintf("To apply them, run: ltc resize %s %s", appName, strings.Join(resizeFlags, " ")))
+}
+
+// peakUsage keeps the highest usage reported by any instance of an app.
+type peakUsage struct {
+	reports       int
+	instances     map[int]struct{}
+	memoryBytes   uint64
+	diskBytes     uint64
+	cpuPercentage float64
+}
+
+func (p *peakUsage) add(instanceMetrics metrics.InstanceMetrics) {
+	p.reports++
+	p.instances[instanceMetrics.Index] = struct{}{}
+	if instanceMetrics.MemoryBytes > p.memoryBytes {
+		p.memoryBytes = instanceMetrics.MemoryBytes
+	}
+	if instanceMetrics.DiskBytes > p.diskBytes {
+		p.diskBytes = instanceMetrics.DiskBytes
+	}
+	if instanceMetrics.CpuPercentage > p.cpuPercentage {
+		p.cpuPercentage = instanceMetrics.CpuPercentage
+	}
+}
+
+type recommendation struct {
+	memoryMB  int
+	diskMB    int
+	cpuWeight uint
+}
+
+// recommend adds headroom to the peak usage, and rounds memory and disk up
+// to whole steps so that small changes in usage don't change the advice.
+// CPU percentages are of one core, so an app that keeps a core busy gets
+// the highest weight, 100.
+func (p *peakUsage) recommend() recommendation {
+	cpuWeight := math.Ceil(p.cpuPercentage * recommendHeadroom)
+	if cpuWeight < 1 {
+		cpuWeight = 1
+	} else if cpuWeight > 100 {
+		cpuWeight = 100
+	}
+
+	return recommendation{
+		memoryMB:  roundUpMB(p.memoryBytes, memoryStepMB),
+		diskMB:    roundUpMB(p.diskBytes, diskStepMB),
+		cpuWeight: uint(cpuWeight),
+	}
+}
+
+func roundUpMB(peakBytes uint64, stepMB int) int {
+	withHeadroomMB := float64(peakBytes) * recommendHeadroom / bytefmt.MEGABYTE
+	steps := int(math.Ceil(withHeadroomMB / float64(stepMB)))
+	if steps < 1 {
+		steps = 1
+	}
+	return steps * stepMB
+}
+
+func formatLimitMB(limitMB int) string {
+	if limitMB == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d MB", limitMB)
+}
@@ -0,0 +1,163 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics/fake_metrics_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("RecommendCommand", func() {
+	var (
+		appExaminer       *fake_app_examiner.FakeAppExaminer
+		fakeMetricsReader *fake_metrics_reader.FakeMetricsReader
+		outputBuffer      *gbytes.Buffer
+		fakeClock         *fakeclock.FakeClock
+		fakeExitHandler   *fake_exit_handler.FakeExitHandler
+		reported          []metrics.InstanceMetrics
+		recommendCommand  cli.Command
+	)
+
+	BeforeEach(func() {
+		appExaminer = &fake_app_examiner.FakeAppExaminer{}
+		appExaminer.AppExistsReturns(true, nil)
+		appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-app", MemoryMB: 128, DiskMB: 1024, CPUWeight: 100}, nil)
+		fakeMetricsReader = &fake_metrics_reader.FakeMetricsReader{}
+		outputBuffer = gbytes.NewBuffer()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+
+		reported = []metrics.InstanceMetrics{
+			{AppName: "cool-app", Index: 0, CpuPercentage: 3, MemoryBytes: 32 * 1024 * 1024, DiskBytes: 512 * 1024 * 1024},
+			{AppName: "cool-app", Index: 1, CpuPercentage: 12.5, MemoryBytes: 64 * 1024 * 1024, DiskBytes: 1024 * 1024 * 1024},
+			{AppName: "cool-app", Index: 0, CpuPercentage: 4, MemoryBytes: 48 * 1024 * 1024, DiskBytes: 512 * 1024 * 1024},
+		}
+		fakeMetricsReader.StreamMetricsStub = func(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error)) {
+			for _, instanceMetrics := range reported {
+				metricsCallback(instanceMetrics)
+			}
+		}
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewMetricsCommandFactory(appExaminer, fakeMetricsReader, terminal.NewUI(nil, outputBuffer, nil), fakeClock, fakeExitHandler)
+		recommendCommand = commandFactory.MakeRecommendCommand()
+	})
+
+	It("recommends limits above the peak usage and how to apply them", func() {
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(appExaminer.AppStatusArgsForCall(0)).To(Equal("cool-app"))
+		appGuid, _, _ := fakeMetricsReader.StreamMetricsArgsForCall(0)
+		Expect(appGuid).To(Equal("cool-app"))
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Collecting metrics for cool-app for 5m0s..."))
+		Expect(outputBuffer).To(test_helpers.SayLine("Peak usage of cool-app over 5m0s, from 3 reports by 2 instances:"))
+		Expect(outputBuffer).To(test_helpers.SayLine("            Peak      Current   Recommended"))
+		Expect(outputBuffer).To(test_helpers.SayLine("Memory      64M       128 MB    96 MB"))
+		Expect(outputBuffer).To(test_helpers.SayLine("Disk        1G        1024 MB   1280 MB"))
+		Expect(outputBuffer).To(test_helpers.SayLine("CPU weight  12.50%    100       16"))
+		Expect(outputBuffer).To(test_helpers.SayLine("To apply them, run: ltc resize cool-app --memory-mb=96 --disk-mb=1280 --cpu-weight=16"))
+		Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+	})
+
+	It("collects metrics for the window", func() {
+		stopped := make(chan struct{})
+		fakeMetricsReader.StopStreamingStub = func() {
+			close(stopped)
+		}
+		fakeMetricsReader.StreamMetricsStub = func(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error)) {
+			metricsCallback(reported[0])
+			<-stopped
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app", "--window=1m"})
+		}()
+
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		fakeClock.Increment(59 * time.Second)
+		Consistently(done).ShouldNot(BeClosed())
+
+		fakeClock.Increment(time.Second)
+		Eventually(done).Should(BeClosed())
+		Expect(outputBuffer).To(test_helpers.SayLine("Peak usage of cool-app over 1m0s, from 1 reports by 1 instances:"))
+	})
+
+	It("only passes the limits that change to ltc resize", func() {
+		appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-app", MemoryMB: 96, DiskMB: 1280, CPUWeight: 50}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("To apply them, run: ltc resize cool-app --cpu-weight=16"))
+	})
+
+	It("says when the app already has the recommended limits", func() {
+		appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-app", MemoryMB: 96, DiskMB: 1280, CPUWeight: 16}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("cool-app already has the recommended limits."))
+		Expect(outputBuffer).NotTo(test_helpers.Say("ltc resize"))
+	})
+
+	It("recommends at least the smallest limits", func() {
+		reported = []metrics.InstanceMetrics{{AppName: "cool-app", Index: 0}}
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("To apply them, run: ltc resize cool-app --memory-mb=32 --disk-mb=64 --cpu-weight=1"))
+	})
+
+	It("shows limits that aren't set as none", func() {
+		appExaminer.AppStatusReturns(app_examiner.AppInfo{ProcessGuid: "cool-app", CPUWeight: 100}, nil)
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Memory      64M       none      96 MB"))
+	})
+
+	It("exits when no metrics are reported", func() {
+		reported = nil
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("No metrics were reported for cool-app.  Recommendations need running instances."))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+
+	It("exits when the app doesn't exist", func() {
+		appExaminer.AppStatusReturns(app_examiner.AppInfo{}, errors.New(app_examiner.AppNotFoundErrorMessage))
+
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app"})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error: " + app_examiner.AppNotFoundErrorMessage))
+		Expect(fakeMetricsReader.StreamMetricsCallCount()).To(BeZero())
+	})
+
+	It("validates its arguments", func() {
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{})
+		test_helpers.ExecuteCommandWithArgs(recommendCommand, []string{"cool-app", "--window=0s"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: Please enter 'ltc recommend APP_NAME [--window=5m]'"))
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --window must be positive"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+		Expect(fakeMetricsReader.StreamMetricsCallCount()).To(BeZero())
+	})
+})
@@ -0,0 +1,63 @@
+// This file was generated by counterfeiter
+package fake_metrics_reader
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+)
+
+type FakeMetricsReader struct {
+	StreamMetricsStub        func(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error))
+	streamMetricsMutex       sync.RWMutex
+	streamMetricsArgsForCall []struct {
+		appGuid         string
+		metricsCallback func(metrics.InstanceMetrics)
+		errorCallback   func(error)
+	}
+	StopStreamingStub        func()
+	stopStreamingMutex       sync.RWMutex
+	stopStreamingArgsForCall []struct{}
+}
+
+func (fake *FakeMetricsReader) StreamMetrics(appGuid string, metricsCallback func(metrics.InstanceMetrics), errorCallback func(error)) {
+	fake.streamMetricsMutex.Lock()
+	fake.streamMetricsArgsForCall = append(fake.streamMetricsArgsForCall, struct {
+		appGuid         string
+		metricsCallback func(metrics.InstanceMetrics)
+		errorCallback   func(error)
+	}{appGuid, metricsCallback, errorCallback})
+	fake.streamMetricsMutex.Unlock()
+	if fake.StreamMetricsStub != nil {
+		fake.StreamMetricsStub(appGuid, metricsCallback, errorCallback)
+	}
+}
+
+func (fake *FakeMetricsReader) StreamMetricsCallCount() int {
+	fake.streamMetricsMutex.RLock()
+	defer fake.streamMetricsMutex.RUnlock()
+	return len(fake.streamMetricsArgsForCall)
+}
+
+func (fake *FakeMetricsReader) StreamMetricsArgsForCall(i int) (string, func(metrics.InstanceMetrics), func(error)) {
+	fake.streamMetricsMutex.RLock()
+	defer fake.streamMetricsMutex.RUnlock()
+	return fake.streamMetricsArgsForCall[i].appGuid, fake.streamMetricsArgsForCall[i].metricsCallback, fake.streamMetricsArgsForCall[i].errorCallback
+}
+
+func (fake *FakeMetricsReader) StopStreaming() {
+	fake.stopStreamingMutex.Lock()
+	fake.stopStreamingArgsForCall = append(fake.stopStreamingArgsForCall, struct{}{})
+	fake.stopStreamingMutex.Unlock()
+	if fake.StopStreamingStub != nil {
+		fake.StopStreamingStub()
+	}
+}
+
+func (fake *FakeMetricsReader) StopStreamingCallCount() int {
+	fake.stopStreamingMutex.RLock()
+	defer fake.stopStreamingMutex.RUnlock()
+	return len(fake.stopStreamingArgsForCall)
+}
+
+var _ metrics.MetricsReader = new(FakeMetricsReader)
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/noaa/events"
+)
+
+type InstanceMetrics struct {
+	AppName       string    `json:"app_name"`
+	Index         int       `json:"index"`
+	CpuPercentage float64   `json:"cpu_percentage"`
+	MemoryBytes   uint64    `json:"memory_bytes"`
+	DiskBytes     uint64    `json:"disk_bytes"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+//go:generate counterfeiter -o fake_metrics_reader/fake_metrics_reader.go . MetricsReader
+type MetricsReader interface {
+	StreamMetrics(appGuid string, metricsCallback func(InstanceMetrics), errorCallback func(error))
+	StopStreaming()
+}
+
+type metricsConsumer interface {
+	Stream(appGuid string, authToken string, outputChan chan<- *events.Envelope, errorChan chan<- error, stopChan chan struct{})
+}
+
+type metricsReader struct {
+	consumer metricsConsumer
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func NewMetricsReader(consumer metricsConsumer) MetricsReader {
+	return &metricsReader{
+		consumer: consumer,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// StreamMetrics calls metricsCallback with each container metric lattice
+// reports for the app until StopStreaming is called.
+func (r *metricsReader) StreamMetrics(appGuid string, metricsCallback func(InstanceMetrics), errorCallback func(error)) {
+	outputChan := make(chan *events.Envelope, 10)
+	errorChan := make(chan error, 10)
+
+	go r.consumer.Stream(appGuid, "", outputChan, errorChan, r.stopChan)
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+				continue
+			}
+			if err != nil {
+				errorCallback(err)
+			}
+		case envelope := <-outputChan:
+			if envelope.GetEventType() != events.Envelope_ContainerMetric {
+				continue
+			}
+			containerMetric := envelope.GetContainerMetric()
+			metricsCallback(InstanceMetrics{
+				AppName:       containerMetric.GetApplicationId(),
+				Index:         int(containerMetric.GetInstanceIndex()),
+				CpuPercentage: containerMetric.GetCpuPercentage(),
+				MemoryBytes:   containerMetric.GetMemoryBytes(),
+				DiskBytes:     containerMetric.GetDiskBytes(),
+				Timestamp:     time.Unix(0, envelope.GetTimestamp()),
+			})
+		}
+	}
+}
+
+func (r *metricsReader) StopStreaming() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
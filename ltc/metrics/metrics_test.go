@@ -0,0 +1,147 @@
+package metrics_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/metrics"
+	"github.com/cloudfoundry/noaa/events"
+	"github.com/gogo/protobuf/proto"
+)
+
+type fakeConsumer struct {
+	inboundEnvelopeStream chan *events.Envelope
+	inboundErrorStream    chan error
+}
+
+func (consumer *fakeConsumer) Stream(appGuid string, authToken string, outputChan chan<- *events.Envelope, errorChan chan<- error, stopChan chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			close(errorChan)
+			return
+		case err := <-consumer.inboundErrorStream:
+			errorChan <- err
+		case envelope := <-consumer.inboundEnvelopeStream:
+			outputChan <- envelope
+		}
+	}
+}
+
+type metricsReceiver struct {
+	sync.RWMutex
+	receivedMetrics []metrics.InstanceMetrics
+	receivedErrors  []error
+}
+
+func (r *metricsReceiver) AppendMetrics(instanceMetrics metrics.InstanceMetrics) {
+	r.Lock()
+	defer r.Unlock()
+	r.receivedMetrics = append(r.receivedMetrics, instanceMetrics)
+}
+
+func (r *metricsReceiver) AppendError(err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.receivedErrors = append(r.receivedErrors, err)
+}
+
+func (r *metricsReceiver) GetMetrics() []metrics.InstanceMetrics {
+	r.RLock()
+	defer r.RUnlock()
+	return r.receivedMetrics
+}
+
+func (r *metricsReceiver) GetErrors() []error {
+	r.RLock()
+	defer r.RUnlock()
+	return r.receivedErrors
+}
+
+func containerMetricEnvelope(appGuid string, index int32, cpu float64, memory, disk uint64) *events.Envelope {
+	return &events.Envelope{
+		Origin:    proto.String("executor"),
+		EventType: events.Envelope_ContainerMetric.Enum(),
+		Timestamp: proto.Int64(time.Unix(1, 0).UnixNano()),
+		ContainerMetric: &events.ContainerMetric{
+			ApplicationId: proto.String(appGuid),
+			InstanceIndex: proto.Int32(index),
+			CpuPercentage: proto.Float64(cpu),
+			MemoryBytes:   proto.Uint64(memory),
+			DiskBytes:     proto.Uint64(disk),
+		},
+	}
+}
+
+var _ = Describe("MetricsReader", func() {
+	var (
+		consumer      *fakeConsumer
+		metricsReader metrics.MetricsReader
+		receiver      *metricsReceiver
+		doneChan      chan struct{}
+	)
+
+	BeforeEach(func() {
+		consumer = &fakeConsumer{
+			inboundEnvelopeStream: make(chan *events.Envelope),
+			inboundErrorStream:    make(chan error),
+		}
+		metricsReader = metrics.NewMetricsReader(consumer)
+		receiver = &metricsReceiver{}
+		doneChan = make(chan struct{})
+
+		go func() {
+			metricsReader.StreamMetrics("app-guid", receiver.AppendMetrics, receiver.AppendError)
+			close(doneChan)
+		}()
+	})
+
+	AfterEach(func() {
+		metricsReader.StopStreaming()
+		Eventually(doneChan).Should(BeClosed())
+	})
+
+	It("provides the metricsCallback with container metrics", func() {
+		consumer.inboundEnvelopeStream <- containerMetricEnvelope("app-guid", 1, 12.5, 1024, 2048)
+
+		Eventually(receiver.GetMetrics).Should(Equal([]metrics.InstanceMetrics{
+			{
+				AppName:       "app-guid",
+				Index:         1,
+				CpuPercentage: 12.5,
+				MemoryBytes:   1024,
+				DiskBytes:     2048,
+				Timestamp:     time.Unix(1, 0),
+			},
+		}))
+	})
+
+	It("ignores envelopes that are not container metrics", func() {
+		consumer.inboundEnvelopeStream <- &events.Envelope{
+			Origin:    proto.String("executor"),
+			EventType: events.Envelope_LogMessage.Enum(),
+		}
+		consumer.inboundEnvelopeStream <- containerMetricEnvelope("app-guid", 0, 1, 2, 3)
+
+		Eventually(receiver.GetMetrics).Should(HaveLen(1))
+		Consistently(receiver.GetMetrics).Should(HaveLen(1))
+	})
+
+	It("provides the errorCallback with errors", func() {
+		consumer.inboundErrorStream <- errors.New("stream error")
+
+		Eventually(receiver.GetErrors).Should(Equal([]error{errors.New("stream error")}))
+	})
+
+	Describe("StopStreaming", func() {
+		It("stops streaming metrics and may be called more than once", func() {
+			metricsReader.StopStreaming()
+
+			Eventually(doneChan).Should(BeClosed())
+		})
+	})
+})
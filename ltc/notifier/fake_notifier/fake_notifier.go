@@ -0,0 +1,97 @@
+// This file was generated by counterfeiter
+package fake_notifier
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier"
+)
+
+type FakeNotifier struct {
+	NotifyDesktopStub        func(title string, message string) error
+	notifyDesktopMutex       sync.RWMutex
+	notifyDesktopArgsForCall []struct {
+		title   string
+		message string
+	}
+	notifyDesktopReturns struct {
+		result1 error
+	}
+	NotifyWebhookStub        func(url string, payload interface{}) error
+	notifyWebhookMutex       sync.RWMutex
+	notifyWebhookArgsForCall []struct {
+		url     string
+		payload interface{}
+	}
+	notifyWebhookReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeNotifier) NotifyDesktop(title string, message string) error {
+	fake.notifyDesktopMutex.Lock()
+	fake.notifyDesktopArgsForCall = append(fake.notifyDesktopArgsForCall, struct {
+		title   string
+		message string
+	}{title, message})
+	fake.notifyDesktopMutex.Unlock()
+	if fake.NotifyDesktopStub != nil {
+		return fake.NotifyDesktopStub(title, message)
+	} else {
+		return fake.notifyDesktopReturns.result1
+	}
+}
+
+func (fake *FakeNotifier) NotifyDesktopCallCount() int {
+	fake.notifyDesktopMutex.RLock()
+	defer fake.notifyDesktopMutex.RUnlock()
+	return len(fake.notifyDesktopArgsForCall)
+}
+
+func (fake *FakeNotifier) NotifyDesktopArgsForCall(i int) (string, string) {
+	fake.notifyDesktopMutex.RLock()
+	defer fake.notifyDesktopMutex.RUnlock()
+	return fake.notifyDesktopArgsForCall[i].title, fake.notifyDesktopArgsForCall[i].message
+}
+
+func (fake *FakeNotifier) NotifyDesktopReturns(result1 error) {
+	fake.NotifyDesktopStub = nil
+	fake.notifyDesktopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNotifier) NotifyWebhook(url string, payload interface{}) error {
+	fake.notifyWebhookMutex.Lock()
+	fake.notifyWebhookArgsForCall = append(fake.notifyWebhookArgsForCall, struct {
+		url     string
+		payload interface{}
+	}{url, payload})
+	fake.notifyWebhookMutex.Unlock()
+	if fake.NotifyWebhookStub != nil {
+		return fake.NotifyWebhookStub(url, payload)
+	} else {
+		return fake.notifyWebhookReturns.result1
+	}
+}
+
+func (fake *FakeNotifier) NotifyWebhookCallCount() int {
+	fake.notifyWebhookMutex.RLock()
+	defer fake.notifyWebhookMutex.RUnlock()
+	return len(fake.notifyWebhookArgsForCall)
+}
+
+func (fake *FakeNotifier) NotifyWebhookArgsForCall(i int) (string, interface{}) {
+	fake.notifyWebhookMutex.RLock()
+	defer fake.notifyWebhookMutex.RUnlock()
+	return fake.notifyWebhookArgsForCall[i].url, fake.notifyWebhookArgsForCall[i].payload
+}
+
+func (fake *FakeNotifier) NotifyWebhookReturns(result1 error) {
+	fake.NotifyWebhookStub = nil
+	fake.notifyWebhookReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ notifier.Notifier = new(FakeNotifier)
@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("Desktop notifications aren't available on this platform.")
+
+//go:generate counterfeiter -o fake_notifier/fake_notifier.go . Notifier
+type Notifier interface {
+	NotifyDesktop(title, message string) error
+	NotifyWebhook(url string, payload interface{}) error
+}
+
+// New returns a notifier that shows desktop notifications the way the
+// current OS does: through Notification Center on OS X (via osascript) or
+// the desktop's notification daemon on Linux (via notify-send).
+func New(httpClient *http.Client) Notifier {
+	switch runtime.GOOS {
+	case "darwin":
+		return NewOSXNotifier(httpClient, "osascript")
+	case "linux":
+		return NewNotifySendNotifier(httpClient, "notify-send")
+	default:
+		return &notifier{httpClient: httpClient}
+	}
+}
+
+type notifier struct {
+	httpClient  *http.Client
+	toolPath    string
+	desktopArgs func(title, message string) []string
+}
+
+func NewOSXNotifier(httpClient *http.Client, osascriptPath string) Notifier {
+	return &notifier{httpClient, osascriptPath, func(title, message string) []string {
+		return []string{"-e", fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))}
+	}}
+}
+
+func NewNotifySendNotifier(httpClient *http.Client, notifySendPath string) Notifier {
+	return &notifier{httpClient, notifySendPath, func(title, message string) []string {
+		return []string{title, message}
+	}}
+}
+
+func (n *notifier) NotifyDesktop(title, message string) error {
+	if n.toolPath == "" {
+		return ErrUnsupported
+	}
+	if _, err := exec.LookPath(n.toolPath); err != nil {
+		return ErrUnsupported
+	}
+
+	stderr := &bytes.Buffer{}
+	command := exec.Command(n.toolPath, n.desktopArgs(title, message)...)
+	command.Stderr = stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("%s failed: %s", n.toolPath, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// NotifyWebhook posts payload to url as JSON.
+func (n *notifier) NotifyWebhook(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, response.Status)
+	}
+	return nil
+}
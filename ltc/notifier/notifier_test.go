@@ -0,0 +1,119 @@
+package notifier_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier"
+)
+
+var _ = Describe("Notifier", func() {
+	var (
+		tmpDir   string
+		toolPath string
+	)
+
+	// writeTool writes a stand-in for osascript/notify-send that records its
+	// arguments, then runs the given shell snippet.
+	writeTool := func(body string) {
+		script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\"; done > " + filepath.Join(tmpDir, "args") + "\n" + body + "\n"
+		Expect(ioutil.WriteFile(toolPath, []byte(script), 0755)).To(Succeed())
+	}
+
+	recordedArgs := func() string {
+		contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "args"))
+		Expect(err).ToNot(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "notifier-test")
+		Expect(err).ToNot(HaveOccurred())
+		toolPath = filepath.Join(tmpDir, "tool")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("NotifyDesktop", func() {
+		It("shows the notification with notify-send", func() {
+			writeTool("")
+
+			err := notifier.NewNotifySendNotifier(http.DefaultClient, toolPath).NotifyDesktop("ltc", "nightly-report finished.")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recordedArgs()).To(Equal("ltc\nnightly-report finished.\n"))
+		})
+
+		It("shows the notification with osascript", func() {
+			writeTool("")
+
+			err := notifier.NewOSXNotifier(http.DefaultClient, toolPath).NotifyDesktop("ltc", `nightly-report failed: "exit 1"`)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recordedArgs()).To(Equal("-e\n" + `display notification "nightly-report failed: \"exit 1\"" with title "ltc"` + "\n"))
+		})
+
+		It("returns what the tool says when it fails", func() {
+			writeTool("echo 'cannot open display' >&2; exit 1")
+
+			err := notifier.NewNotifySendNotifier(http.DefaultClient, toolPath).NotifyDesktop("ltc", "done")
+
+			Expect(err).To(MatchError(toolPath + " failed: cannot open display"))
+		})
+
+		It("is unsupported when the tool isn't installed", func() {
+			err := notifier.NewNotifySendNotifier(http.DefaultClient, filepath.Join(tmpDir, "missing")).NotifyDesktop("ltc", "done")
+
+			Expect(err).To(Equal(notifier.ErrUnsupported))
+		})
+	})
+
+	Describe("NotifyWebhook", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts the payload as JSON", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/hooks/ltc"),
+				ghttp.VerifyContentType("application/json"),
+				ghttp.VerifyJSON(`{"task_guid":"nightly-report"}`),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			))
+
+			err := notifier.New(http.DefaultClient).NotifyWebhook(server.URL()+"/hooks/ltc", map[string]string{"task_guid": "nightly-report"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns an error when the webhook does", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, ""))
+
+			err := notifier.New(http.DefaultClient).NotifyWebhook(server.URL()+"/hooks/ltc", map[string]string{})
+
+			Expect(err).To(MatchError(server.URL() + "/hooks/ltc returned 502 Bad Gateway"))
+		})
+
+		It("returns an error when the webhook can't be reached", func() {
+			err := notifier.New(http.DefaultClient).NotifyWebhook("http://127.0.0.1:1/hooks/ltc", map[string]string{})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
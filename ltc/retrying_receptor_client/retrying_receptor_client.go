@@ -0,0 +1,264 @@
+package retrying_receptor_client
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 250 * time.Millisecond
+	DefaultMaxDelay    = 2 * time.Second
+)
+
+type Config struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// retryingClient retries receptor requests that fail transiently, such as
+// when the router in front of the receptor returns a 502.  The receptor
+// client doesn't take an http.Transport, so retries happen per request.
+//
+// Reads and idempotent updates are retried on any transient failure.
+// Creates, deletes and kills are only retried when the request never reached
+// the receptor, since repeating one that did could fail or act twice.
+type retryingClient struct {
+	client receptor.Client
+	config Config
+	clock  clock.Clock
+}
+
+func New(client receptor.Client, config Config, clock clock.Clock) receptor.Client {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	return &retryingClient{client, config, clock}
+}
+
+func (c *retryingClient) CreateTask(request receptor.TaskCreateRequest) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.CreateTask(request)
+	})
+}
+
+func (c *retryingClient) Tasks() (tasks []receptor.TaskResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		tasks, err = c.client.Tasks()
+		return err
+	})
+	return tasks, err
+}
+
+func (c *retryingClient) TasksByDomain(domain string) (tasks []receptor.TaskResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		tasks, err = c.client.TasksByDomain(domain)
+		return err
+	})
+	return tasks, err
+}
+
+func (c *retryingClient) GetTask(taskId string) (task receptor.TaskResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		task, err = c.client.GetTask(taskId)
+		return err
+	})
+	return task, err
+}
+
+func (c *retryingClient) DeleteTask(taskId string) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.DeleteTask(taskId)
+	})
+}
+
+func (c *retryingClient) CancelTask(taskId string) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.CancelTask(taskId)
+	})
+}
+
+func (c *retryingClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.CreateDesiredLRP(request)
+	})
+}
+
+func (c *retryingClient) GetDesiredLRP(processGuid string) (desiredLRP receptor.DesiredLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		desiredLRP, err = c.client.GetDesiredLRP(processGuid)
+		return err
+	})
+	return desiredLRP, err
+}
+
+func (c *retryingClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	return c.retry(isTransient, func() error {
+		return c.client.UpdateDesiredLRP(processGuid, update)
+	})
+}
+
+func (c *retryingClient) DeleteDesiredLRP(processGuid string) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.DeleteDesiredLRP(processGuid)
+	})
+}
+
+func (c *retryingClient) DesiredLRPs() (desiredLRPs []receptor.DesiredLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		desiredLRPs, err = c.client.DesiredLRPs()
+		return err
+	})
+	return desiredLRPs, err
+}
+
+func (c *retryingClient) DesiredLRPsByDomain(domain string) (desiredLRPs []receptor.DesiredLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		desiredLRPs, err = c.client.DesiredLRPsByDomain(domain)
+		return err
+	})
+	return desiredLRPs, err
+}
+
+func (c *retryingClient) ActualLRPs() (actualLRPs []receptor.ActualLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		actualLRPs, err = c.client.ActualLRPs()
+		return err
+	})
+	return actualLRPs, err
+}
+
+func (c *retryingClient) ActualLRPsByDomain(domain string) (actualLRPs []receptor.ActualLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		actualLRPs, err = c.client.ActualLRPsByDomain(domain)
+		return err
+	})
+	return actualLRPs, err
+}
+
+func (c *retryingClient) ActualLRPsByProcessGuid(processGuid string) (actualLRPs []receptor.ActualLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		actualLRPs, err = c.client.ActualLRPsByProcessGuid(processGuid)
+		return err
+	})
+	return actualLRPs, err
+}
+
+func (c *retryingClient) ActualLRPByProcessGuidAndIndex(processGuid string, index int) (actualLRP receptor.ActualLRPResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		actualLRP, err = c.client.ActualLRPByProcessGuidAndIndex(processGuid, index)
+		return err
+	})
+	return actualLRP, err
+}
+
+func (c *retryingClient) KillActualLRPByProcessGuidAndIndex(processGuid string, index int) error {
+	return c.retry(isUndelivered, func() error {
+		return c.client.KillActualLRPByProcessGuidAndIndex(processGuid, index)
+	})
+}
+
+func (c *retryingClient) SubscribeToEvents() (eventSource receptor.EventSource, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		eventSource, err = c.client.SubscribeToEvents()
+		return err
+	})
+	return eventSource, err
+}
+
+func (c *retryingClient) Cells() (cells []receptor.CellResponse, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		cells, err = c.client.Cells()
+		return err
+	})
+	return cells, err
+}
+
+func (c *retryingClient) UpsertDomain(domain string, ttl time.Duration) error {
+	return c.retry(isTransient, func() error {
+		return c.client.UpsertDomain(domain, ttl)
+	})
+}
+
+func (c *retryingClient) Domains() (domains []string, err error) {
+	err = c.retry(isTransient, func() (err error) {
+		domains, err = c.client.Domains()
+		return err
+	})
+	return domains, err
+}
+
+// retry calls request until it succeeds, fails in a way retryable doesn't
+// accept, or runs out of attempts.  Between attempts it sleeps for a random
+// duration of up to BaseDelay doubled for each previous attempt, capped at
+// MaxDelay.
+func (c *retryingClient) retry(retryable func(error) bool, request func() error) error {
+	var err error
+	for attempt := 0; attempt < c.config.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			c.clock.Sleep(c.backoff(attempt))
+		}
+		if err = request(); err == nil || !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *retryingClient) backoff(attempt int) time.Duration {
+	delay := c.config.BaseDelay << uint(attempt-1)
+	if delay > c.config.MaxDelay || delay < 0 {
+		delay = c.config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+var transientStatusCodes = []int{502, 503, 504}
+
+// isTransient reports whether a failed request is worth repeating: the
+// receptor couldn't be reached, or a proxy in front of it gave up.
+func isTransient(err error) bool {
+	if isUndelivered(err) {
+		return true
+	}
+
+	switch err := err.(type) {
+	case receptor.Error:
+		if err.Type != receptor.InvalidResponse {
+			return false
+		}
+		for _, statusCode := range transientStatusCodes {
+			if err.Message == fmt.Sprintf("Invalid Response with status code: %d", statusCode) {
+				return true
+			}
+		}
+	case *url.Error:
+		if netErr, ok := err.Err.(net.Error); ok {
+			return netErr.Timeout() || netErr.Temporary()
+		}
+	}
+	return false
+}
+
+// isUndelivered reports whether a failed request certainly never reached
+// the receptor, so that even a create or delete is safe to repeat.
+func isUndelivered(err error) bool {
+	switch err := err.(type) {
+	case receptor.Error:
+		return err.Type == receptor.RouterError
+	case *url.Error:
+		opErr, ok := err.Err.(*net.OpError)
+		return ok && opErr.Op == "dial"
+	}
+	return false
+}
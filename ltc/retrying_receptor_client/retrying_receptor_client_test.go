@@ -0,0 +1,179 @@
+package retrying_receptor_client_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/retrying_receptor_client"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("RetryingReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeClock          *fakeclock.FakeClock
+		client             receptor.Client
+
+		badGatewayError error
+		routerError     error
+		dialError       error
+		notFoundError   error
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		client = retrying_receptor_client.New(fakeReceptorClient, retrying_receptor_client.Config{
+			MaxAttempts: 3,
+			BaseDelay:   time.Second,
+			MaxDelay:    2 * time.Second,
+		}, fakeClock)
+
+		badGatewayError = receptor.Error{Type: receptor.InvalidResponse, Message: "Invalid Response with status code: 502"}
+		routerError = receptor.Error{Type: receptor.RouterError, Message: "unknown_route"}
+		dialError = &url.Error{Op: "Post", URL: "http://receptor.example.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+		notFoundError = receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"}
+	})
+
+	// finish moves the fake clock along until the request returns, since
+	// each retry sleeps on it first.
+	finish := func(request func() error) error {
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- request()
+		}()
+
+		for {
+			select {
+			case err := <-errChan:
+				return err
+			case <-time.After(10 * time.Millisecond):
+				fakeClock.Increment(2 * time.Second)
+			}
+		}
+	}
+
+	Describe("reads", func() {
+		It("retries transient failures until the request succeeds", func() {
+			fakeReceptorClient.DesiredLRPsStub = func() ([]receptor.DesiredLRPResponse, error) {
+				if fakeReceptorClient.DesiredLRPsCallCount() < 3 {
+					return nil, badGatewayError
+				}
+				return []receptor.DesiredLRPResponse{{ProcessGuid: "app"}}, nil
+			}
+
+			var desiredLRPs []receptor.DesiredLRPResponse
+			err := finish(func() (err error) {
+				desiredLRPs, err = client.DesiredLRPs()
+				return err
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desiredLRPs).To(Equal([]receptor.DesiredLRPResponse{{ProcessGuid: "app"}}))
+			Expect(fakeReceptorClient.DesiredLRPsCallCount()).To(Equal(3))
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			fakeReceptorClient.ActualLRPsReturns(nil, badGatewayError)
+
+			err := finish(func() error {
+				_, err := client.ActualLRPs()
+				return err
+			})
+
+			Expect(err).To(Equal(badGatewayError))
+			Expect(fakeReceptorClient.ActualLRPsCallCount()).To(Equal(3))
+		})
+
+		It("waits before retrying", func() {
+			fakeReceptorClient.CellsReturns(nil, routerError)
+
+			go client.Cells()
+
+			Eventually(fakeReceptorClient.CellsCallCount).Should(Equal(1))
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Consistently(fakeReceptorClient.CellsCallCount).Should(Equal(1))
+
+			fakeClock.Increment(time.Second)
+			Eventually(fakeReceptorClient.CellsCallCount).Should(Equal(2))
+		})
+
+		It("does not retry errors from the receptor itself", func() {
+			fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{}, notFoundError)
+
+			_, err := client.GetDesiredLRP("app")
+
+			Expect(err).To(Equal(notFoundError))
+			Expect(fakeReceptorClient.GetDesiredLRPCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("idempotent updates", func() {
+		It("retries transient failures", func() {
+			fakeReceptorClient.UpdateDesiredLRPStub = func(string, receptor.DesiredLRPUpdateRequest) error {
+				if fakeReceptorClient.UpdateDesiredLRPCallCount() < 2 {
+					return badGatewayError
+				}
+				return nil
+			}
+
+			err := finish(func() error {
+				return client.UpdateDesiredLRP("app", receptor.DesiredLRPUpdateRequest{})
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(2))
+			processGuid, _ := fakeReceptorClient.UpdateDesiredLRPArgsForCall(1)
+			Expect(processGuid).To(Equal("app"))
+		})
+	})
+
+	Describe("creates and deletes", func() {
+		It("retries requests that never reached the receptor", func() {
+			fakeReceptorClient.CreateDesiredLRPStub = func(receptor.DesiredLRPCreateRequest) error {
+				switch fakeReceptorClient.CreateDesiredLRPCallCount() {
+				case 1:
+					return dialError
+				case 2:
+					return routerError
+				}
+				return nil
+			}
+
+			err := finish(func() error {
+				return client.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "app"})
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(3))
+		})
+
+		It("does not retry requests the receptor may have acted on", func() {
+			fakeReceptorClient.DeleteDesiredLRPReturns(badGatewayError)
+
+			err := client.DeleteDesiredLRP("app")
+
+			Expect(err).To(Equal(badGatewayError))
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when MaxAttempts is less than one", func() {
+		It("makes a single attempt", func() {
+			client = retrying_receptor_client.New(fakeReceptorClient, retrying_receptor_client.Config{}, fakeClock)
+			fakeReceptorClient.TasksReturns(nil, badGatewayError)
+
+			_, err := client.Tasks()
+
+			Expect(err).To(Equal(badGatewayError))
+			Expect(fakeReceptorClient.TasksCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -2,17 +2,30 @@ package route_helpers
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/receptor"
 )
 
-const AppRouter = "cf-router"
+const (
+	AppRouter = "cf-router"
+
+	MaxHostnameLength = 253
+	MaxLabelLength    = 63
+)
+
+var labelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
 
 type AppRoutes []AppRoute
 
+// AppRoute routes Hostnames to Port.  Options ask the router for special
+// handling of those routes, such as session affinity.
 type AppRoute struct {
-	Hostnames []string `json:"hostnames"`
-	Port      uint16   `json:"port"`
+	Hostnames []string          `json:"hostnames"`
+	Port      uint16            `json:"port"`
+	Options   map[string]string `json:"options,omitempty"`
 }
 
 func (l AppRoutes) RoutingInfo() receptor.RoutingInfo {
@@ -33,6 +46,18 @@ func (l AppRoutes) HostnamesByPort() map[uint16][]string {
 	return routesByPort
 }
 
+func (l AppRoutes) OptionsByPort() map[uint16]map[string]string {
+	optionsByPort := make(map[uint16]map[string]string)
+
+	for _, route := range l {
+		if len(route.Options) > 0 {
+			optionsByPort[route.Port] = route.Options
+		}
+	}
+
+	return optionsByPort
+}
+
 func AppRoutesFromRoutingInfo(routingInfo receptor.RoutingInfo) AppRoutes {
 	if routingInfo == nil {
 		return nil
@@ -55,3 +80,24 @@ func AppRoutesFromRoutingInfo(routingInfo receptor.RoutingInfo) AppRoutes {
 
 	return routes
 }
+
+// ValidateHostname returns why hostname can't be resolved and routed to, or
+// nil if it can.
+func ValidateHostname(hostname string) error {
+	if len(hostname) > MaxHostnameLength {
+		return fmt.Errorf("%s is longer than %d characters", hostname, MaxHostnameLength)
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		switch {
+		case label == "":
+			return fmt.Errorf("%s has an empty label", hostname)
+		case len(label) > MaxLabelLength:
+			return fmt.Errorf("%s has a label longer than %d characters: %s", hostname, MaxLabelLength, label)
+		case !labelPattern.MatchString(label):
+			return fmt.Errorf("%s has an invalid label: %s may only contain letters, digits and hyphens, and can't start or end with a hyphen", hostname, label)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package route_helpers_test
 
 import (
 	"encoding/json"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -89,6 +90,17 @@ var _ = Describe("RoutingInfoHelpers", func() {
 				Expect(routes).To(Equal(routesResult))
 			})
 
+			Context("when the routes have options", func() {
+				BeforeEach(func() {
+					routes[0].Options = map[string]string{"session-affinity": ""}
+					routingInfo = routes.RoutingInfo()
+				})
+
+				It("returns the options with the routes", func() {
+					Expect(routesResult[0].Options).To(Equal(map[string]string{"session-affinity": ""}))
+				})
+			})
+
 			Context("when the lattice routes are nil", func() {
 				BeforeEach(func() {
 					routingInfo = receptor.RoutingInfo{route_helpers.AppRouter: nil}
@@ -133,4 +145,46 @@ var _ = Describe("RoutingInfoHelpers", func() {
 			Expect(routes.HostnamesByPort()).To(Equal(expectedHostnamesByPort))
 		})
 	})
+
+	Describe("OptionsByPort", func() {
+		It("returns map of ports to route options, skipping ports without options", func() {
+			routes[1].Options = map[string]string{"session-affinity": "JSESSIONID"}
+
+			Expect(routes.OptionsByPort()).To(Equal(map[uint16]map[string]string{
+				22222: {"session-affinity": "JSESSIONID"},
+			}))
+		})
+	})
+
+	Describe("ValidateHostname", func() {
+		It("accepts hostnames made of letters, digits and hyphens", func() {
+			Expect(route_helpers.ValidateHostname("my-app-8080.192.168.11.11.xip.io")).To(Succeed())
+			Expect(route_helpers.ValidateHostname("myDiegoInstall.com")).To(Succeed())
+		})
+
+		It("rejects labels with other characters", func() {
+			err := route_helpers.ValidateHostname("my_app.example.com")
+			Expect(err).To(MatchError("my_app.example.com has an invalid label: my_app may only contain letters, digits and hyphens, and can't start or end with a hyphen"))
+		})
+
+		It("rejects labels starting or ending with a hyphen", func() {
+			Expect(route_helpers.ValidateHostname("-app.example.com")).NotTo(Succeed())
+			Expect(route_helpers.ValidateHostname("app-.example.com")).NotTo(Succeed())
+		})
+
+		It("rejects empty labels", func() {
+			Expect(route_helpers.ValidateHostname("app..example.com")).To(MatchError("app..example.com has an empty label"))
+		})
+
+		It("rejects labels longer than 63 characters", func() {
+			label := strings.Repeat("a", 64)
+			err := route_helpers.ValidateHostname(label + ".example.com")
+			Expect(err).To(MatchError(label + ".example.com has a label longer than 63 characters: " + label))
+		})
+
+		It("rejects hostnames longer than 253 characters", func() {
+			hostname := strings.Repeat("a.", 126) + "io"
+			Expect(route_helpers.ValidateHostname(hostname)).To(MatchError(hostname + " is longer than 253 characters"))
+		})
+	})
 })
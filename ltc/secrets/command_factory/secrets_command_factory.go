@@ -0,0 +1,112 @@
+package command_factory
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/codegangsta/cli"
+)
+
+type SecretsCommandFactory struct {
+	secretStore secrets.SecretStore
+	config      *config.Config
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+func NewSecretsCommandFactory(secretStore secrets.SecretStore, config *config.Config, ui terminal.UI, exitHandler exit_handler.ExitHandler) *SecretsCommandFactory {
+	return &SecretsCommandFactory{secretStore, config, ui, exitHandler}
+}
+
+func (factory *SecretsCommandFactory) MakeSetSecretCommand() cli.Command {
+	var setSecretCommand = cli.Command{
+		Name:    "set-secret",
+		Aliases: []string{"ss"},
+		Usage:   "Stores an encrypted secret on lattice",
+		Description: `ltc set-secret SECRET_NAME
+
+   The secret value is read from the terminal without being echoed.
+   It is encrypted locally before being stored on the cluster.
+
+   To expose a secret to an app as an environment variable:
+   ltc create APP_NAME DOCKER_IMAGE --secret-env ENV_VAR_NAME=SECRET_NAME`,
+		Action: factory.setSecret,
+	}
+
+	return setSecretCommand
+}
+
+func (factory *SecretsCommandFactory) setSecret(context *cli.Context) {
+	name := context.Args().First()
+	if name == "" {
+		factory.ui.SayIncorrectUsage("SECRET_NAME is required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if len(factory.config.SecretsKey()) == 0 {
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			factory.ui.Say(fmt.Sprintf("Error generating secrets key: %s", err))
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+
+		factory.config.SetSecretsKey(key)
+		if err := factory.config.Save(); err != nil {
+			factory.ui.Say(err.Error())
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+	}
+
+	value := factory.ui.PromptForPassword("Secret value: ")
+	if value == "" {
+		factory.ui.Say("Secret value must not be empty.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := factory.secretStore.SetSecret(name, value); err != nil {
+		factory.ui.Say(fmt.Sprintf("Error setting secret: %s", err))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	factory.ui.Say("Secret " + name + " set.")
+}
+
+func (factory *SecretsCommandFactory) MakeListSecretsCommand() cli.Command {
+	var listSecretsCommand = cli.Command{
+		Name:        "list-secrets",
+		Aliases:     []string{"lss"},
+		Usage:       "Lists the names of the secrets stored on lattice",
+		Description: "ltc list-secrets",
+		Action:      factory.listSecrets,
+	}
+
+	return listSecretsCommand
+}
+
+// listSecrets shows only the names of the secrets, which doesn't need the
+// secrets key.
+func (factory *SecretsCommandFactory) listSecrets(context *cli.Context) {
+	names, err := factory.secretStore.ListSecrets()
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error listing secrets: %s", err))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return
+	}
+
+	if len(names) == 0 {
+		factory.ui.SayLine("No secrets are set.")
+		return
+	}
+	for _, name := range names {
+		factory.ui.SayLine(name)
+	}
+}
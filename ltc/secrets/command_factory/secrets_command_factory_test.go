@@ -0,0 +1,149 @@
+package command_factory_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	config_package "github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets/fake_secret_store"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader/fake_password_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("SecretsCommandFactory", func() {
+	var (
+		outputBuffer       *gbytes.Buffer
+		terminalUI         terminal.UI
+		memPersister       persister.Persister
+		config             *config_package.Config
+		fakeSecretStore    *fake_secret_store.FakeSecretStore
+		fakeExitHandler    *fake_exit_handler.FakeExitHandler
+		fakePasswordReader *fake_password_reader.FakePasswordReader
+	)
+
+	BeforeEach(func() {
+		stdinReader, _ := io.Pipe()
+		outputBuffer = gbytes.NewBuffer()
+		fakePasswordReader = &fake_password_reader.FakePasswordReader{}
+		terminalUI = terminal.NewUI(stdinReader, outputBuffer, fakePasswordReader)
+		memPersister = persister.NewMemPersister()
+		config = config_package.New(memPersister)
+		fakeSecretStore = &fake_secret_store.FakeSecretStore{}
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+	})
+
+	Describe("SetSecretCommand", func() {
+		var setSecretCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewSecretsCommandFactory(fakeSecretStore, config, terminalUI, fakeExitHandler)
+			setSecretCommand = commandFactory.MakeSetSecretCommand()
+		})
+
+		It("reads the value without echoing it and stores the secret", func() {
+			config.SetSecretsKey([]byte("an-existing-key-an-existing-key!"))
+			fakePasswordReader.PromptForPasswordReturns("hunter2")
+
+			test_helpers.ExecuteCommandWithArgs(setSecretCommand, []string{"db-password"})
+
+			Expect(fakePasswordReader.PromptForPasswordCallCount()).To(Equal(1))
+			Expect(fakeSecretStore.SetSecretCallCount()).To(Equal(1))
+			name, value := fakeSecretStore.SetSecretArgsForCall(0)
+			Expect(name).To(Equal("db-password"))
+			Expect(value).To(Equal("hunter2"))
+
+			Expect(outputBuffer).To(test_helpers.Say("Secret db-password set."))
+			Expect(outputBuffer.Contents()).ToNot(ContainSubstring("hunter2"))
+			Expect(config.SecretsKey()).To(Equal([]byte("an-existing-key-an-existing-key!")))
+		})
+
+		It("generates and saves a secrets key when none is configured", func() {
+			fakePasswordReader.PromptForPasswordReturns("hunter2")
+
+			test_helpers.ExecuteCommandWithArgs(setSecretCommand, []string{"db-password"})
+
+			Expect(config.SecretsKey()).To(HaveLen(32))
+
+			reloadedConfig := config_package.New(memPersister)
+			Expect(reloadedConfig.Load()).To(Succeed())
+			Expect(reloadedConfig.SecretsKey()).To(Equal(config.SecretsKey()))
+			Expect(fakeSecretStore.SetSecretCallCount()).To(Equal(1))
+		})
+
+		Context("invalid syntax", func() {
+			It("requires a secret name", func() {
+				test_helpers.ExecuteCommandWithArgs(setSecretCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: SECRET_NAME is required"))
+				Expect(fakeSecretStore.SetSecretCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
+			It("rejects an empty secret value", func() {
+				fakePasswordReader.PromptForPasswordReturns("")
+
+				test_helpers.ExecuteCommandWithArgs(setSecretCommand, []string{"db-password"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Secret value must not be empty."))
+				Expect(fakeSecretStore.SetSecretCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("reports errors from the secret store", func() {
+			fakePasswordReader.PromptForPasswordReturns("hunter2")
+			fakeSecretStore.SetSecretReturns(errors.New("receptor down"))
+
+			test_helpers.ExecuteCommandWithArgs(setSecretCommand, []string{"db-password"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error setting secret: receptor down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	Describe("ListSecretsCommand", func() {
+		var listSecretsCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewSecretsCommandFactory(fakeSecretStore, config, terminalUI, fakeExitHandler)
+			listSecretsCommand = commandFactory.MakeListSecretsCommand()
+		})
+
+		It("lists the names of the secrets", func() {
+			fakeSecretStore.ListSecretsReturns([]string{"api-token", "db-password"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listSecretsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("api-token"))
+			Expect(outputBuffer).To(test_helpers.SayLine("db-password"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("says when there are no secrets", func() {
+			fakeSecretStore.ListSecretsReturns([]string{}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(listSecretsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("No secrets are set."))
+		})
+
+		It("reports errors from the secret store", func() {
+			fakeSecretStore.ListSecretsReturns(nil, errors.New("receptor down"))
+
+			test_helpers.ExecuteCommandWithArgs(listSecretsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing secrets: receptor down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+})
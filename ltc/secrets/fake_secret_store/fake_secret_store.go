@@ -0,0 +1,129 @@
+// This file was generated by counterfeiter
+package fake_secret_store
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+)
+
+type FakeSecretStore struct {
+	SetSecretStub        func(name, value string) error
+	setSecretMutex       sync.RWMutex
+	setSecretArgsForCall []struct {
+		name  string
+		value string
+	}
+	setSecretReturns struct {
+		result1 error
+	}
+	GetSecretStub        func(name string) (string, error)
+	getSecretMutex       sync.RWMutex
+	getSecretArgsForCall []struct {
+		name string
+	}
+	getSecretReturns struct {
+		result1 string
+		result2 error
+	}
+	ListSecretsStub        func() ([]string, error)
+	listSecretsMutex       sync.RWMutex
+	listSecretsArgsForCall []struct{}
+	listSecretsReturns     struct {
+		result1 []string
+		result2 error
+	}
+}
+
+func (fake *FakeSecretStore) SetSecret(name string, value string) error {
+	fake.setSecretMutex.Lock()
+	fake.setSecretArgsForCall = append(fake.setSecretArgsForCall, struct {
+		name  string
+		value string
+	}{name, value})
+	fake.setSecretMutex.Unlock()
+	if fake.SetSecretStub != nil {
+		return fake.SetSecretStub(name, value)
+	} else {
+		return fake.setSecretReturns.result1
+	}
+}
+
+func (fake *FakeSecretStore) SetSecretCallCount() int {
+	fake.setSecretMutex.RLock()
+	defer fake.setSecretMutex.RUnlock()
+	return len(fake.setSecretArgsForCall)
+}
+
+func (fake *FakeSecretStore) SetSecretArgsForCall(i int) (string, string) {
+	fake.setSecretMutex.RLock()
+	defer fake.setSecretMutex.RUnlock()
+	return fake.setSecretArgsForCall[i].name, fake.setSecretArgsForCall[i].value
+}
+
+func (fake *FakeSecretStore) SetSecretReturns(result1 error) {
+	fake.SetSecretStub = nil
+	fake.setSecretReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSecretStore) GetSecret(name string) (string, error) {
+	fake.getSecretMutex.Lock()
+	fake.getSecretArgsForCall = append(fake.getSecretArgsForCall, struct {
+		name string
+	}{name})
+	fake.getSecretMutex.Unlock()
+	if fake.GetSecretStub != nil {
+		return fake.GetSecretStub(name)
+	} else {
+		return fake.getSecretReturns.result1, fake.getSecretReturns.result2
+	}
+}
+
+func (fake *FakeSecretStore) GetSecretCallCount() int {
+	fake.getSecretMutex.RLock()
+	defer fake.getSecretMutex.RUnlock()
+	return len(fake.getSecretArgsForCall)
+}
+
+func (fake *FakeSecretStore) GetSecretArgsForCall(i int) string {
+	fake.getSecretMutex.RLock()
+	defer fake.getSecretMutex.RUnlock()
+	return fake.getSecretArgsForCall[i].name
+}
+
+func (fake *FakeSecretStore) GetSecretReturns(result1 string, result2 error) {
+	fake.GetSecretStub = nil
+	fake.getSecretReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSecretStore) ListSecrets() ([]string, error) {
+	fake.listSecretsMutex.Lock()
+	fake.listSecretsArgsForCall = append(fake.listSecretsArgsForCall, struct{}{})
+	fake.listSecretsMutex.Unlock()
+	if fake.ListSecretsStub != nil {
+		return fake.ListSecretsStub()
+	} else {
+		return fake.listSecretsReturns.result1, fake.listSecretsReturns.result2
+	}
+}
+
+func (fake *FakeSecretStore) ListSecretsCallCount() int {
+	fake.listSecretsMutex.RLock()
+	defer fake.listSecretsMutex.RUnlock()
+	return len(fake.listSecretsArgsForCall)
+}
+
+func (fake *FakeSecretStore) ListSecretsReturns(result1 []string, result2 error) {
+	fake.ListSecretsStub = nil
+	fake.listSecretsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+var _ secrets.SecretStore = new(FakeSecretStore)
@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+const (
+	KeySize = 32
+
+	SecretsDomain = "lattice-secrets"
+
+	secretsRootFS = "docker:///busybox#latest"
+)
+
+var ErrMissingKey = errors.New("No secrets key is configured.")
+
+// MaskedValue is shown in place of the values of env vars that look like
+// secrets.
+const MaskedValue = "********"
+
+var secretEnvVarName = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|CREDENTIAL|PRIVATE_KEY|API_KEY|ACCESS_KEY)`)
+
+// LooksSecret reports whether the env var named name probably holds a
+// secret, such as one exposed with --secret-env, so that its value should
+// be masked when shown.
+func LooksSecret(name string) bool {
+	return secretEnvVarName.MatchString(name)
+}
+
+//go:generate counterfeiter -o fake_secret_store/fake_secret_store.go . SecretStore
+type SecretStore interface {
+	SetSecret(name, value string) error
+	GetSecret(name string) (string, error)
+	ListSecrets() ([]string, error)
+}
+
+type secretNotFoundError string
+
+func (name secretNotFoundError) Error() string {
+	return fmt.Sprintf("Secret %s not found.", string(name))
+}
+
+type secretStore struct {
+	receptorClient receptor.Client
+	config         *config.Config
+}
+
+func New(receptorClient receptor.Client, config *config.Config) SecretStore {
+	return &secretStore{receptorClient, config}
+}
+
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *secretStore) SetSecret(name, value string) error {
+	secrets, exists, err := s.fetchSecrets()
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := s.encrypt(value)
+	if err != nil {
+		return err
+	}
+	secrets[name] = ciphertext
+
+	annotationBytes, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	annotation := string(annotationBytes)
+
+	if exists {
+		return s.receptorClient.UpdateDesiredLRP(reserved_app_ids.LatticeSecretsAppId, receptor.DesiredLRPUpdateRequest{
+			Annotation: &annotation,
+		})
+	}
+
+	if err := s.receptorClient.UpsertDomain(SecretsDomain, 0); err != nil {
+		return err
+	}
+
+	return s.receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+		ProcessGuid: reserved_app_ids.LatticeSecretsAppId,
+		Domain:      SecretsDomain,
+		RootFS:      secretsRootFS,
+		Instances:   0,
+		Action: &models.RunAction{
+			Path: "true",
+		},
+		Annotation: annotation,
+	})
+}
+
+func (s *secretStore) GetSecret(name string) (string, error) {
+	secrets, _, err := s.fetchSecrets()
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, ok := secrets[name]
+	if !ok {
+		return "", secretNotFoundError(name)
+	}
+
+	return s.decrypt(ciphertext)
+}
+
+func (s *secretStore) ListSecrets() ([]string, error) {
+	secrets, _, err := s.fetchSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *secretStore) fetchSecrets() (secrets map[string]string, exists bool, err error) {
+	desiredLRP, err := s.receptorClient.GetDesiredLRP(reserved_app_ids.LatticeSecretsAppId)
+	if err != nil {
+		if receptorError, ok := err.(receptor.Error); ok && receptorError.Type == receptor.DesiredLRPNotFound {
+			return map[string]string{}, false, nil
+		}
+		return nil, false, err
+	}
+
+	secrets = map[string]string{}
+	if desiredLRP.Annotation == "" {
+		return secrets, true, nil
+	}
+
+	if err := json.Unmarshal([]byte(desiredLRP.Annotation), &secrets); err != nil {
+		return nil, true, fmt.Errorf("Error parsing stored secrets: %s", err)
+	}
+
+	return secrets, true, nil
+}
+
+func (s *secretStore) encrypt(plaintext string) (string, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *secretStore) decrypt(ciphertext string) (string, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("Stored secret is corrupt.")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("Unable to decrypt secret. Was it set with a different secrets key?")
+	}
+
+	return string(plaintext), nil
+}
+
+func (s *secretStore) cipher() (cipher.AEAD, error) {
+	key := s.config.SecretsKey()
+	if len(key) == 0 {
+		return nil, ErrMissingKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
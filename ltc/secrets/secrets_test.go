@@ -0,0 +1,169 @@
+package secrets_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/secrets"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("SecretStore", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		cfg                *config.Config
+		secretStore        secrets.SecretStore
+		storedLRP          *receptor.DesiredLRPResponse
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		cfg = config.New(persister.NewMemPersister())
+
+		key, err := secrets.GenerateKey()
+		Expect(err).ToNot(HaveOccurred())
+		cfg.SetSecretsKey(key)
+
+		secretStore = secrets.New(fakeReceptorClient, cfg)
+
+		storedLRP = nil
+		fakeReceptorClient.GetDesiredLRPStub = func(processGuid string) (receptor.DesiredLRPResponse, error) {
+			if storedLRP == nil {
+				return receptor.DesiredLRPResponse{}, receptor.Error{Type: receptor.DesiredLRPNotFound, Message: "not found"}
+			}
+			return *storedLRP, nil
+		}
+		fakeReceptorClient.CreateDesiredLRPStub = func(request receptor.DesiredLRPCreateRequest) error {
+			storedLRP = &receptor.DesiredLRPResponse{
+				ProcessGuid: request.ProcessGuid,
+				Domain:      request.Domain,
+				Annotation:  request.Annotation,
+			}
+			return nil
+		}
+		fakeReceptorClient.UpdateDesiredLRPStub = func(processGuid string, request receptor.DesiredLRPUpdateRequest) error {
+			storedLRP.Annotation = *request.Annotation
+			return nil
+		}
+	})
+
+	Describe("SetSecret", func() {
+		It("creates the secrets LRP on first write", func() {
+			err := secretStore.SetSecret("db-password", "hunter2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeReceptorClient.UpsertDomainCallCount()).To(Equal(1))
+			domain, ttl := fakeReceptorClient.UpsertDomainArgsForCall(0)
+			Expect(domain).To(Equal(secrets.SecretsDomain))
+			Expect(ttl).To(BeZero())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+			request := fakeReceptorClient.CreateDesiredLRPArgsForCall(0)
+			Expect(request.ProcessGuid).To(Equal(reserved_app_ids.LatticeSecretsAppId))
+			Expect(request.Domain).To(Equal(secrets.SecretsDomain))
+			Expect(request.Instances).To(BeZero())
+			Expect(request.Annotation).To(ContainSubstring("db-password"))
+			Expect(request.Annotation).ToNot(ContainSubstring("hunter2"))
+		})
+
+		It("updates the secrets LRP on subsequent writes", func() {
+			Expect(secretStore.SetSecret("db-password", "hunter2")).To(Succeed())
+			Expect(secretStore.SetSecret("api-token", "s3cr3t")).To(Succeed())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			processGuid, _ := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(processGuid).To(Equal(reserved_app_ids.LatticeSecretsAppId))
+		})
+
+		It("returns ErrMissingKey when no key is configured", func() {
+			cfg.SetSecretsKey(nil)
+
+			err := secretStore.SetSecret("db-password", "hunter2")
+			Expect(err).To(Equal(secrets.ErrMissingKey))
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("bubbles up errors fetching the secrets LRP", func() {
+			fakeReceptorClient.GetDesiredLRPStub = nil
+			fakeReceptorClient.GetDesiredLRPReturns(receptor.DesiredLRPResponse{}, errors.New("receptor down"))
+
+			err := secretStore.SetSecret("db-password", "hunter2")
+			Expect(err).To(MatchError("receptor down"))
+		})
+
+		It("bubbles up errors creating the secrets LRP", func() {
+			fakeReceptorClient.CreateDesiredLRPStub = nil
+			fakeReceptorClient.CreateDesiredLRPReturns(errors.New("create failed"))
+
+			err := secretStore.SetSecret("db-password", "hunter2")
+			Expect(err).To(MatchError("create failed"))
+		})
+	})
+
+	Describe("GetSecret", func() {
+		It("round-trips a stored secret", func() {
+			Expect(secretStore.SetSecret("db-password", "hunter2")).To(Succeed())
+
+			value, err := secretStore.GetSecret("db-password")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("hunter2"))
+		})
+
+		It("returns an error for an unknown secret", func() {
+			_, err := secretStore.GetSecret("nope")
+			Expect(err).To(MatchError("Secret nope not found."))
+		})
+
+		It("returns an error when decrypting with a different key", func() {
+			Expect(secretStore.SetSecret("db-password", "hunter2")).To(Succeed())
+
+			otherKey, err := secrets.GenerateKey()
+			Expect(err).ToNot(HaveOccurred())
+			cfg.SetSecretsKey(otherKey)
+
+			_, err = secretStore.GetSecret("db-password")
+			Expect(err).To(MatchError("Unable to decrypt secret. Was it set with a different secrets key?"))
+		})
+
+		It("returns an error when the stored secrets are malformed", func() {
+			storedLRP = &receptor.DesiredLRPResponse{Annotation: "{not-json"}
+
+			_, err := secretStore.GetSecret("db-password")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("Error parsing stored secrets"))
+		})
+	})
+
+	Describe("ListSecrets", func() {
+		It("returns sorted secret names", func() {
+			Expect(secretStore.SetSecret("zebra", "z")).To(Succeed())
+			Expect(secretStore.SetSecret("alpha", "a")).To(Succeed())
+
+			names, err := secretStore.ListSecrets()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"alpha", "zebra"}))
+		})
+
+		It("returns an empty list when no secrets exist", func() {
+			names, err := secretStore.ListSecrets()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(BeEmpty())
+		})
+	})
+
+	Describe("LooksSecret", func() {
+		It("matches the names of env vars that usually hold secrets", func() {
+			Expect(secrets.LooksSecret("DB_PASSWORD")).To(BeTrue())
+			Expect(secrets.LooksSecret("github_token")).To(BeTrue())
+			Expect(secrets.LooksSecret("AWS_SECRET_ACCESS_KEY")).To(BeTrue())
+			Expect(secrets.LooksSecret("LOG_LEVEL")).To(BeFalse())
+		})
+	})
+})
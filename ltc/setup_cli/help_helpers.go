@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/config"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/codegangsta/cli"
 )
 
@@ -88,10 +90,60 @@ func RequestHelp(args []string) bool {
 func CallCoreCommand(args []string, cliApp *cli.App) {
 	err := cliApp.Run(args)
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exit_codes.InvalidSyntax)
 	}
 }
 
+// SkipGlobalFlags returns args with any leading global flags removed, so
+// that the first remaining arg is the command name.
+func SkipGlobalFlags(app *cli.App, args []string) []string {
+Loop:
+	for len(args) > 0 {
+		for _, flag := range app.Flags {
+			boolFlag, ok := flag.(cli.BoolFlag)
+			if !ok {
+				continue
+			}
+			for _, name := range strings.Split(boolFlag.Name, ",") {
+				name = strings.TrimSpace(name)
+				if args[0] == "-"+name || args[0] == "--"+name {
+					args = args[1:]
+					continue Loop
+				}
+			}
+		}
+		break
+	}
+	return args
+}
+
+// ExpandAlias replaces a user-defined alias in args, which start with the
+// program name, with the command it stands for.  Global flags before the
+// alias and args after it are kept, and registered commands always win over
+// aliases of the same name.
+func ExpandAlias(app *cli.App, aliases *config.Aliases, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	commandArgs := SkipGlobalFlags(app, args[1:])
+	if len(commandArgs) == 0 {
+		return args
+	}
+	if _, err := GetByCmdName(app, commandArgs[0]); err == nil {
+		return args
+	}
+
+	aliasArgs, ok := aliases.Get(commandArgs[0])
+	if !ok {
+		return args
+	}
+
+	expandedArgs := append([]string{}, args[:len(args)-len(commandArgs)]...)
+	expandedArgs = append(expandedArgs, aliasArgs...)
+	return append(expandedArgs, commandArgs[1:]...)
+}
+
 func GetCommandFlags(app *cli.App, command string) []string {
 	cmd, err := GetByCmdName(app, command)
 	if err != nil {
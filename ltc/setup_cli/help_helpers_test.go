@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier/fake_target_verifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/setup_cli"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
@@ -34,7 +35,7 @@ var _ = Describe("HelpHelpers", func() {
 		cliApp = cli_app_factory.MakeCliApp(
 			"",
 			"~/",
-			nil,
+			&fake_exit_handler.FakeExitHandler{},
 			cliConfig,
 			nil,
 			fakeTargetVerifier,
@@ -83,6 +84,42 @@ var _ = Describe("HelpHelpers", func() {
 		})
 	})
 
+	Describe("SkipGlobalFlags", func() {
+		It("skips leading global flags", func() {
+			Expect(setup_cli.SkipGlobalFlags(cliApp, []string{"--no-color", "create", "--no-routes"})).To(Equal([]string{"create", "--no-routes"}))
+			Expect(setup_cli.SkipGlobalFlags(cliApp, []string{"-q", "--verbose", "scale", "app", "3"})).To(Equal([]string{"scale", "app", "3"}))
+		})
+
+		It("leaves the args alone when there are no global flags", func() {
+			Expect(setup_cli.SkipGlobalFlags(cliApp, []string{"list"})).To(Equal([]string{"list"}))
+			Expect(setup_cli.SkipGlobalFlags(cliApp, []string{"--help"})).To(Equal([]string{"--help"}))
+			Expect(setup_cli.SkipGlobalFlags(cliApp, []string{})).To(BeEmpty())
+		})
+	})
+
+	Describe("ExpandAlias", func() {
+		var aliases *config.Aliases
+
+		BeforeEach(func() {
+			aliases = config.NewAliases(persister.NewMemPersister())
+			aliases.Set("prodlogs", []string{"logs", "--prefix", "api"})
+			aliases.Set("list", []string{"status", "api"})
+		})
+
+		It("expands an alias, keeping global flags and trailing args", func() {
+			Expect(setup_cli.ExpandAlias(cliApp, aliases, []string{"ltc", "--no-color", "prodlogs", "worker"})).To(Equal([]string{"ltc", "--no-color", "logs", "--prefix", "api", "worker"}))
+		})
+
+		It("prefers registered commands over aliases", func() {
+			Expect(setup_cli.ExpandAlias(cliApp, aliases, []string{"ltc", "list"})).To(Equal([]string{"ltc", "list"}))
+		})
+
+		It("leaves unknown commands alone", func() {
+			Expect(setup_cli.ExpandAlias(cliApp, aliases, []string{"ltc", "devlogs"})).To(Equal([]string{"ltc", "devlogs"}))
+			Expect(setup_cli.ExpandAlias(cliApp, aliases, []string{"ltc"})).To(Equal([]string{"ltc"}))
+		})
+	})
+
 	Describe("GetByCmdName", func() {
 		It("returns command not found error", func() {
 			_, err := setup_cli.GetByCmdName(cliApp, "zz")
@@ -7,6 +7,7 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/cli_app_factory"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/config_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/config/keychain"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier"
 	"github.com/cloudfoundry-incubator/lattice/ltc/config/target_verifier/receptor_client_factory"
@@ -24,18 +25,26 @@ var (
 )
 
 func NewCliApp() *cli.App {
-	config := config.New(persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot())))
+	config := config.NewWithKeychain(persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot())), keychain.New())
 
 	signalChan := make(chan os.Signal)
 	signal.Notify(signalChan, os.Interrupt)
 	exitHandler := exit_handler.New(signalChan, os.Exit)
 	go exitHandler.Run()
 
-	targetVerifier := target_verifier.New(receptor_client_factory.MakeReceptorClient)
+	targetVerifier := target_verifier.New(receptor_client_factory.NewConfiguredReceptorClientFactory(config))
 	app := cli_app_factory.MakeCliApp(latticeVersion, ltcConfigRoot(), exitHandler, config, logger(), targetVerifier, os.Stdout)
 	return app
 }
 
+// LoadAliases reads the user's command aliases.  An unreadable aliases file
+// leaves ltc without aliases rather than stopping every command.
+func LoadAliases() *config.Aliases {
+	aliases := config.NewAliases(persister.NewFilePersister(config_helpers.AliasesFileLocation(ltcConfigRoot())))
+	aliases.Load()
+	return aliases
+}
+
 func logger() lager.Logger {
 	logger := lager.NewLogger("ltc")
 	var logLevel lager.LogLevel
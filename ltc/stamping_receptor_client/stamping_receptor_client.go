@@ -0,0 +1,78 @@
+package stamping_receptor_client
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/lrp_annotation"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
+)
+
+// stampingClient records who created or updated each app, and when, in the
+// app's annotation, so that ltc status can show where an app came from
+// without any other tooling.  Apps whose annotation isn't a JSON object, and
+// the apps lattice uses internally, are left as they are.
+type stampingClient struct {
+	receptor.Client
+	clock clock.Clock
+	user  string
+}
+
+func New(client receptor.Client, clock clock.Clock, user string) receptor.Client {
+	return &stampingClient{client, clock, user}
+}
+
+// CreateDesiredLRP stamps the app as created now, unless the annotation
+// already says when it was created, as it does when an app is created again
+// to change it or restored from its recorded definition.  Those are stamped
+// as updated instead.
+func (c *stampingClient) CreateDesiredLRP(request receptor.DesiredLRPCreateRequest) error {
+	if isInternal(request.ProcessGuid) {
+		return c.Client.CreateDesiredLRP(request)
+	}
+
+	if annotation, err := lrp_annotation.Parse(request.Annotation); err == nil {
+		deployment := annotation.Deployment()
+		if deployment.CreatedAt == nil {
+			deployment = lrp_annotation.Deployment{CreatedAt: c.now(), CreatedBy: c.user}
+		} else {
+			deployment.UpdatedAt, deployment.UpdatedBy = c.now(), c.user
+		}
+		annotation.SetDeployment(deployment)
+		request.Annotation = annotation.String()
+	}
+	return c.Client.CreateDesiredLRP(request)
+}
+
+// UpdateDesiredLRP stamps updates that replace the app's annotation, such
+// as changing its labels or its definition in place, as made now.  ltc
+// builds those annotations from the app's current one, so they already say
+// when the app was created.  Updates that only scale or route the app are
+// passed on as they are, rather than fetching the app to stamp them.
+func (c *stampingClient) UpdateDesiredLRP(processGuid string, update receptor.DesiredLRPUpdateRequest) error {
+	if isInternal(processGuid) || update.Annotation == nil {
+		return c.Client.UpdateDesiredLRP(processGuid, update)
+	}
+
+	annotation, err := lrp_annotation.Parse(*update.Annotation)
+	if err != nil {
+		return c.Client.UpdateDesiredLRP(processGuid, update)
+	}
+
+	deployment := annotation.Deployment()
+	deployment.UpdatedAt, deployment.UpdatedBy = c.now(), c.user
+	annotation.SetDeployment(deployment)
+	stamped := annotation.String()
+	update.Annotation = &stamped
+	return c.Client.UpdateDesiredLRP(processGuid, update)
+}
+
+func (c *stampingClient) now() *time.Time {
+	now := c.clock.Now().UTC().Truncate(time.Second)
+	return &now
+}
+
+func isInternal(processGuid string) bool {
+	return processGuid == reserved_app_ids.LatticeDebugLogStreamAppId || processGuid == reserved_app_ids.LatticeSecretsAppId
+}
@@ -0,0 +1,129 @@
+package stamping_receptor_client_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/stamping_receptor_client"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+)
+
+var _ = Describe("StampingReceptorClient", func() {
+	var (
+		fakeReceptorClient *fake_receptor.FakeClient
+		fakeClock          *fakeclock.FakeClock
+		stampingClient     receptor.Client
+	)
+
+	BeforeEach(func() {
+		fakeReceptorClient = &fake_receptor.FakeClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 500, time.UTC))
+		stampingClient = stamping_receptor_client.New(fakeReceptorClient, fakeClock, "bob")
+	})
+
+	Describe("CreateDesiredLRP", func() {
+		It("stamps the app as created now by the user", func() {
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "web", Annotation: `{"udp_ports":[53]}`})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+			request := fakeReceptorClient.CreateDesiredLRPArgsForCall(0)
+			Expect(request.ProcessGuid).To(Equal("web"))
+			Expect(request.Annotation).To(MatchJSON(`{"udp_ports":[53],"deployment":{"created_at":"2015-07-04T12:00:00Z","created_by":"bob"}}`))
+		})
+
+		It("stamps apps without an annotation", func() {
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "web"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Annotation).To(MatchJSON(`{"deployment":{"created_at":"2015-07-04T12:00:00Z","created_by":"bob"}}`))
+		})
+
+		It("stamps the app as updated when it already says when it was created", func() {
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+				ProcessGuid: "web",
+				Annotation:  `{"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice"}}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Annotation).To(MatchJSON(`{"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice","updated_at":"2015-07-04T12:00:00Z","updated_by":"bob"}}`))
+		})
+
+		It("leaves annotations that aren't JSON objects alone", func() {
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "web", Annotation: "some notes"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Annotation).To(Equal("some notes"))
+		})
+
+		It("leaves the apps lattice uses internally alone", func() {
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: reserved_app_ids.LatticeSecretsAppId, Annotation: `{"password":"s3cret"}`})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPArgsForCall(0).Annotation).To(Equal(`{"password":"s3cret"}`))
+		})
+
+		It("returns errors from the receptor", func() {
+			fakeReceptorClient.CreateDesiredLRPReturns(errors.New("boom"))
+
+			err := stampingClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{ProcessGuid: "web"})
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+
+	Describe("UpdateDesiredLRP", func() {
+		It("stamps the annotation the update gives the app as updated now by the user", func() {
+			annotation := `{"udp_ports":[54],"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice"}}`
+			err := stampingClient.UpdateDesiredLRP("web", receptor.DesiredLRPUpdateRequest{Annotation: &annotation})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.UpdateDesiredLRPCallCount()).To(Equal(1))
+			processGuid, update := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(processGuid).To(Equal("web"))
+			Expect(*update.Annotation).To(MatchJSON(`{"udp_ports":[54],"deployment":{"created_at":"2015-07-01T09:00:00Z","created_by":"alice","updated_at":"2015-07-04T12:00:00Z","updated_by":"bob"}}`))
+			Expect(fakeReceptorClient.GetDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("leaves updates that don't change the annotation alone", func() {
+			instances := 3
+			err := stampingClient.UpdateDesiredLRP("web", receptor.DesiredLRPUpdateRequest{Instances: &instances})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, update := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(*update.Instances).To(Equal(3))
+			Expect(update.Annotation).To(BeNil())
+			Expect(fakeReceptorClient.GetDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("leaves annotations that aren't JSON objects alone", func() {
+			annotation := "some notes"
+			err := stampingClient.UpdateDesiredLRP("web", receptor.DesiredLRPUpdateRequest{Annotation: &annotation})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, update := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(*update.Annotation).To(Equal("some notes"))
+		})
+
+		It("leaves the apps lattice uses internally alone", func() {
+			annotation := `{"password":"s3cret"}`
+			err := stampingClient.UpdateDesiredLRP(reserved_app_ids.LatticeSecretsAppId, receptor.DesiredLRPUpdateRequest{Annotation: &annotation})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, update := fakeReceptorClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(*update.Annotation).To(Equal(`{"password":"s3cret"}`))
+		})
+
+		It("returns errors from the receptor", func() {
+			fakeReceptorClient.UpdateDesiredLRPReturns(errors.New("boom"))
+
+			err := stampingClient.UpdateDesiredLRP("web", receptor.DesiredLRPUpdateRequest{})
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+})
@@ -0,0 +1,254 @@
+package command_factory
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+const DefaultWatchRate time.Duration = 2 * time.Second
+
+// taskNotification is what --webhook posts once the task completes.
+type taskNotification struct {
+	TaskGuid      string `json:"task_guid"`
+	State         string `json:"state"`
+	Failed        bool   `json:"failed"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Result        string `json:"result,omitempty"`
+}
+
+func (factory *TaskExaminerCommandFactory) MakeListTasksCommand() cli.Command {
+	return cli.Command{
+		Name:  "list-tasks",
+		Usage: "Lists the tasks on lattice",
+		Description: `ltc list-tasks [--watch] [--notify=TASK_NAME [--desktop] [--webhook=URL]]
+
+   --watch keeps printing the tasks' state changes as they happen, until
+   interrupted.
+
+   --notify watches until TASK_NAME completes, then shows a desktop
+   notification with --desktop, and posts the task as JSON to URL with
+   --webhook.  Exits with 14 if the task failed.`,
+		Action: factory.listTasks,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "watch, w",
+				Usage: "Prints the tasks' state changes as they happen",
+			},
+			cli.DurationFlag{
+				Name:  "rate, r",
+				Usage: "How often to check the tasks while watching",
+				Value: DefaultWatchRate,
+			},
+			cli.StringFlag{
+				Name:  "notify",
+				Usage: "Watches until TASK_NAME completes",
+			},
+			cli.BoolFlag{
+				Name:  "desktop",
+				Usage: "Shows a desktop notification when the task completes",
+			},
+			cli.StringFlag{
+				Name:  "webhook",
+				Usage: "Posts the task as JSON to URL when it completes",
+			},
+		},
+	}
+}
+
+func (factory *TaskExaminerCommandFactory) listTasks(context *cli.Context) {
+	watchFlag := context.Bool("watch")
+	rateFlag := context.Duration("rate")
+	notifyFlag := context.String("notify")
+	desktopFlag := context.Bool("desktop")
+	webhookFlag := context.String("webhook")
+
+	switch {
+	case (desktopFlag || webhookFlag != "") && notifyFlag == "":
+		factory.ui.SayIncorrectUsage("--desktop and --webhook need --notify=TASK_NAME")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	case rateFlag <= 0:
+		factory.ui.SayIncorrectUsage("--rate must be positive")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	tasks, ok := factory.listTaskStates()
+	if !ok {
+		return
+	}
+	factory.printTasks(tasks)
+
+	if notifyFlag != "" {
+		if _, found := tasks[notifyFlag]; !found {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("No task '%s' was found", notifyFlag)))
+			factory.exitHandler.Exit(exit_codes.NotFound)
+			return
+		}
+	} else if !watchFlag {
+		return
+	}
+
+	done := exit_handler.Done(factory.exitHandler)
+	for {
+		if task, found := tasks[notifyFlag]; found && isCompleted(task) {
+			factory.notify(task, desktopFlag, webhookFlag)
+			return
+		}
+
+		timer := factory.clock.NewTimer(rateFlag)
+		select {
+		case <-timer.C():
+		case <-done:
+			timer.Stop()
+			return
+		}
+
+		updatedTasks, ok := factory.listTaskStates()
+		if !ok {
+			return
+		}
+		factory.printTransitions(tasks, updatedTasks)
+		tasks = updatedTasks
+
+		if _, found := tasks[notifyFlag]; notifyFlag != "" && !found {
+			factory.ui.SayLine(colors.Red(fmt.Sprintf("%s was deleted before it completed.", notifyFlag)))
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+	}
+}
+
+// listTaskStates returns the tasks by name, or reports whether it said why
+// they couldn't be listed and exited.
+func (factory *TaskExaminerCommandFactory) listTaskStates() (map[string]task_examiner.TaskInfo, bool) {
+	taskList, err := factory.taskExaminer.ListTasks()
+	if err != nil {
+		factory.ui.SayLine("Error listing tasks: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return nil, false
+	}
+
+	tasks := make(map[string]task_examiner.TaskInfo, len(taskList))
+	for _, task := range taskList {
+		tasks[task.TaskGuid] = task
+	}
+	return tasks, true
+}
+
+func (factory *TaskExaminerCommandFactory) printTasks(tasks map[string]task_examiner.TaskInfo) {
+	if len(tasks) == 0 {
+		factory.ui.SayLine("No tasks to display.")
+		return
+	}
+
+	w := tabwriter.NewWriter(factory.ui, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", colors.Bold("Task Name"), colors.Bold("Cell ID"), colors.Bold("Status"), colors.Bold("Priority"), colors.Bold("Result"), colors.Bold("Failure Reason"))
+	for _, taskName := range sortedTaskNames(tasks) {
+		task := tasks[taskName]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", colors.Bold(task.TaskGuid), orNA(task.CellID), colorState(task), task.Priority, orNA(task.Result), orNA(task.FailureReason))
+	}
+	w.Flush()
+}
+
+// printTransitions prints a line for each task that was submitted, changed
+// state or was deleted since the tasks were last listed.
+func (factory *TaskExaminerCommandFactory) printTransitions(previous, current map[string]task_examiner.TaskInfo) {
+	timestamp := factory.clock.Now().Format("15:04:05")
+
+	for _, taskName := range sortedTaskNames(current) {
+		task := current[taskName]
+		previousTask, found := previous[taskName]
+		switch {
+		case !found:
+			factory.ui.SayLine(fmt.Sprintf("%s  %s  %s", timestamp, colors.Bold(taskName), colorState(task)))
+		case previousTask.State != task.State:
+			transition := fmt.Sprintf("%s  %s  %s -> %s", timestamp, colors.Bold(taskName), colorState(previousTask), colorState(task))
+			if task.Failed {
+				transition += fmt.Sprintf(" (failed: %s)", task.FailureReason)
+			}
+			factory.ui.SayLine(transition)
+		}
+	}
+
+	for _, taskName := range sortedTaskNames(previous) {
+		if _, found := current[taskName]; !found {
+			factory.ui.SayLine(fmt.Sprintf("%s  %s  deleted", timestamp, colors.Bold(taskName)))
+		}
+	}
+}
+
+func (factory *TaskExaminerCommandFactory) notify(task task_examiner.TaskInfo, desktop bool, webhookURL string) {
+	message := task.TaskGuid + " finished."
+	if task.Failed {
+		message = fmt.Sprintf("%s failed: %s", task.TaskGuid, task.FailureReason)
+		factory.ui.SayLine(colors.Red(message))
+	} else {
+		factory.ui.SayLine(colors.Green(message))
+	}
+
+	notified := true
+	if desktop {
+		if err := factory.notifier.NotifyDesktop("ltc", message); err != nil {
+			factory.ui.SayLine("Error showing desktop notification: " + err.Error())
+			notified = false
+		}
+	}
+	if webhookURL != "" {
+		err := factory.notifier.NotifyWebhook(webhookURL, taskNotification{
+			TaskGuid:      task.TaskGuid,
+			State:         task.State,
+			Failed:        task.Failed,
+			FailureReason: task.FailureReason,
+			Result:        task.Result,
+		})
+		if err != nil {
+			factory.ui.SayLine("Error calling webhook: " + err.Error())
+			notified = false
+		}
+	}
+
+	if task.Failed || !notified {
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+	}
+}
+
+func isCompleted(task task_examiner.TaskInfo) bool {
+	return task.State == receptor.TaskStateCompleted || task.State == receptor.TaskStateResolving
+}
+
+func colorState(task task_examiner.TaskInfo) string {
+	switch {
+	case task.Failed:
+		return colors.Red(task.State)
+	case isCompleted(task):
+		return colors.Green(task.State)
+	default:
+		return colors.Yellow(task.State)
+	}
+}
+
+func sortedTaskNames(tasks map[string]task_examiner.TaskInfo) []string {
+	taskNames := make([]string, 0, len(tasks))
+	for taskName := range tasks {
+		taskNames = append(taskNames, taskName)
+	}
+	sort.Strings(taskNames)
+	return taskNames
+}
+
+func orNA(value string) string {
+	if value == "" {
+		return "N/A"
+	}
+	return value
+}
@@ -0,0 +1,239 @@
+package command_factory_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier/fake_notifier"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("ListTasksCommand", func() {
+	var (
+		fakeTaskExaminer *fake_task_examiner.FakeTaskExaminer
+		outputBuffer     *gbytes.Buffer
+		fakeExitHandler  *fake_exit_handler.FakeExitHandler
+		fakeClock        *fakeclock.FakeClock
+		fakeNotifier     *fake_notifier.FakeNotifier
+		listTasksCommand cli.Command
+	)
+
+	// listTasksReturns makes each call to ListTasks return the next list,
+	// and the last one from then on.
+	listTasksReturns := func(taskLists ...[]task_examiner.TaskInfo) {
+		calls := 0
+		fakeTaskExaminer.ListTasksStub = func() ([]task_examiner.TaskInfo, error) {
+			taskList := taskLists[calls]
+			if calls < len(taskLists)-1 {
+				calls++
+			}
+			return taskList, nil
+		}
+	}
+
+	tick := func() {
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		fakeClock.Increment(command_factory.DefaultWatchRate)
+	}
+
+	BeforeEach(func() {
+		fakeTaskExaminer = new(fake_task_examiner.FakeTaskExaminer)
+		outputBuffer = gbytes.NewBuffer()
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2015, 7, 4, 12, 0, 0, 0, time.UTC))
+		fakeNotifier = &fake_notifier.FakeNotifier{}
+
+		commandFactory := command_factory.NewTaskExaminerCommandFactory(fakeTaskExaminer, terminal.NewUI(nil, outputBuffer, nil), fakeClock, fakeExitHandler, fakeNotifier)
+		listTasksCommand = commandFactory.MakeListTasksCommand()
+	})
+
+	It("lists the tasks", func() {
+		listTasksReturns([]task_examiner.TaskInfo{
+			{TaskGuid: "task-b", State: receptor.TaskStateCompleted, CellID: "cell-01", Failed: true, FailureReason: "exit 1", Priority: "low"},
+			{TaskGuid: "task-a", State: receptor.TaskStateRunning, CellID: "cell-02", Priority: "normal"},
+		})
+
+		test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Task Name")))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Priority")))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Bold("task-a")))
+		Expect(outputBuffer).To(test_helpers.Say("cell-02"))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("RUNNING")))
+		Expect(outputBuffer).To(test_helpers.Say("normal"))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Bold("task-b")))
+		Expect(outputBuffer).To(test_helpers.Say(colors.Red("COMPLETED")))
+		Expect(outputBuffer).To(test_helpers.Say("low"))
+		Expect(outputBuffer).To(test_helpers.Say("exit 1"))
+		Expect(fakeClock.WatcherCount()).To(BeZero())
+	})
+
+	It("says when there are no tasks", func() {
+		listTasksReturns([]task_examiner.TaskInfo{})
+
+		test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("No tasks to display."))
+	})
+
+	It("reports errors listing the tasks", func() {
+		fakeTaskExaminer.ListTasksReturns(nil, errors.New("receptor is down"))
+
+		test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{})
+
+		Expect(outputBuffer).To(test_helpers.SayLine("Error listing tasks: receptor is down"))
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+	})
+
+	Context("with --watch", func() {
+		It("prints state changes until interrupted", func() {
+			listTasksReturns(
+				[]task_examiner.TaskInfo{
+					{TaskGuid: "task-a", State: receptor.TaskStatePending},
+					{TaskGuid: "task-b", State: receptor.TaskStateRunning},
+				},
+				[]task_examiner.TaskInfo{
+					{TaskGuid: "task-a", State: receptor.TaskStateRunning},
+					{TaskGuid: "task-b", State: receptor.TaskStateRunning},
+					{TaskGuid: "task-c", State: receptor.TaskStatePending},
+				},
+				[]task_examiner.TaskInfo{
+					{TaskGuid: "task-a", State: receptor.TaskStateCompleted, Failed: true, FailureReason: "exit 1"},
+					{TaskGuid: "task-c", State: receptor.TaskStatePending},
+				},
+			)
+
+			commandDone := test_helpers.AsyncExecuteCommandWithArgs(listTasksCommand, []string{"--watch"})
+
+			Eventually(outputBuffer).Should(test_helpers.Say(colors.Bold("task-b")))
+			tick()
+			Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:02  " + colors.Bold("task-a") + "  " + colors.Yellow("PENDING") + " -> " + colors.Yellow("RUNNING")))
+			Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:02  " + colors.Bold("task-c") + "  " + colors.Yellow("PENDING")))
+			tick()
+			Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:04  " + colors.Bold("task-a") + "  " + colors.Yellow("RUNNING") + " -> " + colors.Red("COMPLETED") + " (failed: exit 1)"))
+			Eventually(outputBuffer).Should(test_helpers.SayLine("12:00:04  " + colors.Bold("task-b") + "  deleted"))
+			tick()
+			Consistently(outputBuffer).ShouldNot(test_helpers.Say("12:00:06"))
+			Expect(commandDone).NotTo(BeClosed())
+
+			fakeExitHandler.Exit(exit_codes.SigInt)
+
+			Eventually(commandDone).Should(BeClosed())
+		})
+
+		It("stops when the tasks can't be listed", func() {
+			listTasksReturns([]task_examiner.TaskInfo{})
+
+			commandDone := test_helpers.AsyncExecuteCommandWithArgs(listTasksCommand, []string{"--watch"})
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeTaskExaminer.ListTasksReturns(nil, errors.New("receptor is down"))
+			fakeClock.Increment(command_factory.DefaultWatchRate)
+
+			Eventually(commandDone).Should(BeClosed())
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing tasks: receptor is down"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+
+	Context("with --notify", func() {
+		It("notifies once the task finishes", func() {
+			listTasksReturns(
+				[]task_examiner.TaskInfo{{TaskGuid: "nightly-report", State: receptor.TaskStateRunning}},
+				[]task_examiner.TaskInfo{{TaskGuid: "nightly-report", State: receptor.TaskStateCompleted, Result: "42 rows"}},
+			)
+
+			commandDone := test_helpers.AsyncExecuteCommandWithArgs(listTasksCommand, []string{"--notify=nightly-report", "--desktop", "--webhook=https://hooks.example.com/ltc"})
+
+			tick()
+			Eventually(commandDone).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("nightly-report finished.")))
+			Expect(fakeNotifier.NotifyDesktopCallCount()).To(Equal(1))
+			title, message := fakeNotifier.NotifyDesktopArgsForCall(0)
+			Expect(title).To(Equal("ltc"))
+			Expect(message).To(Equal("nightly-report finished."))
+
+			Expect(fakeNotifier.NotifyWebhookCallCount()).To(Equal(1))
+			url, payload := fakeNotifier.NotifyWebhookArgsForCall(0)
+			Expect(url).To(Equal("https://hooks.example.com/ltc"))
+			Expect(payload).To(BeEquivalentTo(struct {
+				TaskGuid      string `json:"task_guid"`
+				State         string `json:"state"`
+				Failed        bool   `json:"failed"`
+				FailureReason string `json:"failure_reason,omitempty"`
+				Result        string `json:"result,omitempty"`
+			}{TaskGuid: "nightly-report", State: receptor.TaskStateCompleted, Result: "42 rows"}))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("exits with 14 when the task failed", func() {
+			listTasksReturns([]task_examiner.TaskInfo{{TaskGuid: "nightly-report", State: receptor.TaskStateCompleted, Failed: true, FailureReason: "exit 1"}})
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{"--notify=nightly-report", "--desktop"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("nightly-report failed: exit 1")))
+			_, message := fakeNotifier.NotifyDesktopArgsForCall(0)
+			Expect(message).To(Equal("nightly-report failed: exit 1"))
+			Expect(fakeNotifier.NotifyWebhookCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("reports notifications that couldn't be sent", func() {
+			listTasksReturns([]task_examiner.TaskInfo{{TaskGuid: "nightly-report", State: receptor.TaskStateCompleted}})
+			fakeNotifier.NotifyWebhookReturns(errors.New("https://hooks.example.com/ltc returned 502 Bad Gateway"))
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{"--notify=nightly-report", "--webhook=https://hooks.example.com/ltc"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error calling webhook: https://hooks.example.com/ltc returned 502 Bad Gateway"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("fails when the task is deleted before it completes", func() {
+			listTasksReturns(
+				[]task_examiner.TaskInfo{{TaskGuid: "nightly-report", State: receptor.TaskStateRunning}},
+				[]task_examiner.TaskInfo{},
+			)
+
+			commandDone := test_helpers.AsyncExecuteCommandWithArgs(listTasksCommand, []string{"--notify=nightly-report", "--desktop"})
+
+			tick()
+			Eventually(commandDone).Should(BeClosed())
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("nightly-report was deleted before it completed.")))
+			Expect(fakeNotifier.NotifyDesktopCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("fails when there's no such task", func() {
+			listTasksReturns([]task_examiner.TaskInfo{})
+
+			test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{"--notify=nightly-report"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("No task 'nightly-report' was found")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+	})
+
+	It("requires --notify for --desktop and --webhook", func() {
+		test_helpers.ExecuteCommandWithArgs(listTasksCommand, []string{"--desktop"})
+
+		Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: --desktop and --webhook need --notify=TASK_NAME"))
+		Expect(fakeTaskExaminer.ListTasksCallCount()).To(BeZero())
+		Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+	})
+})
@@ -2,24 +2,29 @@ package command_factory
 
 import (
 	"fmt"
+	"io/ioutil"
 	"text/tabwriter"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/notifier"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock"
 )
 
 type TaskExaminerCommandFactory struct {
 	taskExaminer task_examiner.TaskExaminer
 	ui           terminal.UI
+	clock        clock.Clock
 	exitHandler  exit_handler.ExitHandler
+	notifier     notifier.Notifier
 }
 
-func NewTaskExaminerCommandFactory(taskExaminer task_examiner.TaskExaminer, ui terminal.UI, exitHandler exit_handler.ExitHandler) *TaskExaminerCommandFactory {
-	return &TaskExaminerCommandFactory{taskExaminer, ui, exitHandler}
+func NewTaskExaminerCommandFactory(taskExaminer task_examiner.TaskExaminer, ui terminal.UI, clock clock.Clock, exitHandler exit_handler.ExitHandler, notifier notifier.Notifier) *TaskExaminerCommandFactory {
+	return &TaskExaminerCommandFactory{taskExaminer, ui, clock, exitHandler, notifier}
 }
 
 func (factory *TaskExaminerCommandFactory) MakeTaskCommand() cli.Command {
@@ -36,6 +41,28 @@ func (factory *TaskExaminerCommandFactory) MakeTaskCommand() cli.Command {
 	return taskCommand
 }
 
+func (factory *TaskExaminerCommandFactory) MakeTaskResultCommand() cli.Command {
+	return cli.Command{
+		Name:  "task-result",
+		Usage: "Prints the result of a completed task",
+		Description: `ltc task-result TASK_NAME [--output=FILE]
+
+   Prints the contents of the task's result file, as the task left it when it
+   completed.  Tasks declare the file with result_file in their JSON, or with
+   'ltc run --result-file'.  Lattice keeps at most 10KB of it.
+
+   Exits with 14 if the task hasn't completed or failed, and 17 if there's no
+   such task.`,
+		Action: factory.taskResult,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "output, o",
+				Usage: "Writes the result to FILE instead of printing it",
+			},
+		},
+	}
+}
+
 func (factory *TaskExaminerCommandFactory) task(context *cli.Context) {
 	taskName := context.Args().First()
 	if taskName == "" {
@@ -44,15 +71,8 @@ func (factory *TaskExaminerCommandFactory) task(context *cli.Context) {
 		return
 	}
 
-	taskInfo, err := factory.taskExaminer.TaskStatus(taskName)
-	if err != nil {
-		if err.Error() == task_examiner.TaskNotFoundErrorMessage {
-			factory.ui.Say(colors.Red(fmt.Sprintf("No task '%s' was found", taskName)))
-			factory.exitHandler.Exit(exit_codes.CommandFailed)
-			return
-		}
-		factory.ui.Say(colors.Red("Error fetching task result: " + err.Error()))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+	taskInfo, ok := factory.taskStatus(taskName)
+	if !ok {
 		return
 	}
 
@@ -60,6 +80,7 @@ func (factory *TaskExaminerCommandFactory) task(context *cli.Context) {
 
 	fmt.Fprintf(w, "%s\t%s\n", "Task Name", taskInfo.TaskGuid)
 	fmt.Fprintf(w, "%s\t%s\n", "Cell ID", taskInfo.CellID)
+	fmt.Fprintf(w, "%s\t%s\n", "Priority", taskInfo.Priority)
 	if taskInfo.State == "PENDING" || taskInfo.State == "CLAIMED" || taskInfo.State == "RUNNING" {
 		fmt.Fprintf(w, "%s\t%s\n", "Status", colors.Yellow(taskInfo.State))
 	} else if (taskInfo.State == "COMPLETED" || taskInfo.State == "RESOLVING") && !taskInfo.Failed {
@@ -72,3 +93,58 @@ func (factory *TaskExaminerCommandFactory) task(context *cli.Context) {
 
 	w.Flush()
 }
+
+func (factory *TaskExaminerCommandFactory) taskResult(context *cli.Context) {
+	outputFlag := context.String("output")
+	taskName := context.Args().First()
+	if taskName == "" {
+		factory.ui.SayIncorrectUsage("TASK_NAME required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	taskInfo, ok := factory.taskStatus(taskName)
+	if !ok {
+		return
+	}
+
+	if taskInfo.Failed {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%s failed: %s", taskName, taskInfo.FailureReason)))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	} else if taskInfo.State != "COMPLETED" && taskInfo.State != "RESOLVING" {
+		factory.ui.SayLine(fmt.Sprintf("%s hasn't completed yet, so it has no result.", taskName))
+		factory.ui.SayLine(fmt.Sprintf("To view status:\n\tltc task %s", taskName))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	if outputFlag == "" {
+		factory.ui.Say(taskInfo.Result)
+		return
+	}
+
+	if err := ioutil.WriteFile(outputFlag, []byte(taskInfo.Result), 0644); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error writing %s: %s", outputFlag, err))
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+	factory.ui.SayLine(fmt.Sprintf("Wrote the result of %s to %s.", taskName, outputFlag))
+}
+
+// taskStatus reports whether the task could be looked up, having said why
+// and exited if not.
+func (factory *TaskExaminerCommandFactory) taskStatus(taskName string) (task_examiner.TaskInfo, bool) {
+	taskInfo, err := factory.taskExaminer.TaskStatus(taskName)
+	if err != nil {
+		if err.Error() == task_examiner.TaskNotFoundErrorMessage {
+			factory.ui.Say(colors.Red(fmt.Sprintf("No task '%s' was found", taskName)))
+			factory.exitHandler.Exit(exit_codes.NotFound)
+			return task_examiner.TaskInfo{}, false
+		}
+		factory.ui.Say(colors.Red("Error fetching task result: " + err.Error()))
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		return task_examiner.TaskInfo{}, false
+	}
+	return taskInfo, true
+}
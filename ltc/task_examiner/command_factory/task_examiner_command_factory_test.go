@@ -2,6 +2,9 @@ package command_factory_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -38,7 +41,7 @@ var _ = Describe("CommandFactory", func() {
 		var taskCommand cli.Command
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewTaskExaminerCommandFactory(fakeTaskExaminer, terminalUI, fakeExitHandler)
+			commandFactory := command_factory.NewTaskExaminerCommandFactory(fakeTaskExaminer, terminalUI, nil, fakeExitHandler, nil)
 			taskCommand = commandFactory.MakeTaskCommand()
 		})
 
@@ -50,6 +53,7 @@ var _ = Describe("CommandFactory", func() {
 				Failed:        false,
 				FailureReason: "",
 				Result:        "",
+				Priority:      "low",
 			}
 			fakeTaskExaminer.TaskStatusReturns(taskInfo, nil)
 
@@ -62,6 +66,8 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.Say("boop"))
 			Expect(outputBuffer).To(test_helpers.Say("Cell ID"))
 			Expect(outputBuffer).To(test_helpers.Say("cell-01"))
+			Expect(outputBuffer).To(test_helpers.Say("Priority"))
+			Expect(outputBuffer).To(test_helpers.Say("low"))
 			Expect(outputBuffer).To(test_helpers.Say("Status"))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Yellow("PENDING")))
 			Expect(outputBuffer).NotTo(test_helpers.Say("Result"))
@@ -139,7 +145,7 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeTaskExaminer.TaskStatusCallCount()).To(Equal(1))
 				Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("boop"))
 				Expect(outputBuffer).To(test_helpers.Say(colors.Red("No task 'boop' was found")))
-				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
 			})
 
 			It("prints random errors", func() {
@@ -154,4 +160,92 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 	})
+
+	Describe("TaskResultCommand", func() {
+		var taskResultCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewTaskExaminerCommandFactory(fakeTaskExaminer, terminalUI, nil, fakeExitHandler, nil)
+			taskResultCommand = commandFactory.MakeTaskResultCommand()
+		})
+
+		It("prints the result of a completed task", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{TaskGuid: "report", State: "COMPLETED", Result: "{\"rows\":42}\n"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report"})
+
+			Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("report"))
+			Expect(string(outputBuffer.Contents())).To(Equal("{\"rows\":42}\n"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		Context("with --output", func() {
+			var tmpDir string
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "task-result")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(tmpDir)).To(Succeed())
+			})
+
+			It("writes the result to the file", func() {
+				fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED", Result: "42"}, nil)
+				outputPath := filepath.Join(tmpDir, "result.json")
+
+				test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report", "--output", outputPath})
+
+				Expect(ioutil.ReadFile(outputPath)).To(Equal([]byte("42")))
+				Expect(outputBuffer).To(test_helpers.SayLine("Wrote the result of report to " + outputPath + "."))
+			})
+
+			It("reports errors writing the file", func() {
+				fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED", Result: "42"}, nil)
+				outputPath := filepath.Join(tmpDir, "missing", "result.json")
+
+				test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report", "--output", outputPath})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error writing " + outputPath))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+			})
+		})
+
+		It("reports a failed task", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED", Failed: true, FailureReason: "Exited with status 1"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("report failed: Exited with status 1")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("says when the task hasn't completed", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "RUNNING"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("report hasn't completed yet, so it has no result."))
+			Expect(outputBuffer).To(test_helpers.SayLine("To view status:\n\tltc task report"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits with not found for a missing task", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{}, errors.New(task_examiner.TaskNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{"report"})
+
+			Expect(outputBuffer).To(test_helpers.Say(colors.Red("No task 'report' was found")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("requires a task name", func() {
+			test_helpers.ExecuteCommandWithArgs(taskResultCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Incorrect Usage: TASK_NAME required"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
 })
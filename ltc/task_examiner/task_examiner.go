@@ -1,6 +1,7 @@
 package task_examiner
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/cloudfoundry-incubator/receptor"
@@ -8,6 +9,9 @@ import (
 
 const (
 	TaskNotFoundErrorMessage = "Task not found."
+
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
 )
 
 type TaskInfo struct {
@@ -17,6 +21,7 @@ type TaskInfo struct {
 	Failed        bool
 	FailureReason string
 	Result        string
+	Priority      string
 }
 
 //go:generate counterfeiter -o fake_task_examiner/fake_task_examiner.go . TaskExaminer
@@ -51,6 +56,7 @@ func (e *taskExaminer) TaskStatus(taskName string) (TaskInfo, error) {
 		Failed:        taskResponse.Failed,
 		FailureReason: taskResponse.FailureReason,
 		Result:        taskResponse.Result,
+		Priority:      taskPriority(taskResponse.Annotation),
 	}, nil
 }
 
@@ -68,8 +74,21 @@ func (e *taskExaminer) ListTasks() ([]TaskInfo, error) {
 			FailureReason: task.FailureReason,
 			Result:        task.Result,
 			State:         task.State,
+			Priority:      taskPriority(task.Annotation),
 		}
 		taskInfoList = append(taskInfoList, taskInfo)
 	}
 	return taskInfoList, err
 }
+
+// taskPriority reads the priority ltc run and ltc submit-task keep in a
+// task's annotation.  Tasks without one are normal.
+func taskPriority(annotation string) string {
+	var parsed struct {
+		Priority string `json:"priority"`
+	}
+	if err := json.Unmarshal([]byte(annotation), &parsed); err != nil || parsed.Priority == "" {
+		return PriorityNormal
+	}
+	return parsed.Priority
+}
@@ -47,10 +47,29 @@ var _ = Describe("TaskExaminer", func() {
 			Expect(taskInfo.Failed).To(BeFalse())
 			Expect(taskInfo.FailureReason).To(BeEmpty())
 			Expect(taskInfo.Result).To(Equal("some-result"))
+			Expect(taskInfo.Priority).To(Equal(task_examiner.PriorityNormal))
 			Expect(fakeReceptorClient.GetTaskCallCount()).To(Equal(1))
 			Expect(fakeReceptorClient.GetTaskArgsForCall(0)).To(Equal("boop"))
 		})
 
+		It("reads the priority from the task's annotation", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "boop", Annotation: `{"priority":"low"}`}, nil)
+
+			taskInfo, err := taskExaminer.TaskStatus("boop")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskInfo.Priority).To(Equal(task_examiner.PriorityLow))
+		})
+
+		It("treats tasks whose annotation isn't ltc's as normal priority", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "boop", Annotation: "nightly backup"}, nil)
+
+			taskInfo, err := taskExaminer.TaskStatus("boop")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskInfo.Priority).To(Equal(task_examiner.PriorityNormal))
+		})
+
 		Context("when the receptor returns errors", func() {
 			It("returns exists false for TaskNotFound", func() {
 				receptorError := receptor.Error{Type: receptor.TaskNotFound, Message: "could not locate this"}
@@ -99,6 +118,7 @@ var _ = Describe("TaskExaminer", func() {
 					FailureReason: "failed",
 					Result:        "Failed",
 					State:         "COMPLETED",
+					Annotation:    `{"priority":"low"}`,
 				},
 			}
 			fakeReceptorClient.TasksReturns(taskListReturns, nil)
@@ -114,11 +134,13 @@ var _ = Describe("TaskExaminer", func() {
 			Expect(task1.FailureReason).To(Equal(""))
 			Expect(task1.Result).To(Equal("Finished"))
 			Expect(task1.State).To(Equal("COMPLETED"))
+			Expect(task1.Priority).To(Equal(task_examiner.PriorityNormal))
 
 			task2 := taskList[1]
 			Expect(task2.TaskGuid).To(Equal("task-guid-2"))
 			Expect(task2.CellID).To(Equal("cell-02"))
 			Expect(task2.Result).To(Equal("Failed"))
+			Expect(task2.Priority).To(Equal(task_examiner.PriorityLow))
 		})
 
 		It("when receptor returns error", func() {
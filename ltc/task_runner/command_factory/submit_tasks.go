@@ -0,0 +1,123 @@
+package command_factory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+)
+
+// maxConcurrentSubmissions bounds how many tasks submit-task sends to the
+// receptor at once, so that large batches don't flood it.
+const maxConcurrentSubmissions = 8
+
+// taskDefinition is the JSON of one task in a batch, along with where it
+// came from so that the results can point back at it.
+type taskDefinition struct {
+	source string
+	json   []byte
+}
+
+// readTaskDirectory returns the task in each .json file in dir, in the
+// order of their names.
+func readTaskDirectory(dir string) ([]taskDefinition, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := []taskDefinition{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		jsonBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, taskDefinition{source: path, json: jsonBytes})
+	}
+	return definitions, nil
+}
+
+func isJSONArray(jsonBytes []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(jsonBytes), []byte("["))
+}
+
+// splitTaskArray returns each task in a JSON array of tasks.
+func splitTaskArray(path string, jsonBytes []byte) ([]taskDefinition, error) {
+	var tasks []json.RawMessage
+	if err := json.Unmarshal(jsonBytes, &tasks); err != nil {
+		return nil, task_runner.InvalidManifestError{Err: err}
+	}
+
+	definitions := make([]taskDefinition, len(tasks))
+	for i, task := range tasks {
+		definitions[i] = taskDefinition{source: fmt.Sprintf("%s[%d]", path, i), json: task}
+	}
+	return definitions, nil
+}
+
+// submitTasks submits every task, even once some fail, and then says how
+// each of them went.
+func (factory *TaskRunnerCommandFactory) submitTasks(definitions []taskDefinition, options task_runner.TaskOptions) {
+	taskNames := make([]string, len(definitions))
+	errs := make([]error, len(definitions))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < maxConcurrentSubmissions && worker < len(definitions); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				jsonBytes, err := task_runner.ApplyTaskOptions(definitions[i].json, options)
+				if err == nil {
+					taskNames[i], err = factory.taskRunner.SubmitTask(jsonBytes)
+				}
+				errs[i] = err
+			}
+		}()
+	}
+
+	for i := range definitions {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	submitted := 0
+	w := tabwriter.NewWriter(factory.ui, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\n", colors.Bold("Source"), colors.Bold("Task Name"), colors.Bold("Result"))
+	for i, definition := range definitions {
+		taskName := taskNames[i]
+		if taskName == "" {
+			taskName = "N/A"
+		}
+		result := colors.Green("submitted")
+		if errs[i] != nil {
+			result = colors.Red(strings.Replace(errs[i].Error(), "\n", " ", -1))
+		} else {
+			submitted++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", definition.source, taskName, result)
+	}
+	w.Flush()
+
+	summary := fmt.Sprintf("Submitted %d of %d tasks.", submitted, len(definitions))
+	if submitted == len(definitions) {
+		factory.ui.SayLine(colors.Green(summary))
+		return
+	}
+	factory.ui.SayLine(colors.Red(summary))
+	factory.exitHandler.Exit(exit_codes.CommandFailed)
+}
@@ -3,10 +3,12 @@ package command_factory
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
@@ -33,11 +35,32 @@ func NewTaskRunnerCommandFactory(taskRunner task_runner.TaskRunner, ui terminal.
 
 func (factory *TaskRunnerCommandFactory) MakeSubmitTaskCommand() cli.Command {
 	var submitTaskCommand = cli.Command{
-		Name:        "submit-task",
-		Aliases:     []string{"su"},
-		Usage:       "Submits a task from JSON on lattice",
-		Description: "ltc submit-task /path/to/json",
-		Action:      factory.submitTask,
+		Name:    "submit-task",
+		Aliases: []string{"su"},
+		Usage:   "Submits a task from JSON on lattice",
+		Description: `ltc submit-task /path/to/json
+
+   The JSON may be a single task, or an array of tasks.  Given a directory,
+   submits the task in each of its .json files.  Several tasks are submitted
+   at once, and a table shows how each of them went.
+
+   --priority=low marks a long batch job so that it gets little CPU while apps
+   and their staging want it.`,
+		Action: factory.submitTask,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "priority, p",
+				Usage: "Priority of the task: normal or low (overrides the JSON)",
+			},
+			cli.IntFlag{
+				Name:  "memory-mb, m",
+				Usage: "Memory limit for container in MB (overrides the JSON)",
+			},
+			cli.IntFlag{
+				Name:  "disk-mb, d",
+				Usage: "Disk limit for container in MB (overrides the JSON)",
+			},
+		},
 	}
 
 	return submitTaskCommand
@@ -50,12 +73,49 @@ func (factory *TaskRunnerCommandFactory) MakeDeleteTaskCommand() cli.Command {
 		Usage:       "Deletes the given task",
 		Description: "ltc delete-task TASK_NAME",
 		Action:      factory.deleteTask,
-		Flags:       []cli.Flag{},
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "force, f",
+				Usage: "Deletes the task without asking for confirmation",
+			},
+		},
 	}
 	return taskDeleteCommand
 }
 
+func (factory *TaskRunnerCommandFactory) MakeCancelTaskCommand() cli.Command {
+	var cancelTaskCommand = cli.Command{
+		Name:  "cancel-task",
+		Usage: "Cancels a task that hasn't completed",
+		Description: `ltc cancel-task TASK_NAME
+
+   Stops the task if it is running.  The cancelled task is kept as a failed
+   task until it is removed with 'ltc delete-task TASK_NAME'.`,
+		Action: factory.cancelTask,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "force, f",
+				Usage: "Cancels the task without asking for confirmation",
+			},
+		},
+	}
+	return cancelTaskCommand
+}
+
+func (factory *TaskRunnerCommandFactory) MakeRetryTaskCommand() cli.Command {
+	var retryTaskCommand = cli.Command{
+		Name:  "retry-task",
+		Usage: "Submits a failed task again",
+		Description: `ltc retry-task TASK_NAME
+
+   Replaces the failed task with a new one submitted from its definition.`,
+		Action: factory.retryTask,
+	}
+	return retryTaskCommand
+}
+
 func (factory *TaskRunnerCommandFactory) submitTask(context *cli.Context) {
+	priorityFlag := context.String("priority")
 	filePath := context.Args().First()
 	if filePath == "" {
 		factory.ui.Say("Path to JSON is required")
@@ -63,17 +123,68 @@ func (factory *TaskRunnerCommandFactory) submitTask(context *cli.Context) {
 		return
 	}
 
-	jsonBytes, err := ioutil.ReadFile(filePath)
+	if err := task_runner.ValidatePriority(priorityFlag); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	options := task_runner.TaskOptions{
+		Priority: priorityFlag,
+		MemoryMB: context.Int("memory-mb"),
+		DiskMB:   context.Int("disk-mb"),
+	}
+
+	var definitions []taskDefinition
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		definitions, err = readTaskDirectory(filePath)
+		if err != nil {
+			factory.ui.SayLine("Error reading directory: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+	} else {
+		jsonBytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			factory.ui.Say("Error reading file: " + err.Error())
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+		if !isJSONArray(jsonBytes) {
+			factory.submitOneTask(jsonBytes, options)
+			return
+		}
+		definitions, err = splitTaskArray(filePath, jsonBytes)
+		if err != nil {
+			factory.ui.SayLine("Error reading task JSON: " + err.Error())
+			factory.ui.SayRemedy(err)
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+			return
+		}
+	}
+
+	if len(definitions) == 0 {
+		factory.ui.SayLine(fmt.Sprintf("No tasks to submit in %s.", filePath))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	factory.submitTasks(definitions, options)
+}
+
+func (factory *TaskRunnerCommandFactory) submitOneTask(jsonBytes []byte, options task_runner.TaskOptions) {
+	jsonBytes, err := task_runner.ApplyTaskOptions(jsonBytes, options)
 	if err != nil {
-		factory.ui.Say("Error reading file: " + err.Error())
-		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		factory.ui.SayLine("Error reading task JSON: " + err.Error())
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 		return
 	}
 
 	taskName, err := factory.taskRunner.SubmitTask(jsonBytes)
 	if err != nil {
-		factory.ui.Say(fmt.Sprintf("Error submitting %s: %s", taskName, err))
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.ui.SayLine(fmt.Sprintf("Error submitting %s: %s", taskName, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 		return
 	}
 	factory.ui.Say(colors.Green("Successfully submitted "+taskName) + "\n")
@@ -86,13 +197,67 @@ func (factory *TaskRunnerCommandFactory) deleteTask(context *cli.Context) {
 		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
-	factory.ui.Say("Deleting the task " + colors.Bold(taskGuid) + "\n")
+
+	if !context.Bool("force") && !factory.ui.PromptForConfirmation(fmt.Sprintf("Really delete task %s?", taskGuid)) {
+		factory.ui.SayLine("Task deletion cancelled.")
+		return
+	}
+
+	factory.ui.SayInfo("Deleting the task " + colors.Bold(taskGuid) + "\n")
 	err := factory.taskRunner.DeleteTask(taskGuid)
 	if err != nil {
 		factory.ui.Say("Error Deleting the task " + colors.Bold(taskGuid) + "\n")
 		factory.ui.Say("Failure Reason:" + colors.Red(err.Error()) + "\n")
-		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
 		return
 	}
 	factory.ui.Say(colors.Green("OK"))
 }
+
+func (factory *TaskRunnerCommandFactory) cancelTask(context *cli.Context) {
+	taskGuid := context.Args().First()
+	if taskGuid == "" {
+		factory.ui.SayIncorrectUsage("Please input a valid TASK_GUID")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !context.Bool("force") && !factory.ui.PromptForConfirmation(fmt.Sprintf("Really cancel task %s?", taskGuid)) {
+		factory.ui.SayLine("Task cancellation cancelled.")
+		return
+	}
+
+	if err := factory.taskRunner.CancelTask(taskGuid); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error cancelling %s: %s", taskGuid, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exitCodeForTaskError(err))
+		return
+	}
+	factory.ui.SayLine(colors.Green("Cancelled " + taskGuid))
+	factory.ui.SayLine(fmt.Sprintf("To remove it:\n\tltc delete-task %s", taskGuid))
+}
+
+func (factory *TaskRunnerCommandFactory) retryTask(context *cli.Context) {
+	taskGuid := context.Args().First()
+	if taskGuid == "" {
+		factory.ui.SayIncorrectUsage("Please input a valid TASK_GUID")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if err := factory.taskRunner.RetryTask(taskGuid); err != nil {
+		factory.ui.SayLine(fmt.Sprintf("Error retrying %s: %s", taskGuid, err))
+		factory.ui.SayRemedy(err)
+		factory.exitHandler.Exit(exitCodeForTaskError(err))
+		return
+	}
+	factory.ui.SayLine(colors.Green("Resubmitted " + taskGuid))
+	factory.ui.SayLine(fmt.Sprintf("To view its status:\n\tltc task %s", taskGuid))
+}
+
+func exitCodeForTaskError(err error) int {
+	if err.Error() == task_examiner.TaskNotFoundErrorMessage {
+		return exit_codes.NotFound
+	}
+	return exit_codes.ForError(err, exit_codes.CommandFailed)
+}
@@ -1,11 +1,13 @@
 package command_factory_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,11 +17,13 @@ import (
 	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner/fake_task_examiner"
+	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/command_factory"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner/fake_task_runner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/codegangsta/cli"
 )
 
@@ -91,6 +95,141 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 			})
 
+			It("suggests a remedy when the task already exists", func() {
+				ioutil.WriteFile(tmpFile.Name(), []byte(`{"Value":"test value"}`), 0700)
+				fakeTaskRunner.SubmitTaskReturns("some-task", task_runner.TaskAlreadyExistsError{TaskGuid: "some-task"})
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{tmpFile.Name()})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error submitting some-task: some-task has already been submitted"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Choose another task_guid, or run ltc delete-task some-task first."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.AlreadyExists}))
+			})
+
+			It("applies the priority and resource flags to the task", func() {
+				ioutil.WriteFile(tmpFile.Name(), []byte(`{"task_guid":"nightly-report","memory_mb":128}`), 0700)
+				fakeTaskRunner.SubmitTaskReturns("nightly-report", nil)
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--priority=low", "--memory-mb=1024", "--disk-mb=512", tmpFile.Name()})
+
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(Equal(1))
+				task := receptor.TaskCreateRequest{}
+				Expect(json.Unmarshal(fakeTaskRunner.SubmitTaskArgsForCall(0), &task)).To(Succeed())
+				Expect(task.TaskGuid).To(Equal("nightly-report"))
+				Expect(task.Annotation).To(MatchJSON(`{"priority":"low"}`))
+				Expect(task.CPUWeight).To(Equal(task_runner.LowPriorityCPUWeight))
+				Expect(task.MemoryMB).To(Equal(1024))
+				Expect(task.DiskMB).To(Equal(512))
+			})
+
+			It("can't set the priority when the JSON's annotation isn't a JSON object", func() {
+				ioutil.WriteFile(tmpFile.Name(), []byte(`{"task_guid":"nightly-report","annotation":"nightly"}`), 0700)
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--priority=low", tmpFile.Name()})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error reading task JSON: The annotation must be a JSON object to set a priority: "))
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("with several tasks", func() {
+			var batchDir string
+
+			BeforeEach(func() {
+				batchDir, err = ioutil.TempDir("", "ltc-submit-task")
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeTaskRunner.SubmitTaskStub = func(submitTaskJson []byte) (string, error) {
+					task := receptor.TaskCreateRequest{}
+					if err := json.Unmarshal(submitTaskJson, &task); err != nil {
+						return "", err
+					}
+					if task.TaskGuid == "broken-task" {
+						return task.TaskGuid, task_runner.TaskAlreadyExistsError{TaskGuid: task.TaskGuid}
+					}
+					return task.TaskGuid, nil
+				}
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(batchDir)).To(Succeed())
+			})
+
+			It("submits every task in a JSON array", func() {
+				arrayPath := filepath.Join(batchDir, "tasks.json")
+				Expect(ioutil.WriteFile(arrayPath, []byte(` [{"task_guid":"task-1"}, {"task_guid":"task-2"}]`), 0600)).To(Succeed())
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--memory-mb=256", arrayPath})
+
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(Equal(2))
+				submitted := []string{}
+				for i := 0; i < 2; i++ {
+					task := receptor.TaskCreateRequest{}
+					Expect(json.Unmarshal(fakeTaskRunner.SubmitTaskArgsForCall(i), &task)).To(Succeed())
+					Expect(task.MemoryMB).To(Equal(256))
+					submitted = append(submitted, task.TaskGuid)
+				}
+				Expect(submitted).To(ConsistOf("task-1", "task-2"))
+
+				Expect(outputBuffer).To(test_helpers.Say(colors.Bold("Source")))
+				Expect(outputBuffer).To(test_helpers.Say(arrayPath + "[0]"))
+				Expect(outputBuffer).To(test_helpers.Say("task-1"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("submitted")))
+				Expect(outputBuffer).To(test_helpers.Say(arrayPath + "[1]"))
+				Expect(outputBuffer).To(test_helpers.Say("task-2"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("submitted")))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Submitted 2 of 2 tasks.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+
+			It("submits the task in each .json file of a directory", func() {
+				Expect(ioutil.WriteFile(filepath.Join(batchDir, "a.json"), []byte(`{"task_guid":"task-1"}`), 0600)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(batchDir, "b.json"), []byte(`{"task_guid":"broken-task"}`), 0600)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(batchDir, "c.json"), []byte(`{"task_guid":`), 0600)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(batchDir, "README"), []byte("not a task"), 0600)).To(Succeed())
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{batchDir})
+
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(Equal(3))
+				Expect(outputBuffer).To(test_helpers.Say(filepath.Join(batchDir, "a.json")))
+				Expect(outputBuffer).To(test_helpers.Say("task-1"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Green("submitted")))
+				Expect(outputBuffer).To(test_helpers.Say(filepath.Join(batchDir, "b.json")))
+				Expect(outputBuffer).To(test_helpers.Say("broken-task"))
+				Expect(outputBuffer).To(test_helpers.Say(colors.Red("broken-task has already been submitted")))
+				Expect(outputBuffer).To(test_helpers.Say(filepath.Join(batchDir, "c.json")))
+				Expect(outputBuffer).To(test_helpers.Say("N/A"))
+				Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Submitted 1 of 3 tasks.")))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("is an error when there are no tasks to submit", func() {
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{batchDir})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("No tasks to submit in " + batchDir + "."))
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("is an error when the JSON array is invalid", func() {
+				arrayPath := filepath.Join(batchDir, "tasks.json")
+				Expect(ioutil.WriteFile(arrayPath, []byte(`[{"task_guid":"task-1"},`), 0600)).To(Succeed())
+
+				test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{arrayPath})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error reading task JSON: "))
+				Expect(fakeTaskRunner.SubmitTaskCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		It("is an error when the priority is unknown", func() {
+			test_helpers.ExecuteCommandWithArgs(submitTaskCommand, []string{"--priority=urgent", "task.json"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Invalid priority urgent: must be normal or low"))
+			Expect(fakeTaskRunner.SubmitTaskCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 		})
 
 		It("is an error when no path is passed in", func() {
@@ -115,10 +254,18 @@ var _ = Describe("CommandFactory", func() {
 
 	})
 	Describe("DeleteTaskCommand", func() {
-		var deleteTaskCommand cli.Command
+		var (
+			deleteTaskCommand cli.Command
+			confirmation      string
+		)
 
 		BeforeEach(func() {
-			commandFactory := command_factory.NewTaskRunnerCommandFactory(fakeTaskRunner, terminalUI, fakeExitHandler)
+			confirmation = "y\n"
+		})
+
+		JustBeforeEach(func() {
+			confirmingUI := terminal.NewUI(strings.NewReader(confirmation), outputBuffer, nil)
+			commandFactory := command_factory.NewTaskRunnerCommandFactory(fakeTaskRunner, confirmingUI, fakeExitHandler)
 			deleteTaskCommand = commandFactory.MakeDeleteTaskCommand()
 		})
 
@@ -131,7 +278,38 @@ var _ = Describe("CommandFactory", func() {
 			fakeTaskRunner.DeleteTaskReturns(nil)
 			test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{"task-guid-1"})
 
+			Expect(outputBuffer).To(test_helpers.Say("Really delete task task-guid-1? [y/N]: "))
 			Expect(outputBuffer).To(test_helpers.Say(colors.Green("OK")))
+			Expect(fakeTaskRunner.DeleteTaskCallCount()).To(Equal(1))
+		})
+
+		Context("when the user does not confirm", func() {
+			BeforeEach(func() {
+				confirmation = "n\n"
+			})
+
+			It("does not delete the task", func() {
+				test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{"task-guid-1"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Really delete task task-guid-1? [y/N]: "))
+				Expect(outputBuffer).To(test_helpers.SayLine("Task deletion cancelled."))
+				Expect(fakeTaskRunner.DeleteTaskCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
+		Context("when the --force flag is passed", func() {
+			BeforeEach(func() {
+				confirmation = ""
+			})
+
+			It("deletes the task without asking", func() {
+				test_helpers.ExecuteCommandWithArgs(deleteTaskCommand, []string{"--force", "task-guid-1"})
+
+				Expect(outputBuffer).NotTo(test_helpers.Say("Really delete"))
+				Expect(fakeTaskRunner.DeleteTaskCallCount()).To(Equal(1))
+				Expect(fakeTaskRunner.DeleteTaskArgsForCall(0)).To(Equal("task-guid-1"))
+			})
 		})
 
 		It("returns error while deleting the task", func() {
@@ -156,4 +334,130 @@ var _ = Describe("CommandFactory", func() {
 		})
 	})
 
+	Describe("CancelTaskCommand", func() {
+		var (
+			cancelTaskCommand cli.Command
+			confirmation      string
+		)
+
+		BeforeEach(func() {
+			confirmation = "y\n"
+		})
+
+		JustBeforeEach(func() {
+			confirmingUI := terminal.NewUI(strings.NewReader(confirmation), outputBuffer, nil)
+			commandFactory := command_factory.NewTaskRunnerCommandFactory(fakeTaskRunner, confirmingUI, fakeExitHandler)
+			cancelTaskCommand = commandFactory.MakeCancelTaskCommand()
+		})
+
+		It("cancels the given task", func() {
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"task-guid-1"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Really cancel task task-guid-1? [y/N]: "))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Cancelled task-guid-1")))
+			Expect(outputBuffer).To(test_helpers.SayLine("To remove it:"))
+			Expect(outputBuffer).To(test_helpers.SayLine("\tltc delete-task task-guid-1"))
+			Expect(fakeTaskRunner.CancelTaskCallCount()).To(Equal(1))
+			Expect(fakeTaskRunner.CancelTaskArgsForCall(0)).To(Equal("task-guid-1"))
+			Expect(fakeTaskRunner.DeleteTaskCallCount()).To(BeZero())
+		})
+
+		Context("when the user does not confirm", func() {
+			BeforeEach(func() {
+				confirmation = "n\n"
+			})
+
+			It("does not cancel the task", func() {
+				test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"task-guid-1"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Task cancellation cancelled."))
+				Expect(fakeTaskRunner.CancelTaskCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+			})
+		})
+
+		Context("when the --force flag is passed", func() {
+			BeforeEach(func() {
+				confirmation = ""
+			})
+
+			It("cancels the task without asking", func() {
+				test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"--force", "task-guid-1"})
+
+				Expect(outputBuffer).NotTo(test_helpers.Say("Really cancel"))
+				Expect(fakeTaskRunner.CancelTaskCallCount()).To(Equal(1))
+			})
+		})
+
+		It("suggests deleting a task that has already completed", func() {
+			fakeTaskRunner.CancelTaskReturns(task_runner.TaskCompletedError{TaskGuid: "task-guid-1"})
+
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"task-guid-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error cancelling task-guid-1: task-guid-1 has already completed"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Run ltc delete-task task-guid-1 to remove it."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits with not found for a missing task", func() {
+			fakeTaskRunner.CancelTaskReturns(errors.New(task_examiner.TaskNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{"task-guid-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error cancelling task-guid-1: Task not found."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("fails with usage", func() {
+			test_helpers.ExecuteCommandWithArgs(cancelTaskCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Please input a valid TASK_GUID"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("RetryTaskCommand", func() {
+		var retryTaskCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := command_factory.NewTaskRunnerCommandFactory(fakeTaskRunner, terminalUI, fakeExitHandler)
+			retryTaskCommand = commandFactory.MakeRetryTaskCommand()
+		})
+
+		It("resubmits the given task", func() {
+			test_helpers.ExecuteCommandWithArgs(retryTaskCommand, []string{"task-guid-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("Resubmitted task-guid-1")))
+			Expect(outputBuffer).To(test_helpers.SayLine("To view its status:"))
+			Expect(outputBuffer).To(test_helpers.SayLine("\tltc task task-guid-1"))
+			Expect(fakeTaskRunner.RetryTaskCallCount()).To(Equal(1))
+			Expect(fakeTaskRunner.RetryTaskArgsForCall(0)).To(Equal("task-guid-1"))
+		})
+
+		It("suggests cancelling a task that hasn't failed", func() {
+			fakeTaskRunner.RetryTaskReturns(task_runner.TaskNotFailedError{TaskGuid: "task-guid-1"})
+
+			test_helpers.ExecuteCommandWithArgs(retryTaskCommand, []string{"task-guid-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error retrying task-guid-1: task-guid-1 hasn't failed, so it can't be retried"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Cancel it with ltc cancel-task task-guid-1 first to run it again."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("exits with not found for a missing task", func() {
+			fakeTaskRunner.RetryTaskReturns(errors.New(task_examiner.TaskNotFoundErrorMessage))
+
+			test_helpers.ExecuteCommandWithArgs(retryTaskCommand, []string{"task-guid-1"})
+
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.NotFound}))
+		})
+
+		It("fails with usage", func() {
+			test_helpers.ExecuteCommandWithArgs(retryTaskCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Please input a valid TASK_GUID"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
 })
@@ -17,6 +17,30 @@ type FakeTaskRunner struct {
 		result1 string
 		result2 error
 	}
+	SubmitDockerTaskStub        func(params task_runner.DockerTaskParams) error
+	submitDockerTaskMutex       sync.RWMutex
+	submitDockerTaskArgsForCall []struct {
+		params task_runner.DockerTaskParams
+	}
+	submitDockerTaskReturns struct {
+		result1 error
+	}
+	CancelTaskStub        func(taskGuid string) error
+	cancelTaskMutex       sync.RWMutex
+	cancelTaskArgsForCall []struct {
+		taskGuid string
+	}
+	cancelTaskReturns struct {
+		result1 error
+	}
+	RetryTaskStub        func(taskGuid string) error
+	retryTaskMutex       sync.RWMutex
+	retryTaskArgsForCall []struct {
+		taskGuid string
+	}
+	retryTaskReturns struct {
+		result1 error
+	}
 	DeleteTaskStub        func(taskGuid string) error
 	deleteTaskMutex       sync.RWMutex
 	deleteTaskArgsForCall []struct {
@@ -60,6 +84,102 @@ func (fake *FakeTaskRunner) SubmitTaskReturns(result1 string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeTaskRunner) SubmitDockerTask(params task_runner.DockerTaskParams) error {
+	fake.submitDockerTaskMutex.Lock()
+	fake.submitDockerTaskArgsForCall = append(fake.submitDockerTaskArgsForCall, struct {
+		params task_runner.DockerTaskParams
+	}{params})
+	fake.submitDockerTaskMutex.Unlock()
+	if fake.SubmitDockerTaskStub != nil {
+		return fake.SubmitDockerTaskStub(params)
+	} else {
+		return fake.submitDockerTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) SubmitDockerTaskCallCount() int {
+	fake.submitDockerTaskMutex.RLock()
+	defer fake.submitDockerTaskMutex.RUnlock()
+	return len(fake.submitDockerTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) SubmitDockerTaskArgsForCall(i int) task_runner.DockerTaskParams {
+	fake.submitDockerTaskMutex.RLock()
+	defer fake.submitDockerTaskMutex.RUnlock()
+	return fake.submitDockerTaskArgsForCall[i].params
+}
+
+func (fake *FakeTaskRunner) SubmitDockerTaskReturns(result1 error) {
+	fake.SubmitDockerTaskStub = nil
+	fake.submitDockerTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTaskRunner) CancelTask(taskGuid string) error {
+	fake.cancelTaskMutex.Lock()
+	fake.cancelTaskArgsForCall = append(fake.cancelTaskArgsForCall, struct {
+		taskGuid string
+	}{taskGuid})
+	fake.cancelTaskMutex.Unlock()
+	if fake.CancelTaskStub != nil {
+		return fake.CancelTaskStub(taskGuid)
+	} else {
+		return fake.cancelTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) CancelTaskCallCount() int {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	return len(fake.cancelTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) CancelTaskArgsForCall(i int) string {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	return fake.cancelTaskArgsForCall[i].taskGuid
+}
+
+func (fake *FakeTaskRunner) CancelTaskReturns(result1 error) {
+	fake.CancelTaskStub = nil
+	fake.cancelTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTaskRunner) RetryTask(taskGuid string) error {
+	fake.retryTaskMutex.Lock()
+	fake.retryTaskArgsForCall = append(fake.retryTaskArgsForCall, struct {
+		taskGuid string
+	}{taskGuid})
+	fake.retryTaskMutex.Unlock()
+	if fake.RetryTaskStub != nil {
+		return fake.RetryTaskStub(taskGuid)
+	} else {
+		return fake.retryTaskReturns.result1
+	}
+}
+
+func (fake *FakeTaskRunner) RetryTaskCallCount() int {
+	fake.retryTaskMutex.RLock()
+	defer fake.retryTaskMutex.RUnlock()
+	return len(fake.retryTaskArgsForCall)
+}
+
+func (fake *FakeTaskRunner) RetryTaskArgsForCall(i int) string {
+	fake.retryTaskMutex.RLock()
+	defer fake.retryTaskMutex.RUnlock()
+	return fake.retryTaskArgsForCall[i].taskGuid
+}
+
+func (fake *FakeTaskRunner) RetryTaskReturns(result1 error) {
+	fake.RetryTaskStub = nil
+	fake.retryTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeTaskRunner) DeleteTask(taskGuid string) error {
 	fake.deleteTaskMutex.Lock()
 	fake.deleteTaskArgsForCall = append(fake.deleteTaskArgsForCall, struct {
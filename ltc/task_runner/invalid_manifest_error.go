@@ -0,0 +1,19 @@
+package task_runner
+
+// InvalidManifestError is returned when the JSON given to SubmitTask can't
+// be read as a task.
+type InvalidManifestError struct {
+	Err error
+}
+
+func (err InvalidManifestError) Error() string {
+	return err.Err.Error()
+}
+
+func (err InvalidManifestError) InvalidManifest() bool {
+	return true
+}
+
+func (err InvalidManifestError) Remedy() string {
+	return "Check the JSON against the receptor API; see docs/lattice-api.md."
+}
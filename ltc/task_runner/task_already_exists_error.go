@@ -0,0 +1,21 @@
+package task_runner
+
+import "fmt"
+
+// TaskAlreadyExistsError is returned when submitting a task whose guid is
+// already known to lattice.
+type TaskAlreadyExistsError struct {
+	TaskGuid string
+}
+
+func (err TaskAlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s has already been submitted", err.TaskGuid)
+}
+
+func (err TaskAlreadyExistsError) AlreadyExists() bool {
+	return true
+}
+
+func (err TaskAlreadyExistsError) Remedy() string {
+	return fmt.Sprintf("Choose another task_guid, or run ltc delete-task %s first.", err.TaskGuid)
+}
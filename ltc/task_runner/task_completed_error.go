@@ -0,0 +1,17 @@
+package task_runner
+
+import "fmt"
+
+// TaskCompletedError is returned when cancelling a task that has already
+// completed.
+type TaskCompletedError struct {
+	TaskGuid string
+}
+
+func (err TaskCompletedError) Error() string {
+	return fmt.Sprintf("%s has already completed", err.TaskGuid)
+}
+
+func (err TaskCompletedError) Remedy() string {
+	return fmt.Sprintf("Run ltc delete-task %s to remove it.", err.TaskGuid)
+}
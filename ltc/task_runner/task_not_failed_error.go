@@ -0,0 +1,16 @@
+package task_runner
+
+import "fmt"
+
+// TaskNotFailedError is returned when retrying a task that hasn't completed.
+type TaskNotFailedError struct {
+	TaskGuid string
+}
+
+func (err TaskNotFailedError) Error() string {
+	return fmt.Sprintf("%s hasn't failed, so it can't be retried", err.TaskGuid)
+}
+
+func (err TaskNotFailedError) Remedy() string {
+	return fmt.Sprintf("Cancel it with ltc cancel-task %s first to run it again.", err.TaskGuid)
+}
@@ -3,39 +3,84 @@ package task_runner
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/app_runner/docker_repository_name_formatter"
 	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
 )
 
 const (
 	AttemptedToCreateLatticeDebugErrorMessage = reserved_app_ids.LatticeDebugLogStreamAppId + " is a reserved app name. It is used internally to stream debug logs for lattice components."
+
+	// LowPriorityCPUWeight caps the CPU weight of low priority tasks, so that
+	// long batch jobs get little of a cell's CPU while apps and their staging
+	// want it.
+	LowPriorityCPUWeight uint = 10
+
+	// priorityAnnotationKey is where a task's priority is kept in its
+	// annotation, for task_examiner to read back.
+	priorityAnnotationKey = "priority"
 )
 
+// TaskOptions override what a task's JSON asks for.  Zero values leave the
+// JSON's own.
+type TaskOptions struct {
+	Priority string
+	MemoryMB int
+	DiskMB   int
+}
+
+// DockerTaskParams describes a task that runs one command in a docker
+// image.  Its output is logged under Name with the TASK source.  The
+// contents of ResultFile, when set, become the task's result.
+type DockerTaskParams struct {
+	Name                 string
+	DockerImagePath      string
+	StartCommand         string
+	Args                 []string
+	EnvironmentVariables map[string]string
+	WorkingDir           string
+	ResultFile           string
+	Priority             string
+	Privileged           bool
+	CPUWeight            uint
+	MemoryMB             int
+	DiskMB               int
+}
+
 //go:generate counterfeiter -o fake_task_runner/fake_task_runner.go . TaskRunner
 type TaskRunner interface {
 	SubmitTask(submitTaskJson []byte) (string, error)
+	SubmitDockerTask(params DockerTaskParams) error
+	CancelTask(taskGuid string) error
+	RetryTask(taskGuid string) error
 	DeleteTask(taskGuid string) error
 }
 
 type taskRunner struct {
 	receptorClient receptor.Client
 	taskExaminer   task_examiner.TaskExaminer
+	reservedAppIds reserved_app_ids.Registry
 }
 
-func New(receptorClient receptor.Client, taskExaminer task_examiner.TaskExaminer) TaskRunner {
-	return &taskRunner{receptorClient, taskExaminer}
+// New returns a TaskRunner that refuses to submit tasks under the ids in
+// reservedAppIds, as well as under those lattice uses internally.
+func New(receptorClient receptor.Client, taskExaminer task_examiner.TaskExaminer, reservedAppIds reserved_app_ids.Registry) TaskRunner {
+	return &taskRunner{receptorClient, taskExaminer, reservedAppIds}
 }
 
 func (taskRunner *taskRunner) SubmitTask(submitTaskJson []byte) (string, error) {
 	task := receptor.TaskCreateRequest{}
 	if err := json.Unmarshal(submitTaskJson, &task); err != nil {
-		return "", err
+		return "", InvalidManifestError{Err: err}
 	}
 
-	if task.TaskGuid == reserved_app_ids.LatticeDebugLogStreamAppId {
-		return task.TaskGuid, errors.New(AttemptedToCreateLatticeDebugErrorMessage)
+	if err := taskRunner.reservedAppIds.Check(task.TaskGuid); err != nil {
+		return task.TaskGuid, err
 	}
 
 	submittedTasks, err := taskRunner.receptorClient.Tasks()
@@ -44,7 +89,7 @@ func (taskRunner *taskRunner) SubmitTask(submitTaskJson []byte) (string, error)
 	}
 	for _, submittedTask := range submittedTasks {
 		if task.TaskGuid == submittedTask.TaskGuid {
-			return task.TaskGuid, errors.New(task.TaskGuid + " has already been submitted")
+			return task.TaskGuid, TaskAlreadyExistsError{TaskGuid: task.TaskGuid}
 		}
 	}
 
@@ -52,7 +97,95 @@ func (taskRunner *taskRunner) SubmitTask(submitTaskJson []byte) (string, error)
 		return task.TaskGuid, err
 	}
 
-	return task.TaskGuid, taskRunner.receptorClient.CreateTask(task)
+	err = taskRunner.receptorClient.CreateTask(task)
+	if receptorErr, ok := err.(receptor.Error); ok && receptorErr.Type == receptor.InvalidTask {
+		return task.TaskGuid, InvalidManifestError{Err: err}
+	}
+	return task.TaskGuid, err
+}
+
+func (taskRunner *taskRunner) SubmitDockerTask(params DockerTaskParams) error {
+	rootFS, err := docker_repository_name_formatter.FormatForReceptor(params.DockerImagePath)
+	if err != nil {
+		return err
+	}
+
+	envVars := []receptor.EnvironmentVariable{}
+	for name, value := range params.EnvironmentVariables {
+		envVars = append(envVars, receptor.EnvironmentVariable{Name: name, Value: value})
+	}
+	sort.Sort(byName(envVars))
+
+	task := receptor.TaskCreateRequest{
+		TaskGuid:             params.Name,
+		Domain:               "lattice",
+		RootFS:               rootFS,
+		CPUWeight:            params.CPUWeight,
+		MemoryMB:             params.MemoryMB,
+		DiskMB:               params.DiskMB,
+		Privileged:           true,
+		LogGuid:              params.Name,
+		LogSource:            "TASK",
+		MetricsGuid:          params.Name,
+		ResultFile:           params.ResultFile,
+		EnvironmentVariables: envVars,
+		Action: &models.RunAction{
+			Path:       params.StartCommand,
+			Args:       params.Args,
+			Dir:        params.WorkingDir,
+			Privileged: params.Privileged,
+		},
+	}
+	if err := setPriority(&task, params.Priority); err != nil {
+		return err
+	}
+
+	taskJson, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = taskRunner.SubmitTask(taskJson)
+	return err
+}
+
+// CancelTask stops a task that hasn't completed.  Lattice then marks it as
+// failed, and keeps it until it is deleted.
+func (e *taskRunner) CancelTask(taskGuid string) error {
+	taskInfo, err := e.taskExaminer.TaskStatus(taskGuid)
+	if err != nil {
+		return err
+	}
+	if taskInfo.State == receptor.TaskStateCompleted || taskInfo.State == receptor.TaskStateResolving {
+		return TaskCompletedError{TaskGuid: taskGuid}
+	}
+	return e.receptorClient.CancelTask(taskGuid)
+}
+
+// RetryTask replaces a failed task with one submitted from the definition
+// lattice kept for it.
+func (e *taskRunner) RetryTask(taskGuid string) error {
+	task, err := e.receptorClient.GetTask(taskGuid)
+	if err != nil {
+		if receptorErr, ok := err.(receptor.Error); ok && receptorErr.Type == receptor.TaskNotFound {
+			return errors.New(task_examiner.TaskNotFoundErrorMessage)
+		}
+		return err
+	}
+	if task.State != receptor.TaskStateCompleted {
+		return TaskNotFailedError{TaskGuid: taskGuid}
+	}
+	if !task.Failed {
+		return fmt.Errorf("%s succeeded, so there is nothing to retry", taskGuid)
+	}
+
+	if err := e.receptorClient.DeleteTask(taskGuid); err != nil {
+		return err
+	}
+	if err := e.receptorClient.CreateTask(createRequestFor(task)); err != nil {
+		return fmt.Errorf("%s was deleted, but couldn't be submitted again: %s", taskGuid, err)
+	}
+	return nil
 }
 
 func (e *taskRunner) DeleteTask(taskGuid string) error {
@@ -78,3 +211,95 @@ func (e *taskRunner) DeleteTask(taskGuid string) error {
 		return nil
 	}
 }
+
+// ValidatePriority returns an error unless priority is one ltc knows, or
+// empty for the default.
+func ValidatePriority(priority string) error {
+	switch priority {
+	case "", task_examiner.PriorityNormal, task_examiner.PriorityLow:
+		return nil
+	}
+	return fmt.Errorf("Invalid priority %s: must be %s or %s", priority, task_examiner.PriorityNormal, task_examiner.PriorityLow)
+}
+
+// ApplyTaskOptions returns the task JSON with options applied, for
+// SubmitTask.  Without options, the JSON is returned as it is.
+func ApplyTaskOptions(submitTaskJson []byte, options TaskOptions) ([]byte, error) {
+	if options == (TaskOptions{}) {
+		return submitTaskJson, nil
+	}
+
+	task := receptor.TaskCreateRequest{}
+	if err := json.Unmarshal(submitTaskJson, &task); err != nil {
+		return nil, InvalidManifestError{Err: err}
+	}
+
+	if options.MemoryMB != 0 {
+		task.MemoryMB = options.MemoryMB
+	}
+	if options.DiskMB != 0 {
+		task.DiskMB = options.DiskMB
+	}
+	if err := setPriority(&task, options.Priority); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(task)
+}
+
+// setPriority records priority in the task's annotation and caps the CPU
+// weight of low priority tasks.  Lattice has no notion of task priority, so
+// the CPU weight is all that changes how the task competes with others.
+func setPriority(task *receptor.TaskCreateRequest, priority string) error {
+	if priority == "" {
+		return nil
+	}
+	if err := ValidatePriority(priority); err != nil {
+		return err
+	}
+
+	annotation := map[string]json.RawMessage{}
+	if task.Annotation != "" {
+		if err := json.Unmarshal([]byte(task.Annotation), &annotation); err != nil {
+			return InvalidManifestError{Err: fmt.Errorf("The annotation must be a JSON object to set a priority: %s", err)}
+		}
+	}
+	annotation[priorityAnnotationKey], _ = json.Marshal(priority)
+	annotationJson, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+	task.Annotation = string(annotationJson)
+
+	if priority == task_examiner.PriorityLow && (task.CPUWeight == 0 || task.CPUWeight > LowPriorityCPUWeight) {
+		task.CPUWeight = LowPriorityCPUWeight
+	}
+	return nil
+}
+
+type byName []receptor.EnvironmentVariable
+
+func (env byName) Len() int           { return len(env) }
+func (env byName) Swap(i, j int)      { env[i], env[j] = env[j], env[i] }
+func (env byName) Less(i, j int) bool { return env[i].Name < env[j].Name }
+
+func createRequestFor(task receptor.TaskResponse) receptor.TaskCreateRequest {
+	return receptor.TaskCreateRequest{
+		Action:                task.Action,
+		Annotation:            task.Annotation,
+		CompletionCallbackURL: task.CompletionCallbackURL,
+		CPUWeight:             task.CPUWeight,
+		DiskMB:                task.DiskMB,
+		Domain:                task.Domain,
+		LogGuid:               task.LogGuid,
+		LogSource:             task.LogSource,
+		MetricsGuid:           task.MetricsGuid,
+		MemoryMB:              task.MemoryMB,
+		ResultFile:            task.ResultFile,
+		TaskGuid:              task.TaskGuid,
+		RootFS:                task.RootFS,
+		Privileged:            task.Privileged,
+		EnvironmentVariables:  task.EnvironmentVariables,
+		EgressRules:           task.EgressRules,
+	}
+}
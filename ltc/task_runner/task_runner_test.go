@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_examiner"
 	"github.com/cloudfoundry-incubator/lattice/ltc/task_runner"
 	"github.com/cloudfoundry-incubator/receptor"
@@ -25,7 +26,7 @@ var _ = Describe("TaskRunner", func() {
 	BeforeEach(func() {
 		fakeReceptorClient = &fake_receptor.FakeClient{}
 		taskExaminer = task_examiner.New(fakeReceptorClient)
-		taskRunner = task_runner.New(fakeReceptorClient, taskExaminer)
+		taskRunner = task_runner.New(fakeReceptorClient, taskExaminer, reserved_app_ids.Registry{"billing"})
 	})
 
 	Describe("SubmitTask", func() {
@@ -131,6 +132,7 @@ var _ = Describe("TaskRunner", func() {
 				Expect(taskName).To(Equal("task-already-submitted"))
 
 				Expect(err).To(MatchError("task-already-submitted has already been submitted"))
+				Expect(err).To(Equal(task_runner.TaskAlreadyExistsError{TaskGuid: "task-already-submitted"}))
 				Expect(fakeReceptorClient.TasksCallCount()).To(Equal(1))
 				Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(0))
 			})
@@ -152,10 +154,22 @@ var _ = Describe("TaskRunner", func() {
 			})
 		})
 
+		Context("when the taskGuid is reserved for the cluster", func() {
+			It("is an error", func() {
+				taskJson, marshalErr := json.Marshal(receptor.TaskCreateRequest{TaskGuid: "billing"})
+				Expect(marshalErr).ToNot(HaveOccurred())
+
+				_, err := taskRunner.SubmitTask(taskJson)
+				Expect(err).To(BeAssignableToTypeOf(reserved_app_ids.ReservedAppIdError{}))
+				Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(0))
+			})
+		})
+
 		It("returns an error for invalid JSON", func() {
 			taskName, err := taskRunner.SubmitTask([]byte(`{"Value":"test value`))
 
 			Expect(err).To(MatchError("unexpected end of JSON input"))
+			Expect(err).To(BeAssignableToTypeOf(task_runner.InvalidManifestError{}))
 			Expect(taskName).To(BeEmpty())
 			Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(0))
 		})
@@ -210,8 +224,251 @@ var _ = Describe("TaskRunner", func() {
 				Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(1))
 			})
 
+			It("returns the receptor's rejection of the task as an invalid manifest", func() {
+				receptorError := receptor.Error{Type: receptor.InvalidTask, Message: "invalid field: Action"}
+				fakeReceptorClient.CreateTaskReturns(receptorError)
+
+				_, err := taskRunner.SubmitTask([]byte(`{"task_guid":"whatever-task"}`))
+
+				Expect(err).To(Equal(task_runner.InvalidManifestError{Err: receptorError}))
+			})
+
+		})
+	})
+
+	Describe("SubmitDockerTask", func() {
+		It("submits a task that runs the command in the image", func() {
+			err := taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{
+				Name:                 "migrate",
+				DockerImagePath:      "cloudfoundry/lattice-app",
+				StartCommand:         "/migrate",
+				Args:                 []string{"--up"},
+				EnvironmentVariables: map[string]string{"ZED": "1", "ALPHA": "2"},
+				WorkingDir:           "/app",
+				ResultFile:           "/tmp/result.json",
+				CPUWeight:            50,
+				MemoryMB:             256,
+				DiskMB:               512,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(1))
+			taskRequest := fakeReceptorClient.CreateTaskArgsForCall(0)
+			Expect(taskRequest.TaskGuid).To(Equal("migrate"))
+			Expect(taskRequest.Domain).To(Equal("lattice"))
+			Expect(taskRequest.RootFS).To(Equal("docker:///cloudfoundry/lattice-app#latest"))
+			Expect(taskRequest.LogGuid).To(Equal("migrate"))
+			Expect(taskRequest.LogSource).To(Equal("TASK"))
+			Expect(taskRequest.ResultFile).To(Equal("/tmp/result.json"))
+			Expect(taskRequest.CPUWeight).To(Equal(uint(50)))
+			Expect(taskRequest.MemoryMB).To(Equal(256))
+			Expect(taskRequest.DiskMB).To(Equal(512))
+			Expect(taskRequest.EnvironmentVariables).To(Equal([]receptor.EnvironmentVariable{
+				{Name: "ALPHA", Value: "2"},
+				{Name: "ZED", Value: "1"},
+			}))
+			Expect(taskRequest.Action).To(Equal(&models.RunAction{
+				Path: "/migrate",
+				Args: []string{"--up"},
+				Dir:  "/app",
+			}))
+			Expect(taskRequest.Annotation).To(BeEmpty())
+		})
+
+		It("records the priority and caps the CPU weight of low priority tasks", func() {
+			err := taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{
+				Name:            "migrate",
+				DockerImagePath: "cloudfoundry/lattice-app",
+				Priority:        task_examiner.PriorityLow,
+				CPUWeight:       100,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			taskRequest := fakeReceptorClient.CreateTaskArgsForCall(0)
+			Expect(taskRequest.Annotation).To(MatchJSON(`{"priority":"low"}`))
+			Expect(taskRequest.CPUWeight).To(Equal(task_runner.LowPriorityCPUWeight))
+		})
+
+		It("refuses unknown priorities", func() {
+			err := taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{
+				Name:            "migrate",
+				DockerImagePath: "cloudfoundry/lattice-app",
+				Priority:        "urgent",
+			})
+			Expect(err).To(MatchError("Invalid priority urgent: must be normal or low"))
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(BeZero())
+		})
+
+		It("returns an error for an invalid image", func() {
+			err := taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{Name: "migrate", DockerImagePath: "Bad:Image:Name"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(BeZero())
+		})
+
+		It("refuses reserved names", func() {
+			err := taskRunner.SubmitDockerTask(task_runner.DockerTaskParams{Name: "billing", DockerImagePath: "cloudfoundry/lattice-app"})
+			Expect(err).To(BeAssignableToTypeOf(reserved_app_ids.ReservedAppIdError{}))
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("ApplyTaskOptions", func() {
+		It("overrides the resources and sets the priority", func() {
+			taskJson, err := task_runner.ApplyTaskOptions([]byte(`{
+				"task_guid": "nightly-report",
+				"annotation": "{\"owner\":\"billing\"}",
+				"cpu_weight": 50,
+				"memory_mb": 128,
+				"disk_mb": 256,
+				"action": {"run": {"path": "/report"}}
+			}`), task_runner.TaskOptions{Priority: task_examiner.PriorityLow, MemoryMB: 1024})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := receptor.TaskCreateRequest{}
+			Expect(json.Unmarshal(taskJson, &task)).To(Succeed())
+			Expect(task.TaskGuid).To(Equal("nightly-report"))
+			Expect(task.Annotation).To(MatchJSON(`{"owner":"billing","priority":"low"}`))
+			Expect(task.CPUWeight).To(Equal(task_runner.LowPriorityCPUWeight))
+			Expect(task.MemoryMB).To(Equal(1024))
+			Expect(task.DiskMB).To(Equal(256))
+			Expect(task.Action).To(Equal(&models.RunAction{Path: "/report"}))
+		})
+
+		It("keeps a lower CPU weight than the low priority one", func() {
+			taskJson, err := task_runner.ApplyTaskOptions([]byte(`{"task_guid": "nightly-report", "cpu_weight": 5}`), task_runner.TaskOptions{Priority: task_examiner.PriorityLow})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := receptor.TaskCreateRequest{}
+			Expect(json.Unmarshal(taskJson, &task)).To(Succeed())
+			Expect(task.CPUWeight).To(Equal(uint(5)))
+		})
+
+		It("returns the JSON as it is without options", func() {
+			taskJson := []byte(`{"task_guid": "nightly-report", "annotation": "nightly", "cpu_weight": 50}`)
+
+			Expect(task_runner.ApplyTaskOptions(taskJson, task_runner.TaskOptions{})).To(Equal(taskJson))
+		})
+
+		It("keeps the JSON's own values for options that aren't set", func() {
+			taskJson, err := task_runner.ApplyTaskOptions([]byte(`{"task_guid": "nightly-report", "annotation": "nightly", "memory_mb": 128}`), task_runner.TaskOptions{DiskMB: 512})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := receptor.TaskCreateRequest{}
+			Expect(json.Unmarshal(taskJson, &task)).To(Succeed())
+			Expect(task.Annotation).To(Equal("nightly"))
+			Expect(task.MemoryMB).To(Equal(128))
+			Expect(task.DiskMB).To(Equal(512))
+		})
+
+		It("can't set the priority when the annotation isn't a JSON object", func() {
+			_, err := task_runner.ApplyTaskOptions([]byte(`{"task_guid": "nightly-report", "annotation": "nightly"}`), task_runner.TaskOptions{Priority: task_examiner.PriorityNormal})
+			Expect(err).To(BeAssignableToTypeOf(task_runner.InvalidManifestError{}))
+			Expect(err.Error()).To(HavePrefix("The annotation must be a JSON object to set a priority: "))
+		})
+
+		It("returns an InvalidManifestError for bad JSON", func() {
+			_, err := task_runner.ApplyTaskOptions([]byte(`{"task_guid":`), task_runner.TaskOptions{MemoryMB: 128})
+			Expect(err).To(BeAssignableToTypeOf(task_runner.InvalidManifestError{}))
+		})
+	})
+
+	Describe("CancelTask", func() {
+		It("cancels a task that hasn't completed", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateRunning}, nil)
+
+			Expect(taskRunner.CancelTask("task-guid-1")).To(Succeed())
+
+			Expect(fakeReceptorClient.CancelTaskCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.CancelTaskArgsForCall(0)).To(Equal("task-guid-1"))
+			Expect(fakeReceptorClient.DeleteTaskCallCount()).To(BeZero())
+		})
+
+		It("refuses to cancel a completed task", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateCompleted}, nil)
+
+			err := taskRunner.CancelTask("task-guid-1")
+
+			Expect(err).To(Equal(task_runner.TaskCompletedError{TaskGuid: "task-guid-1"}))
+			Expect(err).To(MatchError("task-guid-1 has already completed"))
+			Expect(fakeReceptorClient.CancelTaskCallCount()).To(BeZero())
+		})
+
+		It("returns errors looking up the task", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{}, receptor.Error{Type: receptor.TaskNotFound})
+
+			Expect(taskRunner.CancelTask("task-guid-1")).To(MatchError(task_examiner.TaskNotFoundErrorMessage))
+			Expect(fakeReceptorClient.CancelTaskCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("RetryTask", func() {
+		It("replaces a failed task with one submitted from its definition", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{
+				TaskGuid:   "task-guid-1",
+				Domain:     "lattice",
+				RootFS:     "docker:///cloudfoundry/lattice-app#latest",
+				LogGuid:    "task-guid-1",
+				ResultFile: "/tmp/result.json",
+				MemoryMB:   128,
+				Action:     &models.RunAction{Path: "/migrate"},
+				State:      receptor.TaskStateCompleted,
+				Failed:     true,
+				CellID:     "cell-0",
+			}, nil)
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(Succeed())
+
+			Expect(fakeReceptorClient.DeleteTaskCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DeleteTaskArgsForCall(0)).To(Equal("task-guid-1"))
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.CreateTaskArgsForCall(0)).To(Equal(receptor.TaskCreateRequest{
+				TaskGuid:   "task-guid-1",
+				Domain:     "lattice",
+				RootFS:     "docker:///cloudfoundry/lattice-app#latest",
+				LogGuid:    "task-guid-1",
+				ResultFile: "/tmp/result.json",
+				MemoryMB:   128,
+				Action:     &models.RunAction{Path: "/migrate"},
+			}))
+		})
+
+		It("refuses to retry a task that hasn't failed", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateRunning}, nil)
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(Equal(task_runner.TaskNotFailedError{TaskGuid: "task-guid-1"}))
+			Expect(fakeReceptorClient.DeleteTaskCallCount()).To(BeZero())
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(BeZero())
+		})
+
+		It("refuses to retry a task that succeeded", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateCompleted}, nil)
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(MatchError("task-guid-1 succeeded, so there is nothing to retry"))
+			Expect(fakeReceptorClient.DeleteTaskCallCount()).To(BeZero())
+		})
+
+		It("returns not found for a missing task", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{}, receptor.Error{Type: receptor.TaskNotFound})
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(MatchError(task_examiner.TaskNotFoundErrorMessage))
+		})
+
+		It("says the task was deleted when it can't be submitted again", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateCompleted, Failed: true}, nil)
+			fakeReceptorClient.CreateTaskReturns(errors.New("receptor is down"))
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(MatchError("task-guid-1 was deleted, but couldn't be submitted again: receptor is down"))
+		})
+
+		It("leaves the task when it can't be deleted", func() {
+			fakeReceptorClient.GetTaskReturns(receptor.TaskResponse{TaskGuid: "task-guid-1", State: receptor.TaskStateCompleted, Failed: true}, nil)
+			fakeReceptorClient.DeleteTaskReturns(errors.New("receptor is down"))
+
+			Expect(taskRunner.RetryTask("task-guid-1")).To(MatchError("receptor is down"))
+			Expect(fakeReceptorClient.CreateTaskCallCount()).To(BeZero())
 		})
 	})
+
 	Describe("Delete Task", func() {
 		It("delete task when task in COMPLETED state", func() {
 			getTaskResponse := receptor.TaskResponse{
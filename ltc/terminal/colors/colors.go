@@ -2,16 +2,21 @@ package colors
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
 var ColorCodeLength = len(red) + len(defaultStyle)
 
+var colorCodeRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
 const (
 	red             string = "\x1b[91m"
 	cyan            string = "\x1b[36m"
 	green           string = "\x1b[32m"
 	yellow          string = "\x1b[33m"
+	blue            string = "\x1b[34m"
+	purple          string = "\x1b[35m"
 	purpleUnderline string = "\x1b[35;4m"
 	defaultStyle    string = "\x1b[0m"
 	boldStyle       string = "\x1b[1m"
@@ -34,6 +39,14 @@ func Yellow(output string) string {
 	return colorText(output, yellow)
 }
 
+func Blue(output string) string {
+	return colorText(output, blue)
+}
+
+func Purple(output string) string {
+	return colorText(output, purple)
+}
+
 func Gray(output string) string {
 	return colorText(output, grayColor)
 }
@@ -56,3 +69,8 @@ func colorText(output string, color string) string {
 	}
 	return fmt.Sprintf("%s%s%s", color, output, defaultStyle)
 }
+
+// Strip removes color and style codes, leaving cursor movement codes intact.
+func Strip(output string) string {
+	return colorCodeRegexp.ReplaceAllString(output, "")
+}
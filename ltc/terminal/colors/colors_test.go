@@ -82,4 +82,15 @@ var _ = Describe("colors", func() {
 		itShouldNotColorizeWhitespace(colors.NoColor)
 	})
 
+	Describe("Strip", func() {
+		It("removes color and style codes", func() {
+			Expect(colors.Strip(colors.Red("ERROR") + " and " + colors.PurpleUnderline("link"))).To(Equal("ERROR and link"))
+			Expect(colors.Strip(colors.Colorize(colors.ColorBold, "bold"))).To(Equal("bold"))
+		})
+
+		It("leaves cursor movement codes intact", func() {
+			Expect(colors.Strip("\x1b[3A\x1b[0K" + colors.Green("up"))).To(Equal("\x1b[3A\x1b[0Kup"))
+		})
+	})
+
 })
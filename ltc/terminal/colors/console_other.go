@@ -0,0 +1,9 @@
+// +build !windows
+
+package colors
+
+// EnableConsole prepares the console behind fd to show color codes, and
+// reports whether it can.  Terminals on other platforms always can.
+func EnableConsole(fd uintptr) bool {
+	return true
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package colors
+
+import (
+	"github.com/docker/docker/pkg/term"
+)
+
+// enableVirtualTerminalProcessing is the console mode that interprets ANSI
+// escape codes, from Windows 10 on.
+const enableVirtualTerminalProcessing = 0x0004
+
+// EnableConsole prepares the console behind fd to show color codes, and
+// reports whether it can.  Consoles before Windows 10 can't, so colors
+// should be stripped instead.
+func EnableConsole(fd uintptr) bool {
+	mode, err := term.GetConsoleMode(fd)
+	if err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	return term.SetConsoleMode(fd, mode|enableVirtualTerminalProcessing) == nil
+}
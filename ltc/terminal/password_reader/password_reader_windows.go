@@ -0,0 +1,42 @@
+// +build windows
+
+package password_reader
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/pkg/term"
+)
+
+// PromptForPassword turns off the console's echo while the password is
+// typed, since Windows has no stty.
+func (pr passwordReader) PromptForPassword(promptText string, args ...interface{}) (passwd string) {
+	fmt.Printf(promptText, args...)
+
+	fd := os.Stdin.Fd()
+	state, err := term.SaveState(fd)
+	if err != nil {
+		return
+	}
+
+	// DisableEcho changes the state it's given, so the original is kept.
+	original := *state
+	restore := func() { term.RestoreTerminal(fd, &original) }
+	pr.exitHandler.OnExit(restore)
+
+	if err := term.DisableEcho(fd, state); err != nil {
+		return
+	}
+	defer restore()
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err == nil {
+		passwd = strings.TrimSpace(line)
+	}
+
+	fmt.Println("")
+	return
+}
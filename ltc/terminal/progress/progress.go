@@ -0,0 +1,188 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/pivotal-golang/clock"
+)
+
+const barWidth = 20
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Indicator shows that ltc is still waiting on lattice.  Tick is called on
+// every poll and Finish once polling stops.  On a terminal indicators redraw
+// in place; otherwise they fall back to plain text.  Quiet UIs show neither.
+type Indicator interface {
+	Tick()
+	Finish()
+}
+
+type Spinner struct {
+	ui    terminal.UI
+	frame int
+}
+
+// NewSpinner returns an indicator that spins at the end of the current line.
+func NewSpinner(ui terminal.UI) *Spinner {
+	return &Spinner{ui: ui}
+}
+
+func (s *Spinner) Tick() {
+	if !s.ui.IsTerminal() {
+		s.ui.SayInfo(".")
+		return
+	}
+
+	if s.frame > 0 {
+		s.ui.SayInfo("\b")
+	}
+	s.ui.SayInfo(spinnerFrames[s.frame%len(spinnerFrames)])
+	s.frame++
+}
+
+func (s *Spinner) Finish() {
+	if s.ui.IsTerminal() && s.frame > 0 {
+		s.ui.SayInfo("\b \b")
+	}
+	s.ui.SayInfo("\n")
+}
+
+// Bar is safe to update from another goroutine than the one ticking it.
+type Bar struct {
+	ui        terminal.UI
+	total     int
+	label     string
+	lock      sync.Mutex
+	current   int
+	detail    string
+	remaining time.Duration
+	reported  string
+	drawn     bool
+}
+
+// NewBar returns an indicator that reports progress towards total, e.g.
+// "3/10 instances running" for the label "instances running".
+func NewBar(ui terminal.UI, total int, label string) *Bar {
+	return &Bar{ui: ui, total: total, label: label}
+}
+
+func (b *Bar) SetCurrent(current int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.current = current
+}
+
+// SetDetail sets what is shown in parentheses after the count, e.g.
+// "2 starting, 1 crashed".
+func (b *Bar) SetDetail(detail string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.detail = detail
+}
+
+// SetRemaining sets the estimated time left, which is hidden while 0.
+func (b *Bar) SetRemaining(remaining time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.remaining = remaining
+}
+
+func (b *Bar) Tick() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.ui.IsTerminal() {
+		b.draw()
+		return
+	}
+
+	// The estimate changes on every tick, so only a change in the count or
+	// its detail is worth another line in a log.
+	if progress := b.progress(); progress != b.reported {
+		b.ui.SayInfo(fmt.Sprintf("(%s)", b.status()))
+		b.reported = progress
+	}
+	b.ui.SayInfo(".")
+}
+
+func (b *Bar) Finish() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.ui.IsTerminal() && b.drawn {
+		b.remaining = 0
+		b.draw()
+	}
+	b.ui.SayInfo("\n")
+}
+
+func (b *Bar) draw() {
+	filled := barWidth
+	if b.total > 0 {
+		filled = barWidth * b.current / b.total
+	}
+	if filled > barWidth {
+		filled = barWidth
+	} else if filled < 0 {
+		filled = 0
+	}
+
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+	b.ui.SayInfo(fmt.Sprintf("\r[%s] %s%s", bar, b.status(), cursor.ClearToEndOfLine()))
+	b.drawn = true
+}
+
+func (b *Bar) progress() string {
+	progress := fmt.Sprintf("%d/%d %s", b.current, b.total, b.label)
+	if b.detail != "" {
+		progress += fmt.Sprintf(" (%s)", b.detail)
+	}
+	return progress
+}
+
+func (b *Bar) status() string {
+	if b.remaining <= 0 {
+		return b.progress()
+	}
+	return fmt.Sprintf("%s, about %s left", b.progress(), roundUp(b.remaining))
+}
+
+// Estimator guesses how long is left until total is reached from the rate
+// of progress seen so far.
+type Estimator struct {
+	clock   clock.Clock
+	total   int
+	started time.Time
+	initial int
+	polled  bool
+}
+
+func NewEstimator(clock clock.Clock, total int) *Estimator {
+	return &Estimator{clock: clock, total: total}
+}
+
+// Remaining returns the estimated time until current reaches total, or 0
+// until there has been progress to estimate from.
+func (e *Estimator) Remaining(current int) time.Duration {
+	now := e.clock.Now()
+	if !e.polled {
+		e.started, e.initial, e.polled = now, current, true
+		return 0
+	}
+
+	progressed := current - e.initial
+	if progressed <= 0 || current >= e.total {
+		return 0
+	}
+	return now.Sub(e.started) * time.Duration(e.total-current) / time.Duration(progressed)
+}
+
+func roundUp(duration time.Duration) time.Duration {
+	return (duration + time.Second - 1) / time.Second * time.Second
+}
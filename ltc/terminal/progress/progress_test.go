@@ -0,0 +1,214 @@
+package progress_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/cursor"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/progress"
+)
+
+type ttyUI struct {
+	terminal.UI
+}
+
+func (ttyUI) IsTerminal() bool {
+	return true
+}
+
+var _ = Describe("Progress", func() {
+	var (
+		outputBuffer *gbytes.Buffer
+		terminalUI   terminal.UI
+	)
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
+	})
+
+	Describe("Spinner", func() {
+		Context("when output is a terminal", func() {
+			It("spins in place and clears itself when finished", func() {
+				spinner := progress.NewSpinner(ttyUI{terminalUI})
+
+				spinner.Tick()
+				spinner.Tick()
+				spinner.Tick()
+				spinner.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("|\b/\b-\b \b\n"))
+			})
+
+			It("only prints a newline if it never ticked", func() {
+				progress.NewSpinner(ttyUI{terminalUI}).Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("\n"))
+			})
+		})
+
+		Context("when output is not a terminal", func() {
+			It("prints a dot per tick", func() {
+				spinner := progress.NewSpinner(terminalUI)
+
+				spinner.Tick()
+				spinner.Tick()
+				spinner.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("..\n"))
+			})
+		})
+	})
+
+	Describe("Bar", func() {
+		Context("when output is a terminal", func() {
+			It("redraws the bar in place", func() {
+				bar := progress.NewBar(ttyUI{terminalUI}, 4, "instances running")
+
+				bar.SetCurrent(1)
+				bar.Tick()
+				bar.SetCurrent(4)
+				bar.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal(
+					"\r[=====               ] 1/4 instances running" + cursor.ClearToEndOfLine() +
+						"\r[====================] 4/4 instances running" + cursor.ClearToEndOfLine() + "\n",
+				))
+			})
+
+			It("draws a full bar when the total is zero", func() {
+				bar := progress.NewBar(ttyUI{terminalUI}, 0, "instances running")
+
+				bar.Tick()
+
+				Expect(outputBuffer).To(gbytes.Say(`\[====================\] 0/0 instances running`))
+			})
+
+			It("shows the detail and the time left, dropping the estimate when finished", func() {
+				bar := progress.NewBar(ttyUI{terminalUI}, 10, "instances running")
+
+				bar.SetCurrent(3)
+				bar.SetDetail("2 starting, 1 crashed")
+				bar.SetRemaining(19500 * time.Millisecond)
+				bar.Tick()
+				bar.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal(
+					"\r[======              ] 3/10 instances running (2 starting, 1 crashed), about 20s left" + cursor.ClearToEndOfLine() +
+						"\r[======              ] 3/10 instances running (2 starting, 1 crashed)" + cursor.ClearToEndOfLine() + "\n",
+				))
+			})
+
+			It("can be updated while it is ticking", func() {
+				bar := progress.NewBar(ttyUI{terminalUI}, 100, "instances running")
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					for i := 0; i <= 100; i++ {
+						bar.SetCurrent(i)
+						bar.SetDetail(fmt.Sprintf("%d starting", 100-i))
+					}
+				}()
+				for i := 0; i < 100; i++ {
+					bar.Tick()
+				}
+				<-done
+				bar.Finish()
+
+				Expect(outputBuffer).To(gbytes.Say(`100/100 instances running`))
+			})
+
+			It("only prints a newline if it was never drawn", func() {
+				progress.NewBar(ttyUI{terminalUI}, 4, "instances running").Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("\n"))
+			})
+		})
+
+		Context("when output is not a terminal", func() {
+			It("prints a dot per tick and the status whenever it changes", func() {
+				bar := progress.NewBar(terminalUI, 4, "instances running")
+
+				bar.Tick()
+				bar.Tick()
+				bar.SetCurrent(2)
+				bar.Tick()
+				bar.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("(0/4 instances running)..(2/4 instances running).\n"))
+			})
+
+			It("doesn't print the status again when only the estimate changes", func() {
+				bar := progress.NewBar(terminalUI, 4, "instances running")
+
+				bar.SetCurrent(1)
+				bar.SetRemaining(30 * time.Second)
+				bar.Tick()
+				bar.SetRemaining(20 * time.Second)
+				bar.Tick()
+				bar.Finish()
+
+				Expect(string(outputBuffer.Contents())).To(Equal("(1/4 instances running, about 30s left)..\n"))
+			})
+		})
+	})
+
+	Describe("Estimator", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			estimator *progress.Estimator
+		)
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			estimator = progress.NewEstimator(fakeClock, 10)
+		})
+
+		It("estimates from the rate of progress since it was first polled", func() {
+			Expect(estimator.Remaining(2)).To(BeZero())
+
+			fakeClock.IncrementBySeconds(10)
+			Expect(estimator.Remaining(4)).To(Equal(30 * time.Second))
+
+			fakeClock.IncrementBySeconds(10)
+			Expect(estimator.Remaining(7)).To(Equal(12 * time.Second))
+		})
+
+		It("has no estimate without progress", func() {
+			estimator.Remaining(2)
+			fakeClock.IncrementBySeconds(10)
+
+			Expect(estimator.Remaining(2)).To(BeZero())
+		})
+
+		It("has no estimate once the total is reached", func() {
+			estimator.Remaining(2)
+			fakeClock.IncrementBySeconds(10)
+
+			Expect(estimator.Remaining(10)).To(BeZero())
+		})
+	})
+
+	Context("when the UI is quiet", func() {
+		It("shows nothing", func() {
+			terminalUI.SetVerbosity(terminal.Quiet)
+
+			spinner := progress.NewSpinner(terminalUI)
+			spinner.Tick()
+			spinner.Finish()
+
+			bar := progress.NewBar(ttyUI{terminalUI}, 4, "instances running")
+			bar.Tick()
+			bar.Finish()
+
+			Expect(outputBuffer.Contents()).To(BeEmpty())
+		})
+	})
+})
@@ -1,12 +1,27 @@
 package terminal
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader"
+	"github.com/docker/docker/pkg/term"
+)
+
+// Verbosity controls how much of ltc's output a UI shows.
+type Verbosity int
+
+const (
+	// Quiet shows only errors and results, for use in scripts.
+	Quiet Verbosity = iota
+	Normal
+	// Verbose also shows debugging detail, such as the receptor requests
+	// ltc makes.
+	Verbose
 )
 
 type UI interface {
@@ -14,39 +29,181 @@ type UI interface {
 	password_reader.PasswordReader
 
 	Prompt(promptText string, args ...interface{}) string
+	PromptWithDefault(promptText, defaultValue string) string
+	PromptForValidInput(promptText, defaultValue string, validate func(string) error) string
+	PromptForChoice(promptText string, choices []string, defaultChoice string) string
+	PromptForConfirmation(promptText string) bool
 	Say(message string)
+	SayInfo(message string)
+	SayDebug(message string)
 	SayIncorrectUsage(message string)
 	SayLine(message string)
 	SayNewLine()
+	SayRemedy(err error)
+	IsTerminal() bool
+	IsInteractive() bool
+	ConfigurePrompts(assumeYes bool, exitHandler exit_handler.ExitHandler)
+	SetColorEnabled(enabled bool)
+	Verbosity() Verbosity
+	SetVerbosity(verbosity Verbosity)
 }
 
 type terminalUI struct {
 	io.Reader
 	io.Writer
 	password_reader.PasswordReader
+	colorEnabled bool
+	verbosity    Verbosity
+	assumeYes    bool
+	exitHandler  exit_handler.ExitHandler
 }
 
 func NewUI(input io.Reader, output io.Writer, passwordReader password_reader.PasswordReader) UI {
 	return &terminalUI{
-		input,
-		output,
-		passwordReader,
+		Reader:         input,
+		Writer:         output,
+		PasswordReader: passwordReader,
+		colorEnabled:   true,
+		verbosity:      Normal,
+	}
+}
+
+// Write strips color codes from the output when color is disabled.
+func (t *terminalUI) Write(p []byte) (int, error) {
+	if t.colorEnabled {
+		return t.Writer.Write(p)
+	}
+
+	if _, err := t.Writer.Write([]byte(colors.Strip(string(p)))); err != nil {
+		return 0, err
 	}
+	return len(p), nil
 }
 
 func (t *terminalUI) Prompt(promptText string, args ...interface{}) (answer string) {
-	reader := bufio.NewReader(t)
-	fmt.Fprintf(t.Writer, promptText, args...)
+	if !t.canPrompt(fmt.Sprintf(promptText, args...), "Pass the value as an argument or flag instead.") {
+		return ""
+	}
+	fmt.Fprintf(t, promptText, args...)
+
+	answer, _ = t.readLine()
+	return answer
+}
+
+func (t *terminalUI) PromptWithDefault(promptText, defaultValue string) string {
+	answer, _ := t.promptWithDefault(promptText, defaultValue)
+	return answer
+}
+
+// PromptForValidInput re-prompts until validate accepts the answer.  If the
+// input stream ends first, the last answer is returned as-is.
+func (t *terminalUI) PromptForValidInput(promptText, defaultValue string, validate func(string) error) string {
+	for {
+		answer, err := t.promptWithDefault(promptText, defaultValue)
+		if err != nil {
+			return answer
+		}
+
+		validationErr := validate(answer)
+		if validationErr == nil {
+			return answer
+		}
+
+		t.SayLine(validationErr.Error())
+	}
+}
+
+func (t *terminalUI) PromptForChoice(promptText string, choices []string, defaultChoice string) string {
+	choiceText := fmt.Sprintf("%s (%s)", promptText, strings.Join(choices, ", "))
+
+	return t.PromptForValidInput(choiceText, defaultChoice, func(answer string) error {
+		for _, choice := range choices {
+			if answer == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("Please choose one of: %s", strings.Join(choices, ", "))
+	})
+}
+
+// PromptForConfirmation answers yes without asking when the UI assumes yes.
+func (t *terminalUI) PromptForConfirmation(promptText string) bool {
+	if t.assumeYes {
+		t.SayLine(fmt.Sprintf("%s [y/N]: y", promptText))
+		return true
+	}
+	if !t.canPrompt(promptText, "Pass --assume-yes or set LTC_ASSUME_YES=true to confirm.") {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(t.Prompt("%s [y/N]: ", promptText)))
+	return answer == "y" || answer == "yes"
+}
+
+func (t *terminalUI) PromptForPassword(promptText string, args ...interface{}) string {
+	if !t.canPrompt(fmt.Sprintf(promptText, args...), "Pass the value as an argument or flag instead.") {
+		return ""
+	}
+	return t.PasswordReader.PromptForPassword(promptText, args...)
+}
+
+func (t *terminalUI) promptWithDefault(promptText, defaultValue string) (string, error) {
+	if !t.canPrompt(promptText, "Pass the value as an argument or flag instead.") {
+		return defaultValue, io.EOF
+	}
+	if defaultValue != "" {
+		t.Say(fmt.Sprintf("%s [%s]: ", promptText, defaultValue))
+	} else {
+		t.Say(promptText + ": ")
+	}
+
+	answer, err := t.readLine()
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue, err
+	}
+	return answer, err
+}
 
-	result, _ := reader.ReadString('\n')
+// readLine reads a byte at a time so that no input beyond the newline is
+// consumed, allowing consecutive prompts to share the same reader.
+func (t *terminalUI) readLine() (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
 
-	return strings.TrimSuffix(result, "\n")
+	for {
+		n, err := t.Reader.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
 }
 
 func (t *terminalUI) Say(message string) {
 	t.Write([]byte(message))
 }
 
+// SayInfo says routine progress, such as "Creating App: foo", which is left
+// out when the UI is quiet.
+func (t *terminalUI) SayInfo(message string) {
+	if t.verbosity > Quiet {
+		t.Say(message)
+	}
+}
+
+// SayDebug says detail that is only wanted when the UI is verbose.
+func (t *terminalUI) SayDebug(message string) {
+	if t.verbosity >= Verbose {
+		t.Say(message)
+	}
+}
+
 func (t *terminalUI) SayIncorrectUsage(message string) {
 	if len(message) > 0 {
 		t.Say("Incorrect Usage: " + message)
@@ -62,3 +219,72 @@ func (t *terminalUI) SayLine(message string) {
 func (t *terminalUI) SayNewLine() {
 	t.Say("\n")
 }
+
+// SayRemedy says what the user can do about err, for errors that know.
+func (t *terminalUI) SayRemedy(err error) {
+	if err, ok := err.(interface {
+		Remedy() string
+	}); ok {
+		t.SayLine(err.Remedy())
+	}
+}
+
+// IsTerminal reports whether output is going to a terminal rather than to a
+// pipe or a file.
+func (t *terminalUI) IsTerminal() bool {
+	file, ok := t.Writer.(interface {
+		Fd() uintptr
+	})
+	return ok && term.IsTerminal(file.Fd())
+}
+
+// IsInteractive reports whether prompts can be answered, which they can't
+// when input is a file or pipe that isn't a terminal, as in CI.  Readers
+// other than files, such as those tests prompt with, count as interactive.
+func (t *terminalUI) IsInteractive() bool {
+	file, ok := t.Reader.(interface {
+		Fd() uintptr
+	})
+	return !ok || term.IsTerminal(file.Fd())
+}
+
+// ConfigurePrompts makes confirmations answer yes when assumeYes is set,
+// and has prompts that can't be answered exit through exitHandler, rather
+// than block waiting for input that will never come.
+func (t *terminalUI) ConfigurePrompts(assumeYes bool, exitHandler exit_handler.ExitHandler) {
+	t.assumeYes = assumeYes
+	t.exitHandler = exitHandler
+}
+
+// canPrompt fails fast when the prompt can't be answered, saying how to
+// pass the answer instead.
+func (t *terminalUI) canPrompt(promptText, hint string) bool {
+	if t.IsInteractive() {
+		return true
+	}
+
+	t.SayLine(fmt.Sprintf("ltc can't ask '%s' because its input is not a terminal.  %s", strings.TrimRight(promptText, ": "), hint))
+	if t.exitHandler != nil {
+		t.exitHandler.Exit(exit_codes.InvalidSyntax)
+	}
+	return false
+}
+
+// SetColorEnabled turns colors on or off.  Colors stay off on consoles that
+// can't show them, such as those of older Windows versions.
+func (t *terminalUI) SetColorEnabled(enabled bool) {
+	if file, ok := t.Writer.(interface {
+		Fd() uintptr
+	}); enabled && ok && term.IsTerminal(file.Fd()) {
+		enabled = colors.EnableConsole(file.Fd())
+	}
+	t.colorEnabled = enabled
+}
+
+func (t *terminalUI) Verbosity() Verbosity {
+	return t.verbosity
+}
+
+func (t *terminalUI) SetVerbosity(verbosity Verbosity) {
+	t.verbosity = verbosity
+}
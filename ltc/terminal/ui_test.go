@@ -1,13 +1,21 @@
 package terminal_test
 
 import (
+	"errors"
 	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader"
 	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader/fake_password_reader"
 	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
@@ -78,6 +86,18 @@ var _ = Describe("UI", func() {
 				Expect(outputBuffer).To(test_helpers.SayNewLine())
 			})
 		})
+
+		Describe("SayRemedy", func() {
+			It("says the error's remedy", func() {
+				terminalUI.SayRemedy(remedyError("Try turning it off and on again."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Try turning it off and on again."))
+			})
+
+			It("says nothing for errors without a remedy", func() {
+				terminalUI.SayRemedy(errors.New("boom"))
+				Expect(outputBuffer.Contents()).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("Input Methods", func() {
@@ -100,6 +120,97 @@ var _ = Describe("UI", func() {
 			})
 		})
 
+		Describe("PromptWithDefault", func() {
+			It("shows the default and returns the answer", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("Rocky\n"), outputBuffer, fakePasswordReader)
+
+				answer := terminalUI.PromptWithDefault("Nickname", "RockStar")
+
+				Expect(outputBuffer).To(test_helpers.Say("Nickname [RockStar]: "))
+				Expect(answer).To(Equal("Rocky"))
+			})
+
+			It("returns the default when the answer is blank", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("\n"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptWithDefault("Nickname", "RockStar")).To(Equal("RockStar"))
+			})
+
+			It("omits the brackets when there is no default", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("Rocky\n"), outputBuffer, fakePasswordReader)
+
+				terminalUI.PromptWithDefault("Nickname", "")
+
+				Expect(outputBuffer).To(test_helpers.Say("Nickname: "))
+			})
+
+			It("leaves subsequent lines for the next prompt", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("first\nsecond\n"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptWithDefault("One", "")).To(Equal("first"))
+				Expect(terminalUI.PromptWithDefault("Two", "")).To(Equal("second"))
+			})
+		})
+
+		Describe("PromptForValidInput", func() {
+			validateNumber := func(answer string) error {
+				if _, err := strconv.Atoi(answer); err != nil {
+					return errors.New("Must be a number")
+				}
+				return nil
+			}
+
+			It("re-prompts until the input is valid", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("seven\n7\n"), outputBuffer, fakePasswordReader)
+
+				answer := terminalUI.PromptForValidInput("Lucky number", "", validateNumber)
+
+				Expect(answer).To(Equal("7"))
+				Expect(outputBuffer).To(test_helpers.Say("Lucky number: "))
+				Expect(outputBuffer).To(test_helpers.Say("Must be a number\n"))
+				Expect(outputBuffer).To(test_helpers.Say("Lucky number: "))
+			})
+
+			It("returns the last answer when input runs out", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("seven"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptForValidInput("Lucky number", "", validateNumber)).To(Equal("seven"))
+			})
+		})
+
+		Describe("PromptForChoice", func() {
+			It("lists the choices and only accepts one of them", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("purple\nblue\n"), outputBuffer, fakePasswordReader)
+
+				answer := terminalUI.PromptForChoice("Color", []string{"red", "blue"}, "red")
+
+				Expect(answer).To(Equal("blue"))
+				Expect(outputBuffer).To(test_helpers.Say("Color (red, blue) [red]: "))
+				Expect(outputBuffer).To(test_helpers.Say("Please choose one of: red, blue\n"))
+			})
+
+			It("returns the default choice when the answer is blank", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("\n"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptForChoice("Color", []string{"red", "blue"}, "red")).To(Equal("red"))
+			})
+		})
+
+		Describe("PromptForConfirmation", func() {
+			It("returns true for yes", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("Yes\n"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptForConfirmation("Continue?")).To(BeTrue())
+				Expect(outputBuffer).To(test_helpers.Say("Continue? [y/N]: "))
+			})
+
+			It("defaults to false", func() {
+				terminalUI = terminal.NewUI(strings.NewReader("\n"), outputBuffer, fakePasswordReader)
+
+				Expect(terminalUI.PromptForConfirmation("Continue?")).To(BeFalse())
+			})
+		})
+
 		Describe("PasswordReader PromptForPassword", func() {
 			It("Calls to PasswordReader, which contains untested content", func() {
 				fakePasswordReader.PromptForPassword("Password: ")
@@ -109,4 +220,126 @@ var _ = Describe("UI", func() {
 			})
 		})
 	})
+
+	Describe("SetColorEnabled", func() {
+		It("writes colors by default", func() {
+			terminalUI.Say(colors.Red("ERROR"))
+			Expect(outputBuffer.Contents()).To(Equal([]byte(colors.Red("ERROR"))))
+		})
+
+		It("strips colors from everything written once disabled", func() {
+			terminalUI.SetColorEnabled(false)
+
+			terminalUI.Say(colors.Red("ERROR") + " ")
+			terminalUI.SayLine(colors.Bold("bold"))
+			n, err := terminalUI.Write([]byte(colors.Green("ok")))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(len(colors.Green("ok"))))
+			Expect(string(outputBuffer.Contents())).To(Equal("ERROR bold\nok"))
+		})
+	})
+
+	Describe("SetVerbosity", func() {
+		It("shows info but not debug output by default", func() {
+			terminalUI.SayInfo("info ")
+			terminalUI.SayDebug("debug")
+
+			Expect(terminalUI.Verbosity()).To(Equal(terminal.Normal))
+			Expect(string(outputBuffer.Contents())).To(Equal("info "))
+		})
+
+		It("leaves out info output when quiet", func() {
+			terminalUI.SetVerbosity(terminal.Quiet)
+
+			terminalUI.SayInfo("info ")
+			terminalUI.SayDebug("debug ")
+			terminalUI.Say("result")
+
+			Expect(string(outputBuffer.Contents())).To(Equal("result"))
+		})
+
+		It("shows debug output when verbose", func() {
+			terminalUI.SetVerbosity(terminal.Verbose)
+
+			terminalUI.SayInfo("info ")
+			terminalUI.SayDebug("debug")
+
+			Expect(string(outputBuffer.Contents())).To(Equal("info debug"))
+		})
+	})
+
+	Describe("IsTerminal", func() {
+		It("returns false when output is not a file", func() {
+			Expect(terminalUI.IsTerminal()).To(BeFalse())
+		})
+
+		It("returns false when output is a regular file", func() {
+			file, err := ioutil.TempFile("", "ui_test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(file.Name())
+			defer file.Close()
+
+			Expect(terminal.NewUI(nil, file, nil).IsTerminal()).To(BeFalse())
+		})
+	})
+
+	Describe("Prompts that can't be answered", func() {
+		var (
+			inputFile       *os.File
+			fakeExitHandler *fake_exit_handler.FakeExitHandler
+		)
+
+		BeforeEach(func() {
+			var err error
+			inputFile, err = ioutil.TempFile("", "ui_test")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = inputFile.WriteString("y\n")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = inputFile.Seek(0, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+			terminalUI = terminal.NewUI(inputFile, outputBuffer, fakePasswordReader)
+		})
+
+		AfterEach(func() {
+			inputFile.Close()
+			Expect(os.Remove(inputFile.Name())).To(Succeed())
+		})
+
+		It("counts input that isn't a terminal as not interactive", func() {
+			Expect(terminalUI.IsInteractive()).To(BeFalse())
+			Expect(terminal.NewUI(stdinReader, outputBuffer, nil).IsInteractive()).To(BeTrue())
+		})
+
+		It("fails fast instead of reading the answer", func() {
+			terminalUI.ConfigurePrompts(false, fakeExitHandler)
+
+			Expect(terminalUI.PromptForConfirmation("Really remove app?")).To(BeFalse())
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc can't ask 'Really remove app?' because its input is not a terminal.  Pass --assume-yes or set LTC_ASSUME_YES=true to confirm."))
+
+			Expect(terminalUI.PromptWithDefault("Instances", "1")).To(Equal("1"))
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc can't ask 'Instances' because its input is not a terminal.  Pass the value as an argument or flag instead."))
+
+			Expect(terminalUI.PromptForPassword("Password: ")).To(BeEmpty())
+			Expect(outputBuffer).To(test_helpers.SayLine("ltc can't ask 'Password' because its input is not a terminal.  Pass the value as an argument or flag instead."))
+			Expect(fakePasswordReader.PromptForPasswordCallCount()).To(BeZero())
+
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax, exit_codes.InvalidSyntax, exit_codes.InvalidSyntax}))
+		})
+
+		It("confirms without asking when assuming yes", func() {
+			terminalUI.ConfigurePrompts(true, fakeExitHandler)
+
+			Expect(terminalUI.PromptForConfirmation("Really remove app?")).To(BeTrue())
+			Expect(outputBuffer).To(test_helpers.SayLine("Really remove app? [y/N]: y"))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+	})
 })
+
+type remedyError string
+
+func (err remedyError) Error() string  { return "failed" }
+func (err remedyError) Remedy() string { return string(err) }
@@ -0,0 +1,93 @@
+package command_factory
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/version"
+	"github.com/codegangsta/cli"
+)
+
+const SyncCommandName = "sync"
+
+type SyncCommandFactory struct {
+	syncer      version.Syncer
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+	ltcPath     string
+	goos        string
+}
+
+func NewSyncCommandFactory(syncer version.Syncer, ui terminal.UI, exitHandler exit_handler.ExitHandler, ltcPath, goos string) *SyncCommandFactory {
+	return &SyncCommandFactory{syncer, ui, exitHandler, ltcPath, goos}
+}
+
+func (factory *SyncCommandFactory) MakeSyncCommand() cli.Command {
+	var syncFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "version",
+			Usage: "Version of ltc to download, matching the cluster's Lattice version",
+			Value: "latest",
+		},
+		cli.StringFlag{
+			Name:  "url, u",
+			Usage: "Downloads ltc from this url instead of the published releases",
+		},
+		cli.StringFlag{
+			Name:  "sha256",
+			Usage: "Expected SHA-256 checksum of the download (defaults to the contents of URL.sha256)",
+		},
+	}
+
+	var syncCommand = cli.Command{
+		Name:    SyncCommandName,
+		Aliases: []string{"sy"},
+		Usage:   "Replaces ltc with the version matching your cluster",
+		Description: `ltc sync [--version VERSION | --url URL] [--sha256 CHECKSUM]
+
+   Downloads ltc, verifies its checksum and replaces the running ltc with it.
+   The receptor doesn't report the cluster's version, so pass the Lattice
+   version the cluster was deployed from to keep ltc in step with it.`,
+		Action: factory.sync,
+		Flags:  syncFlags,
+	}
+
+	return syncCommand
+}
+
+func (factory *SyncCommandFactory) sync(context *cli.Context) {
+	url := context.String("url")
+	ltcVersion := context.String("version")
+
+	if url != "" && context.IsSet("version") {
+		factory.ui.SayIncorrectUsage("--url and --version can't be used together")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if url == "" {
+		var err error
+		if url, err = version.ReleaseURL(ltcVersion, factory.goos); err != nil {
+			factory.ui.SayLine(err.Error())
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Downloading ltc from %s...", url))
+	if err := factory.syncer.Sync(factory.ltcPath, url, context.String("sha256")); err != nil {
+		factory.ui.SayLine("Error syncing ltc: " + err.Error())
+		if os.IsPermission(err) {
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+		} else {
+			factory.exitHandler.Exit(exit_codes.ForError(err, exit_codes.CommandFailed))
+		}
+		return
+	}
+
+	factory.ui.SayLine(colors.Green(fmt.Sprintf("Updated %s.", factory.ltcPath)))
+}
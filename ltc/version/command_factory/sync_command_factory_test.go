@@ -0,0 +1,124 @@
+package command_factory_test
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/lattice/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/lattice/ltc/terminal/password_reader/fake_password_reader"
+	"github.com/cloudfoundry-incubator/lattice/ltc/test_helpers"
+	"github.com/cloudfoundry-incubator/lattice/ltc/version/command_factory"
+	"github.com/cloudfoundry-incubator/lattice/ltc/version/fake_syncer"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("SyncCommandFactory", func() {
+	var (
+		outputBuffer    *gbytes.Buffer
+		terminalUI      terminal.UI
+		fakeSyncer      *fake_syncer.FakeSyncer
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		goos            string
+		syncCommand     cli.Command
+	)
+
+	BeforeEach(func() {
+		stdinReader, _ := io.Pipe()
+		outputBuffer = gbytes.NewBuffer()
+		terminalUI = terminal.NewUI(stdinReader, outputBuffer, &fake_password_reader.FakePasswordReader{})
+		fakeSyncer = &fake_syncer.FakeSyncer{}
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		goos = "linux"
+	})
+
+	JustBeforeEach(func() {
+		commandFactory := command_factory.NewSyncCommandFactory(fakeSyncer, terminalUI, fakeExitHandler, "/usr/local/bin/ltc", goos)
+		syncCommand = commandFactory.MakeSyncCommand()
+	})
+
+	Describe("SyncCommand", func() {
+		It("replaces ltc with the latest published release", func() {
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{})
+
+			Expect(fakeSyncer.SyncCallCount()).To(Equal(1))
+			ltcPath, url, checksum := fakeSyncer.SyncArgsForCall(0)
+			Expect(ltcPath).To(Equal("/usr/local/bin/ltc"))
+			Expect(url).To(Equal("https://lattice.s3.amazonaws.com/unstable/latest/linux-amd64/ltc"))
+			Expect(checksum).To(BeEmpty())
+
+			Expect(outputBuffer).To(test_helpers.Say("Downloading ltc from https://lattice.s3.amazonaws.com/unstable/latest/linux-amd64/ltc...\n"))
+			Expect(outputBuffer).To(test_helpers.Say(colors.Green("Updated /usr/local/bin/ltc.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+
+		It("downloads the given version, verifying the given checksum", func() {
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{"--version", "v0.3.0", "--sha256", "abc123"})
+
+			_, url, checksum := fakeSyncer.SyncArgsForCall(0)
+			Expect(url).To(Equal("https://lattice.s3.amazonaws.com/unstable/v0.3.0/linux-amd64/ltc"))
+			Expect(checksum).To(Equal("abc123"))
+		})
+
+		It("downloads from the given url", func() {
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{"--url", "https://mirror.example.com/ltc"})
+
+			_, url, _ := fakeSyncer.SyncArgsForCall(0)
+			Expect(url).To(Equal("https://mirror.example.com/ltc"))
+		})
+
+		It("rejects --url with --version", func() {
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{"--url", "https://mirror.example.com/ltc", "--version", "v0.3.0"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(outputBuffer).To(test_helpers.Say("--url and --version can't be used together"))
+			Expect(fakeSyncer.SyncCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		Context("on an operating system ltc isn't published for", func() {
+			BeforeEach(func() {
+				goos = "windows"
+			})
+
+			It("reports an error", func() {
+				test_helpers.ExecuteCommandWithArgs(syncCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.Say("ltc is only published for darwin and linux, not windows"))
+				Expect(fakeSyncer.SyncCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("still downloads from a given url", func() {
+				test_helpers.ExecuteCommandWithArgs(syncCommand, []string{"--url", "https://mirror.example.com/ltc.exe"})
+
+				Expect(fakeSyncer.SyncCallCount()).To(Equal(1))
+			})
+		})
+
+		It("reports errors syncing", func() {
+			fakeSyncer.SyncReturns(errors.New("Checksum mismatch"))
+
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error syncing ltc: Checksum mismatch"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("reports when ltc can't be replaced", func() {
+			fakeSyncer.SyncReturns(&os.PathError{Op: "open", Path: "/usr/local/bin", Err: os.ErrPermission})
+
+			test_helpers.ExecuteCommandWithArgs(syncCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error syncing ltc: open /usr/local/bin: permission denied"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+		})
+	})
+})
@@ -0,0 +1,57 @@
+// This file was generated by counterfeiter
+package fake_syncer
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/version"
+)
+
+type FakeSyncer struct {
+	SyncStub        func(ltcPath, url, checksum string) error
+	syncMutex       sync.RWMutex
+	syncArgsForCall []struct {
+		ltcPath  string
+		url      string
+		checksum string
+	}
+	syncReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeSyncer) Sync(ltcPath string, url string, checksum string) error {
+	fake.syncMutex.Lock()
+	fake.syncArgsForCall = append(fake.syncArgsForCall, struct {
+		ltcPath  string
+		url      string
+		checksum string
+	}{ltcPath, url, checksum})
+	fake.syncMutex.Unlock()
+	if fake.SyncStub != nil {
+		return fake.SyncStub(ltcPath, url, checksum)
+	} else {
+		return fake.syncReturns.result1
+	}
+}
+
+func (fake *FakeSyncer) SyncCallCount() int {
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
+	return len(fake.syncArgsForCall)
+}
+
+func (fake *FakeSyncer) SyncArgsForCall(i int) (string, string, string) {
+	fake.syncMutex.RLock()
+	defer fake.syncMutex.RUnlock()
+	return fake.syncArgsForCall[i].ltcPath, fake.syncArgsForCall[i].url, fake.syncArgsForCall[i].checksum
+}
+
+func (fake *FakeSyncer) SyncReturns(result1 error) {
+	fake.SyncStub = nil
+	fake.syncReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ version.Syncer = new(FakeSyncer)
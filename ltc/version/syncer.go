@@ -0,0 +1,128 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ReleasesURL is where the pipeline publishes ltc binaries, each next to a
+// .sha256 file holding its checksum.
+const ReleasesURL = "https://lattice.s3.amazonaws.com/unstable"
+
+//go:generate counterfeiter -o fake_syncer/fake_syncer.go . Syncer
+type Syncer interface {
+	// Sync replaces the ltc binary at ltcPath with the one at url, once its
+	// SHA-256 matches checksum.  An empty checksum is fetched from the
+	// url's .sha256 file.
+	Sync(ltcPath, url, checksum string) error
+}
+
+type syncer struct {
+	httpClient *http.Client
+}
+
+func NewSyncer(httpClient *http.Client) Syncer {
+	return &syncer{httpClient}
+}
+
+// ReleaseURL is the url of the published ltc binary for version, which may
+// be "latest", built for the os goos.
+func ReleaseURL(version, goos string) (string, error) {
+	if goos != "darwin" && goos != "linux" {
+		return "", fmt.Errorf("ltc is only published for darwin and linux, not %s", goos)
+	}
+	return fmt.Sprintf("%s/%s/%s-amd64/ltc", ReleasesURL, version, goos), nil
+}
+
+// LtcPath is the path of the running ltc binary.
+func LtcPath() string {
+	if path, err := exec.LookPath(os.Args[0]); err == nil {
+		if absPath, err := filepath.Abs(path); err == nil {
+			return absPath
+		}
+	}
+	return os.Args[0]
+}
+
+func (s *syncer) Sync(ltcPath, url, checksum string) error {
+	if checksum == "" {
+		var err error
+		if checksum, err = s.fetchChecksum(url + ".sha256"); err != nil {
+			return err
+		}
+	}
+
+	// The new binary is written next to the old one, so that renaming it
+	// over the old one replaces ltc in one step.
+	tempFile, err := ioutil.TempFile(filepath.Dir(ltcPath), ".ltc-sync")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	body, err := s.get(url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tempFile, hash), body); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("Checksum mismatch for %s: expected %s, got %s", url, checksum, actual)
+	}
+
+	if err := tempFile.Chmod(0755); err != nil {
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), ltcPath)
+}
+
+func (s *syncer) fetchChecksum(url string) (string, error) {
+	body, err := s.get(url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	contents, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	// Accept the "CHECKSUM  FILENAME" lines shasum prints, too.
+	fields := strings.Fields(string(contents))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("No checksum found at %s", url)
+	}
+	return fields[0], nil
+}
+
+func (s *syncer) get(url string) (io.ReadCloser, error) {
+	response, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("Error downloading %s: %s", url, response.Status)
+	}
+	return response.Body, nil
+}
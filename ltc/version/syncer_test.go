@@ -0,0 +1,129 @@
+package version_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/lattice/ltc/version"
+)
+
+var _ = Describe("Syncer", func() {
+	var (
+		server  *ghttp.Server
+		syncer  version.Syncer
+		tmpDir  string
+		ltcPath string
+		newLtc  string
+		sum     string
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		syncer = version.NewSyncer(&http.Client{})
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "ltc-sync")
+		Expect(err).NotTo(HaveOccurred())
+
+		ltcPath = filepath.Join(tmpDir, "ltc")
+		Expect(ioutil.WriteFile(ltcPath, []byte("old ltc"), 0755)).To(Succeed())
+
+		newLtc = "new ltc"
+		checksum := sha256.Sum256([]byte(newLtc))
+		sum = hex.EncodeToString(checksum[:])
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	expectOnlyLtcInTmpDir := func() {
+		files, err := ioutil.ReadDir(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	}
+
+	Describe("Sync", func() {
+		It("replaces ltc with the download once its checksum matches", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v0.3.0/linux-amd64/ltc"),
+				ghttp.RespondWith(http.StatusOK, newLtc),
+			))
+
+			err := syncer.Sync(ltcPath, server.URL()+"/v0.3.0/linux-amd64/ltc", sum)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.ReadFile(ltcPath)).To(Equal([]byte(newLtc)))
+			info, err := os.Stat(ltcPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+			expectOnlyLtcInTmpDir()
+		})
+
+		It("fetches the checksum next to the download when none is given", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/latest/linux-amd64/ltc.sha256"),
+					ghttp.RespondWith(http.StatusOK, sum+"  ltc-linux-amd64\n"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/latest/linux-amd64/ltc"),
+					ghttp.RespondWith(http.StatusOK, newLtc),
+				),
+			)
+
+			err := syncer.Sync(ltcPath, server.URL()+"/latest/linux-amd64/ltc", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.ReadFile(ltcPath)).To(Equal([]byte(newLtc)))
+		})
+
+		It("leaves ltc alone when the checksum doesn't match", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "tampered ltc"))
+
+			err := syncer.Sync(ltcPath, server.URL()+"/ltc", sum)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("Checksum mismatch for " + server.URL() + "/ltc: expected " + sum))
+
+			Expect(ioutil.ReadFile(ltcPath)).To(Equal([]byte("old ltc")))
+			expectOnlyLtcInTmpDir()
+		})
+
+		It("returns an error when the download fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+			err := syncer.Sync(ltcPath, server.URL()+"/ltc", sum)
+			Expect(err).To(MatchError("Error downloading " + server.URL() + "/ltc: 404 Not Found"))
+
+			Expect(ioutil.ReadFile(ltcPath)).To(Equal([]byte("old ltc")))
+			expectOnlyLtcInTmpDir()
+		})
+
+		It("returns an error when the checksum is empty", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "\n"))
+
+			err := syncer.Sync(ltcPath, server.URL()+"/ltc", "")
+			Expect(err).To(MatchError("No checksum found at " + server.URL() + "/ltc.sha256"))
+		})
+	})
+
+	Describe("ReleaseURL", func() {
+		It("returns the url of the published binary", func() {
+			Expect(version.ReleaseURL("v0.3.0", "darwin")).To(Equal("https://lattice.s3.amazonaws.com/unstable/v0.3.0/darwin-amd64/ltc"))
+		})
+
+		It("returns an error for other operating systems", func() {
+			_, err := version.ReleaseURL("latest", "windows")
+			Expect(err).To(MatchError("ltc is only published for darwin and linux, not windows"))
+		})
+	})
+})